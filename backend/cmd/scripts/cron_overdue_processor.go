@@ -40,7 +40,7 @@ func main() {
 		salesRepo,
 		accountRepo,
 		nil, // Notification service - implement based on your needs
-		nil, // Email service - implement based on your needs
+		services.NewEmailService(db), // Queues reminder/overdue emails; the server's email worker delivers them
 	)
 
 	// Process overdue invoices
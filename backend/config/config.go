@@ -37,6 +37,13 @@ type Config struct {
 	MaxLoginAttempts     int
 	LockoutDuration      time.Duration
 	
+	// Two-factor authentication
+	TwoFactorIssuer        string
+	TwoFactorRequiredRoles []string
+	TwoFactorSecretKey     string
+	TwoFactorTrustDuration time.Duration
+	StepUpValidity         time.Duration
+	
 	// Session
 	SessionSecret      string
 	SessionMaxAge      int
@@ -69,6 +76,7 @@ type Config struct {
 	
 	// Development Flags
 	SkipBalanceReset bool
+	EmailSMTPSink    string // host:port of a local SMTP sink; when set every email goes there instead of Settings SMTP
 	
 	// Swagger Configuration
 	SwaggerHost        string
@@ -119,6 +127,13 @@ func LoadConfig() *Config {
 		MaxLoginAttempts:      parseInt(getEnv("MAX_LOGIN_ATTEMPTS", "5"), 5),
 		LockoutDuration:       parseDuration(getEnv("LOCKOUT_DURATION", "15m"), 15*time.Minute),
 		
+		// Two-factor authentication
+		TwoFactorIssuer:        getEnv("TWO_FACTOR_ISSUER", "Sistem Akuntansi"),
+		TwoFactorRequiredRoles: parseStringSlice(getEnv("TWO_FACTOR_REQUIRED_ROLES", "admin,finance,director")),
+		TwoFactorSecretKey:     getEnv("TWO_FACTOR_SECRET_KEY", getEnv("JWT_SECRET", generateDefaultSecret())),
+		TwoFactorTrustDuration: parseDuration(getEnv("TWO_FACTOR_TRUST_DURATION", "30d"), 30*24*time.Hour),
+		StepUpValidity:         parseDuration(getEnv("STEP_UP_VALIDITY", "10m"), 10*time.Minute),
+		
 		// Session
 		SessionSecret:      getEnv("SESSION_SECRET", generateDefaultSecret()),
 		SessionMaxAge:      parseInt(getEnv("SESSION_MAX_AGE", "28800"), 28800),
@@ -151,6 +166,7 @@ func LoadConfig() *Config {
 		
 		// Development Flags
 		SkipBalanceReset: parseBool(getEnv("SKIP_BALANCE_RESET", "false")),
+		EmailSMTPSink:    getEnv("EMAIL_SMTP_SINK", ""),
 		
 		// Swagger Configuration
 		SwaggerHost:        getEnv("SWAGGER_HOST", ""), // Empty means dynamic
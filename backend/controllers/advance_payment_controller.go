@@ -0,0 +1,228 @@
+package controllers
+
+import (
+	"app-sistem-akuntansi/models"
+	"app-sistem-akuntansi/services"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AdvancePaymentController struct {
+	advancePaymentService *services.AdvancePaymentService
+}
+
+func NewAdvancePaymentController(advancePaymentService *services.AdvancePaymentService) *AdvancePaymentController {
+	return &AdvancePaymentController{
+		advancePaymentService: advancePaymentService,
+	}
+}
+
+// GetAdvancePayments godoc
+// @Summary List customer down payments and vendor prepayments
+// @Tags Advance Payments
+// @Produce json
+// @Security BearerAuth
+// @Param type query string false "CUSTOMER or VENDOR"
+// @Param contact_id query int false "Customer or vendor"
+// @Param status query string false "OPEN, APPLIED or VOID"
+// @Param open query bool false "Only advances with a remaining amount"
+// @Success 200 {array} models.AdvancePayment
+// @Router /api/v1/advance-payments [get]
+func (c *AdvancePaymentController) GetAdvancePayments(ctx *gin.Context) {
+	contactID, _ := strconv.ParseUint(ctx.Query("contact_id"), 10, 32)
+	openOnly := ctx.Query("open") == "true"
+
+	advances, err := c.advancePaymentService.GetAdvancePayments(
+		strings.ToUpper(ctx.Query("type")), uint(contactID), strings.ToUpper(ctx.Query("status")), openOnly)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to retrieve advance payments",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    advances,
+	})
+}
+
+// GetAdvancePayment godoc
+// @Summary Advance payment detail with its invoice applications
+// @Tags Advance Payments
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Advance payment ID"
+// @Success 200 {object} models.AdvancePayment
+// @Router /api/v1/advance-payments/{id} [get]
+func (c *AdvancePaymentController) GetAdvancePayment(ctx *gin.Context) {
+	id, ok := parseAdvancePaymentParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	advance, err := c.advancePaymentService.GetAdvancePaymentByID(id)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error":   "Advance payment not found",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    advance,
+	})
+}
+
+// CreateCustomerDownPayment godoc
+// @Summary Receive a down payment from a customer
+// @Description The amount includes PPN at ppn_rate; DPP is credited to Uang Muka Pelanggan and PPN to PPN Keluaran
+// @Tags Advance Payments
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.AdvancePaymentRequest true "Down payment"
+// @Success 201 {object} models.AdvancePayment
+// @Router /api/v1/advance-payments/customer [post]
+func (c *AdvancePaymentController) CreateCustomerDownPayment(ctx *gin.Context) {
+	c.create(ctx, models.AdvancePaymentTypeCustomer, "Customer down payment recorded")
+}
+
+// CreateVendorPrepayment godoc
+// @Summary Pay a prepayment to a vendor
+// @Description The amount includes PPN at ppn_rate; DPP is debited to Uang Muka Pembelian and PPN to PPN Masukan
+// @Tags Advance Payments
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.AdvancePaymentRequest true "Prepayment"
+// @Success 201 {object} models.AdvancePayment
+// @Router /api/v1/advance-payments/vendor [post]
+func (c *AdvancePaymentController) CreateVendorPrepayment(ctx *gin.Context) {
+	c.create(ctx, models.AdvancePaymentTypeVendor, "Vendor prepayment recorded")
+}
+
+// ApplyAdvancePayment godoc
+// @Summary Apply part of an advance to a sale invoice or purchase
+// @Tags Advance Payments
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Advance payment ID"
+// @Param request body models.AdvancePaymentApplyRequest true "sale_id for down payments, purchase_id for prepayments"
+// @Success 200 {object} models.AdvancePayment
+// @Router /api/v1/advance-payments/{id}/apply [post]
+func (c *AdvancePaymentController) ApplyAdvancePayment(ctx *gin.Context) {
+	id, ok := parseAdvancePaymentParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	var req models.AdvancePaymentApplyRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	advance, err := c.advancePaymentService.ApplyAdvancePayment(id, req, ctx.GetUint("user_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to apply advance payment",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    advance,
+		"message": "Advance payment applied",
+	})
+}
+
+// VoidAdvancePayment godoc
+// @Summary Void an advance that has not been applied yet
+// @Tags Advance Payments
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Advance payment ID"
+// @Param request body models.AdvancePaymentVoidRequest true "Reason"
+// @Success 200 {object} models.AdvancePayment
+// @Router /api/v1/advance-payments/{id}/void [post]
+func (c *AdvancePaymentController) VoidAdvancePayment(ctx *gin.Context) {
+	id, ok := parseAdvancePaymentParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	var req models.AdvancePaymentVoidRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	advance, err := c.advancePaymentService.VoidAdvancePayment(id, req.Reason, ctx.GetUint("user_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to void advance payment",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    advance,
+		"message": "Advance payment voided",
+	})
+}
+
+func (c *AdvancePaymentController) create(ctx *gin.Context, advanceType, message string) {
+	var req models.AdvancePaymentRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	advance, err := c.advancePaymentService.CreateAdvancePayment(advanceType, req, ctx.GetUint("user_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to record advance payment",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    advance,
+		"message": message,
+	})
+}
+
+func parseAdvancePaymentParam(ctx *gin.Context, name string) (uint, bool) {
+	id, err := strconv.ParseUint(ctx.Param(name), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid " + name,
+		})
+		return 0, false
+	}
+	return uint(id), true
+}
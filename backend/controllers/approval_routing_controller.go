@@ -0,0 +1,243 @@
+package controllers
+
+import (
+	"app-sistem-akuntansi/models"
+	"app-sistem-akuntansi/services"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ApprovalRoutingController struct {
+	approvalService   *services.ApprovalService
+	delegationService *services.ApprovalDelegationService
+}
+
+func NewApprovalRoutingController(approvalService *services.ApprovalService, delegationService *services.ApprovalDelegationService) *ApprovalRoutingController {
+	return &ApprovalRoutingController{
+		approvalService:   approvalService,
+		delegationService: delegationService,
+	}
+}
+
+// ResolveWorkflowRequest describes a document to preview approval routing for
+type ResolveWorkflowRequest struct {
+	Module        string  `json:"module" binding:"required"`
+	Amount        float64 `json:"amount"`
+	ContactID     *uint   `json:"contact_id"`
+	AccountIDs    []uint  `json:"account_ids"`
+	CostCenterIDs []uint  `json:"cost_center_ids"`
+}
+
+// ResolveWorkflow godoc
+// @Summary Preview which approval workflow a document would be routed to
+// @Tags Approval Routing
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body ResolveWorkflowRequest true "Document routing context"
+// @Success 200 {object} models.ApprovalWorkflow
+// @Router /api/v1/approval-workflows/resolve [post]
+func (c *ApprovalRoutingController) ResolveWorkflow(ctx *gin.Context) {
+	var req ResolveWorkflowRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	workflow, err := c.approvalService.SelectWorkflow(strings.ToUpper(req.Module), models.CreateApprovalRequestDTO{
+		Amount:        req.Amount,
+		ContactID:     req.ContactID,
+		AccountIDs:    req.AccountIDs,
+		CostCenterIDs: req.CostCenterIDs,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusOK, gin.H{
+			"success":           true,
+			"requires_approval": false,
+			"message":           "No active approval workflow matches this document",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success":           true,
+		"requires_approval": true,
+		"data":              workflow,
+	})
+}
+
+// GetDelegations godoc
+// @Summary List approval delegations
+// @Description Admins see every delegation, other users only the ones they gave or received
+// @Tags Approval Routing
+// @Produce json
+// @Security BearerAuth
+// @Param active_only query bool false "Only active delegations"
+// @Success 200 {array} models.ApprovalDelegation
+// @Router /api/v1/approval-delegations [get]
+func (c *ApprovalRoutingController) GetDelegations(ctx *gin.Context) {
+	delegations, err := c.delegationService.GetDelegations(ctx.GetUint("user_id"), isAdminRole(ctx), ctx.Query("active_only") == "true")
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to retrieve approval delegations",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    delegations,
+	})
+}
+
+// GetDelegation godoc
+// @Summary Get approval delegation
+// @Tags Approval Routing
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Delegation ID"
+// @Success 200 {object} models.ApprovalDelegation
+// @Router /api/v1/approval-delegations/{id} [get]
+func (c *ApprovalRoutingController) GetDelegation(ctx *gin.Context) {
+	id, ok := parseDataScopeParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	delegation, err := c.delegationService.GetDelegationByID(id)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error": "Approval delegation not found",
+		})
+		return
+	}
+	userID := ctx.GetUint("user_id")
+	if !isAdminRole(ctx) && delegation.DelegatorID != userID && delegation.DelegateID != userID {
+		ctx.JSON(http.StatusForbidden, gin.H{
+			"error": "You do not have access to this delegation",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    delegation,
+	})
+}
+
+// CreateDelegation godoc
+// @Summary Delegate approvals for a planned absence
+// @Tags Approval Routing
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.ApprovalDelegationRequest true "Delegation"
+// @Success 201 {object} models.ApprovalDelegation
+// @Router /api/v1/approval-delegations [post]
+func (c *ApprovalRoutingController) CreateDelegation(ctx *gin.Context) {
+	var req models.ApprovalDelegationRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	delegation, err := c.delegationService.CreateDelegation(req, ctx.GetUint("user_id"), isAdminRole(ctx))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to create approval delegation",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    delegation,
+		"message": "Approval delegation created successfully",
+	})
+}
+
+// UpdateDelegation godoc
+// @Summary Update approval delegation
+// @Tags Approval Routing
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Delegation ID"
+// @Param request body models.ApprovalDelegationRequest true "Delegation"
+// @Success 200 {object} models.ApprovalDelegation
+// @Router /api/v1/approval-delegations/{id} [put]
+func (c *ApprovalRoutingController) UpdateDelegation(ctx *gin.Context) {
+	id, ok := parseDataScopeParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	var req models.ApprovalDelegationRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	delegation, err := c.delegationService.UpdateDelegation(id, req, ctx.GetUint("user_id"), isAdminRole(ctx))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to update approval delegation",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    delegation,
+		"message": "Approval delegation updated successfully",
+	})
+}
+
+// CancelDelegation godoc
+// @Summary Cancel approval delegation
+// @Description Approvals go back to the original approver immediately
+// @Tags Approval Routing
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Delegation ID"
+// @Success 200 {object} models.ApprovalDelegation
+// @Router /api/v1/approval-delegations/{id}/cancel [post]
+func (c *ApprovalRoutingController) CancelDelegation(ctx *gin.Context) {
+	id, ok := parseDataScopeParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	delegation, err := c.delegationService.CancelDelegation(id, ctx.GetUint("user_id"), isAdminRole(ctx))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to cancel approval delegation",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    delegation,
+		"message": "Approval delegation cancelled",
+	})
+}
+
+func isAdminRole(ctx *gin.Context) bool {
+	return strings.EqualFold(ctx.GetString("role"), models.RoleAdmin)
+}
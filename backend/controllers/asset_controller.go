@@ -16,8 +16,9 @@ import (
 )
 
 type AssetController struct {
-	assetService services.AssetServiceInterface
-	db           *gorm.DB
+	assetService    services.AssetServiceInterface
+	approvalService *services.ApprovalService
+	db              *gorm.DB
 }
 
 type AssetCreateRequest struct {
@@ -70,8 +71,9 @@ func NewAssetController(db *gorm.DB) *AssetController {
 	assetService := services.NewAssetService(assetRepo, db)
 	
 	return &AssetController{
-		assetService: assetService,
-		db:           db,
+		assetService:    assetService,
+		approvalService: services.NewApprovalService(db),
+		db:              db,
 	}
 }
 
@@ -159,6 +161,21 @@ func (ac *AssetController) CreateAsset(c *gin.Context) {
 		asset.IsActive = true
 	}
 
+	// Asset purchases matching an ASSET approval workflow stay inactive until approved
+	approvalRequest := models.CreateApprovalRequestDTO{
+		EntityType:     models.EntityTypeAsset,
+		Amount:         req.PurchasePrice,
+		RequestMessage: req.Notes,
+	}
+	if req.AssetAccountID != nil {
+		approvalRequest.AccountIDs = []uint{*req.AssetAccountID}
+	}
+	requiresApproval := ac.approvalService.RequiresApproval(approvalRequest)
+	if requiresApproval {
+		asset.Status = models.AssetStatusPendingApproval
+		asset.IsActive = false
+	}
+
 	// Default payment method if not provided
 	paymentMethod := req.PaymentMethod
 	if paymentMethod == "" {
@@ -176,6 +193,29 @@ func (ac *AssetController) CreateAsset(c *gin.Context) {
 		return
 	}
 
+	if requiresApproval {
+		approvalRequest.EntityID = asset.ID
+		approvalRequest.RequestTitle = fmt.Sprintf("Asset purchase %s - %s", asset.Code, asset.Name)
+		created, err := ac.approvalService.CreateApprovalRequest(approvalRequest, req.UserID)
+		if err != nil {
+			ac.db.Delete(asset)
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Failed to create approval request",
+				"details": err.Error(),
+			})
+			return
+		}
+		// is_active defaults to true on insert, so it is cleared together with the request link
+		ac.db.Model(asset).Updates(map[string]interface{}{"approval_request_id": created.ID, "is_active": false})
+		asset.ApprovalRequestID = &created.ID
+
+		c.JSON(http.StatusAccepted, gin.H{
+			"message": "Asset saved and is waiting for approval",
+			"data":    asset,
+		})
+		return
+	}
+
 	c.JSON(http.StatusCreated, gin.H{
 		"message": "Asset created successfully",
 		"data":    asset,
@@ -571,6 +611,9 @@ func (ac *AssetController) CreateAssetCategory(c *gin.Context) {
 		Description string `json:"description"`
 		ParentID    *uint  `json:"parent_id"`
 		IsActive    bool   `json:"is_active"`
+
+		DepreciationExpenseAccountID     *uint `json:"depreciation_expense_account_id"`
+		AccumulatedDepreciationAccountID *uint `json:"accumulated_depreciation_account_id"`
 	}
 	
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -587,6 +630,9 @@ func (ac *AssetController) CreateAssetCategory(c *gin.Context) {
 		Description: req.Description,
 		ParentID:    req.ParentID,
 		IsActive:    req.IsActive,
+
+		DepreciationExpenseAccountID:     req.DepreciationExpenseAccountID,
+		AccumulatedDepreciationAccountID: req.AccumulatedDepreciationAccountID,
 	}
 	
 	// Set default IsActive to true if not specified
@@ -0,0 +1,242 @@
+package controllers
+
+import (
+	"app-sistem-akuntansi/models"
+	"app-sistem-akuntansi/services"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AssetDepreciationController struct {
+	depreciationService *services.AssetDepreciationService
+}
+
+func NewAssetDepreciationController(depreciationService *services.AssetDepreciationService) *AssetDepreciationController {
+	return &AssetDepreciationController{
+		depreciationService: depreciationService,
+	}
+}
+
+// GetRuns godoc
+// @Summary List monthly depreciation runs
+// @Tags Assets
+// @Produce json
+// @Security BearerAuth
+// @Param period query string false "Period (YYYY-MM)"
+// @Param status query string false "POSTED or ROLLED_BACK"
+// @Success 200 {array} models.AssetDepreciationRun
+// @Router /api/v1/assets/depreciation-runs [get]
+func (c *AssetDepreciationController) GetRuns(ctx *gin.Context) {
+	runs, err := c.depreciationService.GetRuns(ctx.Query("period"), ctx.Query("status"))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to retrieve depreciation runs",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    runs,
+	})
+}
+
+// GetRun godoc
+// @Summary Get depreciation run with its per-asset entries
+// @Tags Assets
+// @Produce json
+// @Security BearerAuth
+// @Param run_id path int true "Run ID"
+// @Success 200 {object} models.AssetDepreciationRun
+// @Router /api/v1/assets/depreciation-runs/{run_id} [get]
+func (c *AssetDepreciationController) GetRun(ctx *gin.Context) {
+	id, ok := parseDepreciationParam(ctx, "run_id")
+	if !ok {
+		return
+	}
+
+	run, err := c.depreciationService.GetRunByID(id)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error": "Depreciation run not found",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    run,
+	})
+}
+
+// PreviewRun godoc
+// @Summary Preview monthly depreciation (dry run)
+// @Description Calculates the depreciation of every active asset for the period without saving anything
+// @Tags Assets
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.AssetDepreciationRunRequest true "Period"
+// @Success 200 {object} models.AssetDepreciationRun
+// @Router /api/v1/assets/depreciation-runs/preview [post]
+func (c *AssetDepreciationController) PreviewRun(ctx *gin.Context) {
+	var request models.AssetDepreciationRunRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	run, err := c.depreciationService.PreviewRun(request)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to preview depreciation",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    run,
+	})
+}
+
+// CreateRun godoc
+// @Summary Post monthly depreciation
+// @Description Posts one DEPRECIATION journal grouped by asset category; assets already depreciated for the period are skipped
+// @Tags Assets
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.AssetDepreciationRunRequest true "Period"
+// @Success 201 {object} models.AssetDepreciationRun
+// @Router /api/v1/assets/depreciation-runs [post]
+func (c *AssetDepreciationController) CreateRun(ctx *gin.Context) {
+	userID := ctx.GetUint("user_id")
+	if userID == 0 {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	var request models.AssetDepreciationRunRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	run, err := c.depreciationService.RunDepreciation(request, userID)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to post depreciation",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Depreciation posted",
+		"data":    run,
+	})
+}
+
+// RollbackRun godoc
+// @Summary Roll back a depreciation run
+// @Description Reverses the run journal and restores accumulated depreciation; only allowed while the period is open
+// @Tags Assets
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param run_id path int true "Run ID"
+// @Param request body models.AssetDepreciationRollbackRequest true "Reason"
+// @Success 200 {object} models.AssetDepreciationRun
+// @Router /api/v1/assets/depreciation-runs/{run_id}/rollback [post]
+func (c *AssetDepreciationController) RollbackRun(ctx *gin.Context) {
+	userID := ctx.GetUint("user_id")
+	if userID == 0 {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	id, ok := parseDepreciationParam(ctx, "run_id")
+	if !ok {
+		return
+	}
+
+	var request models.AssetDepreciationRollbackRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	run, err := c.depreciationService.RollbackRun(id, request, userID)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to roll back depreciation run",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Depreciation run rolled back",
+		"data":    run,
+	})
+}
+
+// GetAssetLedger godoc
+// @Summary Per-period depreciation ledger of an asset
+// @Tags Assets
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Asset ID"
+// @Success 200 {array} models.AssetDepreciationEntry
+// @Router /api/v1/assets/{id}/depreciation-ledger [get]
+func (c *AssetDepreciationController) GetAssetLedger(ctx *gin.Context) {
+	id, ok := parseDepreciationParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	entries, err := c.depreciationService.GetAssetLedger(id)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to retrieve depreciation ledger",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    entries,
+	})
+}
+
+func parseDepreciationParam(ctx *gin.Context, name string) (uint, bool) {
+	id, err := strconv.ParseUint(ctx.Param(name), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid ID",
+		})
+		return 0, false
+	}
+	return uint(id), true
+}
@@ -0,0 +1,184 @@
+package controllers
+
+import (
+	"app-sistem-akuntansi/models"
+	"app-sistem-akuntansi/services"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AssetDisposalController struct {
+	disposalService *services.AssetDisposalService
+}
+
+func NewAssetDisposalController(disposalService *services.AssetDisposalService) *AssetDisposalController {
+	return &AssetDisposalController{
+		disposalService: disposalService,
+	}
+}
+
+// GetDisposals godoc
+// @Summary List asset disposals and impairments
+// @Tags Assets
+// @Produce json
+// @Security BearerAuth
+// @Param asset_id query int false "Asset ID"
+// @Param type query string false "SALE, DISPOSE, WRITE_OFF or IMPAIRMENT"
+// @Success 200 {array} models.AssetDisposal
+// @Router /api/v1/assets/disposals [get]
+func (c *AssetDisposalController) GetDisposals(ctx *gin.Context) {
+	var assetID uint
+	if raw := ctx.Query("asset_id"); raw != "" {
+		id, err := strconv.ParseUint(raw, 10, 32)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid asset ID",
+			})
+			return
+		}
+		assetID = uint(id)
+	}
+
+	disposals, err := c.disposalService.GetDisposals(assetID, ctx.Query("type"))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to retrieve asset disposals",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    disposals,
+	})
+}
+
+// GetDisposal godoc
+// @Summary Get asset disposal
+// @Tags Assets
+// @Produce json
+// @Security BearerAuth
+// @Param disposal_id path int true "Disposal ID"
+// @Success 200 {object} models.AssetDisposal
+// @Router /api/v1/assets/disposals/{disposal_id} [get]
+func (c *AssetDisposalController) GetDisposal(ctx *gin.Context) {
+	id, ok := parseDepreciationParam(ctx, "disposal_id")
+	if !ok {
+		return
+	}
+
+	disposal, err := c.disposalService.GetDisposalByID(id)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error": "Asset disposal not found",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    disposal,
+	})
+}
+
+// DisposeAsset godoc
+// @Summary Sell, dispose or write off an asset
+// @Description Catches up depreciation to the disposal date, removes cost and accumulated depreciation and books the gain or loss; proceeds go to a cash/bank account or a receivable
+// @Tags Assets
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Asset ID"
+// @Param request body models.AssetDisposalRequest true "Disposal"
+// @Success 201 {object} models.AssetDisposal
+// @Router /api/v1/assets/{id}/dispose [post]
+func (c *AssetDisposalController) DisposeAsset(ctx *gin.Context) {
+	userID := ctx.GetUint("user_id")
+	if userID == 0 {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	id, ok := parseDepreciationParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	var request models.AssetDisposalRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	disposal, err := c.disposalService.DisposeAsset(id, request, userID)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to dispose asset",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Asset disposed",
+		"data":    disposal,
+	})
+}
+
+// ImpairAsset godoc
+// @Summary Impair an asset to its recoverable amount
+// @Tags Assets
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Asset ID"
+// @Param request body models.AssetImpairmentRequest true "Impairment"
+// @Success 201 {object} models.AssetDisposal
+// @Router /api/v1/assets/{id}/impair [post]
+func (c *AssetDisposalController) ImpairAsset(ctx *gin.Context) {
+	userID := ctx.GetUint("user_id")
+	if userID == 0 {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	id, ok := parseDepreciationParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	var request models.AssetImpairmentRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	disposal, err := c.disposalService.ImpairAsset(id, request, userID)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to impair asset",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Asset impairment posted",
+		"data":    disposal,
+	})
+}
@@ -1,23 +1,29 @@
 package controllers
 
 import (
+	"errors"
 	"log"
 	"net/http"
 	"strings"
 	"time"
 	"app-sistem-akuntansi/middleware"
 	"app-sistem-akuntansi/models"
+	"app-sistem-akuntansi/services"
 	"github.com/gin-gonic/gin"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
 type AuthController struct {
-	DB *gorm.DB
+	DB               *gorm.DB
+	twoFactorService *services.TwoFactorService
 }
 
 func NewAuthController(db *gorm.DB) *AuthController {
-	return &AuthController{DB: db}
+	return &AuthController{
+		DB:               db,
+		twoFactorService: services.NewTwoFactorService(db),
+	}
 }
 
 // Helper function to convert role to uppercase for frontend
@@ -181,18 +187,49 @@ func (ac *AuthController) Login(c *gin.Context) {
 		return
 	}
 
-	// Check for existing active sessions and limit them
-	var existingSessions []models.UserSession
-	ac.DB.Where("user_id = ? AND is_active = ?", user.ID, true).Order("created_at DESC").Find(&existingSessions)
-	
-	// If user has more than 5 active sessions, deactivate the oldest ones (increased from 3)
-	if len(existingSessions) > 5 {
-		oldSessions := existingSessions[5:]
-		for _, session := range oldSessions {
-			ac.DB.Model(&session).Update("is_active", false)
+	// Two-factor authentication: ask for a code unless this browser was trusted earlier
+	twoFactorEnabled, err := ac.twoFactorService.IsEnabled(user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check two-factor authentication"})
+		return
+	}
+	if !twoFactorEnabled && ac.twoFactorService.IsRequired(user.Role) {
+		// Mandatory for this role but not set up yet: hand out a ticket that can only enrol an authenticator
+		enrollmentToken, expiresAt, err := ac.twoFactorService.CreateEnrollmentChallenge(user.ID, deviceInfo, twoFactorClientInfo(c))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start two-factor enrolment"})
+			return
 		}
-		log.Printf("Deactivated %d old sessions for user %d during login", len(oldSessions), user.ID)
+		c.JSON(http.StatusOK, gin.H{
+			"success":                   true,
+			"two_factor_setup_required": true,
+			"enrollment_token":          enrollmentToken,
+			"expires_at":                expiresAt,
+			"message":                   "Two-factor authentication is mandatory for your role, enrol an authenticator app to finish signing in",
+		})
+		return
 	}
+	var trustedDevice *models.TrustedDevice
+	if twoFactorEnabled {
+		trustedDevice = ac.twoFactorService.FindTrustedDevice(user.ID, req.DeviceToken)
+		if trustedDevice == nil {
+			challengeToken, expiresAt, err := ac.twoFactorService.CreateLoginChallenge(user.ID, deviceInfo, twoFactorClientInfo(c))
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start two-factor verification"})
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{
+				"success":             true,
+				"two_factor_required": true,
+				"challenge_token":     challengeToken,
+				"expires_at":          expiresAt,
+				"message":             "Enter the code from your authenticator app",
+			})
+			return
+		}
+	}
+
+	ac.limitActiveSessions(user.ID)
 
 	// Initialize JWT Manager
 	jw := middleware.NewJWTManager(ac.DB)
@@ -203,6 +240,9 @@ func (ac *AuthController) Login(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
 		return
 	}
+	if trustedDevice != nil {
+		ac.twoFactorService.TouchTrustedDevice(trustedDevice, tokens.SessionID)
+	}
 
 	ac.logAuthAttempt(identifier, true, "", c.ClientIP(), c.Request.UserAgent())
 	
@@ -218,6 +258,162 @@ func (ac *AuthController) Login(c *gin.Context) {
 	})
 }
 
+// VerifyTwoFactorLogin finishes a login that is waiting for a two-factor code
+// @Summary Complete login with two-factor code
+// @Description Exchange the challenge token from /auth/login and a TOTP or recovery code for access and refresh tokens. With trust_device the response carries a device_token that skips the code on this browser for 30 days.
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param request body models.TwoFactorLoginRequest true "Challenge token and code"
+// @Success 200 {object} models.LoginResponse "Successful login"
+// @Failure 400 {object} models.ErrorResponse "Invalid request format"
+// @Failure 401 {object} models.ErrorResponse "Invalid code or expired challenge"
+// @Router /auth/2fa/verify [post]
+func (ac *AuthController) VerifyTwoFactorLogin(c *gin.Context) {
+	var req models.TwoFactorLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	info := twoFactorClientInfo(c)
+	user, deviceInfo, err := ac.twoFactorService.CompleteLoginChallenge(req.ChallengeToken, req.Code, info)
+	if err != nil {
+		status := http.StatusUnauthorized
+		if errors.Is(err, services.ErrTwoFactorLocked) {
+			status = http.StatusTooManyRequests
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	ac.limitActiveSessions(user.ID)
+
+	jw := middleware.NewJWTManager(ac.DB)
+	tokens, err := jw.GenerateTokenPair(*user, deviceInfo, c.ClientIP())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+	ac.twoFactorService.MarkSessionVerified(tokens.SessionID)
+
+	response := gin.H{
+		"success":       true,
+		"access_token":  tokens.AccessToken,
+		"token":         tokens.AccessToken, // Compatibility field
+		"refresh_token": tokens.RefreshToken,
+		"refreshToken":  tokens.RefreshToken, // Compatibility field
+		"user":          tokens.User,
+		"message":       "Login successful",
+	}
+	if req.TrustDevice {
+		deviceToken, expiresAt, err := ac.twoFactorService.TrustDevice(user.ID, tokens.SessionID, deviceInfo, info)
+		if err != nil {
+			log.Printf("Failed to trust device for user %d: %v", user.ID, err)
+		} else {
+			response["device_token"] = deviceToken
+			response["device_token_expires_at"] = expiresAt
+		}
+	}
+
+	ac.logAuthAttempt(user.Email, true, "", c.ClientIP(), c.Request.UserAgent())
+	c.JSON(http.StatusOK, response)
+}
+
+// BeginTwoFactorEnrollment starts the enrolment a login asked for
+// @Summary Start mandatory two-factor enrolment during login
+// @Description Exchange the enrollment_token from /auth/login for a new TOTP secret and otpauth URL to show as a QR code. The token grants no other access.
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param request body models.TwoFactorEnrollmentTokenRequest true "Enrollment token"
+// @Success 200 {object} models.TwoFactorEnrollment
+// @Failure 400 {object} models.ErrorResponse "Invalid request format"
+// @Failure 401 {object} models.ErrorResponse "Invalid or expired enrollment token"
+// @Router /auth/2fa/enroll [post]
+func (ac *AuthController) BeginTwoFactorEnrollment(c *gin.Context) {
+	var req models.TwoFactorEnrollmentTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	enrollment, err := ac.twoFactorService.BeginChallengeEnrollment(req.EnrollmentToken)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    enrollment,
+		"message": "Scan the QR code with your authenticator app and confirm with the first code",
+	})
+}
+
+// ConfirmTwoFactorEnrollment finishes a login that required enrolment first
+// @Summary Confirm mandatory two-factor enrolment and complete login
+// @Description Enables two-factor authentication with the first code from the authenticator app and returns access and refresh tokens together with the recovery codes.
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param request body models.TwoFactorEnrollmentLoginRequest true "Enrollment token and first code"
+// @Success 200 {object} models.LoginResponse "Successful login"
+// @Failure 400 {object} models.ErrorResponse "Invalid request format"
+// @Failure 401 {object} models.ErrorResponse "Invalid code or expired enrollment token"
+// @Router /auth/2fa/enroll/confirm [post]
+func (ac *AuthController) ConfirmTwoFactorEnrollment(c *gin.Context) {
+	var req models.TwoFactorEnrollmentLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	user, deviceInfo, codes, err := ac.twoFactorService.CompleteEnrollmentChallenge(req.EnrollmentToken, req.Code, twoFactorClientInfo(c))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	ac.limitActiveSessions(user.ID)
+
+	jw := middleware.NewJWTManager(ac.DB)
+	tokens, err := jw.GenerateTokenPair(*user, deviceInfo, c.ClientIP())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+	ac.twoFactorService.MarkSessionVerified(tokens.SessionID)
+
+	ac.logAuthAttempt(user.Email, true, "", c.ClientIP(), c.Request.UserAgent())
+	c.JSON(http.StatusOK, gin.H{
+		"success":        true,
+		"access_token":   tokens.AccessToken,
+		"token":          tokens.AccessToken, // Compatibility field
+		"refresh_token":  tokens.RefreshToken,
+		"refreshToken":   tokens.RefreshToken, // Compatibility field
+		"user":           tokens.User,
+		"recovery_codes": codes,
+		"message":        "Two-factor authentication enabled. Store the recovery codes in a safe place, they are shown only once.",
+	})
+}
+
+// limitActiveSessions keeps the 5 newest active sessions of the user and deactivates the rest.
+// Only called once the login has passed every factor, so a bare password cannot end other sessions.
+func (ac *AuthController) limitActiveSessions(userID uint) {
+	var existingSessions []models.UserSession
+	ac.DB.Where("user_id = ? AND is_active = ?", userID, true).Order("created_at DESC").Find(&existingSessions)
+
+	// If user has more than 5 active sessions, deactivate the oldest ones (increased from 3)
+	if len(existingSessions) > 5 {
+		oldSessions := existingSessions[5:]
+		for _, session := range oldSessions {
+			ac.DB.Model(&session).Update("is_active", false)
+		}
+		log.Printf("Deactivated %d old sessions for user %d during login", len(oldSessions), userID)
+	}
+}
+
 // Log authentication attempts
 func (ac *AuthController) logAuthAttempt(identifier string, success bool, reason, ipAddress, userAgent string) {
 	authAttempt := models.AuthAttempt{
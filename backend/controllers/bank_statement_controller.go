@@ -0,0 +1,469 @@
+package controllers
+
+import (
+	"app-sistem-akuntansi/models"
+	"app-sistem-akuntansi/services"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type BankStatementController struct {
+	statementService *services.BankStatementService
+}
+
+func NewBankStatementController(statementService *services.BankStatementService) *BankStatementController {
+	return &BankStatementController{
+		statementService: statementService,
+	}
+}
+
+// GetStatements godoc
+// @Summary List imported bank statements
+// @Tags Bank Statements
+// @Produce json
+// @Security BearerAuth
+// @Param cash_bank_id query int false "Cash bank account ID"
+// @Success 200 {array} models.BankStatement
+// @Router /api/v1/bank-statements [get]
+func (c *BankStatementController) GetStatements(ctx *gin.Context) {
+	cashBankID, _ := strconv.ParseUint(ctx.Query("cash_bank_id"), 10, 32)
+
+	statements, err := c.statementService.GetStatements(uint(cashBankID))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to retrieve bank statements",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    statements,
+	})
+}
+
+// GetStatement godoc
+// @Summary Get bank statement with its lines
+// @Tags Bank Statements
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Bank statement ID"
+// @Success 200 {object} models.BankStatement
+// @Router /api/v1/bank-statements/{id} [get]
+func (c *BankStatementController) GetStatement(ctx *gin.Context) {
+	id, ok := parseBankStatementParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	statement, err := c.statementService.GetStatementByID(id)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error": "Bank statement not found",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    statement,
+	})
+}
+
+// ImportStatement godoc
+// @Summary Import a bank statement
+// @Description Upload a CSV (with a column mapping preset such as BCA, MANDIRI, BRI or a saved mapping), MT940 or OFX statement
+// @Tags Bank Statements
+// @Accept multipart/form-data
+// @Produce json
+// @Security BearerAuth
+// @Param file formData file true "Statement file"
+// @Param cash_bank_id formData int true "Cash bank account ID"
+// @Param format formData string true "CSV, MT940 or OFX"
+// @Param mapping_code formData string false "CSV column mapping code"
+// @Param mapping formData string false "CSV column mapping as JSON (overrides mapping_code)"
+// @Param auto_match formData bool false "Run auto-matching with default rules after import"
+// @Success 200 {object} services.BankStatementImportResult
+// @Router /api/v1/bank-statements/import [post]
+func (c *BankStatementController) ImportStatement(ctx *gin.Context) {
+	userID := ctx.GetUint("user_id")
+	if userID == 0 {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	cashBankID, err := strconv.ParseUint(ctx.PostForm("cash_bank_id"), 10, 32)
+	if err != nil || cashBankID == 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "cash_bank_id is required",
+		})
+		return
+	}
+
+	request := services.BankStatementImportRequest{
+		CashBankID:  uint(cashBankID),
+		Format:      ctx.PostForm("format"),
+		MappingCode: ctx.PostForm("mapping_code"),
+		AutoMatch:   ctx.PostForm("auto_match") == "true",
+	}
+	if raw := ctx.PostForm("mapping"); raw != "" {
+		var mapping models.BankStatementColumnMapping
+		if err := json.Unmarshal([]byte(raw), &mapping); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid column mapping",
+				"details": err.Error(),
+			})
+			return
+		}
+		request.Mapping = &mapping
+	}
+
+	fileHeader, err := ctx.FormFile("file")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Statement file is required",
+		})
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to read uploaded file",
+			"details": err.Error(),
+		})
+		return
+	}
+	defer file.Close()
+	request.FileName = fileHeader.Filename
+
+	result, err := c.statementService.ImportStatement(file, request, userID)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to import bank statement",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Bank statement imported",
+		"data":    result,
+	})
+}
+
+// DeleteStatement godoc
+// @Summary Delete an imported bank statement
+// @Tags Bank Statements
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Bank statement ID"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/bank-statements/{id} [delete]
+func (c *BankStatementController) DeleteStatement(ctx *gin.Context) {
+	id, ok := parseBankStatementParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	if err := c.statementService.DeleteStatement(id); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to delete bank statement",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Bank statement deleted",
+	})
+}
+
+// AutoMatch godoc
+// @Summary Auto-match statement lines with cash-bank transactions
+// @Description Matches by amount (within amount_tolerance), date (within date_window_days, default 3) and reference
+// @Tags Bank Statements
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Bank statement ID"
+// @Param request body models.BankStatementMatchRequest false "Tolerance rules"
+// @Success 200 {object} services.BankStatementMatchResult
+// @Router /api/v1/bank-statements/{id}/auto-match [post]
+func (c *BankStatementController) AutoMatch(ctx *gin.Context) {
+	userID := ctx.GetUint("user_id")
+	if userID == 0 {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	id, ok := parseBankStatementParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	var rules models.BankStatementMatchRequest
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&rules); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid request data",
+				"details": err.Error(),
+			})
+			return
+		}
+	}
+
+	result, err := c.statementService.AutoMatch(id, rules, userID)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to match bank statement",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Auto-matching completed",
+		"data":    result,
+	})
+}
+
+// GetReconciliation godoc
+// @Summary Unmatched items on both sides
+// @Description Unmatched statement lines, unmatched cash-bank transactions in the statement period and the balance difference
+// @Tags Bank Statements
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Bank statement ID"
+// @Success 200 {object} services.BankStatementReconciliation
+// @Router /api/v1/bank-statements/{id}/reconciliation [get]
+func (c *BankStatementController) GetReconciliation(ctx *gin.Context) {
+	id, ok := parseBankStatementParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	report, err := c.statementService.GetReconciliation(id)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error":   "Failed to build reconciliation",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    report,
+	})
+}
+
+// MatchLine godoc
+// @Summary Manually match a statement line
+// @Tags Bank Statements
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param line_id path int true "Statement line ID"
+// @Param request body models.BankStatementManualMatchRequest true "Cash-bank transaction"
+// @Success 200 {object} models.BankStatementLine
+// @Router /api/v1/bank-statements/lines/{line_id}/match [post]
+func (c *BankStatementController) MatchLine(ctx *gin.Context) {
+	userID := ctx.GetUint("user_id")
+	if userID == 0 {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	lineID, ok := parseBankStatementParam(ctx, "line_id")
+	if !ok {
+		return
+	}
+
+	var request models.BankStatementManualMatchRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	line, err := c.statementService.MatchLine(lineID, request, userID)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to match statement line",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Statement line matched",
+		"data":    line,
+	})
+}
+
+// UnmatchLine godoc
+// @Summary Remove the match of a statement line
+// @Tags Bank Statements
+// @Produce json
+// @Security BearerAuth
+// @Param line_id path int true "Statement line ID"
+// @Success 200 {object} models.BankStatementLine
+// @Router /api/v1/bank-statements/lines/{line_id}/unmatch [post]
+func (c *BankStatementController) UnmatchLine(ctx *gin.Context) {
+	lineID, ok := parseBankStatementParam(ctx, "line_id")
+	if !ok {
+		return
+	}
+
+	line, err := c.statementService.UnmatchLine(lineID)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to unmatch statement line",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Statement line unmatched",
+		"data":    line,
+	})
+}
+
+// CreateJournalFromLine godoc
+// @Summary Post a bank fee or interest journal from an unmatched statement line
+// @Description Money out debits the counter (expense) account, money in credits it (income); the bank side is the cash-bank GL account
+// @Tags Bank Statements
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param line_id path int true "Statement line ID"
+// @Param request body models.BankStatementJournalRequest true "Counter account"
+// @Success 201 {object} models.BankStatementLine
+// @Router /api/v1/bank-statements/lines/{line_id}/journal [post]
+func (c *BankStatementController) CreateJournalFromLine(ctx *gin.Context) {
+	userID := ctx.GetUint("user_id")
+	if userID == 0 {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	lineID, ok := parseBankStatementParam(ctx, "line_id")
+	if !ok {
+		return
+	}
+
+	var request models.BankStatementJournalRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	line, err := c.statementService.CreateJournalFromLine(lineID, request, userID)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to create journal from statement line",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Journal posted from statement line",
+		"data":    line,
+	})
+}
+
+// GetColumnMappings godoc
+// @Summary List CSV column mappings
+// @Description Built-in presets (BCA, MANDIRI, BRI) and saved custom mappings
+// @Tags Bank Statements
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.BankStatementColumnMapping
+// @Router /api/v1/bank-statements/mappings [get]
+func (c *BankStatementController) GetColumnMappings(ctx *gin.Context) {
+	mappings, err := c.statementService.GetColumnMappings()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to retrieve column mappings",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    mappings,
+	})
+}
+
+// SaveColumnMapping godoc
+// @Summary Save a CSV column mapping
+// @Description Creates or updates (by code) a column mapping; saving a preset code overrides the built-in layout
+// @Tags Bank Statements
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.BankStatementColumnMapping true "Column mapping"
+// @Success 200 {object} models.BankStatementColumnMapping
+// @Router /api/v1/bank-statements/mappings [post]
+func (c *BankStatementController) SaveColumnMapping(ctx *gin.Context) {
+	var request models.BankStatementColumnMapping
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	mapping, err := c.statementService.SaveColumnMapping(request)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to save column mapping",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Column mapping saved",
+		"data":    mapping,
+	})
+}
+
+func parseBankStatementParam(ctx *gin.Context, name string) (uint, bool) {
+	id, err := strconv.ParseUint(ctx.Param(name), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid ID",
+		})
+		return 0, false
+	}
+	return uint(id), true
+}
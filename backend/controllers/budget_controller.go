@@ -0,0 +1,443 @@
+package controllers
+
+import (
+	"app-sistem-akuntansi/services"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type BudgetController struct {
+	budgetService *services.BudgetService
+	pdfService    services.PDFServiceInterface
+}
+
+func NewBudgetController(budgetService *services.BudgetService, pdfService services.PDFServiceInterface) *BudgetController {
+	return &BudgetController{
+		budgetService: budgetService,
+		pdfService:    pdfService,
+	}
+}
+
+// GetBudgets godoc
+// @Summary List budgets
+// @Description Retrieve budgets filtered by fiscal year and status
+// @Tags Budgets
+// @Produce json
+// @Security BearerAuth
+// @Param year query int false "Fiscal year"
+// @Param status query string false "DRAFT, APPROVED, ACTIVE, CLOSED"
+// @Success 200 {array} models.Budget
+// @Router /api/v1/budgets [get]
+func (c *BudgetController) GetBudgets(ctx *gin.Context) {
+	year, _ := strconv.Atoi(ctx.Query("year"))
+
+	budgets, err := c.budgetService.GetBudgets(year, ctx.Query("status"))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to retrieve budgets",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    budgets,
+	})
+}
+
+// GetBudget godoc
+// @Summary Get budget by ID
+// @Description Retrieve a budget with its monthly items
+// @Tags Budgets
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Budget ID"
+// @Success 200 {object} models.Budget
+// @Router /api/v1/budgets/{id} [get]
+func (c *BudgetController) GetBudget(ctx *gin.Context) {
+	id, ok := parseBudgetID(ctx)
+	if !ok {
+		return
+	}
+
+	budget, err := c.budgetService.GetBudgetByID(id)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error": "Budget not found",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    budget,
+	})
+}
+
+// CreateBudget godoc
+// @Summary Create budget
+// @Description Create a DRAFT budget for a fiscal year
+// @Tags Budgets
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body services.CreateBudgetRequest true "Budget data"
+// @Success 201 {object} models.Budget
+// @Router /api/v1/budgets [post]
+func (c *BudgetController) CreateBudget(ctx *gin.Context) {
+	userID := ctx.GetUint("user_id")
+	if userID == 0 {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	var request services.CreateBudgetRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	budget, err := c.budgetService.CreateBudget(request, userID)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to create budget",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Budget created successfully",
+		"data":    budget,
+	})
+}
+
+// CopyFromActuals godoc
+// @Summary Create budget from last year's actuals
+// @Description Create a DRAFT budget whose monthly lines are last year's posted actuals, optionally adjusted by a percentage
+// @Tags Budgets
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body services.CopyBudgetFromActualsRequest true "Copy options"
+// @Success 201 {object} models.Budget
+// @Router /api/v1/budgets/copy-from-actuals [post]
+func (c *BudgetController) CopyFromActuals(ctx *gin.Context) {
+	userID := ctx.GetUint("user_id")
+	if userID == 0 {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	var request services.CopyBudgetFromActualsRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	budget, err := c.budgetService.CopyFromPreviousYearActuals(request, userID)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to create budget from actuals",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Budget created from previous year actuals",
+		"data":    budget,
+	})
+}
+
+// UpdateBudget godoc
+// @Summary Update budget
+// @Description Update a DRAFT budget; items, when provided, replace all existing lines
+// @Tags Budgets
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Budget ID"
+// @Param request body services.UpdateBudgetRequest true "Budget data"
+// @Success 200 {object} models.Budget
+// @Router /api/v1/budgets/{id} [put]
+func (c *BudgetController) UpdateBudget(ctx *gin.Context) {
+	id, ok := parseBudgetID(ctx)
+	if !ok {
+		return
+	}
+
+	var request services.UpdateBudgetRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	budget, err := c.budgetService.UpdateBudget(id, request)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to update budget",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Budget updated successfully",
+		"data":    budget,
+	})
+}
+
+// DeleteBudget godoc
+// @Summary Delete budget
+// @Description Delete a DRAFT budget
+// @Tags Budgets
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Budget ID"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/budgets/{id} [delete]
+func (c *BudgetController) DeleteBudget(ctx *gin.Context) {
+	id, ok := parseBudgetID(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.budgetService.DeleteBudget(id); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to delete budget",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Budget deleted successfully",
+	})
+}
+
+// SubmitForApproval godoc
+// @Summary Submit budget for approval
+// @Description Route a DRAFT budget through the BUDGET approval workflow
+// @Tags Budgets
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Budget ID"
+// @Success 200 {object} models.ApprovalRequest
+// @Router /api/v1/budgets/{id}/submit-approval [post]
+func (c *BudgetController) SubmitForApproval(ctx *gin.Context) {
+	userID := ctx.GetUint("user_id")
+	if userID == 0 {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	id, ok := parseBudgetID(ctx)
+	if !ok {
+		return
+	}
+
+	request, err := c.budgetService.SubmitForApproval(id, userID)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to submit budget for approval",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Budget submitted for approval",
+		"data":    request,
+	})
+}
+
+// ActivateBudget godoc
+// @Summary Activate budget
+// @Description Activate an APPROVED budget; any other ACTIVE budget for the same year is closed
+// @Tags Budgets
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Budget ID"
+// @Success 200 {object} models.Budget
+// @Router /api/v1/budgets/{id}/activate [post]
+func (c *BudgetController) ActivateBudget(ctx *gin.Context) {
+	id, ok := parseBudgetID(ctx)
+	if !ok {
+		return
+	}
+
+	budget, err := c.budgetService.ActivateBudget(id)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to activate budget",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Budget activated successfully",
+		"data":    budget,
+	})
+}
+
+// CloseBudget godoc
+// @Summary Close budget
+// @Description Refresh actuals one last time and close an ACTIVE budget
+// @Tags Budgets
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Budget ID"
+// @Success 200 {object} models.Budget
+// @Router /api/v1/budgets/{id}/close [post]
+func (c *BudgetController) CloseBudget(ctx *gin.Context) {
+	id, ok := parseBudgetID(ctx)
+	if !ok {
+		return
+	}
+
+	budget, err := c.budgetService.CloseBudget(id)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to close budget",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Budget closed successfully",
+		"data":    budget,
+	})
+}
+
+// RefreshActuals godoc
+// @Summary Recalculate budget actuals
+// @Description Recompute and store actual amounts and variances from posted unified journal lines. CLOSED budgets keep the figures frozen at closing.
+// @Tags Budgets
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Budget ID"
+// @Success 200 {object} models.Budget
+// @Router /api/v1/budgets/{id}/refresh-actuals [post]
+func (c *BudgetController) RefreshActuals(ctx *gin.Context) {
+	id, ok := parseBudgetID(ctx)
+	if !ok {
+		return
+	}
+
+	budget, err := c.budgetService.RefreshActuals(id)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to refresh actuals",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    budget,
+	})
+}
+
+// GetBudgetVsActual godoc
+// @Summary Budget vs actual report
+// @Description Budget vs actual per account for a month, quarter or year-to-date, computed without writing to the budget. Use format=pdf or format=excel to download.
+// @Tags Budgets
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Budget ID"
+// @Param period query string false "MONTHLY, QUARTERLY or YTD (default)"
+// @Param month query int false "Month (1-12) for MONTHLY, or YTD end month"
+// @Param quarter query int false "Quarter (1-4) for QUARTERLY"
+// @Param format query string false "json (default), pdf, excel"
+// @Success 200 {object} services.BudgetVsActualReport
+// @Router /api/v1/budgets/{id}/vs-actual [get]
+func (c *BudgetController) GetBudgetVsActual(ctx *gin.Context) {
+	id, ok := parseBudgetID(ctx)
+	if !ok {
+		return
+	}
+
+	month, _ := strconv.Atoi(ctx.Query("month"))
+	quarter, _ := strconv.Atoi(ctx.Query("quarter"))
+
+	report, err := c.budgetService.GetBudgetVsActualReport(id, ctx.Query("period"), month, quarter)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to generate budget vs actual report",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	filename := fmt.Sprintf("budget-vs-actual_%s_%s", report.BudgetCode, report.Period)
+	switch ctx.DefaultQuery("format", "json") {
+	case "pdf":
+		pdfBytes, err := c.pdfService.GenerateBudgetVsActualPDF(report)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Failed to generate PDF",
+				"details": err.Error(),
+			})
+			return
+		}
+		ctx.Header("Content-Disposition", "attachment; filename="+filename+".pdf")
+		ctx.Data(http.StatusOK, "application/pdf", pdfBytes)
+	case "excel":
+		excelBytes, err := c.budgetService.ExportBudgetVsActualExcel(report)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Failed to generate Excel",
+				"details": err.Error(),
+			})
+			return
+		}
+		ctx.Header("Content-Disposition", "attachment; filename="+filename+".xlsx")
+		ctx.Data(http.StatusOK, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", excelBytes)
+	default:
+		ctx.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"data":    report,
+		})
+	}
+}
+
+func parseBudgetID(ctx *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid budget ID",
+		})
+		return 0, false
+	}
+	return uint(id), true
+}
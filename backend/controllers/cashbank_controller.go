@@ -1,6 +1,8 @@
 package controllers
 
 import (
+	"app-sistem-akuntansi/middleware"
+	"app-sistem-akuntansi/models"
 	"app-sistem-akuntansi/services"
 	"log"
 	"net/http"
@@ -86,7 +88,7 @@ func determineStatusCode(err error) int {
 // @Success 200 {array} models.CashBank
 // @Router /api/cashbank/accounts [get]
 func (c *CashBankController) GetAccounts(ctx *gin.Context) {
-	accounts, err := c.cashBankService.GetCashBankAccounts()
+	accounts, err := c.cashBankService.GetCashBankAccountsInScope(middleware.GetDataScope(ctx, models.DataScopeModuleCashBank))
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to retrieve accounts",
@@ -118,7 +120,7 @@ func (c *CashBankController) GetAccountByID(ctx *gin.Context) {
 	}
 	
 	account, err := c.cashBankService.GetCashBankByID(uint(id))
-	if err != nil {
+	if err != nil || !middleware.GetDataScope(ctx, models.DataScopeModuleCashBank).AllowsCashBank(account) {
 		ctx.JSON(http.StatusNotFound, gin.H{
 			"error": "Account not found",
 		})
@@ -301,6 +303,12 @@ func (c *CashBankController) ProcessTransfer(ctx *gin.Context) {
 		})
 		return
 	}
+	if !c.cashBankService.AccountsInScope(middleware.GetDataScope(ctx, models.DataScopeModuleCashBank), request.FromAccountID, request.ToAccountID) {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error": "Account not found",
+		})
+		return
+	}
 	
 	userID := ctx.GetUint("user_id")
 	if userID == 0 {
@@ -341,6 +349,12 @@ func (c *CashBankController) ProcessDeposit(ctx *gin.Context) {
 		})
 		return
 	}
+	if !c.cashBankService.AccountsInScope(middleware.GetDataScope(ctx, models.DataScopeModuleCashBank), request.AccountID) {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error": "Account not found",
+		})
+		return
+	}
 	
 	userID := ctx.GetUint("user_id")
 	
@@ -375,6 +389,12 @@ func (c *CashBankController) ProcessWithdrawal(ctx *gin.Context) {
 		})
 		return
 	}
+	if !c.cashBankService.AccountsInScope(middleware.GetDataScope(ctx, models.DataScopeModuleCashBank), request.AccountID) {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error": "Account not found",
+		})
+		return
+	}
 	
 	userID := ctx.GetUint("user_id")
 	
@@ -413,6 +433,16 @@ func (c *CashBankController) GetTransactions(ctx *gin.Context) {
 		return
 	}
 	
+	if scope := middleware.GetDataScope(ctx, models.DataScopeModuleCashBank); scope.IsRestricted() {
+		account, err := c.cashBankService.GetCashBankByID(uint(accountID))
+		if err != nil || !scope.AllowsCashBank(account) {
+			ctx.JSON(http.StatusNotFound, gin.H{
+				"error": "Account not found",
+			})
+			return
+		}
+	}
+	
 	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
 	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "20"))
 	
@@ -541,7 +571,7 @@ equityAccounts, err := c.accountService.GetEquityAccounts(ctx.Request.Context())
 // @Success 200 {object} models.APIResponse
 // @Router /api/cashbank/payment-accounts [get]
 func (c *CashBankController) GetPaymentAccounts(ctx *gin.Context) {
-	accounts, err := c.cashBankService.GetPaymentAccounts()
+	accounts, err := c.cashBankService.GetPaymentAccountsInScope(middleware.GetDataScope(ctx, models.DataScopeModuleCashBank))
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to retrieve payment accounts",
@@ -13,8 +13,9 @@ import (
 
 // COGSController handles COGS (Cost of Goods Sold) operations
 type COGSController struct {
-	db          *gorm.DB
-	cogsService *services.InventoryCOGSService
+	db             *gorm.DB
+	cogsService    *services.InventoryCOGSService
+	costingService *services.InventoryCostingService
 }
 
 // NewCOGSController creates a new COGS controller
@@ -22,7 +23,8 @@ func NewCOGSController(db *gorm.DB) *COGSController {
 	coaService := services.NewCOAService(db)
 	return &COGSController{
 		db:          db,
-		cogsService: services.NewInventoryCOGSService(db, coaService),
+		cogsService:    services.NewInventoryCOGSService(db, coaService),
+		costingService: services.NewInventoryCostingService(db),
 	}
 }
 
@@ -423,3 +425,108 @@ func (c *COGSController) GetSalesWithoutCOGS(ctx *gin.Context) {
 	})
 }
 
+
+// GetInventoryValuation returns stock valued from receipt cost layers, reconciled to the inventory account
+// @Summary Get Inventory Valuation
+// @Description Value stock on hand from FIFO/LIFO receipt layers or moving average cost and compare it with the inventory GL balance
+// @Tags COGS
+// @Produce json
+// @Success 200 {object} services.InventoryValuationReport
+// @Failure 500 {object} map[string]interface{}
+// @Security BearerAuth
+// @Router /api/v1/cogs/valuation [get]
+func (c *COGSController) GetInventoryValuation(ctx *gin.Context) {
+	report, err := c.costingService.GetValuationReport()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Failed to generate inventory valuation",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   report,
+	})
+}
+
+// GetSaleCostLayers returns the receipt layers that make up the COGS of a sale
+// @Summary Get Sale Cost Layers
+// @Description Get the cost layers consumed by each item of a sale
+// @Tags COGS
+// @Produce json
+// @Param sale_id path int true "Sale ID"
+// @Success 200 {array} models.SaleCostLayer
+// @Failure 400 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Security BearerAuth
+// @Router /api/v1/cogs/layers/{sale_id} [get]
+func (c *COGSController) GetSaleCostLayers(ctx *gin.Context) {
+	saleID, err := strconv.ParseUint(ctx.Param("sale_id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Invalid sale_id",
+		})
+		return
+	}
+
+	layers, err := c.costingService.GetSaleCostLayers(uint(saleID))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Failed to get sale cost layers",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   layers,
+	})
+}
+
+// RecostInventory replays receipts and sales in date order and posts the COGS difference
+// @Summary Re-cost Inventory
+// @Description Rebuild sale cost layers (e.g. after a back-dated purchase) and post a COGS adjustment journal
+// @Tags COGS
+// @Accept json
+// @Produce json
+// @Param request body services.InventoryRecostRequest false "Products to re-cost (empty = all)"
+// @Success 200 {object} services.InventoryRecostResult
+// @Failure 400 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Security BearerAuth
+// @Router /api/v1/cogs/recost [post]
+func (c *COGSController) RecostInventory(ctx *gin.Context) {
+	var request services.InventoryRecostRequest
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&request); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"status":  "error",
+				"message": "Invalid request data",
+				"error":   err.Error(),
+			})
+			return
+		}
+	}
+
+	result, err := c.costingService.RunRecost(request, ctx.GetUint("user_id"))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Failed to re-cost inventory",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"data":    result,
+		"message": fmt.Sprintf("Re-costed %d products, %d sales adjusted", result.ProductsProcessed, result.SalesAdjusted),
+	})
+}
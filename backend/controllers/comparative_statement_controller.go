@@ -0,0 +1,187 @@
+package controllers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"app-sistem-akuntansi/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ComparativeStatementController handles multi-column (period-over-period and budget) SSOT statements
+type ComparativeStatementController struct {
+	db            *gorm.DB
+	ssotPLService *services.SSOTProfitLossService
+	ssotBSService *services.SSOTBalanceSheetService
+	ssotCFService *services.SSOTCashFlowService
+	pdfService    services.PDFServiceInterface
+}
+
+// NewComparativeStatementController creates a new comparative statement controller
+func NewComparativeStatementController(db *gorm.DB) *ComparativeStatementController {
+	return &ComparativeStatementController{
+		db:            db,
+		ssotPLService: services.NewSSOTProfitLossService(db),
+		ssotBSService: services.NewSSOTBalanceSheetService(db),
+		ssotCFService: services.NewSSOTCashFlowService(db),
+		pdfService:    services.NewPDFService(db),
+	}
+}
+
+// GetComparativeStatement generates a comparative statement from a column preset
+// @Summary Generate a comparative SSOT financial statement
+// @Description Multi-column profit & loss, balance sheet or cash flow with absolute and percent variances. Presets: MONTHLY (month by month for a year), QUARTER_YOY (quarter vs same quarter last year), YTD_YOY (year to date vs prior year to date), BUDGET (actual vs budget, profit & loss only)
+// @Tags SSOT Reports
+// @Produce json
+// @Param statement path string true "Statement" Enums(profit-loss,balance-sheet,cash-flow)
+// @Param preset query string true "Column preset" Enums(MONTHLY,QUARTER_YOY,YTD_YOY,BUDGET)
+// @Param year query int false "Year (default current year)"
+// @Param quarter query int false "Quarter 1-4 for QUARTER_YOY (default current quarter)"
+// @Param month query int false "Last month 1-12 for YTD_YOY and BUDGET (default current month)"
+// @Param budget_id query int false "Budget for BUDGET (default the active budget of the year)"
+// @Param format query string false "Output format" Enums(json,pdf,excel) default(json)
+// @Success 200 {object} map[string]interface{} "Comparative statement generated successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid request parameters"
+// @Security BearerAuth
+// @Router /reports/ssot/comparative/{statement} [get]
+func (c *ComparativeStatementController) GetComparativeStatement(ctx *gin.Context) {
+	var req services.ComparativeRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Invalid query parameters",
+			"error":   err.Error(),
+		})
+		return
+	}
+	if req.Preset == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "preset is required",
+		})
+		return
+	}
+	c.respond(ctx, req)
+}
+
+// PostComparativeStatement generates a comparative statement from an explicit list of columns
+// @Summary Generate a comparative SSOT financial statement from column definitions
+// @Description Each column has a key, label, start/end date (balance sheet uses end_date only), optional type BUDGET with budget_id, and optional compare_to naming the column it is compared against
+// @Tags SSOT Reports
+// @Accept json
+// @Produce json
+// @Param statement path string true "Statement" Enums(profit-loss,balance-sheet,cash-flow)
+// @Param format query string false "Output format" Enums(json,pdf,excel) default(json)
+// @Param request body services.ComparativeRequest true "Preset or columns"
+// @Success 200 {object} map[string]interface{} "Comparative statement generated successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid request parameters"
+// @Security BearerAuth
+// @Router /reports/ssot/comparative/{statement} [post]
+func (c *ComparativeStatementController) PostComparativeStatement(ctx *gin.Context) {
+	var req services.ComparativeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Invalid request body",
+			"error":   err.Error(),
+		})
+		return
+	}
+	if req.Preset == "" && len(req.Columns) == 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "preset or columns is required",
+		})
+		return
+	}
+	c.respond(ctx, req)
+}
+
+// respond builds the columns, generates the statement and writes it in the requested format
+func (c *ComparativeStatementController) respond(ctx *gin.Context, req services.ComparativeRequest) {
+	statementParam := ctx.Param("statement")
+	statement := map[string]string{
+		"profit-loss":   services.ComparativeStatementProfitLoss,
+		"balance-sheet": services.ComparativeStatementBalanceSheet,
+		"cash-flow":     services.ComparativeStatementCashFlow,
+	}[statementParam]
+	if statement == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Unsupported statement. Use profit-loss, balance-sheet or cash-flow",
+		})
+		return
+	}
+
+	columns, err := services.BuildComparativeColumns(req, statement)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	var result *services.ComparativeStatement
+	switch statement {
+	case services.ComparativeStatementProfitLoss:
+		result, err = c.ssotPLService.GenerateComparativeProfitLoss(columns)
+	case services.ComparativeStatementBalanceSheet:
+		result, err = c.ssotBSService.GenerateComparativeBalanceSheet(columns)
+	case services.ComparativeStatementCashFlow:
+		result, err = c.ssotCFService.GenerateComparativeCashFlow(columns)
+	}
+	if err != nil {
+		log.Printf("❌ Error generating comparative %s: %v", statementParam, err)
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Failed to generate comparative statement",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	filename := fmt.Sprintf("comparative_%s_%s", strings.ReplaceAll(statementParam, "-", "_"), time.Now().Format("20060102"))
+	switch ctx.DefaultQuery("format", "json") {
+	case "json":
+		ctx.JSON(http.StatusOK, gin.H{
+			"status":  "success",
+			"message": "Comparative statement generated successfully",
+			"data":    result,
+		})
+	case "pdf":
+		pdfBytes, err := c.pdfService.GenerateComparativeStatementPDF(result)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"status":  "error",
+				"message": "Failed to generate PDF",
+				"error":   err.Error(),
+			})
+			return
+		}
+		ctx.Header("Content-Disposition", "attachment; filename="+filename+".pdf")
+		ctx.Data(http.StatusOK, "application/pdf", pdfBytes)
+	case "excel":
+		excelBytes, err := services.ExportComparativeStatementExcel(result)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"status":  "error",
+				"message": "Failed to generate Excel",
+				"error":   err.Error(),
+			})
+			return
+		}
+		ctx.Header("Content-Disposition", "attachment; filename="+filename+".xlsx")
+		ctx.Data(http.StatusOK, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", excelBytes)
+	default:
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Unsupported format. Use json, pdf, or excel",
+		})
+	}
+}
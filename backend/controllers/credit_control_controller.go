@@ -0,0 +1,234 @@
+package controllers
+
+import (
+	"app-sistem-akuntansi/models"
+	"app-sistem-akuntansi/services"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CreditControlController struct {
+	creditControlService *services.CreditControlService
+}
+
+func NewCreditControlController(creditControlService *services.CreditControlService) *CreditControlController {
+	return &CreditControlController{
+		creditControlService: creditControlService,
+	}
+}
+
+// GetCustomerExposure godoc
+// @Summary Customer credit position
+// @Description Credit limit, outstanding invoices, unbilled confirmed orders, available credit, credit hold and overdue invoices
+// @Tags Credit Control
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Customer ID"
+// @Success 200 {object} services.CreditExposure
+// @Router /api/v1/credit-control/customers/{id}/exposure [get]
+func (c *CreditControlController) GetCustomerExposure(ctx *gin.Context) {
+	id, ok := parseCreditControlParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	exposure, err := c.creditControlService.GetCustomerExposure(id)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error":   "Failed to retrieve credit exposure",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    exposure,
+	})
+}
+
+// CheckSale godoc
+// @Summary Check a sale against its customer's credit position without confirming it
+// @Tags Credit Control
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Sale ID"
+// @Success 200 {object} services.CreditCheckResult
+// @Router /api/v1/credit-control/sales/{id}/check [get]
+func (c *CreditControlController) CheckSale(ctx *gin.Context) {
+	id, ok := parseCreditControlParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	result, err := c.creditControlService.CheckSale(id)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error":   "Failed to check sale",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    result,
+	})
+}
+
+// GetCustomersOnHold godoc
+// @Summary Customers currently on credit hold
+// @Tags Credit Control
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.Contact
+// @Router /api/v1/credit-control/holds [get]
+func (c *CreditControlController) GetCustomersOnHold(ctx *gin.Context) {
+	contacts, err := c.creditControlService.GetCustomersOnHold()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to retrieve customers on hold",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    contacts,
+	})
+}
+
+// PlaceHold godoc
+// @Summary Put a customer on credit hold
+// @Tags Credit Control
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Customer ID"
+// @Param request body models.CreditHoldRequest true "Reason"
+// @Success 200 {object} models.Contact
+// @Router /api/v1/credit-control/customers/{id}/hold [post]
+func (c *CreditControlController) PlaceHold(ctx *gin.Context) {
+	c.setHold(ctx, true)
+}
+
+// ReleaseHold godoc
+// @Summary Release a customer's credit hold
+// @Tags Credit Control
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Customer ID"
+// @Param request body models.CreditHoldRequest true "Reason"
+// @Success 200 {object} models.Contact
+// @Router /api/v1/credit-control/customers/{id}/release [post]
+func (c *CreditControlController) ReleaseHold(ctx *gin.Context) {
+	c.setHold(ctx, false)
+}
+
+// GetHoldHistory godoc
+// @Summary Credit hold audit trail of a customer
+// @Tags Credit Control
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Customer ID"
+// @Success 200 {array} models.CreditHoldLog
+// @Router /api/v1/credit-control/customers/{id}/hold-history [get]
+func (c *CreditControlController) GetHoldHistory(ctx *gin.Context) {
+	id, ok := parseCreditControlParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	logs, err := c.creditControlService.GetHoldHistory(id)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to retrieve credit hold history",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    logs,
+	})
+}
+
+func (c *CreditControlController) setHold(ctx *gin.Context, hold bool) {
+	id, ok := parseCreditControlParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	var req models.CreditHoldRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	userID := ctx.GetUint("user_id")
+	var contact *models.Contact
+	var err error
+	message := "Credit hold placed"
+	if hold {
+		contact, err = c.creditControlService.PlaceHold(id, req.Reason, userID)
+	} else {
+		contact, err = c.creditControlService.ReleaseHold(id, req.Reason, userID)
+		message = "Credit hold released"
+	}
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to update credit hold",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    contact,
+		"message": message,
+	})
+}
+
+// respondCreditControlError writes the credit check result when err is a credit control failure
+func respondCreditControlError(ctx *gin.Context, err error) bool {
+	var creditErr *services.CreditControlError
+	if !errors.As(err, &creditErr) {
+		return false
+	}
+
+	code := "CREDIT_CHECK_FAILED"
+	if creditErr.Result.ApprovalStatus == models.SaleCreditApprovalPending {
+		code = "CREDIT_APPROVAL_PENDING"
+	} else if creditErr.Result.ApprovalStatus == models.SaleCreditApprovalRejected {
+		code = "CREDIT_APPROVAL_REJECTED"
+	}
+	ctx.JSON(http.StatusUnprocessableEntity, gin.H{
+		"success": false,
+		"error":   "Credit Control",
+		"message": creditErr.Error(),
+		"code":    code,
+		"data":    creditErr.Result,
+	})
+	return true
+}
+
+func parseCreditControlParam(ctx *gin.Context, name string) (uint, bool) {
+	id, err := strconv.ParseUint(ctx.Param(name), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid " + name,
+		})
+		return 0, false
+	}
+	return uint(id), true
+}
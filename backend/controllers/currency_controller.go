@@ -0,0 +1,369 @@
+package controllers
+
+import (
+	"app-sistem-akuntansi/services"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CurrencyController struct {
+	currencyService *services.CurrencyService
+}
+
+func NewCurrencyController(currencyService *services.CurrencyService) *CurrencyController {
+	return &CurrencyController{
+		currencyService: currencyService,
+	}
+}
+
+// GetExchangeRates godoc
+// @Summary List exchange rates
+// @Description Retrieve exchange rates filtered by currency and date range
+// @Tags Currency
+// @Produce json
+// @Security BearerAuth
+// @Param currency query string false "Currency code (e.g. USD)"
+// @Param start_date query string false "Start date (YYYY-MM-DD)"
+// @Param end_date query string false "End date (YYYY-MM-DD)"
+// @Success 200 {array} models.ExchangeRate
+// @Router /api/v1/currency/rates [get]
+func (c *CurrencyController) GetExchangeRates(ctx *gin.Context) {
+	var startDate, endDate *time.Time
+	if v := ctx.Query("start_date"); v != "" {
+		if d, err := time.Parse("2006-01-02", v); err == nil {
+			startDate = &d
+		}
+	}
+	if v := ctx.Query("end_date"); v != "" {
+		if d, err := time.Parse("2006-01-02", v); err == nil {
+			endDate = &d
+		}
+	}
+
+	rates, err := c.currencyService.GetExchangeRates(ctx.Query("currency"), startDate, endDate)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to retrieve exchange rates",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    rates,
+	})
+}
+
+// GetRate godoc
+// @Summary Get effective exchange rate
+// @Description Get the most recent rate on or before a date
+// @Tags Currency
+// @Produce json
+// @Security BearerAuth
+// @Param currency query string true "Currency code"
+// @Param date query string false "Date (YYYY-MM-DD), default today"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/currency/rates/effective [get]
+func (c *CurrencyController) GetRate(ctx *gin.Context) {
+	currency := ctx.Query("currency")
+	if currency == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "currency is required",
+		})
+		return
+	}
+
+	date := time.Now()
+	if v := ctx.Query("date"); v != "" {
+		d, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid date format, use YYYY-MM-DD",
+			})
+			return
+		}
+		date = d
+	}
+
+	rate, err := c.currencyService.GetRate(currency, date)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error":   "Exchange rate not found",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"currency": currency,
+			"date":     date.Format("2006-01-02"),
+			"rate":     rate,
+		},
+	})
+}
+
+// SaveExchangeRate godoc
+// @Summary Create or update exchange rate
+// @Description Save a manual exchange rate; an existing rate for the same currency and date is replaced
+// @Tags Currency
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body services.ExchangeRateRequest true "Exchange rate"
+// @Success 200 {object} models.ExchangeRate
+// @Router /api/v1/currency/rates [post]
+func (c *CurrencyController) SaveExchangeRate(ctx *gin.Context) {
+	userID := ctx.GetUint("user_id")
+	if userID == 0 {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	var request services.ExchangeRateRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	rate, err := c.currencyService.SaveExchangeRate(request, userID)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to save exchange rate",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Exchange rate saved successfully",
+		"data":    rate,
+	})
+}
+
+// ImportExchangeRates godoc
+// @Summary Import exchange rates from CSV
+// @Description Upload a CSV with columns currency_code,rate_date(YYYY-MM-DD),rate[,notes]
+// @Tags Currency
+// @Accept multipart/form-data
+// @Produce json
+// @Security BearerAuth
+// @Param file formData file true "CSV file"
+// @Success 200 {object} services.ExchangeRateImportResult
+// @Router /api/v1/currency/rates/import [post]
+func (c *CurrencyController) ImportExchangeRates(ctx *gin.Context) {
+	userID := ctx.GetUint("user_id")
+	if userID == 0 {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	fileHeader, err := ctx.FormFile("file")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "CSV file is required",
+		})
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to read uploaded file",
+			"details": err.Error(),
+		})
+		return
+	}
+	defer file.Close()
+
+	result, err := c.currencyService.ImportExchangeRatesCSV(file, userID)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to import exchange rates",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Exchange rates imported",
+		"data":    result,
+	})
+}
+
+// DeleteExchangeRate godoc
+// @Summary Delete exchange rate
+// @Tags Currency
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Exchange rate ID"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/currency/rates/{id} [delete]
+func (c *CurrencyController) DeleteExchangeRate(ctx *gin.Context) {
+	id, ok := parseCurrencyID(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.currencyService.DeleteExchangeRate(id); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to delete exchange rate",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Exchange rate deleted successfully",
+	})
+}
+
+// GetRevaluations godoc
+// @Summary List FX revaluations
+// @Tags Currency
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.FXRevaluation
+// @Router /api/v1/currency/revaluations [get]
+func (c *CurrencyController) GetRevaluations(ctx *gin.Context) {
+	runs, err := c.currencyService.GetRevaluations()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to retrieve revaluations",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    runs,
+	})
+}
+
+// GetRevaluation godoc
+// @Summary Get FX revaluation
+// @Description Retrieve a revaluation run with its revalued items
+// @Tags Currency
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Revaluation ID"
+// @Success 200 {object} models.FXRevaluation
+// @Router /api/v1/currency/revaluations/{id} [get]
+func (c *CurrencyController) GetRevaluation(ctx *gin.Context) {
+	id, ok := parseCurrencyID(ctx)
+	if !ok {
+		return
+	}
+
+	run, err := c.currencyService.GetRevaluationByID(id)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error": "Revaluation not found",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    run,
+	})
+}
+
+// RunRevaluation godoc
+// @Summary Run month-end FX revaluation
+// @Description Revalue open foreign currency receivables, payables and bank balances, posting unrealized gain/loss with an automatic reversal on the next day
+// @Tags Currency
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body services.FXRevaluationRequest true "Revaluation request"
+// @Success 201 {object} models.FXRevaluation
+// @Router /api/v1/currency/revaluations [post]
+func (c *CurrencyController) RunRevaluation(ctx *gin.Context) {
+	userID := ctx.GetUint("user_id")
+	if userID == 0 {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	var request services.FXRevaluationRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	run, err := c.currencyService.RunRevaluation(request, userID)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to run FX revaluation",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "FX revaluation posted successfully",
+		"data":    run,
+	})
+}
+
+// CancelRevaluation godoc
+// @Summary Cancel FX revaluation
+// @Description Cancel a revaluation and its automatic reversal journal
+// @Tags Currency
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Revaluation ID"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/currency/revaluations/{id}/cancel [post]
+func (c *CurrencyController) CancelRevaluation(ctx *gin.Context) {
+	id, ok := parseCurrencyID(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.currencyService.CancelRevaluation(id); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to cancel revaluation",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "FX revaluation cancelled successfully",
+	})
+}
+
+func parseCurrencyID(ctx *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid ID",
+		})
+		return 0, false
+	}
+	return uint(id), true
+}
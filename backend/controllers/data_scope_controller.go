@@ -0,0 +1,248 @@
+package controllers
+
+import (
+	"app-sistem-akuntansi/middleware"
+	"app-sistem-akuntansi/models"
+	"app-sistem-akuntansi/services"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DataScopeController struct {
+	dataScopeService *services.DataScopeService
+	dataScope        *middleware.DataScopeMiddleware
+}
+
+func NewDataScopeController(dataScopeService *services.DataScopeService, dataScope *middleware.DataScopeMiddleware) *DataScopeController {
+	return &DataScopeController{
+		dataScopeService: dataScopeService,
+		dataScope:        dataScope,
+	}
+}
+
+// GetScopes godoc
+// @Summary List data scopes
+// @Tags Data Scopes
+// @Produce json
+// @Security BearerAuth
+// @Param user_id query int false "User ID"
+// @Param role query string false "Role"
+// @Param module query string false "Module (sales, purchases, warehouse_locations, cash_bank)"
+// @Success 200 {array} models.DataScope
+// @Router /api/v1/data-scopes [get]
+func (c *DataScopeController) GetScopes(ctx *gin.Context) {
+	userID, _ := strconv.ParseUint(ctx.Query("user_id"), 10, 32)
+	scopes, err := c.dataScopeService.GetScopes(models.DataScopeFilter{
+		UserID: uint(userID),
+		Role:   ctx.Query("role"),
+		Module: ctx.Query("module"),
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to retrieve data scopes",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    scopes,
+	})
+}
+
+// GetScope godoc
+// @Summary Get data scope
+// @Tags Data Scopes
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Data scope ID"
+// @Success 200 {object} models.DataScope
+// @Router /api/v1/data-scopes/{id} [get]
+func (c *DataScopeController) GetScope(ctx *gin.Context) {
+	id, ok := parseDataScopeParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	scope, err := c.dataScopeService.GetScopeByID(id)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error": "Data scope not found",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    scope,
+	})
+}
+
+// CreateScope godoc
+// @Summary Create data scope
+// @Description Limits which records of a module a user or every user of a role can see: own records, sales persons, warehouses, cash/bank accounts or a maximum amount
+// @Tags Data Scopes
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.DataScopeRequest true "Data scope"
+// @Success 201 {object} models.DataScope
+// @Router /api/v1/data-scopes [post]
+func (c *DataScopeController) CreateScope(ctx *gin.Context) {
+	var request models.DataScopeRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	scope, err := c.dataScopeService.CreateScope(request, ctx.GetUint("user_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to create data scope",
+			"details": err.Error(),
+		})
+		return
+	}
+	c.dataScope.Invalidate(scope)
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Data scope created",
+		"data":    scope,
+	})
+}
+
+// UpdateScope godoc
+// @Summary Update data scope
+// @Tags Data Scopes
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Data scope ID"
+// @Param request body models.DataScopeRequest true "Data scope"
+// @Success 200 {object} models.DataScope
+// @Router /api/v1/data-scopes/{id} [put]
+func (c *DataScopeController) UpdateScope(ctx *gin.Context) {
+	id, ok := parseDataScopeParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	var request models.DataScopeRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	previous, err := c.dataScopeService.GetScopeByID(id)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error": "Data scope not found",
+		})
+		return
+	}
+
+	scope, err := c.dataScopeService.UpdateScope(id, request)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to update data scope",
+			"details": err.Error(),
+		})
+		return
+	}
+	// The subject may have moved to another user or role, so drop both
+	c.dataScope.Invalidate(previous)
+	c.dataScope.Invalidate(scope)
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Data scope updated",
+		"data":    scope,
+	})
+}
+
+// DeleteScope godoc
+// @Summary Delete data scope
+// @Tags Data Scopes
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Data scope ID"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/data-scopes/{id} [delete]
+func (c *DataScopeController) DeleteScope(ctx *gin.Context) {
+	id, ok := parseDataScopeParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	scope, err := c.dataScopeService.DeleteScope(id)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error":   "Failed to delete data scope",
+			"details": err.Error(),
+		})
+		return
+	}
+	c.dataScope.Invalidate(scope)
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Data scope deleted",
+	})
+}
+
+// ExplainAccess godoc
+// @Summary Explain record visibility
+// @Description Shows whether a user can see one record and why: the module permission, the data scope that applies and the result of every restriction
+// @Tags Data Scopes
+// @Produce json
+// @Security BearerAuth
+// @Param user_id query int true "User ID"
+// @Param module query string true "Module (sales, purchases, warehouse_locations, cash_bank)"
+// @Param record_id query int true "Record ID"
+// @Success 200 {object} models.DataScopeExplanation
+// @Router /api/v1/data-scopes/explain [get]
+func (c *DataScopeController) ExplainAccess(ctx *gin.Context) {
+	userID, userErr := strconv.ParseUint(ctx.Query("user_id"), 10, 32)
+	recordID, recordErr := strconv.ParseUint(ctx.Query("record_id"), 10, 32)
+	if userErr != nil || recordErr != nil || ctx.Query("module") == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "user_id, module and record_id are required",
+		})
+		return
+	}
+
+	explanation, err := c.dataScopeService.ExplainAccess(uint(userID), ctx.Query("module"), uint(recordID))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to explain access",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    explanation,
+	})
+}
+
+func parseDataScopeParam(ctx *gin.Context, name string) (uint, bool) {
+	id, err := strconv.ParseUint(ctx.Param(name), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid " + name,
+		})
+		return 0, false
+	}
+	return uint(id), true
+}
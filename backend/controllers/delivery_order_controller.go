@@ -0,0 +1,267 @@
+package controllers
+
+import (
+	"app-sistem-akuntansi/models"
+	"app-sistem-akuntansi/services"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DeliveryOrderController struct {
+	deliveryService *services.DeliveryOrderService
+}
+
+func NewDeliveryOrderController(deliveryService *services.DeliveryOrderService) *DeliveryOrderController {
+	return &DeliveryOrderController{
+		deliveryService: deliveryService,
+	}
+}
+
+// GetDeliveryOrders godoc
+// @Summary List delivery orders
+// @Tags Sales
+// @Produce json
+// @Security BearerAuth
+// @Param sale_id query int false "Sale ID"
+// @Param customer_id query int false "Customer ID"
+// @Param status query string false "SHIPPED or CANCELLED"
+// @Success 200 {array} models.DeliveryOrder
+// @Router /api/v1/sales/deliveries [get]
+func (c *DeliveryOrderController) GetDeliveryOrders(ctx *gin.Context) {
+	saleID, _ := strconv.ParseUint(ctx.Query("sale_id"), 10, 32)
+	customerID, _ := strconv.ParseUint(ctx.Query("customer_id"), 10, 32)
+
+	deliveries, err := c.deliveryService.GetDeliveryOrders(uint(saleID), uint(customerID), strings.ToUpper(ctx.Query("status")))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to retrieve delivery orders",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    deliveries,
+	})
+}
+
+// GetSaleDeliveryOrders godoc
+// @Summary List delivery orders of a sale
+// @Tags Sales
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Sale ID"
+// @Success 200 {array} models.DeliveryOrder
+// @Router /api/v1/sales/{id}/deliveries [get]
+func (c *DeliveryOrderController) GetSaleDeliveryOrders(ctx *gin.Context) {
+	saleID, ok := parseDeliveryOrderParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	deliveries, err := c.deliveryService.GetDeliveryOrders(saleID, 0, "")
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to retrieve delivery orders",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    deliveries,
+	})
+}
+
+// GetDeliveryOrder godoc
+// @Summary Get delivery order
+// @Tags Sales
+// @Produce json
+// @Security BearerAuth
+// @Param delivery_id path int true "Delivery order ID"
+// @Success 200 {object} models.DeliveryOrder
+// @Router /api/v1/sales/deliveries/{delivery_id} [get]
+func (c *DeliveryOrderController) GetDeliveryOrder(ctx *gin.Context) {
+	id, ok := parseDeliveryOrderParam(ctx, "delivery_id")
+	if !ok {
+		return
+	}
+
+	delivery, err := c.deliveryService.GetDeliveryOrderByID(id)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error": "Delivery order not found",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    delivery,
+	})
+}
+
+// CreateDeliveryOrder godoc
+// @Summary Ship goods of a confirmed sale
+// @Description Post a delivery order (surat jalan) for part or all of the remaining quantities and reduce stock; without items everything left is shipped
+// @Tags Sales
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Sale ID"
+// @Param request body models.DeliveryOrderRequest true "Delivery order"
+// @Success 201 {object} models.DeliveryOrder
+// @Router /api/v1/sales/{id}/deliveries [post]
+func (c *DeliveryOrderController) CreateDeliveryOrder(ctx *gin.Context) {
+	userID := ctx.GetUint("user_id")
+	if userID == 0 {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	saleID, ok := parseDeliveryOrderParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	var request models.DeliveryOrderRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	delivery, err := c.deliveryService.CreateDeliveryOrder(saleID, request, userID)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to create delivery order",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Delivery order posted successfully",
+		"data":    delivery,
+	})
+}
+
+// CancelDeliveryOrder godoc
+// @Summary Cancel a delivery order and put the goods back into stock
+// @Description Only possible while the sale has not been invoiced
+// @Tags Sales
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param delivery_id path int true "Delivery order ID"
+// @Param request body models.DeliveryOrderCancelRequest true "Reason"
+// @Success 200 {object} models.DeliveryOrder
+// @Router /api/v1/sales/deliveries/{delivery_id}/cancel [post]
+func (c *DeliveryOrderController) CancelDeliveryOrder(ctx *gin.Context) {
+	id, ok := parseDeliveryOrderParam(ctx, "delivery_id")
+	if !ok {
+		return
+	}
+
+	var request models.DeliveryOrderCancelRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	delivery, err := c.deliveryService.CancelDeliveryOrder(id, request.Reason, ctx.GetUint("user_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to cancel delivery order",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Delivery order cancelled",
+		"data":    delivery,
+	})
+}
+
+// GetDeliveryNotePDF godoc
+// @Summary Download delivery note (surat jalan)
+// @Tags Sales
+// @Produce application/pdf
+// @Security BearerAuth
+// @Param delivery_id path int true "Delivery order ID"
+// @Success 200 {file} file
+// @Router /api/v1/sales/deliveries/{delivery_id}/pdf [get]
+func (c *DeliveryOrderController) GetDeliveryNotePDF(ctx *gin.Context) {
+	id, ok := parseDeliveryOrderParam(ctx, "delivery_id")
+	if !ok {
+		return
+	}
+
+	pdfBytes, delivery, err := c.deliveryService.GenerateDeliveryNotePDF(id)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to generate delivery note PDF",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.Header("Content-Type", "application/pdf")
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=Delivery_Note_%s.pdf", delivery.Code))
+	ctx.Data(http.StatusOK, "application/pdf", pdfBytes)
+}
+
+// GetBackorders godoc
+// @Summary Sale items waiting to be shipped
+// @Tags Sales
+// @Produce json
+// @Security BearerAuth
+// @Param customer_id query int false "Customer ID"
+// @Param product_id query int false "Product ID"
+// @Success 200 {array} models.BackorderItem
+// @Router /api/v1/sales/backorders [get]
+func (c *DeliveryOrderController) GetBackorders(ctx *gin.Context) {
+	customerID, _ := strconv.ParseUint(ctx.Query("customer_id"), 10, 32)
+	productID, _ := strconv.ParseUint(ctx.Query("product_id"), 10, 32)
+
+	items, err := c.deliveryService.GetBackorders(uint(customerID), uint(productID))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to retrieve backorders",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    items,
+	})
+}
+
+func parseDeliveryOrderParam(ctx *gin.Context, name string) (uint, bool) {
+	id, err := strconv.ParseUint(ctx.Param(name), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid " + name,
+		})
+		return 0, false
+	}
+	return uint(id), true
+}
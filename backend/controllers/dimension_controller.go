@@ -0,0 +1,368 @@
+package controllers
+
+import (
+	"app-sistem-akuntansi/models"
+	"app-sistem-akuntansi/services"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DimensionController struct {
+	dimensionService *services.DimensionService
+}
+
+func NewDimensionController(dimensionService *services.DimensionService) *DimensionController {
+	return &DimensionController{
+		dimensionService: dimensionService,
+	}
+}
+
+// GetDimensions godoc
+// @Summary List analytic dimensions (cost centers, projects, tags)
+// @Tags Dimensions
+// @Produce json
+// @Security BearerAuth
+// @Param type query string false "COST_CENTER, PROJECT or TAG"
+// @Param tag_group query string false "Tag group"
+// @Param active query bool false "Only active dimensions"
+// @Success 200 {array} models.Dimension
+// @Router /api/v1/dimensions [get]
+func (c *DimensionController) GetDimensions(ctx *gin.Context) {
+	activeOnly := ctx.Query("active") == "true"
+
+	dimensions, err := c.dimensionService.GetDimensions(ctx.Query("type"), ctx.Query("tag_group"), activeOnly)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to retrieve dimensions",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    dimensions,
+	})
+}
+
+// GetDimension godoc
+// @Summary Get an analytic dimension
+// @Tags Dimensions
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Dimension ID"
+// @Success 200 {object} models.Dimension
+// @Router /api/v1/dimensions/{id} [get]
+func (c *DimensionController) GetDimension(ctx *gin.Context) {
+	id, ok := parseDimensionParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	dimension, err := c.dimensionService.GetDimensionByID(id)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error":   "Dimension not found",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    dimension,
+	})
+}
+
+// CreateDimension godoc
+// @Summary Create a cost center, project or tag
+// @Tags Dimensions
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.DimensionRequest true "Dimension"
+// @Success 201 {object} models.Dimension
+// @Router /api/v1/dimensions [post]
+func (c *DimensionController) CreateDimension(ctx *gin.Context) {
+	userID := ctx.GetUint("user_id")
+	if userID == 0 {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	var request models.DimensionRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	dimension, err := c.dimensionService.CreateDimension(request, userID)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to create dimension",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Dimension created",
+		"data":    dimension,
+	})
+}
+
+// UpdateDimension godoc
+// @Summary Update an analytic dimension
+// @Tags Dimensions
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Dimension ID"
+// @Param request body models.DimensionRequest true "Dimension"
+// @Success 200 {object} models.Dimension
+// @Router /api/v1/dimensions/{id} [put]
+func (c *DimensionController) UpdateDimension(ctx *gin.Context) {
+	id, ok := parseDimensionParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	var request models.DimensionRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	dimension, err := c.dimensionService.UpdateDimension(id, request)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to update dimension",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Dimension updated",
+		"data":    dimension,
+	})
+}
+
+// DeleteDimension godoc
+// @Summary Delete an unused analytic dimension
+// @Tags Dimensions
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Dimension ID"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/dimensions/{id} [delete]
+func (c *DimensionController) DeleteDimension(ctx *gin.Context) {
+	id, ok := parseDimensionParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	if err := c.dimensionService.DeleteDimension(id); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to delete dimension",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Dimension deleted",
+	})
+}
+
+// GetRules godoc
+// @Summary List mandatory dimension rules
+// @Tags Dimensions
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.DimensionRule
+// @Router /api/v1/dimensions/rules [get]
+func (c *DimensionController) GetRules(ctx *gin.Context) {
+	rules, err := c.dimensionService.GetRules()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to retrieve dimension rules",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    rules,
+	})
+}
+
+// CreateRule godoc
+// @Summary Make a dimension mandatory for an account or account code prefix
+// @Tags Dimensions
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.DimensionRuleRequest true "Rule"
+// @Success 201 {object} models.DimensionRule
+// @Router /api/v1/dimensions/rules [post]
+func (c *DimensionController) CreateRule(ctx *gin.Context) {
+	userID := ctx.GetUint("user_id")
+	if userID == 0 {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	var request models.DimensionRuleRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	rule, err := c.dimensionService.CreateRule(request, userID)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to create dimension rule",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Dimension rule created",
+		"data":    rule,
+	})
+}
+
+// UpdateRule godoc
+// @Summary Update a mandatory dimension rule
+// @Tags Dimensions
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param rule_id path int true "Rule ID"
+// @Param request body models.DimensionRuleRequest true "Rule"
+// @Success 200 {object} models.DimensionRule
+// @Router /api/v1/dimensions/rules/{rule_id} [put]
+func (c *DimensionController) UpdateRule(ctx *gin.Context) {
+	id, ok := parseDimensionParam(ctx, "rule_id")
+	if !ok {
+		return
+	}
+
+	var request models.DimensionRuleRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	rule, err := c.dimensionService.UpdateRule(id, request)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to update dimension rule",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Dimension rule updated",
+		"data":    rule,
+	})
+}
+
+// DeleteRule godoc
+// @Summary Delete a mandatory dimension rule
+// @Tags Dimensions
+// @Produce json
+// @Security BearerAuth
+// @Param rule_id path int true "Rule ID"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/dimensions/rules/{rule_id} [delete]
+func (c *DimensionController) DeleteRule(ctx *gin.Context) {
+	id, ok := parseDimensionParam(ctx, "rule_id")
+	if !ok {
+		return
+	}
+
+	if err := c.dimensionService.DeleteRule(id); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to delete dimension rule",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Dimension rule deleted",
+	})
+}
+
+func parseDimensionParam(ctx *gin.Context, name string) (uint, bool) {
+	id, err := strconv.ParseUint(ctx.Param(name), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid ID",
+		})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// parseDimensionFilter reads the optional report dimension parameters: cost_center_id, project_id,
+// tag_id (0 selects unassigned lines), tag_group and group_by (cost_center, project or tag)
+func parseDimensionFilter(ctx *gin.Context) (services.DimensionFilter, string, error) {
+	var filter services.DimensionFilter
+	for name, target := range map[string]**uint{
+		"cost_center_id": &filter.CostCenterID,
+		"project_id":     &filter.ProjectID,
+		"tag_id":         &filter.TagID,
+	} {
+		raw := strings.TrimSpace(ctx.Query(name))
+		if raw == "" {
+			continue
+		}
+		id, err := strconv.ParseUint(raw, 10, 32)
+		if err != nil {
+			return filter, "", fmt.Errorf("invalid %s", name)
+		}
+		value := uint(id)
+		*target = &value
+	}
+	filter.TagGroup = strings.ToUpper(strings.TrimSpace(ctx.Query("tag_group")))
+
+	groupBy := strings.ToLower(strings.TrimSpace(ctx.Query("group_by")))
+	switch groupBy {
+	case "", services.DimensionGroupCostCenter, services.DimensionGroupProject, services.DimensionGroupTag:
+	default:
+		return filter, "", fmt.Errorf("invalid group_by, use cost_center, project or tag")
+	}
+	return filter, groupBy, nil
+}
@@ -0,0 +1,417 @@
+package controllers
+
+import (
+	"app-sistem-akuntansi/middleware"
+	"app-sistem-akuntansi/models"
+	"app-sistem-akuntansi/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type EmailController struct {
+	emailService *services.EmailService
+}
+
+func NewEmailController(emailService *services.EmailService) *EmailController {
+	return &EmailController{emailService: emailService}
+}
+
+// SendInvoiceEmail godoc
+// @Summary Email a sales invoice with its PDF attached
+// @Description Queues the invoice email; the recipient defaults to the customer's email address
+// @Tags Email
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Sale ID"
+// @Param request body models.SendDocumentEmailRequest false "Recipient, cc, language and message"
+// @Success 202 {object} models.EmailMessage
+// @Router /api/v1/sales/{id}/invoice/email [post]
+func (c *EmailController) SendInvoiceEmail(ctx *gin.Context) {
+	id, ok := parseDataScopeParam(ctx, "id")
+	if !ok {
+		return
+	}
+	var req models.SendDocumentEmailRequest
+	if !bindOptionalEmailRequest(ctx, &req) {
+		return
+	}
+
+	message, err := c.emailService.SendInvoiceEmail(id, req, middleware.GetDataScope(ctx, models.DataScopeModuleSales), ctx.GetUint("user_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to send invoice email",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{
+		"success": true,
+		"data":    message,
+		"message": "Invoice email queued",
+	})
+}
+
+// SendReceiptEmail godoc
+// @Summary Email the payment receipt of a fully paid sale with its PDF attached
+// @Tags Email
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Sale ID"
+// @Param request body models.SendDocumentEmailRequest false "Recipient, cc, language and message"
+// @Success 202 {object} models.EmailMessage
+// @Router /api/v1/sales/{id}/receipt/email [post]
+func (c *EmailController) SendReceiptEmail(ctx *gin.Context) {
+	id, ok := parseDataScopeParam(ctx, "id")
+	if !ok {
+		return
+	}
+	var req models.SendDocumentEmailRequest
+	if !bindOptionalEmailRequest(ctx, &req) {
+		return
+	}
+
+	message, err := c.emailService.SendReceiptEmail(id, req, middleware.GetDataScope(ctx, models.DataScopeModuleSales), ctx.GetUint("user_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to send receipt email",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{
+		"success": true,
+		"data":    message,
+		"message": "Receipt email queued",
+	})
+}
+
+// SendStatementEmail godoc
+// @Summary Email a statement of account with its PDF attached
+// @Tags Email
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param contact_id path int true "Customer or vendor ID"
+// @Param request body models.SendStatementEmailRequest true "Period, recipient, cc, language and message"
+// @Success 202 {object} models.EmailMessage
+// @Router /api/v1/statements/contacts/{contact_id}/email [post]
+func (c *EmailController) SendStatementEmail(ctx *gin.Context) {
+	contactID, ok := parseDataScopeParam(ctx, "contact_id")
+	if !ok {
+		return
+	}
+	var req models.SendStatementEmailRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	message, err := c.emailService.SendStatementEmail(contactID, req, ctx.GetUint("user_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to send statement email",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{
+		"success": true,
+		"data":    message,
+		"message": "Statement email queued",
+	})
+}
+
+// SendTestEmail godoc
+// @Summary Send a test email immediately with the current SMTP settings
+// @Tags Email
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body object true "{\"to\": \"someone@example.com\"}"
+// @Success 200 {object} models.EmailMessage
+// @Router /api/v1/email/test [post]
+func (c *EmailController) SendTestEmail(ctx *gin.Context) {
+	var req struct {
+		To string `json:"to" binding:"required"`
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	message, err := c.emailService.SendTestEmail(req.To, ctx.GetUint("user_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to send test email",
+			"details": err.Error(),
+		})
+		return
+	}
+	if message.Status != models.EmailStatusSent {
+		ctx.JSON(http.StatusBadGateway, gin.H{
+			"error":   "Test email was not accepted by the SMTP server",
+			"details": message.LastError,
+			"data":    message,
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    message,
+		"message": "Test email sent",
+	})
+}
+
+// GetMessages godoc
+// @Summary List the outbound email queue
+// @Tags Email
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "QUEUED, SENDING, SENT, FAILED or BOUNCED"
+// @Param type query string false "Email type"
+// @Param entity_type query string false "sale, contact or notification"
+// @Param entity_id query int false "Entity ID"
+// @Param to query string false "Recipient address contains"
+// @Param page query int false "Page"
+// @Param limit query int false "Limit"
+// @Success 200 {object} services.EmailMessageListResult
+// @Router /api/v1/email/messages [get]
+func (c *EmailController) GetMessages(ctx *gin.Context) {
+	var filter models.EmailMessageFilter
+	if err := ctx.ShouldBindQuery(&filter); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid query parameters",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	result, err := c.emailService.GetMessages(filter)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to retrieve emails",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    result,
+	})
+}
+
+// GetMessage godoc
+// @Summary Get an outbound email with its attachments
+// @Tags Email
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Email ID"
+// @Success 200 {object} models.EmailMessage
+// @Router /api/v1/email/messages/{id} [get]
+func (c *EmailController) GetMessage(ctx *gin.Context) {
+	id, ok := parseDataScopeParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	message, err := c.emailService.GetMessage(id)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error":   "Email not found",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    message,
+	})
+}
+
+// RetryMessage godoc
+// @Summary Requeue a FAILED or BOUNCED email
+// @Tags Email
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Email ID"
+// @Success 200 {object} models.EmailMessage
+// @Router /api/v1/email/messages/{id}/retry [post]
+func (c *EmailController) RetryMessage(ctx *gin.Context) {
+	id, ok := parseDataScopeParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	message, err := c.emailService.RetryMessage(id)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to retry email",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    message,
+		"message": "Email requeued",
+	})
+}
+
+// MarkBounced godoc
+// @Summary Record a bounce reported after the SMTP server accepted the email
+// @Tags Email
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Email ID"
+// @Param request body object true "{\"reason\": \"550 mailbox unavailable\"}"
+// @Success 200 {object} models.EmailMessage
+// @Router /api/v1/email/messages/{id}/bounce [post]
+func (c *EmailController) MarkBounced(ctx *gin.Context) {
+	id, ok := parseDataScopeParam(ctx, "id")
+	if !ok {
+		return
+	}
+	var req struct {
+		Reason string `json:"reason" binding:"required"`
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	message, err := c.emailService.MarkBounced(id, req.Reason)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to mark email as bounced",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    message,
+		"message": "Email marked as bounced",
+	})
+}
+
+// GetTemplates godoc
+// @Summary List the effective email template of every type and language
+// @Tags Email
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} services.EffectiveEmailTemplate
+// @Router /api/v1/email/templates [get]
+func (c *EmailController) GetTemplates(ctx *gin.Context) {
+	templates, err := c.emailService.GetTemplates()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to retrieve email templates",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    templates,
+	})
+}
+
+// SaveTemplate godoc
+// @Summary Create or replace the email template of a type and language
+// @Tags Email
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.EmailTemplateRequest true "Template"
+// @Success 200 {object} models.EmailTemplate
+// @Router /api/v1/email/templates [put]
+func (c *EmailController) SaveTemplate(ctx *gin.Context) {
+	var req models.EmailTemplateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	template, err := c.emailService.SaveTemplate(req, ctx.GetUint("user_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to save email template",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    template,
+		"message": "Email template saved",
+	})
+}
+
+// DeleteTemplate godoc
+// @Summary Delete a template override so the built-in template is used again
+// @Tags Email
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Template ID"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/email/templates/{id} [delete]
+func (c *EmailController) DeleteTemplate(ctx *gin.Context) {
+	id, ok := parseDataScopeParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	if err := c.emailService.DeleteTemplate(id); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to delete email template",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Email template deleted",
+	})
+}
+
+// bindOptionalEmailRequest binds the optional JSON body of a document email
+func bindOptionalEmailRequest(ctx *gin.Context, req *models.SendDocumentEmailRequest) bool {
+	if ctx.Request.ContentLength == 0 {
+		return true
+	}
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return false
+	}
+	return true
+}
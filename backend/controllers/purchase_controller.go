@@ -7,6 +7,7 @@ import (
 	"strconv"
 	"strings"
 	"time"
+	"app-sistem-akuntansi/middleware"
 	"app-sistem-akuntansi/models"
 	"app-sistem-akuntansi/services"
 	"app-sistem-akuntansi/repositories"
@@ -90,6 +91,7 @@ func (pc *PurchaseController) GetPurchases(c *gin.Context) {
 			filter.UserID = userID.(uint)
 		}
 	}
+	filter.Scope = middleware.GetDataScope(c, models.DataScopeModulePurchases)
 
 	result, err := pc.purchaseService.GetPurchases(filter)
 	if err != nil {
@@ -120,7 +122,7 @@ func (pc *PurchaseController) GetPurchase(c *gin.Context) {
 	}
 
 	purchase, err := pc.purchaseService.GetPurchaseByID(uint(id))
-	if err != nil {
+	if err != nil || !middleware.GetDataScope(c, models.DataScopeModulePurchases).AllowsPurchase(purchase) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Purchase not found"})
 		return
 	}
@@ -730,6 +732,17 @@ func (pc *PurchaseController) GetPurchasesSummary(c *gin.Context) {
 	c.JSON(http.StatusOK, summary)
 }
 
+// GetPayablesReport returns outstanding purchases and open vendor prepayments
+func (pc *PurchaseController) GetPayablesReport(c *gin.Context) {
+	report, err := pc.purchaseService.GetPayablesReport()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
 // GetVendorPurchaseSummary returns purchase summary for a specific vendor
 func (pc *PurchaseController) GetVendorPurchaseSummary(c *gin.Context) {
 	vendorID, err := strconv.ParseUint(c.Param("vendor_id"), 10, 32)
@@ -870,6 +883,7 @@ func (pc *PurchaseController) CreateIntegratedPayment(c *gin.Context) {
 		CashBankID  *uint   `json:"cash_bank_id"`
 		Reference   string  `json:"reference"`
 		Notes       string  `json:"notes"`
+		ExchangeRate *float64 `json:"exchange_rate"` // Settlement rate for a foreign currency bill; nil = rate table
 	}
 
 	if err := c.ShouldBindJSON(&request); err != nil {
@@ -899,6 +913,7 @@ func (pc *PurchaseController) CreateIntegratedPayment(c *gin.Context) {
 		request.CashBankID,
 		request.Reference,
 		request.Notes,
+		request.ExchangeRate,
 		userID,
 	)
 	if err != nil {
@@ -1099,6 +1114,17 @@ func (pc *PurchaseController) CreatePurchasePayment(c *gin.Context) {
 	}
 	log.Printf("✅ Payment created successfully: ID=%d, Code=%s", payment.ID, payment.Code)
 
+	// Held by a PAYMENT approval workflow: nothing is posted until it is approved
+	if payment.Status == models.PaymentStatusPendingApproval {
+		c.JSON(http.StatusAccepted, gin.H{
+			"success": true,
+			"payment": payment,
+			"message": "Payment is waiting for approval and will be posted once approved",
+			"status":  "pending_approval",
+		})
+		return
+	}
+
 	// 🔥 NEW: Create SSOT journal entry for purchase payment
 	log.Printf("🧾 Creating SSOT journal entry for purchase payment...")
 	err = pc.purchaseService.CreatePurchasePaymentJournal(
@@ -0,0 +1,394 @@
+package controllers
+
+import (
+	"app-sistem-akuntansi/models"
+	"app-sistem-akuntansi/services"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PurchaseRequisitionController struct {
+	requisitionService *services.PurchaseRequisitionService
+}
+
+func NewPurchaseRequisitionController(requisitionService *services.PurchaseRequisitionService) *PurchaseRequisitionController {
+	return &PurchaseRequisitionController{
+		requisitionService: requisitionService,
+	}
+}
+
+// GetRequisitions godoc
+// @Summary List purchase requisitions
+// @Description Employees only see their own requisitions
+// @Tags Purchase Requisitions
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "DRAFT, PENDING_APPROVAL, APPROVED, REJECTED, PARTIALLY_ORDERED, ORDERED or CANCELLED"
+// @Param requester_id query int false "Requester user ID"
+// @Success 200 {array} models.PurchaseRequisition
+// @Router /api/v1/purchase-requisitions [get]
+func (c *PurchaseRequisitionController) GetRequisitions(ctx *gin.Context) {
+	requesterID, _ := strconv.ParseUint(ctx.Query("requester_id"), 10, 32)
+	if requisitionOwnOnly(ctx) {
+		requesterID = uint64(ctx.GetUint("user_id"))
+	}
+
+	requisitions, err := c.requisitionService.GetRequisitions(uint(requesterID), strings.ToUpper(ctx.Query("status")))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to retrieve purchase requisitions",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    requisitions,
+	})
+}
+
+// GetMyRequisitions godoc
+// @Summary Purchase requisitions raised by the current user
+// @Tags Purchase Requisitions
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "Status"
+// @Success 200 {array} models.PurchaseRequisition
+// @Router /api/v1/purchase-requisitions/my [get]
+func (c *PurchaseRequisitionController) GetMyRequisitions(ctx *gin.Context) {
+	requisitions, err := c.requisitionService.GetRequisitions(ctx.GetUint("user_id"), strings.ToUpper(ctx.Query("status")))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to retrieve purchase requisitions",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    requisitions,
+	})
+}
+
+// GetRequisition godoc
+// @Summary Get purchase requisition
+// @Tags Purchase Requisitions
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Requisition ID"
+// @Success 200 {object} models.PurchaseRequisition
+// @Router /api/v1/purchase-requisitions/{id} [get]
+func (c *PurchaseRequisitionController) GetRequisition(ctx *gin.Context) {
+	requisition, ok := c.loadAccessible(ctx)
+	if !ok {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    requisition,
+	})
+}
+
+// CreateRequisition godoc
+// @Summary Create purchase requisition
+// @Tags Purchase Requisitions
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.PurchaseRequisitionRequest true "Requisition"
+// @Success 201 {object} models.PurchaseRequisition
+// @Router /api/v1/purchase-requisitions [post]
+func (c *PurchaseRequisitionController) CreateRequisition(ctx *gin.Context) {
+	userID := ctx.GetUint("user_id")
+	if userID == 0 {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	var request models.PurchaseRequisitionRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	requisition, err := c.requisitionService.CreateRequisition(request, userID)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to create purchase requisition",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Purchase requisition created successfully",
+		"data":    requisition,
+	})
+}
+
+// UpdateRequisition godoc
+// @Summary Update a draft or rejected purchase requisition
+// @Tags Purchase Requisitions
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Requisition ID"
+// @Param request body models.PurchaseRequisitionRequest true "Requisition"
+// @Success 200 {object} models.PurchaseRequisition
+// @Router /api/v1/purchase-requisitions/{id} [put]
+func (c *PurchaseRequisitionController) UpdateRequisition(ctx *gin.Context) {
+	current, ok := c.loadAccessible(ctx)
+	if !ok {
+		return
+	}
+
+	var request models.PurchaseRequisitionRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	requisition, err := c.requisitionService.UpdateRequisition(current.ID, request, ctx.GetUint("user_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to update purchase requisition",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Purchase requisition updated successfully",
+		"data":    requisition,
+	})
+}
+
+// DeleteRequisition godoc
+// @Summary Delete a draft purchase requisition
+// @Tags Purchase Requisitions
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Requisition ID"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/purchase-requisitions/{id} [delete]
+func (c *PurchaseRequisitionController) DeleteRequisition(ctx *gin.Context) {
+	requisition, ok := c.loadAccessible(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.requisitionService.DeleteRequisition(requisition.ID); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to delete purchase requisition",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Purchase requisition deleted successfully",
+	})
+}
+
+// SubmitRequisition godoc
+// @Summary Submit a draft purchase requisition for approval
+// @Tags Purchase Requisitions
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Requisition ID"
+// @Success 200 {object} models.PurchaseRequisition
+// @Router /api/v1/purchase-requisitions/{id}/submit [post]
+func (c *PurchaseRequisitionController) SubmitRequisition(ctx *gin.Context) {
+	current, ok := c.loadAccessible(ctx)
+	if !ok {
+		return
+	}
+
+	requisition, err := c.requisitionService.SubmitForApproval(current.ID, ctx.GetUint("user_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to submit purchase requisition",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Purchase requisition submitted for approval",
+		"data":    requisition,
+	})
+}
+
+// CancelRequisition godoc
+// @Summary Cancel a purchase requisition that has not been ordered
+// @Tags Purchase Requisitions
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Requisition ID"
+// @Param request body models.PurchaseRequisitionCancelRequest true "Reason"
+// @Success 200 {object} models.PurchaseRequisition
+// @Router /api/v1/purchase-requisitions/{id}/cancel [post]
+func (c *PurchaseRequisitionController) CancelRequisition(ctx *gin.Context) {
+	current, ok := c.loadAccessible(ctx)
+	if !ok {
+		return
+	}
+
+	var request models.PurchaseRequisitionCancelRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	requisition, err := c.requisitionService.CancelRequisition(current.ID, request.Reason, ctx.GetUint("user_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to cancel purchase requisition",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Purchase requisition cancelled",
+		"data":    requisition,
+	})
+}
+
+// GetFulfilment godoc
+// @Summary Ordering and receiving status of each requisition line
+// @Tags Purchase Requisitions
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Requisition ID"
+// @Success 200 {object} models.RequisitionFulfilment
+// @Router /api/v1/purchase-requisitions/{id}/fulfilment [get]
+func (c *PurchaseRequisitionController) GetFulfilment(ctx *gin.Context) {
+	requisition, ok := c.loadAccessible(ctx)
+	if !ok {
+		return
+	}
+
+	fulfilment, err := c.requisitionService.GetFulfilment(requisition.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to retrieve requisition fulfilment",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    fulfilment,
+	})
+}
+
+// GetOpenLines godoc
+// @Summary Approved requisition lines not fully ordered yet
+// @Tags Purchase Requisitions
+// @Produce json
+// @Security BearerAuth
+// @Param vendor_id query int false "Suggested vendor"
+// @Success 200 {array} models.PurchaseRequisitionItem
+// @Router /api/v1/purchase-requisitions/open-lines [get]
+func (c *PurchaseRequisitionController) GetOpenLines(ctx *gin.Context) {
+	vendorID, _ := strconv.ParseUint(ctx.Query("vendor_id"), 10, 32)
+
+	lines, err := c.requisitionService.GetOpenLines(uint(vendorID))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to retrieve open requisition lines",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    lines,
+	})
+}
+
+// ConsolidateRequisitions godoc
+// @Summary Turn approved requisition lines into purchase orders, one per vendor
+// @Tags Purchase Requisitions
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.RequisitionConsolidateRequest true "Lines to order"
+// @Success 201 {object} models.RequisitionConsolidateResult
+// @Router /api/v1/purchase-requisitions/consolidate [post]
+func (c *PurchaseRequisitionController) ConsolidateRequisitions(ctx *gin.Context) {
+	var request models.RequisitionConsolidateRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	result, err := c.requisitionService.ConsolidateToPurchases(request, ctx.GetUint("user_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to create purchase orders",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Purchase orders created from requisitions",
+		"data":    result,
+	})
+}
+
+// loadAccessible loads the requisition in the :id param; employees may only reach their own
+func (c *PurchaseRequisitionController) loadAccessible(ctx *gin.Context) (*models.PurchaseRequisition, bool) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid requisition ID",
+		})
+		return nil, false
+	}
+
+	requisition, err := c.requisitionService.GetRequisitionByID(uint(id))
+	if err != nil || (requisitionOwnOnly(ctx) && requisition.RequesterID != ctx.GetUint("user_id")) {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error": "Purchase requisition not found",
+		})
+		return nil, false
+	}
+	return requisition, true
+}
+
+// requisitionOwnOnly reports whether the current user may only see documents they raised
+func requisitionOwnOnly(ctx *gin.Context) bool {
+	return ctx.GetString("role") == models.RoleEmployee
+}
@@ -0,0 +1,246 @@
+package controllers
+
+import (
+	"app-sistem-akuntansi/models"
+	"app-sistem-akuntansi/services"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PurchaseReturnController struct {
+	returnService *services.PurchaseReturnService
+}
+
+func NewPurchaseReturnController(returnService *services.PurchaseReturnService) *PurchaseReturnController {
+	return &PurchaseReturnController{
+		returnService: returnService,
+	}
+}
+
+// GetPurchaseReturns godoc
+// @Summary List purchase returns
+// @Tags Purchases
+// @Produce json
+// @Security BearerAuth
+// @Param purchase_id query int false "Purchase ID"
+// @Param vendor_id query int false "Vendor ID"
+// @Success 200 {array} models.PurchaseReturn
+// @Router /api/v1/purchases/returns [get]
+func (c *PurchaseReturnController) GetPurchaseReturns(ctx *gin.Context) {
+	purchaseID, _ := strconv.ParseUint(ctx.Query("purchase_id"), 10, 32)
+	vendorID, _ := strconv.ParseUint(ctx.Query("vendor_id"), 10, 32)
+
+	returns, err := c.returnService.GetReturns(uint(purchaseID), uint(vendorID))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to retrieve purchase returns",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    returns,
+	})
+}
+
+// GetPurchaseReturn godoc
+// @Summary Get purchase return
+// @Tags Purchases
+// @Produce json
+// @Security BearerAuth
+// @Param return_id path int true "Purchase return ID"
+// @Success 200 {object} models.PurchaseReturn
+// @Router /api/v1/purchases/returns/{return_id} [get]
+func (c *PurchaseReturnController) GetPurchaseReturn(ctx *gin.Context) {
+	id, ok := parsePurchaseReturnParam(ctx, "return_id")
+	if !ok {
+		return
+	}
+
+	purchaseReturn, err := c.returnService.GetReturnByID(id)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error": "Purchase return not found",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    purchaseReturn,
+	})
+}
+
+// CreatePurchaseReturn godoc
+// @Summary Return goods to the vendor
+// @Description Reduce stock, post the reversing journal and settle the value against the purchase outstanding amount; any excess becomes a vendor credit
+// @Tags Purchases
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Purchase ID"
+// @Param request body models.PurchaseReturnRequest true "Purchase return"
+// @Success 201 {object} models.PurchaseReturn
+// @Router /api/v1/purchases/{id}/returns [post]
+func (c *PurchaseReturnController) CreatePurchaseReturn(ctx *gin.Context) {
+	userID := ctx.GetUint("user_id")
+	if userID == 0 {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	purchaseID, ok := parsePurchaseReturnParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	var request models.PurchaseReturnRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	purchaseReturn, err := c.returnService.CreateReturn(purchaseID, request, userID)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to create purchase return",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Purchase return posted successfully",
+		"data":    purchaseReturn,
+	})
+}
+
+// GetDebitNotePDF godoc
+// @Summary Download vendor debit note
+// @Tags Purchases
+// @Produce application/pdf
+// @Security BearerAuth
+// @Param return_id path int true "Purchase return ID"
+// @Success 200 {file} file
+// @Router /api/v1/purchases/returns/{return_id}/debit-note/pdf [get]
+func (c *PurchaseReturnController) GetDebitNotePDF(ctx *gin.Context) {
+	id, ok := parsePurchaseReturnParam(ctx, "return_id")
+	if !ok {
+		return
+	}
+
+	pdfBytes, purchaseReturn, err := c.returnService.GenerateDebitNotePDF(id)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to generate debit note PDF",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.Header("Content-Type", "application/pdf")
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=Debit_Note_%s.pdf", purchaseReturn.DebitNoteNumber))
+	ctx.Data(http.StatusOK, "application/pdf", pdfBytes)
+}
+
+// GetVendorCredits godoc
+// @Summary List vendor credits
+// @Description Credits issued from purchase returns; open_only limits to credits that can still be used
+// @Tags Purchases
+// @Produce json
+// @Security BearerAuth
+// @Param vendor_id path int true "Vendor ID"
+// @Param open_only query bool false "Only credits with a remaining amount"
+// @Success 200 {array} models.VendorCredit
+// @Router /api/v1/purchases/vendor/{vendor_id}/credits [get]
+func (c *PurchaseReturnController) GetVendorCredits(ctx *gin.Context) {
+	vendorID, ok := parsePurchaseReturnParam(ctx, "vendor_id")
+	if !ok {
+		return
+	}
+
+	credits, err := c.returnService.GetVendorCredits(vendorID, ctx.Query("open_only") == "true")
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to retrieve vendor credits",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    credits,
+	})
+}
+
+// ApplyVendorCredit godoc
+// @Summary Pay a purchase with vendor credit
+// @Tags Purchases
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Purchase ID"
+// @Param request body models.VendorCreditApplyRequest true "Vendor credit application"
+// @Success 200 {object} models.Purchase
+// @Router /api/v1/purchases/{id}/apply-vendor-credit [post]
+func (c *PurchaseReturnController) ApplyVendorCredit(ctx *gin.Context) {
+	userID := ctx.GetUint("user_id")
+	if userID == 0 {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	purchaseID, ok := parsePurchaseReturnParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	var request models.VendorCreditApplyRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	purchase, err := c.returnService.ApplyVendorCredit(purchaseID, request, userID)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to apply vendor credit",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Vendor credit applied successfully",
+		"data":    purchase,
+	})
+}
+
+func parsePurchaseReturnParam(ctx *gin.Context, name string) (uint, bool) {
+	id, err := strconv.ParseUint(ctx.Param(name), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid ID",
+		})
+		return 0, false
+	}
+	return uint(id), true
+}
@@ -3,6 +3,7 @@ package controllers
 import (
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"app-sistem-akuntansi/models"
@@ -13,12 +14,14 @@ import (
 type QuoteController struct {
 	quoteService   *services.QuoteServiceFull
 	invoiceService *services.InvoiceServiceFull
+	salesService   *services.SalesServiceV2
 }
 
-func NewQuoteController(quoteService *services.QuoteServiceFull, invoiceService *services.InvoiceServiceFull) *QuoteController {
+func NewQuoteController(quoteService *services.QuoteServiceFull, invoiceService *services.InvoiceServiceFull, salesService *services.SalesServiceV2) *QuoteController {
 	return &QuoteController{
 		quoteService:   quoteService,
 		invoiceService: invoiceService,
+		salesService:   salesService,
 	}
 }
 
@@ -195,7 +198,7 @@ func (c *QuoteController) ConvertToInvoice(ctx *gin.Context) {
 		return
 	}
 
-	invoice, err := c.quoteService.ConvertToInvoice(uint(id), userID.(uint), nil)
+	invoice, err := c.quoteService.ConvertToInvoice(uint(id), userID.(uint), c.invoiceService)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -205,4 +208,88 @@ func (c *QuoteController) ConvertToInvoice(ctx *gin.Context) {
 		"message": "Quote converted to invoice successfully",
 		"data":    invoice,
 	})
-}
\ No newline at end of file
+}
+
+// UpdateQuoteStatus handles POST /quotes/:id/status
+func (c *QuoteController) UpdateQuoteStatus(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid quote ID"})
+		return
+	}
+
+	var request models.QuoteStatusRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	quote, err := c.quoteService.UpdateQuoteStatus(uint(id), request, ctx.GetUint("user_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Quote status updated successfully",
+		"data":    quote,
+	})
+}
+
+// ConvertToSale handles POST /quotes/:id/convert-to-sale
+func (c *QuoteController) ConvertToSale(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid quote ID"})
+		return
+	}
+
+	// Get user ID from JWT token
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	// Body is optional; every field has a default
+	var request models.QuoteConvertToSaleRequest
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&request); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	sale, err := c.quoteService.ConvertToSale(uint(id), request, userID.(uint), c.salesService)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"message": "Quote converted to sale successfully",
+		"data":    sale,
+	})
+}
+
+// ExpireQuotes handles POST /quotes/expire - runs the expiry job now
+func (c *QuoteController) ExpireQuotes(ctx *gin.Context) {
+	result, err := c.quoteService.ExpireQuotes(time.Now())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": result})
+}
+
+// GetConversionAnalytics handles GET /quotes/analytics
+func (c *QuoteController) GetConversionAnalytics(ctx *gin.Context) {
+	analytics, err := c.quoteService.GetConversionAnalytics(ctx.Query("start_date"), ctx.Query("end_date"))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": analytics})
+}
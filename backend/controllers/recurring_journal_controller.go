@@ -0,0 +1,446 @@
+package controllers
+
+import (
+	"app-sistem-akuntansi/models"
+	"app-sistem-akuntansi/services"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RecurringJournalController struct {
+	recurringService *services.RecurringJournalService
+}
+
+func NewRecurringJournalController(recurringService *services.RecurringJournalService) *RecurringJournalController {
+	return &RecurringJournalController{
+		recurringService: recurringService,
+	}
+}
+
+// GetTemplates godoc
+// @Summary List recurring journal templates
+// @Tags Recurring Journals
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "ACTIVE, PAUSED or COMPLETED"
+// @Param type query string false "JOURNAL or EXPENSE"
+// @Success 200 {array} models.RecurringTemplate
+// @Router /api/v1/recurring-journals [get]
+func (c *RecurringJournalController) GetTemplates(ctx *gin.Context) {
+	templates, err := c.recurringService.GetTemplates(ctx.Query("status"), ctx.Query("type"))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to retrieve recurring templates",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    templates,
+	})
+}
+
+// GetTemplate godoc
+// @Summary Get recurring journal template
+// @Tags Recurring Journals
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Template ID"
+// @Success 200 {object} models.RecurringTemplate
+// @Router /api/v1/recurring-journals/{id} [get]
+func (c *RecurringJournalController) GetTemplate(ctx *gin.Context) {
+	id, ok := parseRecurringParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	template, err := c.recurringService.GetTemplateByID(id)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error": "Recurring template not found",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    template,
+	})
+}
+
+// CreateTemplate godoc
+// @Summary Create recurring journal template
+// @Description Daily/weekly/monthly/yearly schedule ending on end_date or after max_occurrences; entries are drafts unless auto_post is set
+// @Tags Recurring Journals
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.RecurringTemplateRequest true "Template"
+// @Success 201 {object} models.RecurringTemplate
+// @Router /api/v1/recurring-journals [post]
+func (c *RecurringJournalController) CreateTemplate(ctx *gin.Context) {
+	userID := ctx.GetUint("user_id")
+	if userID == 0 {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	var request models.RecurringTemplateRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	template, err := c.recurringService.CreateTemplate(request, userID)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to create recurring template",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Recurring template created",
+		"data":    template,
+	})
+}
+
+// CreateFromExpense godoc
+// @Summary Make an expense recurring
+// @Description Each run copies the expense and posts Dr expense account / Cr credit_account_id
+// @Tags Recurring Journals
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param expense_id path int true "Expense ID"
+// @Param request body models.RecurringExpenseRequest true "Schedule"
+// @Success 201 {object} models.RecurringTemplate
+// @Router /api/v1/recurring-journals/from-expense/{expense_id} [post]
+func (c *RecurringJournalController) CreateFromExpense(ctx *gin.Context) {
+	userID := ctx.GetUint("user_id")
+	if userID == 0 {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	expenseID, ok := parseRecurringParam(ctx, "expense_id")
+	if !ok {
+		return
+	}
+
+	var request models.RecurringExpenseRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	template, err := c.recurringService.CreateTemplateFromExpense(expenseID, request, userID)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to create recurring expense",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Recurring expense created",
+		"data":    template,
+	})
+}
+
+// UpdateTemplate godoc
+// @Summary Update recurring journal template
+// @Tags Recurring Journals
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Template ID"
+// @Param request body models.RecurringTemplateRequest true "Template"
+// @Success 200 {object} models.RecurringTemplate
+// @Router /api/v1/recurring-journals/{id} [put]
+func (c *RecurringJournalController) UpdateTemplate(ctx *gin.Context) {
+	id, ok := parseRecurringParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	var request models.RecurringTemplateRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	template, err := c.recurringService.UpdateTemplate(id, request)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to update recurring template",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Recurring template updated",
+		"data":    template,
+	})
+}
+
+// DeleteTemplate godoc
+// @Summary Delete recurring journal template
+// @Description Journals already generated are kept
+// @Tags Recurring Journals
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Template ID"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/recurring-journals/{id} [delete]
+func (c *RecurringJournalController) DeleteTemplate(ctx *gin.Context) {
+	id, ok := parseRecurringParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	if err := c.recurringService.DeleteTemplate(id); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to delete recurring template",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Recurring template deleted",
+	})
+}
+
+// PauseTemplate godoc
+// @Summary Pause recurring journal template
+// @Tags Recurring Journals
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Template ID"
+// @Success 200 {object} models.RecurringTemplate
+// @Router /api/v1/recurring-journals/{id}/pause [post]
+func (c *RecurringJournalController) PauseTemplate(ctx *gin.Context) {
+	id, ok := parseRecurringParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	template, err := c.recurringService.PauseTemplate(id)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to pause recurring template",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Recurring template paused",
+		"data":    template,
+	})
+}
+
+// ResumeTemplate godoc
+// @Summary Resume recurring journal template
+// @Description Dates missed while paused are generated on the next run
+// @Tags Recurring Journals
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Template ID"
+// @Success 200 {object} models.RecurringTemplate
+// @Router /api/v1/recurring-journals/{id}/resume [post]
+func (c *RecurringJournalController) ResumeTemplate(ctx *gin.Context) {
+	id, ok := parseRecurringParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	template, err := c.recurringService.ResumeTemplate(id)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to resume recurring template",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Recurring template resumed",
+		"data":    template,
+	})
+}
+
+// RunTemplate godoc
+// @Summary Generate due entries of one template now
+// @Tags Recurring Journals
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Template ID"
+// @Success 200 {object} services.RecurringRunResult
+// @Router /api/v1/recurring-journals/{id}/run [post]
+func (c *RecurringJournalController) RunTemplate(ctx *gin.Context) {
+	id, ok := parseRecurringParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	result, err := c.recurringService.RunTemplate(id, ctx.GetUint("user_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to run recurring template",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    result,
+	})
+}
+
+// RunDue godoc
+// @Summary Generate all due recurring entries
+// @Description Same as the hourly scheduler; as_of defaults to now
+// @Tags Recurring Journals
+// @Produce json
+// @Security BearerAuth
+// @Param as_of query string false "Process schedules due up to this date (YYYY-MM-DD)"
+// @Success 200 {object} services.RecurringRunResult
+// @Router /api/v1/recurring-journals/run-due [post]
+func (c *RecurringJournalController) RunDue(ctx *gin.Context) {
+	asOf := time.Now()
+	if v := ctx.Query("as_of"); v != "" {
+		d, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid as_of date, use YYYY-MM-DD",
+			})
+			return
+		}
+		if d.Before(asOf) {
+			asOf = d.Add(24*time.Hour - time.Second)
+		}
+	}
+
+	result, err := c.recurringService.RunDue(asOf, ctx.GetUint("user_id"))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to run recurring journals",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    result,
+	})
+}
+
+// GetRuns godoc
+// @Summary Recurring generation history
+// @Tags Recurring Journals
+// @Produce json
+// @Security BearerAuth
+// @Param template_id query int false "Template ID"
+// @Param status query string false "GENERATED, POSTED, SKIPPED, QUEUED or FAILED"
+// @Success 200 {array} models.RecurringRun
+// @Router /api/v1/recurring-journals/runs [get]
+func (c *RecurringJournalController) GetRuns(ctx *gin.Context) {
+	templateID, _ := strconv.ParseUint(ctx.Query("template_id"), 10, 32)
+
+	runs, err := c.recurringService.GetRuns(uint(templateID), ctx.Query("status"))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to retrieve recurring runs",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    runs,
+	})
+}
+
+// ReleaseRun godoc
+// @Summary Post a queued run into an open period
+// @Tags Recurring Journals
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param run_id path int true "Run ID"
+// @Param request body models.RecurringRunReleaseRequest true "Entry date in an open period"
+// @Success 200 {object} models.RecurringRun
+// @Router /api/v1/recurring-journals/runs/{run_id}/release [post]
+func (c *RecurringJournalController) ReleaseRun(ctx *gin.Context) {
+	runID, ok := parseRecurringParam(ctx, "run_id")
+	if !ok {
+		return
+	}
+
+	var request models.RecurringRunReleaseRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	run, err := c.recurringService.ReleaseQueuedRun(runID, request, ctx.GetUint("user_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to release queued run",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Queued run posted",
+		"data":    run,
+	})
+}
+
+func parseRecurringParam(ctx *gin.Context, name string) (uint, bool) {
+	id, err := strconv.ParseUint(ctx.Param(name), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid ID",
+		})
+		return 0, false
+	}
+	return uint(id), true
+}
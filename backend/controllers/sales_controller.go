@@ -9,6 +9,7 @@ import (
 	"strconv"
 	"strings"
 	"time"
+	"app-sistem-akuntansi/middleware"
 	"app-sistem-akuntansi/models"
 	"app-sistem-akuntansi/services"
 	"app-sistem-akuntansi/repositories"
@@ -94,6 +95,7 @@ func (sc *SalesController) GetSales(c *gin.Context) {
 		Search:     search,
 		Page:       page,
 		Limit:      limit,
+		Scope:      middleware.GetDataScope(c, models.DataScopeModuleSales),
 	}
 
 	log.Printf("🔍 Fetching sales with filters: page=%d, limit=%d, status=%s, customer_id=%s", 
@@ -142,8 +144,8 @@ func (sc *SalesController) GetSale(c *gin.Context) {
 	log.Printf("🔍 Getting sale details for ID: %d", id)
 	
 	sale, err := sc.salesServiceV2.GetSaleByID(uint(id))
-	if err != nil {
-		log.Printf("❌ Sale %d not found: %v", id, err)
+	if err != nil || !middleware.GetDataScope(c, models.DataScopeModuleSales).AllowsSale(sale) {
+		log.Printf("❌ Sale %d not found or outside data scope: %v", id, err)
 		utils.SendSaleNotFound(c, uint(id))
 		return
 	}
@@ -359,6 +361,9 @@ func (sc *SalesController) ConfirmSale(c *gin.Context) {
 
 	sale, err := sc.salesServiceV2.ConfirmSale(uint(id), userID)
 	if err != nil {
+		if respondCreditControlError(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -393,6 +398,9 @@ func (sc *SalesController) InvoiceSale(c *gin.Context) {
 	invoice, err := sc.salesServiceV2.CreateInvoice(uint(id), userID)
 	if err != nil {
 		log.Printf("❌ Failed to create invoice for sale %d: %v", id, err)
+		if respondCreditControlError(c, err) {
+			return
+		}
 		
 		// Handle specific error types with appropriate status codes and messages
 		errorMsg := err.Error()
@@ -932,7 +940,13 @@ c.JSON(http.StatusNotImplemented, gin.H{"error": "GetSalesAnalytics is not imple
 
 // GetReceivablesReport gets accounts receivable report
 func (sc *SalesController) GetReceivablesReport(c *gin.Context) {
-c.JSON(http.StatusNotImplemented, gin.H{"error": "GetReceivablesReport is not implemented in this build"})
+	report, err := sc.salesServiceV2.GetReceivablesReport()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get receivables report", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
 }
 
 // PDF Export
@@ -948,7 +962,7 @@ func (sc *SalesController) ExportSaleInvoicePDF(c *gin.Context) {
 
 	// Load sale and generate PDF via pdfService
 	sale, err := sc.salesServiceV2.GetSaleByID(uint(id))
-	if err != nil {
+	if err != nil || !middleware.GetDataScope(c, models.DataScopeModuleSales).AllowsSale(sale) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Sale not found"})
 		return
 	}
@@ -990,7 +1004,7 @@ func (sc *SalesController) ExportSaleReceiptPDF(c *gin.Context) {
 
 	// Load sale details (with payments if available)
 	sale, err := sc.salesServiceV2.GetSaleByID(uint(id))
-	if err != nil {
+	if err != nil || !middleware.GetDataScope(c, models.DataScopeModuleSales).AllowsSale(sale) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Sale not found"})
 		return
 	}
@@ -1053,7 +1067,7 @@ func (sc *SalesController) ExportSalesReportPDF(c *gin.Context) {
 	}
 
 // Collect sales data via service and generate PDF
-	filter := models.SalesFilter{StartDate: startDate, EndDate: endDate, Status: status, CustomerID: customerID, Search: search, Page: 1, Limit: 10000}
+	filter := models.SalesFilter{StartDate: startDate, EndDate: endDate, Status: status, CustomerID: customerID, Search: search, Page: 1, Limit: 10000, Scope: middleware.GetDataScope(c, models.DataScopeModuleSales)}
 	res, err := sc.salesServiceV2.GetSales(filter)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -1088,7 +1102,7 @@ func (sc *SalesController) ExportSalesReportCSV(c *gin.Context) {
 	status := c.Query("status")
 	customerID := c.Query("customer_id")
 	search := c.Query("search")
-	filter := models.SalesFilter{StartDate: startDate, EndDate: endDate, Status: status, CustomerID: customerID, Search: search, Page: 1, Limit: 10000}
+	filter := models.SalesFilter{StartDate: startDate, EndDate: endDate, Status: status, CustomerID: customerID, Search: search, Page: 1, Limit: 10000, Scope: middleware.GetDataScope(c, models.DataScopeModuleSales)}
 	res, err := sc.salesServiceV2.GetSales(filter)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -5,6 +5,7 @@ import (
 	"log"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 	
 	"app-sistem-akuntansi/services"
@@ -37,6 +38,7 @@ func NewSSOTCashFlowController(db *gorm.DB) *SSOTCashFlowController {
 // @Param start_date query string true "Start date (YYYY-MM-DD)" example(2025-01-01)
 // @Param end_date query string true "End date (YYYY-MM-DD)" example(2025-12-31)
 // @Param format query string false "Output format" Enums(json,pdf,excel,csv) default(json)
+// @Param method query string false "Presentation method; direct adds a reconciliation to the indirect figures" Enums(indirect,direct) default(indirect)
 // @Success 200 {object} map[string]interface{} "Cash Flow statement generated successfully"
 // @Failure 400 {object} map[string]interface{} "Invalid request parameters"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
@@ -46,6 +48,7 @@ func (c *SSOTCashFlowController) GetSSOTCashFlow(ctx *gin.Context) {
 	startDate := ctx.Query("start_date")
 	endDate := ctx.Query("end_date")
 	format := ctx.DefaultQuery("format", "json")
+	method := strings.ToLower(ctx.DefaultQuery("method", services.CashFlowMethodIndirect))
 
 	// Validate required parameters
 	if startDate == "" || endDate == "" {
@@ -56,6 +59,14 @@ func (c *SSOTCashFlowController) GetSSOTCashFlow(ctx *gin.Context) {
 		return
 	}
 
+	if method != services.CashFlowMethodIndirect && method != services.CashFlowMethodDirect {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Invalid method. Use indirect or direct",
+		})
+		return
+	}
+
 	// Validate date formats
 	if _, err := time.Parse("2006-01-02", startDate); err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{
@@ -76,7 +87,7 @@ func (c *SSOTCashFlowController) GetSSOTCashFlow(ctx *gin.Context) {
 	}
 
 	// Generate SSOT Cash Flow data
-	ssotData, err := c.ssotCashFlowService.GenerateSSOTCashFlow(startDate, endDate)
+	ssotData, err := c.ssotCashFlowService.GenerateSSOTCashFlowWithMethod(startDate, endDate, method)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"status":  "error",
@@ -117,6 +128,22 @@ func (c *SSOTCashFlowController) GetSSOTCashFlow(ctx *gin.Context) {
 		ctx.Data(http.StatusOK, "application/pdf", pdfData)
 		
 case "csv":
+		// Direct method: the client-side CSV builder only knows the indirect sections, so send the file
+		if ssotData.DirectMethod != nil {
+			csvData, err := c.exportService.ExportToCSV(ssotData, ctx.GetUint("user_id"))
+			if err != nil {
+				ctx.JSON(http.StatusInternalServerError, gin.H{
+					"status":  "error",
+					"message": "Failed to generate CSV",
+					"error":   err.Error(),
+				})
+				return
+			}
+			ctx.Header("Content-Type", "text/csv")
+			ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", c.exportService.GetCSVFilename(ssotData)))
+			ctx.Data(http.StatusOK, "text/csv", csvData)
+			return
+		}
 		// Align with SSOT P&L: return JSON metadata indicating client-side CSV generation
 		response := gin.H{
 			"start_date":     ssotData.StartDate.Format("2006-01-02"),
@@ -359,6 +386,9 @@ func (c *SSOTCashFlowController) TransformToFrontendFormat(ssotData *services.SS
 			"cash_flow_per_share":       ssotData.CashFlowRatios.CashFlowPerShare,
 		},
 		"message": c.generateAnalysisMessage(ssotData),
+		"method":  ssotData.Method,
+		// Direct method statement with its reconciliation block (nil for the indirect method)
+		"direct_method": ssotData.DirectMethod,
 	}
 }
 
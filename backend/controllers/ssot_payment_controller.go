@@ -6,6 +6,7 @@ import (
 	"time"
 	"strings"
 
+	"app-sistem-akuntansi/models"
 	"app-sistem-akuntansi/repositories"
 	"app-sistem-akuntansi/services"
 	"github.com/gin-gonic/gin"
@@ -87,6 +88,16 @@ func (ctrl *SSOTPaymentController) CreatePayablePayment(c *gin.Context) {
 		return
 	}
 
+	// Vendor payment held by a PAYMENT approval workflow; posted once approved
+	if response.Payment != nil && response.Payment.Status == models.PaymentStatusPendingApproval {
+		c.JSON(http.StatusAccepted, gin.H{
+			"message": "Payment is waiting for approval and will be posted once approved",
+			"data":    response,
+			"status":  "pending_approval",
+		})
+		return
+	}
+
 	c.JSON(http.StatusCreated, gin.H{
 		"message": "Payable payment created successfully",
 		"data":    response,
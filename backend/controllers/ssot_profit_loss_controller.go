@@ -38,6 +38,11 @@ func NewSSOTProfitLossController(db *gorm.DB) *SSOTProfitLossController {
 // @Param start_date query string true "Start date (YYYY-MM-DD)" example(2025-01-01)
 // @Param end_date query string true "End date (YYYY-MM-DD)" example(2025-12-31)
 // @Param format query string false "Output format" Enums(json,pdf,excel,csv) default(json)
+// @Param cost_center_id query int false "Cost center filter (0 = unassigned)"
+// @Param project_id query int false "Project filter (0 = unassigned)"
+// @Param tag_id query int false "Tag filter (0 = without a tag of tag_group)"
+// @Param tag_group query string false "Tag group"
+// @Param group_by query string false "One column per dimension (JSON only)" Enums(cost_center,project,tag)
 // @Success 200 {object} map[string]interface{} "P&L report generated successfully"
 // @Failure 400 {object} map[string]interface{} "Invalid request parameters"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
@@ -57,8 +62,34 @@ func (c *SSOTProfitLossController) GetSSOTProfitLoss(ctx *gin.Context) {
 		return
 	}
 
+	// Optional analytic dimension filter / grouping
+	filter, groupBy, err := parseDimensionFilter(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": err.Error(),
+		})
+		return
+	}
+	if groupBy != "" {
+		byDimension, err := c.ssotPLService.GenerateSSOTProfitLossByDimension(startDate, endDate, filter, groupBy, filter.TagGroup)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"status":  "error",
+				"message": "Failed to generate SSOT P&L report by dimension",
+				"error":   err.Error(),
+			})
+			return
+		}
+		ctx.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   byDimension,
+		})
+		return
+	}
+
 	// Generate SSOT P&L data
-	ssotData, err := c.ssotPLService.GenerateSSOTProfitLoss(startDate, endDate)
+	ssotData, err := c.ssotPLService.GenerateSSOTProfitLossFiltered(startDate, endDate, filter)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"status":  "error",
@@ -306,6 +306,11 @@ func (c *SSOTReportIntegrationController) GetSSOTPurchaseReport(ctx *gin.Context
 // @Produce json
 // @Param as_of_date query string false "As of date (YYYY-MM-DD)" default(today)
 // @Param format query string false "Output format (json, pdf, csv)" default(json)
+// @Param cost_center_id query int false "Cost center filter (0 = unassigned)"
+// @Param project_id query int false "Project filter (0 = unassigned)"
+// @Param tag_id query int false "Tag filter (0 = without a tag of tag_group)"
+// @Param tag_group query string false "Tag group"
+// @Param group_by query string false "One column per dimension (JSON only)" Enums(cost_center,project,tag)
 // @Success 200 {object} services.TrialBalanceData
 // @Failure 400 {object} map[string]string
 // @Failure 500 {object} map[string]string
@@ -329,8 +334,34 @@ func (c *SSOTReportIntegrationController) GetSSOTTrialBalance(ctx *gin.Context)
 		}
 	}
 
+	// Optional analytic dimension filter / grouping
+	filter, groupBy, err := parseDimensionFilter(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": err.Error(),
+		})
+		return
+	}
+	if groupBy != "" {
+		byDimension, err := c.integrationService.GenerateTrialBalanceByDimension(asOfDate, filter, groupBy, filter.TagGroup)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"status":  "error",
+				"message": "Failed to generate trial balance by dimension",
+				"error":   err.Error(),
+			})
+			return
+		}
+		ctx.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   byDimension,
+		})
+		return
+	}
+
 	// Generate trial balance from SSOT
-	trialBalance, err := c.integrationService.GenerateTrialBalanceFromSSot(asOfDate)
+	trialBalance, err := c.integrationService.GenerateTrialBalanceFromSSotFiltered(asOfDate, filter)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"status":  "error",
@@ -0,0 +1,130 @@
+package controllers
+
+import (
+	"app-sistem-akuntansi/services"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type StatementController struct {
+	statementService *services.StatementService
+}
+
+func NewStatementController(statementService *services.StatementService) *StatementController {
+	return &StatementController{
+		statementService: statementService,
+	}
+}
+
+// GetContactStatement godoc
+// @Summary Statement of account for a customer or vendor
+// @Description Opening balance, invoices/bills, payments, credit notes and returns in the period, closing balance and 0-30/31-60/61-90/90+ aging
+// @Tags Statements
+// @Produce json
+// @Produce application/pdf
+// @Security BearerAuth
+// @Param contact_id path int true "Customer or vendor ID"
+// @Param start_date query string true "Start date (YYYY-MM-DD)"
+// @Param end_date query string true "End date (YYYY-MM-DD)"
+// @Param format query string false "json (default) or pdf"
+// @Param language query string false "PDF language: id or en (default from settings)"
+// @Success 200 {object} services.StatementOfAccount
+// @Router /api/v1/statements/contacts/{contact_id} [get]
+func (c *StatementController) GetContactStatement(ctx *gin.Context) {
+	contactID, err := strconv.ParseUint(ctx.Param("contact_id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid contact ID",
+		})
+		return
+	}
+	startDate, endDate, ok := parseStatementPeriod(ctx)
+	if !ok {
+		return
+	}
+
+	switch ctx.DefaultQuery("format", "json") {
+	case "pdf":
+		pdfBytes, statement, err := c.statementService.GenerateStatementPDF(uint(contactID), startDate, endDate, ctx.Query("language"))
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Failed to generate statement PDF",
+				"details": err.Error(),
+			})
+			return
+		}
+		ctx.Header("Content-Disposition", "attachment; filename="+services.StatementFilename(statement))
+		ctx.Data(http.StatusOK, "application/pdf", pdfBytes)
+	case "json":
+		statement, err := c.statementService.GetStatement(uint(contactID), startDate, endDate)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Failed to generate statement",
+				"details": err.Error(),
+			})
+			return
+		}
+		ctx.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"data":    statement,
+		})
+	default:
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Unsupported format. Use json or pdf",
+		})
+	}
+}
+
+// GetCustomerStatementsBatch godoc
+// @Summary Statements of account for every customer with an outstanding balance, as one ZIP of PDFs
+// @Tags Statements
+// @Produce application/zip
+// @Security BearerAuth
+// @Param start_date query string true "Start date (YYYY-MM-DD)"
+// @Param end_date query string true "End date (YYYY-MM-DD)"
+// @Param language query string false "PDF language: id or en (default from settings)"
+// @Success 200 {file} file
+// @Router /api/v1/statements/customers/batch [get]
+func (c *StatementController) GetCustomerStatementsBatch(ctx *gin.Context) {
+	startDate, endDate, ok := parseStatementPeriod(ctx)
+	if !ok {
+		return
+	}
+
+	zipBytes, count, err := c.statementService.GenerateCustomerStatementsZIP(startDate, endDate, ctx.Query("language"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to generate customer statements",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	filename := fmt.Sprintf("customer_statements_%s.zip", endDate.Format("20060102"))
+	ctx.Header("Content-Disposition", "attachment; filename="+filename)
+	ctx.Header("X-Statement-Count", strconv.Itoa(count))
+	ctx.Data(http.StatusOK, "application/zip", zipBytes)
+}
+
+// parseStatementPeriod reads the required start_date and end_date (YYYY-MM-DD) query parameters
+func parseStatementPeriod(ctx *gin.Context) (time.Time, time.Time, bool) {
+	startDate, err := time.Parse("2006-01-02", ctx.Query("start_date"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid start_date format. Use YYYY-MM-DD",
+		})
+		return time.Time{}, time.Time{}, false
+	}
+	endDate, err := time.Parse("2006-01-02", ctx.Query("end_date"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid end_date format. Use YYYY-MM-DD",
+		})
+		return time.Time{}, time.Time{}, false
+	}
+	return startDate, endDate, true
+}
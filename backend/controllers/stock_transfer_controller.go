@@ -0,0 +1,422 @@
+package controllers
+
+import (
+	"app-sistem-akuntansi/middleware"
+	"app-sistem-akuntansi/models"
+	"app-sistem-akuntansi/services"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type StockTransferController struct {
+	transferService *services.StockTransferService
+	stockService    *services.StockService
+}
+
+func NewStockTransferController(transferService *services.StockTransferService, stockService *services.StockService) *StockTransferController {
+	return &StockTransferController{
+		transferService: transferService,
+		stockService:    stockService,
+	}
+}
+
+// WarehouseThresholdRequest - Batas stok minimum dan reorder per gudang (0 = ikut produk)
+type WarehouseThresholdRequest struct {
+	ProductID           uint `json:"product_id" binding:"required"`
+	WarehouseLocationID uint `json:"warehouse_location_id" binding:"required"`
+	MinStock            int  `json:"min_stock" binding:"min=0"`
+	ReorderLevel        int  `json:"reorder_level" binding:"min=0"`
+}
+
+// GetTransfers godoc
+// @Summary List stock transfers
+// @Tags Inventory
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "DRAFT, IN_TRANSIT, RECEIVED or CANCELLED"
+// @Param warehouse_id query int false "Source or destination warehouse"
+// @Success 200 {array} models.StockTransfer
+// @Router /api/v1/stock-transfers [get]
+func (c *StockTransferController) GetTransfers(ctx *gin.Context) {
+	warehouseID, _ := strconv.ParseUint(ctx.Query("warehouse_id"), 10, 32)
+
+	transfers, err := c.transferService.GetTransfers(ctx.Query("status"), uint(warehouseID))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to retrieve stock transfers",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	scope := middleware.GetDataScope(ctx, models.DataScopeModuleWarehouseLocations)
+	visible := make([]models.StockTransfer, 0, len(transfers))
+	for _, transfer := range transfers {
+		if scope.AllowsStockTransfer(transfer.FromWarehouseID, transfer.ToWarehouseID) {
+			visible = append(visible, transfer)
+		}
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    visible,
+	})
+}
+
+// GetTransfer godoc
+// @Summary Get stock transfer
+// @Tags Inventory
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Transfer ID"
+// @Success 200 {object} models.StockTransfer
+// @Router /api/v1/stock-transfers/{id} [get]
+func (c *StockTransferController) GetTransfer(ctx *gin.Context) {
+	id, ok := parseStockTransferID(ctx)
+	if !ok {
+		return
+	}
+
+	transfer, err := c.transferService.GetTransferByID(id)
+	if err != nil || !middleware.GetDataScope(ctx, models.DataScopeModuleWarehouseLocations).AllowsStockTransfer(transfer.FromWarehouseID, transfer.ToWarehouseID) {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error": "Stock transfer not found",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    transfer,
+	})
+}
+
+// CreateTransfer godoc
+// @Summary Create stock transfer
+// @Description Create a draft transfer between two warehouse locations
+// @Tags Inventory
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body services.StockTransferRequest true "Stock transfer"
+// @Success 201 {object} models.StockTransfer
+// @Router /api/v1/stock-transfers [post]
+func (c *StockTransferController) CreateTransfer(ctx *gin.Context) {
+	userID := ctx.GetUint("user_id")
+	if userID == 0 {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	var request services.StockTransferRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+	if !requestWarehousesInScope(ctx, request.FromWarehouseID, request.ToWarehouseID) {
+		return
+	}
+
+	transfer, err := c.transferService.CreateTransfer(request, userID)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to create stock transfer",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Stock transfer created successfully",
+		"data":    transfer,
+	})
+}
+
+// UpdateTransfer godoc
+// @Summary Update stock transfer
+// @Description Only draft transfers can be edited
+// @Tags Inventory
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Transfer ID"
+// @Param request body services.StockTransferRequest true "Stock transfer"
+// @Success 200 {object} models.StockTransfer
+// @Router /api/v1/stock-transfers/{id} [put]
+func (c *StockTransferController) UpdateTransfer(ctx *gin.Context) {
+	id, ok := parseStockTransferID(ctx)
+	if !ok || !c.transferInScope(ctx, id) {
+		return
+	}
+
+	var request services.StockTransferRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+	if !requestWarehousesInScope(ctx, request.FromWarehouseID, request.ToWarehouseID) {
+		return
+	}
+
+	transfer, err := c.transferService.UpdateTransfer(id, request)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to update stock transfer",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Stock transfer updated successfully",
+		"data":    transfer,
+	})
+}
+
+// DeleteTransfer godoc
+// @Summary Delete draft stock transfer
+// @Tags Inventory
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Transfer ID"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/stock-transfers/{id} [delete]
+func (c *StockTransferController) DeleteTransfer(ctx *gin.Context) {
+	id, ok := parseStockTransferID(ctx)
+	if !ok || !c.transferInScope(ctx, id) {
+		return
+	}
+
+	if err := c.transferService.DeleteTransfer(id); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to delete stock transfer",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Stock transfer deleted successfully",
+	})
+}
+
+// ShipTransfer godoc
+// @Summary Ship stock transfer
+// @Description Take the quantities out of the source warehouse; they stay in transit until received
+// @Tags Inventory
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Transfer ID"
+// @Success 200 {object} models.StockTransfer
+// @Router /api/v1/stock-transfers/{id}/ship [post]
+func (c *StockTransferController) ShipTransfer(ctx *gin.Context) {
+	id, ok := parseStockTransferID(ctx)
+	if !ok || !c.transferInScope(ctx, id) {
+		return
+	}
+
+	transfer, err := c.transferService.ShipTransfer(id, ctx.GetUint("user_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to ship stock transfer",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Stock transfer shipped",
+		"data":    transfer,
+	})
+}
+
+// ReceiveTransfer godoc
+// @Summary Receive stock transfer
+// @Description Put the in-transit quantities into the destination warehouse
+// @Tags Inventory
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Transfer ID"
+// @Success 200 {object} models.StockTransfer
+// @Router /api/v1/stock-transfers/{id}/receive [post]
+func (c *StockTransferController) ReceiveTransfer(ctx *gin.Context) {
+	id, ok := parseStockTransferID(ctx)
+	if !ok || !c.transferInScope(ctx, id) {
+		return
+	}
+
+	transfer, err := c.transferService.ReceiveTransfer(id, ctx.GetUint("user_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to receive stock transfer",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Stock transfer received",
+		"data":    transfer,
+	})
+}
+
+// CancelTransfer godoc
+// @Summary Cancel stock transfer
+// @Description Cancel a draft or in-transit transfer; in-transit quantities return to the source warehouse
+// @Tags Inventory
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Transfer ID"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/stock-transfers/{id}/cancel [post]
+func (c *StockTransferController) CancelTransfer(ctx *gin.Context) {
+	id, ok := parseStockTransferID(ctx)
+	if !ok || !c.transferInScope(ctx, id) {
+		return
+	}
+
+	if err := c.transferService.CancelTransfer(id); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to cancel stock transfer",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Stock transfer cancelled",
+	})
+}
+
+// GetWarehouseStocks godoc
+// @Summary List per-warehouse stock balances
+// @Tags Inventory
+// @Produce json
+// @Security BearerAuth
+// @Param product_id query int false "Product ID"
+// @Param warehouse_id query int false "Warehouse location ID"
+// @Success 200 {array} models.WarehouseStock
+// @Router /api/v1/warehouse-stocks [get]
+func (c *StockTransferController) GetWarehouseStocks(ctx *gin.Context) {
+	productID, _ := strconv.ParseUint(ctx.Query("product_id"), 10, 32)
+	warehouseID, _ := strconv.ParseUint(ctx.Query("warehouse_id"), 10, 32)
+
+	balances, err := c.stockService.GetWarehouseStocks(uint(productID), uint(warehouseID))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to retrieve warehouse stock",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	scope := middleware.GetDataScope(ctx, models.DataScopeModuleWarehouseLocations)
+	visible := make([]models.WarehouseStock, 0, len(balances))
+	for _, balance := range balances {
+		if scope.AllowsWarehouse(balance.WarehouseLocationID) {
+			visible = append(visible, balance)
+		}
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    visible,
+	})
+}
+
+// SetWarehouseThresholds godoc
+// @Summary Set per-warehouse stock thresholds
+// @Description Set minimum stock and reorder level of a product in one warehouse (0 = use product values)
+// @Tags Inventory
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body WarehouseThresholdRequest true "Thresholds"
+// @Success 200 {object} models.WarehouseStock
+// @Router /api/v1/warehouse-stocks/thresholds [put]
+func (c *StockTransferController) SetWarehouseThresholds(ctx *gin.Context) {
+	var request WarehouseThresholdRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+	if !middleware.GetDataScope(ctx, models.DataScopeModuleWarehouseLocations).AllowsWarehouse(request.WarehouseLocationID) {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error": "Warehouse location not found",
+		})
+		return
+	}
+
+	balance, err := c.stockService.SetWarehouseThresholds(request.ProductID, request.WarehouseLocationID, request.MinStock, request.ReorderLevel)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to update warehouse thresholds",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Warehouse thresholds updated successfully",
+		"data":    balance,
+	})
+}
+
+func parseStockTransferID(ctx *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid ID",
+		})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// transferInScope answers 404 unless both warehouses of the transfer lie inside the user's data scope
+func (c *StockTransferController) transferInScope(ctx *gin.Context, id uint) bool {
+	scope := middleware.GetDataScope(ctx, models.DataScopeModuleWarehouseLocations)
+	if !scope.IsRestricted() {
+		return true
+	}
+	transfer, err := c.transferService.GetTransferByID(id)
+	if err != nil || !scope.AllowsStockTransfer(transfer.FromWarehouseID, transfer.ToWarehouseID) {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error": "Stock transfer not found",
+		})
+		return false
+	}
+	return true
+}
+
+// requestWarehousesInScope answers 404 when a requested source or destination warehouse lies outside
+// the user's data scope
+func requestWarehousesInScope(ctx *gin.Context, fromWarehouseID, toWarehouseID uint) bool {
+	if !middleware.GetDataScope(ctx, models.DataScopeModuleWarehouseLocations).AllowsStockTransfer(fromWarehouseID, toWarehouseID) {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error": "Warehouse location not found",
+		})
+		return false
+	}
+	return true
+}
@@ -0,0 +1,515 @@
+package controllers
+
+import (
+	"app-sistem-akuntansi/models"
+	"app-sistem-akuntansi/services"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TaxInvoiceController struct {
+	taxInvoiceService *services.TaxInvoiceService
+}
+
+func NewTaxInvoiceController(taxInvoiceService *services.TaxInvoiceService) *TaxInvoiceController {
+	return &TaxInvoiceController{
+		taxInvoiceService: taxInvoiceService,
+	}
+}
+
+// GetNumberRanges godoc
+// @Summary List tax invoice number ranges (NSFP)
+// @Tags Tax Invoices
+// @Produce json
+// @Security BearerAuth
+// @Param year query int false "Year"
+// @Success 200 {array} models.TaxInvoiceNumberRange
+// @Router /api/v1/tax-invoices/ranges [get]
+func (c *TaxInvoiceController) GetNumberRanges(ctx *gin.Context) {
+	year, _ := strconv.Atoi(ctx.Query("year"))
+
+	ranges, err := c.taxInvoiceService.GetNumberRanges(year)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to retrieve number ranges",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    ranges,
+	})
+}
+
+// CreateNumberRange godoc
+// @Summary Register an NSFP range allocated by DJP
+// @Tags Tax Invoices
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.TaxInvoiceRangeRequest true "Number range"
+// @Success 201 {object} models.TaxInvoiceNumberRange
+// @Router /api/v1/tax-invoices/ranges [post]
+func (c *TaxInvoiceController) CreateNumberRange(ctx *gin.Context) {
+	userID := ctx.GetUint("user_id")
+	if userID == 0 {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	var request models.TaxInvoiceRangeRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	numberRange, err := c.taxInvoiceService.CreateNumberRange(request, userID)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to create number range",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Tax invoice number range created",
+		"data":    numberRange,
+	})
+}
+
+// CloseNumberRange godoc
+// @Summary Close an NSFP range
+// @Tags Tax Invoices
+// @Produce json
+// @Security BearerAuth
+// @Param range_id path int true "Range ID"
+// @Success 200 {object} models.TaxInvoiceNumberRange
+// @Router /api/v1/tax-invoices/ranges/{range_id}/close [post]
+func (c *TaxInvoiceController) CloseNumberRange(ctx *gin.Context) {
+	id, ok := parseTaxInvoiceParam(ctx, "range_id")
+	if !ok {
+		return
+	}
+
+	numberRange, err := c.taxInvoiceService.CloseNumberRange(id)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to close number range",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Tax invoice number range closed",
+		"data":    numberRange,
+	})
+}
+
+// GetTaxInvoices godoc
+// @Summary List tax invoices
+// @Tags Tax Invoices
+// @Produce json
+// @Security BearerAuth
+// @Param direction query string false "OUTPUT or INPUT"
+// @Param month query int false "Tax period month"
+// @Param year query int false "Tax period year"
+// @Param status query string false "ACTIVE or CANCELLED"
+// @Success 200 {array} models.TaxInvoice
+// @Router /api/v1/tax-invoices [get]
+func (c *TaxInvoiceController) GetTaxInvoices(ctx *gin.Context) {
+	month, _ := strconv.Atoi(ctx.Query("month"))
+	year, _ := strconv.Atoi(ctx.Query("year"))
+
+	invoices, err := c.taxInvoiceService.GetTaxInvoices(ctx.Query("direction"), month, year, ctx.Query("status"))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to retrieve tax invoices",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    invoices,
+	})
+}
+
+// AllocateSaleInvoice godoc
+// @Summary Allocate a tax invoice number to a sale
+// @Description Takes the next NSFP from the active range of the invoice year and records the PPN Keluaran invoice
+// @Tags Tax Invoices
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param sale_id path int true "Sale ID"
+// @Param request body models.TaxInvoiceAllocateRequest false "Allocation"
+// @Success 201 {object} models.TaxInvoice
+// @Router /api/v1/tax-invoices/sales/{sale_id} [post]
+func (c *TaxInvoiceController) AllocateSaleInvoice(ctx *gin.Context) {
+	userID := ctx.GetUint("user_id")
+	if userID == 0 {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	saleID, ok := parseTaxInvoiceParam(ctx, "sale_id")
+	if !ok {
+		return
+	}
+
+	var request models.TaxInvoiceAllocateRequest
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&request); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid request data",
+				"details": err.Error(),
+			})
+			return
+		}
+	}
+
+	invoice, err := c.taxInvoiceService.AllocateSaleInvoice(saleID, request, userID)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to allocate tax invoice number",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Tax invoice number allocated",
+		"data":    invoice,
+	})
+}
+
+// RecordPurchaseInvoice godoc
+// @Summary Record the vendor tax invoice of a purchase (PPN Masukan)
+// @Tags Tax Invoices
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param purchase_id path int true "Purchase ID"
+// @Param request body models.PurchaseTaxInvoiceRequest true "Vendor tax invoice"
+// @Success 201 {object} models.TaxInvoice
+// @Router /api/v1/tax-invoices/purchases/{purchase_id} [post]
+func (c *TaxInvoiceController) RecordPurchaseInvoice(ctx *gin.Context) {
+	userID := ctx.GetUint("user_id")
+	if userID == 0 {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	purchaseID, ok := parseTaxInvoiceParam(ctx, "purchase_id")
+	if !ok {
+		return
+	}
+
+	var request models.PurchaseTaxInvoiceRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	invoice, err := c.taxInvoiceService.RecordPurchaseInvoice(purchaseID, request, userID)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to record purchase tax invoice",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Purchase tax invoice recorded",
+		"data":    invoice,
+	})
+}
+
+// ImportInputInvoices godoc
+// @Summary Import PPN Masukan invoices (e-Faktur FM CSV)
+// @Description Invoices are matched to purchases by vendor NPWP and PPN amount
+// @Tags Tax Invoices
+// @Accept multipart/form-data
+// @Produce json
+// @Security BearerAuth
+// @Param file formData file true "e-Faktur PPN Masukan CSV"
+// @Success 200 {object} services.TaxInvoiceImportResult
+// @Router /api/v1/tax-invoices/input/import [post]
+func (c *TaxInvoiceController) ImportInputInvoices(ctx *gin.Context) {
+	userID := ctx.GetUint("user_id")
+	if userID == 0 {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	fileHeader, err := ctx.FormFile("file")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "e-Faktur file is required",
+		})
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to read uploaded file",
+			"details": err.Error(),
+		})
+		return
+	}
+	defer file.Close()
+
+	result, err := c.taxInvoiceService.ImportInputInvoices(file, userID)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to import tax invoices",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "PPN Masukan invoices imported",
+		"data":    result,
+	})
+}
+
+// MatchInputInvoice godoc
+// @Summary Match an imported PPN Masukan invoice to a purchase
+// @Tags Tax Invoices
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Tax invoice ID"
+// @Param request body models.TaxInvoiceMatchRequest true "Purchase"
+// @Success 200 {object} models.TaxInvoice
+// @Router /api/v1/tax-invoices/{id}/match [post]
+func (c *TaxInvoiceController) MatchInputInvoice(ctx *gin.Context) {
+	id, ok := parseTaxInvoiceParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	var request models.TaxInvoiceMatchRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	invoice, err := c.taxInvoiceService.MatchInputInvoice(id, request)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to match tax invoice",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Tax invoice matched",
+		"data":    invoice,
+	})
+}
+
+// CancelTaxInvoice godoc
+// @Summary Cancel a tax invoice
+// @Tags Tax Invoices
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Tax invoice ID"
+// @Success 200 {object} models.TaxInvoice
+// @Router /api/v1/tax-invoices/{id}/cancel [post]
+func (c *TaxInvoiceController) CancelTaxInvoice(ctx *gin.Context) {
+	id, ok := parseTaxInvoiceParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	invoice, err := c.taxInvoiceService.CancelTaxInvoice(id)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to cancel tax invoice",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Tax invoice cancelled",
+		"data":    invoice,
+	})
+}
+
+// ExportOutputInvoices godoc
+// @Summary Export PPN Keluaran for e-Faktur
+// @Description CSV uses the e-Faktur FK/LT/OF import layout, XML the Coretax bulk import layout
+// @Tags Tax Invoices
+// @Produce text/csv
+// @Produce application/xml
+// @Security BearerAuth
+// @Param month query int true "Tax period month"
+// @Param year query int true "Tax period year"
+// @Param format query string false "csv (default) or xml"
+// @Success 200 {file} file
+// @Router /api/v1/tax-invoices/export/output [get]
+func (c *TaxInvoiceController) ExportOutputInvoices(ctx *gin.Context) {
+	month, year, ok := parseTaxPeriod(ctx)
+	if !ok {
+		return
+	}
+
+	format := strings.ToLower(ctx.DefaultQuery("format", "csv"))
+	var (
+		data        []byte
+		err         error
+		contentType string
+	)
+	switch format {
+	case "csv":
+		data, err = c.taxInvoiceService.ExportOutputCSV(month, year)
+		contentType = "text/csv"
+	case "xml":
+		data, err = c.taxInvoiceService.ExportOutputXML(month, year)
+		contentType = "application/xml"
+	default:
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Unsupported format, use csv or xml",
+		})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to export PPN Keluaran",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	filename := fmt.Sprintf("PPN_Keluaran_%04d%02d.%s", year, month, format)
+	ctx.Header("Content-Type", contentType)
+	ctx.Header("Content-Disposition", "attachment; filename="+filename)
+	ctx.Header("Content-Length", strconv.Itoa(len(data)))
+	ctx.Data(http.StatusOK, contentType, data)
+}
+
+// ExportInputInvoices godoc
+// @Summary Export PPN Masukan for e-Faktur (FM CSV)
+// @Tags Tax Invoices
+// @Produce text/csv
+// @Security BearerAuth
+// @Param month query int true "Tax period month"
+// @Param year query int true "Tax period year"
+// @Success 200 {file} file
+// @Router /api/v1/tax-invoices/export/input [get]
+func (c *TaxInvoiceController) ExportInputInvoices(ctx *gin.Context) {
+	month, year, ok := parseTaxPeriod(ctx)
+	if !ok {
+		return
+	}
+
+	data, err := c.taxInvoiceService.ExportInputCSV(month, year)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to export PPN Masukan",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	filename := fmt.Sprintf("PPN_Masukan_%04d%02d.csv", year, month)
+	ctx.Header("Content-Type", "text/csv")
+	ctx.Header("Content-Disposition", "attachment; filename="+filename)
+	ctx.Header("Content-Length", strconv.Itoa(len(data)))
+	ctx.Data(http.StatusOK, "text/csv", data)
+}
+
+// GetSPTMasaWorksheet godoc
+// @Summary SPT Masa PPN worksheet
+// @Description Reconciles the tax invoices of the month against the PPN Keluaran and PPN Masukan accounts
+// @Tags Tax Invoices
+// @Produce json
+// @Security BearerAuth
+// @Param month query int true "Tax period month"
+// @Param year query int true "Tax period year"
+// @Success 200 {object} services.SPTMasaPPNWorksheet
+// @Router /api/v1/tax-invoices/spt-masa [get]
+func (c *TaxInvoiceController) GetSPTMasaWorksheet(ctx *gin.Context) {
+	month, year, ok := parseTaxPeriod(ctx)
+	if !ok {
+		return
+	}
+
+	worksheet, err := c.taxInvoiceService.GetSPTMasaWorksheet(month, year)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to build SPT Masa PPN worksheet",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    worksheet,
+	})
+}
+
+func parseTaxInvoiceParam(ctx *gin.Context, name string) (uint, bool) {
+	id, err := strconv.ParseUint(ctx.Param(name), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid ID",
+		})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// parseTaxPeriod reads month and year, defaulting to the current month
+func parseTaxPeriod(ctx *gin.Context) (int, int, bool) {
+	now := time.Now()
+	month, errMonth := strconv.Atoi(ctx.DefaultQuery("month", strconv.Itoa(int(now.Month()))))
+	year, errYear := strconv.Atoi(ctx.DefaultQuery("year", strconv.Itoa(now.Year())))
+	if errMonth != nil || errYear != nil || month < 1 || month > 12 {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid tax period, use month=1..12 and year=YYYY",
+		})
+		return 0, 0, false
+	}
+	return month, year, true
+}
@@ -0,0 +1,368 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"app-sistem-akuntansi/models"
+	"app-sistem-akuntansi/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type TwoFactorController struct {
+	db               *gorm.DB
+	twoFactorService *services.TwoFactorService
+}
+
+func NewTwoFactorController(db *gorm.DB, twoFactorService *services.TwoFactorService) *TwoFactorController {
+	return &TwoFactorController{
+		db:               db,
+		twoFactorService: twoFactorService,
+	}
+}
+
+// GetStatus godoc
+// @Summary Two-factor authentication status of the current user
+// @Tags Two-Factor Authentication
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} models.TwoFactorStatus
+// @Router /api/v1/two-factor/status [get]
+func (c *TwoFactorController) GetStatus(ctx *gin.Context) {
+	user, ok := c.currentUser(ctx)
+	if !ok {
+		return
+	}
+
+	status, err := c.twoFactorService.GetStatus(user, ctx.GetString("session_id"))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to retrieve two-factor status",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    status,
+	})
+}
+
+// BeginEnrollment godoc
+// @Summary Start two-factor enrolment
+// @Description Generates a new TOTP secret; render otpauth_url as a QR code for the authenticator app, then confirm with the first code
+// @Tags Two-Factor Authentication
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} models.TwoFactorEnrollment
+// @Router /api/v1/two-factor/enroll [post]
+func (c *TwoFactorController) BeginEnrollment(ctx *gin.Context) {
+	user, ok := c.currentUser(ctx)
+	if !ok {
+		return
+	}
+
+	enrollment, err := c.twoFactorService.BeginEnrollment(user)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to start two-factor enrolment",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    enrollment,
+	})
+}
+
+// ConfirmEnrollment godoc
+// @Summary Confirm two-factor enrolment
+// @Description Activates 2FA with the first code from the authenticator app and returns the recovery codes, which are shown only once
+// @Tags Two-Factor Authentication
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.TwoFactorCodeRequest true "TOTP code"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/two-factor/enroll/confirm [post]
+func (c *TwoFactorController) ConfirmEnrollment(ctx *gin.Context) {
+	user, ok := c.currentUser(ctx)
+	if !ok {
+		return
+	}
+
+	var request models.TwoFactorCodeRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	codes, err := c.twoFactorService.ConfirmEnrollment(user, request.Code, twoFactorClientInfo(ctx))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to enable two-factor authentication",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Two-factor authentication enabled. Store the recovery codes in a safe place, they are shown only once.",
+		"data":    gin.H{"recovery_codes": codes},
+	})
+}
+
+// Disable godoc
+// @Summary Disable two-factor authentication
+// @Description Not available for roles where 2FA is mandatory
+// @Tags Two-Factor Authentication
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.TwoFactorCodeRequest true "TOTP or recovery code"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/two-factor/disable [post]
+func (c *TwoFactorController) Disable(ctx *gin.Context) {
+	user, ok := c.currentUser(ctx)
+	if !ok {
+		return
+	}
+
+	var request models.TwoFactorCodeRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if err := c.twoFactorService.Disable(user, request.Code, twoFactorClientInfo(ctx)); err != nil {
+		ctx.JSON(twoFactorErrorStatus(err), gin.H{
+			"error":   "Failed to disable two-factor authentication",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Two-factor authentication disabled",
+	})
+}
+
+// RegenerateRecoveryCodes godoc
+// @Summary Replace recovery codes
+// @Tags Two-Factor Authentication
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.TwoFactorCodeRequest true "TOTP or recovery code"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/two-factor/recovery-codes [post]
+func (c *TwoFactorController) RegenerateRecoveryCodes(ctx *gin.Context) {
+	user, ok := c.currentUser(ctx)
+	if !ok {
+		return
+	}
+
+	var request models.TwoFactorCodeRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	codes, err := c.twoFactorService.RegenerateRecoveryCodes(user, request.Code, twoFactorClientInfo(ctx))
+	if err != nil {
+		ctx.JSON(twoFactorErrorStatus(err), gin.H{
+			"error":   "Failed to regenerate recovery codes",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "New recovery codes generated, the old ones no longer work",
+		"data":    gin.H{"recovery_codes": codes},
+	})
+}
+
+// StepUp godoc
+// @Summary Confirm a high-risk action with a two-factor code
+// @Description Marks the current session as verified for a few minutes, after which payment approvals and period closing are allowed
+// @Tags Two-Factor Authentication
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.TwoFactorCodeRequest true "TOTP or recovery code"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/two-factor/step-up [post]
+func (c *TwoFactorController) StepUp(ctx *gin.Context) {
+	var request models.TwoFactorCodeRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	validUntil, err := c.twoFactorService.StepUp(ctx.GetUint("user_id"), request.Code, twoFactorClientInfo(ctx))
+	if err != nil {
+		ctx.JSON(twoFactorErrorStatus(err), gin.H{
+			"error":   "Two-factor verification failed",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Verified",
+		"data":    gin.H{"valid_until": validUntil},
+	})
+}
+
+// GetTrustedDevices godoc
+// @Summary List trusted devices of the current user
+// @Tags Two-Factor Authentication
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.TrustedDevice
+// @Router /api/v1/two-factor/trusted-devices [get]
+func (c *TwoFactorController) GetTrustedDevices(ctx *gin.Context) {
+	devices, err := c.twoFactorService.ListTrustedDevices(ctx.GetUint("user_id"))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to retrieve trusted devices",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    devices,
+	})
+}
+
+// RevokeTrustedDevice godoc
+// @Summary Revoke a trusted device
+// @Description The device has to enter a code again on the next login and its open sessions are ended
+// @Tags Two-Factor Authentication
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Trusted device ID"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/two-factor/trusted-devices/{id} [delete]
+func (c *TwoFactorController) RevokeTrustedDevice(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid id",
+		})
+		return
+	}
+
+	if err := c.twoFactorService.RevokeTrustedDevice(ctx.GetUint("user_id"), uint(id)); err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error":   "Failed to revoke trusted device",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Trusted device revoked",
+	})
+}
+
+// ResetUserTwoFactor godoc
+// @Summary Reset another user's two-factor authentication
+// @Description For lost authenticators: removes the secret, recovery codes and trusted devices so the user can enrol again
+// @Tags Two-Factor Authentication
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Param request body models.TwoFactorResetRequest true "Reason"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/two-factor/users/{id}/reset [post]
+func (c *TwoFactorController) ResetUserTwoFactor(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid id",
+		})
+		return
+	}
+
+	var request models.TwoFactorResetRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if err := c.twoFactorService.AdminReset(uint(id), ctx.GetUint("user_id"), request.Reason, twoFactorClientInfo(ctx)); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to reset two-factor authentication",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Two-factor authentication reset, the user has to enrol again",
+	})
+}
+
+func (c *TwoFactorController) currentUser(ctx *gin.Context) (models.User, bool) {
+	var user models.User
+	if err := c.db.First(&user, ctx.GetUint("user_id")).Error; err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return user, false
+	}
+	return user, true
+}
+
+func twoFactorClientInfo(ctx *gin.Context) services.TwoFactorClientInfo {
+	return services.TwoFactorClientInfo{
+		IPAddress: ctx.ClientIP(),
+		UserAgent: ctx.Request.UserAgent(),
+		Method:    ctx.Request.Method,
+		Path:      ctx.Request.URL.Path,
+		SessionID: ctx.GetString("session_id"),
+	}
+}
+
+func twoFactorErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrTwoFactorLocked):
+		return http.StatusTooManyRequests
+	case errors.Is(err, services.ErrInvalidTwoFactorCode):
+		return http.StatusUnauthorized
+	default:
+		return http.StatusBadRequest
+	}
+}
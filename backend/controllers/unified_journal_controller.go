@@ -5,19 +5,22 @@ import (
 	"strconv"
 	"time"
 
+	"app-sistem-akuntansi/models"
 	"app-sistem-akuntansi/services"
 	"github.com/gin-gonic/gin"
 )
 
 // UnifiedJournalController handles REST endpoints for the SSOT journal system
 type UnifiedJournalController struct {
-	journalService *services.UnifiedJournalService
+	journalService  *services.UnifiedJournalService
+	approvalService *services.ApprovalService
 }
 
 // NewUnifiedJournalController creates a new instance of UnifiedJournalController
-func NewUnifiedJournalController(journalService *services.UnifiedJournalService) *UnifiedJournalController {
+func NewUnifiedJournalController(journalService *services.UnifiedJournalService, approvalService *services.ApprovalService) *UnifiedJournalController {
 	return &UnifiedJournalController{
-		journalService: journalService,
+		journalService:  journalService,
+		approvalService: approvalService,
 	}
 }
 
@@ -29,6 +32,7 @@ func NewUnifiedJournalController(journalService *services.UnifiedJournalService)
 // @Produce json
 // @Param journal body services.JournalEntryRequest true "Journal Entry Request"
 // @Success 201 {object} services.JournalResponse
+// @Success 202 {object} map[string]interface{} "Draft waiting for approval (Settings.RequireJournalApproval)"
 // @Failure 400 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /api/v1/journals [post]
@@ -43,6 +47,28 @@ func (c *UnifiedJournalController) CreateJournalEntry(ctx *gin.Context) {
 	if req.EntryDate.IsZero() {
 		req.EntryDate = time.Now()
 	}
+	if req.SourceType == "" {
+		req.SourceType = models.SSOTSourceTypeManual
+	}
+	if req.CreatedBy == 0 {
+		req.CreatedBy = uint64(ctx.GetUint("user_id"))
+	}
+
+	// Manual journals wait as DRAFT for a JOURNAL approval workflow when required by settings
+	if c.approvalService != nil && c.journalService.ManualJournalRequiresApproval(&req) {
+		entry, approvalRequest, err := c.journalService.CreateJournalEntryForApproval(&req, c.approvalService)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusAccepted, gin.H{
+			"success":          true,
+			"data":             entry,
+			"approval_request": approvalRequest,
+			"message":          "Journal entry saved as draft and is waiting for approval",
+		})
+		return
+	}
 
 	response, err := c.journalService.CreateJournalEntry(&req)
 	if err != nil {
@@ -131,7 +131,7 @@ func (uc *UserController) GetUser(c *gin.Context) {
 	}
 
 	var user models.User
-	if err := uc.db.Select("id, username, email, role, first_name, last_name, phone, address, department, position, hire_date, is_active, last_login_at, created_at, updated_at").First(&user, userID).Error; err != nil {
+	if err := uc.db.Select("id, username, email, role, first_name, last_name, phone, address, department, position, hire_date, manager_id, is_active, last_login_at, created_at, updated_at").First(&user, userID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 			return
@@ -169,6 +169,7 @@ func (uc *UserController) GetUser(c *gin.Context) {
 		"department":    user.Department,
 		"position":      user.Position,
 		"hire_date":     user.HireDate,
+		"manager_id":    user.ManagerID,
 		"is_active":     user.IsActive,
 		"last_login_at": user.LastLoginAt,
 		"created_at":    user.CreatedAt,
@@ -262,6 +263,24 @@ func (uc *UserController) UpdateUser(c *gin.Context) {
 	delete(updateData, "created_at")
 	delete(updateData, "updated_at")
 
+	// Manager used by MANAGER approval steps; must be another existing user
+	if managerID, exists := updateData["manager_id"]; exists && managerID != nil {
+		id, ok := managerID.(float64)
+		if !ok || id <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid manager_id"})
+			return
+		}
+		if uint(id) == user.ID {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "A user cannot be their own manager"})
+			return
+		}
+		var manager models.User
+		if err := uc.db.Select("id").First(&manager, uint(id)).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Manager not found"})
+			return
+		}
+	}
+
 	// Handle password update separately if provided
 	if password, exists := updateData["password"]; exists {
 		if passwordStr, ok := password.(string); ok && passwordStr != "" {
@@ -282,7 +301,7 @@ func (uc *UserController) UpdateUser(c *gin.Context) {
 	}
 
 	// Fetch updated user
-	if err := uc.db.Select("id, username, email, role, first_name, last_name, phone, address, department, position, hire_date, is_active, last_login_at, created_at, updated_at").First(&user, userID).Error; err != nil {
+	if err := uc.db.Select("id, username, email, role, first_name, last_name, phone, address, department, position, hire_date, manager_id, is_active, last_login_at, created_at, updated_at").First(&user, userID).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch updated user"})
 		return
 	}
@@ -316,6 +335,7 @@ func (uc *UserController) UpdateUser(c *gin.Context) {
 		"department":    user.Department,
 		"position":      user.Position,
 		"hire_date":     user.HireDate,
+		"manager_id":    user.ManagerID,
 		"is_active":     user.IsActive,
 		"last_login_at": user.LastLoginAt,
 		"created_at":    user.CreatedAt,
@@ -0,0 +1,613 @@
+package controllers
+
+import (
+	"app-sistem-akuntansi/models"
+	"app-sistem-akuntansi/services"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type VendorPriceController struct {
+	vendorPriceService *services.VendorPriceService
+}
+
+func NewVendorPriceController(vendorPriceService *services.VendorPriceService) *VendorPriceController {
+	return &VendorPriceController{
+		vendorPriceService: vendorPriceService,
+	}
+}
+
+// ========== PRICE LISTS ==========
+
+// GetPriceLists godoc
+// @Summary List vendor prices
+// @Tags Vendor Prices
+// @Produce json
+// @Security BearerAuth
+// @Param vendor_id query int false "Vendor ID"
+// @Param product_id query int false "Product ID"
+// @Param valid_on query string false "Only entries valid on this date (YYYY-MM-DD)"
+// @Param active query bool false "Active flag"
+// @Success 200 {array} models.VendorPriceList
+// @Router /api/v1/vendor-prices [get]
+func (c *VendorPriceController) GetPriceLists(ctx *gin.Context) {
+	vendorID, _ := strconv.ParseUint(ctx.Query("vendor_id"), 10, 32)
+	productID, _ := strconv.ParseUint(ctx.Query("product_id"), 10, 32)
+	filter := models.VendorPriceFilter{
+		VendorID:  uint(vendorID),
+		ProductID: uint(productID),
+	}
+	if validOn := ctx.Query("valid_on"); validOn != "" {
+		date, err := time.Parse("2006-01-02", validOn)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid valid_on date, use YYYY-MM-DD",
+			})
+			return
+		}
+		filter.ValidOn = &date
+	}
+	if active := ctx.Query("active"); active != "" {
+		value := active == "true"
+		filter.Active = &value
+	}
+
+	entries, err := c.vendorPriceService.GetPriceLists(filter)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to retrieve vendor prices",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    entries,
+	})
+}
+
+// GetPriceList godoc
+// @Summary Get vendor price
+// @Tags Vendor Prices
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Price list entry ID"
+// @Success 200 {object} models.VendorPriceList
+// @Router /api/v1/vendor-prices/{id} [get]
+func (c *VendorPriceController) GetPriceList(ctx *gin.Context) {
+	id, ok := parseVendorPriceParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	entry, err := c.vendorPriceService.GetPriceListByID(id)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error": "Price list entry not found",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    entry,
+	})
+}
+
+// CreatePriceList godoc
+// @Summary Add vendor price
+// @Description Price of a product at one vendor, optionally tiered by minimum quantity and limited to a validity period
+// @Tags Vendor Prices
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.VendorPriceListRequest true "Vendor price"
+// @Success 201 {object} models.VendorPriceList
+// @Router /api/v1/vendor-prices [post]
+func (c *VendorPriceController) CreatePriceList(ctx *gin.Context) {
+	var request models.VendorPriceListRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	entry, err := c.vendorPriceService.CreatePriceList(request, ctx.GetUint("user_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to create vendor price",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Vendor price created successfully",
+		"data":    entry,
+	})
+}
+
+// UpdatePriceList godoc
+// @Summary Update vendor price
+// @Tags Vendor Prices
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Price list entry ID"
+// @Param request body models.VendorPriceListRequest true "Vendor price"
+// @Success 200 {object} models.VendorPriceList
+// @Router /api/v1/vendor-prices/{id} [put]
+func (c *VendorPriceController) UpdatePriceList(ctx *gin.Context) {
+	id, ok := parseVendorPriceParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	var request models.VendorPriceListRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	entry, err := c.vendorPriceService.UpdatePriceList(id, request, ctx.GetUint("user_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to update vendor price",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Vendor price updated successfully",
+		"data":    entry,
+	})
+}
+
+// DeletePriceList godoc
+// @Summary Delete vendor price
+// @Tags Vendor Prices
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Price list entry ID"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/vendor-prices/{id} [delete]
+func (c *VendorPriceController) DeletePriceList(ctx *gin.Context) {
+	id, ok := parseVendorPriceParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	if err := c.vendorPriceService.DeletePriceList(id); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to delete vendor price",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Vendor price deleted successfully",
+	})
+}
+
+// GetBestPrice godoc
+// @Summary Best valid vendor price for a product
+// @Description Cheapest active entry valid on the date whose minimum quantity is reached; without vendor_id every vendor is considered
+// @Tags Vendor Prices
+// @Produce json
+// @Security BearerAuth
+// @Param product_id query int true "Product ID"
+// @Param vendor_id query int false "Vendor ID"
+// @Param quantity query int false "Quantity (default 1)"
+// @Param date query string false "Date (YYYY-MM-DD, default today)"
+// @Success 200 {object} models.BestVendorPrice
+// @Router /api/v1/vendor-prices/best [get]
+func (c *VendorPriceController) GetBestPrice(ctx *gin.Context) {
+	productID, err := strconv.ParseUint(ctx.Query("product_id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "product_id is required",
+		})
+		return
+	}
+	vendorID, _ := strconv.ParseUint(ctx.Query("vendor_id"), 10, 32)
+	quantity, _ := strconv.Atoi(ctx.DefaultQuery("quantity", "1"))
+	date := time.Now()
+	if value := ctx.Query("date"); value != "" {
+		if date, err = time.Parse("2006-01-02", value); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid date, use YYYY-MM-DD",
+			})
+			return
+		}
+	}
+
+	best, err := c.vendorPriceService.GetBestPrice(uint(productID), uint(vendorID), quantity, date)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error":   "No vendor price found",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    best,
+	})
+}
+
+// ========== RFQ ==========
+
+// GetRFQs godoc
+// @Summary List requests for quotation
+// @Tags Vendor Prices
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "DRAFT, SENT, CLOSED or CANCELLED"
+// @Param vendor_id query int false "Invited vendor ID"
+// @Success 200 {array} models.RequestForQuotation
+// @Router /api/v1/rfqs [get]
+func (c *VendorPriceController) GetRFQs(ctx *gin.Context) {
+	vendorID, _ := strconv.ParseUint(ctx.Query("vendor_id"), 10, 32)
+
+	rfqs, err := c.vendorPriceService.GetRFQs(strings.ToUpper(ctx.Query("status")), uint(vendorID))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to retrieve RFQs",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    rfqs,
+	})
+}
+
+// GetRFQ godoc
+// @Summary Get request for quotation
+// @Tags Vendor Prices
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "RFQ ID"
+// @Success 200 {object} models.RequestForQuotation
+// @Router /api/v1/rfqs/{id} [get]
+func (c *VendorPriceController) GetRFQ(ctx *gin.Context) {
+	id, ok := parseVendorPriceParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	rfq, err := c.vendorPriceService.GetRFQByID(id)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error": "RFQ not found",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    rfq,
+	})
+}
+
+// CreateRFQ godoc
+// @Summary Create request for quotation
+// @Tags Vendor Prices
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.RFQRequest true "RFQ"
+// @Success 201 {object} models.RequestForQuotation
+// @Router /api/v1/rfqs [post]
+func (c *VendorPriceController) CreateRFQ(ctx *gin.Context) {
+	var request models.RFQRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	rfq, err := c.vendorPriceService.CreateRFQ(request, ctx.GetUint("user_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to create RFQ",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "RFQ created successfully",
+		"data":    rfq,
+	})
+}
+
+// UpdateRFQ godoc
+// @Summary Update draft request for quotation
+// @Tags Vendor Prices
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "RFQ ID"
+// @Param request body models.RFQRequest true "RFQ"
+// @Success 200 {object} models.RequestForQuotation
+// @Router /api/v1/rfqs/{id} [put]
+func (c *VendorPriceController) UpdateRFQ(ctx *gin.Context) {
+	id, ok := parseVendorPriceParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	var request models.RFQRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	rfq, err := c.vendorPriceService.UpdateRFQ(id, request, ctx.GetUint("user_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to update RFQ",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "RFQ updated successfully",
+		"data":    rfq,
+	})
+}
+
+// DeleteRFQ godoc
+// @Summary Delete draft request for quotation
+// @Tags Vendor Prices
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "RFQ ID"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/rfqs/{id} [delete]
+func (c *VendorPriceController) DeleteRFQ(ctx *gin.Context) {
+	id, ok := parseVendorPriceParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	if err := c.vendorPriceService.DeleteRFQ(id); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to delete RFQ",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "RFQ deleted successfully",
+	})
+}
+
+// SendRFQ godoc
+// @Summary Mark request for quotation as sent to the vendors
+// @Tags Vendor Prices
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "RFQ ID"
+// @Success 200 {object} models.RequestForQuotation
+// @Router /api/v1/rfqs/{id}/send [post]
+func (c *VendorPriceController) SendRFQ(ctx *gin.Context) {
+	id, ok := parseVendorPriceParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	rfq, err := c.vendorPriceService.SendRFQ(id, ctx.GetUint("user_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to send RFQ",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "RFQ sent",
+		"data":    rfq,
+	})
+}
+
+// RecordReply godoc
+// @Summary Record a vendor's reply to a request for quotation
+// @Description Replaces an earlier reply of the same vendor; mark declined=true when the vendor does not quote
+// @Tags Vendor Prices
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "RFQ ID"
+// @Param vendor_id path int true "Vendor ID"
+// @Param request body models.RFQReplyRequest true "Reply"
+// @Success 200 {object} models.RequestForQuotation
+// @Router /api/v1/rfqs/{id}/vendors/{vendor_id}/reply [post]
+func (c *VendorPriceController) RecordReply(ctx *gin.Context) {
+	id, ok := parseVendorPriceParam(ctx, "id")
+	if !ok {
+		return
+	}
+	vendorID, ok := parseVendorPriceParam(ctx, "vendor_id")
+	if !ok {
+		return
+	}
+
+	var request models.RFQReplyRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	rfq, err := c.vendorPriceService.RecordReply(id, vendorID, request, ctx.GetUint("user_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to record reply",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Vendor reply recorded",
+		"data":    rfq,
+	})
+}
+
+// CompareRFQ godoc
+// @Summary Compare vendor replies side by side
+// @Tags Vendor Prices
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "RFQ ID"
+// @Success 200 {object} models.RFQComparison
+// @Router /api/v1/rfqs/{id}/comparison [get]
+func (c *VendorPriceController) CompareRFQ(ctx *gin.Context) {
+	id, ok := parseVendorPriceParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	comparison, err := c.vendorPriceService.CompareRFQ(id)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error":   "Failed to compare RFQ replies",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    comparison,
+	})
+}
+
+// AwardRFQ godoc
+// @Summary Award a request for quotation to one vendor
+// @Description Closes the RFQ; with save_to_price_list the vendor's quoted prices become price list entries
+// @Tags Vendor Prices
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "RFQ ID"
+// @Param request body models.RFQAwardRequest true "Award"
+// @Success 200 {object} models.RequestForQuotation
+// @Router /api/v1/rfqs/{id}/award [post]
+func (c *VendorPriceController) AwardRFQ(ctx *gin.Context) {
+	id, ok := parseVendorPriceParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	var request models.RFQAwardRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	rfq, err := c.vendorPriceService.AwardRFQ(id, request, ctx.GetUint("user_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to award RFQ",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "RFQ awarded",
+		"data":    rfq,
+	})
+}
+
+// CancelRFQ godoc
+// @Summary Cancel request for quotation
+// @Tags Vendor Prices
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "RFQ ID"
+// @Param request body models.RFQCancelRequest true "Reason"
+// @Success 200 {object} models.RequestForQuotation
+// @Router /api/v1/rfqs/{id}/cancel [post]
+func (c *VendorPriceController) CancelRFQ(ctx *gin.Context) {
+	id, ok := parseVendorPriceParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	var request models.RFQCancelRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	rfq, err := c.vendorPriceService.CancelRFQ(id, request.Reason, ctx.GetUint("user_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to cancel RFQ",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "RFQ cancelled",
+		"data":    rfq,
+	})
+}
+
+func parseVendorPriceParam(ctx *gin.Context, name string) (uint, bool) {
+	id, err := strconv.ParseUint(ctx.Param(name), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid " + name,
+		})
+		return 0, false
+	}
+	return uint(id), true
+}
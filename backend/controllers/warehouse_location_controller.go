@@ -4,7 +4,9 @@ import (
 	"net/http"
 	"strconv"
 
+	"app-sistem-akuntansi/middleware"
 	"app-sistem-akuntansi/models"
+	"app-sistem-akuntansi/repositories"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
@@ -24,6 +26,7 @@ func (wc *WarehouseLocationController) GetWarehouseLocations(c *gin.Context) {
 	var locations []models.WarehouseLocation
 	
 	query := wc.DB.Where("is_active = ?", true)
+	query = repositories.ApplyWarehouseLocationScope(query, middleware.GetDataScope(c, models.DataScopeModuleWarehouseLocations), "id")
 	
 	// Add search functionality
 	if search := c.Query("search"); search != "" {
@@ -49,7 +52,7 @@ func (wc *WarehouseLocationController) GetWarehouseLocation(c *gin.Context) {
 	}
 
 	var location models.WarehouseLocation
-	if err := wc.DB.First(&location, id).Error; err != nil {
+	if err := wc.DB.First(&location, id).Error; err != nil || !middleware.GetDataScope(c, models.DataScopeModuleWarehouseLocations).AllowsWarehouse(location.ID) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Warehouse location not found"})
 		return
 	}
@@ -67,6 +70,12 @@ func (wc *WarehouseLocationController) CreateWarehouseLocation(c *gin.Context) {
 		return
 	}
 
+	// A user limited to some warehouses cannot add locations outside that list
+	if scope := middleware.GetDataScope(c, models.DataScopeModuleWarehouseLocations); scope.IsRestricted() && len(scope.WarehouseIDs) > 0 {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Data scope does not allow creating warehouse locations"})
+		return
+	}
+
 	// Check if location code already exists
 	var existingLocation models.WarehouseLocation
 	if err := wc.DB.Session(&gorm.Session{Logger: logger.Default.LogMode(logger.Silent)}).Where("code = ?", location.Code).First(&existingLocation).Error; err == nil {
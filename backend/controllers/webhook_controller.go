@@ -0,0 +1,382 @@
+package controllers
+
+import (
+	"app-sistem-akuntansi/models"
+	"app-sistem-akuntansi/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WebhookController struct {
+	webhookService *services.WebhookService
+}
+
+func NewWebhookController(webhookService *services.WebhookService) *WebhookController {
+	return &WebhookController{webhookService: webhookService}
+}
+
+// GetEventTypes godoc
+// @Summary List the event types a webhook subscription can filter on
+// @Tags Webhooks
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} string
+// @Router /api/v1/webhooks/event-types [get]
+func (c *WebhookController) GetEventTypes(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    models.WebhookEventTypes,
+	})
+}
+
+// GetSubscriptions godoc
+// @Summary List webhook subscriptions
+// @Tags Webhooks
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.WebhookSubscription
+// @Router /api/v1/webhooks/subscriptions [get]
+func (c *WebhookController) GetSubscriptions(ctx *gin.Context) {
+	subscriptions, err := c.webhookService.GetSubscriptions()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to retrieve webhook subscriptions",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    subscriptions,
+	})
+}
+
+// GetSubscription godoc
+// @Summary Get a webhook subscription
+// @Tags Webhooks
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Subscription ID"
+// @Success 200 {object} models.WebhookSubscription
+// @Router /api/v1/webhooks/subscriptions/{id} [get]
+func (c *WebhookController) GetSubscription(ctx *gin.Context) {
+	id, ok := parseDataScopeParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	subscription, err := c.webhookService.GetSubscription(id)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error":   "Webhook subscription not found",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    subscription,
+	})
+}
+
+// CreateSubscription godoc
+// @Summary Create a webhook subscription
+// @Description The signing secret is generated when omitted and is returned only in this response
+// @Tags Webhooks
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.WebhookSubscriptionRequest true "Name, URL, secret and event filter"
+// @Success 201 {object} services.WebhookSubscriptionWithSecret
+// @Router /api/v1/webhooks/subscriptions [post]
+func (c *WebhookController) CreateSubscription(ctx *gin.Context) {
+	var req models.WebhookSubscriptionRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	subscription, err := c.webhookService.CreateSubscription(req, ctx.GetUint("user_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to create webhook subscription",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    subscription,
+		"message": "Webhook subscription created, store the secret now as it is not shown again",
+	})
+}
+
+// UpdateSubscription godoc
+// @Summary Update a webhook subscription
+// @Description The secret is only replaced when a new one is sent
+// @Tags Webhooks
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Subscription ID"
+// @Param request body models.WebhookSubscriptionRequest true "Name, URL, secret and event filter"
+// @Success 200 {object} models.WebhookSubscription
+// @Router /api/v1/webhooks/subscriptions/{id} [put]
+func (c *WebhookController) UpdateSubscription(ctx *gin.Context) {
+	id, ok := parseDataScopeParam(ctx, "id")
+	if !ok {
+		return
+	}
+	var req models.WebhookSubscriptionRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	subscription, err := c.webhookService.UpdateSubscription(id, req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to update webhook subscription",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    subscription,
+		"message": "Webhook subscription updated",
+	})
+}
+
+// DeleteSubscription godoc
+// @Summary Delete a webhook subscription and cancel its pending deliveries
+// @Tags Webhooks
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Subscription ID"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/webhooks/subscriptions/{id} [delete]
+func (c *WebhookController) DeleteSubscription(ctx *gin.Context) {
+	id, ok := parseDataScopeParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	if err := c.webhookService.DeleteSubscription(id); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to delete webhook subscription",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Webhook subscription deleted",
+	})
+}
+
+// EnableSubscription godoc
+// @Summary Enable a webhook subscription again after it was disabled
+// @Description Resets the consecutive failure counter; queued deliveries resume on the next worker run
+// @Tags Webhooks
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Subscription ID"
+// @Success 200 {object} models.WebhookSubscription
+// @Router /api/v1/webhooks/subscriptions/{id}/enable [post]
+func (c *WebhookController) EnableSubscription(ctx *gin.Context) {
+	id, ok := parseDataScopeParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	subscription, err := c.webhookService.EnableSubscription(id)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to enable webhook subscription",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    subscription,
+		"message": "Webhook subscription enabled",
+	})
+}
+
+// RotateSecret godoc
+// @Summary Replace the signing secret of a webhook subscription
+// @Description The new secret is returned only in this response
+// @Tags Webhooks
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Subscription ID"
+// @Success 200 {object} services.WebhookSubscriptionWithSecret
+// @Router /api/v1/webhooks/subscriptions/{id}/rotate-secret [post]
+func (c *WebhookController) RotateSecret(ctx *gin.Context) {
+	id, ok := parseDataScopeParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	subscription, err := c.webhookService.RotateSecret(id)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to rotate webhook secret",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    subscription,
+		"message": "Webhook secret rotated, store the new secret now as it is not shown again",
+	})
+}
+
+// SendPing godoc
+// @Summary Send a signed webhook.ping event to the endpoint immediately
+// @Tags Webhooks
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Subscription ID"
+// @Success 200 {object} models.WebhookDelivery
+// @Router /api/v1/webhooks/subscriptions/{id}/ping [post]
+func (c *WebhookController) SendPing(ctx *gin.Context) {
+	id, ok := parseDataScopeParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	delivery, err := c.webhookService.SendPing(id)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to send webhook ping",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	message := "Webhook ping delivered"
+	if delivery.Status != models.WebhookDeliverySucceeded {
+		message = "Webhook ping failed, see the delivery attempt for details"
+	}
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": delivery.Status == models.WebhookDeliverySucceeded,
+		"data":    delivery,
+		"message": message,
+	})
+}
+
+// GetDeliveries godoc
+// @Summary List the webhook delivery log
+// @Tags Webhooks
+// @Produce json
+// @Security BearerAuth
+// @Param subscription_id query int false "Subscription ID"
+// @Param event_type query string false "Event type"
+// @Param status query string false "PENDING, SENDING, SUCCEEDED, FAILED or CANCELLED"
+// @Param event_id query int false "Event ID"
+// @Param page query int false "Page"
+// @Param limit query int false "Limit"
+// @Success 200 {object} services.WebhookDeliveryListResult
+// @Router /api/v1/webhooks/deliveries [get]
+func (c *WebhookController) GetDeliveries(ctx *gin.Context) {
+	var filter models.WebhookDeliveryFilter
+	if err := ctx.ShouldBindQuery(&filter); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid query parameters",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	result, err := c.webhookService.GetDeliveries(filter)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to retrieve webhook deliveries",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    result,
+	})
+}
+
+// GetDelivery godoc
+// @Summary Get a webhook delivery with its event payload and every attempt
+// @Tags Webhooks
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Delivery ID"
+// @Success 200 {object} models.WebhookDelivery
+// @Router /api/v1/webhooks/deliveries/{id} [get]
+func (c *WebhookController) GetDelivery(ctx *gin.Context) {
+	id, ok := parseDataScopeParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	delivery, err := c.webhookService.GetDelivery(id)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error":   "Webhook delivery not found",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    delivery,
+	})
+}
+
+// ReplayDelivery godoc
+// @Summary Replay a webhook delivery
+// @Description Queues the same event for the same subscription as a new delivery
+// @Tags Webhooks
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Delivery ID"
+// @Success 202 {object} models.WebhookDelivery
+// @Router /api/v1/webhooks/deliveries/{id}/replay [post]
+func (c *WebhookController) ReplayDelivery(ctx *gin.Context) {
+	id, ok := parseDataScopeParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	delivery, err := c.webhookService.ReplayDelivery(id, ctx.GetUint("user_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to replay webhook delivery",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{
+		"success": true,
+		"data":    delivery,
+		"message": "Webhook delivery queued for replay",
+	})
+}
@@ -0,0 +1,70 @@
+package controllers
+
+import (
+	"app-sistem-akuntansi/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WriteOffSuggestionController struct {
+	writeOffService *services.WriteOffSuggestionService
+}
+
+func NewWriteOffSuggestionController(writeOffService *services.WriteOffSuggestionService) *WriteOffSuggestionController {
+	return &WriteOffSuggestionController{writeOffService: writeOffService}
+}
+
+// GetSuggestions godoc
+// @Summary List receivable write-off suggestions
+// @Tags Write-Off Suggestions
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "Status (PENDING_APPROVAL, APPROVED, REJECTED, WRITTEN_OFF)"
+// @Success 200 {array} models.WriteOffSuggestion
+// @Router /api/v1/write-off-suggestions [get]
+func (c *WriteOffSuggestionController) GetSuggestions(ctx *gin.Context) {
+	suggestions, err := c.writeOffService.GetSuggestions(ctx.Query("status"))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to retrieve write-off suggestions",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    suggestions,
+	})
+}
+
+// SubmitForApproval godoc
+// @Summary Submit a write-off suggestion to its WRITE_OFF approval workflow
+// @Tags Write-Off Suggestions
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Write-off suggestion ID"
+// @Success 200 {object} models.WriteOffSuggestion
+// @Router /api/v1/write-off-suggestions/{id}/submit-approval [post]
+func (c *WriteOffSuggestionController) SubmitForApproval(ctx *gin.Context) {
+	id, ok := parseDataScopeParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	suggestion, err := c.writeOffService.SubmitForApproval(id, ctx.GetUint("user_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to submit write-off suggestion",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    suggestion,
+		"message": "Write-off suggestion submitted for approval",
+	})
+}
@@ -40,6 +40,7 @@ func SeedAccountsImproved(db *gorm.DB) error {
 			{Code: "1114", Name: strings.ToUpper("PPh 21 DIBAYAR DIMUKA"), Type: models.AccountTypeAsset, Category: models.CategoryCurrentAsset, Level: 3, IsHeader: false, IsActive: true, Balance: 0},
 			{Code: "1115", Name: strings.ToUpper("PPh 23 DIBAYAR DIMUKA"), Type: models.AccountTypeAsset, Category: models.CategoryCurrentAsset, Level: 3, IsHeader: false, IsActive: true, Balance: 0},
 			{Code: "1116", Name: strings.ToUpper("POTONGAN PAJAK LAINNYA DIBAYAR DIMUKA"), Type: models.AccountTypeAsset, Category: models.CategoryCurrentAsset, Level: 3, IsHeader: false, IsActive: true, Balance: 0},
+			{Code: "1117", Name: strings.ToUpper("UANG MUKA PEMBELIAN"), Type: models.AccountTypeAsset, Category: models.CategoryCurrentAsset, Level: 3, IsHeader: false, IsActive: true, Balance: 0},
 			{Code: "1240", Name: strings.ToUpper("PPN MASUKAN"), Type: models.AccountTypeAsset, Category: models.CategoryCurrentAsset, Level: 3, IsHeader: false, IsActive: true, Balance: 0},
 			
 			// Inventory
@@ -50,6 +51,7 @@ func SeedAccountsImproved(db *gorm.DB) error {
 			{Code: "1502", Name: strings.ToUpper("KENDARAAN"), Type: models.AccountTypeAsset, Category: models.CategoryFixedAsset, Level: 3, IsHeader: false, IsActive: true, Balance: 0},
 			{Code: "1503", Name: strings.ToUpper("BANGUNAN"), Type: models.AccountTypeAsset, Category: models.CategoryFixedAsset, Level: 3, IsHeader: false, IsActive: true, Balance: 0},
 			{Code: "1509", Name: strings.ToUpper("TRUK"), Type: models.AccountTypeAsset, Category: models.CategoryFixedAsset, Level: 3, IsHeader: false, IsActive: true, Balance: 0},
+			{Code: "1590", Name: strings.ToUpper("AKUMULASI PENYUSUTAN"), Type: models.AccountTypeAsset, Category: models.CategoryFixedAsset, Level: 3, IsHeader: false, IsActive: true, Balance: 0},
 
 			// LIABILITIES (2xxx)
 			{Code: "2000", Name: strings.ToUpper("LIABILITIES"), Type: models.AccountTypeLiability, Category: models.CategoryCurrentLiability, Level: 1, IsHeader: true, IsActive: true},
@@ -57,6 +59,7 @@ func SeedAccountsImproved(db *gorm.DB) error {
 			{Code: "2101", Name: strings.ToUpper("UTANG USAHA"), Type: models.AccountTypeLiability, Category: models.CategoryCurrentLiability, Level: 3, IsHeader: false, IsActive: true, Balance: 0},
 			{Code: "2103", Name: strings.ToUpper("PPN KELUARAN"), Type: models.AccountTypeLiability, Category: models.CategoryCurrentLiability, Level: 3, IsHeader: false, IsActive: true, Balance: 0},
 			{Code: "2104", Name: strings.ToUpper("PPh YANG DIPOTONG"), Type: models.AccountTypeLiability, Category: models.CategoryCurrentLiability, Level: 3, IsHeader: false, IsActive: true, Balance: 0},
+			{Code: "2106", Name: strings.ToUpper("UANG MUKA PELANGGAN"), Type: models.AccountTypeLiability, Category: models.CategoryCurrentLiability, Level: 3, IsHeader: false, IsActive: true, Balance: 0},
 			{Code: "2107", Name: strings.ToUpper("PEMOTONGAN PAJAK LAINNYA"), Type: models.AccountTypeLiability, Category: models.CategoryCurrentLiability, Level: 3, IsHeader: false, IsActive: true, Balance: 0},
 			{Code: "2108", Name: strings.ToUpper("PENAMBAHAN PAJAK LAINNYA"), Type: models.AccountTypeLiability, Category: models.CategoryCurrentLiability, Level: 3, IsHeader: false, IsActive: true, Balance: 0},
 			
@@ -74,6 +77,8 @@ func SeedAccountsImproved(db *gorm.DB) error {
 			{Code: "4101", Name: strings.ToUpper("PENDAPATAN PENJUALAN"), Type: models.AccountTypeRevenue, Category: models.CategoryOperatingRevenue, Level: 2, IsHeader: false, IsActive: true, Balance: 0},
 			{Code: "4102", Name: strings.ToUpper("PENDAPATAN JASA/ONGKIR"), Type: models.AccountTypeRevenue, Category: models.CategoryOperatingRevenue, Level: 2, IsHeader: false, IsActive: true, Balance: 0},
 			{Code: "4201", Name: strings.ToUpper("PENDAPATAN LAIN-LAIN"), Type: models.AccountTypeRevenue, Category: models.CategoryOtherIncome, Level: 2, IsHeader: false, IsActive: true, Balance: 0},
+			{Code: "4202", Name: strings.ToUpper("LABA SELISIH KURS"), Type: models.AccountTypeRevenue, Category: models.CategoryOtherIncome, Level: 2, IsHeader: false, IsActive: true, Balance: 0},
+			{Code: "4203", Name: strings.ToUpper("LABA PELEPASAN ASET TETAP"), Type: models.AccountTypeRevenue, Category: models.CategoryGainOnSale, Level: 2, IsHeader: false, IsActive: true, Balance: 0},
 		{Code: "4900", Name: strings.ToUpper("OTHER INCOME"), Type: models.AccountTypeRevenue, Category: models.CategoryOtherIncome, Level: 2, IsHeader: false, IsActive: true, Balance: 0},
 
 			// EXPENSES (5xxx)
@@ -83,7 +88,11 @@ func SeedAccountsImproved(db *gorm.DB) error {
 			{Code: "5202", Name: strings.ToUpper("BEBAN LISTRIK"), Type: models.AccountTypeExpense, Category: models.CategoryOperatingExpense, Level: 2, IsHeader: false, IsActive: true, Balance: 0},
 			{Code: "5203", Name: strings.ToUpper("BEBAN TELEPON"), Type: models.AccountTypeExpense, Category: models.CategoryOperatingExpense, Level: 2, IsHeader: false, IsActive: true, Balance: 0},
 			{Code: "5204", Name: strings.ToUpper("BEBAN TRANSPORTASI"), Type: models.AccountTypeExpense, Category: models.CategoryOperatingExpense, Level: 2, IsHeader: false, IsActive: true, Balance: 0},
+			{Code: "5205", Name: strings.ToUpper("BEBAN PENYUSUTAN"), Type: models.AccountTypeExpense, Category: models.CategoryDepreciationExp, Level: 2, IsHeader: false, IsActive: true, Balance: 0},
 			{Code: "5900", Name: strings.ToUpper("GENERAL EXPENSE"), Type: models.AccountTypeExpense, Category: models.CategoryOperatingExpense, Level: 2, IsHeader: false, IsActive: true, Balance: 0},
+			{Code: "5902", Name: strings.ToUpper("RUGI SELISIH KURS"), Type: models.AccountTypeExpense, Category: models.CategoryOtherExpense, Level: 2, IsHeader: false, IsActive: true, Balance: 0},
+			{Code: "5903", Name: strings.ToUpper("RUGI PELEPASAN ASET TETAP"), Type: models.AccountTypeExpense, Category: models.CategoryLossOnSale, Level: 2, IsHeader: false, IsActive: true, Balance: 0},
+			{Code: "5904", Name: strings.ToUpper("RUGI PENURUNAN NILAI ASET"), Type: models.AccountTypeExpense, Category: models.CategoryOtherExpense, Level: 2, IsHeader: false, IsActive: true, Balance: 0},
 		}
 
 		// Verify no duplicates in seed data itself
@@ -239,6 +248,7 @@ func setParentRelationships(tx *gorm.DB, accountMap map[string]uint) error {
 		"1114": "1200", // PPh 21 Dibayar Dimuka -> ACCOUNTS RECEIVABLE
 		"1115": "1200", // PPh 23 Dibayar Dimuka -> ACCOUNTS RECEIVABLE
 		"1116": "1200", // Potongan Pajak Lainnya Dibayar Dimuka -> ACCOUNTS RECEIVABLE
+		"1117": "1100", // Uang Muka Pembelian -> CURRENT ASSETS
 		"1240": "1100", // PPN Masukan -> CURRENT ASSETS
 		"1301": "1100", // Persediaan Barang Dagangan -> CURRENT ASSETS
 		"1500": "1000", // FIXED ASSETS -> ASSETS
@@ -246,10 +256,12 @@ func setParentRelationships(tx *gorm.DB, accountMap map[string]uint) error {
 		"1502": "1500", // Kendaraan -> FIXED ASSETS
 		"1503": "1500", // Bangunan -> FIXED ASSETS
 		"1509": "1500", // TRUK -> FIXED ASSETS
+		"1590": "1500", // Akumulasi Penyusutan -> FIXED ASSETS
 		"2100": "2000", // CURRENT LIABILITIES -> LIABILITIES
 		"2101": "2100", // Utang Usaha -> CURRENT LIABILITIES
 		"2103": "2100", // PPN Keluaran -> CURRENT LIABILITIES
 		"2104": "2100", // PPh Yang Dipotong -> CURRENT LIABILITIES
+		"2106": "2100", // Uang Muka Pelanggan -> CURRENT LIABILITIES
 		"2107": "2100", // Pemotongan Pajak Lainnya -> CURRENT LIABILITIES
 		"2108": "2100", // Penambahan Pajak Lainnya -> CURRENT LIABILITIES
 		"2111": "2100", // Utang PPh 21 -> CURRENT LIABILITIES
@@ -259,12 +271,16 @@ func setParentRelationships(tx *gorm.DB, accountMap map[string]uint) error {
 		"4101": "4000", // Pendapatan Penjualan -> REVENUE
 		"4102": "4000", // Pendapatan Jasa/Ongkir -> REVENUE
 		"4201": "4000", // Pendapatan Lain-lain -> REVENUE
+		"4203": "4000", // Laba Pelepasan Aset Tetap -> REVENUE
 		"4900": "4000", // Other Income -> REVENUE
 		"5101": "5000", // Harga Pokok Penjualan -> EXPENSES
 		"5201": "5000", // Beban Gaji -> EXPENSES
 		"5202": "5000", // Beban Listrik -> EXPENSES
 		"5203": "5000", // Beban Telepon -> EXPENSES
 		"5204": "5000", // Beban Transportasi -> EXPENSES
+		"5205": "5000", // Beban Penyusutan -> EXPENSES
+		"5903": "5000", // Rugi Pelepasan Aset Tetap -> EXPENSES
+		"5904": "5000", // Rugi Penurunan Nilai Aset -> EXPENSES
 		"5900": "5000", // General Expense -> EXPENSES
 	}
 
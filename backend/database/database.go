@@ -638,6 +638,10 @@ func AutoMigrate(db *gorm.DB) {
 		&models.ProductUnit{},
 		&models.WarehouseLocation{},
 		&models.Inventory{},
+		&models.SaleCostLayer{},
+		&models.WarehouseStock{},
+		&models.StockTransfer{},
+		&models.StockTransferItem{},
 		
 		// Sales
 		&models.Sale{},
@@ -645,10 +649,15 @@ func AutoMigrate(db *gorm.DB) {
 		&models.SalePayment{},
 		&models.SaleReturn{},
 		&models.SaleReturnItem{},
+		&models.DeliveryOrder{},
+		&models.DeliveryOrderItem{},
 		
 		// Invoices
 		&models.Invoice{},
 		&models.InvoiceItem{},
+		&models.Quote{},
+		&models.QuoteItem{},
+		&models.QuoteRevision{},
 		
 		// Purchases
 		&models.Purchase{},
@@ -656,6 +665,18 @@ func AutoMigrate(db *gorm.DB) {
 		&models.PurchaseDocument{},
 		&models.PurchaseReceipt{},
 		&models.PurchaseReceiptItem{},
+		&models.PurchaseReturn{},
+		&models.PurchaseReturnItem{},
+		&models.VendorCredit{},
+		&models.VendorCreditApplication{},
+		&models.PurchaseRequisition{},
+		&models.PurchaseRequisitionItem{},
+		&models.PurchaseRequisitionLink{},
+		&models.VendorPriceList{},
+		&models.RequestForQuotation{},
+		&models.RFQItem{},
+		&models.RFQVendor{},
+		&models.RFQReply{},
 		
 		// Expenses
 		&models.ExpenseCategory{},
@@ -664,17 +685,40 @@ func AutoMigrate(db *gorm.DB) {
 		// Assets
 		&models.AssetCategory{},
 		&models.Asset{},
+		&models.AssetDepreciationRun{},
+		&models.AssetDepreciationEntry{},
+		&models.AssetDisposal{},
+		
+		// Customer credit control
+		&models.CreditHoldLog{},
+		
+		// Analytic dimensions (cost centers, projects, tags)
+		&models.Dimension{},
+		&models.DimensionTag{},
+		&models.DimensionRule{},
+		
+		// Tax invoices (e-Faktur)
+		&models.TaxInvoiceNumberRange{},
+		&models.TaxInvoice{},
 		
 		// Cash & Bank
 		&models.CashBank{},
 		&models.CashBankTransaction{},
+		&models.BankStatement{},
+		&models.BankStatementLine{},
+		&models.BankStatementColumnMapping{},
 		&models.Payment{},
 		&models.PaymentAllocation{},
+		&models.AdvancePayment{},
+		&models.AdvancePaymentApplication{},
 		
 		// Journals and reports
 		&models.Journal{},
 		&models.JournalEntry{},
 		&models.JournalLine{},
+		&models.RecurringTemplate{},
+		&models.RecurringTemplateLine{},
+		&models.RecurringRun{},
 
 		// Note: SSOT Journal tables are ensured separately to avoid GORM dropping constraints on existing DBs
 		// See EnsureSSOTTables for safe creation when missing
@@ -712,6 +756,20 @@ func AutoMigrate(db *gorm.DB) {
 		&models.BlacklistedToken{},
 		&models.RateLimitRecord{},
 		&models.AuthAttempt{},
+		&models.UserTwoFactor{},
+		&models.TwoFactorRecoveryCode{},
+		&models.TwoFactorChallenge{},
+		&models.TrustedDevice{},
+		&models.DataScope{},
+		&models.ApprovalDelegation{},
+		&models.EmailTemplate{},
+		&models.EmailMessage{},
+		&models.EmailAttachment{},
+		&models.WebhookSubscription{},
+		&models.WebhookEvent{},
+		&models.WebhookDelivery{},
+		&models.WebhookDeliveryAttempt{},
+		&models.WebhookCursor{},
 		
 		// CashBank Migration Models
 		&models.CashBankTransferMigration{},
@@ -724,6 +782,11 @@ func AutoMigrate(db *gorm.DB) {
 		// Settings model
 		&models.Settings{},
 		
+		// Multi-currency models
+		&models.ExchangeRate{},
+		&models.FXRevaluation{},
+		&models.FXRevaluationItem{},
+		
 		// Accounting Period model
 		&models.AccountingPeriod{},
 		
@@ -894,6 +957,18 @@ func EnsureSSOTTables(db *gorm.DB) {
 		);
 	`)
 
+	// Foreign currency columns on unified_journal_lines (debit/credit stay in base currency)
+	db.Exec(`ALTER TABLE unified_journal_lines ADD COLUMN IF NOT EXISTS currency_code VARCHAR(5) NOT NULL DEFAULT 'IDR'`)
+	db.Exec(`ALTER TABLE unified_journal_lines ADD COLUMN IF NOT EXISTS foreign_debit_amount DECIMAL(20,2) NOT NULL DEFAULT 0`)
+	db.Exec(`ALTER TABLE unified_journal_lines ADD COLUMN IF NOT EXISTS foreign_credit_amount DECIMAL(20,2) NOT NULL DEFAULT 0`)
+	db.Exec(`ALTER TABLE unified_journal_lines ADD COLUMN IF NOT EXISTS exchange_rate DECIMAL(18,6) NOT NULL DEFAULT 1`)
+
+	// Analytic dimension columns on unified_journal_lines (tags live in dimension_tags)
+	db.Exec(`ALTER TABLE unified_journal_lines ADD COLUMN IF NOT EXISTS cost_center_id BIGINT`)
+	db.Exec(`ALTER TABLE unified_journal_lines ADD COLUMN IF NOT EXISTS project_id BIGINT`)
+	db.Exec(`CREATE INDEX IF NOT EXISTS idx_unified_journal_lines_cost_center_id ON unified_journal_lines(cost_center_id)`)
+	db.Exec(`CREATE INDEX IF NOT EXISTS idx_unified_journal_lines_project_id ON unified_journal_lines(project_id)`)
+
 	// journal_event_log (without DB-side uuid default)
 	db.Exec(`
 		CREATE TABLE IF NOT EXISTS journal_event_log (
@@ -918,6 +993,7 @@ func EnsureSSOTTables(db *gorm.DB) {
 	db.Exec(`CREATE INDEX IF NOT EXISTS idx_journal_date_status ON unified_journal_ledger(entry_date, status)`)
 	db.Exec(`CREATE INDEX IF NOT EXISTS idx_journal_lines_journal ON unified_journal_lines(journal_id)`)
 	db.Exec(`CREATE INDEX IF NOT EXISTS idx_journal_lines_account ON unified_journal_lines(account_id, journal_id)`)
+	db.Exec(`CREATE INDEX IF NOT EXISTS idx_journal_lines_currency ON unified_journal_lines(currency_code) WHERE currency_code <> 'IDR'`)
 	log.Println("SSOT core tables ensured.")
 }
 
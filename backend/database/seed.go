@@ -59,6 +59,10 @@ func SeedData(db *gorm.DB) {
 	// Seed default approval workflows for PURCHASE module
 	seedApprovalWorkflows(db)
 
+	// Seed default approval workflow for annual budgets
+	seedBudgetApprovalWorkflow(db)
+	seedRequisitionApprovalWorkflow(db)
+
 	// REMOVED: Sample sales and purchases seeding to use only real data
 	// No dummy data will be generated for sales, purchases, or transactions
 	// Dashboard will display only actual business data
@@ -683,6 +687,63 @@ func seedApprovalWorkflows(db *gorm.DB) {
 	db.Create(&stepC2)
 }
 
+func seedBudgetApprovalWorkflow(db *gorm.DB) {
+	var count int64
+	db.Model(&models.ApprovalWorkflow{}).Where("module = ?", models.ApprovalModuleBudget).Count(&count)
+	if count > 0 {
+		return
+	}
+
+	// Every budget goes Finance -> Director regardless of amount
+	wf := models.ApprovalWorkflow{
+		Name:            "Annual Budget Approval",
+		Module:          models.ApprovalModuleBudget,
+		MinAmount:       0,
+		MaxAmount:       0, // no upper bound
+		IsActive:        true,
+		RequireFinance:  true,
+		RequireDirector: true,
+	}
+	db.Create(&wf)
+	step1 := models.ApprovalStep{WorkflowID: wf.ID, StepOrder: 1, StepName: "Finance Review", ApproverRole: "finance"}
+	step2 := models.ApprovalStep{WorkflowID: wf.ID, StepOrder: 2, StepName: "Director Approval", ApproverRole: "director"}
+	db.Create(&step1)
+	db.Create(&step2)
+}
+
+func seedRequisitionApprovalWorkflow(db *gorm.DB) {
+	var count int64
+	db.Model(&models.ApprovalWorkflow{}).Where("module = ?", models.ApprovalModuleRequisition).Count(&count)
+	if count > 0 {
+		return
+	}
+
+	// Finance reviews every requisition; large ones also need the director
+	wfA := models.ApprovalWorkflow{
+		Name:           "Purchase Requisition - Standard",
+		Module:         models.ApprovalModuleRequisition,
+		MinAmount:      0,
+		MaxAmount:      25000000,
+		IsActive:       true,
+		RequireFinance: true,
+	}
+	db.Create(&wfA)
+	db.Create(&models.ApprovalStep{WorkflowID: wfA.ID, StepOrder: 1, StepName: "Finance Review", ApproverRole: "finance"})
+
+	wfB := models.ApprovalWorkflow{
+		Name:            "Purchase Requisition - Large",
+		Module:          models.ApprovalModuleRequisition,
+		MinAmount:       25000000.01,
+		MaxAmount:       0, // no upper bound
+		IsActive:        true,
+		RequireFinance:  true,
+		RequireDirector: true,
+	}
+	db.Create(&wfB)
+	db.Create(&models.ApprovalStep{WorkflowID: wfB.ID, StepOrder: 1, StepName: "Finance Review", ApproverRole: "finance"})
+	db.Create(&models.ApprovalStep{WorkflowID: wfB.ID, StepOrder: 2, StepName: "Director Approval", ApproverRole: "director"})
+}
+
 // REMOVED: seedSampleSales creates sample sales data for dashboard analytics
 // This function is disabled to prevent dummy data generation
 /*
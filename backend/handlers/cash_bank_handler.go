@@ -4,6 +4,8 @@ import (
 	"net/http"
 	"strconv"
 
+	"app-sistem-akuntansi/middleware"
+	"app-sistem-akuntansi/models"
 	"app-sistem-akuntansi/services"
 	"app-sistem-akuntansi/utils"
 	"github.com/gin-gonic/gin"
@@ -23,7 +25,7 @@ func NewCashBankHandler(cashBankService *services.CashBankService, accountServic
 
 // GetCashBankAccounts handles GET /api/cash-bank/accounts
 func (h *CashBankHandler) GetCashBankAccounts(c *gin.Context) {
-	accounts, err := h.cashBankService.GetCashBankAccounts()
+	accounts, err := h.cashBankService.GetCashBankAccountsInScope(middleware.GetDataScope(c, models.DataScopeModuleCashBank))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, utils.CreateErrorResponse("Failed to get cash-bank accounts", err))
 		return
@@ -41,7 +43,7 @@ func (h *CashBankHandler) GetCashBankByID(c *gin.Context) {
 	}
 
 	account, err := h.cashBankService.GetCashBankByID(uint(id))
-	if err != nil {
+	if err != nil || !middleware.GetDataScope(c, models.DataScopeModuleCashBank).AllowsCashBank(account) {
 		c.JSON(http.StatusNotFound, utils.CreateErrorResponse("Cash-bank account not found", err))
 		return
 	}
@@ -120,6 +122,10 @@ func (h *CashBankHandler) ProcessDeposit(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, utils.CreateErrorResponse("Invalid request data", err))
 		return
 	}
+	if !h.cashBankService.AccountsInScope(middleware.GetDataScope(c, models.DataScopeModuleCashBank), request.AccountID) {
+		c.JSON(http.StatusNotFound, utils.CreateErrorResponse("Cash-bank account not found", nil))
+		return
+	}
 
 	// Get user ID from context
 	userID, exists := c.Get("user_id")
@@ -149,6 +155,10 @@ func (h *CashBankHandler) ProcessWithdrawal(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, utils.CreateErrorResponse("Invalid request data", err))
 		return
 	}
+	if !h.cashBankService.AccountsInScope(middleware.GetDataScope(c, models.DataScopeModuleCashBank), request.AccountID) {
+		c.JSON(http.StatusNotFound, utils.CreateErrorResponse("Cash-bank account not found", nil))
+		return
+	}
 
 	// Get user ID from context
 	userID, exists := c.Get("user_id")
@@ -178,6 +188,10 @@ func (h *CashBankHandler) ProcessTransfer(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, utils.CreateErrorResponse("Invalid request data", err))
 		return
 	}
+	if !h.cashBankService.AccountsInScope(middleware.GetDataScope(c, models.DataScopeModuleCashBank), request.FromAccountID, request.ToAccountID) {
+		c.JSON(http.StatusNotFound, utils.CreateErrorResponse("Cash-bank account not found", nil))
+		return
+	}
 
 	// Get user ID from context
 	userID, exists := c.Get("user_id")
@@ -208,6 +222,14 @@ func (h *CashBankHandler) GetTransactions(c *gin.Context) {
 		return
 	}
 
+	if scope := middleware.GetDataScope(c, models.DataScopeModuleCashBank); scope.IsRestricted() {
+		account, err := h.cashBankService.GetCashBankByID(uint(accountID))
+		if err != nil || !scope.AllowsCashBank(account) {
+			c.JSON(http.StatusNotFound, utils.CreateErrorResponse("Cash-bank account not found", err))
+			return
+		}
+	}
+
 	// Parse query parameters for filtering
 	filter := services.TransactionFilter{
 		Type:  c.Query("type"),
@@ -251,7 +273,7 @@ func (h *CashBankHandler) GetBalanceSummary(c *gin.Context) {
 
 // GetPaymentAccounts handles GET /api/cash-bank/payment-accounts
 func (h *CashBankHandler) GetPaymentAccounts(c *gin.Context) {
-	accounts, err := h.cashBankService.GetPaymentAccounts()
+	accounts, err := h.cashBankService.GetPaymentAccountsInScope(middleware.GetDataScope(c, models.DataScopeModuleCashBank))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, utils.CreateErrorResponse("Failed to retrieve payment accounts", err))
 		return
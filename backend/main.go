@@ -7,6 +7,7 @@ import (
 
 	"app-sistem-akuntansi/config"
 	"app-sistem-akuntansi/database"
+	"app-sistem-akuntansi/repositories"
 	"app-sistem-akuntansi/routes"
 	"app-sistem-akuntansi/services"
 	"app-sistem-akuntansi/startup"
@@ -104,6 +105,22 @@ func main() {
 	// Run startup tasks including fix account header status
 	startupService := services.NewStartupService(db)
 	startupService.RunStartupTasks()
+
+	// Start recurring journal scheduler (catches up dates missed while the server was down)
+	recurringJournalService := services.NewRecurringJournalService(db)
+	go recurringJournalService.StartScheduler()
+
+	// Start quote expiry job (marks sent quotes past their validity date as EXPIRED)
+	quoteService := services.NewQuoteServiceFull(db, repositories.NewContactRepository(db), repositories.NewProductRepository(db))
+	go quoteService.StartExpiryScheduler()
+
+	// Start outbound email worker (delivers the email queue and emails new notifications)
+	emailService := services.NewEmailService(db)
+	go emailService.StartQueueWorker()
+
+	// Start webhook worker (turns accounting events into signed webhook deliveries and retries failures)
+	webhookService := services.NewWebhookService(db)
+	go webhookService.StartWorker()
 	
 	// 🚀 Run database optimization for better performance
 	log.Println("⚡ Starting database performance optimization...")
@@ -0,0 +1,132 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+
+	"app-sistem-akuntansi/models"
+	"app-sistem-akuntansi/services"
+	"github.com/gin-gonic/gin"
+)
+
+// DataScopeMiddleware resolves which records of a module the signed-in user may see and puts the
+// result in the request context. It is chained right after the module permission check
+// (RequirePermission / CanView); list endpoints and repositories read it with GetDataScope, write
+// endpoints use RequireRecord.
+type DataScopeMiddleware struct {
+	scopeService *services.DataScopeService
+	cache        *PermissionCache
+}
+
+// NewDataScopeMiddleware creates a new data scope middleware; scopes are cached per user in cache
+func NewDataScopeMiddleware(scopeService *services.DataScopeService, cache *PermissionCache) *DataScopeMiddleware {
+	return &DataScopeMiddleware{
+		scopeService: scopeService,
+		cache:        cache,
+	}
+}
+
+// Apply resolves the data scope of the user for the module
+func (dm *DataScopeMiddleware) Apply(module string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := dm.resolve(c, module); !ok {
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireRecord resolves the data scope like Apply and also rejects the request when the record in
+// the path parameter lies outside it. Write endpoints (update, delete, confirm, payments) use it so a
+// restricted user cannot act on records the listings hide; the answer is the same 404 as a GET.
+func (dm *DataScopeMiddleware) RequireRecord(module, param string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		scope, ok := dm.resolve(c, module)
+		if !ok {
+			return
+		}
+		if !scope.IsRestricted() {
+			c.Next()
+			return
+		}
+
+		recordID, err := strconv.ParseUint(c.Param(param), 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + param})
+			c.Abort()
+			return
+		}
+		allowed, err := dm.scopeService.RecordInScope(scope, module, uint(recordID))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Error checking data scope",
+				"code":  "DATA_SCOPE_CHECK_ERROR",
+			})
+			c.Abort()
+			return
+		}
+		if !allowed {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Record not found",
+				"code":  "DATA_SCOPE_RESTRICTED",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// resolve puts the user's scope for the module in the request context; it aborts the request and
+// returns false when the scope cannot be determined
+func (dm *DataScopeMiddleware) resolve(c *gin.Context, module string) (*models.EffectiveDataScope, bool) {
+	userID := c.GetUint("user_id")
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		c.Abort()
+		return nil, false
+	}
+
+	scope, found := dm.cache.GetScope(userID, module)
+	if !found {
+		resolved, err := dm.scopeService.ResolveScope(userID, c.GetString("role"), module)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Error checking data scope",
+				"code":  "DATA_SCOPE_CHECK_ERROR",
+			})
+			c.Abort()
+			return nil, false
+		}
+		scope = &resolved
+		dm.cache.SetScope(userID, module, scope)
+	}
+
+	c.Set(dataScopeContextKey(module), scope)
+	return scope, true
+}
+
+// Invalidate drops cached scopes after a scope changed; role scopes touch every user of the role
+func (dm *DataScopeMiddleware) Invalidate(scope *models.DataScope) {
+	if scope != nil && scope.UserID != nil {
+		dm.cache.InvalidateUser(*scope.UserID)
+		return
+	}
+	dm.cache.Clear()
+}
+
+// GetDataScope returns the data scope resolved by DataScopeMiddleware.Apply, nil when the route
+// is not scoped (which means unrestricted)
+func GetDataScope(c *gin.Context, module string) *models.EffectiveDataScope {
+	if v, ok := c.Get(dataScopeContextKey(module)); ok {
+		if scope, ok := v.(*models.EffectiveDataScope); ok {
+			return scope
+		}
+	}
+	return nil
+}
+
+func dataScopeContextKey(module string) string {
+	return "data_scope:" + module
+}
@@ -0,0 +1,160 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"app-sistem-akuntansi/models"
+	"app-sistem-akuntansi/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const scopedUserID uint = 7
+
+// setupDataScopeTestDB creates the columns the scope checks read; the full models need Postgres
+func setupDataScopeTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1) // Every connection to :memory: is a separate database
+
+	statements := []string{
+		"CREATE TABLE sales (id INTEGER PRIMARY KEY, user_id INTEGER, sales_person_id INTEGER, total_amount REAL, deleted_at DATETIME)",
+		"CREATE TABLE purchases (id INTEGER PRIMARY KEY, user_id INTEGER, total_amount REAL, deleted_at DATETIME)",
+		"CREATE TABLE cash_banks (id INTEGER PRIMARY KEY, user_id INTEGER, deleted_at DATETIME)",
+		"INSERT INTO sales (id, user_id, sales_person_id, total_amount) VALUES (1, 7, 20, 1000000), (2, 8, 20, 1000000), (3, 7, 21, 1000000), (4, 7, 20, 9000000)",
+		"INSERT INTO purchases (id, user_id, total_amount) VALUES (1, 7, 500000), (2, 8, 500000)",
+		"INSERT INTO cash_banks (id, user_id) VALUES (1, 7), (2, 7)",
+	}
+	for _, statement := range statements {
+		require.NoError(t, db.Exec(statement).Error)
+	}
+	return db
+}
+
+func TestDataScopeRequireRecord(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := setupDataScopeTestDB(t)
+
+	restricted := map[string]*models.EffectiveDataScope{
+		models.DataScopeModuleSales: {
+			UserID: scopedUserID, Module: models.DataScopeModuleSales, Source: models.DataScopeSourceUser,
+			OwnRecordsOnly: true, SalesPersonIDs: []uint{20}, MaxAmount: 5000000,
+		},
+		models.DataScopeModulePurchases: {
+			UserID: scopedUserID, Module: models.DataScopeModulePurchases, Source: models.DataScopeSourceUser,
+			OwnRecordsOnly: true,
+		},
+		models.DataScopeModuleCashBank: {
+			UserID: scopedUserID, Module: models.DataScopeModuleCashBank, Source: models.DataScopeSourceUser,
+			CashBankIDs: []uint{1},
+		},
+		models.DataScopeModuleWarehouseLocations: {
+			UserID: scopedUserID, Module: models.DataScopeModuleWarehouseLocations, Source: models.DataScopeSourceUser,
+			WarehouseIDs: []uint{1, 3},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		module     string
+		scope      *models.EffectiveDataScope
+		path       string
+		wantStatus int
+	}{
+		{name: "own sale of an allowed sales person", module: models.DataScopeModuleSales, path: "/1", wantStatus: http.StatusOK},
+		{name: "sale created by another user", module: models.DataScopeModuleSales, path: "/2", wantStatus: http.StatusNotFound},
+		{name: "sale of another sales person", module: models.DataScopeModuleSales, path: "/3", wantStatus: http.StatusNotFound},
+		{name: "sale above the amount ceiling", module: models.DataScopeModuleSales, path: "/4", wantStatus: http.StatusNotFound},
+		{name: "missing sale is left to the handler", module: models.DataScopeModuleSales, path: "/99", wantStatus: http.StatusOK},
+		{name: "invalid id", module: models.DataScopeModuleSales, path: "/abc", wantStatus: http.StatusBadRequest},
+		{name: "own purchase", module: models.DataScopeModulePurchases, path: "/1", wantStatus: http.StatusOK},
+		{name: "purchase created by another user", module: models.DataScopeModulePurchases, path: "/2", wantStatus: http.StatusNotFound},
+		{name: "allowed cash bank account", module: models.DataScopeModuleCashBank, path: "/1", wantStatus: http.StatusOK},
+		{name: "cash bank account outside the list", module: models.DataScopeModuleCashBank, path: "/2", wantStatus: http.StatusNotFound},
+		{name: "allowed warehouse location", module: models.DataScopeModuleWarehouseLocations, path: "/3", wantStatus: http.StatusOK},
+		{name: "warehouse location outside the list", module: models.DataScopeModuleWarehouseLocations, path: "/2", wantStatus: http.StatusNotFound},
+		{
+			name:       "unrestricted scope skips the record check",
+			module:     models.DataScopeModuleSales,
+			scope:      &models.EffectiveDataScope{UserID: scopedUserID, Module: models.DataScopeModuleSales, Unrestricted: true},
+			path:       "/2",
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scope := tt.scope
+			if scope == nil {
+				scope = restricted[tt.module]
+			}
+			cache := NewPermissionCache(time.Minute)
+			cache.SetScope(scopedUserID, tt.module, scope)
+			dataScope := NewDataScopeMiddleware(services.NewDataScopeService(db), cache)
+
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				c.Set("user_id", scopedUserID)
+				c.Set("role", "employee")
+			})
+			router.PUT("/records/:id", dataScope.RequireRecord(tt.module, "id"), func(c *gin.Context) {
+				assert.Same(t, scope, GetDataScope(c, tt.module))
+				c.Status(http.StatusOK)
+			})
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/records"+tt.path, nil))
+			assert.Equal(t, tt.wantStatus, w.Code, w.Body.String())
+		})
+	}
+}
+
+func TestDataScopeSharesPermissionCache(t *testing.T) {
+	permissions := &PermissionMiddleware{cache: NewPermissionCache(time.Minute)}
+	dataScope := NewDataScopeMiddleware(nil, permissions.Cache())
+
+	scope := &models.EffectiveDataScope{UserID: scopedUserID, Module: models.DataScopeModuleSales, OwnRecordsOnly: true}
+	permissions.Cache().SetScope(scopedUserID, models.DataScopeModuleSales, scope)
+	permissions.Cache().Set(scopedUserID, "sales", "view", true)
+
+	userID := scopedUserID
+	dataScope.Invalidate(&models.DataScope{UserID: &userID})
+
+	_, found := permissions.Cache().GetScope(scopedUserID, models.DataScopeModuleSales)
+	assert.False(t, found)
+	_, found = permissions.Cache().Get(scopedUserID, "sales", "view")
+	assert.False(t, found, "changing a scope drops the user's cached permissions from the same cache")
+}
+
+func TestDataScopeAllowsStockTransfer(t *testing.T) {
+	scope := &models.EffectiveDataScope{UserID: scopedUserID, Module: models.DataScopeModuleWarehouseLocations, WarehouseIDs: []uint{1, 3}}
+
+	tests := []struct {
+		name     string
+		scope    *models.EffectiveDataScope
+		from, to uint
+		want     bool
+	}{
+		{name: "both warehouses allowed", scope: scope, from: 1, to: 3, want: true},
+		{name: "source outside the list", scope: scope, from: 2, to: 3, want: false},
+		{name: "destination outside the list", scope: scope, from: 1, to: 2, want: false},
+		{name: "no scope", from: 2, to: 4, want: true},
+		{name: "unrestricted scope", scope: &models.EffectiveDataScope{Unrestricted: true, WarehouseIDs: []uint{1}}, from: 2, to: 4, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.scope.AllowsStockTransfer(tt.from, tt.to))
+		})
+	}
+}
@@ -143,6 +143,7 @@ func (jm *JWTManager) GenerateTokenPair(user models.User, deviceInfo, ipAddress
 		ExpiresIn:    int64(90 * 60), // 90 minutes in seconds
 		ExpiresAt:    accessTokenExpiry,
 		User:         user,
+		SessionID:    sessionID,
 	}, nil
 }
 
@@ -55,6 +55,7 @@ func (pvm *PeriodValidationMiddleware) ValidateTransactionPeriod() gin.HandlerFu
 			"sale_date",      // Sales
 			"purchase_date",  // Purchases
 			"payment_date",   // Payments
+			"return_date",    // Sales and purchase returns
 			"date",          // Generic date field
 			"transaction_date", // Alternative naming
 		}
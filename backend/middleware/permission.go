@@ -22,6 +22,12 @@ func NewPermissionMiddleware(db *gorm.DB) *PermissionMiddleware {
 	}
 }
 
+// Cache returns the permission cache so related middleware (data scopes) can share it and be
+// invalidated together with the permissions
+func (pm *PermissionMiddleware) Cache() *PermissionCache {
+	return pm.cache
+}
+
 // CheckModulePermission checks if user has specific permission for a module
 func (pm *PermissionMiddleware) CheckModulePermission(module string, action string) gin.HandlerFunc {
 	return func(c *gin.Context) {
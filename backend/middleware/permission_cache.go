@@ -4,11 +4,14 @@ import (
 	"fmt"
 	"sync"
 	"time"
+
+	"app-sistem-akuntansi/models"
 )
 
 // PermissionCacheEntry stores cached permission result
 type PermissionCacheEntry struct {
 	HasPermission bool
+	Scope         *models.EffectiveDataScope // Set for data scope entries
 	ExpiresAt     time.Time
 }
 
@@ -59,6 +62,30 @@ func (pc *PermissionCache) Set(userID uint, module, action string, hasPermission
 	}
 }
 
+// GetScope retrieves the cached data scope of a user in a module
+func (pc *PermissionCache) GetScope(userID uint, module string) (*models.EffectiveDataScope, bool) {
+	pc.mu.RLock()
+	defer pc.mu.RUnlock()
+
+	entry, exists := pc.cache[pc.scopeCacheKey(userID, module)]
+	if !exists || entry.Scope == nil || time.Now().After(entry.ExpiresAt) {
+		return nil, false
+	}
+
+	return entry.Scope, true
+}
+
+// SetScope stores the data scope of a user in a module
+func (pc *PermissionCache) SetScope(userID uint, module string, scope *models.EffectiveDataScope) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	pc.cache[pc.scopeCacheKey(userID, module)] = PermissionCacheEntry{
+		Scope:     scope,
+		ExpiresAt: time.Now().Add(pc.ttl),
+	}
+}
+
 // InvalidateUser clears all cached permissions for a user
 func (pc *PermissionCache) InvalidateUser(userID uint) {
 	pc.mu.Lock()
@@ -85,6 +112,12 @@ func (pc *PermissionCache) cacheKey(userID uint, module, action string) string {
 	return fmt.Sprintf("user:%d:module:%s:action:%s", userID, module, action)
 }
 
+// scopeCacheKey generates the cache key of a data scope; it shares the user prefix so
+// InvalidateUser drops it together with the permissions
+func (pc *PermissionCache) scopeCacheKey(userID uint, module string) string {
+	return fmt.Sprintf("user:%d:module:%s:scope", userID, module)
+}
+
 // cleanupExpired removes expired entries periodically
 func (pc *PermissionCache) cleanupExpired() {
 	ticker := time.NewTicker(5 * time.Minute)
@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+
+	"app-sistem-akuntansi/services"
+	"github.com/gin-gonic/gin"
+)
+
+// StepUpMiddleware asks for a fresh two-factor code before high-risk actions
+type StepUpMiddleware struct {
+	twoFactorService *services.TwoFactorService
+}
+
+// NewStepUpMiddleware creates a new step-up middleware
+func NewStepUpMiddleware(twoFactorService *services.TwoFactorService) *StepUpMiddleware {
+	return &StepUpMiddleware{
+		twoFactorService: twoFactorService,
+	}
+}
+
+// Required lets the request through only when the session confirmed a two-factor code recently.
+// Users of roles where 2FA is optional and who never enrolled are not challenged.
+func (sm *StepUpMiddleware) Required() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		err := sm.twoFactorService.CheckStepUp(c.GetUint("user_id"), c.GetString("role"), c.GetString("session_id"))
+		if err == nil {
+			c.Next()
+			return
+		}
+
+		code := "STEP_UP_REQUIRED"
+		message := "Verify with POST /api/v1/two-factor/step-up and retry the request"
+		if errors.Is(err, services.ErrTwoFactorSetupRequired) {
+			code = "TWO_FACTOR_SETUP_REQUIRED"
+			message = "Enrol an authenticator app via /api/v1/two-factor/enroll first"
+		} else if !errors.Is(err, services.ErrStepUpRequired) {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Failed to check two-factor verification",
+				"details": err.Error(),
+			})
+			c.Abort()
+			return
+		}
+
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":   err.Error(),
+			"code":    code,
+			"message": message,
+		})
+		c.Abort()
+	}
+}
@@ -0,0 +1,118 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// AdvancePayment is a down payment received from a customer or a prepayment made to a vendor
+// before the invoice exists. The gross amount includes PPN; the DPP part sits on the customer
+// deposit (2106) or vendor advance (1117) account until it is applied to invoices.
+type AdvancePayment struct {
+	ID              uint           `json:"id" gorm:"primaryKey"`
+	Code            string         `json:"code" gorm:"unique;not null;size:30"`
+	Type            string         `json:"type" gorm:"size:20;not null;index"` // CUSTOMER, VENDOR
+	ContactID       uint           `json:"contact_id" gorm:"not null;index"`
+	Date            time.Time      `json:"date"`
+	CashBankID      uint           `json:"cash_bank_id" gorm:"not null;index"`
+	Amount          float64        `json:"amount" gorm:"type:decimal(15,2);default:0"` // Gross, PPN included
+	PPNRate         float64        `json:"ppn_rate" gorm:"type:decimal(5,2);default:0"`
+	PPNAmount       float64        `json:"ppn_amount" gorm:"type:decimal(15,2);default:0"`
+	NetAmount       float64        `json:"net_amount" gorm:"type:decimal(15,2);default:0"` // DPP
+	AppliedAmount   float64        `json:"applied_amount" gorm:"type:decimal(15,2);default:0"`
+	AppliedPPN      float64        `json:"applied_ppn" gorm:"type:decimal(15,2);default:0"`
+	RemainingAmount float64        `json:"remaining_amount" gorm:"type:decimal(15,2);default:0"`
+	Status          string         `json:"status" gorm:"size:20;default:'OPEN';index"`
+	Reference       string         `json:"reference" gorm:"size:50"`
+	Notes           string         `json:"notes" gorm:"type:text"`
+	PaymentID       *uint          `json:"payment_id" gorm:"index"`
+	JournalEntryID  *uint          `json:"journal_entry_id" gorm:"index"`
+	UserID          uint           `json:"user_id" gorm:"not null;index"`
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+	DeletedAt       gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Relations
+	Contact      Contact                     `json:"contact" gorm:"foreignKey:ContactID"`
+	CashBank     CashBank                    `json:"cash_bank" gorm:"foreignKey:CashBankID"`
+	Applications []AdvancePaymentApplication `json:"applications,omitempty" gorm:"foreignKey:AdvancePaymentID"`
+}
+
+// AdvancePaymentApplication records part of an advance used to settle a sale invoice or a purchase
+type AdvancePaymentApplication struct {
+	ID                  uint      `json:"id" gorm:"primaryKey"`
+	AdvancePaymentID    uint      `json:"advance_payment_id" gorm:"not null;index"`
+	PaymentAllocationID *uint     `json:"payment_allocation_id" gorm:"index"`
+	SaleID              *uint     `json:"sale_id" gorm:"index"`
+	PurchaseID          *uint     `json:"purchase_id" gorm:"index"`
+	Date                time.Time `json:"date"`
+	Amount              float64   `json:"amount" gorm:"type:decimal(15,2);default:0"`     // Gross, PPN included
+	PPNAmount           float64   `json:"ppn_amount" gorm:"type:decimal(15,2);default:0"` // PPN part released from the advance
+	JournalEntryID      *uint     `json:"journal_entry_id" gorm:"index"`
+	UserID              uint      `json:"user_id" gorm:"not null;index"`
+	CreatedAt           time.Time `json:"created_at"`
+
+	// Relations
+	Sale     *Sale     `json:"sale,omitempty" gorm:"foreignKey:SaleID"`
+	Purchase *Purchase `json:"purchase,omitempty" gorm:"foreignKey:PurchaseID"`
+}
+
+// Advance payment types and statuses
+const (
+	AdvancePaymentTypeCustomer = "CUSTOMER"
+	AdvancePaymentTypeVendor   = "VENDOR"
+
+	AdvancePaymentStatusOpen    = "OPEN"
+	AdvancePaymentStatusApplied = "APPLIED"
+	AdvancePaymentStatusVoid    = "VOID"
+)
+
+// Payment types of the Payment records behind advances
+const (
+	PaymentTypeAdvanceReceipt   = "ADVANCE_RECEIPT"
+	PaymentTypeVendorPrepayment = "VENDOR_PREPAYMENT"
+)
+
+// Payment methods used on sale and purchase payments settled from an advance
+const (
+	SalePaymentMethodDownPayment = "DOWN_PAYMENT"
+	PurchasePaymentAdvance       = "ADVANCE"
+)
+
+// AdvancePaymentRequest records a customer down payment or a vendor prepayment
+type AdvancePaymentRequest struct {
+	ContactID  uint      `json:"contact_id" binding:"required"`
+	Date       time.Time `json:"date" binding:"required"`
+	CashBankID uint      `json:"cash_bank_id" binding:"required"`
+	Amount     float64   `json:"amount" binding:"required,gt=0"` // Gross, PPN included
+	PPNRate    float64   `json:"ppn_rate" binding:"gte=0,lte=100"`
+	Reference  string    `json:"reference"`
+	Notes      string    `json:"notes"`
+}
+
+// AdvancePaymentApplyRequest applies part of an advance to a sale invoice (customer) or purchase (vendor)
+type AdvancePaymentApplyRequest struct {
+	SaleID     *uint     `json:"sale_id"`
+	PurchaseID *uint     `json:"purchase_id"`
+	Amount     float64   `json:"amount" binding:"required,gt=0"`
+	Date       time.Time `json:"date"`
+	Notes      string    `json:"notes"`
+}
+
+// AdvancePaymentVoidRequest voids an advance that has not been applied yet
+type AdvancePaymentVoidRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+// AdvanceCreditItem is an open advance shown as a credit on the receivables or payables report
+type AdvanceCreditItem struct {
+	AdvancePaymentID uint      `json:"advance_payment_id"`
+	Code             string    `json:"code"`
+	ContactID        uint      `json:"contact_id"`
+	ContactName      string    `json:"contact_name"`
+	Date             time.Time `json:"date"`
+	Amount           float64   `json:"amount"`
+	AppliedAmount    float64   `json:"applied_amount"`
+	RemainingAmount  float64   `json:"remaining_amount"`
+}
@@ -15,6 +15,13 @@ type ApprovalWorkflow struct {
 	IsActive        bool           `json:"is_active" gorm:"default:true"`
 	RequireDirector bool           `json:"require_director" gorm:"default:false"`
 	RequireFinance  bool           `json:"require_finance" gorm:"default:false"`
+
+	// Routing conditions; empty means any. When several workflows match, the one with the most
+	// conditions wins, then the highest Priority, then the highest MinAmount.
+	ContactCategory string         `json:"contact_category" gorm:"size:50"` // Customer/vendor category (RETAIL, WHOLESALE, ...)
+	AccountID       *uint          `json:"account_id" gorm:"index"`          // Document touches this account
+	CostCenterID    *uint          `json:"cost_center_id" gorm:"index"`      // Document is charged to this cost center
+	Priority        int            `json:"priority" gorm:"default:0"`
 	CreatedAt       time.Time      `json:"created_at"`
 	UpdatedAt       time.Time      `json:"updated_at"`
 	DeletedAt       gorm.DeletedAt `json:"-" gorm:"index"`
@@ -33,6 +40,14 @@ type ApprovalStep struct {
 	IsOptional   bool           `json:"is_optional" gorm:"default:false"`
 	IsParallel   bool           `json:"is_parallel" gorm:"default:false"` // Allow parallel approval with other steps in same order
 	TimeLimit    int            `json:"time_limit" gorm:"default:24"` // hours
+
+	// Who approves the step: a role (ApproverRole), one user, the requester's manager or any
+	// RequiredApprovals members of a group
+	ApproverType      string `json:"approver_type" gorm:"size:20;default:'ROLE'"` // ROLE, USER, MANAGER, GROUP
+	ApproverUserID    *uint  `json:"approver_user_id" gorm:"index"`
+	GroupUserIDs      []uint `json:"group_user_ids" gorm:"serializer:json"`
+	RequiredApprovals int    `json:"required_approvals" gorm:"default:1"` // GROUP: N of the M members
+
 	CreatedAt    time.Time      `json:"created_at"`
 	UpdatedAt    time.Time      `json:"updated_at"`
 	DeletedAt    gorm.DeletedAt `json:"-" gorm:"index"`
@@ -77,6 +92,9 @@ type ApprovalAction struct {
 	Comments     string         `json:"comments" gorm:"type:text"`
 	ActionDate   *time.Time     `json:"action_date"`
 	IsActive     bool           `json:"is_active" gorm:"default:false"`
+	ApprovedBy   []uint         `json:"approved_by" gorm:"serializer:json"` // GROUP steps: members who approved so far
+	ActedBy      []uint         `json:"acted_by" gorm:"serializer:json"`    // GROUP steps: users who cast those approvals (the delegate when acting for a member)
+	OnBehalfOf   *uint          `json:"on_behalf_of" gorm:"index"` // Set when a delegate acted for the approver
 	CreatedAt    time.Time      `json:"created_at"`
 	UpdatedAt    time.Time      `json:"updated_at"`
 	DeletedAt    gorm.DeletedAt `json:"-" gorm:"index"`
@@ -122,10 +140,23 @@ const (
 
 // Approval Module Constants
 const (
-	ApprovalModuleSales    = "SALES"
-	ApprovalModulePurchase = "PURCHASE"
-	ApprovalModuleExpense  = "EXPENSE"
-	ApprovalModuleAsset    = "ASSET"
+	ApprovalModuleSales       = "SALES"
+	ApprovalModulePurchase    = "PURCHASE"
+	ApprovalModuleExpense     = "EXPENSE"
+	ApprovalModuleAsset       = "ASSET"
+	ApprovalModuleBudget      = "BUDGET"
+	ApprovalModuleRequisition = "REQUISITION"
+	ApprovalModuleJournal     = "JOURNAL"
+	ApprovalModulePayment     = "PAYMENT"
+	ApprovalModuleWriteOff    = "WRITE_OFF"
+)
+
+// Approver Type Constants
+const (
+	ApproverTypeRole    = "ROLE"
+	ApproverTypeUser    = "USER"
+	ApproverTypeManager = "MANAGER"
+	ApproverTypeGroup   = "GROUP"
 )
 
 // Approval Action Constants
@@ -139,10 +170,15 @@ const (
 
 // Entity Type Constants
 const (
-	EntityTypeSale     = "SALE"
-	EntityTypePurchase = "PURCHASE"
-	EntityTypeExpense  = "EXPENSE"
-	EntityTypeAsset    = "ASSET"
+	EntityTypeSale        = "SALE"
+	EntityTypePurchase    = "PURCHASE"
+	EntityTypeExpense     = "EXPENSE"
+	EntityTypeAsset       = "ASSET"
+	EntityTypeBudget      = "BUDGET"
+	EntityTypeRequisition = "REQUISITION"
+	EntityTypeJournal     = "JOURNAL"
+	EntityTypePayment     = "PAYMENT"
+	EntityTypeWriteOff    = "WRITE_OFF"
 )
 
 // DTOs for API requests/responses
@@ -153,15 +189,24 @@ type CreateApprovalWorkflowRequest struct {
 	MaxAmount       float64                       `json:"max_amount"`
 	RequireDirector bool                          `json:"require_director"`
 	RequireFinance  bool                          `json:"require_finance"`
+	ContactCategory string                        `json:"contact_category"`
+	AccountID       *uint                         `json:"account_id"`
+	CostCenterID    *uint                         `json:"cost_center_id"`
+	Priority        int                           `json:"priority"`
 	Steps           []CreateApprovalStepRequest   `json:"steps"`
 }
 
 type CreateApprovalStepRequest struct {
-	StepOrder    int    `json:"step_order" binding:"required"`
-	StepName     string `json:"step_name" binding:"required"`
-	ApproverRole string `json:"approver_role" binding:"required"`
-	IsOptional   bool   `json:"is_optional"`
-	TimeLimit    int    `json:"time_limit"`
+	StepOrder         int    `json:"step_order" binding:"required"`
+	StepName          string `json:"step_name" binding:"required"`
+	ApproverType      string `json:"approver_type"` // Default ROLE
+	ApproverRole      string `json:"approver_role"` // Required for ROLE steps
+	ApproverUserID    *uint  `json:"approver_user_id"`
+	GroupUserIDs      []uint `json:"group_user_ids"`
+	RequiredApprovals int    `json:"required_approvals"`
+	IsOptional        bool   `json:"is_optional"`
+	IsParallel        bool   `json:"is_parallel"`
+	TimeLimit         int    `json:"time_limit"`
 }
 
 type CreateApprovalRequestDTO struct {
@@ -171,6 +216,11 @@ type CreateApprovalRequestDTO struct {
 	Priority       string  `json:"priority"`
 	RequestTitle   string  `json:"request_title" binding:"required"`
 	RequestMessage string  `json:"request_message"`
+
+	// Routing context used to select the workflow
+	ContactID     *uint  `json:"contact_id,omitempty"`
+	AccountIDs    []uint `json:"account_ids,omitempty"`
+	CostCenterIDs []uint `json:"cost_center_ids,omitempty"`
 }
 
 type ApprovalActionDTO struct {
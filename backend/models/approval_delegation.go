@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ApprovalDelegation lets an approver hand their approvals to another user during a planned
+// absence. While it is in effect the delegate is notified of, and can act on, every step the
+// delegator could approve. Delegation is not transitive: a delegate's own delegations are ignored.
+type ApprovalDelegation struct {
+	ID          uint           `json:"id" gorm:"primaryKey"`
+	DelegatorID uint           `json:"delegator_id" gorm:"not null;index"`
+	DelegateID  uint           `json:"delegate_id" gorm:"not null;index"`
+	Module      string         `json:"module" gorm:"size:50"` // Empty = every approval module
+	StartDate   time.Time      `json:"start_date" gorm:"not null;index"`
+	EndDate     time.Time      `json:"end_date" gorm:"not null;index"`
+	Reason      string         `json:"reason" gorm:"type:text"`
+	IsActive    bool           `json:"is_active" gorm:"default:true"`
+	CreatedBy   uint           `json:"created_by" gorm:"not null"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Relations
+	Delegator User `json:"delegator" gorm:"foreignKey:DelegatorID"`
+	Delegate  User `json:"delegate" gorm:"foreignKey:DelegateID"`
+}
+
+// CoversAt reports whether the delegation is in effect at t for the module
+func (d ApprovalDelegation) CoversAt(t time.Time, module string) bool {
+	if !d.IsActive || t.Before(d.StartDate) || t.After(d.EndDate) {
+		return false
+	}
+	return d.Module == "" || d.Module == module
+}
+
+// ApprovalDelegationRequest creates or updates a delegation. DelegatorID defaults to the signed-in
+// user; only admins can set up a delegation for someone else.
+type ApprovalDelegationRequest struct {
+	DelegatorID uint      `json:"delegator_id"`
+	DelegateID  uint      `json:"delegate_id" binding:"required"`
+	Module      string    `json:"module"`
+	StartDate   time.Time `json:"start_date" binding:"required"`
+	EndDate     time.Time `json:"end_date" binding:"required"`
+	Reason      string    `json:"reason"`
+}
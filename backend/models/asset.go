@@ -16,6 +16,10 @@ type AssetCategory struct {
     UpdatedAt   time.Time      `json:"updated_at"`
     DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
 
+    // Depreciation accounts used by the monthly depreciation run (defaults apply when empty)
+    DepreciationExpenseAccountID     *uint `json:"depreciation_expense_account_id" gorm:"index"`
+    AccumulatedDepreciationAccountID *uint `json:"accumulated_depreciation_account_id" gorm:"index"`
+
     // Relations
     Parent   *AssetCategory `json:"parent,omitempty" gorm:"foreignKey:ParentID"`
     Children []AssetCategory `json:"children,omitempty" gorm:"foreignKey:ParentID"`
@@ -42,6 +46,7 @@ type Asset struct {
     SerialNumber  string         `json:"serial_number" gorm:"size:50"`
     Condition     string         `json:"condition" gorm:"size:20;default:'Good'"`
     ImagePath     string         `json:"image_path" gorm:"size:255"`
+    ApprovalRequestID *uint      `json:"approval_request_id" gorm:"index"`
     CreatedAt     time.Time      `json:"created_at"`
     UpdatedAt     time.Time      `json:"updated_at"`
     DeletedAt     gorm.DeletedAt `json:"-" gorm:"index"`
@@ -50,6 +55,15 @@ type Asset struct {
     AssetAccountID     *uint     `json:"asset_account_id" gorm:"index"`
     DepreciationAccountID *uint  `json:"depreciation_account_id" gorm:"index"`
     AccumulatedDepreciation float64 `json:"accumulated_depreciation" gorm:"type:decimal(15,2);default:0"`
+    ImpairmentLoss float64       `json:"impairment_loss" gorm:"type:decimal(15,2);default:0"` // Accumulated impairment, reduces book value
+    ImpairedAt     *time.Time    `json:"impaired_at"`                                                     // Date of the last impairment
+    AccumulatedAtImpairment float64 `json:"accumulated_at_impairment" gorm:"type:decimal(15,2);default:0"` // Accumulated depreciation on ImpairedAt
+
+    // Disposal (set when the asset is sold, disposed or written off)
+    DisposalDate   *time.Time    `json:"disposal_date"`
+    DisposalValue  float64       `json:"disposal_value" gorm:"type:decimal(15,2);default:0"` // Proceeds
+    FinalBookValue *float64      `json:"final_book_value" gorm:"type:decimal(15,2)"`
+
     AssetCategory   *AssetCategory `json:"asset_category,omitempty" gorm:"foreignKey:CategoryID"`
     AssetAccount    *Account     `json:"asset_account" gorm:"foreignKey:AssetAccountID"`
     DepreciationAccount *Account `json:"depreciation_account" gorm:"foreignKey:DepreciationAccountID"`
@@ -60,6 +74,10 @@ const (
     AssetStatusActive   = "ACTIVE"
     AssetStatusInactive = "INACTIVE"
     AssetStatusSold     = "SOLD"
+    AssetStatusDisposed   = "DISPOSED"
+    AssetStatusWrittenOff = "WRITTEN_OFF"
+    AssetStatusPendingApproval = "PENDING_APPROVAL" // Purchase waiting for the ASSET approval workflow
+    AssetStatusRejected        = "REJECTED"
 )
 
 // Depreciation Methods Constants
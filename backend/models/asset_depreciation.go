@@ -0,0 +1,76 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// AssetDepreciationRun is one monthly batch depreciation posting. All assets of the run are booked in
+// a single journal with one expense / accumulated depreciation pair per asset category.
+type AssetDepreciationRun struct {
+	ID                uint           `json:"id" gorm:"primaryKey"`
+	Code              string         `json:"code" gorm:"unique;not null;size:30"`
+	Period            string         `json:"period" gorm:"size:7;not null;index"` // YYYY-MM
+	PeriodEnd         time.Time      `json:"period_end" gorm:"type:date;not null"`
+	Status            string         `json:"status" gorm:"size:20;not null;default:'POSTED';index"` // PREVIEW (dry run only), POSTED, ROLLED_BACK
+	TotalAmount       float64        `json:"total_amount" gorm:"type:decimal(20,2);default:0"`
+	AssetCount        int            `json:"asset_count" gorm:"default:0"`
+	JournalEntryID    *uint64        `json:"journal_entry_id" gorm:"index"`
+	ReversalJournalID *uint64        `json:"reversal_journal_id" gorm:"index"`
+	Notes             string         `json:"notes" gorm:"type:text"`
+	CreatedBy         uint           `json:"created_by" gorm:"not null;index"`
+	RolledBackBy      *uint          `json:"rolled_back_by"`
+	RolledBackAt      *time.Time     `json:"rolled_back_at"`
+	RollbackReason    string         `json:"rollback_reason" gorm:"type:text"`
+	CreatedAt         time.Time      `json:"created_at"`
+	UpdatedAt         time.Time      `json:"updated_at"`
+	DeletedAt         gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Relations
+	Entries []AssetDepreciationEntry `json:"entries" gorm:"foreignKey:RunID"`
+}
+
+// AssetDepreciationEntry is the per-asset depreciation ledger. The partial unique index allows only one
+// POSTED entry per asset and month, so a period can never be depreciated twice; rolled back entries stay
+// as history and do not block a new run. Catch-up depreciation booked by a disposal or impairment is
+// recorded with DisposalID instead of RunID.
+type AssetDepreciationEntry struct {
+	ID                   uint      `json:"id" gorm:"primaryKey"`
+	RunID                *uint     `json:"run_id" gorm:"index"`
+	DisposalID           *uint     `json:"disposal_id" gorm:"index"`
+	AssetID              uint      `json:"asset_id" gorm:"not null;uniqueIndex:idx_asset_depreciation_period,where:status = 'POSTED'"`
+	CategoryID           *uint     `json:"category_id" gorm:"index"`
+	Period               string    `json:"period" gorm:"size:7;not null;uniqueIndex:idx_asset_depreciation_period"`
+	PeriodEnd            time.Time `json:"period_end" gorm:"type:date;not null"`
+	Amount               float64   `json:"amount" gorm:"type:decimal(15,2);default:0"`
+	AccumulatedBefore    float64   `json:"accumulated_before" gorm:"type:decimal(15,2);default:0"`
+	AccumulatedAfter     float64   `json:"accumulated_after" gorm:"type:decimal(15,2);default:0"`
+	BookValueAfter       float64   `json:"book_value_after" gorm:"type:decimal(15,2);default:0"`
+	ExpenseAccountID     uint      `json:"expense_account_id" gorm:"not null"`
+	AccumulatedAccountID uint      `json:"accumulated_account_id" gorm:"not null"`
+	Status               string    `json:"status" gorm:"size:20;not null;default:'POSTED'"` // POSTED, ROLLED_BACK
+	CreatedAt            time.Time `json:"created_at"`
+	UpdatedAt            time.Time `json:"updated_at"`
+
+	// Relations
+	Asset *Asset `json:"asset,omitempty" gorm:"foreignKey:AssetID"`
+}
+
+// Depreciation run constants
+const (
+	DepreciationRunPreview    = "PREVIEW"
+	DepreciationRunPosted     = "POSTED"
+	DepreciationRunRolledBack = "ROLLED_BACK"
+)
+
+// AssetDepreciationRunRequest - Input penyusutan bulanan (juga dipakai untuk dry run)
+type AssetDepreciationRunRequest struct {
+	Period string `json:"period" binding:"required"` // YYYY-MM
+	Notes  string `json:"notes"`
+}
+
+// AssetDepreciationRollbackRequest - Pembatalan run penyusutan sebelum periode ditutup
+type AssetDepreciationRollbackRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
@@ -0,0 +1,73 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// AssetDisposal records a sale, disposal, write-off or impairment of a fixed asset together with the
+// depreciation caught up to the transaction date and the resulting gain or loss.
+type AssetDisposal struct {
+	ID                  uint      `json:"id" gorm:"primaryKey"`
+	Code                string    `json:"code" gorm:"unique;not null;size:30"`
+	AssetID             uint      `json:"asset_id" gorm:"not null;index"`
+	Type                string    `json:"type" gorm:"size:20;not null;index"` // SALE, DISPOSE, WRITE_OFF, IMPAIRMENT
+	DisposalDate        time.Time `json:"disposal_date" gorm:"type:date;not null;index"`
+	PaymentMethod       string    `json:"payment_method" gorm:"size:20"` // CASH_BANK, RECEIVABLE (only when there are proceeds)
+	CashBankID          *uint     `json:"cash_bank_id" gorm:"index"`
+	ReceivableAccountID *uint     `json:"receivable_account_id"`
+	BuyerName           string    `json:"buyer_name" gorm:"size:100"`
+
+	// Amounts at the transaction date
+	Cost                    float64 `json:"cost" gorm:"type:decimal(15,2);default:0"`
+	CatchUpDepreciation     float64 `json:"catch_up_depreciation" gorm:"type:decimal(15,2);default:0"`
+	AccumulatedDepreciation float64 `json:"accumulated_depreciation" gorm:"type:decimal(15,2);default:0"` // Including the catch-up
+	ImpairmentLoss          float64 `json:"impairment_loss" gorm:"type:decimal(15,2);default:0"`          // Accumulated impairment before this transaction
+	BookValue               float64 `json:"book_value" gorm:"type:decimal(15,2);default:0"`               // Carrying amount before this transaction
+	Proceeds                float64 `json:"proceeds" gorm:"type:decimal(15,2);default:0"`
+	RecoverableAmount       float64 `json:"recoverable_amount" gorm:"type:decimal(15,2);default:0"` // IMPAIRMENT only
+	ImpairmentAmount        float64 `json:"impairment_amount" gorm:"type:decimal(15,2);default:0"`  // IMPAIRMENT only
+	GainLoss                float64 `json:"gain_loss" gorm:"type:decimal(15,2);default:0"`          // Positive = gain, negative = loss
+
+	JournalEntryID *uint64        `json:"journal_entry_id" gorm:"index"`
+	Notes          string         `json:"notes" gorm:"type:text"`
+	CreatedBy      uint           `json:"created_by" gorm:"not null;index"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Relations
+	Asset    *Asset    `json:"asset,omitempty" gorm:"foreignKey:AssetID"`
+	CashBank *CashBank `json:"cash_bank,omitempty" gorm:"foreignKey:CashBankID"`
+}
+
+// Asset disposal constants
+const (
+	AssetDisposalSale       = "SALE"
+	AssetDisposalDispose    = "DISPOSE"
+	AssetDisposalWriteOff   = "WRITE_OFF"
+	AssetDisposalImpairment = "IMPAIRMENT"
+
+	AssetProceedsCashBank   = "CASH_BANK"
+	AssetProceedsReceivable = "RECEIVABLE"
+)
+
+// AssetDisposalRequest - Input penjualan, pelepasan atau penghapusan aset
+type AssetDisposalRequest struct {
+	Type                string    `json:"type" binding:"required,oneof=SALE DISPOSE WRITE_OFF"`
+	DisposalDate        time.Time `json:"disposal_date" binding:"required"`
+	Proceeds            float64   `json:"proceeds" binding:"min=0"`
+	PaymentMethod       string    `json:"payment_method"` // CASH_BANK or RECEIVABLE, required when proceeds > 0
+	CashBankID          *uint     `json:"cash_bank_id"`
+	ReceivableAccountID *uint     `json:"receivable_account_id"` // Defaults to Piutang Usaha
+	BuyerName           string    `json:"buyer_name"`
+	Notes               string    `json:"notes"`
+}
+
+// AssetImpairmentRequest - Input penurunan nilai aset ke jumlah terpulihkan
+type AssetImpairmentRequest struct {
+	ImpairmentDate    time.Time `json:"impairment_date" binding:"required"`
+	RecoverableAmount float64   `json:"recoverable_amount" binding:"min=0"`
+	Notes             string    `json:"notes"`
+}
@@ -34,6 +34,7 @@ type UserSession struct {
 	LastActivity time.Time     `json:"last_activity"`
 	ExpiresAt   time.Time      `json:"expires_at"`
 	IsActive    bool           `json:"is_active" gorm:"default:true"`
+	TwoFactorVerifiedAt *time.Time `json:"two_factor_verified_at"` // Last TOTP check in this session, used for step-up
 	CreatedAt   time.Time      `json:"created_at"`
 	UpdatedAt   time.Time      `json:"updated_at"`
 	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
@@ -113,6 +114,7 @@ type TokenResponse struct {
 	ExpiresIn    int64     `json:"expires_in"`
 	ExpiresAt    time.Time `json:"expires_at"`
 	User         User      `json:"user"`
+	SessionID    string    `json:"-"`
 }
 
 type RefreshTokenRequest struct {
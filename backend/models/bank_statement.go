@@ -0,0 +1,117 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// BankStatement - Rekening koran yang diimpor dari bank (CSV, MT940 atau OFX)
+type BankStatement struct {
+	ID             uint           `json:"id" gorm:"primaryKey"`
+	CashBankID     uint           `json:"cash_bank_id" gorm:"not null;index"`
+	Format         string         `json:"format" gorm:"size:10;not null"` // CSV, MT940, OFX
+	BankPreset     string         `json:"bank_preset" gorm:"size:20"`     // BCA, MANDIRI, BRI, CUSTOM (CSV only)
+	FileName       string         `json:"file_name" gorm:"size:255"`
+	StatementRef   string         `json:"statement_ref" gorm:"size:100"` // MT940 :20: / OFX account id
+	PeriodStart    time.Time      `json:"period_start" gorm:"index"`
+	PeriodEnd      time.Time      `json:"period_end" gorm:"index"`
+	OpeningBalance float64        `json:"opening_balance" gorm:"type:decimal(20,2);default:0"`
+	ClosingBalance float64        `json:"closing_balance" gorm:"type:decimal(20,2);default:0"`
+	TotalDebit     float64        `json:"total_debit" gorm:"type:decimal(20,2);default:0"`  // Money out of the bank account
+	TotalCredit    float64        `json:"total_credit" gorm:"type:decimal(20,2);default:0"` // Money into the bank account
+	LineCount      int            `json:"line_count" gorm:"default:0"`
+	MatchedCount   int            `json:"matched_count" gorm:"default:0"`
+	Status         string         `json:"status" gorm:"size:20;default:'IMPORTED'"` // IMPORTED, RECONCILED
+	ImportedBy     uint           `json:"imported_by" gorm:"not null;index"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Relations
+	CashBank CashBank            `json:"cash_bank" gorm:"foreignKey:CashBankID"`
+	Lines    []BankStatementLine `json:"lines,omitempty" gorm:"foreignKey:StatementID"`
+}
+
+// BankStatementLine - Satu mutasi pada rekening koran
+type BankStatementLine struct {
+	ID              uint      `json:"id" gorm:"primaryKey"`
+	StatementID     uint      `json:"statement_id" gorm:"not null;index"`
+	CashBankID      uint      `json:"cash_bank_id" gorm:"not null;index"`
+	LineNumber      int       `json:"line_number"`
+	TransactionDate time.Time `json:"transaction_date" gorm:"index"`
+	Description     string    `json:"description" gorm:"type:text"`
+	Reference       string    `json:"reference" gorm:"size:100;index"`
+	Amount          float64   `json:"amount" gorm:"type:decimal(20,2);default:0"` // Signed like CashBankTransaction.Amount: positive = money in
+	Balance         *float64  `json:"balance" gorm:"type:decimal(20,2)"`          // Running balance as printed by the bank, if any
+
+	MatchStatus          string         `json:"match_status" gorm:"size:20;default:'UNMATCHED';index"` // UNMATCHED, AUTO, MANUAL, JOURNAL
+	MatchedTransactionID *uint          `json:"matched_transaction_id" gorm:"index"`
+	MatchScore           int            `json:"match_score" gorm:"default:0"`
+	MatchedAt            *time.Time     `json:"matched_at"`
+	MatchedBy            *uint          `json:"matched_by"`
+	JournalEntryID       *uint64        `json:"journal_entry_id" gorm:"index"`
+	CreatedAt            time.Time      `json:"created_at"`
+	UpdatedAt            time.Time      `json:"updated_at"`
+	DeletedAt            gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Relations
+	MatchedTransaction *CashBankTransaction `json:"matched_transaction,omitempty" gorm:"foreignKey:MatchedTransactionID"`
+}
+
+// BankStatementColumnMapping - Pemetaan kolom CSV per bank.
+// Column indexes are zero-based; -1 means the column is not present in the export.
+type BankStatementColumnMapping struct {
+	ID                uint           `json:"id" gorm:"primaryKey"`
+	Code              string         `json:"code" gorm:"unique;not null;size:20"`
+	Name              string         `json:"name" gorm:"size:100"`
+	Delimiter         string         `json:"delimiter" gorm:"size:1;default:','"`
+	SkipRows          int            `json:"skip_rows" gorm:"default:1"`
+	DateColumn        int            `json:"date_column" gorm:"default:0"`
+	DateFormat        string         `json:"date_format" gorm:"size:30;default:'02/01/2006'"`
+	DescriptionColumn int            `json:"description_column" gorm:"default:1"`
+	ReferenceColumn   int            `json:"reference_column" gorm:"default:-1"`
+	AmountColumn      int            `json:"amount_column" gorm:"default:-1"` // Single signed amount column
+	DebitColumn       int            `json:"debit_column" gorm:"default:-1"`  // Money out
+	CreditColumn      int            `json:"credit_column" gorm:"default:-1"` // Money in
+	DrCrColumn        int            `json:"dr_cr_column" gorm:"default:-1"`  // "DB"/"CR" indicator next to AmountColumn (BCA)
+	BalanceColumn     int            `json:"balance_column" gorm:"default:-1"`
+	DecimalSeparator  string         `json:"decimal_separator" gorm:"size:1;default:'.'"`
+	IsActive          bool           `json:"is_active" gorm:"default:true"`
+	CreatedAt         time.Time      `json:"created_at"`
+	UpdatedAt         time.Time      `json:"updated_at"`
+	DeletedAt         gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// Bank statement formats and statuses
+const (
+	BankStatementFormatCSV   = "CSV"
+	BankStatementFormatMT940 = "MT940"
+	BankStatementFormatOFX   = "OFX"
+
+	BankStatementStatusImported   = "IMPORTED"
+	BankStatementStatusReconciled = "RECONCILED"
+
+	StatementLineUnmatched = "UNMATCHED"
+	StatementLineAuto      = "AUTO"
+	StatementLineManual    = "MANUAL"
+	StatementLineJournal   = "JOURNAL"
+)
+
+// BankStatementMatchRequest - Aturan toleransi untuk pencocokan otomatis
+type BankStatementMatchRequest struct {
+	AmountTolerance  float64 `json:"amount_tolerance"` // Absolute difference allowed, e.g. 0 or 1000 for transfer fees
+	DateWindowDays   int     `json:"date_window_days"` // Days before/after the statement date
+	RequireReference bool    `json:"require_reference"`
+}
+
+// BankStatementManualMatchRequest - Pencocokan manual baris rekening koran dengan transaksi kas/bank
+type BankStatementManualMatchRequest struct {
+	TransactionID uint `json:"transaction_id" binding:"required"`
+}
+
+// BankStatementJournalRequest - Buat jurnal biaya/bunga bank dari baris yang belum cocok
+type BankStatementJournalRequest struct {
+	CounterAccountID uint   `json:"counter_account_id" binding:"required"` // Bank fee expense or interest income account
+	Description      string `json:"description"`
+}
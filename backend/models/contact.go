@@ -19,6 +19,10 @@ type Contact struct {
 	TaxNumber    string         `json:"tax_number" gorm:"size:50"`
 	CreditLimit  float64        `json:"credit_limit" gorm:"type:decimal(15,2);default:0"`
 	PaymentTerms int            `json:"payment_terms" gorm:"default:30"` // Days
+	CreditHold       bool       `json:"credit_hold" gorm:"default:false"` // Set/released via credit control only
+	CreditHoldReason string     `json:"credit_hold_reason" gorm:"type:text"`
+	CreditHoldAt     *time.Time `json:"credit_hold_at"`
+	CreditHoldBy     *uint      `json:"credit_hold_by"`
 	IsActive     bool           `json:"is_active" gorm:"default:true"`
 	
 	// Additional fields
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+)
+
+// CreditHoldLog is the audit trail of credit holds placed on and released from a customer
+type CreditHoldLog struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	ContactID uint      `json:"contact_id" gorm:"not null;index"`
+	Action    string    `json:"action" gorm:"size:20;not null"` // HOLD, RELEASE
+	Reason    string    `json:"reason" gorm:"type:text"`
+	UserID    uint      `json:"user_id" gorm:"not null;index"`
+	CreatedAt time.Time `json:"created_at"`
+
+	// Relations
+	User *User `json:"user,omitempty" gorm:"foreignKey:UserID"`
+}
+
+// Credit hold actions
+const (
+	CreditHoldActionHold    = "HOLD"
+	CreditHoldActionRelease = "RELEASE"
+)
+
+// Credit control actions (Settings.CreditControlAction): what happens to a sale that fails the credit check
+const (
+	CreditControlActionBlock    = "BLOCK"    // Reject the confirmation/invoice
+	CreditControlActionApproval = "APPROVAL" // Route the sale to the SALES approval workflow
+)
+
+// Sale credit approval statuses (Sale.CreditApprovalStatus); empty means no approval was needed
+const (
+	SaleCreditApprovalPending  = "PENDING"
+	SaleCreditApprovalApproved = "APPROVED"
+	SaleCreditApprovalRejected = "REJECTED"
+)
+
+// CreditHoldRequest places or releases a customer's credit hold
+type CreditHoldRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
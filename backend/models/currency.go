@@ -0,0 +1,82 @@
+package models
+
+import (
+	"time"
+	"gorm.io/gorm"
+)
+
+// BaseCurrency is the functional currency of the books; all ledger amounts are stored in it
+const BaseCurrency = "IDR"
+
+// ExchangeRate stores the rate of one unit of foreign currency in base currency for a date
+type ExchangeRate struct {
+	ID           uint           `json:"id" gorm:"primaryKey"`
+	CurrencyCode string         `json:"currency_code" gorm:"not null;size:5;uniqueIndex:idx_exchange_rate_currency_date"`
+	RateDate     time.Time      `json:"rate_date" gorm:"type:date;not null;uniqueIndex:idx_exchange_rate_currency_date"`
+	Rate         float64        `json:"rate" gorm:"type:decimal(18,6);not null"`
+	Source       string         `json:"source" gorm:"size:20;default:'MANUAL'"` // MANUAL, CSV_IMPORT
+	Notes        string         `json:"notes" gorm:"type:text"`
+	CreatedBy    uint           `json:"created_by" gorm:"index"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// FXRevaluation is a month-end unrealized gain/loss run and its automatic reversal
+type FXRevaluation struct {
+	ID                uint           `json:"id" gorm:"primaryKey"`
+	Code              string         `json:"code" gorm:"unique;not null;size:30"`
+	PeriodEndDate     time.Time      `json:"period_end_date" gorm:"type:date;not null;index"`
+	ReversalDate      time.Time      `json:"reversal_date" gorm:"type:date;not null"`
+	Status            string         `json:"status" gorm:"not null;size:20;default:'POSTED'"` // POSTED, CANCELLED
+	TotalGain         float64        `json:"total_gain" gorm:"type:decimal(20,2);default:0"`
+	TotalLoss         float64        `json:"total_loss" gorm:"type:decimal(20,2);default:0"`
+	NetAmount         float64        `json:"net_amount" gorm:"type:decimal(20,2);default:0"`
+	JournalID         *uint64        `json:"journal_id" gorm:"index"`
+	ReversalJournalID *uint64        `json:"reversal_journal_id" gorm:"index"`
+	Notes             string         `json:"notes" gorm:"type:text"`
+	CreatedBy         uint           `json:"created_by" gorm:"not null;index"`
+	CreatedAt         time.Time      `json:"created_at"`
+	UpdatedAt         time.Time      `json:"updated_at"`
+	DeletedAt         gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Relations
+	Items []FXRevaluationItem `json:"items" gorm:"foreignKey:RevaluationID"`
+}
+
+// FXRevaluationItem is a single revalued receivable, payable or bank balance
+type FXRevaluationItem struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	RevaluationID  uint      `json:"revaluation_id" gorm:"not null;index"`
+	ItemType       string    `json:"item_type" gorm:"not null;size:20"` // RECEIVABLE, PAYABLE, CASH_BANK
+	ReferenceID    uint      `json:"reference_id" gorm:"not null"`
+	ReferenceCode  string    `json:"reference_code" gorm:"size:50"`
+	AccountID      uint      `json:"account_id" gorm:"not null;index"`
+	CurrencyCode   string    `json:"currency_code" gorm:"not null;size:5"`
+	ForeignAmount  float64   `json:"foreign_amount" gorm:"type:decimal(20,2);default:0"`
+	BookRate       float64   `json:"book_rate" gorm:"type:decimal(18,6);default:0"`
+	BookAmount     float64   `json:"book_amount" gorm:"type:decimal(20,2);default:0"`
+	ClosingRate    float64   `json:"closing_rate" gorm:"type:decimal(18,6);default:0"`
+	RevaluedAmount float64   `json:"revalued_amount" gorm:"type:decimal(20,2);default:0"`
+	Difference     float64   `json:"difference" gorm:"type:decimal(20,2);default:0"` // positive = gain, negative = loss
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// Exchange Rate Source Constants
+const (
+	ExchangeRateSourceManual    = "MANUAL"
+	ExchangeRateSourceCSVImport = "CSV_IMPORT"
+)
+
+// FX Revaluation Status Constants
+const (
+	FXRevaluationStatusPosted    = "POSTED"
+	FXRevaluationStatusCancelled = "CANCELLED"
+)
+
+// FX Revaluation Item Type Constants
+const (
+	FXItemTypeReceivable = "RECEIVABLE"
+	FXItemTypePayable    = "PAYABLE"
+	FXItemTypeCashBank   = "CASH_BANK"
+)
@@ -0,0 +1,168 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Modules that support data scopes
+const (
+	DataScopeModuleSales              = "sales"
+	DataScopeModulePurchases          = "purchases"
+	DataScopeModuleWarehouseLocations = "warehouse_locations"
+	DataScopeModuleCashBank           = "cash_bank"
+)
+
+// Where an effective data scope came from
+const (
+	DataScopeSourceUser = "USER"
+	DataScopeSourceRole = "ROLE"
+	DataScopeSourceNone = "NONE"
+)
+
+// DataScope narrows which records of a module a user or role can see on top of the module
+// permission. All filled restrictions apply together; a user scope replaces the role scope.
+type DataScope struct {
+	ID             uint           `json:"id" gorm:"primaryKey"`
+	UserID         *uint          `json:"user_id" gorm:"index"`      // Scope for one user...
+	Role           string         `json:"role" gorm:"size:20;index"` // ...or for every user of a role
+	Module         string         `json:"module" gorm:"not null;size:50;index"`
+	OwnRecordsOnly bool           `json:"own_records_only" gorm:"default:false"`          // Only records the user created
+	SalesPersonIDs []uint         `json:"sales_person_ids" gorm:"serializer:json"`        // Sales: allowed sales persons (contacts)
+	WarehouseIDs   []uint         `json:"warehouse_ids" gorm:"serializer:json"`           // Warehouse locations: allowed locations
+	CashBankIDs    []uint         `json:"cash_bank_ids" gorm:"serializer:json"`           // Cash & bank: allowed accounts
+	MaxAmount      float64        `json:"max_amount" gorm:"type:decimal(15,2);default:0"` // Sales/purchases: highest visible total, 0 = no ceiling
+	IsActive       bool           `json:"is_active" gorm:"default:true"`
+	Notes          string         `json:"notes" gorm:"type:text"`
+	CreatedBy      uint           `json:"created_by" gorm:"index"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Relations
+	User *User `json:"user,omitempty" gorm:"foreignKey:UserID"`
+}
+
+// EffectiveDataScope is the scope that applies to one user in one module
+type EffectiveDataScope struct {
+	UserID         uint    `json:"user_id"`
+	Role           string  `json:"role"`
+	Module         string  `json:"module"`
+	Source         string  `json:"source"` // USER, ROLE or NONE
+	ScopeID        uint    `json:"scope_id,omitempty"`
+	Unrestricted   bool    `json:"unrestricted"`
+	OwnRecordsOnly bool    `json:"own_records_only"`
+	SalesPersonIDs []uint  `json:"sales_person_ids"`
+	WarehouseIDs   []uint  `json:"warehouse_ids"`
+	CashBankIDs    []uint  `json:"cash_bank_ids"`
+	MaxAmount      float64 `json:"max_amount"`
+}
+
+// IsRestricted reports whether the scope filters anything at all
+func (s *EffectiveDataScope) IsRestricted() bool {
+	return s != nil && !s.Unrestricted
+}
+
+// AllowsSale reports whether the sale falls inside the scope
+func (s *EffectiveDataScope) AllowsSale(sale *Sale) bool {
+	if !s.IsRestricted() {
+		return true
+	}
+	if s.OwnRecordsOnly && sale.UserID != s.UserID {
+		return false
+	}
+	if len(s.SalesPersonIDs) > 0 && (sale.SalesPersonID == nil || !containsUint(s.SalesPersonIDs, *sale.SalesPersonID)) {
+		return false
+	}
+	return s.MaxAmount <= 0 || sale.TotalAmount <= s.MaxAmount
+}
+
+// AllowsPurchase reports whether the purchase falls inside the scope
+func (s *EffectiveDataScope) AllowsPurchase(purchase *Purchase) bool {
+	if !s.IsRestricted() {
+		return true
+	}
+	if s.OwnRecordsOnly && purchase.UserID != s.UserID {
+		return false
+	}
+	return s.MaxAmount <= 0 || purchase.TotalAmount <= s.MaxAmount
+}
+
+// AllowsWarehouse reports whether the warehouse location falls inside the scope
+func (s *EffectiveDataScope) AllowsWarehouse(warehouseID uint) bool {
+	if !s.IsRestricted() || len(s.WarehouseIDs) == 0 {
+		return true
+	}
+	return containsUint(s.WarehouseIDs, warehouseID)
+}
+
+// AllowsStockTransfer reports whether both the source and the destination warehouse of the transfer
+// fall inside the scope
+func (s *EffectiveDataScope) AllowsStockTransfer(fromWarehouseID, toWarehouseID uint) bool {
+	return s.AllowsWarehouse(fromWarehouseID) && s.AllowsWarehouse(toWarehouseID)
+}
+
+// AllowsCashBank reports whether the cash/bank account falls inside the scope
+func (s *EffectiveDataScope) AllowsCashBank(cashBank *CashBank) bool {
+	if !s.IsRestricted() {
+		return true
+	}
+	if s.OwnRecordsOnly && cashBank.UserID != s.UserID {
+		return false
+	}
+	return len(s.CashBankIDs) == 0 || containsUint(s.CashBankIDs, cashBank.ID)
+}
+
+// DataScopeRequest creates or updates a data scope; set either UserID or Role
+type DataScopeRequest struct {
+	UserID         *uint   `json:"user_id"`
+	Role           string  `json:"role"`
+	Module         string  `json:"module" binding:"required"`
+	OwnRecordsOnly bool    `json:"own_records_only"`
+	SalesPersonIDs []uint  `json:"sales_person_ids"`
+	WarehouseIDs   []uint  `json:"warehouse_ids"`
+	CashBankIDs    []uint  `json:"cash_bank_ids"`
+	MaxAmount      float64 `json:"max_amount" binding:"min=0"`
+	IsActive       *bool   `json:"is_active"`
+	Notes          string  `json:"notes"`
+}
+
+// DataScopeFilter filters the data scope list
+type DataScopeFilter struct {
+	UserID uint   `json:"user_id"`
+	Role   string `json:"role"`
+	Module string `json:"module"`
+}
+
+// DataScopeCheck is one evaluated rule in an access explanation
+type DataScopeCheck struct {
+	Rule   string `json:"rule"`
+	Passed bool   `json:"passed"`
+	Detail string `json:"detail"`
+}
+
+// DataScopeExplanation tells why a user can or cannot see a given record
+type DataScopeExplanation struct {
+	UserID        uint               `json:"user_id"`
+	Username      string             `json:"username"`
+	Role          string             `json:"role"`
+	Module        string             `json:"module"`
+	RecordID      uint               `json:"record_id"`
+	RecordFound   bool               `json:"record_found"`
+	CanView       bool               `json:"can_view"`
+	PermissionVia string             `json:"permission_via"` // USER_RECORD or ROLE_DEFAULT
+	Scope         EffectiveDataScope `json:"scope"`
+	Checks        []DataScopeCheck   `json:"checks"`
+	Allowed       bool               `json:"allowed"`
+	Reason        string             `json:"reason"`
+}
+
+func containsUint(values []uint, value uint) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,99 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DeliveryOrder (surat jalan) ships part or all of a confirmed sales order. Stock leaves the
+// warehouse when the delivery order is posted; the sale is invoiced later for what was shipped.
+type DeliveryOrder struct {
+	ID              uint           `json:"id" gorm:"primaryKey"`
+	Code            string         `json:"code" gorm:"unique;not null;size:30"`
+	SaleID          uint           `json:"sale_id" gorm:"not null;index"`
+	CustomerID      uint           `json:"customer_id" gorm:"not null;index"`
+	Date            time.Time      `json:"date"`
+	ShippingAddress string         `json:"shipping_address" gorm:"type:text"`
+	ShippingMethod  string         `json:"shipping_method" gorm:"size:50"`
+	VehicleNumber   string         `json:"vehicle_number" gorm:"size:30"`
+	DriverName      string         `json:"driver_name" gorm:"size:100"`
+	Status          string         `json:"status" gorm:"size:20;default:'SHIPPED';index"` // SHIPPED, CANCELLED
+	Notes           string         `json:"notes" gorm:"type:text"`
+	CancelReason    string         `json:"cancel_reason" gorm:"type:text"`
+	UserID          uint           `json:"user_id" gorm:"not null;index"`
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+	DeletedAt       gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Relations
+	Sale     Sale                `json:"sale" gorm:"foreignKey:SaleID"`
+	Customer Contact             `json:"customer" gorm:"foreignKey:CustomerID"`
+	User     User                `json:"user" gorm:"foreignKey:UserID"`
+	Items    []DeliveryOrderItem `json:"items" gorm:"foreignKey:DeliveryOrderID"`
+}
+
+// DeliveryOrderItem is the quantity of one sale item shipped on a delivery order
+type DeliveryOrderItem struct {
+	ID                  uint      `json:"id" gorm:"primaryKey"`
+	DeliveryOrderID     uint      `json:"delivery_order_id" gorm:"not null;index"`
+	SaleItemID          uint      `json:"sale_item_id" gorm:"not null;index"`
+	ProductID           uint      `json:"product_id" gorm:"not null;index"`
+	WarehouseLocationID *uint     `json:"warehouse_location_id" gorm:"index"`
+	Quantity            int       `json:"quantity" gorm:"not null"`
+	CreatedAt           time.Time `json:"created_at"`
+
+	// Relations
+	Product  Product  `json:"product" gorm:"foreignKey:ProductID"`
+	SaleItem SaleItem `json:"sale_item" gorm:"foreignKey:SaleItemID"`
+}
+
+// Delivery order statuses
+const (
+	DeliveryOrderStatusShipped   = "SHIPPED"
+	DeliveryOrderStatusCancelled = "CANCELLED"
+)
+
+// Sale delivery statuses (Sale.DeliveryStatus); empty means nothing has been shipped
+const (
+	SaleDeliveryPartial   = "PARTIAL"
+	SaleDeliveryDelivered = "DELIVERED"
+)
+
+// DeliveryOrderRequest ships items of a confirmed sale; without items every remaining quantity is shipped
+type DeliveryOrderRequest struct {
+	Date            time.Time                  `json:"date" binding:"required"`
+	ShippingAddress string                     `json:"shipping_address"`
+	ShippingMethod  string                     `json:"shipping_method"`
+	VehicleNumber   string                     `json:"vehicle_number"`
+	DriverName      string                     `json:"driver_name"`
+	Notes           string                     `json:"notes"`
+	Items           []DeliveryOrderItemRequest `json:"items"`
+}
+
+// DeliveryOrderItemRequest is the quantity to ship of one sale item
+type DeliveryOrderItemRequest struct {
+	SaleItemID          uint  `json:"sale_item_id" binding:"required"`
+	Quantity            int   `json:"quantity" binding:"required,gt=0"`
+	WarehouseLocationID *uint `json:"warehouse_location_id"` // Gudang asal; kosong = gudang item penjualan
+}
+
+// DeliveryOrderCancelRequest cancels a delivery order of a sale that has not been invoiced yet
+type DeliveryOrderCancelRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+// BackorderItem is a sale item with quantity still waiting to be shipped
+type BackorderItem struct {
+	SaleID            uint      `json:"sale_id"`
+	SaleCode          string    `json:"sale_code"`
+	SaleItemID        uint      `json:"sale_item_id"`
+	CustomerID        uint      `json:"customer_id"`
+	CustomerName      string    `json:"customer_name"`
+	ProductID         uint      `json:"product_id"`
+	ProductName       string    `json:"product_name"`
+	Date              time.Time `json:"date"`
+	Quantity          int       `json:"quantity"`
+	DeliveredQuantity int       `json:"delivered_quantity"`
+	BackorderQuantity int       `json:"backorder_quantity"`
+}
@@ -0,0 +1,126 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Dimension is an analytic dimension value that can be attached to journal lines and source documents:
+// a cost center (department/branch), a project, or a user-defined tag grouped by TagGroup.
+type Dimension struct {
+	ID          uint           `json:"id" gorm:"primaryKey"`
+	Type        string         `json:"type" gorm:"size:20;not null;uniqueIndex:idx_dimension_type_code"` // COST_CENTER, PROJECT, TAG
+	Code        string         `json:"code" gorm:"size:30;not null;uniqueIndex:idx_dimension_type_code"`
+	Name        string         `json:"name" gorm:"size:100;not null"`
+	TagGroup    string         `json:"tag_group" gorm:"size:50;index"` // TAG only, e.g. CHANNEL, REGION
+	ParentID    *uint          `json:"parent_id" gorm:"index"`
+	Description string         `json:"description" gorm:"type:text"`
+	IsActive    bool           `json:"is_active" gorm:"default:true"`
+	CreatedBy   uint           `json:"created_by"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Relations
+	Parent *Dimension `json:"parent,omitempty" gorm:"foreignKey:ParentID"`
+}
+
+// DimensionTag links a TAG dimension to a journal line or a source document line
+type DimensionTag struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	OwnerType   string    `json:"owner_type" gorm:"size:20;not null;uniqueIndex:idx_dimension_tag_owner"` // JOURNAL_LINE, SALE_ITEM, PURCHASE_ITEM, EXPENSE
+	OwnerID     uint64    `json:"owner_id" gorm:"not null;uniqueIndex:idx_dimension_tag_owner"`
+	DimensionID uint      `json:"dimension_id" gorm:"not null;uniqueIndex:idx_dimension_tag_owner;index"`
+	CreatedAt   time.Time `json:"created_at"`
+
+	// Relations
+	Dimension *Dimension `json:"dimension,omitempty" gorm:"foreignKey:DimensionID"`
+}
+
+// DimensionRule makes a dimension mandatory on journal lines of an account, or of every account whose
+// code starts with AccountCodePrefix (e.g. "5" for all expense accounts)
+type DimensionRule struct {
+	ID                uint           `json:"id" gorm:"primaryKey"`
+	AccountID         *uint          `json:"account_id" gorm:"index"`
+	AccountCodePrefix string         `json:"account_code_prefix" gorm:"size:20"`
+	DimensionType     string         `json:"dimension_type" gorm:"size:20;not null"` // COST_CENTER, PROJECT, TAG
+	TagGroup          string         `json:"tag_group" gorm:"size:50"`               // TAG only: a tag of this group is required
+	Description       string         `json:"description" gorm:"type:text"`
+	IsActive          bool           `json:"is_active" gorm:"default:true"`
+	CreatedBy         uint           `json:"created_by"`
+	CreatedAt         time.Time      `json:"created_at"`
+	UpdatedAt         time.Time      `json:"updated_at"`
+	DeletedAt         gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Relations
+	Account *Account `json:"account,omitempty" gorm:"foreignKey:AccountID"`
+}
+
+// Dimension constants
+const (
+	DimensionTypeCostCenter = "COST_CENTER"
+	DimensionTypeProject    = "PROJECT"
+	DimensionTypeTag        = "TAG"
+
+	DimensionOwnerJournalLine  = "JOURNAL_LINE"
+	DimensionOwnerSaleItem     = "SALE_ITEM"
+	DimensionOwnerPurchaseItem = "PURCHASE_ITEM"
+	DimensionOwnerExpense      = "EXPENSE"
+)
+
+// LineDimensions is the set of dimensions carried by one journal line
+type LineDimensions struct {
+	CostCenterID *uint  `json:"cost_center_id"`
+	ProjectID    *uint  `json:"project_id"`
+	TagIDs       []uint `json:"tag_ids"`
+}
+
+// IsEmpty reports whether no dimension is set
+func (d LineDimensions) IsEmpty() bool {
+	return d.CostCenterID == nil && d.ProjectID == nil && len(d.TagIDs) == 0
+}
+
+// Key identifies the combination so that lines with the same account and dimensions can be merged
+func (d LineDimensions) Key() string {
+	tags := make([]uint, len(d.TagIDs))
+	copy(tags, d.TagIDs)
+	sort.Slice(tags, func(i, j int) bool { return tags[i] < tags[j] })
+
+	parts := []string{optionalID(d.CostCenterID), optionalID(d.ProjectID)}
+	for _, id := range tags {
+		parts = append(parts, fmt.Sprint(id))
+	}
+	return strings.Join(parts, "|")
+}
+
+func optionalID(id *uint) string {
+	if id == nil {
+		return ""
+	}
+	return fmt.Sprint(*id)
+}
+
+// DimensionRequest - Input master dimensi (cost center, proyek, tag)
+type DimensionRequest struct {
+	Type        string `json:"type" binding:"required,oneof=COST_CENTER PROJECT TAG"`
+	Code        string `json:"code" binding:"required"`
+	Name        string `json:"name" binding:"required"`
+	TagGroup    string `json:"tag_group"`
+	ParentID    *uint  `json:"parent_id"`
+	Description string `json:"description"`
+	IsActive    *bool  `json:"is_active"`
+}
+
+// DimensionRuleRequest - Input aturan dimensi wajib per akun
+type DimensionRuleRequest struct {
+	AccountID         *uint  `json:"account_id"`
+	AccountCodePrefix string `json:"account_code_prefix"`
+	DimensionType     string `json:"dimension_type" binding:"required,oneof=COST_CENTER PROJECT TAG"`
+	TagGroup          string `json:"tag_group"`
+	Description       string `json:"description"`
+	IsActive          *bool  `json:"is_active"`
+}
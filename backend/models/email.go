@@ -0,0 +1,126 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// EmailTemplate is the HTML template of one email type in one language. Subject and BodyHTML are Go
+// templates rendered with the data of the document (company, contact, document number, amounts).
+// Types without a row fall back to the built-in templates of EmailService.
+type EmailTemplate struct {
+	ID          uint           `json:"id" gorm:"primaryKey"`
+	Type        string         `json:"type" gorm:"not null;size:50;uniqueIndex:idx_email_template_type_lang"`
+	Language    string         `json:"language" gorm:"not null;size:5;uniqueIndex:idx_email_template_type_lang"` // id, en
+	Subject     string         `json:"subject" gorm:"not null;size:255"`
+	BodyHTML    string         `json:"body_html" gorm:"not null;type:text"`
+	Description string         `json:"description" gorm:"type:text"`
+	IsActive    bool           `json:"is_active" gorm:"default:true"`
+	UpdatedBy   uint           `json:"updated_by"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// EmailMessage is one outbound email in the persistent queue. The queue worker delivers QUEUED
+// messages, retries temporary failures with backoff and marks permanent rejections as BOUNCED.
+type EmailMessage struct {
+	ID              uint           `json:"id" gorm:"primaryKey"`
+	Type            string         `json:"type" gorm:"not null;size:50;index"`
+	Language        string         `json:"language" gorm:"size:5"`
+	ToAddress       string         `json:"to_address" gorm:"not null;size:255;index"`
+	ToName          string         `json:"to_name" gorm:"size:255"`
+	CcAddresses     []string       `json:"cc_addresses" gorm:"serializer:json"`
+	Subject         string         `json:"subject" gorm:"not null;size:255"`
+	BodyHTML        string         `json:"body_html" gorm:"type:text"`
+	EntityType      string         `json:"entity_type" gorm:"size:50;index:idx_email_message_entity"`
+	EntityID        *uint          `json:"entity_id" gorm:"index:idx_email_message_entity"`
+	RecipientUserID *uint          `json:"recipient_user_id" gorm:"index"` // Set for notification emails to users
+	ContactID       *uint          `json:"contact_id" gorm:"index"`        // Set for documents sent to customers/vendors
+	Status          string         `json:"status" gorm:"not null;size:20;default:'QUEUED';index"`
+	Attempts        int            `json:"attempts" gorm:"default:0"`
+	MaxAttempts     int            `json:"max_attempts" gorm:"default:5"`
+	NextAttemptAt   time.Time      `json:"next_attempt_at" gorm:"index"`
+	LastError       string         `json:"last_error" gorm:"type:text"`
+	SMTPMessageID   string         `json:"smtp_message_id" gorm:"size:255"`
+	SentAt          *time.Time     `json:"sent_at"`
+	BouncedAt       *time.Time     `json:"bounced_at"`
+	BounceReason    string         `json:"bounce_reason" gorm:"type:text"`
+	CreatedBy       *uint          `json:"created_by"`
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+	DeletedAt       gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Relations
+	Attachments []EmailAttachment `json:"attachments,omitempty" gorm:"foreignKey:EmailMessageID;constraint:OnDelete:CASCADE"`
+}
+
+// EmailAttachment is a file (usually a document PDF) sent with a queued email. The content is stored
+// with the message so that retries send exactly what was queued.
+type EmailAttachment struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	EmailMessageID uint      `json:"email_message_id" gorm:"not null;index"`
+	Filename       string    `json:"filename" gorm:"not null;size:255"`
+	ContentType    string    `json:"content_type" gorm:"not null;size:100"`
+	Size           int       `json:"size"`
+	Content        []byte    `json:"-" gorm:"type:bytea"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// Email types, also the template types
+const (
+	EmailTypeInvoice         = "INVOICE"
+	EmailTypeReceipt         = "RECEIPT"
+	EmailTypeStatement       = "STATEMENT"
+	EmailTypePaymentReminder = "PAYMENT_REMINDER"
+	EmailTypeOverdueNotice   = "OVERDUE_NOTICE"
+	EmailTypeNotification    = "NOTIFICATION"
+	EmailTypeTest            = "TEST"
+)
+
+// Email message statuses
+const (
+	EmailStatusQueued  = "QUEUED"  // Waiting for the first or a retry attempt
+	EmailStatusSending = "SENDING" // Claimed by the queue worker
+	EmailStatusSent    = "SENT"    // Accepted by the SMTP server
+	EmailStatusFailed  = "FAILED"  // Gave up after MaxAttempts temporary failures
+	EmailStatusBounced = "BOUNCED" // Rejected permanently by the SMTP server or reported as bounced
+)
+
+// SendDocumentEmailRequest sends a document (invoice, receipt, statement) by email. Recipients
+// default to the contact's email address; Language defaults to Settings.Language.
+type SendDocumentEmailRequest struct {
+	To       string   `json:"to"`
+	Cc       []string `json:"cc"`
+	Language string   `json:"language"`
+	Message  string   `json:"message"` // Optional personal message added to the template
+}
+
+// SendStatementEmailRequest sends a statement of account for a period
+type SendStatementEmailRequest struct {
+	SendDocumentEmailRequest
+	StartDate string `json:"start_date" binding:"required"` // YYYY-MM-DD
+	EndDate   string `json:"end_date" binding:"required"`   // YYYY-MM-DD
+}
+
+// EmailTemplateRequest creates or replaces the template of a type and language
+type EmailTemplateRequest struct {
+	Type        string `json:"type" binding:"required"`
+	Language    string `json:"language" binding:"required"`
+	Subject     string `json:"subject" binding:"required"`
+	BodyHTML    string `json:"body_html" binding:"required"`
+	Description string `json:"description"`
+	IsActive    *bool  `json:"is_active"`
+}
+
+// EmailMessageFilter filters the outbound queue
+type EmailMessageFilter struct {
+	Status     string `form:"status"`
+	Type       string `form:"type"`
+	EntityType string `form:"entity_type"`
+	EntityID   uint   `form:"entity_id"`
+	ToAddress  string `form:"to"`
+	Page       int    `form:"page"`
+	Limit      int    `form:"limit"`
+}
@@ -18,6 +18,9 @@ type Expense struct {
 	Notes         string         `json:"notes" gorm:"type:text"`
 	ReceiptNumber string         `json:"receipt_number" gorm:"size:50"`
 	IsRecurring   bool           `json:"is_recurring" gorm:"default:false"`
+	CostCenterID  *uint          `json:"cost_center_id" gorm:"index"`
+	ProjectID     *uint          `json:"project_id" gorm:"index"`
+	TagIDs        []uint         `json:"tag_ids,omitempty" gorm:"-"` // Stored in dimension_tags (owner EXPENSE)
 	Status        string         `json:"status" gorm:"size:20"` // PENDING, PAID
 	CreatedAt     time.Time      `json:"created_at"`
 	UpdatedAt     time.Time      `json:"updated_at"`
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+)
+
+// SaleCostLayer records how much of a receipt layer was consumed by a sale item and at what cost.
+// COGS for a sale is the sum of its cost layers; a re-costing run rewrites them.
+type SaleCostLayer struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	SaleID         uint      `json:"sale_id" gorm:"not null;index"`
+	SaleItemID     uint      `json:"sale_item_id" gorm:"not null;index"`
+	ProductID      uint      `json:"product_id" gorm:"not null;index"`
+	InventoryOutID uint      `json:"inventory_out_id" gorm:"not null;index"` // OUT movement of the sale item
+	ReceiptLayerID *uint     `json:"receipt_layer_id" gorm:"index"`          // IN movement consumed; nil when stock had no receipt layer
+	CostingMethod  string    `json:"costing_method" gorm:"size:20"`
+	Quantity       int       `json:"quantity" gorm:"not null"`
+	UnitCost       float64   `json:"unit_cost" gorm:"type:decimal(15,2);default:0"`
+	TotalCost      float64   `json:"total_cost" gorm:"type:decimal(20,2);default:0"`
+	CostingDate    time.Time `json:"costing_date"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+
+	// Relations
+	Product Product `json:"product" gorm:"foreignKey:ProductID"`
+}
@@ -15,6 +15,7 @@ type Notification struct {
 	IsRead      bool           `json:"is_read" gorm:"default:false"`
 	ReadAt      *time.Time     `json:"read_at"`
 	Priority    string         `json:"priority" gorm:"size:20;default:'NORMAL'"` // LOW, NORMAL, HIGH, URGENT
+	EmailStatus string         `json:"email_status" gorm:"size:20;index"`        // Empty until the email dispatcher handled it: QUEUED or SKIPPED
 	CreatedAt   time.Time      `json:"created_at"`
 	UpdatedAt   time.Time      `json:"updated_at"`
 	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
@@ -26,6 +27,7 @@ type Notification struct {
 type StockAlert struct {
 	ID          uint           `json:"id" gorm:"primaryKey"`
 	ProductID   uint           `json:"product_id" gorm:"not null;index"`
+	WarehouseLocationID *uint  `json:"warehouse_location_id" gorm:"index"` // nil = company-wide alert
 	AlertType   string         `json:"alert_type" gorm:"not null;size:50"` // LOW_STOCK, OUT_OF_STOCK, OVERSTOCK
 	CurrentStock int           `json:"current_stock"`
 	ThresholdStock int         `json:"threshold_stock"`
@@ -36,7 +38,8 @@ type StockAlert struct {
 	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
 
 	// Relations
-	Product Product `json:"product" gorm:"foreignKey:ProductID"`
+	Product           Product            `json:"product" gorm:"foreignKey:ProductID"`
+	WarehouseLocation *WarehouseLocation `json:"warehouse_location,omitempty" gorm:"foreignKey:WarehouseLocationID"`
 }
 
 // Notification Types Constants
@@ -51,6 +51,26 @@ type NotificationPreference struct {
 	User User `json:"user" gorm:"foreignKey:UserID"`
 }
 
+// WantsType reports whether the per-type toggle allows the notification type; it applies to every channel
+func (p *NotificationPreference) WantsType(notificationType string) bool {
+	switch notificationType {
+	case NotificationTypeApprovalPending:
+		return p.ApprovalPending
+	case NotificationTypeApprovalApproved:
+		return p.ApprovalApproved
+	case NotificationTypeApprovalRejected:
+		return p.ApprovalRejected
+	case NotificationTypeApprovalEscalated:
+		return p.ApprovalEscalated
+	case NotificationTypeLowStock, NotificationTypeStockOut:
+		return p.StockAlerts
+	case NotificationTypePaymentDue:
+		return p.PaymentReminders
+	default:
+		return p.SystemAlerts
+	}
+}
+
 // NotificationBatch groups similar notifications to reduce spam
 type NotificationBatch struct {
 	ID              uint           `json:"id" gorm:"primaryKey"`
@@ -81,6 +81,7 @@ type WriteOffSuggestion struct {
 	ApprovedBy        *uint      `json:"approved_by,omitempty" gorm:"index"`
 	ApprovedAt        *time.Time `json:"approved_at,omitempty"`
 	WrittenOffAt      *time.Time `json:"written_off_at,omitempty"`
+	ApprovalRequestID *uint      `json:"approval_request_id,omitempty" gorm:"index"`
 	CreatedAt         time.Time  `json:"created_at"`
 	UpdatedAt         time.Time  `json:"updated_at"`
 	
@@ -237,3 +238,11 @@ func (p *PaymentReminder) BeforeCreate(tx *gorm.DB) error {
 	p.CreatedAt = time.Now()
 	return nil
 }
+
+// Write-off suggestion status constants
+const (
+	WriteOffStatusPendingApproval = "PENDING_APPROVAL"
+	WriteOffStatusApproved        = "APPROVED"
+	WriteOffStatusRejected        = "REJECTED"
+	WriteOffStatusWrittenOff      = "WRITTEN_OFF"
+)
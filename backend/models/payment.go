@@ -12,12 +12,16 @@ type Payment struct {
     UserID          uint           `json:"user_id" gorm:"not null;index"`
     Date            time.Time      `json:"date"`
     Amount          float64        `json:"amount" gorm:"type:decimal(15,2);default:0"`
+    Currency        string         `json:"currency" gorm:"size:5;default:'IDR'"`
+    ExchangeRate    float64        `json:"exchange_rate" gorm:"type:decimal(12,6);default:1"` // Settlement rate for foreign currency invoices/bills
     Method          string         `json:"method" gorm:"size:20"` // CASH, BANK_TRANSFER, CHECK, etc.
     Reference       string         `json:"reference" gorm:"size:50"`
     Status          string         `json:"status" gorm:"size:20"` // PENDING, COMPLETED, FAILED, REVERSED
     PaymentType     string         `json:"payment_type" gorm:"size:30;index"` // REGULAR, TAX_PPN, TAX_PPN_INPUT, TAX_PPN_OUTPUT
     Notes           string         `json:"notes" gorm:"type:text"`
     JournalEntryID  *uint          `json:"journal_entry_id" gorm:"index"`  // Link to SSOT journal entry
+    ApprovalRequestID *uint        `json:"approval_request_id" gorm:"index"`
+    HeldRequest     string         `json:"-" gorm:"type:text"` // Payment request posted once approved
     CreatedAt       time.Time      `json:"created_at"`
     UpdatedAt       time.Time      `json:"updated_at"`
     DeletedAt       gorm.DeletedAt `json:"-" gorm:"index"`
@@ -48,6 +52,8 @@ const (
     PaymentStatusCompleted = "COMPLETED"
     PaymentStatusFailed    = "FAILED"
     PaymentStatusReversed  = "REVERSED"
+    PaymentStatusPendingApproval = "PENDING_APPROVAL" // Held until the PAYMENT approval workflow approves it
+    PaymentStatusRejected        = "REJECTED"
 )
 
 // Payment Method Constants
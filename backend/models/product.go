@@ -26,6 +26,7 @@ type Product struct {
 	CostPrice     float64        `json:"cost_price" gorm:"type:decimal(15,2);default:0"`
 	SalePrice     float64        `json:"sale_price" gorm:"type:decimal(15,2);default:0"`
 	PricingTier   string         `json:"pricing_tier" gorm:"size:100"`
+	CostingMethod string         `json:"costing_method" gorm:"size:20;default:'FIFO'"` // FIFO, LIFO, Average
 	Stock         int            `json:"stock" gorm:"default:0"`
 	MinStock      int            `json:"min_stock" gorm:"default:0"`
 	MaxStock      int            `json:"max_stock" gorm:"default:0"`
@@ -116,6 +117,7 @@ type Inventory struct {
 	UnitCost      float64        `json:"unit_cost" gorm:"type:decimal(15,2);default:0"`
 	TotalCost     float64        `json:"total_cost" gorm:"type:decimal(15,2);default:0"`
 	RemainingQty  int            `json:"remaining_qty" gorm:"default:0"`
+	WarehouseLocationID *uint    `json:"warehouse_location_id" gorm:"index"`
 	Notes         string         `json:"notes" gorm:"type:text"`
 	TransactionDate time.Time    `json:"transaction_date"`
 	CreatedAt     time.Time      `json:"created_at"`
@@ -132,6 +134,13 @@ const (
 	InventoryTypeOut = "OUT"
 )
 
+// Inventory Reference Type Constants
+const (
+	InventoryRefPurchase       = "PURCHASE"
+	InventoryRefSale           = "SALE"
+	InventoryRefPurchaseReturn = "PURCHASE_RETURN"
+)
+
 // ProductPriceUpdate represents bulk price update data
 type ProductPriceUpdate struct {
 	ProductID     uint    `json:"product_id"`
@@ -12,6 +12,8 @@ type Purchase struct {
 	UserID       uint           `json:"user_id" gorm:"not null;index"`
 	Date         time.Time      `json:"date"`
 	DueDate      time.Time      `json:"due_date"`
+	Currency     string         `json:"currency" gorm:"size:5;default:'IDR'"`
+	ExchangeRate float64        `json:"exchange_rate" gorm:"type:decimal(12,6);default:1"`
 	// Monetary breakdown
 	SubtotalBeforeDiscount float64 `json:"subtotal_before_discount" gorm:"type:decimal(15,2);default:0"`
 	ItemDiscountAmount     float64 `json:"item_discount_amount" gorm:"type:decimal(15,2);default:0"`
@@ -84,6 +86,10 @@ type PurchaseItem struct {
 	Discount        float64        `json:"discount" gorm:"type:decimal(15,2);default:0"`
 	Tax             float64        `json:"tax" gorm:"type:decimal(15,2);default:0"`
 	ExpenseAccountID uint          `json:"expense_account_id" gorm:"index"`
+	WarehouseLocationID *uint      `json:"warehouse_location_id" gorm:"index"` // Gudang penerima; kosong = gudang default produk
+	CostCenterID    *uint          `json:"cost_center_id" gorm:"index"`
+	ProjectID       *uint          `json:"project_id" gorm:"index"`
+	TagIDs          []uint         `json:"tag_ids,omitempty" gorm:"-"` // Stored in dimension_tags (owner PURCHASE_ITEM)
 	CreatedAt       time.Time      `json:"created_at"`
 	UpdatedAt       time.Time      `json:"updated_at"`
 	DeletedAt       gorm.DeletedAt `json:"-" gorm:"index"`
@@ -166,12 +172,16 @@ type PurchaseFilter struct {
 	RequiresApproval *bool  `json:"requires_approval"`
 	Page             int    `json:"page"`
 	Limit            int    `json:"limit"`
+
+	Scope *EffectiveDataScope `json:"-"` // Data scope of the requesting user, nil = unrestricted
 }
 
 type PurchaseCreateRequest struct {
 	VendorID     uint                     `json:"vendor_id" binding:"required"`
 	Date         time.Time                `json:"date" binding:"required"`
 	DueDate      time.Time                `json:"due_date"`
+	Currency     string                   `json:"currency"`
+	ExchangeRate *float64                 `json:"exchange_rate"`
 	Discount     float64                  `json:"discount"`
 	
 	// Payment method fields
@@ -227,10 +237,14 @@ type PurchaseUpdateRequest struct {
 type PurchaseItemRequest struct {
 	ProductID        uint    `json:"product_id" binding:"required"`
 	Quantity         int     `json:"quantity" binding:"required,min=1"`
-	UnitPrice        float64 `json:"unit_price" binding:"required,min=0"`
+	UnitPrice        float64 `json:"unit_price" binding:"min=0"` // 0 = best valid vendor price list entry
 	Discount         float64 `json:"discount"`
 	Tax              float64 `json:"tax"`
 	ExpenseAccountID uint    `json:"expense_account_id"`
+	WarehouseLocationID *uint `json:"warehouse_location_id"`
+	CostCenterID     *uint   `json:"cost_center_id"`
+	ProjectID        *uint   `json:"project_id"`
+	TagIDs           []uint  `json:"tag_ids"`
 }
 
 // Document Management
@@ -276,6 +290,7 @@ type PurchaseReceiptItem struct {
 	ReceiptID           uint           `json:"receipt_id" gorm:"not null;index"`
 	PurchaseItemID      uint           `json:"purchase_item_id" gorm:"not null;index"`
 	QuantityReceived    int            `json:"quantity_received" gorm:"not null"`
+	WarehouseLocationID *uint          `json:"warehouse_location_id" gorm:"index"`
 	Condition           string         `json:"condition" gorm:"size:50"`
 	Notes               string         `json:"notes" gorm:"type:text"`
 	CreatedAt           time.Time      `json:"created_at"`
@@ -342,6 +357,9 @@ type PayablesReportResponse struct {
 	TotalOutstanding float64               `json:"total_outstanding"`
 	OverdueAmount    float64               `json:"overdue_amount"`
 	Payables         []PayablesReportData  `json:"payables"`
+	OpenCredits      []AdvanceCreditItem   `json:"open_credits"` // Unapplied vendor prepayments
+	TotalOpenCredits float64               `json:"total_open_credits"`
+	NetOutstanding   float64               `json:"net_outstanding"` // TotalOutstanding less TotalOpenCredits
 }
 
 // Request DTOs for Receipt Management
@@ -355,6 +373,7 @@ type PurchaseReceiptRequest struct {
 type PurchaseReceiptItemRequest struct {
 	PurchaseItemID         uint   `json:"purchase_item_id" binding:"required"`
 	QuantityReceived       int    `json:"quantity_received" binding:"required,min=1"`
+	WarehouseLocationID    *uint  `json:"warehouse_location_id"` // gudang tujuan; berbeda dari item pembelian = stok dipindahkan
 	Condition              string `json:"condition"`
 	Notes                  string `json:"notes"`
 	// Optional: trigger asset capitalization journal for this item
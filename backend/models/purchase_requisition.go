@@ -0,0 +1,161 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PurchaseRequisition is an employee's request to buy goods. Once approved, purchasing staff
+// consolidate its lines into purchase orders per vendor.
+type PurchaseRequisition struct {
+	ID                uint           `json:"id" gorm:"primaryKey"`
+	Code              string         `json:"code" gorm:"unique;not null;size:30"`
+	RequesterID       uint           `json:"requester_id" gorm:"not null;index"`
+	Date              time.Time      `json:"date"`
+	NeededBy          time.Time      `json:"needed_by"`
+	Justification     string         `json:"justification" gorm:"type:text"`
+	SuggestedVendorID *uint          `json:"suggested_vendor_id" gorm:"index"` // Default for lines without their own vendor
+	EstimatedTotal    float64        `json:"estimated_total" gorm:"type:decimal(15,2);default:0"`
+	Status            string         `json:"status" gorm:"size:20;default:'DRAFT';index"`
+	ApprovalRequestID *uint          `json:"approval_request_id" gorm:"index"`
+	ApprovedAt        *time.Time     `json:"approved_at"`
+	CancelReason      string         `json:"cancel_reason" gorm:"type:text"`
+	Notes             string         `json:"notes" gorm:"type:text"`
+	CreatedAt         time.Time      `json:"created_at"`
+	UpdatedAt         time.Time      `json:"updated_at"`
+	DeletedAt         gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Relations
+	Requester       User                      `json:"requester" gorm:"foreignKey:RequesterID"`
+	SuggestedVendor *Contact                  `json:"suggested_vendor,omitempty" gorm:"foreignKey:SuggestedVendorID"`
+	ApprovalRequest *ApprovalRequest          `json:"approval_request,omitempty" gorm:"foreignKey:ApprovalRequestID"`
+	Items           []PurchaseRequisitionItem `json:"items" gorm:"foreignKey:RequisitionID"`
+}
+
+// PurchaseRequisitionItem is one requested product
+type PurchaseRequisitionItem struct {
+	ID                 uint      `json:"id" gorm:"primaryKey"`
+	RequisitionID      uint      `json:"requisition_id" gorm:"not null;index"`
+	ProductID          uint      `json:"product_id" gorm:"not null;index"`
+	Description        string    `json:"description" gorm:"type:text"`
+	Quantity           int       `json:"quantity" gorm:"not null"`
+	EstimatedUnitPrice float64   `json:"estimated_unit_price" gorm:"type:decimal(15,2);default:0"`
+	EstimatedTotal     float64   `json:"estimated_total" gorm:"type:decimal(15,2);default:0"`
+	SuggestedVendorID  *uint     `json:"suggested_vendor_id" gorm:"index"`
+	CreatedAt          time.Time `json:"created_at"`
+	UpdatedAt          time.Time `json:"updated_at"`
+
+	// Relations
+	Product         Product                   `json:"product" gorm:"foreignKey:ProductID"`
+	SuggestedVendor *Contact                  `json:"suggested_vendor,omitempty" gorm:"foreignKey:SuggestedVendorID"`
+	Links           []PurchaseRequisitionLink `json:"links,omitempty" gorm:"foreignKey:RequisitionItemID"`
+}
+
+// PurchaseRequisitionLink ties a requisition line to the purchase order line that orders it. One
+// PO line may carry several requisition lines of the same product.
+type PurchaseRequisitionLink struct {
+	ID                uint      `json:"id" gorm:"primaryKey"`
+	RequisitionItemID uint      `json:"requisition_item_id" gorm:"not null;index"`
+	PurchaseID        uint      `json:"purchase_id" gorm:"not null;index"`
+	PurchaseItemID    uint      `json:"purchase_item_id" gorm:"not null;index"`
+	Quantity          int       `json:"quantity" gorm:"not null"`
+	UserID            uint      `json:"user_id" gorm:"not null;index"`
+	CreatedAt         time.Time `json:"created_at"`
+
+	// Relations
+	Purchase Purchase `json:"-" gorm:"foreignKey:PurchaseID"`
+}
+
+// Purchase requisition statuses
+const (
+	RequisitionStatusDraft            = "DRAFT"
+	RequisitionStatusPendingApproval  = "PENDING_APPROVAL"
+	RequisitionStatusApproved         = "APPROVED"
+	RequisitionStatusRejected         = "REJECTED"
+	RequisitionStatusPartiallyOrdered = "PARTIALLY_ORDERED"
+	RequisitionStatusOrdered          = "ORDERED"
+	RequisitionStatusCancelled        = "CANCELLED"
+)
+
+// PurchaseRequisitionRequest creates or replaces a draft requisition
+type PurchaseRequisitionRequest struct {
+	Date              time.Time                        `json:"date" binding:"required"`
+	NeededBy          time.Time                        `json:"needed_by" binding:"required"`
+	Justification     string                           `json:"justification" binding:"required"`
+	SuggestedVendorID *uint                            `json:"suggested_vendor_id"`
+	Notes             string                           `json:"notes"`
+	Items             []PurchaseRequisitionItemRequest `json:"items" binding:"required,min=1,dive"`
+}
+
+// PurchaseRequisitionItemRequest is one requested product
+type PurchaseRequisitionItemRequest struct {
+	ProductID          uint    `json:"product_id" binding:"required"`
+	Description        string  `json:"description"`
+	Quantity           int     `json:"quantity" binding:"required,gt=0"`
+	EstimatedUnitPrice float64 `json:"estimated_unit_price" binding:"gte=0"` // Empty = product purchase price
+	SuggestedVendorID  *uint   `json:"suggested_vendor_id"`
+}
+
+// PurchaseRequisitionCancelRequest cancels a requisition that has not been ordered yet
+type PurchaseRequisitionCancelRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+// RequisitionConsolidateRequest turns approved requisition lines into purchase orders, one per vendor
+type RequisitionConsolidateRequest struct {
+	Date            time.Time                        `json:"date" binding:"required"`
+	DueDate         time.Time                        `json:"due_date"`
+	PaymentMethod   string                           `json:"payment_method"`
+	BankAccountID   *uint                            `json:"bank_account_id"`
+	CreditAccountID *uint                            `json:"credit_account_id"`
+	PPNRate         *float64                         `json:"ppn_rate"`
+	Notes           string                           `json:"notes"`
+	Lines           []RequisitionConsolidateLineItem `json:"lines" binding:"required,min=1,dive"`
+}
+
+// RequisitionConsolidateLineItem orders (part of) one requisition line
+type RequisitionConsolidateLineItem struct {
+	RequisitionItemID uint     `json:"requisition_item_id" binding:"required"`
+	VendorID          *uint    `json:"vendor_id"`  // Empty = suggested vendor of the line or requisition
+	Quantity          int      `json:"quantity"`   // Empty = everything not ordered yet
+	UnitPrice         *float64 `json:"unit_price"` // Empty = estimated unit price
+}
+
+// RequisitionConsolidateResult lists the purchase orders created from requisitions
+type RequisitionConsolidateResult struct {
+	Purchases    []Purchase `json:"purchases"`
+	Requisitions []string   `json:"requisitions"`
+}
+
+// RequisitionFulfilment shows the requester how far each requested line has been ordered and received
+type RequisitionFulfilment struct {
+	RequisitionID uint                        `json:"requisition_id"`
+	Code          string                      `json:"code"`
+	Status        string                      `json:"status"`
+	NeededBy      time.Time                   `json:"needed_by"`
+	Lines         []RequisitionFulfilmentLine `json:"lines"`
+}
+
+// RequisitionFulfilmentLine traces one requisition line to its purchase order lines and receipts
+type RequisitionFulfilmentLine struct {
+	RequisitionItemID uint                        `json:"requisition_item_id"`
+	ProductID         uint                        `json:"product_id"`
+	ProductName       string                      `json:"product_name"`
+	Quantity          int                         `json:"quantity"`
+	OrderedQuantity   int                         `json:"ordered_quantity"`
+	ReceivedQuantity  int                         `json:"received_quantity"`
+	Status            string                      `json:"status"` // NOT_ORDERED, PARTIALLY_ORDERED, ORDERED, PARTIALLY_RECEIVED, RECEIVED
+	Orders            []RequisitionFulfilmentLink `json:"orders"`
+}
+
+// RequisitionFulfilmentLink is one purchase order line carrying a requisition line
+type RequisitionFulfilmentLink struct {
+	PurchaseID       uint   `json:"purchase_id"`
+	PurchaseCode     string `json:"purchase_code"`
+	PurchaseStatus   string `json:"purchase_status"`
+	VendorName       string `json:"vendor_name"`
+	PurchaseItemID   uint   `json:"purchase_item_id"`
+	Quantity         int    `json:"quantity"`
+	ReceivedQuantity int    `json:"received_quantity"`
+}
@@ -0,0 +1,137 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PurchaseReturn is goods sent back to a vendor against a purchase (and optionally one of its receipts).
+// The returned value first reduces the purchase outstanding amount; any excess becomes a vendor credit.
+type PurchaseReturn struct {
+	ID                uint           `json:"id" gorm:"primaryKey"`
+	Code              string         `json:"code" gorm:"unique;not null;size:30"`
+	DebitNoteNumber   string         `json:"debit_note_number" gorm:"unique;size:50"`
+	PurchaseID        uint           `json:"purchase_id" gorm:"not null;index"`
+	PurchaseReceiptID *uint          `json:"purchase_receipt_id" gorm:"index"`
+	VendorID          uint           `json:"vendor_id" gorm:"not null;index"`
+	UserID            uint           `json:"user_id" gorm:"not null;index"`
+	Date              time.Time      `json:"date"`
+	Currency          string         `json:"currency" gorm:"size:5;default:'IDR'"`
+	ExchangeRate      float64        `json:"exchange_rate" gorm:"type:decimal(12,6);default:1"`
+	Reason            string         `json:"reason" gorm:"type:text"`
+	Notes             string         `json:"notes" gorm:"type:text"`
+
+	// Amounts in the purchase currency
+	Subtotal          float64 `json:"subtotal" gorm:"type:decimal(15,2);default:0"`
+	PPNAmount         float64 `json:"ppn_amount" gorm:"type:decimal(15,2);default:0"`
+	PPh21Amount       float64 `json:"pph21_amount" gorm:"type:decimal(15,2);default:0"`
+	PPh23Amount       float64 `json:"pph23_amount" gorm:"type:decimal(15,2);default:0"`
+	TotalAmount       float64 `json:"total_amount" gorm:"type:decimal(15,2);default:0"` // Subtotal + PPN - PPh
+	AppliedAmount     float64 `json:"applied_amount" gorm:"type:decimal(15,2);default:0"` // Deducted from Purchase.OutstandingAmount
+	CreditAmount      float64 `json:"credit_amount" gorm:"type:decimal(15,2);default:0"`  // Issued as vendor credit
+
+	Status         string         `json:"status" gorm:"size:20;default:'POSTED'"`
+	JournalEntryID *uint64        `json:"journal_entry_id" gorm:"index"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Relations
+	Purchase        Purchase             `json:"purchase" gorm:"foreignKey:PurchaseID"`
+	PurchaseReceipt *PurchaseReceipt     `json:"purchase_receipt,omitempty" gorm:"foreignKey:PurchaseReceiptID"`
+	Vendor          Contact              `json:"vendor" gorm:"foreignKey:VendorID"`
+	User            User                 `json:"user" gorm:"foreignKey:UserID"`
+	ReturnItems     []PurchaseReturnItem `json:"return_items" gorm:"foreignKey:PurchaseReturnID"`
+}
+
+type PurchaseReturnItem struct {
+	ID                  uint           `json:"id" gorm:"primaryKey"`
+	PurchaseReturnID    uint           `json:"purchase_return_id" gorm:"not null;index"`
+	PurchaseItemID      uint           `json:"purchase_item_id" gorm:"not null;index"`
+	ProductID           uint           `json:"product_id" gorm:"not null;index"`
+	Quantity            int            `json:"quantity" gorm:"not null"`
+	UnitPrice           float64        `json:"unit_price" gorm:"type:decimal(15,2);default:0"` // Net unit price of the purchase item
+	TotalAmount         float64        `json:"total_amount" gorm:"type:decimal(15,2);default:0"`
+	WarehouseLocationID *uint          `json:"warehouse_location_id" gorm:"index"` // Gudang asal barang yang dikembalikan
+	Reason              string         `json:"reason" gorm:"size:255"`
+	CreatedAt           time.Time      `json:"created_at"`
+	UpdatedAt           time.Time      `json:"updated_at"`
+	DeletedAt           gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Relations
+	PurchaseItem PurchaseItem `json:"purchase_item" gorm:"foreignKey:PurchaseItemID"`
+	Product      Product      `json:"product" gorm:"foreignKey:ProductID"`
+}
+
+// VendorCredit is the part of a purchase return that exceeded the outstanding amount of its purchase.
+// It can be applied to later purchases of the same vendor in the same currency.
+type VendorCredit struct {
+	ID               uint           `json:"id" gorm:"primaryKey"`
+	VendorID         uint           `json:"vendor_id" gorm:"not null;index"`
+	PurchaseReturnID uint           `json:"purchase_return_id" gorm:"not null;index"`
+	Date             time.Time      `json:"date"`
+	Currency         string         `json:"currency" gorm:"size:5;default:'IDR'"`
+	Amount           float64        `json:"amount" gorm:"type:decimal(15,2);default:0"`
+	UsedAmount       float64        `json:"used_amount" gorm:"type:decimal(15,2);default:0"`
+	RemainingAmount  float64        `json:"remaining_amount" gorm:"type:decimal(15,2);default:0"`
+	Status           string         `json:"status" gorm:"size:20;default:'OPEN'"`
+	CreatedAt        time.Time      `json:"created_at"`
+	UpdatedAt        time.Time      `json:"updated_at"`
+	DeletedAt        gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Relations
+	Vendor         Contact                   `json:"vendor" gorm:"foreignKey:VendorID"`
+	PurchaseReturn PurchaseReturn            `json:"purchase_return" gorm:"foreignKey:PurchaseReturnID"`
+	Applications   []VendorCreditApplication `json:"applications,omitempty" gorm:"foreignKey:VendorCreditID"`
+}
+
+// VendorCreditApplication records a vendor credit used to settle a purchase
+type VendorCreditApplication struct {
+	ID                uint      `json:"id" gorm:"primaryKey"`
+	VendorCreditID    uint      `json:"vendor_credit_id" gorm:"not null;index"`
+	PurchaseID        uint      `json:"purchase_id" gorm:"not null;index"`
+	PurchasePaymentID *uint     `json:"purchase_payment_id" gorm:"index"`
+	Amount            float64   `json:"amount" gorm:"type:decimal(15,2);default:0"`
+	Date              time.Time `json:"date"`
+	UserID            uint      `json:"user_id" gorm:"not null;index"`
+	CreatedAt         time.Time `json:"created_at"`
+
+	// Relations
+	Purchase Purchase `json:"-" gorm:"foreignKey:PurchaseID"`
+}
+
+// Purchase return and vendor credit statuses
+const (
+	PurchaseReturnStatusPosted = "POSTED"
+
+	VendorCreditStatusOpen = "OPEN"
+	VendorCreditStatusUsed = "USED"
+)
+
+// PurchasePaymentVendorCredit is the PurchasePayment method used when a vendor credit settles a purchase
+const PurchasePaymentVendorCredit = "VENDOR_CREDIT"
+
+// PurchaseReturnRequest - Input retur pembelian
+type PurchaseReturnRequest struct {
+	PurchaseReceiptID *uint                       `json:"purchase_receipt_id"`
+	ReturnDate        time.Time                   `json:"return_date" binding:"required"`
+	Reason            string                      `json:"reason" binding:"required"`
+	Notes             string                      `json:"notes"`
+	ReturnItems       []PurchaseReturnItemRequest `json:"return_items" binding:"required,min=1,dive"`
+}
+
+type PurchaseReturnItemRequest struct {
+	PurchaseItemID      uint   `json:"purchase_item_id" binding:"required"`
+	Quantity            int    `json:"quantity" binding:"required,min=1"`
+	WarehouseLocationID *uint  `json:"warehouse_location_id"`
+	Reason              string `json:"reason"`
+}
+
+// VendorCreditApplyRequest - Pemakaian kredit vendor untuk membayar pembelian
+type VendorCreditApplyRequest struct {
+	VendorCreditID uint      `json:"vendor_credit_id" binding:"required"`
+	Amount         float64   `json:"amount" binding:"required,gt=0"`
+	Date           time.Time `json:"date"`
+	Notes          string    `json:"notes"`
+}
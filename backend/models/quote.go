@@ -28,6 +28,8 @@ type Quote struct {
 	Customer     Contact   `json:"customer" gorm:"foreignKey:CustomerID"`
 	UserID       uint      `json:"user_id" gorm:"not null"`
 	User         User      `json:"user" gorm:"foreignKey:UserID"`
+	SalesPersonID *uint    `json:"sales_person_id" gorm:"index"`
+	SalesPerson  *Contact  `json:"sales_person,omitempty" gorm:"foreignKey:SalesPersonID"`
 	Date         time.Time `json:"date" gorm:"not null"`
 	ValidUntil   time.Time `json:"valid_until" gorm:"not null"`
 	
@@ -46,17 +48,23 @@ type Quote struct {
 	OtherTaxDeductions    *float64 `json:"other_tax_deductions" gorm:"type:decimal(15,2)"`
 	
 	// Status and Notes
-	Status string `json:"status" gorm:"default:'DRAFT'"`
-	Notes  string `json:"notes"`
-	Terms  string `json:"terms"` // Terms and conditions
+	Status          string     `json:"status" gorm:"default:'DRAFT'"`
+	Notes           string     `json:"notes"`
+	Terms           string     `json:"terms"`                          // Terms and conditions
+	Revision        int        `json:"revision" gorm:"default:1"`      // Bumped each time a sent quote is amended
+	StatusReason    string     `json:"status_reason" gorm:"type:text"` // Why the quote was rejected or cancelled
+	StatusChangedAt *time.Time `json:"status_changed_at"`
 	
 	// Conversion tracking
 	ConvertedToInvoice bool  `json:"converted_to_invoice" gorm:"default:false"`
 	InvoiceID          *uint `json:"invoice_id,omitempty"`
 	Invoice            *Invoice `json:"invoice,omitempty" gorm:"foreignKey:InvoiceID"`
+	SaleID             *uint    `json:"sale_id,omitempty" gorm:"index"` // Sales order created from this quote
+	Sale               *Sale    `json:"sale,omitempty" gorm:"foreignKey:SaleID"`
 	
 	// Items
 	QuoteItems []QuoteItem `json:"quote_items" gorm:"foreignKey:QuoteID;constraint:OnDelete:CASCADE"`
+	Revisions  []QuoteRevision `json:"revisions,omitempty" gorm:"foreignKey:QuoteID"`
 }
 
 // QuoteItem represents an item in a quotation
@@ -76,6 +84,19 @@ type QuoteItem struct {
 	Description   string  `json:"description"`
 }
 
+// QuoteRevision keeps the quote as it was sent to the customer before it was amended
+type QuoteRevision struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	QuoteID     uint      `json:"quote_id" gorm:"not null;index"`
+	Revision    int       `json:"revision" gorm:"not null"`   // Revision number the snapshot was sent as
+	Snapshot    string    `json:"snapshot" gorm:"type:jsonb"` // Quote with items before the amendment
+	TotalAmount float64   `json:"total_amount" gorm:"type:decimal(15,2);default:0"`
+	Reason      string    `json:"reason" gorm:"type:text"`
+	UserID      uint      `json:"user_id" gorm:"not null;index"`
+	User        User      `json:"user" gorm:"foreignKey:UserID"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
 // TableName specifies the table name for Quote
 func (Quote) TableName() string {
 	return "quotes"
@@ -89,6 +110,7 @@ func (QuoteItem) TableName() string {
 // QuoteCreateRequest represents the request to create a new quote
 type QuoteCreateRequest struct {
 	CustomerID         uint                      `json:"customer_id" binding:"required"`
+	SalesPersonID      *uint                     `json:"sales_person_id"`
 	Date               time.Time                 `json:"date" binding:"required"`
 	ValidUntil         time.Time                 `json:"valid_until" binding:"required"`
 	Discount           float64                   `json:"discount"`
@@ -113,6 +135,7 @@ type QuoteItemCreateRequest struct {
 // QuoteUpdateRequest represents the request to update a quote
 type QuoteUpdateRequest struct {
 	CustomerID         *uint                      `json:"customer_id"`
+	SalesPersonID      *uint                      `json:"sales_person_id"`
 	Date               *time.Time                 `json:"date"`
 	ValidUntil         *time.Time                 `json:"valid_until"`
 	Discount           *float64                   `json:"discount"`
@@ -124,6 +147,7 @@ type QuoteUpdateRequest struct {
 	Notes              *string                    `json:"notes"`
 	Terms              *string                    `json:"terms"`
 	Items              []QuoteItemCreateRequest   `json:"items"`
+	RevisionReason     string                     `json:"revision_reason"` // Recorded when a sent quote is amended
 }
 
 // QuoteFilter represents filter parameters for quote queries
@@ -147,4 +171,55 @@ type QuoteSummary struct {
 	RejectedCount   int64   `json:"rejected_count"`
 	ExpiredCount    int64   `json:"expired_count"`
 	ConversionRate  float64 `json:"conversion_rate"`
-}
\ No newline at end of file
+}
+
+// QuoteStatusRequest moves a quote through SENT, ACCEPTED, REJECTED or CANCELLED
+type QuoteStatusRequest struct {
+	Status string `json:"status" binding:"required"`
+	Reason string `json:"reason"`
+}
+
+// QuoteConvertToSaleRequest carries the sale fields a quote does not have
+type QuoteConvertToSaleRequest struct {
+	Date              *time.Time `json:"date"` // Default today
+	DueDate           *time.Time `json:"due_date"`
+	PaymentTerms      string     `json:"payment_terms"`
+	PaymentMethodType string     `json:"payment_method_type"`
+	CashBankID        *uint      `json:"cash_bank_id"`
+	InvoiceTypeID     *uint      `json:"invoice_type_id"`
+	ShippingAddress   string     `json:"shipping_address"`
+	Reference         string     `json:"reference"`
+}
+
+// QuoteExpiryResult is the outcome of one run of the quote expiry job
+type QuoteExpiryResult struct {
+	AsOf    time.Time `json:"as_of"`
+	Expired int       `json:"expired"`
+	Codes   []string  `json:"codes"`
+}
+
+// QuoteConversionStats counts the outcome of quotes for one sales person, customer or overall.
+// Won = accepted (converted or not), lost = rejected or expired, open = draft or sent.
+type QuoteConversionStats struct {
+	ID             uint    `json:"id"`
+	Name           string  `json:"name"`
+	TotalQuotes    int64   `json:"total_quotes"`
+	WonCount       int64   `json:"won_count"`
+	LostCount      int64   `json:"lost_count"`
+	OpenCount      int64   `json:"open_count"`
+	ConvertedCount int64   `json:"converted_count"`
+	TotalAmount    float64 `json:"total_amount"`
+	WonAmount      float64 `json:"won_amount"`
+	LostAmount     float64 `json:"lost_amount"`
+	WinRate        float64 `json:"win_rate"`        // Won / (won + lost), in percent
+	ConversionRate float64 `json:"conversion_rate"` // Converted to a sale / total quotes, in percent
+}
+
+// QuoteConversionAnalytics is the win/loss report of quotes dated within a period
+type QuoteConversionAnalytics struct {
+	StartDate     string                 `json:"start_date"`
+	EndDate       string                 `json:"end_date"`
+	Overall       QuoteConversionStats   `json:"overall"`
+	BySalesPerson []QuoteConversionStats `json:"by_sales_person"`
+	ByCustomer    []QuoteConversionStats `json:"by_customer"`
+}
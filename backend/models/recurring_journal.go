@@ -0,0 +1,149 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RecurringTemplate is a schedule that generates journal entries (and, for expenses, an Expense record)
+// for rent, salaries, prepaid amortisation and the like.
+type RecurringTemplate struct {
+	ID          uint   `json:"id" gorm:"primaryKey"`
+	Code        string `json:"code" gorm:"unique;not null;size:30"`
+	Name        string `json:"name" gorm:"not null;size:100"`
+	Description string `json:"description" gorm:"type:text"`
+	Type        string `json:"type" gorm:"size:20;not null;default:'JOURNAL'"` // JOURNAL, EXPENSE
+
+	// Schedule
+	Frequency      string     `json:"frequency" gorm:"size:20;not null"`        // DAILY, WEEKLY, MONTHLY, YEARLY
+	IntervalCount  int        `json:"interval_count" gorm:"not null;default:1"` // Every N days/weeks/months/years
+	StartDate      time.Time  `json:"start_date" gorm:"not null"`
+	EndDate        *time.Time `json:"end_date"`
+	MaxOccurrences *int       `json:"max_occurrences"` // Stop after this many scheduled dates (skipped dates included)
+
+	// Behaviour
+	AutoPost           bool   `json:"auto_post" gorm:"default:false"`                      // false = generate DRAFT entries for review
+	ClosedPeriodAction string `json:"closed_period_action" gorm:"size:10;default:'QUEUE'"` // SKIP, QUEUE
+
+	// Expense templates: the recurring expense copied on every run
+	SourceExpenseID *uint `json:"source_expense_id" gorm:"index"`
+
+	// Progress
+	NextSequence    int        `json:"next_sequence" gorm:"default:0"` // Index of the next scheduled date, 0 = StartDate
+	NextRunDate     *time.Time `json:"next_run_date" gorm:"index"`
+	LastRunDate     *time.Time `json:"last_run_date"`
+	OccurrenceCount int        `json:"occurrence_count" gorm:"default:0"`            // Entries actually generated
+	Status          string     `json:"status" gorm:"size:20;default:'ACTIVE';index"` // ACTIVE, PAUSED, COMPLETED
+	FailureCount    int        `json:"failure_count" gorm:"default:0"`               // Failed attempts on NextRunDate in a row
+	LastError       string     `json:"last_error" gorm:"type:text"`                  // Why the last attempt failed (and the template was paused)
+
+	CreatedBy uint           `json:"created_by" gorm:"not null;index"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Relations
+	Lines         []RecurringTemplateLine `json:"lines" gorm:"foreignKey:TemplateID"`
+	SourceExpense *Expense                `json:"source_expense,omitempty" gorm:"foreignKey:SourceExpenseID"`
+}
+
+type RecurringTemplateLine struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	TemplateID   uint      `json:"template_id" gorm:"not null;index"`
+	AccountID    uint      `json:"account_id" gorm:"not null;index"`
+	Description  string    `json:"description" gorm:"size:255"`
+	DebitAmount  float64   `json:"debit_amount" gorm:"type:decimal(20,2);default:0"`
+	CreditAmount float64   `json:"credit_amount" gorm:"type:decimal(20,2);default:0"`
+	CostCenterID *uint     `json:"cost_center_id"`
+	ProjectID    *uint     `json:"project_id"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+
+	// Relations
+	Account Account `json:"account" gorm:"foreignKey:AccountID"`
+}
+
+// RecurringRun is the generation history of a template. The unique (template_id, scheduled_date)
+// index makes every scheduled date produce at most one entry, also when missed runs are caught up.
+type RecurringRun struct {
+	ID             uint       `json:"id" gorm:"primaryKey"`
+	TemplateID     uint       `json:"template_id" gorm:"not null;uniqueIndex:idx_recurring_run_date"`
+	Sequence       int        `json:"sequence"`
+	ScheduledDate  time.Time  `json:"scheduled_date" gorm:"type:date;not null;uniqueIndex:idx_recurring_run_date"`
+	EntryDate      *time.Time `json:"entry_date"`                           // Date the journal was booked on (differs for released queued runs)
+	Status         string     `json:"status" gorm:"size:20;not null;index"` // GENERATED, POSTED, SKIPPED, QUEUED, FAILED
+	JournalEntryID *uint64    `json:"journal_entry_id" gorm:"index"`
+	ExpenseID      *uint      `json:"expense_id" gorm:"index"`
+	Message        string     `json:"message" gorm:"type:text"`
+	ProcessedBy    uint       `json:"processed_by"` // 0 = scheduler
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+
+	// Relations
+	Template RecurringTemplate `json:"-" gorm:"foreignKey:TemplateID"`
+}
+
+// Recurring template constants
+const (
+	RecurringTypeJournal = "JOURNAL"
+	RecurringTypeExpense = "EXPENSE"
+
+	RecurringFrequencyDaily   = "DAILY"
+	RecurringFrequencyWeekly  = "WEEKLY"
+	RecurringFrequencyMonthly = "MONTHLY"
+	RecurringFrequencyYearly  = "YEARLY"
+
+	RecurringClosedPeriodSkip  = "SKIP"
+	RecurringClosedPeriodQueue = "QUEUE"
+
+	RecurringStatusActive    = "ACTIVE"
+	RecurringStatusPaused    = "PAUSED"
+	RecurringStatusCompleted = "COMPLETED"
+
+	RecurringRunGenerated = "GENERATED" // Draft journal created
+	RecurringRunPosted    = "POSTED"
+	RecurringRunSkipped   = "SKIPPED"
+	RecurringRunQueued    = "QUEUED"
+	RecurringRunFailed    = "FAILED"
+)
+
+// RecurringTemplateRequest - Input template jurnal berulang
+type RecurringTemplateRequest struct {
+	Name               string                         `json:"name" binding:"required"`
+	Description        string                         `json:"description"`
+	Frequency          string                         `json:"frequency" binding:"required,oneof=DAILY WEEKLY MONTHLY YEARLY"`
+	IntervalCount      int                            `json:"interval_count"`
+	StartDate          time.Time                      `json:"start_date" binding:"required"`
+	EndDate            *time.Time                     `json:"end_date"`
+	MaxOccurrences     *int                           `json:"max_occurrences"`
+	AutoPost           bool                           `json:"auto_post"`
+	ClosedPeriodAction string                         `json:"closed_period_action"`
+	Lines              []RecurringTemplateLineRequest `json:"lines" binding:"required,min=2,dive"`
+}
+
+type RecurringTemplateLineRequest struct {
+	AccountID    uint    `json:"account_id" binding:"required"`
+	Description  string  `json:"description"`
+	DebitAmount  float64 `json:"debit_amount" binding:"min=0"`
+	CreditAmount float64 `json:"credit_amount" binding:"min=0"`
+	CostCenterID *uint   `json:"cost_center_id"`
+	ProjectID    *uint   `json:"project_id"`
+}
+
+// RecurringExpenseRequest - Jadikan pengeluaran sebagai pengeluaran berulang
+type RecurringExpenseRequest struct {
+	CreditAccountID    uint       `json:"credit_account_id" binding:"required"` // Cash/bank or accrued liability account
+	Frequency          string     `json:"frequency" binding:"required,oneof=DAILY WEEKLY MONTHLY YEARLY"`
+	IntervalCount      int        `json:"interval_count"`
+	StartDate          time.Time  `json:"start_date" binding:"required"`
+	EndDate            *time.Time `json:"end_date"`
+	MaxOccurrences     *int       `json:"max_occurrences"`
+	AutoPost           bool       `json:"auto_post"`
+	ClosedPeriodAction string     `json:"closed_period_action"`
+}
+
+// RecurringRunReleaseRequest - Posting run yang tertunda (periode tertutup) pada tanggal periode terbuka
+type RecurringRunReleaseRequest struct {
+	EntryDate time.Time `json:"entry_date" binding:"required"`
+}
@@ -66,6 +66,11 @@ type Sale struct {
 	Notes              string          `json:"notes" gorm:"type:text"`
 	InternalNotes      string          `json:"internal_notes" gorm:"type:text"`
 	Reference          string          `json:"reference" gorm:"size:100"`
+	CreditApprovalStatus    string     `json:"credit_approval_status" gorm:"size:20"` // PENDING, APPROVED, REJECTED; empty = not required
+	CreditApprovalRequestID *uint      `json:"credit_approval_request_id" gorm:"index"`
+	DeliveryStatus     string          `json:"delivery_status" gorm:"size:20"` // PARTIAL, DELIVERED; empty = nothing shipped
+	BackorderOfID      *uint           `json:"backorder_of_id" gorm:"index"`   // Sale this order was split from when it was invoiced
+	QuoteID            *uint           `json:"quote_id" gorm:"index"`          // Quote this sale was converted from
 	CreatedAt          time.Time       `json:"created_at"`
 	UpdatedAt          time.Time       `json:"updated_at"`
 	DeletedAt          gorm.DeletedAt  `json:"-" gorm:"index"`
@@ -108,6 +113,12 @@ type SaleItem struct {
 	Tax              float64        `json:"tax" gorm:"type:decimal(15,2);default:0;->"`           // Read-only: Legacy field
 	RevenueAccountID uint           `json:"revenue_account_id" gorm:"index"`
 	TaxAccountID     *uint          `json:"tax_account_id" gorm:"index"`
+	WarehouseLocationID *uint       `json:"warehouse_location_id" gorm:"index"` // Gudang asal barang; kosong = gudang default produk
+	DeliveredQuantity int           `json:"delivered_quantity" gorm:"default:0"` // Shipped on delivery orders
+	BackorderQuantity int           `json:"backorder_quantity" gorm:"default:0"` // Ordered but still waiting to be shipped
+	CostCenterID     *uint          `json:"cost_center_id" gorm:"index"`
+	ProjectID        *uint          `json:"project_id" gorm:"index"`
+	TagIDs           []uint         `json:"tag_ids,omitempty" gorm:"-"` // Stored in dimension_tags (owner SALE_ITEM)
 	CreatedAt      time.Time      `json:"created_at"`
 	UpdatedAt      time.Time      `json:"updated_at"`
 	DeletedAt      gorm.DeletedAt `json:"-" gorm:"index"`
@@ -147,6 +158,8 @@ type SalesFilter struct {
 	Search     string `json:"search"`
 	Page       int    `json:"page"`
 	Limit      int    `json:"limit"`
+
+	Scope *EffectiveDataScope `json:"-"` // Data scope of the requesting user, nil = unrestricted
 }
 
 type SaleCreateRequest struct {
@@ -232,6 +245,10 @@ type SaleItemRequest struct {
 	Taxable          *bool    `json:"taxable"`
 	RevenueAccountID uint     `json:"revenue_account_id"`
 	TaxAccountID     *uint    `json:"tax_account_id"`
+	WarehouseLocationID *uint `json:"warehouse_location_id"`
+	CostCenterID     *uint    `json:"cost_center_id"`
+	ProjectID        *uint    `json:"project_id"`
+	TagIDs           []uint   `json:"tag_ids"`
 }
 
 // Return related to a Sale
@@ -348,9 +365,12 @@ type ReceivableItem struct {
 }
 
 type ReceivablesReportResponse struct {
-	TotalOutstanding float64          `json:"total_outstanding"`
-	OverdueAmount    float64          `json:"overdue_amount"`
-	Receivables      []ReceivableItem `json:"receivables"`
+	TotalOutstanding float64             `json:"total_outstanding"`
+	OverdueAmount    float64             `json:"overdue_amount"`
+	Receivables      []ReceivableItem    `json:"receivables"`
+	OpenCredits      []AdvanceCreditItem `json:"open_credits"` // Unapplied customer down payments
+	TotalOpenCredits float64             `json:"total_open_credits"`
+	NetOutstanding   float64             `json:"net_outstanding"` // TotalOutstanding less TotalOpenCredits
 }
 
 // Payment and Return DTOs
@@ -363,6 +383,7 @@ type SalePaymentRequest struct {
 	Notes         string    `json:"notes"`
 	CashBankID    *uint     `json:"cash_bank_id"` // Add cashbank integration
 	AccountID     *uint     `json:"account_id"`   // Add account integration
+	ExchangeRate  *float64  `json:"exchange_rate"` // Settlement rate for foreign currency invoices; nil = rate table
 }
 
 type SaleReturnRequest struct {
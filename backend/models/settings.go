@@ -53,11 +53,32 @@ type Settings struct {
 	JournalPrefix         string `json:"journal_prefix" gorm:"default:'JE'"`
 	JournalNextNumber     int    `json:"journal_next_number" gorm:"default:1"`
 	RequireJournalApproval bool  `json:"require_journal_approval" gorm:"default:false"`
+
+	// Credit Control Settings
+	CreditOverdueDays   int    `json:"credit_overdue_days" gorm:"default:0"`                 // Hold sales of customers with invoices overdue more than N days; 0 = off
+	CreditControlAction string `json:"credit_control_action" gorm:"size:20;default:'BLOCK'"` // BLOCK or APPROVAL
+
+	// Email (SMTP) Settings
+	EmailEnabled     bool   `json:"email_enabled" gorm:"default:false"`
+	SMTPHost         string `json:"smtp_host" gorm:"size:255"`
+	SMTPPort         int    `json:"smtp_port" gorm:"default:587"`
+	SMTPUsername     string `json:"smtp_username" gorm:"size:255"`
+	SMTPPassword     string `json:"-" gorm:"size:255"`                                 // Write-only through smtp_password, never returned
+	SMTPEncryption   string `json:"smtp_encryption" gorm:"size:20;default:'STARTTLS'"` // NONE, STARTTLS or SSL
+	EmailFromAddress string `json:"email_from_address" gorm:"size:255"`
+	EmailFromName    string `json:"email_from_name" gorm:"size:255"`
 	
 	// Additional Settings
 	UpdatedBy uint `json:"updated_by"` // User ID who last updated
 }
 
+// SMTP encryption modes
+const (
+	SMTPEncryptionNone     = "NONE"     // Plain SMTP, e.g. a local SMTP sink during development and tests
+	SMTPEncryptionStartTLS = "STARTTLS" // Upgrade with STARTTLS, usually port 587
+	SMTPEncryptionSSL      = "SSL"      // Implicit TLS, usually port 465
+)
+
 // TableName overrides the table name
 func (Settings) TableName() string {
 	return "settings"
@@ -110,6 +131,20 @@ type SettingsResponse struct {
 	JournalPrefix          string `json:"journal_prefix"`
 	JournalNextNumber      int    `json:"journal_next_number"`
 	RequireJournalApproval bool   `json:"require_journal_approval"`
+
+	// Credit Control Settings
+	CreditOverdueDays   int    `json:"credit_overdue_days"`
+	CreditControlAction string `json:"credit_control_action"`
+
+	// Email (SMTP) Settings
+	EmailEnabled     bool   `json:"email_enabled"`
+	SMTPHost         string `json:"smtp_host"`
+	SMTPPort         int    `json:"smtp_port"`
+	SMTPUsername     string `json:"smtp_username"`
+	SMTPPasswordSet  bool   `json:"smtp_password_set"`
+	SMTPEncryption   string `json:"smtp_encryption"`
+	EmailFromAddress string `json:"email_from_address"`
+	EmailFromName    string `json:"email_from_name"`
 	
 }
 
@@ -148,5 +183,15 @@ func (s *Settings) ToResponse() SettingsResponse {
 		JournalPrefix:          s.JournalPrefix,
 		JournalNextNumber:      s.JournalNextNumber,
 		RequireJournalApproval: s.RequireJournalApproval,
+		CreditOverdueDays:      s.CreditOverdueDays,
+		CreditControlAction:    s.CreditControlAction,
+		EmailEnabled:           s.EmailEnabled,
+		SMTPHost:               s.SMTPHost,
+		SMTPPort:               s.SMTPPort,
+		SMTPUsername:           s.SMTPUsername,
+		SMTPPasswordSet:        s.SMTPPassword != "",
+		SMTPEncryption:         s.SMTPEncryption,
+		EmailFromAddress:       s.EmailFromAddress,
+		EmailFromName:          s.EmailFromName,
 	}
 }
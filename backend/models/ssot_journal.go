@@ -81,6 +81,17 @@ type SSOTJournalLine struct {
 	Quantity     *decimal.Decimal `json:"quantity,omitempty" gorm:"type:decimal(15,4)"`
 	UnitPrice    *decimal.Decimal `json:"unit_price,omitempty" gorm:"type:decimal(15,4)"`
 	
+	// Foreign Currency Information (Debit/Credit above are always in base currency)
+	CurrencyCode        string          `json:"currency_code" gorm:"size:5;not null;default:IDR"`
+	ForeignDebitAmount  decimal.Decimal `json:"foreign_debit_amount" gorm:"type:decimal(20,2);not null;default:0"`
+	ForeignCreditAmount decimal.Decimal `json:"foreign_credit_amount" gorm:"type:decimal(20,2);not null;default:0"`
+	ExchangeRate        decimal.Decimal `json:"exchange_rate" gorm:"type:decimal(18,6);not null;default:1"`
+	
+	// Analytic Dimensions (tags are stored in dimension_tags with owner JOURNAL_LINE)
+	CostCenterID *uint           `json:"cost_center_id" gorm:"index"`
+	ProjectID    *uint           `json:"project_id" gorm:"index"`
+	TagIDs       []uint          `json:"tag_ids,omitempty" gorm:"-"`
+	
 	// Audit Fields
 	CreatedAt    time.Time       `json:"created_at" gorm:"not null;default:CURRENT_TIMESTAMP"`
 	UpdatedAt    time.Time       `json:"updated_at" gorm:"not null;default:CURRENT_TIMESTAMP"`
@@ -88,6 +99,8 @@ type SSOTJournalLine struct {
 	// Relations
 	Journal      *SSOTJournalEntry `json:"journal,omitempty" gorm:"foreignKey:JournalID"`
 	Account      *Account         `json:"account,omitempty" gorm:"foreignKey:AccountID"`
+	CostCenter   *Dimension       `json:"cost_center,omitempty" gorm:"foreignKey:CostCenterID"`
+	Project      *Dimension       `json:"project,omitempty" gorm:"foreignKey:ProjectID"`
 }
 
 // SSOTJournalEventLog represents audit trail for journal events
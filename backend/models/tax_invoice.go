@@ -0,0 +1,135 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// TaxInvoiceNumberRange is a block of tax invoice serial numbers (NSFP) allocated by DJP.
+// A 13 digit NSFP is the 5 digit prefix (branch + year) followed by an 8 digit serial.
+type TaxInvoiceNumberRange struct {
+	ID            uint           `json:"id" gorm:"primaryKey"`
+	Year          int            `json:"year" gorm:"not null;index"`
+	Prefix        string         `json:"prefix" gorm:"size:5;not null"`
+	StartSerial   int64          `json:"start_serial" gorm:"not null"`
+	EndSerial     int64          `json:"end_serial" gorm:"not null"`
+	NextSerial    int64          `json:"next_serial" gorm:"not null"`
+	Status        string         `json:"status" gorm:"size:20;not null;default:'ACTIVE';index"` // ACTIVE, EXHAUSTED, CLOSED
+	AllocationRef string         `json:"allocation_ref" gorm:"size:100"`                        // DJP allocation letter
+	Notes         string         `json:"notes" gorm:"type:text"`
+	CreatedBy     uint           `json:"created_by" gorm:"not null"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Computed on load
+	StartNumber     string `json:"start_number" gorm:"-"`
+	EndNumber       string `json:"end_number" gorm:"-"`
+	RemainingNumber int64  `json:"remaining_numbers" gorm:"-"`
+}
+
+// AfterFind fills the formatted numbers of the range
+func (r *TaxInvoiceNumberRange) AfterFind(tx *gorm.DB) (err error) {
+	r.StartNumber = FormatNSFP(r.Prefix, r.StartSerial)
+	r.EndNumber = FormatNSFP(r.Prefix, r.EndSerial)
+	r.RemainingNumber = r.EndSerial - r.NextSerial + 1
+	if r.RemainingNumber < 0 {
+		r.RemainingNumber = 0
+	}
+	return
+}
+
+// TaxInvoice is an e-Faktur record: OUTPUT invoices are numbered from an NSFP range for sales,
+// INPUT invoices are vendor tax invoices recorded or imported for purchases.
+type TaxInvoice struct {
+	ID              uint      `json:"id" gorm:"primaryKey"`
+	Direction       string    `json:"direction" gorm:"size:10;not null;uniqueIndex:idx_tax_invoice_number"` // OUTPUT (PPN Keluaran), INPUT (PPN Masukan)
+	Number          string    `json:"number" gorm:"size:13;not null;uniqueIndex:idx_tax_invoice_number"`    // 13 digit NSFP
+	TransactionCode string    `json:"transaction_code" gorm:"size:2;not null;default:'01'"`                 // KD_JENIS_TRANSAKSI
+	ReplacementFlag int       `json:"replacement_flag" gorm:"default:0;uniqueIndex:idx_tax_invoice_number"` // FG_PENGGANTI
+	InvoiceDate     time.Time `json:"invoice_date" gorm:"type:date;not null;index"`
+	TaxPeriodMonth  int       `json:"tax_period_month" gorm:"not null;index:idx_tax_invoice_period"`
+	TaxPeriodYear   int       `json:"tax_period_year" gorm:"not null;index:idx_tax_invoice_period"`
+	RangeID         *uint     `json:"range_id" gorm:"index"`
+	SaleID          *uint     `json:"sale_id" gorm:"index"`
+	PurchaseID      *uint     `json:"purchase_id" gorm:"index"`
+	Reference       string    `json:"reference" gorm:"size:50"` // Sale or purchase code
+
+	// Counterparty (buyer for OUTPUT, seller for INPUT)
+	CounterpartyNPWP    string `json:"counterparty_npwp" gorm:"size:20"`
+	CounterpartyName    string `json:"counterparty_name" gorm:"size:100"`
+	CounterpartyAddress string `json:"counterparty_address" gorm:"type:text"`
+
+	// Amounts in base currency
+	DPP        float64 `json:"dpp" gorm:"type:decimal(15,2);default:0"`
+	PPN        float64 `json:"ppn" gorm:"type:decimal(15,2);default:0"`
+	PPnBM      float64 `json:"ppnbm" gorm:"type:decimal(15,2);default:0"`
+	Creditable bool    `json:"creditable" gorm:"default:true"` // INPUT only: IS_CREDITABLE
+
+	Status      string     `json:"status" gorm:"size:20;not null;default:'ACTIVE';index"` // ACTIVE, CANCELLED
+	MatchStatus string     `json:"match_status" gorm:"size:20"`                           // INPUT only: MATCHED, UNMATCHED
+	ExportedAt  *time.Time `json:"exported_at"`
+	CancelledAt *time.Time `json:"cancelled_at"`
+	CreatedBy   uint       `json:"created_by"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+
+	// Relations
+	Sale     *Sale     `json:"sale,omitempty" gorm:"foreignKey:SaleID"`
+	Purchase *Purchase `json:"purchase,omitempty" gorm:"foreignKey:PurchaseID"`
+}
+
+// Tax invoice constants
+const (
+	TaxInvoiceOutput = "OUTPUT"
+	TaxInvoiceInput  = "INPUT"
+
+	TaxInvoiceStatusActive    = "ACTIVE"
+	TaxInvoiceStatusCancelled = "CANCELLED"
+
+	TaxInvoiceMatched   = "MATCHED"
+	TaxInvoiceUnmatched = "UNMATCHED"
+
+	TaxInvoiceRangeActive    = "ACTIVE"
+	TaxInvoiceRangeExhausted = "EXHAUSTED"
+	TaxInvoiceRangeClosed    = "CLOSED"
+)
+
+// FormatNSFP builds the 13 digit tax invoice number from its prefix and serial
+func FormatNSFP(prefix string, serial int64) string {
+	return fmt.Sprintf("%s%08d", prefix, serial)
+}
+
+// TaxInvoiceRangeRequest - Input rentang NSFP dari DJP
+type TaxInvoiceRangeRequest struct {
+	StartNumber   string `json:"start_number" binding:"required"` // 13 digits, punctuation allowed
+	EndNumber     string `json:"end_number" binding:"required"`
+	AllocationRef string `json:"allocation_ref"`
+	Notes         string `json:"notes"`
+}
+
+// TaxInvoiceAllocateRequest - Alokasi nomor faktur pajak untuk penjualan
+type TaxInvoiceAllocateRequest struct {
+	TransactionCode string     `json:"transaction_code"` // Defaults to 01
+	InvoiceDate     *time.Time `json:"invoice_date"`     // Defaults to the sale date
+}
+
+// PurchaseTaxInvoiceRequest - Input faktur pajak masukan dari vendor
+type PurchaseTaxInvoiceRequest struct {
+	Number          string    `json:"number" binding:"required"`
+	TransactionCode string    `json:"transaction_code"`
+	ReplacementFlag int       `json:"replacement_flag"`
+	InvoiceDate     time.Time `json:"invoice_date" binding:"required"`
+	TaxPeriodMonth  int       `json:"tax_period_month"` // Defaults to the invoice month
+	TaxPeriodYear   int       `json:"tax_period_year"`
+	DPP             *float64  `json:"dpp"` // Defaults to the purchase amounts
+	PPN             *float64  `json:"ppn"`
+	Creditable      *bool     `json:"creditable"`
+}
+
+// TaxInvoiceMatchRequest - Cocokkan faktur masukan hasil impor dengan pembelian
+type TaxInvoiceMatchRequest struct {
+	PurchaseID uint `json:"purchase_id" binding:"required"`
+}
@@ -0,0 +1,137 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// UserTwoFactor holds a user's TOTP (RFC 6238) enrolment. The secret is stored encrypted and only
+// becomes active once the first code has been confirmed.
+type UserTwoFactor struct {
+	ID              uint       `json:"id" gorm:"primaryKey"`
+	UserID          uint       `json:"user_id" gorm:"uniqueIndex;not null"`
+	SecretEncrypted string     `json:"-" gorm:"type:text;not null"`
+	IsEnabled       bool       `json:"is_enabled" gorm:"default:false"`
+	EnabledAt       *time.Time `json:"enabled_at"`
+	LastUsedStep    int64      `json:"-" gorm:"default:0"` // Last accepted TOTP time step, blocks code replay
+	FailedAttempts  int        `json:"failed_attempts" gorm:"default:0"`
+	LockedUntil     *time.Time `json:"locked_until"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+
+	// Relations
+	User User `json:"-" gorm:"foreignKey:UserID"`
+}
+
+// TwoFactorRecoveryCode is a single-use backup code, stored as a bcrypt hash
+type TwoFactorRecoveryCode struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	UserID    uint       `json:"user_id" gorm:"not null;index"`
+	CodeHash  string     `json:"-" gorm:"size:100;not null"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// TwoFactorChallenge is the short-lived ticket handed out after a correct password when a code is
+// still needed to finish the login. Users of roles where 2FA is mandatory who have not enrolled yet
+// get an ENROLLMENT ticket instead, which can only be used to set up the authenticator.
+type TwoFactorChallenge struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	TokenHash  string     `json:"-" gorm:"uniqueIndex;size:64;not null"`
+	UserID     uint       `json:"user_id" gorm:"not null;index"`
+	Purpose    string     `json:"purpose" gorm:"size:20;not null;default:'LOGIN'"` // LOGIN or ENROLLMENT
+	DeviceInfo string     `json:"device_info" gorm:"size:500"`
+	IPAddress  string     `json:"ip_address" gorm:"size:45"`
+	UserAgent  string     `json:"user_agent" gorm:"type:text"`
+	Attempts   int        `json:"attempts" gorm:"default:0"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	ConsumedAt *time.Time `json:"consumed_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// Two-factor challenge purposes
+const (
+	TwoFactorChallengeLogin      = "LOGIN"
+	TwoFactorChallengeEnrollment = "ENROLLMENT"
+)
+
+// TrustedDevice lets a browser skip the login code for a limited time. It remembers the user
+// session it was trusted from; revoking the device also ends that session.
+type TrustedDevice struct {
+	ID            uint           `json:"id" gorm:"primaryKey"`
+	UserID        uint           `json:"user_id" gorm:"not null;index"`
+	TokenHash     string         `json:"-" gorm:"uniqueIndex;size:64;not null"`
+	SessionID     string         `json:"session_id" gorm:"size:255;index"` // Session the device was trusted from
+	LastSessionID string         `json:"last_session_id" gorm:"size:255"`  // Latest session opened with it
+	DeviceInfo    string         `json:"device_info" gorm:"size:500"`
+	IPAddress     string         `json:"ip_address" gorm:"size:45"`
+	UserAgent     string         `json:"user_agent" gorm:"type:text"`
+	ExpiresAt     time.Time      `json:"expires_at"`
+	LastUsedAt    *time.Time     `json:"last_used_at"`
+	RevokedAt     *time.Time     `json:"revoked_at"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// TwoFactorStatus summarises a user's 2FA setup
+type TwoFactorStatus struct {
+	Enabled            bool       `json:"enabled"`
+	Required           bool       `json:"required"` // Mandatory for the user's role
+	EnabledAt          *time.Time `json:"enabled_at"`
+	RecoveryCodesLeft  int64      `json:"recovery_codes_left"`
+	TrustedDevices     int64      `json:"trusted_devices"`
+	LockedUntil        *time.Time `json:"locked_until"`
+	StepUpVerifiedAt   *time.Time `json:"step_up_verified_at"` // Of the current session
+	StepUpValidSeconds int64      `json:"step_up_valid_seconds"`
+}
+
+// TwoFactorEnrollment is returned when enrolment starts; the otpauth URL is rendered as a QR code
+type TwoFactorEnrollment struct {
+	Secret     string `json:"secret"`
+	OTPAuthURL string `json:"otpauth_url"`
+	Issuer     string `json:"issuer"`
+	Account    string `json:"account"`
+	Digits     int    `json:"digits"`
+	Period     int    `json:"period"`
+	Algorithm  string `json:"algorithm"`
+}
+
+// TwoFactorCodeRequest carries a TOTP or recovery code
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// TwoFactorLoginRequest finishes a login that is waiting for a code
+type TwoFactorLoginRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code" binding:"required"`
+	TrustDevice    bool   `json:"trust_device"`
+}
+
+// TwoFactorEnrollmentTokenRequest starts the enrolment a login handed out instead of tokens
+type TwoFactorEnrollmentTokenRequest struct {
+	EnrollmentToken string `json:"enrollment_token" binding:"required"`
+}
+
+// TwoFactorEnrollmentLoginRequest confirms that enrolment with the first code and finishes the login
+type TwoFactorEnrollmentLoginRequest struct {
+	EnrollmentToken string `json:"enrollment_token" binding:"required"`
+	Code            string `json:"code" binding:"required"`
+}
+
+// TwoFactorResetRequest lets an administrator remove another user's 2FA
+type TwoFactorResetRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+// Incident types raised by two-factor authentication
+const (
+	IncidentTypeTwoFactorEnrolled     = "TWO_FACTOR_ENROLLED"
+	IncidentTypeTwoFactorDisabled     = "TWO_FACTOR_DISABLED"
+	IncidentTypeTwoFactorReset        = "TWO_FACTOR_RESET"
+	IncidentTypeTwoFactorFailed       = "TWO_FACTOR_FAILED"
+	IncidentTypeTwoFactorLocked       = "TWO_FACTOR_LOCKED"
+	IncidentTypeTwoFactorRecoveryUsed = "TWO_FACTOR_RECOVERY_USED"
+)
@@ -17,6 +17,7 @@ type User struct {
 	Address      string         `json:"address" gorm:"type:text"`
 	Department   string         `json:"department" gorm:"size:50"`
 	Position     string         `json:"position" gorm:"size:50"`
+	ManagerID    *uint          `json:"manager_id" gorm:"index"` // Approves MANAGER approval steps
 	HireDate     *time.Time     `json:"hire_date"`
 	Salary       float64        `json:"-" gorm:"type:decimal(15,2)"`
 	IsActive     bool           `json:"is_active" gorm:"default:true"`
@@ -44,8 +45,9 @@ func (u *User) GetDisplayName() string {
 }
 
 type LoginRequest struct {
-	Email    string `json:"email" binding:"required"`
-	Password string `json:"password" binding:"required"`
+	Email       string `json:"email" binding:"required"`
+	Password    string `json:"password" binding:"required"`
+	DeviceToken string `json:"device_token"` // Trusted device token from an earlier two-factor login
 }
 
 type RegisterRequest struct {
@@ -0,0 +1,259 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// VendorPriceList is a vendor's price for a product, optionally tiered by minimum quantity and
+// limited to a validity period. New purchase items default to the best valid entry.
+type VendorPriceList struct {
+	ID           uint           `json:"id" gorm:"primaryKey"`
+	VendorID     uint           `json:"vendor_id" gorm:"not null;index:idx_vendor_price_lookup"`
+	ProductID    uint           `json:"product_id" gorm:"not null;index:idx_vendor_price_lookup"`
+	Unit         string         `json:"unit" gorm:"size:20"` // Empty = product unit
+	MinQuantity  int            `json:"min_quantity" gorm:"not null;default:1"`
+	Price        float64        `json:"price" gorm:"type:decimal(15,2);not null"`
+	ValidFrom    time.Time      `json:"valid_from" gorm:"not null"`
+	ValidTo      *time.Time     `json:"valid_to"` // Empty = open ended
+	LeadTimeDays int            `json:"lead_time_days" gorm:"default:0"`
+	IsActive     bool           `json:"is_active" gorm:"default:true"`
+	Source       string         `json:"source" gorm:"size:20;default:'MANUAL'"` // MANUAL or RFQ
+	RFQID        *uint          `json:"rfq_id" gorm:"index"`
+	Notes        string         `json:"notes" gorm:"type:text"`
+	UserID       uint           `json:"user_id" gorm:"not null;index"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Relations
+	Vendor  Contact `json:"vendor" gorm:"foreignKey:VendorID"`
+	Product Product `json:"product" gorm:"foreignKey:ProductID"`
+}
+
+// Vendor price list sources
+const (
+	VendorPriceSourceManual = "MANUAL"
+	VendorPriceSourceRFQ    = "RFQ"
+)
+
+// RequestForQuotation asks several vendors to quote the same products so their replies can be compared
+type RequestForQuotation struct {
+	ID               uint           `json:"id" gorm:"primaryKey"`
+	Code             string         `json:"code" gorm:"unique;not null;size:30"`
+	Date             time.Time      `json:"date"`
+	ResponseDeadline time.Time      `json:"response_deadline"`
+	Status           string         `json:"status" gorm:"size:20;default:'DRAFT';index"`
+	RequisitionID    *uint          `json:"requisition_id" gorm:"index"` // Optional source requisition
+	Notes            string         `json:"notes" gorm:"type:text"`
+	UserID           uint           `json:"user_id" gorm:"not null;index"`
+	SentAt           *time.Time     `json:"sent_at"`
+	ClosedAt         *time.Time     `json:"closed_at"`
+	CreatedAt        time.Time      `json:"created_at"`
+	UpdatedAt        time.Time      `json:"updated_at"`
+	DeletedAt        gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Relations
+	User    User        `json:"user" gorm:"foreignKey:UserID"`
+	Items   []RFQItem   `json:"items" gorm:"foreignKey:RFQID"`
+	Vendors []RFQVendor `json:"vendors" gorm:"foreignKey:RFQID"`
+}
+
+// TableName keeps the RFQ tables under one prefix
+func (RequestForQuotation) TableName() string {
+	return "rfqs"
+}
+
+// RFQItem is one product the vendors are asked to quote
+type RFQItem struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	RFQID       uint      `json:"rfq_id" gorm:"not null;index"`
+	ProductID   uint      `json:"product_id" gorm:"not null;index"`
+	Description string    `json:"description" gorm:"type:text"`
+	Quantity    int       `json:"quantity" gorm:"not null"`
+	Unit        string    `json:"unit" gorm:"size:20"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+
+	// Relations
+	Product Product `json:"product" gorm:"foreignKey:ProductID"`
+}
+
+// RFQVendor is one vendor invited to an RFQ together with its reply
+type RFQVendor struct {
+	ID           uint       `json:"id" gorm:"primaryKey"`
+	RFQID        uint       `json:"rfq_id" gorm:"not null;index"`
+	VendorID     uint       `json:"vendor_id" gorm:"not null;index"`
+	Status       string     `json:"status" gorm:"size:20;default:'INVITED'"`
+	RespondedAt  *time.Time `json:"responded_at"`
+	QuoteRef     string     `json:"quote_ref" gorm:"size:100"` // Vendor's own quotation number
+	ValidUntil   *time.Time `json:"valid_until"`
+	LeadTimeDays int        `json:"lead_time_days" gorm:"default:0"`
+	Notes        string     `json:"notes" gorm:"type:text"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+
+	// Relations
+	Vendor  Contact    `json:"vendor" gorm:"foreignKey:VendorID"`
+	Replies []RFQReply `json:"replies" gorm:"foreignKey:RFQVendorID"`
+}
+
+// RFQReply is the price a vendor quoted for one RFQ item
+type RFQReply struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	RFQVendorID  uint      `json:"rfq_vendor_id" gorm:"not null;index"`
+	RFQItemID    uint      `json:"rfq_item_id" gorm:"not null;index"`
+	UnitPrice    float64   `json:"unit_price" gorm:"type:decimal(15,2);not null"`
+	Quantity     int       `json:"quantity"` // Quantity the vendor can supply; 0 = as requested
+	LeadTimeDays int       `json:"lead_time_days" gorm:"default:0"`
+	Notes        string    `json:"notes" gorm:"type:text"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// RFQ statuses
+const (
+	RFQStatusDraft     = "DRAFT"
+	RFQStatusSent      = "SENT"
+	RFQStatusClosed    = "CLOSED"
+	RFQStatusCancelled = "CANCELLED"
+)
+
+// RFQ vendor statuses
+const (
+	RFQVendorStatusInvited   = "INVITED"
+	RFQVendorStatusResponded = "RESPONDED"
+	RFQVendorStatusDeclined  = "DECLINED"
+	RFQVendorStatusAwarded   = "AWARDED"
+)
+
+// VendorPriceListRequest creates or updates a price list entry
+type VendorPriceListRequest struct {
+	VendorID     uint       `json:"vendor_id" binding:"required"`
+	ProductID    uint       `json:"product_id" binding:"required"`
+	Unit         string     `json:"unit"`
+	MinQuantity  int        `json:"min_quantity" binding:"gte=0"` // Empty = 1
+	Price        float64    `json:"price" binding:"required,gt=0"`
+	ValidFrom    time.Time  `json:"valid_from" binding:"required"`
+	ValidTo      *time.Time `json:"valid_to"`
+	LeadTimeDays int        `json:"lead_time_days" binding:"gte=0"`
+	IsActive     *bool      `json:"is_active"` // Empty = active
+	Notes        string     `json:"notes"`
+}
+
+// VendorPriceFilter narrows the price list
+type VendorPriceFilter struct {
+	VendorID  uint
+	ProductID uint
+	ValidOn   *time.Time
+	Active    *bool
+}
+
+// BestVendorPrice is the cheapest valid price list entry for a product and quantity
+type BestVendorPrice struct {
+	ProductID    uint      `json:"product_id"`
+	VendorID     uint      `json:"vendor_id"`
+	VendorName   string    `json:"vendor_name"`
+	PriceListID  uint      `json:"price_list_id"`
+	Unit         string    `json:"unit"`
+	MinQuantity  int       `json:"min_quantity"`
+	Price        float64   `json:"price"`
+	LeadTimeDays int       `json:"lead_time_days"`
+	Date         time.Time `json:"date"`
+}
+
+// RFQRequest creates or replaces a draft RFQ
+type RFQRequest struct {
+	Date             time.Time        `json:"date" binding:"required"`
+	ResponseDeadline time.Time        `json:"response_deadline" binding:"required"`
+	RequisitionID    *uint            `json:"requisition_id"`
+	Notes            string           `json:"notes"`
+	VendorIDs        []uint           `json:"vendor_ids" binding:"required,min=1"`
+	Items            []RFQItemRequest `json:"items" binding:"required,min=1,dive"`
+}
+
+// RFQItemRequest is one product to be quoted
+type RFQItemRequest struct {
+	ProductID   uint   `json:"product_id" binding:"required"`
+	Description string `json:"description"`
+	Quantity    int    `json:"quantity" binding:"required,gt=0"`
+	Unit        string `json:"unit"` // Empty = product unit
+}
+
+// RFQReplyRequest records a vendor's answer to an RFQ
+type RFQReplyRequest struct {
+	Declined     bool                  `json:"declined"`
+	QuoteRef     string                `json:"quote_ref"`
+	ValidUntil   *time.Time            `json:"valid_until"`
+	LeadTimeDays int                   `json:"lead_time_days" binding:"gte=0"`
+	Notes        string                `json:"notes"`
+	Items        []RFQReplyItemRequest `json:"items" binding:"dive"`
+}
+
+// RFQReplyItemRequest is the quoted price for one RFQ item
+type RFQReplyItemRequest struct {
+	RFQItemID    uint    `json:"rfq_item_id" binding:"required"`
+	UnitPrice    float64 `json:"unit_price" binding:"required,gt=0"`
+	Quantity     int     `json:"quantity" binding:"gte=0"`
+	LeadTimeDays int     `json:"lead_time_days" binding:"gte=0"`
+	Notes        string  `json:"notes"`
+}
+
+// RFQAwardRequest closes an RFQ in favour of one vendor
+type RFQAwardRequest struct {
+	VendorID        uint       `json:"vendor_id" binding:"required"`
+	SaveToPriceList bool       `json:"save_to_price_list"`
+	ValidTo         *time.Time `json:"valid_to"` // Empty = vendor's valid-until date
+}
+
+// RFQCancelRequest cancels an RFQ that has not been awarded
+type RFQCancelRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+// RFQComparison lays the vendor replies next to each other per item
+type RFQComparison struct {
+	RFQID   uint                  `json:"rfq_id"`
+	Code    string                `json:"code"`
+	Status  string                `json:"status"`
+	Vendors []RFQComparisonVendor `json:"vendors"`
+	Items   []RFQComparisonItem   `json:"items"`
+}
+
+// RFQComparisonVendor summarises one vendor's reply over all items
+type RFQComparisonVendor struct {
+	VendorID      uint    `json:"vendor_id"`
+	VendorName    string  `json:"vendor_name"`
+	Status        string  `json:"status"`
+	LeadTimeDays  int     `json:"lead_time_days"`
+	ItemsQuoted   int     `json:"items_quoted"`
+	TotalAmount   float64 `json:"total_amount"`    // Requested quantity x quoted price over quoted items
+	BestPriceWins int     `json:"best_price_wins"` // Items where this vendor is cheapest
+	IsComplete    bool    `json:"is_complete"`     // Quoted every item
+}
+
+// RFQComparisonItem is one RFQ item with every vendor's quote
+type RFQComparisonItem struct {
+	RFQItemID    uint                 `json:"rfq_item_id"`
+	ProductID    uint                 `json:"product_id"`
+	ProductName  string               `json:"product_name"`
+	Quantity     int                  `json:"quantity"`
+	Unit         string               `json:"unit"`
+	CurrentPrice float64              `json:"current_price"` // Product purchase price for reference
+	BestVendorID uint                 `json:"best_vendor_id"`
+	BestPrice    float64              `json:"best_price"`
+	Quotes       []RFQComparisonQuote `json:"quotes"`
+}
+
+// RFQComparisonQuote is one vendor's quote for one item
+type RFQComparisonQuote struct {
+	VendorID     uint    `json:"vendor_id"`
+	VendorName   string  `json:"vendor_name"`
+	UnitPrice    float64 `json:"unit_price"`
+	Quantity     int     `json:"quantity"`
+	LeadTimeDays int     `json:"lead_time_days"`
+	LineTotal    float64 `json:"line_total"`
+	IsBest       bool    `json:"is_best"`
+	DiffFromBest float64 `json:"diff_from_best_percent"`
+}
@@ -0,0 +1,84 @@
+package models
+
+import (
+	"time"
+	"gorm.io/gorm"
+)
+
+// WarehouseStock is the on-hand quantity of a product in one warehouse location.
+// Product.Stock remains the company-wide total across all warehouses.
+type WarehouseStock struct {
+	ID                  uint      `json:"id" gorm:"primaryKey"`
+	ProductID           uint      `json:"product_id" gorm:"not null;uniqueIndex:idx_warehouse_stock_product_location"`
+	WarehouseLocationID uint      `json:"warehouse_location_id" gorm:"not null;uniqueIndex:idx_warehouse_stock_product_location"`
+	Quantity            int       `json:"quantity" gorm:"not null;default:0"`
+	MinStock            int       `json:"min_stock" gorm:"default:0"`      // 0 = use product min_stock
+	ReorderLevel        int       `json:"reorder_level" gorm:"default:0"`  // 0 = use product reorder_level
+	CreatedAt           time.Time `json:"created_at"`
+	UpdatedAt           time.Time `json:"updated_at"`
+
+	// Relations
+	Product           Product           `json:"product" gorm:"foreignKey:ProductID"`
+	WarehouseLocation WarehouseLocation `json:"warehouse_location" gorm:"foreignKey:WarehouseLocationID"`
+}
+
+// EffectiveMinStock returns the warehouse threshold or the product's when not set
+func (ws *WarehouseStock) EffectiveMinStock(product *Product) int {
+	if ws.MinStock > 0 {
+		return ws.MinStock
+	}
+	return product.MinStock
+}
+
+// EffectiveReorderLevel returns the warehouse reorder level or the product's when not set
+func (ws *WarehouseStock) EffectiveReorderLevel(product *Product) int {
+	if ws.ReorderLevel > 0 {
+		return ws.ReorderLevel
+	}
+	return product.ReorderLevel
+}
+
+// StockTransfer moves stock between warehouse locations (DRAFT → IN_TRANSIT → RECEIVED)
+type StockTransfer struct {
+	ID              uint           `json:"id" gorm:"primaryKey"`
+	Code            string         `json:"code" gorm:"unique;not null;size:30"`
+	FromWarehouseID uint           `json:"from_warehouse_id" gorm:"not null;index"`
+	ToWarehouseID   uint           `json:"to_warehouse_id" gorm:"not null;index"`
+	TransferDate    time.Time      `json:"transfer_date"`
+	Status          string         `json:"status" gorm:"not null;size:20;default:'DRAFT'"` // DRAFT, IN_TRANSIT, RECEIVED, CANCELLED
+	Notes           string         `json:"notes" gorm:"type:text"`
+	CreatedBy       uint           `json:"created_by" gorm:"not null;index"`
+	ShippedBy       *uint          `json:"shipped_by"`
+	ShippedAt       *time.Time     `json:"shipped_at"`
+	ReceivedBy      *uint          `json:"received_by"`
+	ReceivedAt      *time.Time     `json:"received_at"`
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+	DeletedAt       gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Relations
+	FromWarehouse WarehouseLocation   `json:"from_warehouse" gorm:"foreignKey:FromWarehouseID"`
+	ToWarehouse   WarehouseLocation   `json:"to_warehouse" gorm:"foreignKey:ToWarehouseID"`
+	Items         []StockTransferItem `json:"items" gorm:"foreignKey:StockTransferID"`
+}
+
+type StockTransferItem struct {
+	ID              uint      `json:"id" gorm:"primaryKey"`
+	StockTransferID uint      `json:"stock_transfer_id" gorm:"not null;index"`
+	ProductID       uint      `json:"product_id" gorm:"not null;index"`
+	Quantity        int       `json:"quantity" gorm:"not null"`
+	Notes           string    `json:"notes" gorm:"type:text"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+
+	// Relations
+	Product Product `json:"product" gorm:"foreignKey:ProductID"`
+}
+
+// Stock Transfer Status Constants
+const (
+	StockTransferStatusDraft     = "DRAFT"
+	StockTransferStatusInTransit = "IN_TRANSIT"
+	StockTransferStatusReceived  = "RECEIVED"
+	StockTransferStatusCancelled = "CANCELLED"
+)
@@ -0,0 +1,174 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// WebhookSubscription is an external endpoint that receives accounting events. Every delivery is a
+// JSON POST signed with HMAC-SHA256 using Secret. Endpoints that keep failing are disabled automatically.
+type WebhookSubscription struct {
+	ID                  uint           `json:"id" gorm:"primaryKey"`
+	Name                string         `json:"name" gorm:"not null;size:100"`
+	URL                 string         `json:"url" gorm:"not null;size:500"`
+	Secret              string         `json:"-" gorm:"not null;size:100"`
+	Events              []string       `json:"events" gorm:"serializer:json;type:text"` // Event types, "*" or a prefix such as "sale.*"
+	Description         string         `json:"description" gorm:"type:text"`
+	IsActive            bool           `json:"is_active" gorm:"default:true;index"`
+	ConsecutiveFailures int            `json:"consecutive_failures" gorm:"default:0"` // Failed attempts since the last success
+	LastSuccessAt       *time.Time     `json:"last_success_at"`
+	LastFailureAt       *time.Time     `json:"last_failure_at"`
+	DisabledAt          *time.Time     `json:"disabled_at"`
+	DisabledReason      string         `json:"disabled_reason" gorm:"type:text"`
+	DisabledBy          string         `json:"disabled_by" gorm:"size:20"` // FAILURES or USER, empty while active
+	CreatedBy           uint           `json:"created_by"`
+	CreatedAt           time.Time      `json:"created_at"`
+	UpdatedAt           time.Time      `json:"updated_at"`
+	DeletedAt           gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// QueuesEvents reports whether events are still queued for the subscription: it is active, or the webhook
+// worker disabled it after repeated failures and the backlog goes out once it is enabled again. A
+// subscription paused by a user gets nothing until it is resumed.
+func (s *WebhookSubscription) QueuesEvents() bool {
+	return s.IsActive || s.DisabledBy == WebhookDisabledByFailures
+}
+
+// Subscribes reports whether the subscription's event filter includes the event type
+func (s *WebhookSubscription) Subscribes(eventType string) bool {
+	for _, filter := range s.Events {
+		switch {
+		case filter == "*" || filter == eventType:
+			return true
+		case strings.HasSuffix(filter, ".*") && strings.HasPrefix(eventType, strings.TrimSuffix(filter, "*")):
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookEvent is one accounting event in the webhook outbox. Business services record events inside
+// their transaction; the webhook worker fans each event out into a delivery per matching subscription.
+type WebhookEvent struct {
+	ID            uint       `json:"id" gorm:"primaryKey"`
+	EventUUID     string     `json:"event_uuid" gorm:"not null;size:36;uniqueIndex"`
+	EventType     string     `json:"event_type" gorm:"not null;size:50;index"`
+	EntityType    string     `json:"entity_type" gorm:"size:50;index:idx_webhook_event_entity"`
+	EntityID      *uint      `json:"entity_id" gorm:"index:idx_webhook_event_entity"`
+	Data          string     `json:"data" gorm:"type:jsonb;not null"`
+	CorrelationID string     `json:"correlation_id" gorm:"size:36;index"` // Journal transaction UUID when the event comes from the journal event log
+	SourceEventID *uint64    `json:"source_event_id" gorm:"uniqueIndex"`  // journal_event_log.id for journal events
+	Status        string     `json:"status" gorm:"not null;size:20;default:'PENDING';index"`
+	DispatchedAt  *time.Time `json:"dispatched_at"`
+	OccurredAt    time.Time  `json:"occurred_at" gorm:"not null;index"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// WebhookDelivery is one event sent to one subscription, retried with exponential backoff
+type WebhookDelivery struct {
+	ID             uint       `json:"id" gorm:"primaryKey"`
+	SubscriptionID uint       `json:"subscription_id" gorm:"not null;index"`
+	EventID        uint       `json:"event_id" gorm:"not null;index"`
+	EventType      string     `json:"event_type" gorm:"not null;size:50;index"`
+	Status         string     `json:"status" gorm:"not null;size:20;default:'PENDING';index"`
+	Attempts       int        `json:"attempts" gorm:"default:0"`
+	MaxAttempts    int        `json:"max_attempts" gorm:"default:8"`
+	NextAttemptAt  time.Time  `json:"next_attempt_at" gorm:"index"`
+	LastStatusCode int        `json:"last_status_code"`
+	LastError      string     `json:"last_error" gorm:"type:text"`
+	DeliveredAt    *time.Time `json:"delivered_at"`
+	ReplayOfID     *uint      `json:"replay_of_id" gorm:"index"` // Set when the delivery was replayed from the delivery log
+	ReplayedBy     *uint      `json:"replayed_by"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+
+	// Relations
+	Subscription *WebhookSubscription     `json:"subscription,omitempty" gorm:"foreignKey:SubscriptionID"`
+	Event        *WebhookEvent            `json:"event,omitempty" gorm:"foreignKey:EventID"`
+	AttemptLog   []WebhookDeliveryAttempt `json:"attempt_log,omitempty" gorm:"foreignKey:DeliveryID;constraint:OnDelete:CASCADE"`
+}
+
+// WebhookDeliveryAttempt is the delivery log entry of one HTTP attempt
+type WebhookDeliveryAttempt struct {
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	DeliveryID    uint      `json:"delivery_id" gorm:"not null;index"`
+	AttemptNumber int       `json:"attempt_number"`
+	StatusCode    int       `json:"status_code"`
+	Success       bool      `json:"success"`
+	Error         string    `json:"error" gorm:"type:text"`
+	ResponseBody  string    `json:"response_body" gorm:"type:text"` // Truncated
+	DurationMs    int64     `json:"duration_ms"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// WebhookCursor is the position of the webhook worker in an external event stream such as
+// journal_event_log, so that every source event is converted exactly once
+type WebhookCursor struct {
+	Name      string    `json:"name" gorm:"primaryKey;size:50"`
+	Position  uint64    `json:"position"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Webhook event types
+const (
+	WebhookEventSaleInvoiced    = "sale.invoiced"
+	WebhookEventPaymentReceived = "payment.received"
+	WebhookEventJournalPosted   = "journal.posted"
+	WebhookEventJournalReversed = "journal.reversed"
+	WebhookEventStockLow        = "stock.low"
+	WebhookEventPeriodClosed    = "period.closed"
+	WebhookEventPing            = "webhook.ping" // Sent by the test endpoint only
+)
+
+// WebhookEventTypes lists the event types a subscription can filter on
+var WebhookEventTypes = []string{
+	WebhookEventSaleInvoiced,
+	WebhookEventPaymentReceived,
+	WebhookEventJournalPosted,
+	WebhookEventJournalReversed,
+	WebhookEventStockLow,
+	WebhookEventPeriodClosed,
+}
+
+// Webhook event statuses
+const (
+	WebhookEventStatusPending    = "PENDING"    // Not yet fanned out to subscriptions
+	WebhookEventStatusDispatched = "DISPATCHED" // Deliveries created
+)
+
+// Webhook delivery statuses
+const (
+	WebhookDeliveryPending   = "PENDING"   // Waiting for the first or a retry attempt
+	WebhookDeliverySending   = "SENDING"   // Claimed by the webhook worker
+	WebhookDeliverySucceeded = "SUCCEEDED" // Endpoint answered 2xx
+	WebhookDeliveryFailed    = "FAILED"    // Gave up after MaxAttempts
+	WebhookDeliveryCancelled = "CANCELLED" // Subscription deleted or paused before delivery
+)
+
+// Why a webhook subscription is inactive
+const (
+	WebhookDisabledByFailures = "FAILURES" // Disabled by the webhook worker after consecutive failed deliveries
+	WebhookDisabledByUser     = "USER"     // Paused by a user
+)
+
+// WebhookSubscriptionRequest creates or updates a subscription. Secret is generated when empty.
+type WebhookSubscriptionRequest struct {
+	Name        string   `json:"name" binding:"required"`
+	URL         string   `json:"url" binding:"required"`
+	Secret      string   `json:"secret"`
+	Events      []string `json:"events" binding:"required,min=1"`
+	Description string   `json:"description"`
+	IsActive    *bool    `json:"is_active"`
+}
+
+// WebhookDeliveryFilter filters the delivery log
+type WebhookDeliveryFilter struct {
+	SubscriptionID uint   `form:"subscription_id"`
+	EventType      string `form:"event_type"`
+	Status         string `form:"status"`
+	EventID        uint   `form:"event_id"`
+	Page           int    `form:"page"`
+	Limit          int    `form:"limit"`
+}
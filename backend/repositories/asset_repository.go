@@ -128,11 +128,13 @@ func (r *AssetRepository) GetAssetsByCategory(category string) ([]models.Asset,
 	return assets, err
 }
 
-// GetAssetsForDepreciation retrieves assets that need depreciation calculation
+// GetAssetsForDepreciation retrieves assets that need depreciation calculation, together with
+// disposed assets so the report can show their final book value
 func (r *AssetRepository) GetAssetsForDepreciation() ([]models.Asset, error) {
 	var assets []models.Asset
-	err := r.db.Where("is_active = ? AND status = ? AND useful_life > 0", 
-		true, models.AssetStatusActive).
+	err := r.db.Where("useful_life > 0 AND ((is_active = ? AND status = ?) OR status IN ?)", 
+		true, models.AssetStatusActive,
+		[]string{models.AssetStatusSold, models.AssetStatusDisposed, models.AssetStatusWrittenOff}).
 		Preload("AssetAccount").Preload("DepreciationAccount").
 		Find(&assets).Error
 	return assets, err
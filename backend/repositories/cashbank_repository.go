@@ -25,6 +25,14 @@ func (r *CashBankRepository) FindAll() ([]models.CashBank, error) {
 	return accounts, err
 }
 
+// FindAllInScope retrieves the active accounts inside the user's data scope
+func (r *CashBankRepository) FindAllInScope(scope *models.EffectiveDataScope) ([]models.CashBank, error) {
+	var accounts []models.CashBank
+	query := ApplyCashBankScope(r.db.Preload("Account").Where("cash_banks.is_active = ?", true), scope)
+	err := query.Find(&accounts).Error
+	return accounts, err
+}
+
 // FindByID retrieves account by ID
 func (r *CashBankRepository) FindByID(id uint) (*models.CashBank, error) {
 	var account models.CashBank
@@ -87,7 +87,7 @@ func (r *contactRepository) GetByType(contactType string) ([]models.Contact, err
 
 // Create creates a new contact
 func (r *contactRepository) Create(contact models.Contact) (*models.Contact, error) {
-	err := r.db.Create(&contact).Error
+	err := r.db.Omit("credit_hold", "credit_hold_reason", "credit_hold_at", "credit_hold_by").Create(&contact).Error
 	if err != nil {
 		return nil, err
 	}
@@ -98,7 +98,8 @@ func (r *contactRepository) Create(contact models.Contact) (*models.Contact, err
 
 // Update updates an existing contact
 func (r *contactRepository) Update(contact models.Contact) (*models.Contact, error) {
-	err := r.db.Save(&contact).Error
+	// Credit hold is only changed through credit control so it keeps its audit trail
+	err := r.db.Omit("credit_hold", "credit_hold_reason", "credit_hold_at", "credit_hold_by").Save(&contact).Error
 	if err != nil {
 		return nil, err
 	}
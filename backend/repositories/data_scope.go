@@ -0,0 +1,61 @@
+package repositories
+
+import (
+	"app-sistem-akuntansi/models"
+
+	"gorm.io/gorm"
+)
+
+// ApplySalesScope limits a sales query to the records the data scope allows
+func ApplySalesScope(query *gorm.DB, scope *models.EffectiveDataScope) *gorm.DB {
+	if !scope.IsRestricted() {
+		return query
+	}
+	if scope.OwnRecordsOnly {
+		query = query.Where("sales.user_id = ?", scope.UserID)
+	}
+	if len(scope.SalesPersonIDs) > 0 {
+		query = query.Where("sales.sales_person_id IN ?", scope.SalesPersonIDs)
+	}
+	if scope.MaxAmount > 0 {
+		query = query.Where("sales.total_amount <= ?", scope.MaxAmount)
+	}
+	return query
+}
+
+// ApplyPurchaseScope limits a purchase query to the records the data scope allows
+func ApplyPurchaseScope(query *gorm.DB, scope *models.EffectiveDataScope) *gorm.DB {
+	if !scope.IsRestricted() {
+		return query
+	}
+	if scope.OwnRecordsOnly {
+		query = query.Where("purchases.user_id = ?", scope.UserID)
+	}
+	if scope.MaxAmount > 0 {
+		query = query.Where("purchases.total_amount <= ?", scope.MaxAmount)
+	}
+	return query
+}
+
+// ApplyWarehouseLocationScope limits a warehouse location query to the allowed locations.
+// column is the warehouse location ID column of the queried table.
+func ApplyWarehouseLocationScope(query *gorm.DB, scope *models.EffectiveDataScope, column string) *gorm.DB {
+	if !scope.IsRestricted() || len(scope.WarehouseIDs) == 0 {
+		return query
+	}
+	return query.Where(column+" IN ?", scope.WarehouseIDs)
+}
+
+// ApplyCashBankScope limits a cash/bank account query to the allowed accounts
+func ApplyCashBankScope(query *gorm.DB, scope *models.EffectiveDataScope) *gorm.DB {
+	if !scope.IsRestricted() {
+		return query
+	}
+	if scope.OwnRecordsOnly {
+		query = query.Where("cash_banks.user_id = ?", scope.UserID)
+	}
+	if len(scope.CashBankIDs) > 0 {
+		query = query.Where("cash_banks.id IN ?", scope.CashBankIDs)
+	}
+	return query
+}
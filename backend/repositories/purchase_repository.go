@@ -121,6 +121,8 @@ func (r *PurchaseRepository) FindWithFilter(filter models.PurchaseFilter) ([]mod
 		query = query.Where("requires_approval = ?", *filter.RequiresApproval)
 	}
 
+	query = ApplyPurchaseScope(query, filter.Scope)
+
 	// Get total count
 	query.Count(&total)
 
@@ -591,8 +593,16 @@ func (r *PurchaseRepository) GetPayablesReport() (*models.PayablesReportResponse
 		totalOutstanding += item.OutstandingAmount
 	}
 
+	openCredits, totalOpenCredits, err := getOpenAdvanceCredits(r.db, models.AdvancePaymentTypeVendor)
+	if err != nil {
+		return nil, err
+	}
+
 	return &models.PayablesReportResponse{
 		TotalOutstanding: totalOutstanding,
 		Payables:         payables,
+		OpenCredits:      openCredits,
+		TotalOpenCredits: totalOpenCredits,
+		NetOutstanding:   totalOutstanding - totalOpenCredits,
 	}, nil
 }
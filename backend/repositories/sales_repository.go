@@ -121,6 +121,9 @@ func (r *SalesRepository) FindWithFilter(filter models.SalesFilter) ([]models.Sa
 				searchPattern, searchPattern, searchPattern)
 	}
 
+	countQuery = ApplySalesScope(countQuery, filter.Scope)
+	dataQuery = ApplySalesScope(dataQuery, filter.Scope)
+
 	// Count total records
 	err := countQuery.Count(&total).Error
 	if err != nil {
@@ -542,13 +545,51 @@ func (r *SalesRepository) GetReceivablesReport() (*models.ReceivablesReportRespo
 		}
 	}
 
+	openCredits, totalOpenCredits, err := getOpenAdvanceCredits(r.db, models.AdvancePaymentTypeCustomer)
+	if err != nil {
+		return nil, err
+	}
+
 	return &models.ReceivablesReportResponse{
 		TotalOutstanding: totalOutstanding,
 		OverdueAmount:    overdueAmount,
 		Receivables:      receivables,
+		OpenCredits:      openCredits,
+		TotalOpenCredits: totalOpenCredits,
+		NetOutstanding:   totalOutstanding - totalOpenCredits,
 	}, nil
 }
 
+// getOpenAdvanceCredits returns the unapplied customer down payments or vendor prepayments
+func getOpenAdvanceCredits(db *gorm.DB, advanceType string) ([]models.AdvanceCreditItem, float64, error) {
+	var credits []models.AdvanceCreditItem
+	err := db.Model(&models.AdvancePayment{}).
+		Select(`
+			advance_payments.id as advance_payment_id,
+			advance_payments.code,
+			advance_payments.contact_id,
+			contacts.name as contact_name,
+			advance_payments.date,
+			advance_payments.amount,
+			advance_payments.applied_amount,
+			advance_payments.remaining_amount
+		`).
+		Joins("JOIN contacts ON contacts.id = advance_payments.contact_id").
+		Where("advance_payments.type = ? AND advance_payments.status = ? AND advance_payments.remaining_amount > 0",
+			advanceType, models.AdvancePaymentStatusOpen).
+		Order("advance_payments.date ASC").
+		Scan(&credits).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var total float64
+	for _, credit := range credits {
+		total += credit.RemainingAmount
+	}
+	return credits, total, nil
+}
+
 
 // GetCustomerOutstandingAmount gets customer outstanding amount
 func (r *SalesRepository) GetCustomerOutstandingAmount(customerID uint) (float64, error) {
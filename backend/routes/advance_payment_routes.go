@@ -0,0 +1,26 @@
+package routes
+
+import (
+	"app-sistem-akuntansi/controllers"
+	"app-sistem-akuntansi/middleware"
+	"app-sistem-akuntansi/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// SetupAdvancePaymentRoutes registers customer down payment and vendor prepayment routes
+func SetupAdvancePaymentRoutes(protected *gin.RouterGroup, db *gorm.DB) {
+	advancePaymentController := controllers.NewAdvancePaymentController(services.NewAdvancePaymentService(db))
+
+	advancePayments := protected.Group("/advance-payments")
+	{
+		advancePayments.GET("", middleware.RoleRequired("admin", "finance", "director"), advancePaymentController.GetAdvancePayments)
+		advancePayments.GET("/:id", middleware.RoleRequired("admin", "finance", "director"), advancePaymentController.GetAdvancePayment)
+
+		advancePayments.POST("/customer", middleware.RoleRequired("admin", "finance"), advancePaymentController.CreateCustomerDownPayment)
+		advancePayments.POST("/vendor", middleware.RoleRequired("admin", "finance"), advancePaymentController.CreateVendorPrepayment)
+		advancePayments.POST("/:id/apply", middleware.RoleRequired("admin", "finance", "director"), advancePaymentController.ApplyAdvancePayment)
+		advancePayments.POST("/:id/void", middleware.RoleRequired("admin", "finance"), advancePaymentController.VoidAdvancePayment)
+	}
+}
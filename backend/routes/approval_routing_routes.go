@@ -0,0 +1,34 @@
+package routes
+
+import (
+	"app-sistem-akuntansi/controllers"
+	"app-sistem-akuntansi/middleware"
+	"app-sistem-akuntansi/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// SetupApprovalRoutingRoutes registers approval delegations, the workflow routing preview and the
+// submission of receivable write-off suggestions to their approval workflow
+func SetupApprovalRoutingRoutes(protected *gin.RouterGroup, db *gorm.DB, approvalService *services.ApprovalService) {
+	routingController := controllers.NewApprovalRoutingController(approvalService, services.NewApprovalDelegationService(db))
+	writeOffController := controllers.NewWriteOffSuggestionController(services.NewWriteOffSuggestionService(db, approvalService))
+
+	protected.POST("/approval-workflows/resolve", middleware.RoleRequired("admin"), routingController.ResolveWorkflow)
+
+	delegations := protected.Group("/approval-delegations")
+	{
+		delegations.GET("", routingController.GetDelegations)
+		delegations.GET("/:id", routingController.GetDelegation)
+		delegations.POST("", routingController.CreateDelegation)
+		delegations.PUT("/:id", routingController.UpdateDelegation)
+		delegations.POST("/:id/cancel", routingController.CancelDelegation)
+	}
+
+	writeOffs := protected.Group("/write-off-suggestions", middleware.RoleRequired("admin", "finance", "director"))
+	{
+		writeOffs.GET("", writeOffController.GetSuggestions)
+		writeOffs.POST("/:id/submit-approval", writeOffController.SubmitForApproval)
+	}
+}
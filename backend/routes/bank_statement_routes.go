@@ -0,0 +1,39 @@
+package routes
+
+import (
+	"app-sistem-akuntansi/controllers"
+	"app-sistem-akuntansi/middleware"
+	"app-sistem-akuntansi/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// SetupBankStatementRoutes registers bank statement import and statement-based reconciliation routes
+func SetupBankStatementRoutes(protected *gin.RouterGroup, db *gorm.DB) {
+	statementService := services.NewBankStatementService(db)
+	statementController := controllers.NewBankStatementController(statementService)
+
+	statements := protected.Group("/bank-statements")
+	statements.Use(middleware.RoleRequired("admin", "finance", "director"))
+	{
+		// CSV column mappings (BCA/Mandiri/BRI presets and custom layouts)
+		statements.GET("/mappings", statementController.GetColumnMappings)
+		statements.POST("/mappings", middleware.RoleRequired("admin", "finance"), statementController.SaveColumnMapping)
+
+		// Import (CSV, MT940, OFX)
+		statements.GET("", statementController.GetStatements)
+		statements.GET("/:id", statementController.GetStatement)
+		statements.POST("/import", middleware.RoleRequired("admin", "finance"), statementController.ImportStatement)
+		statements.DELETE("/:id", middleware.RoleRequired("admin", "finance"), statementController.DeleteStatement)
+
+		// Matching against cash-bank transactions
+		statements.POST("/:id/auto-match", middleware.RoleRequired("admin", "finance"), statementController.AutoMatch)
+		statements.GET("/:id/reconciliation", statementController.GetReconciliation)
+		statements.POST("/lines/:line_id/match", middleware.RoleRequired("admin", "finance"), statementController.MatchLine)
+		statements.POST("/lines/:line_id/unmatch", middleware.RoleRequired("admin", "finance"), statementController.UnmatchLine)
+
+		// Bank fee / interest journals from unmatched statement lines
+		statements.POST("/lines/:line_id/journal", middleware.RoleRequired("admin", "finance"), statementController.CreateJournalFromLine)
+	}
+}
@@ -0,0 +1,36 @@
+package routes
+
+import (
+	"app-sistem-akuntansi/controllers"
+	"app-sistem-akuntansi/middleware"
+	"app-sistem-akuntansi/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// SetupBudgetRoutes registers budgeting and budget-vs-actual routes
+func SetupBudgetRoutes(protected *gin.RouterGroup, db *gorm.DB, approvalService *services.ApprovalService, pdfService services.PDFServiceInterface) {
+	budgetService := services.NewBudgetService(db, approvalService)
+	budgetController := controllers.NewBudgetController(budgetService, pdfService)
+
+	budgets := protected.Group("/budgets")
+	budgets.Use(middleware.RoleRequired("admin", "finance", "director"))
+	{
+		budgets.GET("", budgetController.GetBudgets)
+		budgets.POST("", budgetController.CreateBudget)
+		budgets.POST("/copy-from-actuals", budgetController.CopyFromActuals)
+		budgets.GET("/:id", budgetController.GetBudget)
+		budgets.PUT("/:id", budgetController.UpdateBudget)
+		budgets.DELETE("/:id", budgetController.DeleteBudget)
+
+		// Workflow: DRAFT -> (approval) -> APPROVED -> ACTIVE -> CLOSED
+		budgets.POST("/:id/submit-approval", budgetController.SubmitForApproval)
+		budgets.POST("/:id/activate", middleware.RoleRequired("admin", "director"), budgetController.ActivateBudget)
+		budgets.POST("/:id/close", middleware.RoleRequired("admin", "director"), budgetController.CloseBudget)
+
+		// Actuals and reporting (format=json|pdf|excel)
+		budgets.POST("/:id/refresh-actuals", budgetController.RefreshActuals)
+		budgets.GET("/:id/vs-actual", budgetController.GetBudgetVsActual)
+	}
+}
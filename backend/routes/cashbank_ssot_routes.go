@@ -2,6 +2,7 @@ package routes
 
 import (
 	"app-sistem-akuntansi/handlers"
+	"app-sistem-akuntansi/models"
 	"app-sistem-akuntansi/repositories"
 	"app-sistem-akuntansi/services"
 	"app-sistem-akuntansi/middleware"
@@ -10,7 +11,7 @@ import (
 )
 
 // SetupCashBankSSOTRoutes sets up all cash-bank routes with SSOT integration
-func SetupCashBankSSOTRoutes(v1 *gin.RouterGroup, db *gorm.DB, jwtManager *middleware.JWTManager) {
+func SetupCashBankSSOTRoutes(v1 *gin.RouterGroup, db *gorm.DB, jwtManager *middleware.JWTManager, dataScope *middleware.DataScopeMiddleware) {
 	// Initialize repositories
 	accountRepo := repositories.NewAccountRepository(db)
 	cashBankRepo := repositories.NewCashBankRepository(db)
@@ -34,42 +35,42 @@ func SetupCashBankSSOTRoutes(v1 *gin.RouterGroup, db *gorm.DB, jwtManager *middl
 		// Account Management
 		accounts := cashBankGroup.Group("/accounts")
 		{
-			accounts.GET("", permMiddleware.CanView("cash_bank"), cashBankHandler.GetCashBankAccounts)
-			accounts.GET("/:id", permMiddleware.CanView("cash_bank"), cashBankHandler.GetCashBankByID)
+			accounts.GET("", permMiddleware.CanView("cash_bank"), dataScope.Apply(models.DataScopeModuleCashBank), cashBankHandler.GetCashBankAccounts)
+			accounts.GET("/:id", permMiddleware.CanView("cash_bank"), dataScope.Apply(models.DataScopeModuleCashBank), cashBankHandler.GetCashBankByID)
 			accounts.POST("", permMiddleware.CanCreate("cash_bank"), cashBankHandler.CreateCashBankAccount)
-			accounts.PUT("/:id", permMiddleware.CanEdit("cash_bank"), cashBankHandler.UpdateCashBankAccount)
-			accounts.DELETE("/:id", permMiddleware.CanDelete("cash_bank"), cashBankHandler.DeleteCashBankAccount)
+			accounts.PUT("/:id", permMiddleware.CanEdit("cash_bank"), dataScope.RequireRecord(models.DataScopeModuleCashBank, "id"), cashBankHandler.UpdateCashBankAccount)
+			accounts.DELETE("/:id", permMiddleware.CanDelete("cash_bank"), dataScope.RequireRecord(models.DataScopeModuleCashBank, "id"), cashBankHandler.DeleteCashBankAccount)
 			
 			// Transaction History
-			accounts.GET("/:id/transactions", permMiddleware.CanView("cash_bank"), cashBankHandler.GetTransactions)
+			accounts.GET("/:id/transactions", permMiddleware.CanView("cash_bank"), dataScope.Apply(models.DataScopeModuleCashBank), cashBankHandler.GetTransactions)
 			
 			// Bank Reconciliation
-			accounts.POST("/:id/reconcile", permMiddleware.CanEdit("cash_bank"), cashBankHandler.ReconcileAccount)
+			accounts.POST("/:id/reconcile", permMiddleware.CanEdit("cash_bank"), dataScope.RequireRecord(models.DataScopeModuleCashBank, "id"), cashBankHandler.ReconcileAccount)
 		}
 		
 		// Transaction Processing (all with SSOT journal integration)
 		transactions := cashBankGroup.Group("/transactions")
 		{
-			transactions.POST("/deposit", permMiddleware.CanCreate("cash_bank"), cashBankHandler.ProcessDeposit)
-			transactions.POST("/withdrawal", permMiddleware.CanCreate("cash_bank"), cashBankHandler.ProcessWithdrawal)
-			transactions.POST("/transfer", permMiddleware.CanCreate("cash_bank"), cashBankHandler.ProcessTransfer)
+			transactions.POST("/deposit", permMiddleware.CanCreate("cash_bank"), dataScope.Apply(models.DataScopeModuleCashBank), cashBankHandler.ProcessDeposit)
+			transactions.POST("/withdrawal", permMiddleware.CanCreate("cash_bank"), dataScope.Apply(models.DataScopeModuleCashBank), cashBankHandler.ProcessWithdrawal)
+			transactions.POST("/transfer", permMiddleware.CanCreate("cash_bank"), dataScope.Apply(models.DataScopeModuleCashBank), cashBankHandler.ProcessTransfer)
 		}
 
 		// Compatibility routes without /transactions prefix (as documented in Swagger)
-		cashBankGroup.POST("/deposit", permMiddleware.CanCreate("cash_bank"), cashBankHandler.ProcessDeposit)
-		cashBankGroup.POST("/withdrawal", permMiddleware.CanCreate("cash_bank"), cashBankHandler.ProcessWithdrawal)
-		cashBankGroup.POST("/transfer", permMiddleware.CanCreate("cash_bank"), cashBankHandler.ProcessTransfer)
+		cashBankGroup.POST("/deposit", permMiddleware.CanCreate("cash_bank"), dataScope.Apply(models.DataScopeModuleCashBank), cashBankHandler.ProcessDeposit)
+		cashBankGroup.POST("/withdrawal", permMiddleware.CanCreate("cash_bank"), dataScope.Apply(models.DataScopeModuleCashBank), cashBankHandler.ProcessWithdrawal)
+		cashBankGroup.POST("/transfer", permMiddleware.CanCreate("cash_bank"), dataScope.Apply(models.DataScopeModuleCashBank), cashBankHandler.ProcessTransfer)
 		
 		// Reporting and Summary
 		reports := cashBankGroup.Group("/reports")
 		{
 			reports.GET("/balance-summary", permMiddleware.CanView("cash_bank"), cashBankHandler.GetBalanceSummary)
-			reports.GET("/payment-accounts", permMiddleware.CanView("cash_bank"), cashBankHandler.GetPaymentAccounts)
+			reports.GET("/payment-accounts", permMiddleware.CanView("cash_bank"), dataScope.Apply(models.DataScopeModuleCashBank), cashBankHandler.GetPaymentAccounts)
 		}
 
 		// Backward-compatibility routes (documented path without /reports prefix)
 		cashBankGroup.GET("/balance-summary", permMiddleware.CanView("cash_bank"), cashBankHandler.GetBalanceSummary)
-		cashBankGroup.GET("/payment-accounts", permMiddleware.CanView("cash_bank"), cashBankHandler.GetPaymentAccounts)
+		cashBankGroup.GET("/payment-accounts", permMiddleware.CanView("cash_bank"), dataScope.Apply(models.DataScopeModuleCashBank), cashBankHandler.GetPaymentAccounts)
 		cashBankGroup.GET("/deposit-source-accounts", permMiddleware.CanView("cash_bank"), cashBankHandler.GetDepositSourceAccounts)
 cashBankGroup.GET("/revenue-accounts", permMiddleware.CanView("cash_bank"), func(c *gin.Context) {
 			accounts, err := accountService.GetRevenueAccounts(c.Request.Context())
@@ -0,0 +1,28 @@
+package routes
+
+import (
+	"app-sistem-akuntansi/controllers"
+	"app-sistem-akuntansi/middleware"
+	"app-sistem-akuntansi/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// SetupCreditControlRoutes registers customer credit exposure and credit hold routes
+func SetupCreditControlRoutes(protected *gin.RouterGroup, db *gorm.DB) {
+	creditControlService := services.NewCreditControlService(db, services.NewApprovalService(db), services.NewSettingsService(db))
+	creditControlController := controllers.NewCreditControlController(creditControlService)
+
+	creditControl := protected.Group("/credit-control")
+	{
+		creditControl.GET("/holds", middleware.RoleRequired("admin", "finance", "director"), creditControlController.GetCustomersOnHold)
+		creditControl.GET("/customers/:id/exposure", middleware.RoleRequired("admin", "finance", "director"), creditControlController.GetCustomerExposure)
+		creditControl.GET("/customers/:id/hold-history", middleware.RoleRequired("admin", "finance", "director"), creditControlController.GetHoldHistory)
+		creditControl.GET("/sales/:id/check", middleware.RoleRequired("admin", "finance", "director"), creditControlController.CheckSale)
+
+		// Only finance (and admin) can place and release holds
+		creditControl.POST("/customers/:id/hold", middleware.RoleRequired("admin", "finance"), creditControlController.PlaceHold)
+		creditControl.POST("/customers/:id/release", middleware.RoleRequired("admin", "finance"), creditControlController.ReleaseHold)
+	}
+}
@@ -0,0 +1,33 @@
+package routes
+
+import (
+	"app-sistem-akuntansi/controllers"
+	"app-sistem-akuntansi/middleware"
+	"app-sistem-akuntansi/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// SetupCurrencyRoutes registers exchange rate and FX revaluation routes
+func SetupCurrencyRoutes(protected *gin.RouterGroup, db *gorm.DB) {
+	currencyService := services.NewCurrencyService(db)
+	currencyController := controllers.NewCurrencyController(currencyService)
+
+	currency := protected.Group("/currency")
+	currency.Use(middleware.RoleRequired("admin", "finance", "director"))
+	{
+		// Exchange rates (manual entry and CSV import)
+		currency.GET("/rates", currencyController.GetExchangeRates)
+		currency.GET("/rates/effective", currencyController.GetRate)
+		currency.POST("/rates", currencyController.SaveExchangeRate)
+		currency.POST("/rates/import", currencyController.ImportExchangeRates)
+		currency.DELETE("/rates/:id", currencyController.DeleteExchangeRate)
+
+		// Month-end unrealized gain/loss revaluation
+		currency.GET("/revaluations", currencyController.GetRevaluations)
+		currency.GET("/revaluations/:id", currencyController.GetRevaluation)
+		currency.POST("/revaluations", middleware.RoleRequired("admin", "finance"), currencyController.RunRevaluation)
+		currency.POST("/revaluations/:id/cancel", middleware.RoleRequired("admin"), currencyController.CancelRevaluation)
+	}
+}
@@ -0,0 +1,25 @@
+package routes
+
+import (
+	"app-sistem-akuntansi/controllers"
+	"app-sistem-akuntansi/middleware"
+	"app-sistem-akuntansi/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupDataScopeRoutes registers the administration of data scopes and the endpoint that explains
+// why a user can or cannot see a record. Enforcement happens on the scoped list routes themselves.
+func SetupDataScopeRoutes(protected *gin.RouterGroup, dataScopeService *services.DataScopeService, dataScope *middleware.DataScopeMiddleware) {
+	dataScopeController := controllers.NewDataScopeController(dataScopeService, dataScope)
+
+	scopes := protected.Group("/data-scopes", middleware.RoleRequired("admin"))
+	{
+		scopes.GET("", dataScopeController.GetScopes)
+		scopes.GET("/explain", dataScopeController.ExplainAccess)
+		scopes.GET("/:id", dataScopeController.GetScope)
+		scopes.POST("", dataScopeController.CreateScope)
+		scopes.PUT("/:id", dataScopeController.UpdateScope)
+		scopes.DELETE("/:id", dataScopeController.DeleteScope)
+	}
+}
@@ -0,0 +1,33 @@
+package routes
+
+import (
+	"app-sistem-akuntansi/controllers"
+	"app-sistem-akuntansi/middleware"
+	"app-sistem-akuntansi/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// SetupDimensionRoutes registers cost center, project and tag dimension routes and mandatory dimension rules
+func SetupDimensionRoutes(protected *gin.RouterGroup, db *gorm.DB) {
+	dimensionService := services.NewDimensionService(db)
+	dimensionController := controllers.NewDimensionController(dimensionService)
+
+	dimensions := protected.Group("/dimensions")
+	dimensions.Use(middleware.RoleRequired("admin", "finance", "director"))
+	{
+		// Mandatory dimension rules per account / account code prefix
+		dimensions.GET("/rules", dimensionController.GetRules)
+		dimensions.POST("/rules", middleware.RoleRequired("admin", "finance"), dimensionController.CreateRule)
+		dimensions.PUT("/rules/:rule_id", middleware.RoleRequired("admin", "finance"), dimensionController.UpdateRule)
+		dimensions.DELETE("/rules/:rule_id", middleware.RoleRequired("admin", "finance"), dimensionController.DeleteRule)
+
+		// Cost centers, projects and tags
+		dimensions.GET("", dimensionController.GetDimensions)
+		dimensions.GET("/:id", dimensionController.GetDimension)
+		dimensions.POST("", middleware.RoleRequired("admin", "finance"), dimensionController.CreateDimension)
+		dimensions.PUT("/:id", middleware.RoleRequired("admin", "finance"), dimensionController.UpdateDimension)
+		dimensions.DELETE("/:id", middleware.RoleRequired("admin", "finance"), dimensionController.DeleteDimension)
+	}
+}
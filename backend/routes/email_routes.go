@@ -0,0 +1,40 @@
+package routes
+
+import (
+	"app-sistem-akuntansi/controllers"
+	"app-sistem-akuntansi/middleware"
+	"app-sistem-akuntansi/models"
+	"app-sistem-akuntansi/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// SetupEmailRoutes registers document emails (invoice, receipt, statement), the outbound queue,
+// email templates and the SMTP test email
+func SetupEmailRoutes(protected *gin.RouterGroup, db *gorm.DB, permMiddleware *middleware.PermissionMiddleware, dataScope *middleware.DataScopeMiddleware) {
+	emailController := controllers.NewEmailController(services.NewEmailService(db))
+
+	sales := protected.Group("/sales")
+	{
+		sales.POST("/:id/invoice/email", permMiddleware.CanExport("sales"), dataScope.Apply(models.DataScopeModuleSales), emailController.SendInvoiceEmail)
+		sales.POST("/:id/receipt/email", permMiddleware.CanExport("sales"), dataScope.Apply(models.DataScopeModuleSales), emailController.SendReceiptEmail)
+	}
+
+	protected.POST("/statements/contacts/:contact_id/email", middleware.RoleRequired("admin", "finance", "director"), emailController.SendStatementEmail)
+
+	email := protected.Group("/email")
+	email.Use(middleware.RoleRequired("admin", "finance", "director"))
+	{
+		email.GET("/messages", emailController.GetMessages)
+		email.GET("/messages/:id", emailController.GetMessage)
+		email.POST("/messages/:id/retry", emailController.RetryMessage)
+		email.POST("/messages/:id/bounce", emailController.MarkBounced)
+
+		email.GET("/templates", emailController.GetTemplates)
+		email.PUT("/templates", middleware.RoleRequired("admin"), emailController.SaveTemplate)
+		email.DELETE("/templates/:id", middleware.RoleRequired("admin"), emailController.DeleteTemplate)
+
+		email.POST("/test", middleware.RoleRequired("admin"), emailController.SendTestEmail)
+	}
+}
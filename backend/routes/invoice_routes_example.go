@@ -11,7 +11,7 @@ import (
 
 // SetupInvoiceRoutes registers all invoice-related routes
 // This is an example of how to integrate invoice routes with settings
-func SetupInvoiceRoutes(protected *gin.RouterGroup, db *gorm.DB) {
+func SetupInvoiceRoutes(protected *gin.RouterGroup, db *gorm.DB, salesService *services.SalesServiceV2) {
 	// Initialize repositories
 	contactRepo := repositories.NewContactRepository(db)
 	productRepo := repositories.NewProductRepository(db)
@@ -22,7 +22,7 @@ func SetupInvoiceRoutes(protected *gin.RouterGroup, db *gorm.DB) {
 	
 	// Initialize controllers
 	invoiceController := controllers.NewInvoiceController(invoiceService)
-	quoteController := controllers.NewQuoteController(quoteService, invoiceService)
+	quoteController := controllers.NewQuoteController(quoteService, invoiceService, salesService)
 
 	// Initialize Permission Middleware
 	permMiddleware := middleware.NewPermissionMiddleware(db)
@@ -45,6 +45,7 @@ func SetupInvoiceRoutes(protected *gin.RouterGroup, db *gorm.DB) {
 	quotes := protected.Group("/quotes")
 	{
 		quotes.GET("", permMiddleware.CanView("sales"), quoteController.GetQuotes)
+		quotes.GET("/analytics", permMiddleware.CanView("sales"), quoteController.GetConversionAnalytics)
 		quotes.GET("/:id", permMiddleware.CanView("sales"), quoteController.GetQuote)
 		quotes.POST("", permMiddleware.CanCreate("sales"), quoteController.CreateQuote)
 		quotes.PUT("/:id", permMiddleware.CanEdit("sales"), quoteController.UpdateQuote)
@@ -54,5 +55,8 @@ func SetupInvoiceRoutes(protected *gin.RouterGroup, db *gorm.DB) {
 		quotes.POST("/generate-code", permMiddleware.CanCreate("sales"), quoteController.GenerateQuoteCode)
 		quotes.POST("/format-currency", permMiddleware.CanView("sales"), quoteController.FormatCurrency)
 		quotes.POST("/:id/convert-to-invoice", permMiddleware.CanCreate("sales"), quoteController.ConvertToInvoice)
+		quotes.POST("/:id/convert-to-sale", permMiddleware.CanCreate("sales"), quoteController.ConvertToSale)
+		quotes.POST("/:id/status", permMiddleware.CanEdit("sales"), quoteController.UpdateQuoteStatus)
+		quotes.POST("/expire", middleware.RoleRequired("admin", "finance", "director"), quoteController.ExpireQuotes)
 	}
 }
@@ -3,13 +3,14 @@ package routes
 import (
 	"app-sistem-akuntansi/controllers"
 	"app-sistem-akuntansi/middleware"
+	"app-sistem-akuntansi/models"
 	"app-sistem-akuntansi/repositories"
 	"app-sistem-akuntansi/services"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
-func SetupPaymentRoutes(router *gin.RouterGroup, paymentController *controllers.PaymentController, cashBankController *controllers.CashBankController, cashBankService *services.CashBankService, jwtManager *middleware.JWTManager, db *gorm.DB) {
+func SetupPaymentRoutes(router *gin.RouterGroup, paymentController *controllers.PaymentController, cashBankController *controllers.CashBankController, cashBankService *services.CashBankService, jwtManager *middleware.JWTManager, db *gorm.DB, dataScope *middleware.DataScopeMiddleware) {
 	// Note: FixCashBankController removed - deprecated admin endpoints
 	// Initialize permission middleware
 	permissionMiddleware := middleware.NewPermissionMiddleware(db)
@@ -56,10 +57,10 @@ func SetupPaymentRoutes(router *gin.RouterGroup, paymentController *controllers.
 	cashbank := router.Group("/cashbank")
 	{
 		// Account management
-		cashbank.GET("/accounts", permissionMiddleware.CanView("cash_bank"), cashBankController.GetAccounts)
+		cashbank.GET("/accounts", permissionMiddleware.CanView("cash_bank"), dataScope.Apply(models.DataScopeModuleCashBank), cashBankController.GetAccounts)
 		
 		// Payment accounts endpoint - specifically for payment form dropdowns
-		cashbank.GET("/payment-accounts", permissionMiddleware.CanView("cash_bank"), cashBankController.GetPaymentAccounts)
+		cashbank.GET("/payment-accounts", permissionMiddleware.CanView("cash_bank"), dataScope.Apply(models.DataScopeModuleCashBank), cashBankController.GetPaymentAccounts)
 		
 		// Revenue accounts endpoint - for deposit source selection
 		cashbank.GET("/revenue-accounts", permissionMiddleware.CanView("cash_bank"), cashBankController.GetRevenueAccounts)
@@ -68,15 +69,15 @@ func SetupPaymentRoutes(router *gin.RouterGroup, paymentController *controllers.
 		cashbank.GET("/deposit-source-accounts", permissionMiddleware.CanView("cash_bank"), cashBankController.GetDepositSourceAccounts)
 		
 		
-		cashbank.GET("/accounts/:id", permissionMiddleware.CanView("cash_bank"), cashBankController.GetAccountByID)
+		cashbank.GET("/accounts/:id", permissionMiddleware.CanView("cash_bank"), dataScope.Apply(models.DataScopeModuleCashBank), cashBankController.GetAccountByID)
 cashbank.POST("/accounts", permissionMiddleware.CanCreate("cash_bank"), cashBankController.CreateAccount)
-cashbank.PUT("/accounts/:id", permissionMiddleware.CanEdit("cash_bank"), cashBankController.UpdateAccount)
+cashbank.PUT("/accounts/:id", permissionMiddleware.CanEdit("cash_bank"), dataScope.RequireRecord(models.DataScopeModuleCashBank, "id"), cashBankController.UpdateAccount)
 		
 		// Transactions
-		cashbank.POST("/transfer", permissionMiddleware.CanCreate("cash_bank"), cashBankController.ProcessTransfer)
-		cashbank.POST("/deposit", permissionMiddleware.CanCreate("cash_bank"), cashBankController.ProcessDeposit)
-		cashbank.POST("/withdrawal", permissionMiddleware.CanCreate("cash_bank"), cashBankController.ProcessWithdrawal)
-		cashbank.GET("/accounts/:id/transactions", permissionMiddleware.CanView("cash_bank"), cashBankController.GetTransactions)
+		cashbank.POST("/transfer", permissionMiddleware.CanCreate("cash_bank"), dataScope.Apply(models.DataScopeModuleCashBank), cashBankController.ProcessTransfer)
+		cashbank.POST("/deposit", permissionMiddleware.CanCreate("cash_bank"), dataScope.Apply(models.DataScopeModuleCashBank), cashBankController.ProcessDeposit)
+		cashbank.POST("/withdrawal", permissionMiddleware.CanCreate("cash_bank"), dataScope.Apply(models.DataScopeModuleCashBank), cashBankController.ProcessWithdrawal)
+		cashbank.GET("/accounts/:id/transactions", permissionMiddleware.CanView("cash_bank"), dataScope.Apply(models.DataScopeModuleCashBank), cashBankController.GetTransactions)
 		
 		// Reports
 		cashbank.GET("/balance-summary", permissionMiddleware.CanView("cash_bank"), cashBankController.GetBalanceSummary)
@@ -0,0 +1,32 @@
+package routes
+
+import (
+	"app-sistem-akuntansi/controllers"
+	"app-sistem-akuntansi/middleware"
+	"app-sistem-akuntansi/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// SetupPurchaseRequisitionRoutes registers purchase requisition routes. Every role can raise
+// requisitions; purchasing staff consolidate approved ones into purchase orders.
+func SetupPurchaseRequisitionRoutes(protected *gin.RouterGroup, db *gorm.DB, approvalService *services.ApprovalService, purchaseService *services.PurchaseService) {
+	requisitionController := controllers.NewPurchaseRequisitionController(services.NewPurchaseRequisitionService(db, approvalService, purchaseService))
+
+	requisitions := protected.Group("/purchase-requisitions")
+	{
+		requisitions.GET("", requisitionController.GetRequisitions)
+		requisitions.GET("/my", requisitionController.GetMyRequisitions)
+		requisitions.GET("/open-lines", middleware.RoleRequired("admin", "finance", "director", "inventory_manager"), requisitionController.GetOpenLines)
+		requisitions.POST("/consolidate", middleware.RoleRequired("admin", "finance", "inventory_manager"), requisitionController.ConsolidateRequisitions)
+
+		requisitions.POST("", requisitionController.CreateRequisition)
+		requisitions.GET("/:id", requisitionController.GetRequisition)
+		requisitions.PUT("/:id", requisitionController.UpdateRequisition)
+		requisitions.DELETE("/:id", requisitionController.DeleteRequisition)
+		requisitions.POST("/:id/submit", requisitionController.SubmitRequisition)
+		requisitions.POST("/:id/cancel", requisitionController.CancelRequisition)
+		requisitions.GET("/:id/fulfilment", requisitionController.GetFulfilment)
+	}
+}
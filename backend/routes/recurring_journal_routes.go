@@ -0,0 +1,36 @@
+package routes
+
+import (
+	"app-sistem-akuntansi/controllers"
+	"app-sistem-akuntansi/middleware"
+	"app-sistem-akuntansi/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// SetupRecurringJournalRoutes registers recurring journal / recurring expense template routes
+func SetupRecurringJournalRoutes(protected *gin.RouterGroup, db *gorm.DB) {
+	recurringService := services.NewRecurringJournalService(db)
+	recurringController := controllers.NewRecurringJournalController(recurringService)
+
+	recurring := protected.Group("/recurring-journals")
+	recurring.Use(middleware.RoleRequired("admin", "finance", "director"))
+	{
+		// Generation history and manual runs
+		recurring.GET("/runs", recurringController.GetRuns)
+		recurring.POST("/runs/:run_id/release", middleware.RoleRequired("admin", "finance"), recurringController.ReleaseRun)
+		recurring.POST("/run-due", middleware.RoleRequired("admin", "finance"), recurringController.RunDue)
+
+		// Templates
+		recurring.GET("", recurringController.GetTemplates)
+		recurring.GET("/:id", recurringController.GetTemplate)
+		recurring.POST("", middleware.RoleRequired("admin", "finance"), recurringController.CreateTemplate)
+		recurring.POST("/from-expense/:expense_id", middleware.RoleRequired("admin", "finance"), recurringController.CreateFromExpense)
+		recurring.PUT("/:id", middleware.RoleRequired("admin", "finance"), recurringController.UpdateTemplate)
+		recurring.DELETE("/:id", middleware.RoleRequired("admin", "finance"), recurringController.DeleteTemplate)
+		recurring.POST("/:id/pause", middleware.RoleRequired("admin", "finance"), recurringController.PauseTemplate)
+		recurring.POST("/:id/resume", middleware.RoleRequired("admin", "finance"), recurringController.ResumeTemplate)
+		recurring.POST("/:id/run", middleware.RoleRequired("admin", "finance"), recurringController.RunTemplate)
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"app-sistem-akuntansi/config"
 	"app-sistem-akuntansi/controllers"
 	"app-sistem-akuntansi/handlers"
+	"app-sistem-akuntansi/models"
 	"app-sistem-akuntansi/repositories"
 	"app-sistem-akuntansi/services"
 	"app-sistem-akuntansi/middleware"
@@ -157,12 +158,16 @@ func swaggerIndexHTML(docURL string) string {
 func SetupRoutes(r *gin.Engine, db *gorm.DB, startupService *services.StartupService) {
 	// Controllers
 	authController := controllers.NewAuthController(db)
+	twoFactorService := services.NewTwoFactorService(db)
+	stepUp := middleware.NewStepUpMiddleware(twoFactorService) // Fresh 2FA code before high-risk actions
 	userController := controllers.NewUserController(db)
 	permissionController := controllers.NewPermissionController(db)
 	categoryController := controllers.NewCategoryController(db)
 	unitController := controllers.NewProductUnitController(db)
 	inventoryController := controllers.NewInventoryController(db)
 	assetController := controllers.NewAssetController(db)
+	assetDepreciationController := controllers.NewAssetDepreciationController(services.NewAssetDepreciationService(db))
+	assetDisposalController := controllers.NewAssetDisposalController(services.NewAssetDisposalService(db))
 	debugController := controllers.NewDebugController()
 	monitoringController := controllers.NewMonitoringController()
 	
@@ -192,6 +197,7 @@ func SetupRoutes(r *gin.Engine, db *gorm.DB, startupService *services.StartupSer
 	
 	// Initialize WarehouseLocationController
 	warehouseLocationController := controllers.NewWarehouseLocationController(db)
+	stockTransferController := controllers.NewStockTransferController(services.NewStockTransferService(db), services.NewStockService(db))
 	
 	// Fiscal Year Closing Service removed - now using Unified Period Closing Service
 	// which provides more flexibility and consistent closing logic
@@ -262,7 +268,7 @@ func SetupRoutes(r *gin.Engine, db *gorm.DB, startupService *services.StartupSer
 	// Journal Entry controller removed - migrated to SSOT unified system
 	
 	// Initialize SSOT Unified Journal Controller (service already initialized above)
-	unifiedJournalController := controllers.NewUnifiedJournalController(unifiedJournalService)
+	unifiedJournalController := controllers.NewUnifiedJournalController(unifiedJournalService, approvalService)
 	
 	// Initialize JWT Manager
 	jwtManager := middleware.NewJWTManager(db)
@@ -276,6 +282,10 @@ func SetupRoutes(r *gin.Engine, db *gorm.DB, startupService *services.StartupSer
 	
 	// Initialize Permission Middleware
 	permMiddleware := middleware.NewPermissionMiddleware(db)
+	// 🔒 Data scopes narrow module permissions down to records (own, sales persons, warehouses,
+	// cash/bank accounts, amount ceilings); resolved scopes share the permission cache
+	dataScopeService := services.NewDataScopeService(db)
+	dataScope := middleware.NewDataScopeMiddleware(dataScopeService, permMiddleware.Cache())
 	// 🔒 Initialize Enhanced Security Middleware
 	enhancedSecurity := middleware.NewEnhancedSecurityMiddleware(db)
 	
@@ -305,6 +315,9 @@ func SetupRoutes(r *gin.Engine, db *gorm.DB, startupService *services.StartupSer
 		auth.Use(enhancedSecurity.SecurityHeaders()) // Extra security for auth endpoints
 		{
 			auth.POST("/login", authController.Login)
+			auth.POST("/2fa/verify", authController.VerifyTwoFactorLogin) // Second step of a login with 2FA
+			auth.POST("/2fa/enroll", authController.BeginTwoFactorEnrollment) // Mandatory 2FA not set up yet: enrolment token only
+			auth.POST("/2fa/enroll/confirm", authController.ConfirmTwoFactorEnrollment) // First code enables 2FA and completes the login
 			// 🔒 PRODUCTION: Disable register endpoint in production
 			if isDevelopmentMode() || os.Getenv("ALLOW_REGISTRATION") == "true" {
 				auth.POST("/register", authController.Register)
@@ -497,11 +510,31 @@ func SetupRoutes(r *gin.Engine, db *gorm.DB, startupService *services.StartupSer
 			// 🏢 Warehouse Location routes with enhanced permission checks
 			warehouseLocations := protected.Group("/warehouse-locations")
 			{
-				warehouseLocations.GET("", permMiddleware.CanView("products"), warehouseLocationController.GetWarehouseLocations)
-				warehouseLocations.GET("/:id", permMiddleware.CanView("products"), warehouseLocationController.GetWarehouseLocation)
-				warehouseLocations.POST("", permMiddleware.CanCreate("products"), warehouseLocationController.CreateWarehouseLocation)
-				warehouseLocations.PUT("/:id", permMiddleware.CanEdit("products"), warehouseLocationController.UpdateWarehouseLocation)
-				warehouseLocations.DELETE("/:id", permMiddleware.CanDelete("products"), warehouseLocationController.DeleteWarehouseLocation)
+				warehouseLocations.GET("", permMiddleware.CanView("products"), dataScope.Apply(models.DataScopeModuleWarehouseLocations), warehouseLocationController.GetWarehouseLocations)
+				warehouseLocations.GET("/:id", permMiddleware.CanView("products"), dataScope.Apply(models.DataScopeModuleWarehouseLocations), warehouseLocationController.GetWarehouseLocation)
+				warehouseLocations.POST("", permMiddleware.CanCreate("products"), dataScope.Apply(models.DataScopeModuleWarehouseLocations), warehouseLocationController.CreateWarehouseLocation)
+				warehouseLocations.PUT("/:id", permMiddleware.CanEdit("products"), dataScope.RequireRecord(models.DataScopeModuleWarehouseLocations, "id"), warehouseLocationController.UpdateWarehouseLocation)
+				warehouseLocations.DELETE("/:id", permMiddleware.CanDelete("products"), dataScope.RequireRecord(models.DataScopeModuleWarehouseLocations, "id"), warehouseLocationController.DeleteWarehouseLocation)
+			}
+
+			// 📦 Per-warehouse stock balances and thresholds
+			warehouseStocks := protected.Group("/warehouse-stocks")
+			{
+				warehouseStocks.GET("", permMiddleware.CanView("products"), dataScope.Apply(models.DataScopeModuleWarehouseLocations), stockTransferController.GetWarehouseStocks)
+				warehouseStocks.PUT("/thresholds", permMiddleware.CanEdit("products"), dataScope.Apply(models.DataScopeModuleWarehouseLocations), stockTransferController.SetWarehouseThresholds)
+			}
+
+			// 🚚 Inter-warehouse stock transfers (DRAFT → IN_TRANSIT → RECEIVED)
+			stockTransfers := protected.Group("/stock-transfers")
+			{
+				stockTransfers.GET("", permMiddleware.CanView("products"), dataScope.Apply(models.DataScopeModuleWarehouseLocations), stockTransferController.GetTransfers)
+				stockTransfers.GET("/:id", permMiddleware.CanView("products"), dataScope.Apply(models.DataScopeModuleWarehouseLocations), stockTransferController.GetTransfer)
+				stockTransfers.POST("", permMiddleware.CanCreate("products"), dataScope.Apply(models.DataScopeModuleWarehouseLocations), stockTransferController.CreateTransfer)
+				stockTransfers.PUT("/:id", permMiddleware.CanEdit("products"), dataScope.Apply(models.DataScopeModuleWarehouseLocations), stockTransferController.UpdateTransfer)
+				stockTransfers.DELETE("/:id", permMiddleware.CanDelete("products"), dataScope.Apply(models.DataScopeModuleWarehouseLocations), stockTransferController.DeleteTransfer)
+				stockTransfers.POST("/:id/ship", permMiddleware.CanEdit("products"), dataScope.Apply(models.DataScopeModuleWarehouseLocations), stockTransferController.ShipTransfer)
+				stockTransfers.POST("/:id/receive", permMiddleware.CanEdit("products"), dataScope.Apply(models.DataScopeModuleWarehouseLocations), stockTransferController.ReceiveTransfer)
+				stockTransfers.POST("/:id/cancel", permMiddleware.CanEdit("products"), dataScope.Apply(models.DataScopeModuleWarehouseLocations), stockTransferController.CancelTransfer)
 			}
 
 			// 📊 Account routes (Chart of Accounts) dengan enhanced security
@@ -617,6 +650,7 @@ func SetupRoutes(r *gin.Engine, db *gorm.DB, startupService *services.StartupSer
 	
 	// Initialize PaymentService with PurchasePaymentJournalService for proper credit purchase payment handling
 	paymentService := services.NewPaymentService(db, paymentRepo, salesRepo, purchaseRepo, cashBankRepo, accountRepo, contactRepo, purchasePaymentJournalService)
+	paymentService.SetApprovalService(approvalService)
 	paymentController := controllers.NewPaymentController(paymentService)
 	cashBankService := services.NewCashBankService(db, cashBankRepo, accountRepo)
 	accountService := services.NewAccountService(accountRepo)
@@ -630,6 +664,8 @@ unifiedSalesPaymentService := services.NewUnifiedSalesPaymentService(db)
 	
 	// Initialize PurchaseController with PaymentService integration (moved here after paymentService is available)
 	purchaseController := controllers.NewPurchaseController(purchaseService, paymentService, db, accountRepo)
+	purchaseReturnController := controllers.NewPurchaseReturnController(services.NewPurchaseReturnService(db, pdfService))
+	deliveryOrderController := controllers.NewDeliveryOrderController(services.NewDeliveryOrderService(db, stockService, pdfService))
 
 			// 🔔 Notification routes (accessible by all authenticated users)
 			notifs := protected.Group("/notifications")
@@ -647,29 +683,38 @@ unifiedSalesPaymentService := services.NewUnifiedSalesPaymentService(db)
 				sales := protected.Group("/sales")
 			{
 				// Basic CRUD operations
-				sales.GET("", permMiddleware.CanView("sales"), salesController.GetSales)
-				sales.GET("/:id", permMiddleware.CanView("sales"), salesController.GetSale)
+				sales.GET("", permMiddleware.CanView("sales"), dataScope.Apply(models.DataScopeModuleSales), salesController.GetSales)
+				sales.GET("/:id", permMiddleware.CanView("sales"), dataScope.Apply(models.DataScopeModuleSales), salesController.GetSale)
 				// Validate stock for sales create form
 				sales.POST("/validate-stock", permMiddleware.CanCreate("sales"), salesController.ValidateSaleStock)
 				sales.POST("", permMiddleware.CanCreate("sales"), periodValidationMiddleware.ValidateTransactionPeriod(), salesController.CreateSale)
-				sales.PUT("/:id", permMiddleware.CanEdit("sales"), periodValidationMiddleware.ValidateTransactionPeriod(), salesController.UpdateSale)
-				sales.DELETE("/:id", permMiddleware.CanDelete("sales"), salesController.DeleteSale)
+				sales.PUT("/:id", permMiddleware.CanEdit("sales"), dataScope.RequireRecord(models.DataScopeModuleSales, "id"), periodValidationMiddleware.ValidateTransactionPeriod(), salesController.UpdateSale)
+				sales.DELETE("/:id", permMiddleware.CanDelete("sales"), dataScope.RequireRecord(models.DataScopeModuleSales, "id"), salesController.DeleteSale)
 
 				// Status management
-				sales.POST("/:id/confirm", middleware.RoleRequired("admin", "finance", "director"), salesController.ConfirmSale)
-				sales.POST("/:id/invoice", middleware.RoleRequired("admin", "finance", "director"), salesController.InvoiceSale)
-				sales.POST("/:id/cancel", middleware.RoleRequired("admin", "finance", "director"), salesController.CancelSale)
+				sales.POST("/:id/confirm", middleware.RoleRequired("admin", "finance", "director"), dataScope.RequireRecord(models.DataScopeModuleSales, "id"), salesController.ConfirmSale)
+				sales.POST("/:id/invoice", middleware.RoleRequired("admin", "finance", "director"), dataScope.RequireRecord(models.DataScopeModuleSales, "id"), salesController.InvoiceSale)
+				sales.POST("/:id/cancel", middleware.RoleRequired("admin", "finance", "director"), dataScope.RequireRecord(models.DataScopeModuleSales, "id"), salesController.CancelSale)
 
 				// Payment management
 				sales.GET("/:id/payments", middleware.RoleRequired("admin", "finance", "director", "employee"), salesController.GetSalePayments)
-				sales.POST("/:id/payments", middleware.RoleRequired("admin", "finance", "director"), salesController.CreateSalePayment)
+				sales.POST("/:id/payments", middleware.RoleRequired("admin", "finance", "director"), dataScope.RequireRecord(models.DataScopeModuleSales, "id"), salesController.CreateSalePayment)
 				
 				// Integrated Payment Management routes
 				sales.GET("/:id/for-payment", middleware.RoleRequired("admin", "finance", "director"), salesController.GetSaleForPayment)
-				sales.POST("/:id/integrated-payment", middleware.RoleRequired("admin", "finance", "director"), salesController.CreateIntegratedPayment)
+				sales.POST("/:id/integrated-payment", middleware.RoleRequired("admin", "finance", "director"), dataScope.RequireRecord(models.DataScopeModuleSales, "id"), salesController.CreateIntegratedPayment)
+
+				// Delivery orders (surat jalan) and backorders
+				sales.GET("/deliveries", permMiddleware.CanView("sales"), deliveryOrderController.GetDeliveryOrders)
+				sales.GET("/deliveries/:delivery_id", permMiddleware.CanView("sales"), deliveryOrderController.GetDeliveryOrder)
+				sales.GET("/deliveries/:delivery_id/pdf", permMiddleware.CanExport("sales"), deliveryOrderController.GetDeliveryNotePDF)
+				sales.POST("/deliveries/:delivery_id/cancel", middleware.RoleRequired("admin", "finance", "director"), deliveryOrderController.CancelDeliveryOrder)
+				sales.GET("/:id/deliveries", permMiddleware.CanView("sales"), deliveryOrderController.GetSaleDeliveryOrders)
+				sales.POST("/:id/deliveries", middleware.RoleRequired("admin", "finance", "director", "employee"), dataScope.RequireRecord(models.DataScopeModuleSales, "id"), deliveryOrderController.CreateDeliveryOrder)
+				sales.GET("/backorders", permMiddleware.CanView("sales"), deliveryOrderController.GetBackorders)
 
 				// Returns management
-				sales.POST("/:id/returns", middleware.RoleRequired("admin", "finance", "director"), salesController.CreateSaleReturn)
+				sales.POST("/:id/returns", middleware.RoleRequired("admin", "finance", "director"), dataScope.RequireRecord(models.DataScopeModuleSales, "id"), salesController.CreateSaleReturn)
 				sales.GET("/returns", middleware.RoleRequired("admin", "finance", "director"), salesController.GetSaleReturns)
 
 				// Analytics and reporting
@@ -678,10 +723,10 @@ unifiedSalesPaymentService := services.NewUnifiedSalesPaymentService(db)
 				sales.GET("/receivables", middleware.RoleRequired("admin", "finance", "director"), salesController.GetReceivablesReport)
 
 				// PDF exports
-				sales.GET("/:id/invoice/pdf", permMiddleware.CanExport("sales"), salesController.ExportSaleInvoicePDF)
-				sales.GET("/:id/receipt/pdf", permMiddleware.CanExport("sales"), salesController.ExportSaleReceiptPDF)
-				sales.GET("/report/pdf", permMiddleware.CanExport("sales"), salesController.ExportSalesReportPDF)
-				sales.GET("/report/csv", permMiddleware.CanExport("sales"), salesController.ExportSalesReportCSV)
+				sales.GET("/:id/invoice/pdf", permMiddleware.CanExport("sales"), dataScope.Apply(models.DataScopeModuleSales), salesController.ExportSaleInvoicePDF)
+				sales.GET("/:id/receipt/pdf", permMiddleware.CanExport("sales"), dataScope.Apply(models.DataScopeModuleSales), salesController.ExportSaleReceiptPDF)
+				sales.GET("/report/pdf", permMiddleware.CanExport("sales"), dataScope.Apply(models.DataScopeModuleSales), salesController.ExportSalesReportPDF)
+				sales.GET("/report/csv", permMiddleware.CanExport("sales"), dataScope.Apply(models.DataScopeModuleSales), salesController.ExportSalesReportCSV)
 
 				// Customer portal
 				sales.GET("/customer/:customer_id", middleware.RoleRequired("admin", "finance", "director"), salesController.GetCustomerSales)
@@ -715,7 +760,7 @@ unifiedSalesPaymentService := services.NewUnifiedSalesPaymentService(db)
 			}
 			
 			// 📄 Setup Invoice routes
-			SetupInvoiceRoutes(protected, db)
+			SetupInvoiceRoutes(protected, db, salesServiceV2)
 
 	// Initialize Balance Monitoring service and controller
 	balanceMonitoringService := services.NewBalanceMonitoringService(db)
@@ -738,13 +783,13 @@ unifiedSalesPaymentService := services.NewUnifiedSalesPaymentService(db)
 			// 🔒 PRODUCTION GUARD: Only enable legacy routes in development with explicit flag
 			if os.Getenv("ENABLE_LEGACY_PAYMENT_ROUTES") == "true" && isDevelopmentMode() {
 				log.Printf("⚠️ WARNING: Legacy payment routes enabled - may cause conflicts with SalesJournalServiceV2")
-				SetupPaymentRoutes(protected, paymentController, cashBankController, cashBankService, jwtManager, db)
+				SetupPaymentRoutes(protected, paymentController, cashBankController, cashBankService, jwtManager, db, dataScope)
 			} else {
 				log.Printf("✅ Legacy payment routes disabled - using SalesJournalServiceV2 consistent flow only")
 			}
 			
 		// ✅ NEW: Setup SSOT Payment routes with journal integration (prevents double posting)
-		SetupSSOTPaymentRoutes(protected, db, jwtManager)
+		SetupSSOTPaymentRoutes(protected, db, jwtManager, approvalService)
 
 		// 📄 Setup Receipt routes
 		SetupReceiptRoutes(protected, db, jwtManager)
@@ -785,32 +830,32 @@ unifiedSalesPaymentService := services.NewUnifiedSalesPaymentService(db)
 			SetupCashBankIntegratedRoutes(protected, db, jwtManager)
 			
 			// 💰 Setup NEW Cash-Bank routes with SSOT integration
-			SetupCashBankSSOTRoutes(v1, db, jwtManager)
+			SetupCashBankSSOTRoutes(v1, db, jwtManager, dataScope)
 
 			// 💰 Purchases routes with enhanced permission checks
 			purchases := protected.Group("/purchases")
 	purchases.Use(enhancedSecurity.RequestMonitoring()) // 📊 Enhanced monitoring
 			{
 				// Basic CRUD operations dengan enhanced security
-				purchases.GET("", permMiddleware.CanView("purchases"), purchaseController.GetPurchases)
+				purchases.GET("", permMiddleware.CanView("purchases"), dataScope.Apply(models.DataScopeModulePurchases), purchaseController.GetPurchases)
 				// Approval statistics (must be defined before parameterized "/:id" route)
 				purchases.GET("/approval-stats", permMiddleware.CanApprove("purchases"), purchaseApprovalHandler.GetApprovalStats)
-				purchases.GET("/:id", permMiddleware.CanView("purchases"), purchaseController.GetPurchase)
+				purchases.GET("/:id", permMiddleware.CanView("purchases"), dataScope.Apply(models.DataScopeModulePurchases), purchaseController.GetPurchase)
 				purchases.POST("", permMiddleware.CanCreate("purchases"), periodValidationMiddleware.ValidateTransactionPeriod(), purchaseController.CreatePurchase)
-				purchases.PUT("/:id", permMiddleware.CanEdit("purchases"), periodValidationMiddleware.ValidateTransactionPeriod(), purchaseController.UpdatePurchase)
-				purchases.DELETE("/:id", permMiddleware.CanDelete("purchases"), purchaseController.DeletePurchase)
+				purchases.PUT("/:id", permMiddleware.CanEdit("purchases"), dataScope.RequireRecord(models.DataScopeModulePurchases, "id"), periodValidationMiddleware.ValidateTransactionPeriod(), purchaseController.UpdatePurchase)
+				purchases.DELETE("/:id", permMiddleware.CanDelete("purchases"), dataScope.RequireRecord(models.DataScopeModulePurchases, "id"), purchaseController.DeletePurchase)
 				
 				// Approval operations dengan permission checks
-				purchases.POST("/:id/submit-approval", permMiddleware.CanCreate("purchases"), purchaseController.SubmitForApproval)
-				purchases.POST("/:id/approve", permMiddleware.CanApprove("purchases"), purchaseController.ApprovePurchase)
-				purchases.POST("/:id/reject", permMiddleware.CanApprove("purchases"), purchaseController.RejectPurchase)
+				purchases.POST("/:id/submit-approval", permMiddleware.CanCreate("purchases"), dataScope.RequireRecord(models.DataScopeModulePurchases, "id"), purchaseController.SubmitForApproval)
+				purchases.POST("/:id/approve", permMiddleware.CanApprove("purchases"), dataScope.RequireRecord(models.DataScopeModulePurchases, "id"), stepUp.Required(), purchaseController.ApprovePurchase)
+				purchases.POST("/:id/reject", permMiddleware.CanApprove("purchases"), dataScope.RequireRecord(models.DataScopeModulePurchases, "id"), purchaseController.RejectPurchase)
 				// Approval history endpoint (accessible by those who can view purchases)
 				purchases.GET("/:id/approval-history", permMiddleware.CanView("purchases"), purchaseApprovalHandler.GetApprovalHistory)
 				// Pending approvals (for those who can approve)
 				purchases.GET("/pending-approval", permMiddleware.CanApprove("purchases"), purchaseApprovalHandler.GetPurchasesForApproval)
 				
 				// Document management dengan permission checks
-				purchases.POST("/:id/documents", permMiddleware.CanEdit("purchases"), purchaseController.UploadDocument)
+				purchases.POST("/:id/documents", permMiddleware.CanEdit("purchases"), dataScope.RequireRecord(models.DataScopeModulePurchases, "id"), purchaseController.UploadDocument)
 				purchases.GET("/:id/documents", permMiddleware.CanView("purchases"), purchaseController.GetPurchaseDocuments)
 				purchases.DELETE("/documents/:document_id", permMiddleware.CanDelete("purchases"), purchaseController.DeleteDocument)
 				
@@ -824,6 +869,7 @@ unifiedSalesPaymentService := services.NewUnifiedSalesPaymentService(db)
 				
 				// Analytics and reporting dengan permission checks
 				purchases.GET("/summary", permMiddleware.CanView("purchases"), purchaseController.GetPurchasesSummary)
+				purchases.GET("/payables", middleware.RoleRequired("admin", "finance", "director"), purchaseController.GetPayablesReport)
 				purchases.GET("/pending-approvals", permMiddleware.CanApprove("purchases"), purchaseController.GetPendingApprovals)
 				
 				// Export routes dengan permission checks
@@ -834,15 +880,23 @@ unifiedSalesPaymentService := services.NewUnifiedSalesPaymentService(db)
 				
 				// Payment management (similar to sales payment management)
 				purchases.GET("/:id/payments", middleware.RoleRequired("admin", "finance", "director", "employee"), purchaseController.GetPurchasePayments)
-				purchases.POST("/:id/payments", middleware.RoleRequired("admin", "finance", "director"), purchaseController.CreatePurchasePayment)
+				purchases.POST("/:id/payments", middleware.RoleRequired("admin", "finance", "director"), dataScope.RequireRecord(models.DataScopeModulePurchases, "id"), purchaseController.CreatePurchasePayment)
 				
 				// Integrated Payment Management routes  
 				purchases.GET("/:id/for-payment", middleware.RoleRequired("admin", "finance", "director"), purchaseController.GetPurchaseForPayment)
-				purchases.POST("/:id/integrated-payment", middleware.RoleRequired("admin", "finance", "director"), purchaseController.CreateIntegratedPayment)
+				purchases.POST("/:id/integrated-payment", middleware.RoleRequired("admin", "finance", "director"), dataScope.RequireRecord(models.DataScopeModulePurchases, "id"), purchaseController.CreateIntegratedPayment)
+
+				// Returns, debit notes and vendor credits
+				purchases.GET("/returns", permMiddleware.CanView("purchases"), purchaseReturnController.GetPurchaseReturns)
+				purchases.GET("/returns/:return_id", permMiddleware.CanView("purchases"), purchaseReturnController.GetPurchaseReturn)
+				purchases.GET("/returns/:return_id/debit-note/pdf", permMiddleware.CanExport("purchases"), purchaseReturnController.GetDebitNotePDF)
+				purchases.POST("/:id/returns", middleware.RoleRequired("admin", "finance", "director"), dataScope.RequireRecord(models.DataScopeModulePurchases, "id"), periodValidationMiddleware.ValidateTransactionPeriod(), purchaseReturnController.CreatePurchaseReturn)
+				purchases.GET("/vendor/:vendor_id/credits", permMiddleware.CanView("purchases"), purchaseReturnController.GetVendorCredits)
+				purchases.POST("/:id/apply-vendor-credit", middleware.RoleRequired("admin", "finance", "director"), dataScope.RequireRecord(models.DataScopeModulePurchases, "id"), purchaseReturnController.ApplyVendorCredit)
 				
 				// Three-way matching dengan permission checks
 				purchases.GET("/:id/matching", permMiddleware.CanView("purchases"), purchaseController.GetPurchaseMatching)
-				purchases.POST("/:id/validate-matching", permMiddleware.CanApprove("purchases"), purchaseController.ValidateThreeWayMatching)
+				purchases.POST("/:id/validate-matching", permMiddleware.CanApprove("purchases"), dataScope.RequireRecord(models.DataScopeModulePurchases, "id"), purchaseController.ValidateThreeWayMatching)
 				
 				// Journal entries integration dengan SSOT Journal System
 				purchases.GET("/:id/journal-entries", permMiddleware.CanView("reports"), purchaseController.GetPurchaseJournalEntries)
@@ -874,6 +928,20 @@ unifiedSalesPaymentService := services.NewUnifiedSalesPaymentService(db)
 				assets.GET("/depreciation-report", permMiddleware.CanView("assets"), assetController.GetDepreciationReport)
 				assets.GET("/:id/depreciation-schedule", permMiddleware.CanView("assets"), assetController.GetDepreciationSchedule)
 				assets.GET("/:id/calculate-depreciation", permMiddleware.CanView("assets"), assetController.CalculateCurrentDepreciation)
+				assets.GET("/:id/depreciation-ledger", permMiddleware.CanView("assets"), assetDepreciationController.GetAssetLedger)
+				
+				// Monthly batch depreciation (dry run, posting and rollback before period close)
+				assets.GET("/depreciation-runs", permMiddleware.CanView("assets"), assetDepreciationController.GetRuns)
+				assets.GET("/depreciation-runs/:run_id", permMiddleware.CanView("assets"), assetDepreciationController.GetRun)
+				assets.POST("/depreciation-runs/preview", permMiddleware.CanView("assets"), assetDepreciationController.PreviewRun)
+				assets.POST("/depreciation-runs", permMiddleware.CanEdit("assets"), middleware.RoleRequired("admin", "finance"), assetDepreciationController.CreateRun)
+				assets.POST("/depreciation-runs/:run_id/rollback", permMiddleware.CanEdit("assets"), middleware.RoleRequired("admin", "finance"), assetDepreciationController.RollbackRun)
+				
+				// Disposal, sale, write-off and impairment
+				assets.GET("/disposals", permMiddleware.CanView("assets"), assetDisposalController.GetDisposals)
+				assets.GET("/disposals/:disposal_id", permMiddleware.CanView("assets"), assetDisposalController.GetDisposal)
+				assets.POST("/:id/dispose", permMiddleware.CanEdit("assets"), middleware.RoleRequired("admin", "finance"), assetDisposalController.DisposeAsset)
+				assets.POST("/:id/impair", permMiddleware.CanEdit("assets"), middleware.RoleRequired("admin", "finance"), assetDisposalController.ImpairAsset)
 				
 				// Export routes - REMOVED: Not implemented yet
 			}
@@ -903,7 +971,7 @@ unifiedSalesPaymentService := services.NewUnifiedSalesPaymentService(db)
 			{
 				employeeApprovals.GET("/requests", employeeApprovalHandler.GetMyApprovalRequests)
 				employeeApprovals.GET("/pending", employeeApprovalHandler.GetPendingApprovalsForMe)
-				employeeApprovals.POST("/:id/process", employeeApprovalHandler.ProcessApproval)
+				employeeApprovals.POST("/:id/process", stepUp.Required(), employeeApprovalHandler.ProcessApproval)
 				employeeApprovals.GET("/:id/history", employeeApprovalHandler.GetApprovalHistory)
 				employeeApprovals.GET("/my-requests", employeeApprovalHandler.GetMySubmittedRequests)
 				employeeApprovals.GET("/workflows", employeeApprovalHandler.GetApprovalWorkflowsForEmployee)
@@ -919,6 +987,54 @@ unifiedSalesPaymentService := services.NewUnifiedSalesPaymentService(db)
 			
 			// Setup Settings routes
 			SetupSettingsRoutes(protected, db)
+
+			// 📊 Budget routes (budgets per fiscal year and budget-vs-actual reporting)
+			SetupBudgetRoutes(protected, db, approvalService, pdfService)
+
+			// 💱 Multi-currency routes (exchange rates and month-end FX revaluation)
+			SetupCurrencyRoutes(protected, db)
+
+			// 🏦 Bank statement import (CSV/MT940/OFX) and statement-based reconciliation
+			SetupBankStatementRoutes(protected, db)
+
+			// 🔁 Recurring journals and recurring expenses
+			SetupRecurringJournalRoutes(protected, db)
+
+			// 🧾 e-Faktur tax invoice numbering, PPN export/import and SPT Masa PPN worksheet
+			SetupTaxInvoiceRoutes(protected, db)
+
+			// 🏷️ Analytic dimensions (cost centers, projects, tags) and mandatory dimension rules
+			SetupDimensionRoutes(protected, db)
+
+			// 📄 Customer/vendor statements of account with aging (single PDF or batch ZIP)
+			SetupStatementRoutes(protected, db, pdfService)
+
+			// 🛑 Customer credit control: exposure, credit holds and sale credit checks
+			SetupCreditControlRoutes(protected, db)
+
+			// 💵 Customer down payments and vendor prepayments, applied to invoices
+			SetupAdvancePaymentRoutes(protected, db)
+
+			// 🛒 Purchase requisitions, approved and consolidated into purchase orders
+			SetupPurchaseRequisitionRoutes(protected, db, approvalService, purchaseService)
+
+			// 🏷️ Vendor price lists and requests for quotation
+			SetupVendorPriceRoutes(protected, db)
+
+			// 🔐 Two-factor authentication: enrolment, step-up and trusted devices
+			SetupTwoFactorRoutes(protected, db, twoFactorService, stepUp)
+
+			// 🔒 Data scopes: administration and record visibility explanation
+			SetupDataScopeRoutes(protected, dataScopeService, dataScope)
+
+			// 🧭 Approval routing: delegations, workflow preview and write-off submissions
+			SetupApprovalRoutingRoutes(protected, db, approvalService)
+
+			// 📧 Outbound email: invoice/receipt/statement emails, queue, templates and SMTP test
+			SetupEmailRoutes(protected, db, permMiddleware, dataScope)
+
+			// 🔗 Outgoing webhooks: subscriptions, delivery log and replay
+			SetupWebhookRoutes(protected, db)
 			
 		// 🏁 Fiscal Year-End Closing routes (USING UNIFIED SYSTEM)
 		fiscalClosing := protected.Group("/fiscal-closing")
@@ -926,7 +1042,7 @@ unifiedSalesPaymentService := services.NewUnifiedSalesPaymentService(db)
 		{
 			// Use period closing for preview and execute (more flexible)
 			fiscalClosing.GET("/preview", periodClosingController.PreviewClosing)
-			fiscalClosing.POST("/execute", periodClosingController.ExecuteClosing)
+			fiscalClosing.POST("/execute", stepUp.Required(), periodClosingController.ExecuteClosing)
 			// Use unified history controller that checks all sources
 			fiscalClosing.GET("/history", unifiedClosingHistoryController.GetUnifiedClosingHistory)
 		}
@@ -937,8 +1053,8 @@ unifiedSalesPaymentService := services.NewUnifiedSalesPaymentService(db)
 		{
 			periodClosing.GET("/last-info", periodClosingController.GetLastClosingInfo)        // Get last closing info
 			periodClosing.GET("/preview", periodClosingController.PreviewClosing)              // Preview period closing
-			periodClosing.POST("/execute", periodClosingController.ExecuteClosing)             // Execute period closing
-			periodClosing.POST("/reopen", periodClosingController.ReopenPeriod)                // Reopen closed period
+			periodClosing.POST("/execute", stepUp.Required(), periodClosingController.ExecuteClosing) // Execute period closing
+			periodClosing.POST("/reopen", stepUp.Required(), periodClosingController.ReopenPeriod)    // Reopen closed period
 			periodClosing.GET("/history", unifiedClosingHistoryController.GetUnifiedClosingHistory) // Use unified history
 			periodClosing.GET("/check-date", periodClosingController.CheckDateInClosedPeriod) // Check if date is closed
 		}
@@ -1021,6 +1137,9 @@ unifiedSalesPaymentService := services.NewUnifiedSalesPaymentService(db)
 				cogsRoutes.GET("/missing", cogsController.GetSalesWithoutCOGS)      // Get sales without COGS entries
 				cogsRoutes.POST("/backfill", cogsController.BackfillCOGS)           // Backfill COGS for existing sales
 				cogsRoutes.POST("/record/:sale_id", cogsController.RecordCOGSForSale) // Record COGS for specific sale
+				cogsRoutes.GET("/valuation", cogsController.GetInventoryValuation)   // Inventory valuation vs inventory GL account
+				cogsRoutes.GET("/layers/:sale_id", cogsController.GetSaleCostLayers)  // Cost layers consumed by a sale
+				cogsRoutes.POST("/recost", cogsController.RecostInventory)          // Re-cost sales after back-dated receipts
 			}
 
 			// 📊 SSOT Balance Sheet Controller - Direct Balance Sheet endpoint for frontend
@@ -1028,6 +1147,9 @@ unifiedSalesPaymentService := services.NewUnifiedSalesPaymentService(db)
 			
 			// 💰 SSOT Cash Flow Controller - Direct Cash Flow endpoint for frontend
 			ssotCFController := controllers.NewSSOTCashFlowController(db)
+
+			// 📈 Comparative statements - period-over-period and budget columns for P&L, balance sheet and cash flow
+			comparativeController := controllers.NewComparativeStatementController(db)
 			
 			// Balance Sheet Report routes for frontend integration
 			ssotBSReports := v1.Group("/reports/ssot")
@@ -1044,6 +1166,10 @@ unifiedSalesPaymentService := services.NewUnifiedSalesPaymentService(db)
 				ssotBSReports.GET("/cash-flow", ssotCFController.GetSSOTCashFlow)
 				ssotBSReports.GET("/cash-flow/summary", ssotCFController.GetSSOTCashFlowSummary)
 				ssotBSReports.GET("/cash-flow/validate", ssotCFController.ValidateSSOTCashFlow)
+
+				// 📈 Comparative statements (:statement = profit-loss, balance-sheet, cash-flow)
+				ssotBSReports.GET("/comparative/:statement", comparativeController.GetComparativeStatement)
+				ssotBSReports.POST("/comparative/:statement", comparativeController.PostComparativeStatement)
 			}
 
 			// Monitoring routes (admin only)
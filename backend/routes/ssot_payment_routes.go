@@ -10,7 +10,7 @@ import (
 )
 
 // SetupSSOTPaymentRoutes sets up payment routes using SSOT journal integration
-func SetupSSOTPaymentRoutes(router *gin.RouterGroup, db *gorm.DB, jwtManager *middleware.JWTManager) {
+func SetupSSOTPaymentRoutes(router *gin.RouterGroup, db *gorm.DB, jwtManager *middleware.JWTManager, approvalService *services.ApprovalService) {
 	// Initialize repositories
 	paymentRepo := repositories.NewPaymentRepository(db)
 	contactRepo := repositories.NewContactRepository(db)
@@ -35,6 +35,7 @@ func SetupSSOTPaymentRoutes(router *gin.RouterGroup, db *gorm.DB, jwtManager *mi
 		purchaseRepo,
 		unifiedJournalService,
 	)
+	enhancedPaymentService.SetApprovalService(approvalService)
 
 	// Initialize SSOT Payment Controller
 	ssotPaymentController := controllers.NewSSOTPaymentController(enhancedPaymentService)
@@ -0,0 +1,23 @@
+package routes
+
+import (
+	"app-sistem-akuntansi/controllers"
+	"app-sistem-akuntansi/middleware"
+	"app-sistem-akuntansi/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// SetupStatementRoutes registers customer/vendor statement of account routes
+func SetupStatementRoutes(protected *gin.RouterGroup, db *gorm.DB, pdfService services.PDFServiceInterface) {
+	statementService := services.NewStatementService(db, pdfService)
+	statementController := controllers.NewStatementController(statementService)
+
+	statements := protected.Group("/statements")
+	statements.Use(middleware.RoleRequired("admin", "finance", "director"))
+	{
+		statements.GET("/contacts/:contact_id", statementController.GetContactStatement)
+		statements.GET("/customers/batch", statementController.GetCustomerStatementsBatch)
+	}
+}
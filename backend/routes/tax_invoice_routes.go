@@ -0,0 +1,38 @@
+package routes
+
+import (
+	"app-sistem-akuntansi/controllers"
+	"app-sistem-akuntansi/middleware"
+	"app-sistem-akuntansi/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// SetupTaxInvoiceRoutes registers e-Faktur numbering, export/import and SPT Masa PPN routes
+func SetupTaxInvoiceRoutes(protected *gin.RouterGroup, db *gorm.DB) {
+	taxInvoiceService := services.NewTaxInvoiceService(db)
+	taxInvoiceController := controllers.NewTaxInvoiceController(taxInvoiceService)
+
+	taxInvoices := protected.Group("/tax-invoices")
+	taxInvoices.Use(middleware.RoleRequired("admin", "finance", "director"))
+	{
+		// NSFP ranges allocated by DJP
+		taxInvoices.GET("/ranges", taxInvoiceController.GetNumberRanges)
+		taxInvoices.POST("/ranges", middleware.RoleRequired("admin", "finance"), taxInvoiceController.CreateNumberRange)
+		taxInvoices.POST("/ranges/:range_id/close", middleware.RoleRequired("admin", "finance"), taxInvoiceController.CloseNumberRange)
+
+		// Tax invoices (PPN Keluaran for sales, PPN Masukan for purchases)
+		taxInvoices.GET("", taxInvoiceController.GetTaxInvoices)
+		taxInvoices.POST("/sales/:sale_id", middleware.RoleRequired("admin", "finance"), taxInvoiceController.AllocateSaleInvoice)
+		taxInvoices.POST("/purchases/:purchase_id", middleware.RoleRequired("admin", "finance"), taxInvoiceController.RecordPurchaseInvoice)
+		taxInvoices.POST("/input/import", middleware.RoleRequired("admin", "finance"), taxInvoiceController.ImportInputInvoices)
+		taxInvoices.POST("/:id/match", middleware.RoleRequired("admin", "finance"), taxInvoiceController.MatchInputInvoice)
+		taxInvoices.POST("/:id/cancel", middleware.RoleRequired("admin", "finance"), taxInvoiceController.CancelTaxInvoice)
+
+		// e-Faktur exports and SPT Masa PPN
+		taxInvoices.GET("/export/output", taxInvoiceController.ExportOutputInvoices)
+		taxInvoices.GET("/export/input", taxInvoiceController.ExportInputInvoices)
+		taxInvoices.GET("/spt-masa", taxInvoiceController.GetSPTMasaWorksheet)
+	}
+}
@@ -0,0 +1,29 @@
+package routes
+
+import (
+	"app-sistem-akuntansi/controllers"
+	"app-sistem-akuntansi/middleware"
+	"app-sistem-akuntansi/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// SetupTwoFactorRoutes registers TOTP enrolment, step-up and trusted device routes for the signed-in
+// user. Completing a login with a code lives under /auth/2fa/verify because it has no token yet.
+func SetupTwoFactorRoutes(protected *gin.RouterGroup, db *gorm.DB, twoFactorService *services.TwoFactorService, stepUp *middleware.StepUpMiddleware) {
+	twoFactorController := controllers.NewTwoFactorController(db, twoFactorService)
+
+	twoFactor := protected.Group("/two-factor")
+	{
+		twoFactor.GET("/status", twoFactorController.GetStatus)
+		twoFactor.POST("/enroll", twoFactorController.BeginEnrollment)
+		twoFactor.POST("/enroll/confirm", twoFactorController.ConfirmEnrollment)
+		twoFactor.POST("/disable", twoFactorController.Disable)
+		twoFactor.POST("/recovery-codes", twoFactorController.RegenerateRecoveryCodes)
+		twoFactor.POST("/step-up", twoFactorController.StepUp)
+		twoFactor.GET("/trusted-devices", twoFactorController.GetTrustedDevices)
+		twoFactor.DELETE("/trusted-devices/:id", twoFactorController.RevokeTrustedDevice)
+		twoFactor.POST("/users/:id/reset", middleware.RoleRequired("admin"), stepUp.Required(), twoFactorController.ResetUserTwoFactor)
+	}
+}
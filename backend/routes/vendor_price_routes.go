@@ -0,0 +1,43 @@
+package routes
+
+import (
+	"app-sistem-akuntansi/controllers"
+	"app-sistem-akuntansi/middleware"
+	"app-sistem-akuntansi/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// SetupVendorPriceRoutes registers vendor price list and request-for-quotation routes. Everyone who
+// raises purchases can look prices up; purchasing staff maintain them and run RFQs.
+func SetupVendorPriceRoutes(protected *gin.RouterGroup, db *gorm.DB) {
+	vendorPriceController := controllers.NewVendorPriceController(services.NewVendorPriceService(db))
+
+	viewRoles := middleware.RoleRequired("admin", "finance", "director", "inventory_manager", "employee")
+	manageRoles := middleware.RoleRequired("admin", "finance", "inventory_manager")
+
+	prices := protected.Group("/vendor-prices")
+	{
+		prices.GET("", viewRoles, vendorPriceController.GetPriceLists)
+		prices.GET("/best", viewRoles, vendorPriceController.GetBestPrice)
+		prices.GET("/:id", viewRoles, vendorPriceController.GetPriceList)
+		prices.POST("", manageRoles, vendorPriceController.CreatePriceList)
+		prices.PUT("/:id", manageRoles, vendorPriceController.UpdatePriceList)
+		prices.DELETE("/:id", manageRoles, vendorPriceController.DeletePriceList)
+	}
+
+	rfqs := protected.Group("/rfqs")
+	{
+		rfqs.GET("", viewRoles, vendorPriceController.GetRFQs)
+		rfqs.GET("/:id", viewRoles, vendorPriceController.GetRFQ)
+		rfqs.GET("/:id/comparison", viewRoles, vendorPriceController.CompareRFQ)
+		rfqs.POST("", manageRoles, vendorPriceController.CreateRFQ)
+		rfqs.PUT("/:id", manageRoles, vendorPriceController.UpdateRFQ)
+		rfqs.DELETE("/:id", manageRoles, vendorPriceController.DeleteRFQ)
+		rfqs.POST("/:id/send", manageRoles, vendorPriceController.SendRFQ)
+		rfqs.POST("/:id/vendors/:vendor_id/reply", manageRoles, vendorPriceController.RecordReply)
+		rfqs.POST("/:id/award", middleware.RoleRequired("admin", "finance", "director"), vendorPriceController.AwardRFQ)
+		rfqs.POST("/:id/cancel", manageRoles, vendorPriceController.CancelRFQ)
+	}
+}
@@ -0,0 +1,34 @@
+package routes
+
+import (
+	"app-sistem-akuntansi/controllers"
+	"app-sistem-akuntansi/middleware"
+	"app-sistem-akuntansi/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// SetupWebhookRoutes registers webhook subscriptions and the delivery log with replay (admin only)
+func SetupWebhookRoutes(protected *gin.RouterGroup, db *gorm.DB) {
+	webhookController := controllers.NewWebhookController(services.NewWebhookService(db))
+
+	webhooks := protected.Group("/webhooks")
+	webhooks.Use(middleware.RoleRequired("admin"))
+	{
+		webhooks.GET("/event-types", webhookController.GetEventTypes)
+
+		webhooks.GET("/subscriptions", webhookController.GetSubscriptions)
+		webhooks.POST("/subscriptions", webhookController.CreateSubscription)
+		webhooks.GET("/subscriptions/:id", webhookController.GetSubscription)
+		webhooks.PUT("/subscriptions/:id", webhookController.UpdateSubscription)
+		webhooks.DELETE("/subscriptions/:id", webhookController.DeleteSubscription)
+		webhooks.POST("/subscriptions/:id/enable", webhookController.EnableSubscription)
+		webhooks.POST("/subscriptions/:id/rotate-secret", webhookController.RotateSecret)
+		webhooks.POST("/subscriptions/:id/ping", webhookController.SendPing)
+
+		webhooks.GET("/deliveries", webhookController.GetDeliveries)
+		webhooks.GET("/deliveries/:id", webhookController.GetDelivery)
+		webhooks.POST("/deliveries/:id/replay", webhookController.ReplayDelivery)
+	}
+}
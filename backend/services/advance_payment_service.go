@@ -0,0 +1,690 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"app-sistem-akuntansi/models"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Default accounts for down payments and prepayments (see SeedAccountsImproved)
+const (
+	CustomerDepositAccountCode = "2106" // Uang Muka Pelanggan
+	VendorAdvanceAccountCode   = "1117" // Uang Muka Pembelian
+	AdvancePPNOutputCode       = "2103" // PPN Keluaran
+	AdvancePPNInputCode        = "1240" // PPN Masukan
+	AdvanceReceivableCode      = "1201" // Piutang Usaha
+	AdvancePayableCode         = "2101" // Utang Usaha
+)
+
+// ReferenceType of cash-bank transactions created by down payments and prepayments
+const CashBankRefAdvancePayment = "ADVANCE_PAYMENT"
+
+// AdvancePaymentService handles customer down payments and vendor prepayments. PPN is due on an
+// advance when it is received or paid, so the gross amount is split into DPP and PPN up front:
+//
+//	Customer: Dr Cash/Bank, Cr Uang Muka Pelanggan (DPP), Cr PPN Keluaran (PPN)
+//	Vendor:   Dr Uang Muka Pembelian (DPP), Dr PPN Masukan (PPN), Cr Cash/Bank
+//
+// Applying an advance to an invoice releases the same share of DPP and PPN against the
+// receivable or payable, so the PPN of the final invoice is only counted once.
+type AdvancePaymentService struct {
+	db             *gorm.DB
+	journalService *UnifiedJournalService
+	periodService  *UnifiedPeriodClosingService
+}
+
+func NewAdvancePaymentService(db *gorm.DB) *AdvancePaymentService {
+	return &AdvancePaymentService{
+		db:             db,
+		journalService: NewUnifiedJournalService(db),
+		periodService:  NewUnifiedPeriodClosingService(db),
+	}
+}
+
+// ========== QUERIES ==========
+
+// GetAdvancePayments - Daftar uang muka, opsional difilter tipe, kontak dan status; openOnly hanya yang masih bisa dipakai
+func (s *AdvancePaymentService) GetAdvancePayments(advanceType string, contactID uint, status string, openOnly bool) ([]models.AdvancePayment, error) {
+	var advances []models.AdvancePayment
+	query := s.db.Preload("Contact").Preload("CashBank")
+	if advanceType != "" {
+		query = query.Where("type = ?", advanceType)
+	}
+	if contactID > 0 {
+		query = query.Where("contact_id = ?", contactID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if openOnly {
+		query = query.Where("status = ? AND remaining_amount > 0", models.AdvancePaymentStatusOpen)
+	}
+	err := query.Order("date DESC, id DESC").Find(&advances).Error
+	return advances, err
+}
+
+// GetAdvancePaymentByID - Detail uang muka beserta penerapannya ke faktur
+func (s *AdvancePaymentService) GetAdvancePaymentByID(id uint) (*models.AdvancePayment, error) {
+	var advance models.AdvancePayment
+	err := s.db.Preload("Contact").Preload("CashBank").
+		Preload("Applications", func(db *gorm.DB) *gorm.DB { return db.Order("date ASC, id ASC") }).
+		Preload("Applications.Sale").Preload("Applications.Purchase").
+		First(&advance, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &advance, nil
+}
+
+// ========== TRANSACTIONS ==========
+
+// CreateAdvancePayment records a customer down payment (money in) or a vendor prepayment (money out)
+func (s *AdvancePaymentService) CreateAdvancePayment(advanceType string, req models.AdvancePaymentRequest, userID uint) (*models.AdvancePayment, error) {
+	date := dateOnly(req.Date)
+	if err := s.validateDate(date); err != nil {
+		return nil, err
+	}
+	if advanceType != models.AdvancePaymentTypeCustomer && advanceType != models.AdvancePaymentTypeVendor {
+		return nil, fmt.Errorf("unsupported advance type %s", advanceType)
+	}
+
+	var advanceID uint
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var contact models.Contact
+		if err := tx.First(&contact, req.ContactID).Error; err != nil {
+			return errors.New("contact not found")
+		}
+		if advanceType == models.AdvancePaymentTypeCustomer && contact.Type != models.ContactTypeCustomer {
+			return errors.New("a down payment can only be received from a customer")
+		}
+		if advanceType == models.AdvancePaymentTypeVendor && contact.Type != models.ContactTypeVendor {
+			return errors.New("a prepayment can only be made to a vendor")
+		}
+
+		cashBank, err := s.lockCashBank(tx, req.CashBankID)
+		if err != nil {
+			return err
+		}
+
+		gross := decimal.NewFromFloat(req.Amount).Round(2)
+		net, ppn := splitAdvancePPN(gross, req.PPNRate)
+
+		if advanceType == models.AdvancePaymentTypeVendor && cashBank.Balance < gross.InexactFloat64() {
+			return fmt.Errorf("insufficient balance. Available: %.2f", cashBank.Balance)
+		}
+
+		code, err := s.generateAdvanceCode(tx, advanceType, date)
+		if err != nil {
+			return fmt.Errorf("failed to generate advance payment code: %v", err)
+		}
+
+		paymentType, method := models.PaymentTypeAdvanceReceipt, models.PaymentMethodBankTransfer
+		if advanceType == models.AdvancePaymentTypeVendor {
+			paymentType = models.PaymentTypeVendorPrepayment
+		}
+		if cashBank.Type == "CASH" {
+			method = models.PaymentMethodCash
+		}
+		payment := models.Payment{
+			Code:         code,
+			ContactID:    contact.ID,
+			UserID:       userID,
+			Date:         date,
+			Amount:       gross.InexactFloat64(),
+			Currency:     "IDR",
+			ExchangeRate: 1,
+			Method:       method,
+			Reference:    req.Reference,
+			Status:       models.PaymentStatusCompleted,
+			PaymentType:  paymentType,
+			Notes:        req.Notes,
+		}
+		if err := tx.Omit(clause.Associations).Create(&payment).Error; err != nil {
+			return fmt.Errorf("failed to create payment: %v", err)
+		}
+
+		advance := models.AdvancePayment{
+			Code:            code,
+			Type:            advanceType,
+			ContactID:       contact.ID,
+			Date:            date,
+			CashBankID:      cashBank.ID,
+			Amount:          gross.InexactFloat64(),
+			PPNRate:         req.PPNRate,
+			PPNAmount:       ppn.InexactFloat64(),
+			NetAmount:       net.InexactFloat64(),
+			RemainingAmount: gross.InexactFloat64(),
+			Status:          models.AdvancePaymentStatusOpen,
+			Reference:       req.Reference,
+			Notes:           req.Notes,
+			PaymentID:       &payment.ID,
+			UserID:          userID,
+		}
+		if err := tx.Omit(clause.Associations).Create(&advance).Error; err != nil {
+			return fmt.Errorf("failed to create advance payment: %v", err)
+		}
+
+		var lines []JournalLineRequest
+		var description string
+		if advanceType == models.AdvancePaymentTypeCustomer {
+			description = fmt.Sprintf("Uang muka pelanggan %s - %s", code, contact.Name)
+			deposit, err := s.accountByCode(tx, CustomerDepositAccountCode)
+			if err != nil {
+				return err
+			}
+			lines = append(lines,
+				JournalLineRequest{AccountID: uint64(cashBank.AccountID), DebitAmount: gross, Description: description},
+				JournalLineRequest{AccountID: uint64(deposit.ID), CreditAmount: net, Description: description},
+			)
+			if ppn.GreaterThan(decimal.Zero) {
+				ppnOutput, err := s.accountByCode(tx, AdvancePPNOutputCode)
+				if err != nil {
+					return err
+				}
+				lines = append(lines, JournalLineRequest{AccountID: uint64(ppnOutput.ID), CreditAmount: ppn, Description: description})
+			}
+		} else {
+			description = fmt.Sprintf("Uang muka pembelian %s - %s", code, contact.Name)
+			vendorAdvance, err := s.accountByCode(tx, VendorAdvanceAccountCode)
+			if err != nil {
+				return err
+			}
+			lines = append(lines, JournalLineRequest{AccountID: uint64(vendorAdvance.ID), DebitAmount: net, Description: description})
+			if ppn.GreaterThan(decimal.Zero) {
+				ppnInput, err := s.accountByCode(tx, AdvancePPNInputCode)
+				if err != nil {
+					return err
+				}
+				lines = append(lines, JournalLineRequest{AccountID: uint64(ppnInput.ID), DebitAmount: ppn, Description: description})
+			}
+			lines = append(lines, JournalLineRequest{AccountID: uint64(cashBank.AccountID), CreditAmount: gross, Description: description})
+		}
+
+		journalID, err := s.postJournal(tx, payment.ID, code, date, description, lines, userID)
+		if err != nil {
+			return err
+		}
+		advance.JournalEntryID = &journalID
+		if err := tx.Model(&advance).Update("journal_entry_id", journalID).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&payment).Update("journal_entry_id", journalID).Error; err != nil {
+			return err
+		}
+
+		cashAmount := advance.Amount
+		if advanceType == models.AdvancePaymentTypeVendor {
+			cashAmount = -cashAmount
+		}
+		if err := s.moveCash(tx, cashBank, advance.ID, cashAmount, date, description); err != nil {
+			return err
+		}
+
+		advanceID = advance.ID
+		log.Printf("💰 Advance %s recorded for %s: gross %.2f, DPP %.2f, PPN %.2f", code, contact.Name, advance.Amount, advance.NetAmount, advance.PPNAmount)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetAdvancePaymentByID(advanceID)
+}
+
+// ApplyAdvancePayment settles part of a sale invoice or purchase from an open advance:
+//
+//	Customer: Dr Uang Muka Pelanggan (DPP share), Dr PPN Keluaran (PPN share), Cr Piutang Usaha
+//	Vendor:   Dr Utang Usaha, Cr Uang Muka Pembelian (DPP share), Cr PPN Masukan (PPN share)
+func (s *AdvancePaymentService) ApplyAdvancePayment(id uint, req models.AdvancePaymentApplyRequest, userID uint) (*models.AdvancePayment, error) {
+	date := dateOnly(req.Date)
+	if req.Date.IsZero() {
+		date = dateOnly(time.Now())
+	}
+	if err := s.validateDate(date); err != nil {
+		return nil, err
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var advance models.AdvancePayment
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&advance, id).Error; err != nil {
+			return errors.New("advance payment not found")
+		}
+		if advance.Status != models.AdvancePaymentStatusOpen || req.Amount > advance.RemainingAmount+0.005 {
+			return fmt.Errorf("amount exceeds remaining advance (%.2f)", advance.RemainingAmount)
+		}
+		if date.Before(dateOnly(advance.Date)) {
+			return errors.New("an advance cannot be applied before the date it was recorded")
+		}
+
+		gross := decimal.NewFromFloat(req.Amount).Round(2)
+		ppn := s.ppnShare(&advance, gross)
+		net := gross.Sub(ppn)
+
+		application := models.AdvancePaymentApplication{
+			AdvancePaymentID: advance.ID,
+			Date:             date,
+			Amount:           gross.InexactFloat64(),
+			PPNAmount:        ppn.InexactFloat64(),
+			UserID:           userID,
+		}
+
+		var err error
+		if advance.Type == models.AdvancePaymentTypeCustomer {
+			err = s.applyToSale(tx, &advance, &application, req, net, ppn, userID)
+		} else {
+			err = s.applyToPurchase(tx, &advance, &application, req, net, ppn, userID)
+		}
+		if err != nil {
+			return err
+		}
+		if err := tx.Omit(clause.Associations).Create(&application).Error; err != nil {
+			return fmt.Errorf("failed to record advance application: %v", err)
+		}
+
+		advance.AppliedAmount = roundAmount(advance.AppliedAmount + application.Amount)
+		advance.AppliedPPN = roundAmount(advance.AppliedPPN + application.PPNAmount)
+		advance.RemainingAmount = roundAmount(advance.Amount - advance.AppliedAmount)
+		if advance.RemainingAmount <= 0.005 {
+			advance.RemainingAmount = 0
+			advance.Status = models.AdvancePaymentStatusApplied
+		}
+		return tx.Model(&advance).Updates(map[string]interface{}{
+			"applied_amount":   advance.AppliedAmount,
+			"applied_ppn":      advance.AppliedPPN,
+			"remaining_amount": advance.RemainingAmount,
+			"status":           advance.Status,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetAdvancePaymentByID(id)
+}
+
+// VoidAdvancePayment reverses an advance that has not been applied to any invoice yet
+func (s *AdvancePaymentService) VoidAdvancePayment(id uint, reason string, userID uint) (*models.AdvancePayment, error) {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var advance models.AdvancePayment
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Contact").First(&advance, id).Error; err != nil {
+			return errors.New("advance payment not found")
+		}
+		if advance.Status == models.AdvancePaymentStatusVoid {
+			return errors.New("advance payment is already void")
+		}
+		if advance.AppliedAmount > 0 {
+			return errors.New("advance payment has already been applied to invoices and cannot be voided")
+		}
+
+		date := dateOnly(time.Now())
+		if err := s.validateDate(date); err != nil {
+			return err
+		}
+
+		cashBank, err := s.lockCashBank(tx, advance.CashBankID)
+		if err != nil {
+			return err
+		}
+		cashAmount := -advance.Amount
+		if advance.Type == models.AdvancePaymentTypeVendor {
+			cashAmount = advance.Amount
+		} else if cashBank.Balance < advance.Amount {
+			return fmt.Errorf("insufficient balance. Available: %.2f", cashBank.Balance)
+		}
+
+		// Reverse the original journal line by line
+		var original []models.SSOTJournalLine
+		if advance.JournalEntryID != nil {
+			if err := tx.Where("journal_id = ?", *advance.JournalEntryID).Order("line_number ASC").Find(&original).Error; err != nil {
+				return fmt.Errorf("failed to load advance journal: %v", err)
+			}
+		}
+		if len(original) == 0 {
+			return errors.New("journal of the advance payment not found")
+		}
+		description := fmt.Sprintf("Pembatalan uang muka %s - %s: %s", advance.Code, advance.Contact.Name, reason)
+		lines := make([]JournalLineRequest, 0, len(original))
+		for _, line := range original {
+			lines = append(lines, JournalLineRequest{
+				AccountID:    line.AccountID,
+				DebitAmount:  line.CreditAmount,
+				CreditAmount: line.DebitAmount,
+				Description:  description,
+			})
+		}
+		paymentID := uint(0)
+		if advance.PaymentID != nil {
+			paymentID = *advance.PaymentID
+		}
+		if _, err := s.postJournal(tx, paymentID, advance.Code+"-VOID", date, description, lines, userID); err != nil {
+			return err
+		}
+		if err := s.moveCash(tx, cashBank, advance.ID, cashAmount, date, description); err != nil {
+			return err
+		}
+
+		if advance.PaymentID != nil {
+			if err := tx.Model(&models.Payment{}).Where("id = ?", *advance.PaymentID).
+				Update("status", models.PaymentStatusReversed).Error; err != nil {
+				return fmt.Errorf("failed to update payment: %v", err)
+			}
+		}
+		notes := "VOID: " + reason
+		if advance.Notes != "" {
+			notes = advance.Notes + "\n" + notes
+		}
+		log.Printf("🚫 Advance %s voided: %s", advance.Code, reason)
+		return tx.Model(&advance).Updates(map[string]interface{}{
+			"status":           models.AdvancePaymentStatusVoid,
+			"remaining_amount": 0,
+			"notes":            notes,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetAdvancePaymentByID(id)
+}
+
+// ========== HELPERS ==========
+
+func (s *AdvancePaymentService) applyToSale(tx *gorm.DB, advance *models.AdvancePayment, application *models.AdvancePaymentApplication, req models.AdvancePaymentApplyRequest, net, ppn decimal.Decimal, userID uint) error {
+	if req.SaleID == nil || req.PurchaseID != nil {
+		return errors.New("sale_id is required to apply a customer down payment")
+	}
+	var sale models.Sale
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&sale, *req.SaleID).Error; err != nil {
+		return errors.New("sale not found")
+	}
+	if sale.CustomerID != advance.ContactID {
+		return errors.New("down payment belongs to another customer")
+	}
+	if sale.Status != models.SaleStatusInvoiced && sale.Status != models.SaleStatusOverdue {
+		return fmt.Errorf("cannot apply a down payment to a sale with status %s", sale.Status)
+	}
+	if isForeignCurrency(sale.Currency) {
+		return errors.New("down payments can only be applied to base currency invoices")
+	}
+	if application.Amount > sale.OutstandingAmount+0.005 {
+		return fmt.Errorf("amount (%.2f) exceeds outstanding amount (%.2f)", application.Amount, sale.OutstandingAmount)
+	}
+
+	description := fmt.Sprintf("Penerapan uang muka %s ke invoice %s", advance.Code, firstNonEmpty(sale.InvoiceNumber, sale.Code))
+	deposit, err := s.accountByCode(tx, CustomerDepositAccountCode)
+	if err != nil {
+		return err
+	}
+	receivable, err := s.accountByCode(tx, AdvanceReceivableCode)
+	if err != nil {
+		return err
+	}
+	lines := []JournalLineRequest{{AccountID: uint64(deposit.ID), DebitAmount: net, Description: description}}
+	if ppn.GreaterThan(decimal.Zero) {
+		ppnOutput, err := s.accountByCode(tx, AdvancePPNOutputCode)
+		if err != nil {
+			return err
+		}
+		lines = append(lines, JournalLineRequest{AccountID: uint64(ppnOutput.ID), DebitAmount: ppn, Description: description})
+	}
+	lines = append(lines, JournalLineRequest{AccountID: uint64(receivable.ID), CreditAmount: net.Add(ppn), Description: description})
+
+	journalID, err := s.postJournal(tx, *advance.PaymentID, advance.Code, application.Date, description, lines, userID)
+	if err != nil {
+		return err
+	}
+	application.JournalEntryID = &journalID
+	application.SaleID = &sale.ID
+
+	allocation := models.PaymentAllocation{
+		PaymentID:       uint64(*advance.PaymentID),
+		InvoiceID:       &sale.ID,
+		AllocatedAmount: application.Amount,
+	}
+	if err := tx.Omit(clause.Associations).Create(&allocation).Error; err != nil {
+		return fmt.Errorf("failed to create payment allocation: %v", err)
+	}
+	application.PaymentAllocationID = &allocation.ID
+
+	if err := tx.Create(&models.SalePayment{
+		SaleID:        sale.ID,
+		Amount:        application.Amount,
+		PaymentDate:   application.Date,
+		PaymentMethod: models.SalePaymentMethodDownPayment,
+		Reference:     advance.Code,
+		Notes:         req.Notes,
+		UserID:        userID,
+		Status:        models.SalePaymentStatusCompleted,
+	}).Error; err != nil {
+		return fmt.Errorf("failed to record down payment on sale: %v", err)
+	}
+
+	sale.PaidAmount = roundAmount(sale.PaidAmount + application.Amount)
+	sale.OutstandingAmount = roundAmount(sale.OutstandingAmount - application.Amount)
+	if sale.OutstandingAmount <= 0.01 {
+		sale.OutstandingAmount = 0
+		sale.Status = models.SaleStatusPaid
+	}
+	return tx.Model(&sale).Updates(map[string]interface{}{
+		"paid_amount":        sale.PaidAmount,
+		"outstanding_amount": sale.OutstandingAmount,
+		"status":             sale.Status,
+	}).Error
+}
+
+func (s *AdvancePaymentService) applyToPurchase(tx *gorm.DB, advance *models.AdvancePayment, application *models.AdvancePaymentApplication, req models.AdvancePaymentApplyRequest, net, ppn decimal.Decimal, userID uint) error {
+	if req.PurchaseID == nil || req.SaleID != nil {
+		return errors.New("purchase_id is required to apply a vendor prepayment")
+	}
+	var purchase models.Purchase
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&purchase, *req.PurchaseID).Error; err != nil {
+		return errors.New("purchase not found")
+	}
+	if purchase.VendorID != advance.ContactID {
+		return errors.New("prepayment belongs to another vendor")
+	}
+	if purchase.Status != models.PurchaseStatusApproved && purchase.Status != models.PurchaseStatusCompleted {
+		return fmt.Errorf("cannot apply a prepayment to a purchase with status %s", purchase.Status)
+	}
+	if isForeignCurrency(purchase.Currency) {
+		return errors.New("prepayments can only be applied to base currency purchases")
+	}
+	if application.Amount > purchase.OutstandingAmount+0.005 {
+		return fmt.Errorf("amount (%.2f) exceeds outstanding amount (%.2f)", application.Amount, purchase.OutstandingAmount)
+	}
+
+	description := fmt.Sprintf("Penerapan uang muka %s ke pembelian %s", advance.Code, purchase.Code)
+	payable, err := s.accountByCode(tx, AdvancePayableCode)
+	if err != nil {
+		return err
+	}
+	vendorAdvance, err := s.accountByCode(tx, VendorAdvanceAccountCode)
+	if err != nil {
+		return err
+	}
+	lines := []JournalLineRequest{
+		{AccountID: uint64(payable.ID), DebitAmount: net.Add(ppn), Description: description},
+		{AccountID: uint64(vendorAdvance.ID), CreditAmount: net, Description: description},
+	}
+	if ppn.GreaterThan(decimal.Zero) {
+		ppnInput, err := s.accountByCode(tx, AdvancePPNInputCode)
+		if err != nil {
+			return err
+		}
+		lines = append(lines, JournalLineRequest{AccountID: uint64(ppnInput.ID), CreditAmount: ppn, Description: description})
+	}
+
+	journalID, err := s.postJournal(tx, *advance.PaymentID, advance.Code, application.Date, description, lines, userID)
+	if err != nil {
+		return err
+	}
+	application.JournalEntryID = &journalID
+	application.PurchaseID = &purchase.ID
+
+	allocation := models.PaymentAllocation{
+		PaymentID:       uint64(*advance.PaymentID),
+		BillID:          &purchase.ID,
+		AllocatedAmount: application.Amount,
+	}
+	if err := tx.Omit(clause.Associations).Create(&allocation).Error; err != nil {
+		return fmt.Errorf("failed to create payment allocation: %v", err)
+	}
+	application.PaymentAllocationID = &allocation.ID
+
+	paymentID := *advance.PaymentID
+	if err := tx.Create(&models.PurchasePayment{
+		PurchaseID:    purchase.ID,
+		PaymentNumber: advance.Code,
+		Date:          application.Date,
+		Amount:        application.Amount,
+		Method:        models.PurchasePaymentAdvance,
+		Reference:     advance.Code,
+		Notes:         req.Notes,
+		UserID:        userID,
+		PaymentID:     &paymentID,
+	}).Error; err != nil {
+		return fmt.Errorf("failed to record prepayment on purchase: %v", err)
+	}
+
+	purchase.PaidAmount = roundAmount(purchase.PaidAmount + application.Amount)
+	purchase.OutstandingAmount = roundAmount(purchase.OutstandingAmount - application.Amount)
+	if purchase.OutstandingAmount <= 0.01 {
+		purchase.OutstandingAmount = 0
+		purchase.Status = models.PurchaseStatusPaid
+	}
+	return tx.Model(&purchase).Updates(map[string]interface{}{
+		"paid_amount":        purchase.PaidAmount,
+		"outstanding_amount": purchase.OutstandingAmount,
+		"status":             purchase.Status,
+	}).Error
+}
+
+// splitAdvancePPN splits a PPN-inclusive gross amount into DPP and PPN at the given rate (percent)
+func splitAdvancePPN(gross decimal.Decimal, rate float64) (net, ppn decimal.Decimal) {
+	if rate <= 0 {
+		return gross, decimal.Zero
+	}
+	r := decimal.NewFromFloat(rate)
+	ppn = gross.Mul(r).Div(r.Add(decimal.NewFromInt(100))).Round(2)
+	return gross.Sub(ppn), ppn
+}
+
+// ppnShare is the PPN released by applying gross of the advance: pro rata to the gross amount,
+// with the last application taking whatever PPN is left so rounding never strands a balance
+func (s *AdvancePaymentService) ppnShare(advance *models.AdvancePayment, gross decimal.Decimal) decimal.Decimal {
+	remainingPPN := decimal.NewFromFloat(advance.PPNAmount).Sub(decimal.NewFromFloat(advance.AppliedPPN)).Round(2)
+	if advance.PPNAmount <= 0 || remainingPPN.LessThanOrEqual(decimal.Zero) {
+		return decimal.Zero
+	}
+	if gross.InexactFloat64() >= advance.RemainingAmount-0.005 {
+		return remainingPPN
+	}
+	share := gross.Mul(decimal.NewFromFloat(advance.PPNAmount)).Div(decimal.NewFromFloat(advance.Amount)).Round(2)
+	if share.GreaterThan(remainingPPN) {
+		return remainingPPN
+	}
+	return share
+}
+
+func (s *AdvancePaymentService) postJournal(tx *gorm.DB, paymentID uint, reference string, date time.Time, description string, lines []JournalLineRequest, userID uint) (uint, error) {
+	journal, err := s.journalService.CreateJournalEntryWithTx(tx, &JournalEntryRequest{
+		SourceType:  models.SSOTSourceTypePayment,
+		SourceID:    uint64(paymentID),
+		Reference:   reference,
+		EntryDate:   date,
+		Description: description,
+		Lines:       lines,
+		AutoPost:    true,
+		CreatedBy:   uint64(userID),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to post advance payment journal: %v", err)
+	}
+	return uint(journal.ID), nil
+}
+
+func (s *AdvancePaymentService) lockCashBank(tx *gorm.DB, id uint) (*models.CashBank, error) {
+	var cashBank models.CashBank
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&cashBank, id).Error; err != nil {
+		return nil, errors.New("cash bank account not found")
+	}
+	if !cashBank.IsActive {
+		return nil, errors.New("cash bank account is inactive")
+	}
+	if cashBank.AccountID == 0 {
+		return nil, errors.New("cash bank account is not linked to a GL account")
+	}
+	if isForeignCurrency(cashBank.Currency) {
+		return nil, errors.New("advances must be received or paid through a base currency cash or bank account")
+	}
+	return &cashBank, nil
+}
+
+// moveCash updates the cash bank balance and records the movement (negative amount for money out)
+func (s *AdvancePaymentService) moveCash(tx *gorm.DB, cashBank *models.CashBank, advanceID uint, amount float64, date time.Time, notes string) error {
+	cashBank.Balance = roundAmount(cashBank.Balance + amount)
+	if err := tx.Model(cashBank).Update("balance", cashBank.Balance).Error; err != nil {
+		return fmt.Errorf("failed to update cash bank balance: %v", err)
+	}
+	if err := tx.Create(&models.CashBankTransaction{
+		CashBankID:      cashBank.ID,
+		ReferenceType:   CashBankRefAdvancePayment,
+		ReferenceID:     advanceID,
+		Amount:          amount,
+		BalanceAfter:    cashBank.Balance,
+		TransactionDate: date,
+		Notes:           notes,
+	}).Error; err != nil {
+		return fmt.Errorf("failed to create cash bank transaction: %v", err)
+	}
+	return nil
+}
+
+func (s *AdvancePaymentService) validateDate(date time.Time) error {
+	if date.After(time.Now()) {
+		return errors.New("date cannot be in the future")
+	}
+	closed, err := s.periodService.IsDateInClosedPeriod(context.Background(), date)
+	if err != nil {
+		return fmt.Errorf("failed to check accounting period: %v", err)
+	}
+	if closed {
+		return fmt.Errorf("accounting period of %s is closed", date.Format("2006-01-02"))
+	}
+	return nil
+}
+
+func (s *AdvancePaymentService) accountByCode(tx *gorm.DB, code string) (*models.Account, error) {
+	var account models.Account
+	if err := tx.Where("code = ?", code).First(&account).Error; err != nil {
+		return nil, fmt.Errorf("account code %s not found: %v", code, err)
+	}
+	if account.IsHeader {
+		return nil, fmt.Errorf("account %s is a header account", account.Code)
+	}
+	return &account, nil
+}
+
+func (s *AdvancePaymentService) generateAdvanceCode(tx *gorm.DB, advanceType string, date time.Time) (string, error) {
+	prefix := "DPC"
+	if advanceType == models.AdvancePaymentTypeVendor {
+		prefix = "DPV"
+	}
+	prefix = fmt.Sprintf("%s-%s-", prefix, date.Format("200601"))
+
+	var count int64
+	if err := tx.Unscoped().Model(&models.AdvancePayment{}).
+		Where("code LIKE ?", prefix+"%").
+		Count(&count).Error; err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s%04d", prefix, count+1), nil
+}
@@ -0,0 +1,187 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"app-sistem-akuntansi/models"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestSplitAdvancePPN(t *testing.T) {
+	tests := []struct {
+		name    string
+		gross   float64
+		rate    float64
+		wantNet string
+		wantPPN string
+	}{
+		{name: "11% included", gross: 11100000, rate: 11, wantNet: "10000000", wantPPN: "1100000"},
+		{name: "12% included", gross: 1120000, rate: 12, wantNet: "1000000", wantPPN: "120000"},
+		{name: "rounded to the cent", gross: 1000000, rate: 11, wantNet: "900900.9", wantPPN: "99099.1"},
+		{name: "no PPN", gross: 500000, rate: 0, wantNet: "500000", wantPPN: "0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			net, ppn := splitAdvancePPN(decimal.NewFromFloat(tt.gross), tt.rate)
+			assert.Equal(t, tt.wantNet, net.String())
+			assert.Equal(t, tt.wantPPN, ppn.String())
+			assert.True(t, net.Add(ppn).Equal(decimal.NewFromFloat(tt.gross)))
+		})
+	}
+}
+
+func TestAdvancePPNShare(t *testing.T) {
+	service := &AdvancePaymentService{}
+	tests := []struct {
+		name    string
+		advance models.AdvancePayment
+		gross   float64
+		want    string
+	}{
+		{name: "pro rata", advance: models.AdvancePayment{Amount: 11100000, PPNAmount: 1100000, RemainingAmount: 11100000}, gross: 3330000, want: "330000"},
+		{name: "rounded pro rata", advance: models.AdvancePayment{Amount: 1000000, PPNAmount: 99099.1, RemainingAmount: 1000000}, gross: 333333.33, want: "33033.03"},
+		{name: "last application takes what is left", advance: models.AdvancePayment{Amount: 1000000, PPNAmount: 99099.1, AppliedPPN: 66066.06, RemainingAmount: 333333.34}, gross: 333333.34, want: "33033.04"},
+		{name: "never more than what is left", advance: models.AdvancePayment{Amount: 1000000, PPNAmount: 100000, AppliedPPN: 99990, RemainingAmount: 500000}, gross: 200000, want: "10"},
+		{name: "no PPN on the advance", advance: models.AdvancePayment{Amount: 500000, RemainingAmount: 500000}, gross: 200000, want: "0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, service.ppnShare(&tt.advance, decimal.NewFromFloat(tt.gross)).String())
+		})
+	}
+}
+
+func setupAdvancePaymentTest(t *testing.T) (*AdvancePaymentService, *gorm.DB, *models.CashBank) {
+	db := setupJournalTestDB(t, &models.Contact{}, &models.CashBank{}, &models.CashBankTransaction{}, &models.Payment{},
+		&models.PaymentAllocation{}, &models.AdvancePayment{}, &models.AdvancePaymentApplication{}, &models.Sale{},
+		&models.SalePayment{}, &models.Purchase{}, &models.PurchasePayment{}, &models.AccountingPeriod{})
+	accounts := seedTestAccounts(t, db, map[string]string{
+		"1101":                     models.AccountTypeAsset,
+		VendorAdvanceAccountCode:   models.AccountTypeAsset,
+		AdvancePPNInputCode:        models.AccountTypeAsset,
+		AdvanceReceivableCode:      models.AccountTypeAsset,
+		CustomerDepositAccountCode: models.AccountTypeLiability,
+		AdvancePPNOutputCode:       models.AccountTypeLiability,
+		AdvancePayableCode:         models.AccountTypeLiability,
+	})
+	cashBank := &models.CashBank{Code: "BNK-001", Name: "Bank BCA", Type: "BANK", AccountID: accounts["1101"].ID, Currency: "IDR", Balance: 5000000, IsActive: true}
+	require.NoError(t, db.Create(cashBank).Error)
+	return NewAdvancePaymentService(db), db, cashBank
+}
+
+// netByAccount sums debit - credit per account code over all journals
+func netByAccount(t *testing.T, db *gorm.DB, entries []models.SSOTJournalEntry) map[string]float64 {
+	totals := make(map[string]float64)
+	for _, entry := range entries {
+		for code, amount := range journalAmountsByAccount(t, db, entry) {
+			totals[code] = roundAmount(totals[code] + amount)
+		}
+	}
+	return totals
+}
+
+func TestCustomerDownPaymentJournals(t *testing.T) {
+	service, db, cashBank := setupAdvancePaymentTest(t)
+	date := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	customer := models.Contact{Code: "CUST-001", Name: "PT Pelanggan", Type: models.ContactTypeCustomer}
+	vendor := models.Contact{Code: "VEND-001", Name: "PT Pemasok", Type: models.ContactTypeVendor}
+	require.NoError(t, db.Create(&customer).Error)
+	require.NoError(t, db.Create(&vendor).Error)
+
+	_, err := service.CreateAdvancePayment(models.AdvancePaymentTypeCustomer, models.AdvancePaymentRequest{ContactID: vendor.ID, Date: date, CashBankID: cashBank.ID, Amount: 1000000}, 1)
+	assert.EqualError(t, err, "a down payment can only be received from a customer")
+
+	advance, err := service.CreateAdvancePayment(models.AdvancePaymentTypeCustomer, models.AdvancePaymentRequest{
+		ContactID: customer.ID, Date: date, CashBankID: cashBank.ID, Amount: 11100000, PPNRate: 11}, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "DPC-202403-0001", advance.Code)
+	assert.InDelta(t, 10000000, advance.NetAmount, 0.001)
+	assert.InDelta(t, 1100000, advance.PPNAmount, 0.001)
+	assert.InDelta(t, 16100000, advance.CashBank.Balance, 0.001)
+
+	sale := models.Sale{Code: "SO-001", InvoiceNumber: "INV-001", CustomerID: customer.ID, UserID: 1, Date: date, Status: models.SaleStatusInvoiced,
+		Currency: "IDR", ExchangeRate: 1, TotalAmount: 22200000, OutstandingAmount: 22200000}
+	require.NoError(t, db.Create(&sale).Error)
+
+	apply := func(amount float64) (*models.AdvancePayment, error) {
+		return service.ApplyAdvancePayment(advance.ID, models.AdvancePaymentApplyRequest{SaleID: &sale.ID, Amount: amount, Date: date.AddDate(0, 0, 10)}, 1)
+	}
+	advance, err = apply(3330000)
+	require.NoError(t, err)
+	assert.InDelta(t, 330000, advance.AppliedPPN, 0.001)
+	assert.InDelta(t, 7770000, advance.RemainingAmount, 0.001)
+	_, err = apply(8000000)
+	assert.EqualError(t, err, "amount exceeds remaining advance (7770000.00)")
+	advance, err = apply(7770000)
+	require.NoError(t, err)
+	assert.Equal(t, models.AdvancePaymentStatusApplied, advance.Status)
+	assert.InDelta(t, 1100000, advance.AppliedPPN, 0.001)
+	require.Len(t, advance.Applications, 2)
+
+	require.NoError(t, db.First(&sale, sale.ID).Error)
+	assert.InDelta(t, 11100000, sale.OutstandingAmount, 0.001)
+	assert.InDelta(t, 11100000, sale.PaidAmount, 0.001)
+
+	_, err = service.VoidAdvancePayment(advance.ID, "salah input", 1)
+	assert.EqualError(t, err, "advance payment has already been applied to invoices and cannot be voided")
+
+	entries := assertJournalsBalanced(t, db)
+	require.Len(t, entries, 3)
+	totals := netByAccount(t, db, entries)
+	assert.InDelta(t, 11100000, totals["1101"], 0.001)
+	assert.InDelta(t, -11100000, totals[AdvanceReceivableCode], 0.001)
+	assert.Zero(t, totals[CustomerDepositAccountCode], "the deposit is fully released")
+	assert.Zero(t, totals[AdvancePPNOutputCode], "PPN is only counted once, on the final invoice")
+}
+
+func TestVendorPrepaymentJournals(t *testing.T) {
+	service, db, cashBank := setupAdvancePaymentTest(t)
+	date := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	vendor := models.Contact{Code: "VEND-001", Name: "PT Pemasok", Type: models.ContactTypeVendor}
+	require.NoError(t, db.Create(&vendor).Error)
+	request := models.AdvancePaymentRequest{ContactID: vendor.ID, Date: date, CashBankID: cashBank.ID, Amount: 6000000, PPNRate: 11}
+
+	_, err := service.CreateAdvancePayment(models.AdvancePaymentTypeVendor, request, 1)
+	assert.EqualError(t, err, "insufficient balance. Available: 5000000.00")
+
+	request.Amount = 1000000
+	advance, err := service.CreateAdvancePayment(models.AdvancePaymentTypeVendor, request, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "DPV-202403-0001", advance.Code)
+	assert.InDelta(t, 4000000, advance.CashBank.Balance, 0.001)
+
+	purchase := models.Purchase{Code: "PO-001", VendorID: vendor.ID, UserID: 1, Date: date, Status: models.PurchaseStatusApproved,
+		Currency: "IDR", TotalAmount: 2000000, OutstandingAmount: 2000000}
+	require.NoError(t, db.Create(&purchase).Error)
+	for _, amount := range []float64{333333.33, 333333.33, 333333.34} {
+		advance, err = service.ApplyAdvancePayment(advance.ID, models.AdvancePaymentApplyRequest{PurchaseID: &purchase.ID, Amount: amount, Date: date}, 1)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, models.AdvancePaymentStatusApplied, advance.Status)
+	require.Len(t, advance.Applications, 3)
+	assert.InDelta(t, 33033.04, advance.Applications[2].PPNAmount, 0.001, "rounding lands on the last application")
+
+	// A second prepayment that is voided reverses its journal and cash movement
+	request.Amount = 500000
+	voided, err := service.CreateAdvancePayment(models.AdvancePaymentTypeVendor, request, 1)
+	require.NoError(t, err)
+	voided, err = service.VoidAdvancePayment(voided.ID, "batal pesan", 1)
+	require.NoError(t, err)
+	assert.Equal(t, models.AdvancePaymentStatusVoid, voided.Status)
+	assert.InDelta(t, 4000000, voided.CashBank.Balance, 0.001)
+
+	entries := assertJournalsBalanced(t, db)
+	require.Len(t, entries, 6)
+	totals := netByAccount(t, db, entries)
+	assert.InDelta(t, -1000000, totals["1101"], 0.001)
+	assert.InDelta(t, 1000000, totals[AdvancePayableCode], 0.001)
+	assert.Zero(t, totals[VendorAdvanceAccountCode])
+	assert.Zero(t, totals[AdvancePPNInputCode])
+}
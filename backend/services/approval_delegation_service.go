@@ -0,0 +1,192 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"app-sistem-akuntansi/models"
+
+	"gorm.io/gorm"
+)
+
+// ApprovalDelegationService - Delegasi persetujuan selama approver berhalangan (cuti, dinas).
+// ApprovalService membaca delegasi yang berlaku saat memeriksa dan memberi notifikasi approver.
+type ApprovalDelegationService struct {
+	db *gorm.DB
+}
+
+func NewApprovalDelegationService(db *gorm.DB) *ApprovalDelegationService {
+	return &ApprovalDelegationService{db: db}
+}
+
+var approvalDelegationModules = []string{
+	models.ApprovalModuleSales,
+	models.ApprovalModulePurchase,
+	models.ApprovalModuleExpense,
+	models.ApprovalModuleAsset,
+	models.ApprovalModuleBudget,
+	models.ApprovalModuleRequisition,
+	models.ApprovalModuleJournal,
+	models.ApprovalModulePayment,
+	models.ApprovalModuleWriteOff,
+}
+
+// GetDelegations - Daftar delegasi; selain admin hanya melihat delegasi miliknya atau kepadanya
+func (s *ApprovalDelegationService) GetDelegations(userID uint, isAdmin bool, activeOnly bool) ([]models.ApprovalDelegation, error) {
+	query := s.db.Preload("Delegator").Preload("Delegate").Order("start_date DESC")
+	if !isAdmin {
+		query = query.Where("delegator_id = ? OR delegate_id = ?", userID, userID)
+	}
+	if activeOnly {
+		query = query.Where("is_active = ?", true)
+	}
+
+	var delegations []models.ApprovalDelegation
+	if err := query.Find(&delegations).Error; err != nil {
+		return nil, err
+	}
+	return delegations, nil
+}
+
+// GetDelegationByID - Detail satu delegasi
+func (s *ApprovalDelegationService) GetDelegationByID(id uint) (*models.ApprovalDelegation, error) {
+	var delegation models.ApprovalDelegation
+	if err := s.db.Preload("Delegator").Preload("Delegate").First(&delegation, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("approval delegation not found")
+		}
+		return nil, err
+	}
+	return &delegation, nil
+}
+
+// CreateDelegation - Buat delegasi; DelegatorID kosong berarti user yang login
+func (s *ApprovalDelegationService) CreateDelegation(request models.ApprovalDelegationRequest, userID uint, isAdmin bool) (*models.ApprovalDelegation, error) {
+	delegation := models.ApprovalDelegation{IsActive: true, CreatedBy: userID}
+	if err := s.applyRequest(&delegation, request, userID, isAdmin); err != nil {
+		return nil, err
+	}
+	if err := s.validateDelegation(&delegation); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Create(&delegation).Error; err != nil {
+		return nil, err
+	}
+	log.Printf("🤝 Approval delegation %d: user %d -> user %d (%s to %s)", delegation.ID, delegation.DelegatorID,
+		delegation.DelegateID, delegation.StartDate.Format("2006-01-02"), delegation.EndDate.Format("2006-01-02"))
+	return s.GetDelegationByID(delegation.ID)
+}
+
+// UpdateDelegation - Ubah delegasi yang masih aktif
+func (s *ApprovalDelegationService) UpdateDelegation(id uint, request models.ApprovalDelegationRequest, userID uint, isAdmin bool) (*models.ApprovalDelegation, error) {
+	delegation, err := s.GetDelegationByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if !isAdmin && delegation.DelegatorID != userID {
+		return nil, errors.New("only the delegator or an admin can change this delegation")
+	}
+	if !delegation.IsActive {
+		return nil, errors.New("cancelled delegations cannot be changed")
+	}
+
+	if request.DelegatorID == 0 {
+		request.DelegatorID = delegation.DelegatorID
+	}
+	if err := s.applyRequest(delegation, request, userID, isAdmin); err != nil {
+		return nil, err
+	}
+	if err := s.validateDelegation(delegation); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Model(&models.ApprovalDelegation{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"delegator_id": delegation.DelegatorID,
+		"delegate_id":  delegation.DelegateID,
+		"module":       delegation.Module,
+		"start_date":   delegation.StartDate,
+		"end_date":     delegation.EndDate,
+		"reason":       delegation.Reason,
+	}).Error; err != nil {
+		return nil, err
+	}
+	return s.GetDelegationByID(id)
+}
+
+// CancelDelegation - Menghentikan delegasi; approval kembali ke approver asli
+func (s *ApprovalDelegationService) CancelDelegation(id uint, userID uint, isAdmin bool) (*models.ApprovalDelegation, error) {
+	delegation, err := s.GetDelegationByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if !isAdmin && delegation.DelegatorID != userID {
+		return nil, errors.New("only the delegator or an admin can cancel this delegation")
+	}
+
+	if err := s.db.Model(&models.ApprovalDelegation{}).Where("id = ?", id).Update("is_active", false).Error; err != nil {
+		return nil, err
+	}
+	log.Printf("🤝 Approval delegation %d cancelled by user %d", id, userID)
+	return s.GetDelegationByID(id)
+}
+
+func (s *ApprovalDelegationService) applyRequest(delegation *models.ApprovalDelegation, request models.ApprovalDelegationRequest, userID uint, isAdmin bool) error {
+	delegatorID := request.DelegatorID
+	if delegatorID == 0 {
+		delegatorID = userID
+	}
+	if delegatorID != userID && !isAdmin {
+		return errors.New("only admins can set up a delegation for another user")
+	}
+
+	delegation.DelegatorID = delegatorID
+	delegation.DelegateID = request.DelegateID
+	delegation.Module = strings.ToUpper(strings.TrimSpace(request.Module))
+	delegation.StartDate = request.StartDate
+	delegation.EndDate = request.EndDate
+	delegation.Reason = request.Reason
+	return nil
+}
+
+// validateDelegation - Delegate aktif dan berbeda, periode valid, tidak tumpang tindih
+func (s *ApprovalDelegationService) validateDelegation(delegation *models.ApprovalDelegation) error {
+	if delegation.DelegatorID == delegation.DelegateID {
+		return errors.New("cannot delegate approvals to yourself")
+	}
+	if !delegation.EndDate.After(delegation.StartDate) {
+		return errors.New("end_date must be after start_date")
+	}
+	if delegation.Module != "" && !containsString(approvalDelegationModules, delegation.Module) {
+		return fmt.Errorf("invalid module %s, must be one of %s", delegation.Module, strings.Join(approvalDelegationModules, ", "))
+	}
+
+	var delegate models.User
+	if err := s.db.Select("id, is_active").First(&delegate, delegation.DelegateID).Error; err != nil {
+		return errors.New("delegate not found")
+	}
+	if !delegate.IsActive {
+		return errors.New("delegate is not an active user")
+	}
+
+	// One delegate per delegator, module and period, so routing is never ambiguous
+	query := s.db.Model(&models.ApprovalDelegation{}).
+		Where("delegator_id = ? AND is_active = ? AND start_date <= ? AND end_date >= ?",
+			delegation.DelegatorID, true, delegation.EndDate, delegation.StartDate)
+	if delegation.Module != "" {
+		query = query.Where("(module = '' OR module = ?)", delegation.Module)
+	}
+	if delegation.ID != 0 {
+		query = query.Where("id <> ?", delegation.ID)
+	}
+	var overlapping int64
+	if err := query.Count(&overlapping).Error; err != nil {
+		return err
+	}
+	if overlapping > 0 {
+		return errors.New("an active delegation already covers this period and module")
+	}
+	return nil
+}
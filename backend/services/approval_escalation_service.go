@@ -144,7 +144,10 @@ func (s *EscalationService) updateEntityStatusEscalation(tx *gorm.DB, entityType
 	case models.EntityTypePurchase:
 		return tx.Model(&models.Purchase{}).Where("id = ?", entityID).Updates(updates).Error
 	case models.EntityTypeSale:
-		return tx.Model(&models.Sale{}).Where("id = ?", entityID).Updates(updates).Error
+		return tx.Model(&models.Sale{}).Where("id = ?", entityID).Updates(map[string]interface{}{
+			"credit_approval_status": status,
+			"updated_at":             now,
+		}).Error
 	default:
 		return fmt.Errorf("unsupported entity type: %s", entityType)
 	}
@@ -0,0 +1,335 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"app-sistem-akuntansi/models"
+	"gorm.io/gorm"
+)
+
+// ========== WORKFLOW SELECTION ==========
+
+// approvalModuleFor - Memetakan entity type dokumen ke module workflow persetujuan
+func approvalModuleFor(entityType string) (string, error) {
+	switch entityType {
+	case models.EntityTypeSale:
+		return models.ApprovalModuleSales, nil
+	case models.EntityTypePurchase:
+		return models.ApprovalModulePurchase, nil
+	case models.EntityTypeBudget:
+		return models.ApprovalModuleBudget, nil
+	case models.EntityTypeRequisition:
+		return models.ApprovalModuleRequisition, nil
+	case models.EntityTypeJournal:
+		return models.ApprovalModuleJournal, nil
+	case models.EntityTypePayment:
+		return models.ApprovalModulePayment, nil
+	case models.EntityTypeAsset:
+		return models.ApprovalModuleAsset, nil
+	case models.EntityTypeWriteOff:
+		return models.ApprovalModuleWriteOff, nil
+	default:
+		return "", errors.New("unsupported entity type")
+	}
+}
+
+// SelectWorkflow - Memilih workflow untuk dokumen berdasarkan module, nominal, kategori
+// customer/vendor, akun dan cost center. Workflow dengan kondisi terbanyak yang cocok menang,
+// lalu Priority tertinggi, lalu MinAmount tertinggi.
+func (s *ApprovalService) SelectWorkflow(module string, req models.CreateApprovalRequestDTO) (*models.ApprovalWorkflow, error) {
+	var candidates []models.ApprovalWorkflow
+	err := s.db.Preload("Steps", func(db *gorm.DB) *gorm.DB {
+		return db.Order("step_order ASC")
+	}).Where(
+		"module = ? AND is_active = ? AND min_amount <= ? AND (max_amount >= ? OR max_amount = 0)",
+		module, true, req.Amount, req.Amount,
+	).Find(&candidates).Error
+	if err != nil {
+		return nil, err
+	}
+
+	contactCategory := ""
+	if req.ContactID != nil {
+		var contact models.Contact
+		if err := s.db.Select("id, category").First(&contact, *req.ContactID).Error; err == nil {
+			contactCategory = contact.Category
+		}
+	}
+
+	var selected *models.ApprovalWorkflow
+	selectedScore := -1
+	for i := range candidates {
+		workflow := &candidates[i]
+		score, ok := workflowConditionsMatch(workflow, contactCategory, req)
+		if !ok {
+			continue
+		}
+		if selected == nil || score > selectedScore ||
+			(score == selectedScore && (workflow.Priority > selected.Priority ||
+				(workflow.Priority == selected.Priority && workflow.MinAmount > selected.MinAmount))) {
+			selected = workflow
+			selectedScore = score
+		}
+	}
+
+	if selected == nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return selected, nil
+}
+
+// RequiresApproval - Cek apakah ada workflow aktif yang berlaku untuk dokumen
+func (s *ApprovalService) RequiresApproval(req models.CreateApprovalRequestDTO) bool {
+	module, err := approvalModuleFor(req.EntityType)
+	if err != nil {
+		return false
+	}
+	_, err = s.SelectWorkflow(module, req)
+	return err == nil
+}
+
+// workflowConditionsMatch returns how many routing conditions of the workflow the document meets,
+// and false when one of them is not met
+func workflowConditionsMatch(workflow *models.ApprovalWorkflow, contactCategory string, req models.CreateApprovalRequestDTO) (int, bool) {
+	score := 0
+	if workflow.ContactCategory != "" {
+		if !strings.EqualFold(workflow.ContactCategory, contactCategory) {
+			return 0, false
+		}
+		score++
+	}
+	if workflow.AccountID != nil {
+		if !containsApprovalID(req.AccountIDs, *workflow.AccountID) {
+			return 0, false
+		}
+		score++
+	}
+	if workflow.CostCenterID != nil {
+		if !containsApprovalID(req.CostCenterIDs, *workflow.CostCenterID) {
+			return 0, false
+		}
+		score++
+	}
+	return score, true
+}
+
+// validateWorkflowSteps - Validasi target approver setiap step
+func validateWorkflowSteps(steps []models.CreateApprovalStepRequest) error {
+	if len(steps) == 0 {
+		return errors.New("workflow needs at least one step")
+	}
+	for _, step := range steps {
+		switch step.ApproverType {
+		case "", models.ApproverTypeRole:
+			if strings.TrimSpace(step.ApproverRole) == "" {
+				return fmt.Errorf("step %q: approver_role is required for ROLE steps", step.StepName)
+			}
+		case models.ApproverTypeUser:
+			if step.ApproverUserID == nil {
+				return fmt.Errorf("step %q: approver_user_id is required for USER steps", step.StepName)
+			}
+		case models.ApproverTypeManager:
+		case models.ApproverTypeGroup:
+			if len(step.GroupUserIDs) == 0 {
+				return fmt.Errorf("step %q: group_user_ids is required for GROUP steps", step.StepName)
+			}
+			if step.RequiredApprovals > len(step.GroupUserIDs) {
+				return fmt.Errorf("step %q: required_approvals cannot exceed the %d group members", step.StepName, len(step.GroupUserIDs))
+			}
+		default:
+			return fmt.Errorf("step %q: invalid approver_type %s", step.StepName, step.ApproverType)
+		}
+	}
+	return nil
+}
+
+// requiredStepApprovals - Jumlah persetujuan yang dibutuhkan step (GROUP: N dari M)
+func requiredStepApprovals(step *models.ApprovalStep) int {
+	if step.ApproverType == models.ApproverTypeGroup && step.RequiredApprovals > 1 {
+		return step.RequiredApprovals
+	}
+	return 1
+}
+
+// ========== APPROVER RESOLUTION ==========
+
+// approverResolver answers "who may act on this step" for one call, caching the users and
+// delegations it looks up so that listing many requests stays cheap
+type approverResolver struct {
+	db          *gorm.DB
+	now         time.Time
+	users       map[uint]*models.User
+	delegations map[uint][]models.ApprovalDelegation // by delegate
+}
+
+func (s *ApprovalService) newApproverResolver() *approverResolver {
+	return &approverResolver{
+		db:          s.db,
+		now:         time.Now(),
+		users:       make(map[uint]*models.User),
+		delegations: make(map[uint][]models.ApprovalDelegation),
+	}
+}
+
+func (r *approverResolver) user(id uint) *models.User {
+	if user, ok := r.users[id]; ok {
+		return user
+	}
+	var user models.User
+	if err := r.db.Select("id, role, manager_id, is_active").First(&user, id).Error; err != nil {
+		r.users[id] = nil
+		return nil
+	}
+	r.users[id] = &user
+	return &user
+}
+
+// isStepApprover - Cek apakah user termasuk approver step sesuai ApproverType
+func (r *approverResolver) isStepApprover(userID, requesterID uint, step *models.ApprovalStep) bool {
+	switch step.ApproverType {
+	case models.ApproverTypeUser:
+		return step.ApproverUserID != nil && *step.ApproverUserID == userID
+	case models.ApproverTypeManager:
+		requester := r.user(requesterID)
+		return requester != nil && requester.ManagerID != nil && *requester.ManagerID == userID
+	case models.ApproverTypeGroup:
+		return containsApprovalID(step.GroupUserIDs, userID)
+	default:
+		user := r.user(userID)
+		return user != nil && roleCanApprove(user.Role, step.ApproverRole)
+	}
+}
+
+// delegationsTo - Delegasi yang sedang berlaku dengan user sebagai delegate
+func (r *approverResolver) delegationsTo(userID uint) []models.ApprovalDelegation {
+	if delegations, ok := r.delegations[userID]; ok {
+		return delegations
+	}
+	var delegations []models.ApprovalDelegation
+	r.db.Where("delegate_id = ? AND is_active = ? AND start_date <= ? AND end_date >= ?", userID, true, r.now, r.now).
+		Find(&delegations)
+	r.delegations[userID] = delegations
+	return delegations
+}
+
+// canAct - Cek apakah user dapat memproses action, langsung atau sebagai delegasi. Bila sebagai
+// delegasi, onBehalfOf berisi approver yang diwakili. Anggota GROUP yang sudah menyetujui dilewati, dan
+// satu orang hanya memberi satu persetujuan per step GROUP meski mewakili beberapa anggota.
+func (r *approverResolver) canAct(userID uint, request *models.ApprovalRequest, action *models.ApprovalAction) (onBehalfOf *uint, ok bool) {
+	if containsApprovalID(action.ActedBy, userID) {
+		return nil, false
+	}
+	if r.isStepApprover(userID, request.RequesterID, &action.Step) && !containsApprovalID(action.ApprovedBy, userID) {
+		return nil, true
+	}
+	for _, delegation := range r.delegationsTo(userID) {
+		if !delegation.CoversAt(r.now, request.Workflow.Module) {
+			continue
+		}
+		delegatorID := delegation.DelegatorID
+		if r.isStepApprover(delegatorID, request.RequesterID, &action.Step) && !containsApprovalID(action.ApprovedBy, delegatorID) {
+			return &delegatorID, true
+		}
+	}
+	return nil, false
+}
+
+// actionableRequestIDs - ID request PENDING yang step aktifnya dapat diproses user
+func (s *ApprovalService) actionableRequestIDs(userID uint) ([]uint, error) {
+	var actions []models.ApprovalAction
+	if err := s.db.Preload("Step").Preload("Request.Workflow").
+		Where("is_active = ? AND status = ?", true, models.ApprovalStatusPending).
+		Find(&actions).Error; err != nil {
+		return nil, err
+	}
+
+	resolver := s.newApproverResolver()
+	seen := make(map[uint]bool)
+	var ids []uint
+	for i := range actions {
+		action := &actions[i]
+		if seen[action.RequestID] || action.Request.Status != models.ApprovalStatusPending {
+			continue
+		}
+		if _, ok := resolver.canAct(userID, &action.Request, action); ok {
+			seen[action.RequestID] = true
+			ids = append(ids, action.RequestID)
+		}
+	}
+	return ids, nil
+}
+
+// stepApproverIDs - User yang perlu diberi notifikasi untuk step, termasuk delegasi mereka
+func (s *ApprovalService) stepApproverIDs(request *models.ApprovalRequest, step models.ApprovalStep) []uint {
+	var ids []uint
+	switch step.ApproverType {
+	case models.ApproverTypeUser:
+		if step.ApproverUserID != nil {
+			ids = append(ids, *step.ApproverUserID)
+		}
+	case models.ApproverTypeManager:
+		var requester models.User
+		if err := s.db.Select("id, manager_id").First(&requester, request.RequesterID).Error; err == nil && requester.ManagerID != nil {
+			ids = append(ids, *requester.ManagerID)
+		}
+	case models.ApproverTypeGroup:
+		ids = append(ids, step.GroupUserIDs...)
+	default:
+		s.db.Model(&models.User{}).Where("LOWER(role) = LOWER(?) AND is_active = ?", step.ApproverRole, true).Pluck("id", &ids)
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	var workflow models.ApprovalWorkflow
+	s.db.Select("id, module").First(&workflow, request.WorkflowID)
+
+	now := time.Now()
+	var delegations []models.ApprovalDelegation
+	s.db.Where("delegator_id IN ? AND is_active = ? AND start_date <= ? AND end_date >= ?", ids, true, now, now).
+		Find(&delegations)
+	for _, delegation := range delegations {
+		if delegation.CoversAt(now, workflow.Module) && !containsApprovalID(ids, delegation.DelegateID) {
+			ids = append(ids, delegation.DelegateID)
+		}
+	}
+	return ids
+}
+
+// checkStepApprovers - Pastikan setiap step workflow punya approver untuk requester ini
+func (s *ApprovalService) checkStepApprovers(workflow *models.ApprovalWorkflow, requesterID uint) error {
+	for _, step := range workflow.Steps {
+		if step.ApproverType != models.ApproverTypeManager {
+			continue
+		}
+		var requester models.User
+		if err := s.db.Select("id, manager_id").First(&requester, requesterID).Error; err != nil {
+			return err
+		}
+		if requester.ManagerID == nil {
+			return fmt.Errorf("workflow %s needs the requester's manager but no manager is assigned", workflow.Name)
+		}
+		return nil
+	}
+	return nil
+}
+
+// appendApprovalID - Tambah ID routing (akun, cost center) tanpa nol dan duplikat
+func appendApprovalID(ids []uint, id uint) []uint {
+	if id == 0 || containsApprovalID(ids, id) {
+		return ids
+	}
+	return append(ids, id)
+}
+
+func containsApprovalID(ids []uint, id uint) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
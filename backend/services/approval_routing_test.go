@@ -0,0 +1,131 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"app-sistem-akuntansi/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestApproverResolverCanAct(t *testing.T) {
+	now := time.Date(2024, 6, 10, 9, 0, 0, 0, time.UTC)
+	delegation := func(delegatorID, delegateID uint, module string) models.ApprovalDelegation {
+		return models.ApprovalDelegation{
+			DelegatorID: delegatorID, DelegateID: delegateID, Module: module, IsActive: true,
+			StartDate: now.AddDate(0, 0, -1), EndDate: now.AddDate(0, 0, 1),
+		}
+	}
+	// Users 1-3 form the group; 4 stands in for 1 and 2, 3 stands in for 2
+	resolver := &approverResolver{
+		now: now,
+		users: map[uint]*models.User{
+			1: {ID: 1, Role: "finance"}, 2: {ID: 2, Role: "finance"}, 3: {ID: 3, Role: "finance"},
+			4: {ID: 4, Role: "employee"}, 9: {ID: 9, Role: "employee"},
+		},
+		delegations: map[uint][]models.ApprovalDelegation{
+			1: nil, 2: nil, 9: nil,
+			3: {delegation(2, 3, "")},
+			4: {delegation(1, 4, ""), delegation(2, 4, "PURCHASE")},
+		},
+	}
+	request := &models.ApprovalRequest{RequesterID: 9, Workflow: models.ApprovalWorkflow{Module: "PURCHASE"}}
+	groupStep := models.ApprovalStep{ApproverType: models.ApproverTypeGroup, GroupUserIDs: []uint{1, 2, 3}, RequiredApprovals: 2}
+
+	uintPtr := func(v uint) *uint { return &v }
+	tests := []struct {
+		name       string
+		userID     uint
+		approvedBy []uint
+		actedBy    []uint
+		wantOK     bool
+		wantFor    *uint
+	}{
+		{name: "member votes directly", userID: 1, wantOK: true},
+		{name: "member who already approved", userID: 1, approvedBy: []uint{1}, actedBy: []uint{1}},
+		{name: "delegate votes for a member", userID: 4, wantOK: true, wantFor: uintPtr(1)},
+		{name: "delegate moves on to the next member they represent", userID: 4, approvedBy: []uint{1}, actedBy: []uint{1}, wantOK: true, wantFor: uintPtr(2)},
+		{name: "delegate who already voted for one member cannot vote for another", userID: 4, approvedBy: []uint{1}, actedBy: []uint{4}},
+		{name: "member who voted as a delegate cannot vote again as themselves", userID: 3, approvedBy: []uint{2}, actedBy: []uint{3}},
+		{name: "member who is also a delegate votes as themselves first", userID: 3, wantOK: true},
+		{name: "delegate whose members all voted themselves", userID: 4, approvedBy: []uint{1, 2}, actedBy: []uint{1, 2}},
+		{name: "outsider without delegation", userID: 9},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			action := &models.ApprovalAction{Step: groupStep, ApprovedBy: tt.approvedBy, ActedBy: tt.actedBy}
+			onBehalfOf, ok := resolver.canAct(tt.userID, request, action)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.wantFor, onBehalfOf)
+		})
+	}
+}
+
+func TestSelectWorkflow(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1) // Every connection to :memory: is a separate database
+	require.NoError(t, db.AutoMigrate(&models.Contact{}, &models.ApprovalWorkflow{}, &models.ApprovalStep{}))
+	service := NewApprovalService(db)
+
+	wholesale := models.Contact{Code: "VEND-001", Name: "PT Grosir", Type: models.ContactTypeVendor, Category: models.CategoryWholesale}
+	retail := models.Contact{Code: "VEND-002", Name: "Toko Eceran", Type: models.ContactTypeVendor, Category: models.CategoryRetail}
+	require.NoError(t, db.Create(&wholesale).Error)
+	require.NoError(t, db.Create(&retail).Error)
+
+	uintPtr := func(v uint) *uint { return &v }
+	workflows := []models.ApprovalWorkflow{
+		{Name: "Purchase default", Module: models.ApprovalModulePurchase, IsActive: true},
+		{Name: "Purchase small", Module: models.ApprovalModulePurchase, MaxAmount: 5000000, Priority: 1, IsActive: true},
+		{Name: "Purchase large", Module: models.ApprovalModulePurchase, MinAmount: 10000000, IsActive: true},
+		{Name: "Wholesale vendors", Module: models.ApprovalModulePurchase, ContactCategory: "wholesale", IsActive: true},
+		{Name: "IT equipment", Module: models.ApprovalModulePurchase, AccountID: uintPtr(7), CostCenterID: uintPtr(5), IsActive: true},
+		{Name: "Retired", Module: models.ApprovalModulePurchase, Priority: 99, IsActive: true},
+		{Name: "Sales default", Module: models.ApprovalModuleSales, IsActive: true},
+	}
+	for i := range workflows {
+		require.NoError(t, db.Create(&workflows[i]).Error)
+	}
+	require.NoError(t, db.Model(&workflows[5]).Update("is_active", false).Error)
+
+	tests := []struct {
+		name   string
+		module string
+		req    models.CreateApprovalRequestDTO
+		want   string
+	}{
+		{name: "higher priority among equal matches", module: models.ApprovalModulePurchase, req: models.CreateApprovalRequestDTO{Amount: 1000000}, want: "Purchase small"},
+		{name: "outside the amount range", module: models.ApprovalModulePurchase, req: models.CreateApprovalRequestDTO{Amount: 8000000}, want: "Purchase default"},
+		{name: "highest minimum amount on a tie", module: models.ApprovalModulePurchase, req: models.CreateApprovalRequestDTO{Amount: 20000000}, want: "Purchase large"},
+		{name: "contact category", module: models.ApprovalModulePurchase, req: models.CreateApprovalRequestDTO{Amount: 1000000, ContactID: &wholesale.ID}, want: "Wholesale vendors"},
+		{name: "other contact category", module: models.ApprovalModulePurchase, req: models.CreateApprovalRequestDTO{Amount: 1000000, ContactID: &retail.ID}, want: "Purchase small"},
+		{name: "account and cost center", module: models.ApprovalModulePurchase, req: models.CreateApprovalRequestDTO{Amount: 1000000, AccountIDs: []uint{3, 7}, CostCenterIDs: []uint{5}}, want: "IT equipment"},
+		{name: "only one of two conditions", module: models.ApprovalModulePurchase, req: models.CreateApprovalRequestDTO{Amount: 1000000, CostCenterIDs: []uint{5}}, want: "Purchase small"},
+		{name: "other module", module: models.ApprovalModuleSales, req: models.CreateApprovalRequestDTO{Amount: 1000000}, want: "Sales default"},
+		{name: "no workflow", module: models.ApprovalModuleAsset, req: models.CreateApprovalRequestDTO{Amount: 1000000}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workflow, err := service.SelectWorkflow(tt.module, tt.req)
+			if tt.want == "" {
+				assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, workflow.Name)
+		})
+	}
+
+	assert.True(t, service.RequiresApproval(models.CreateApprovalRequestDTO{EntityType: models.EntityTypePurchase, Amount: 1000000}))
+	assert.False(t, service.RequiresApproval(models.CreateApprovalRequestDTO{EntityType: models.EntityTypeAsset, Amount: 1000000}))
+	assert.False(t, service.RequiresApproval(models.CreateApprovalRequestDTO{EntityType: "UNKNOWN", Amount: 1000000}))
+}
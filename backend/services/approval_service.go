@@ -16,13 +16,27 @@ type PostApprovalCallback interface {
 	OnPurchaseApproved(purchaseID uint) error
 }
 
+// EntityApprovalCallback runs after the approval of a document has been committed, for work that
+// cannot happen inside the approval transaction (e.g. posting a held payment)
+type EntityApprovalCallback func(entityID uint) error
+
+// EntityApprovalTxHook runs inside the approval transaction when a document is fully approved.
+// An error rolls the approval back (e.g. stock and cost layers of a purchase that cannot be booked).
+type EntityApprovalTxHook func(tx *gorm.DB, entityID uint) error
+
 type ApprovalService struct {
 	db *gorm.DB
 	postApprovalCallback PostApprovalCallback
+	entityCallbacks      map[string][]EntityApprovalCallback
+	entityTxHooks        map[string]EntityApprovalTxHook
 }
 
 func NewApprovalService(db *gorm.DB) *ApprovalService {
-	return &ApprovalService{db: db}
+	return &ApprovalService{
+		db:              db,
+		entityCallbacks: make(map[string][]EntityApprovalCallback),
+		entityTxHooks:   make(map[string]EntityApprovalTxHook),
+	}
 }
 
 // SetPostApprovalCallback sets the callback for post-approval processing
@@ -30,10 +44,26 @@ func (s *ApprovalService) SetPostApprovalCallback(callback PostApprovalCallback)
 	s.postApprovalCallback = callback
 }
 
+// RegisterApprovalCallback adds a callback run once a document of entityType is fully approved.
+// Every callback of the entity type runs; each one ignores documents it does not own.
+func (s *ApprovalService) RegisterApprovalCallback(entityType string, callback EntityApprovalCallback) {
+	s.entityCallbacks[entityType] = append(s.entityCallbacks[entityType], callback)
+}
+
+// SetApprovalTxHook sets the hook run inside the approval transaction once a document of entityType is
+// fully approved. There is one hook per entity type, so services constructed more than once replace it.
+func (s *ApprovalService) SetApprovalTxHook(entityType string, hook EntityApprovalTxHook) {
+	s.entityTxHooks[entityType] = hook
+}
+
 // Workflow Management
 
 // CreateWorkflow creates a new approval workflow
 func (s *ApprovalService) CreateWorkflow(req models.CreateApprovalWorkflowRequest) (*models.ApprovalWorkflow, error) {
+	if err := validateWorkflowSteps(req.Steps); err != nil {
+		return nil, err
+	}
+
 	tx := s.db.Begin()
 	defer func() {
 		if r := recover(); r != nil {
@@ -48,6 +78,10 @@ func (s *ApprovalService) CreateWorkflow(req models.CreateApprovalWorkflowReques
 		MaxAmount:       req.MaxAmount,
 		RequireDirector: req.RequireDirector,
 		RequireFinance:  req.RequireFinance,
+		ContactCategory: req.ContactCategory,
+		AccountID:       req.AccountID,
+		CostCenterID:    req.CostCenterID,
+		Priority:        req.Priority,
 		IsActive:        true,
 	}
 
@@ -59,12 +93,23 @@ func (s *ApprovalService) CreateWorkflow(req models.CreateApprovalWorkflowReques
 	// Create workflow steps
 	for _, stepReq := range req.Steps {
 		step := models.ApprovalStep{
-			WorkflowID:   workflow.ID,
-			StepOrder:    stepReq.StepOrder,
-			StepName:     stepReq.StepName,
-			ApproverRole: stepReq.ApproverRole,
-			IsOptional:   stepReq.IsOptional,
-			TimeLimit:    stepReq.TimeLimit,
+			WorkflowID:        workflow.ID,
+			StepOrder:         stepReq.StepOrder,
+			StepName:          stepReq.StepName,
+			ApproverType:      stepReq.ApproverType,
+			ApproverRole:      stepReq.ApproverRole,
+			ApproverUserID:    stepReq.ApproverUserID,
+			GroupUserIDs:      stepReq.GroupUserIDs,
+			RequiredApprovals: stepReq.RequiredApprovals,
+			IsOptional:        stepReq.IsOptional,
+			IsParallel:        stepReq.IsParallel,
+			TimeLimit:         stepReq.TimeLimit,
+		}
+		if step.ApproverType == "" {
+			step.ApproverType = models.ApproverTypeRole
+		}
+		if step.RequiredApprovals < 1 {
+			step.RequiredApprovals = 1
 		}
 
 		if err := tx.Create(&step).Error; err != nil {
@@ -121,21 +166,25 @@ func (s *ApprovalService) CreateApprovalRequest(req models.CreateApprovalRequest
 	}()
 
 	// Find appropriate workflow
-	var module string
-	switch req.EntityType {
-	case models.EntityTypeSale:
-		module = models.ApprovalModuleSales
-	case models.EntityTypePurchase:
-		module = models.ApprovalModulePurchase
-	default:
-		return nil, errors.New("unsupported entity type")
+	module, err := approvalModuleFor(req.EntityType)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
 	}
 
-	workflow, err := s.GetWorkflowByAmount(module, req.Amount)
+	workflow, err := s.SelectWorkflow(module, req)
 	if err != nil {
 		tx.Rollback()
 		return nil, fmt.Errorf("no workflow found for amount %.2f: %v", req.Amount, err)
 	}
+	if len(workflow.Steps) == 0 {
+		tx.Rollback()
+		return nil, fmt.Errorf("workflow %s has no steps", workflow.Name)
+	}
+	if err := s.checkStepApprovers(workflow, requesterID); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
 
 	// Generate request code
 	requestCode := s.generateRequestCode(req.EntityType)
@@ -231,13 +280,16 @@ func (s *ApprovalService) ProcessApprovalAction(requestID uint, userID uint, act
 		return errors.New("approval request is no longer pending")
 	}
 
-	// Find current active step assigned to this user's role (supports parallel steps)
+	// Find current active step this user can act on, directly or as a delegate (supports parallel steps)
+	resolver := s.newApproverResolver()
 	var currentAction *models.ApprovalAction
+	var onBehalfOf *uint
 	for i := range approvalReq.ApprovalSteps {
 		a := &approvalReq.ApprovalSteps[i]
 		if a.IsActive && a.Status == models.ApprovalStatusPending {
-			if s.canUserApprove(userID, a.Step.ApproverRole) {
+			if delegatorID, ok := resolver.canAct(userID, &approvalReq, a); ok {
 				currentAction = a
+				onBehalfOf = delegatorID
 				break
 			}
 		}
@@ -263,9 +315,46 @@ func (s *ApprovalService) ProcessApprovalAction(requestID uint, userID uint, act
 		return errors.New("invalid action")
 	}
 
+	historyComments := action.Comments
+	historyMetadata := "{}"
+	if onBehalfOf != nil {
+		historyComments = strings.TrimSpace(fmt.Sprintf("[on behalf of user %d] %s", *onBehalfOf, action.Comments))
+		historyMetadata = fmt.Sprintf("{\"on_behalf_of\": %d}", *onBehalfOf)
+	}
+
+	// GROUP steps stay active until enough members approved
+	if newStatus == models.ApprovalStatusApproved && currentAction.Step.ApproverType == models.ApproverTypeGroup {
+		voterID := userID
+		if onBehalfOf != nil {
+			voterID = *onBehalfOf
+		}
+		currentAction.ApprovedBy = append(currentAction.ApprovedBy, voterID)
+		currentAction.ActedBy = append(currentAction.ActedBy, userID)
+		required := requiredStepApprovals(&currentAction.Step)
+		if len(currentAction.ApprovedBy) < required {
+			if err := tx.Save(currentAction).Error; err != nil {
+				tx.Rollback()
+				return err
+			}
+			history := models.ApprovalHistory{
+				RequestID: requestID,
+				UserID:    userID,
+				Action:    historyAction,
+				Comments:  strings.TrimSpace(fmt.Sprintf("[%d of %d approvals for %s] %s", len(currentAction.ApprovedBy), required, currentAction.Step.StepName, historyComments)),
+				Metadata:  historyMetadata,
+			}
+			if err := tx.Create(&history).Error; err != nil {
+				tx.Rollback()
+				return err
+			}
+			return tx.Commit().Error
+		}
+	}
+
 	// Update current action
 	currentAction.Status = newStatus
 	currentAction.ApproverID = &userID
+	currentAction.OnBehalfOf = onBehalfOf
 	currentAction.Comments = action.Comments
 	currentAction.ActionDate = &now
 	currentAction.IsActive = false
@@ -280,8 +369,8 @@ func (s *ApprovalService) ProcessApprovalAction(requestID uint, userID uint, act
 		RequestID: requestID,
 		UserID:    userID,
 		Action:    historyAction,
-		Comments:  action.Comments,
-		Metadata:  "{}",
+		Comments:  historyComments,
+		Metadata:  historyMetadata,
 	}
 
 	if err := tx.Create(&history).Error; err != nil {
@@ -301,7 +390,7 @@ func (s *ApprovalService) ProcessApprovalAction(requestID uint, userID uint, act
 		}
 
 		// Update entity status
-		if err := s.updateEntityStatus(tx, approvalReq.EntityType, approvalReq.EntityID, "REJECTED"); err != nil {
+		if err := s.updateEntityStatus(tx, approvalReq.EntityType, approvalReq.EntityID, "REJECTED", userID); err != nil {
 			tx.Rollback()
 			return err
 		}
@@ -311,7 +400,9 @@ func (s *ApprovalService) ProcessApprovalAction(requestID uint, userID uint, act
 		var userCanApproveOtherSteps []*models.ApprovalAction
 		for i := range approvalReq.ApprovalSteps {
 			step := &approvalReq.ApprovalSteps[i]
-			if step.Status == models.ApprovalStatusPending && !step.IsActive {
+			// Only role steps: a named user, manager or group must approve in person
+			if step.Status == models.ApprovalStatusPending && !step.IsActive &&
+				(step.Step.ApproverType == "" || step.Step.ApproverType == models.ApproverTypeRole) {
 				if s.canUserApprove(userID, step.Step.ApproverRole) {
 					userCanApproveOtherSteps = append(userCanApproveOtherSteps, step)
 				}
@@ -405,10 +496,16 @@ func (s *ApprovalService) ProcessApprovalAction(requestID uint, userID uint, act
 			}
 
 			// Update entity status
-			if err := s.updateEntityStatus(tx, approvalReq.EntityType, approvalReq.EntityID, "APPROVED"); err != nil {
+			if err := s.updateEntityStatus(tx, approvalReq.EntityType, approvalReq.EntityID, "APPROVED", userID); err != nil {
 				tx.Rollback()
 				return err
 			}
+			if hook := s.entityTxHooks[approvalReq.EntityType]; hook != nil {
+				if err := hook(tx, approvalReq.EntityID); err != nil {
+					tx.Rollback()
+					return fmt.Errorf("approval could not be completed: %v", err)
+				}
+			}
 		} else {
 			// Check if there are any pending director steps that should be activated
 			var pendingDirectorStep *models.ApprovalAction
@@ -444,6 +541,15 @@ func (s *ApprovalService) ProcessApprovalAction(requestID uint, userID uint, act
 		return err
 	}
 
+	// Documents that are only finalised after commit (held payments, ...)
+	if approvalReq.Status == models.ApprovalStatusApproved {
+		for _, callback := range s.entityCallbacks[approvalReq.EntityType] {
+			if err := callback(approvalReq.EntityID); err != nil {
+				fmt.Printf("⚠️ Post-approval processing failed for %s %d: %v\n", approvalReq.EntityType, approvalReq.EntityID, err)
+			}
+		}
+	}
+
 	// POST-APPROVAL PROCESSING: Trigger business logic for approved purchases
 	if purchaseToProcess != nil && s.postApprovalCallback != nil {
 		go func(purchaseID uint) {
@@ -472,11 +578,16 @@ func (s *ApprovalService) GetApprovalRequests(userID uint, userRole string, stat
 
 	// Filter by user permissions
 	if userRoleNorm != "admin" && userRoleNorm != "director" {
-		// Regular users can only see requests they created or need to approve (case-insensitive role match)
-		query = query.Where(
-			"requester_id = ? OR id IN (SELECT DISTINCT request_id FROM approval_actions WHERE is_active = ? AND step_id IN (SELECT id FROM approval_steps WHERE LOWER(approver_role) = LOWER(?)))",
-			userID, true, userRoleNorm,
-		)
+		// Regular users can only see requests they created or need to approve (own steps or delegated)
+		actionableIDs, err := s.actionableRequestIDs(userID)
+		if err != nil {
+			return nil, 0, err
+		}
+		if len(actionableIDs) > 0 {
+			query = query.Where("requester_id = ? OR approval_requests.id IN ?", userID, actionableIDs)
+		} else {
+			query = query.Where("requester_id = ?", userID)
+		}
 	}
 
 	if status != "" {
@@ -502,12 +613,15 @@ func (s *ApprovalService) GetApprovalRequests(userID uint, userRole string, stat
 func (s *ApprovalService) GetPendingApprovals(userID uint, userRole string) ([]models.ApprovalRequest, error) {
 	var requests []models.ApprovalRequest
 
-	userRoleNorm := strings.ToLower(strings.TrimSpace(userRole))
-	
-	err := s.db.Preload("Workflow").Preload("Requester").
+	// userRole is kept for callers; the steps themselves decide who approves
+	actionableIDs, err := s.actionableRequestIDs(userID)
+	if err != nil || len(actionableIDs) == 0 {
+		return requests, err
+	}
+
+	err = s.db.Preload("Workflow").Preload("Requester").
 		Preload("ApprovalSteps.Step").Preload("ApprovalSteps.Approver").
-		Where("status = ? AND id IN (SELECT request_id FROM approval_actions WHERE is_active = ? AND step_id IN (SELECT id FROM approval_steps WHERE LOWER(approver_role) = LOWER(?)))",
-			models.ApprovalStatusPending, true, userRoleNorm).
+		Where("status = ? AND id IN ?", models.ApprovalStatusPending, actionableIDs).
 		Order("created_at ASC").
 		Find(&requests).Error
 
@@ -549,6 +663,18 @@ func (s *ApprovalService) generateRequestCode(entityType string) string {
 		prefix = "APP-SALE"
 	case models.EntityTypePurchase:
 		prefix = "APP-PUR"
+	case models.EntityTypeBudget:
+		prefix = "APP-BGT"
+	case models.EntityTypeRequisition:
+		prefix = "APP-PRQ"
+	case models.EntityTypeJournal:
+		prefix = "APP-JRN"
+	case models.EntityTypePayment:
+		prefix = "APP-PAY"
+	case models.EntityTypeAsset:
+		prefix = "APP-AST"
+	case models.EntityTypeWriteOff:
+		prefix = "APP-WO"
 	default:
 		prefix = "APP-REQ"
 	}
@@ -564,8 +690,13 @@ func (s *ApprovalService) canUserApprove(userID uint, requiredRole string) bool
 		return false
 	}
 
+	return roleCanApprove(user.Role, requiredRole)
+}
+
+// roleCanApprove checks if a user role can approve a step of the required role
+func roleCanApprove(role string, requiredRole string) bool {
 	// Normalize roles to lower-case for comparison
-	userRole := strings.ToLower(strings.TrimSpace(user.Role))
+	userRole := strings.ToLower(strings.TrimSpace(role))
 	reqRole := strings.ToLower(strings.TrimSpace(requiredRole))
 
 	// Admin can approve anything
@@ -608,7 +739,7 @@ func (s *ApprovalService) getNextStep(actions []models.ApprovalAction, currentOr
 }
 
 // updateEntityStatus updates the status of sale/purchase entity
-func (s *ApprovalService) updateEntityStatus(tx *gorm.DB, entityType string, entityID uint, approvalStatus string) error {
+func (s *ApprovalService) updateEntityStatus(tx *gorm.DB, entityType string, entityID uint, approvalStatus string, approverID uint) error {
 	var status, approvalStatusField string
 
 	switch approvalStatus {
@@ -635,7 +766,12 @@ func (s *ApprovalService) updateEntityStatus(tx *gorm.DB, entityType string, ent
 
 	switch entityType {
 	case models.EntityTypeSale:
-		return tx.Model(&models.Sale{}).Where("id = ?", entityID).Updates(updates).Error
+		// Sales are only routed to approval by credit control; the sale itself stays in its status
+		// and is confirmed/invoiced again once approved
+		return tx.Model(&models.Sale{}).Where("id = ?", entityID).Updates(map[string]interface{}{
+			"credit_approval_status": approvalStatusField,
+			"updated_at":             now,
+		}).Error
 	case models.EntityTypePurchase:
 		err := tx.Model(&models.Purchase{}).Where("id = ?", entityID).Updates(updates).Error
 		if err != nil {
@@ -650,6 +786,68 @@ func (s *ApprovalService) updateEntityStatus(tx *gorm.DB, entityType string, ent
 		}
 		
 		return nil
+	case models.EntityTypeBudget:
+		// Budgets have no approval_status column; rejected budgets go back to DRAFT for revision
+		budgetUpdates := map[string]interface{}{
+			"status":     models.BudgetStatusDraft,
+			"updated_at": now,
+		}
+		if approvalStatus == "APPROVED" {
+			budgetUpdates["status"] = models.BudgetStatusApproved
+			budgetUpdates["approved_at"] = now
+		}
+		return tx.Model(&models.Budget{}).Where("id = ?", entityID).Updates(budgetUpdates).Error
+	case models.EntityTypeRequisition:
+		// Approved requisitions wait for purchasing to consolidate them into purchase orders
+		requisitionUpdates := map[string]interface{}{
+			"status":     models.RequisitionStatusRejected,
+			"updated_at": now,
+		}
+		if approvalStatus == "APPROVED" {
+			requisitionUpdates["status"] = models.RequisitionStatusApproved
+			requisitionUpdates["approved_at"] = now
+		}
+		return tx.Model(&models.PurchaseRequisition{}).Where("id = ?", entityID).Updates(requisitionUpdates).Error
+	case models.EntityTypeJournal:
+		// Manual journals wait as DRAFT; they are posted by the final approval
+		if approvalStatus == "APPROVED" {
+			return NewUnifiedJournalService(s.db).PostDraftJournalEntryWithTx(tx, uint64(entityID), uint64(approverID))
+		}
+		return tx.Model(&models.SSOTJournalEntry{}).Where("id = ?", entityID).Updates(map[string]interface{}{
+			"status":     models.SSOTStatusCancelled,
+			"updated_at": now,
+		}).Error
+	case models.EntityTypePayment:
+		// Approved payments stay held until the PAYMENT callback posts them after commit
+		if approvalStatus == "APPROVED" {
+			return nil
+		}
+		return tx.Model(&models.Payment{}).Where("id = ?", entityID).Updates(map[string]interface{}{
+			"status":     models.PaymentStatusRejected,
+			"updated_at": now,
+		}).Error
+	case models.EntityTypeAsset:
+		assetUpdates := map[string]interface{}{
+			"status":     models.AssetStatusRejected,
+			"is_active":  false,
+			"updated_at": now,
+		}
+		if approvalStatus == "APPROVED" {
+			assetUpdates["status"] = models.AssetStatusActive
+			assetUpdates["is_active"] = true
+		}
+		return tx.Model(&models.Asset{}).Where("id = ?", entityID).Updates(assetUpdates).Error
+	case models.EntityTypeWriteOff:
+		writeOffUpdates := map[string]interface{}{
+			"status":     models.WriteOffStatusRejected,
+			"updated_at": now,
+		}
+		if approvalStatus == "APPROVED" {
+			writeOffUpdates["status"] = models.WriteOffStatusApproved
+			writeOffUpdates["approved_by"] = approverID
+			writeOffUpdates["approved_at"] = now
+		}
+		return tx.Model(&models.WriteOffSuggestion{}).Where("id = ?", entityID).Updates(writeOffUpdates).Error
 	default:
 		return errors.New("unsupported entity type")
 	}
@@ -657,13 +855,12 @@ func (s *ApprovalService) updateEntityStatus(tx *gorm.DB, entityType string, ent
 
 // notifyApprovers sends notifications to approvers
 func (s *ApprovalService) notifyApprovers(request *models.ApprovalRequest, step models.ApprovalStep) {
-	// Get users with the required role (case-insensitive)
-	var users []models.User
-	s.db.Where("LOWER(role) = LOWER(?) AND is_active = ?", step.ApproverRole, true).Find(&users)
+	// Get the approvers of the step (role, user, manager or group) and their delegates
+	approverIDs := s.stepApproverIDs(request, step)
 
-	for _, user := range users {
+	for _, approverID := range approverIDs {
 		// Check for duplicate notifications
-		if s.isDuplicateApprovalNotification(user.ID, request.ID, models.NotificationTypeApprovalPending) {
+		if s.isDuplicateApprovalNotification(approverID, request.ID, models.NotificationTypeApprovalPending) {
 			continue // Skip creating duplicate
 		}
 
@@ -677,7 +874,7 @@ func (s *ApprovalService) notifyApprovers(request *models.ApprovalRequest, step
 		}
 
 		notification := models.Notification{
-			UserID:   user.ID,
+			UserID:   approverID,
 			Type:     models.NotificationTypeApprovalPending,
 			Title:    fmt.Sprintf("Approval Required: %s", request.RequestTitle),
 			Message:  fmt.Sprintf("You have a pending approval request for %s (Amount: %s)", request.RequestTitle, utils.FormatRupiahWithoutDecimals(actualAmount)),
@@ -0,0 +1,533 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"app-sistem-akuntansi/models"
+	"app-sistem-akuntansi/repositories"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Default accounts for the monthly depreciation run (see SeedAccountsImproved).
+// Asset categories can override them with their own expense / accumulated depreciation accounts.
+const (
+	DepreciationExpenseAccountCode     = "5205" // Beban Penyusutan
+	AccumulatedDepreciationAccountCode = "1590" // Akumulasi Penyusutan
+)
+
+type AssetDepreciationService struct {
+	db             *gorm.DB
+	assetService   AssetServiceInterface
+	journalService *UnifiedJournalService
+	periodService  *UnifiedPeriodClosingService
+}
+
+func NewAssetDepreciationService(db *gorm.DB) *AssetDepreciationService {
+	return &AssetDepreciationService{
+		db:             db,
+		assetService:   NewAssetService(repositories.NewAssetRepository(db), db),
+		journalService: NewUnifiedJournalService(db),
+		periodService:  NewUnifiedPeriodClosingService(db),
+	}
+}
+
+// depreciationGroup is one expense / accumulated depreciation pair of the consolidated journal
+type depreciationGroup struct {
+	CategoryID           uint
+	CategoryName         string
+	ExpenseAccountID     uint
+	AccumulatedAccountID uint
+	Amount               decimal.Decimal
+}
+
+// ========== RUNS ==========
+
+// GetRuns - Daftar run penyusutan bulanan
+func (s *AssetDepreciationService) GetRuns(period, status string) ([]models.AssetDepreciationRun, error) {
+	var runs []models.AssetDepreciationRun
+	query := s.db.Model(&models.AssetDepreciationRun{})
+	if period != "" {
+		query = query.Where("period = ?", period)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Order("period DESC, id DESC").Find(&runs).Error; err != nil {
+		return nil, err
+	}
+	return runs, nil
+}
+
+// GetRunByID - Detail run beserta penyusutan per aset
+func (s *AssetDepreciationService) GetRunByID(id uint) (*models.AssetDepreciationRun, error) {
+	var run models.AssetDepreciationRun
+	if err := s.db.Preload("Entries", func(db *gorm.DB) *gorm.DB {
+		return db.Order("asset_id ASC")
+	}).Preload("Entries.Asset").First(&run, id).Error; err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+// GetAssetLedger - Riwayat penyusutan per periode untuk satu aset
+func (s *AssetDepreciationService) GetAssetLedger(assetID uint) ([]models.AssetDepreciationEntry, error) {
+	var entries []models.AssetDepreciationEntry
+	if err := s.db.Where("asset_id = ?", assetID).Order("period ASC, id ASC").Find(&entries).Error; err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// ========== DEPRECIATION ==========
+
+// PreviewRun - Dry run: hitung penyusutan periode tanpa menyimpan atau membuat jurnal
+func (s *AssetDepreciationService) PreviewRun(req models.AssetDepreciationRunRequest) (*models.AssetDepreciationRun, error) {
+	periodEnd, err := parseDepreciationPeriod(req.Period)
+	if err != nil {
+		return nil, err
+	}
+
+	run, err := s.buildRun(s.db, req.Period, periodEnd, false)
+	if err != nil {
+		return nil, err
+	}
+	run.Status = models.DepreciationRunPreview
+	run.Notes = req.Notes
+	return run, nil
+}
+
+// RunDepreciation posts the depreciation of every active asset for a month. Assets that already have a
+// POSTED ledger entry for the month are skipped, so running the same month again only picks up assets
+// added since the last run.
+func (s *AssetDepreciationService) RunDepreciation(req models.AssetDepreciationRunRequest, userID uint) (*models.AssetDepreciationRun, error) {
+	periodEnd, err := parseDepreciationPeriod(req.Period)
+	if err != nil {
+		return nil, err
+	}
+	closed, err := s.periodService.IsDateInClosedPeriod(context.Background(), periodEnd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check accounting period: %v", err)
+	}
+	if closed {
+		return nil, fmt.Errorf("accounting period %s is closed", req.Period)
+	}
+
+	var runID uint
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		run, err := s.buildRun(tx, req.Period, periodEnd, true)
+		if err != nil {
+			return err
+		}
+		if len(run.Entries) == 0 {
+			return fmt.Errorf("no assets left to depreciate for %s", req.Period)
+		}
+
+		code, err := s.generateRunCode(tx, periodEnd)
+		if err != nil {
+			return err
+		}
+		run.Code = code
+		run.Status = models.DepreciationRunPosted
+		run.Notes = req.Notes
+		run.CreatedBy = userID
+
+		entries := run.Entries
+		if err := tx.Omit(clause.Associations).Create(run).Error; err != nil {
+			return fmt.Errorf("failed to create depreciation run: %v", err)
+		}
+		for i := range entries {
+			entries[i].RunID = &run.ID
+		}
+		if err := tx.Omit(clause.Associations).Create(&entries).Error; err != nil {
+			return fmt.Errorf("failed to record depreciation ledger: %v", err)
+		}
+
+		lines := buildDepreciationLines(entries, run.Period, false)
+		journal, err := s.journalService.CreateJournalEntryWithTx(tx, &JournalEntryRequest{
+			SourceType:  models.SSOTSourceTypeDepreciation,
+			SourceID:    uint64(run.ID),
+			Reference:   run.Code,
+			EntryDate:   periodEnd,
+			Description: fmt.Sprintf("Penyusutan aset tetap periode %s", run.Period),
+			Lines:       lines,
+			AutoPost:    true,
+			CreatedBy:   uint64(userID),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to post depreciation journal: %v", err)
+		}
+		if err := tx.Model(run).Update("journal_entry_id", journal.ID).Error; err != nil {
+			return err
+		}
+
+		for _, entry := range entries {
+			if err := tx.Model(&models.Asset{}).Where("id = ?", entry.AssetID).
+				Update("accumulated_depreciation", entry.AccumulatedAfter).Error; err != nil {
+				return fmt.Errorf("failed to update accumulated depreciation of asset %d: %v", entry.AssetID, err)
+			}
+		}
+
+		runID = run.ID
+		log.Printf("🏭 Depreciation run %s posted: %d assets, total %.2f", run.Code, run.AssetCount, run.TotalAmount)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetRunByID(runID)
+}
+
+// RollbackRun reverses a posted run while its period is still open. The journal is reversed on the
+// period end date and the accumulated depreciation of every asset in the run is restored.
+func (s *AssetDepreciationService) RollbackRun(id uint, req models.AssetDepreciationRollbackRequest, userID uint) (*models.AssetDepreciationRun, error) {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var run models.AssetDepreciationRun
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&run, id).Error; err != nil {
+			return errors.New("depreciation run not found")
+		}
+		if run.Status != models.DepreciationRunPosted {
+			return fmt.Errorf("depreciation run is already %s", run.Status)
+		}
+
+		closed, err := s.periodService.IsDateInClosedPeriod(context.Background(), run.PeriodEnd)
+		if err != nil {
+			return fmt.Errorf("failed to check accounting period: %v", err)
+		}
+		if closed {
+			return fmt.Errorf("accounting period %s is closed, the run can no longer be rolled back", run.Period)
+		}
+
+		var entries []models.AssetDepreciationEntry
+		if err := tx.Preload("Asset.AssetCategory").
+			Where("run_id = ? AND status = ?", run.ID, models.DepreciationRunPosted).
+			Find(&entries).Error; err != nil {
+			return err
+		}
+
+		assetIDs := make([]uint, len(entries))
+		for i, entry := range entries {
+			assetIDs[i] = entry.AssetID
+		}
+		if len(assetIDs) > 0 {
+			var later int64
+			if err := tx.Model(&models.AssetDepreciationEntry{}).
+				Where("asset_id IN ? AND period > ? AND status = ?", assetIDs, run.Period, models.DepreciationRunPosted).
+				Count(&later).Error; err != nil {
+				return err
+			}
+			if later > 0 {
+				return errors.New("assets in this run were depreciated in a later period, roll back the later runs first")
+			}
+
+			var disposed int64
+			if err := tx.Model(&models.Asset{}).
+				Where("id IN ? AND status <> ?", assetIDs, models.AssetStatusActive).
+				Count(&disposed).Error; err != nil {
+				return err
+			}
+			if disposed > 0 {
+				return errors.New("assets in this run have already been disposed, the run can no longer be rolled back")
+			}
+		}
+
+		updates := map[string]interface{}{
+			"status":          models.DepreciationRunRolledBack,
+			"rolled_back_by":  userID,
+			"rolled_back_at":  time.Now(),
+			"rollback_reason": req.Reason,
+		}
+
+		if run.JournalEntryID != nil && len(entries) > 0 {
+			reversal, err := s.journalService.CreateJournalEntryWithTx(tx, &JournalEntryRequest{
+				SourceType:  models.SSOTSourceTypeDepreciation,
+				SourceID:    uint64(run.ID),
+				Reference:   run.Code,
+				EntryDate:   run.PeriodEnd,
+				Description: fmt.Sprintf("Pembatalan penyusutan aset tetap periode %s", run.Period),
+				Lines:       buildDepreciationLines(entries, run.Period, true),
+				AutoPost:    true,
+				CreatedBy:   uint64(userID),
+			})
+			if err != nil {
+				return fmt.Errorf("failed to post depreciation reversal: %v", err)
+			}
+			if err := tx.Model(&models.SSOTJournalEntry{}).Where("id = ?", reversal.ID).Updates(map[string]interface{}{
+				"reversed_from":   *run.JournalEntryID,
+				"reversal_reason": fmt.Sprintf("Rollback of depreciation run %s: %s", run.Code, req.Reason),
+			}).Error; err != nil {
+				return fmt.Errorf("failed to link depreciation reversal: %v", err)
+			}
+			updates["reversal_journal_id"] = reversal.ID
+		}
+
+		for _, entry := range entries {
+			if err := tx.Model(&models.Asset{}).Where("id = ?", entry.AssetID).
+				Update("accumulated_depreciation", gorm.Expr("accumulated_depreciation - ?", entry.Amount)).Error; err != nil {
+				return fmt.Errorf("failed to restore accumulated depreciation of asset %d: %v", entry.AssetID, err)
+			}
+		}
+		if err := tx.Model(&models.AssetDepreciationEntry{}).Where("run_id = ?", run.ID).
+			Update("status", models.DepreciationRunRolledBack).Error; err != nil {
+			return err
+		}
+
+		log.Printf("🏭 Depreciation run %s rolled back by user %d", run.Code, userID)
+		return tx.Model(&run).Updates(updates).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetRunByID(id)
+}
+
+// buildRun calculates the depreciation of the month for every active asset that has not been posted yet
+func (s *AssetDepreciationService) buildRun(tx *gorm.DB, period string, periodEnd time.Time, lock bool) (*models.AssetDepreciationRun, error) {
+	query := tx.Preload("AssetCategory").
+		Where("status = ? AND is_active = ? AND purchase_date <= ?", models.AssetStatusActive, true, periodEnd)
+	if lock {
+		query = query.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+	var assets []models.Asset
+	if err := query.Order("id ASC").Find(&assets).Error; err != nil {
+		return nil, err
+	}
+
+	var postedIDs []uint
+	if err := tx.Model(&models.AssetDepreciationEntry{}).
+		Where("period = ? AND status = ?", period, models.DepreciationRunPosted).
+		Pluck("asset_id", &postedIDs).Error; err != nil {
+		return nil, err
+	}
+	posted := make(map[uint]bool, len(postedIDs))
+	for _, id := range postedIDs {
+		posted[id] = true
+	}
+
+	run := &models.AssetDepreciationRun{
+		Period:    period,
+		PeriodEnd: periodEnd,
+	}
+
+	defaults := make(map[string]uint)
+	total := decimal.Zero
+	for i := range assets {
+		asset := &assets[i]
+		if posted[asset.ID] {
+			continue
+		}
+
+		amount, err := s.monthlyAmount(asset, periodEnd)
+		if err != nil {
+			return nil, fmt.Errorf("failed to calculate depreciation of asset %s: %v", asset.Code, err)
+		}
+		if amount <= 0 {
+			continue
+		}
+
+		expenseID, accumulatedID, err := depreciationAccountsFor(tx, asset, defaults)
+		if err != nil {
+			return nil, err
+		}
+
+		accumulatedAfter := roundAmount(asset.AccumulatedDepreciation + amount)
+		run.Entries = append(run.Entries, models.AssetDepreciationEntry{
+			AssetID:              asset.ID,
+			CategoryID:           asset.CategoryID,
+			Period:               period,
+			PeriodEnd:            periodEnd,
+			Amount:               amount,
+			AccumulatedBefore:    asset.AccumulatedDepreciation,
+			AccumulatedAfter:     accumulatedAfter,
+			BookValueAfter:       roundAmount(asset.PurchasePrice - accumulatedAfter - asset.ImpairmentLoss),
+			ExpenseAccountID:     expenseID,
+			AccumulatedAccountID: accumulatedID,
+			Status:               models.DepreciationRunPosted,
+			Asset:                asset,
+		})
+		total = total.Add(decimal.NewFromFloat(amount))
+	}
+
+	run.AssetCount = len(run.Entries)
+	run.TotalAmount = total.Round(2).InexactFloat64()
+	return run, nil
+}
+
+// monthlyAmount is the depreciation still to be booked up to the period end. It catches up months that
+// were never posted and never takes the book value (after impairment) below the salvage value.
+//
+// After an impairment the carrying amount left at ImpairedAt is spread over the remaining useful life
+// (PSAK 48): the rest of the original schedule is scaled down to the post-impairment carrying amount less
+// salvage, which for straight line is an equal charge over the remaining months.
+func (s *AssetDepreciationService) monthlyAmount(asset *models.Asset, periodEnd time.Time) (float64, error) {
+	depreciable := asset.PurchasePrice - asset.SalvageValue - asset.ImpairmentLoss
+	if depreciable <= 0 {
+		return 0, nil
+	}
+
+	target, err := s.assetService.CalculateDepreciation(asset, periodEnd)
+	if err != nil {
+		return 0, err
+	}
+	if asset.ImpairedAt != nil {
+		if target, err = s.targetAfterImpairment(asset, target, periodEnd); err != nil {
+			return 0, err
+		}
+	}
+	if target > depreciable {
+		target = depreciable
+	}
+	return roundAmount(target - asset.AccumulatedDepreciation), nil
+}
+
+// targetAfterImpairment rebases the original cumulative depreciation at periodEnd on the carrying amount
+// left after the last impairment
+func (s *AssetDepreciationService) targetAfterImpairment(asset *models.Asset, target float64, periodEnd time.Time) (float64, error) {
+	if !periodEnd.After(*asset.ImpairedAt) {
+		return asset.AccumulatedAtImpairment, nil
+	}
+	targetAtImpairment, err := s.assetService.CalculateDepreciation(asset, *asset.ImpairedAt)
+	if err != nil {
+		return 0, err
+	}
+
+	remainingSchedule := asset.PurchasePrice - asset.SalvageValue - targetAtImpairment
+	remainingCarrying := asset.PurchasePrice - asset.SalvageValue - asset.ImpairmentLoss - asset.AccumulatedAtImpairment
+	if remainingSchedule <= 0 || remainingCarrying <= 0 {
+		return asset.AccumulatedAtImpairment, nil
+	}
+	return asset.AccumulatedAtImpairment + (target-targetAtImpairment)*remainingCarrying/remainingSchedule, nil
+}
+
+// buildDepreciationLines consolidates the ledger entries into one debit/credit pair per category and account
+// mapping. reverse swaps the sides for the rollback journal.
+func buildDepreciationLines(entries []models.AssetDepreciationEntry, period string, reverse bool) []JournalLineRequest {
+	type groupKey struct {
+		categoryID, expenseID, accumulatedID uint
+	}
+	groups := make(map[groupKey]*depreciationGroup)
+	var keys []groupKey
+	for _, entry := range entries {
+		key := groupKey{expenseID: entry.ExpenseAccountID, accumulatedID: entry.AccumulatedAccountID}
+		if entry.CategoryID != nil {
+			key.categoryID = *entry.CategoryID
+		}
+		group, ok := groups[key]
+		if !ok {
+			group = &depreciationGroup{
+				CategoryID:           key.categoryID,
+				CategoryName:         "Tanpa Kategori",
+				ExpenseAccountID:     key.expenseID,
+				AccumulatedAccountID: key.accumulatedID,
+				Amount:               decimal.Zero,
+			}
+			if entry.Asset != nil && entry.Asset.AssetCategory != nil {
+				group.CategoryName = entry.Asset.AssetCategory.Name
+			} else if entry.Asset != nil && entry.Asset.Category != "" {
+				group.CategoryName = entry.Asset.Category
+			}
+			groups[key] = group
+			keys = append(keys, key)
+		}
+		group.Amount = group.Amount.Add(decimal.NewFromFloat(entry.Amount))
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].categoryID != keys[j].categoryID {
+			return keys[i].categoryID < keys[j].categoryID
+		}
+		if keys[i].expenseID != keys[j].expenseID {
+			return keys[i].expenseID < keys[j].expenseID
+		}
+		return keys[i].accumulatedID < keys[j].accumulatedID
+	})
+
+	lines := make([]JournalLineRequest, 0, len(keys)*2)
+	for _, key := range keys {
+		group := groups[key]
+		amount := group.Amount.Round(2)
+		description := fmt.Sprintf("Penyusutan %s %s", group.CategoryName, period)
+		expense := JournalLineRequest{AccountID: uint64(group.ExpenseAccountID), DebitAmount: amount, Description: description}
+		accumulated := JournalLineRequest{AccountID: uint64(group.AccumulatedAccountID), CreditAmount: amount, Description: description}
+		if reverse {
+			expense.DebitAmount, expense.CreditAmount = decimal.Zero, amount
+			accumulated.DebitAmount, accumulated.CreditAmount = amount, decimal.Zero
+			expense.Description = "Pembatalan: " + description
+			accumulated.Description = "Pembatalan: " + description
+		}
+		lines = append(lines, expense, accumulated)
+	}
+	return lines
+}
+
+func (s *AssetDepreciationService) generateRunCode(tx *gorm.DB, periodEnd time.Time) (string, error) {
+	var count int64
+	prefix := fmt.Sprintf("DEP-%s-", periodEnd.Format("200601"))
+	if err := tx.Unscoped().Model(&models.AssetDepreciationRun{}).
+		Where("code LIKE ?", prefix+"%").
+		Count(&count).Error; err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s%04d", prefix, count+1), nil
+}
+
+// depreciationAccountsFor returns the expense and accumulated depreciation accounts of an asset:
+// the asset's own expense account, then the category mapping, then the default account codes.
+// defaults caches the default account IDs across assets of one run.
+func depreciationAccountsFor(tx *gorm.DB, asset *models.Asset, defaults map[string]uint) (uint, uint, error) {
+	expenseID, accumulatedID := uint(0), uint(0)
+	if asset.DepreciationAccountID != nil {
+		expenseID = *asset.DepreciationAccountID
+	} else if asset.AssetCategory != nil && asset.AssetCategory.DepreciationExpenseAccountID != nil {
+		expenseID = *asset.AssetCategory.DepreciationExpenseAccountID
+	}
+	if asset.AssetCategory != nil && asset.AssetCategory.AccumulatedDepreciationAccountID != nil {
+		accumulatedID = *asset.AssetCategory.AccumulatedDepreciationAccountID
+	}
+
+	var err error
+	if expenseID == 0 {
+		if expenseID, err = defaultDepreciationAccount(tx, DepreciationExpenseAccountCode, defaults); err != nil {
+			return 0, 0, err
+		}
+	}
+	if accumulatedID == 0 {
+		if accumulatedID, err = defaultDepreciationAccount(tx, AccumulatedDepreciationAccountCode, defaults); err != nil {
+			return 0, 0, err
+		}
+	}
+	return expenseID, accumulatedID, nil
+}
+
+func defaultDepreciationAccount(tx *gorm.DB, code string, defaults map[string]uint) (uint, error) {
+	if id, ok := defaults[code]; ok {
+		return id, nil
+	}
+	var account models.Account
+	if err := tx.Where("code = ?", code).First(&account).Error; err != nil {
+		return 0, fmt.Errorf("depreciation account %s not found, map the asset category to an account: %v", code, err)
+	}
+	defaults[code] = account.ID
+	return account.ID, nil
+}
+
+// parseDepreciationPeriod validates a YYYY-MM period and returns its last day
+func parseDepreciationPeriod(period string) (time.Time, error) {
+	start, err := time.Parse("2006-01", period)
+	if err != nil {
+		return time.Time{}, errors.New("period must be in YYYY-MM format")
+	}
+	if start.After(time.Now()) {
+		return time.Time{}, errors.New("cannot depreciate a future period")
+	}
+	return start.AddDate(0, 1, -1), nil
+}
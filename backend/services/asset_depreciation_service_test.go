@@ -0,0 +1,131 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"app-sistem-akuntansi/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMonthlyAmountCatchUp(t *testing.T) {
+	service := &AssetDepreciationService{assetService: &AssetService{}}
+	purchased := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	monthEnd := func(year int, month time.Month) time.Time {
+		return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC)
+	}
+
+	// Impaired by 3,000,000 at the end of June 2024, when 6,000,000 of depreciation had been booked
+	impairedAt := monthEnd(2024, time.June)
+	impaired := func(price, salvage, accumulated float64) models.Asset {
+		return models.Asset{
+			PurchasePrice: price, SalvageValue: salvage, UsefulLife: 1, PurchaseDate: purchased,
+			ImpairmentLoss: 3000000, ImpairedAt: &impairedAt, AccumulatedAtImpairment: 6000000,
+			AccumulatedDepreciation: accumulated,
+		}
+	}
+
+	tests := []struct {
+		name      string
+		asset     models.Asset
+		periodEnd time.Time
+		want      float64
+	}{
+		{
+			name:      "first month",
+			asset:     models.Asset{PurchasePrice: 12000000, UsefulLife: 1, PurchaseDate: purchased},
+			periodEnd: monthEnd(2024, time.January),
+			want:      1000000,
+		},
+		{
+			name:      "catches up the months that were never posted",
+			asset:     models.Asset{PurchasePrice: 12000000, UsefulLife: 1, PurchaseDate: purchased, AccumulatedDepreciation: 1000000},
+			periodEnd: monthEnd(2024, time.March),
+			want:      2000000,
+		},
+		{
+			name:      "nothing left when the period is already posted",
+			asset:     models.Asset{PurchasePrice: 12000000, UsefulLife: 1, PurchaseDate: purchased, AccumulatedDepreciation: 3000000},
+			periodEnd: monthEnd(2024, time.March),
+			want:      0,
+		},
+		{
+			name:      "stops at the salvage value after the useful life",
+			asset:     models.Asset{PurchasePrice: 12000000, SalvageValue: 1200000, UsefulLife: 1, PurchaseDate: purchased, AccumulatedDepreciation: 9900000},
+			periodEnd: monthEnd(2025, time.June),
+			want:      900000,
+		},
+		{
+			name:      "impairment without a date only caps the depreciable amount",
+			asset:     models.Asset{PurchasePrice: 12000000, ImpairmentLoss: 3000000, UsefulLife: 1, PurchaseDate: purchased, AccumulatedDepreciation: 8000000},
+			periodEnd: monthEnd(2024, time.December),
+			want:      1000000,
+		},
+		{
+			name:      "nothing more in the month of the impairment",
+			asset:     impaired(12000000, 0, 6000000),
+			periodEnd: monthEnd(2024, time.June),
+			want:      0,
+		},
+		{
+			name:      "carrying amount after impairment spread over the remaining months",
+			asset:     impaired(12000000, 0, 6000000),
+			periodEnd: monthEnd(2024, time.July),
+			want:      500000,
+		},
+		{
+			name:      "catches up months after the impairment at the reduced charge",
+			asset:     impaired(12000000, 0, 6500000),
+			periodEnd: monthEnd(2024, time.September),
+			want:      1000000,
+		},
+		{
+			name:      "last month after impairment ends at the depreciable amount",
+			asset:     impaired(12000000, 0, 8500000),
+			periodEnd: monthEnd(2024, time.December),
+			want:      500000,
+		},
+		{
+			name:      "nothing left after the useful life of an impaired asset",
+			asset:     impaired(12000000, 0, 9000000),
+			periodEnd: monthEnd(2025, time.March),
+			want:      0,
+		},
+		{
+			name:      "salvage value stays out of the charge after impairment",
+			asset:     impaired(13200000, 1200000, 6000000),
+			periodEnd: monthEnd(2024, time.July),
+			want:      500000,
+		},
+		{
+			name:      "fully impaired asset is not depreciated",
+			asset:     models.Asset{PurchasePrice: 12000000, ImpairmentLoss: 12000000, UsefulLife: 1, PurchaseDate: purchased},
+			periodEnd: monthEnd(2024, time.December),
+			want:      0,
+		},
+		{
+			name:      "rounds to cents",
+			asset:     models.Asset{PurchasePrice: 1000000, UsefulLife: 3, PurchaseDate: purchased},
+			periodEnd: monthEnd(2024, time.January),
+			want:      27777.78,
+		},
+		{
+			name:      "before the purchase month",
+			asset:     models.Asset{PurchasePrice: 12000000, UsefulLife: 1, PurchaseDate: purchased},
+			periodEnd: monthEnd(2023, time.December),
+			want:      0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			asset := tt.asset
+			asset.DepreciationMethod = models.DepreciationMethodStraightLine
+			got, err := service.monthlyAmount(&asset, tt.periodEnd)
+			require.NoError(t, err)
+			assert.InDelta(t, tt.want, got, 0.001)
+		})
+	}
+}
@@ -0,0 +1,504 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"app-sistem-akuntansi/models"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Default accounts for asset disposal results (see SeedAccountsImproved)
+const (
+	AssetDisposalGainAccountCode = "4203" // Laba Pelepasan Aset Tetap
+	AssetDisposalLossAccountCode = "5903" // Rugi Pelepasan Aset Tetap
+	AssetImpairmentAccountCode   = "5904" // Rugi Penurunan Nilai Aset
+	AssetReceivableAccountCode   = "1201" // Piutang Usaha
+)
+
+// ReferenceType of cash-bank transactions created by asset sale proceeds
+const CashBankRefAssetDisposal = "ASSET_DISPOSAL"
+
+type AssetDisposalService struct {
+	db                  *gorm.DB
+	depreciationService *AssetDepreciationService
+	journalService      *UnifiedJournalService
+	periodService       *UnifiedPeriodClosingService
+}
+
+func NewAssetDisposalService(db *gorm.DB) *AssetDisposalService {
+	return &AssetDisposalService{
+		db:                  db,
+		depreciationService: NewAssetDepreciationService(db),
+		journalService:      NewUnifiedJournalService(db),
+		periodService:       NewUnifiedPeriodClosingService(db),
+	}
+}
+
+// GetDisposals - Daftar transaksi pelepasan dan penurunan nilai aset
+func (s *AssetDisposalService) GetDisposals(assetID uint, disposalType string) ([]models.AssetDisposal, error) {
+	var disposals []models.AssetDisposal
+	query := s.db.Preload("Asset")
+	if assetID != 0 {
+		query = query.Where("asset_id = ?", assetID)
+	}
+	if disposalType != "" {
+		query = query.Where("type = ?", strings.ToUpper(disposalType))
+	}
+	if err := query.Order("disposal_date DESC, id DESC").Find(&disposals).Error; err != nil {
+		return nil, err
+	}
+	return disposals, nil
+}
+
+// GetDisposalByID - Detail transaksi pelepasan aset
+func (s *AssetDisposalService) GetDisposalByID(id uint) (*models.AssetDisposal, error) {
+	var disposal models.AssetDisposal
+	if err := s.db.Preload("Asset").Preload("CashBank").First(&disposal, id).Error; err != nil {
+		return nil, err
+	}
+	return &disposal, nil
+}
+
+// DisposeAsset sells, disposes or writes off an asset. Depreciation is caught up to the disposal date,
+// cost and accumulated depreciation are removed and the difference with the proceeds is booked as gain or loss:
+//
+//	Dr Cash/Bank or Receivable   (proceeds)
+//	Dr Accumulated Depreciation  (accumulated depreciation + impairment)
+//	Dr Loss on Disposal / Cr Gain on Disposal
+//	    Cr Fixed Asset           (cost)
+func (s *AssetDisposalService) DisposeAsset(assetID uint, req models.AssetDisposalRequest, userID uint) (*models.AssetDisposal, error) {
+	date := dateOnly(req.DisposalDate)
+	if err := s.validateDate(date); err != nil {
+		return nil, err
+	}
+
+	req.PaymentMethod = strings.ToUpper(strings.TrimSpace(req.PaymentMethod))
+	switch {
+	case req.Type == models.AssetDisposalWriteOff && req.Proceeds > 0:
+		return nil, errors.New("a write-off has no proceeds, use SALE or DISPOSE instead")
+	case req.Type == models.AssetDisposalSale && req.Proceeds <= 0:
+		return nil, errors.New("proceeds must be greater than 0 for a sale")
+	case req.Proceeds > 0 && req.PaymentMethod == models.AssetProceedsCashBank && req.CashBankID == nil:
+		return nil, errors.New("cash_bank_id is required when proceeds are received in cash or bank")
+	case req.Proceeds > 0 && req.PaymentMethod != models.AssetProceedsCashBank && req.PaymentMethod != models.AssetProceedsReceivable:
+		return nil, errors.New("payment_method must be CASH_BANK or RECEIVABLE")
+	}
+
+	var disposalID uint
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		asset, err := s.lockAsset(tx, assetID, date)
+		if err != nil {
+			return err
+		}
+		if asset.AssetAccountID == nil {
+			return errors.New("asset is not linked to a fixed asset account")
+		}
+
+		disposal, lines, err := s.catchUp(tx, asset, req.Type, date)
+		if err != nil {
+			return err
+		}
+		disposal.PaymentMethod = req.PaymentMethod
+		disposal.BuyerName = req.BuyerName
+		disposal.Notes = req.Notes
+		disposal.CreatedBy = userID
+
+		proceeds := decimal.NewFromFloat(req.Proceeds).Round(2)
+		bookValue := decimal.NewFromFloat(disposal.BookValue)
+		gainLoss := proceeds.Sub(bookValue)
+		disposal.Proceeds = proceeds.InexactFloat64()
+		disposal.GainLoss = gainLoss.InexactFloat64()
+		if proceeds.IsZero() {
+			disposal.PaymentMethod = ""
+		}
+
+		var cashBank *models.CashBank
+		description := fmt.Sprintf("%s aset %s - %s", disposalTypeLabel(req.Type), asset.Code, asset.Name)
+		if proceeds.GreaterThan(decimal.Zero) {
+			proceedsAccountID := uint(0)
+			if disposal.PaymentMethod == models.AssetProceedsCashBank {
+				cashBank = &models.CashBank{}
+				if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(cashBank, *req.CashBankID).Error; err != nil {
+					return errors.New("cash bank account not found")
+				}
+				if !cashBank.IsActive {
+					return errors.New("cash bank account is inactive")
+				}
+				if cashBank.AccountID == 0 {
+					return errors.New("cash bank account is not linked to a GL account")
+				}
+				if isForeignCurrency(cashBank.Currency) {
+					return errors.New("asset proceeds must be received in a base currency cash or bank account")
+				}
+				disposal.CashBankID = &cashBank.ID
+				proceedsAccountID = cashBank.AccountID
+			} else {
+				receivable, err := s.resolveAccount(tx, req.ReceivableAccountID, AssetReceivableAccountCode)
+				if err != nil {
+					return err
+				}
+				disposal.ReceivableAccountID = &receivable.ID
+				proceedsAccountID = receivable.ID
+			}
+			lines = append(lines, JournalLineRequest{AccountID: uint64(proceedsAccountID), DebitAmount: proceeds, Description: description})
+		}
+
+		removed := decimal.NewFromFloat(disposal.AccumulatedDepreciation).Add(decimal.NewFromFloat(disposal.ImpairmentLoss)).Round(2)
+		if removed.GreaterThan(decimal.Zero) {
+			lines = append(lines, JournalLineRequest{AccountID: uint64(disposal.accumulatedAccountID), DebitAmount: removed, Description: description})
+		}
+		if gainLoss.LessThan(decimal.Zero) {
+			loss, err := s.resolveAccount(tx, nil, AssetDisposalLossAccountCode)
+			if err != nil {
+				return err
+			}
+			lines = append(lines, JournalLineRequest{AccountID: uint64(loss.ID), DebitAmount: gainLoss.Abs(), Description: description})
+		}
+		lines = append(lines, JournalLineRequest{AccountID: uint64(*asset.AssetAccountID), CreditAmount: decimal.NewFromFloat(asset.PurchasePrice).Round(2), Description: description})
+		if gainLoss.GreaterThan(decimal.Zero) {
+			gain, err := s.resolveAccount(tx, nil, AssetDisposalGainAccountCode)
+			if err != nil {
+				return err
+			}
+			lines = append(lines, JournalLineRequest{AccountID: uint64(gain.ID), CreditAmount: gainLoss, Description: description})
+		}
+
+		if err := s.post(tx, disposal, lines, description, userID); err != nil {
+			return err
+		}
+
+		if cashBank != nil {
+			cashBank.Balance = roundAmount(cashBank.Balance + disposal.Proceeds)
+			if err := tx.Model(cashBank).Update("balance", cashBank.Balance).Error; err != nil {
+				return fmt.Errorf("failed to update cash bank balance: %v", err)
+			}
+			if err := tx.Create(&models.CashBankTransaction{
+				CashBankID:      cashBank.ID,
+				ReferenceType:   CashBankRefAssetDisposal,
+				ReferenceID:     disposal.ID,
+				Amount:          disposal.Proceeds,
+				BalanceAfter:    cashBank.Balance,
+				TransactionDate: date,
+				Notes:           description,
+			}).Error; err != nil {
+				return fmt.Errorf("failed to create cash bank transaction: %v", err)
+			}
+		}
+
+		status := models.AssetStatusDisposed
+		switch req.Type {
+		case models.AssetDisposalSale:
+			status = models.AssetStatusSold
+		case models.AssetDisposalWriteOff:
+			status = models.AssetStatusWrittenOff
+		}
+		finalBookValue := disposal.BookValue
+		if err := tx.Model(asset).Updates(map[string]interface{}{
+			"status":                   status,
+			"is_active":                false,
+			"accumulated_depreciation": disposal.AccumulatedDepreciation,
+			"disposal_date":            date,
+			"disposal_value":           disposal.Proceeds,
+			"final_book_value":         finalBookValue,
+		}).Error; err != nil {
+			return fmt.Errorf("failed to update asset: %v", err)
+		}
+
+		disposalID = disposal.ID
+		log.Printf("🏭 Asset %s %s on %s: book value %.2f, proceeds %.2f, gain/loss %.2f",
+			asset.Code, strings.ToLower(req.Type), date.Format("2006-01-02"), disposal.BookValue, disposal.Proceeds, disposal.GainLoss)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetDisposalByID(disposalID)
+}
+
+// ImpairAsset writes the carrying amount down to the recoverable amount after catching up depreciation:
+//
+//	Dr Impairment Loss
+//	    Cr Accumulated Depreciation
+func (s *AssetDisposalService) ImpairAsset(assetID uint, req models.AssetImpairmentRequest, userID uint) (*models.AssetDisposal, error) {
+	date := dateOnly(req.ImpairmentDate)
+	if err := s.validateDate(date); err != nil {
+		return nil, err
+	}
+
+	var disposalID uint
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		asset, err := s.lockAsset(tx, assetID, date)
+		if err != nil {
+			return err
+		}
+
+		disposal, lines, err := s.catchUp(tx, asset, models.AssetDisposalImpairment, date)
+		if err != nil {
+			return err
+		}
+
+		recoverable := decimal.NewFromFloat(req.RecoverableAmount).Round(2)
+		impairment := decimal.NewFromFloat(disposal.BookValue).Sub(recoverable)
+		if impairment.LessThanOrEqual(decimal.Zero) {
+			return fmt.Errorf("recoverable amount must be below the carrying amount of %.2f", disposal.BookValue)
+		}
+		disposal.RecoverableAmount = recoverable.InexactFloat64()
+		disposal.ImpairmentAmount = impairment.InexactFloat64()
+		disposal.GainLoss = impairment.Neg().InexactFloat64()
+		disposal.Notes = req.Notes
+		disposal.CreatedBy = userID
+
+		lossAccount, err := s.resolveAccount(tx, nil, AssetImpairmentAccountCode)
+		if err != nil {
+			return err
+		}
+		description := fmt.Sprintf("Penurunan nilai aset %s - %s", asset.Code, asset.Name)
+		lines = append(lines,
+			JournalLineRequest{AccountID: uint64(lossAccount.ID), DebitAmount: impairment, Description: description},
+			JournalLineRequest{AccountID: uint64(disposal.accumulatedAccountID), CreditAmount: impairment, Description: description},
+		)
+
+		if err := s.post(tx, disposal, lines, description, userID); err != nil {
+			return err
+		}
+
+		if err := tx.Model(asset).Updates(map[string]interface{}{
+			"accumulated_depreciation":  disposal.AccumulatedDepreciation,
+			"impairment_loss":           roundAmount(asset.ImpairmentLoss + disposal.ImpairmentAmount),
+			"impaired_at":               date,
+			"accumulated_at_impairment": disposal.AccumulatedDepreciation,
+		}).Error; err != nil {
+			return fmt.Errorf("failed to update asset: %v", err)
+		}
+
+		disposalID = disposal.ID
+		log.Printf("🏭 Asset %s impaired by %.2f on %s", asset.Code, disposal.ImpairmentAmount, date.Format("2006-01-02"))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetDisposalByID(disposalID)
+}
+
+// pendingDisposal carries the accumulated depreciation account resolved during the catch-up and the
+// ledger entry of the catch-up (nil when there is nothing to catch up)
+type pendingDisposal struct {
+	models.AssetDisposal
+	accumulatedAccountID uint
+	catchUpEntry         *models.AssetDepreciationEntry
+}
+
+// lockAsset loads an asset that can still be disposed or impaired on the given date
+func (s *AssetDisposalService) lockAsset(tx *gorm.DB, assetID uint, date time.Time) (*models.Asset, error) {
+	var asset models.Asset
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("AssetCategory").First(&asset, assetID).Error; err != nil {
+		return nil, errors.New("asset not found")
+	}
+	if asset.Status != models.AssetStatusActive && asset.Status != models.AssetStatusInactive {
+		return nil, fmt.Errorf("asset is already %s", strings.ToLower(asset.Status))
+	}
+	if date.Before(dateOnly(asset.PurchaseDate)) {
+		return nil, errors.New("date cannot be before the purchase date of the asset")
+	}
+
+	// Depreciation already posted past the date would have to be taken back first
+	var later models.AssetDepreciationEntry
+	err := tx.Where("asset_id = ? AND status = ? AND period_end > ?", asset.ID, models.DepreciationRunPosted, date).
+		Order("period_end DESC").First(&later).Error
+	if err == nil {
+		return nil, fmt.Errorf("asset is depreciated up to %s, roll back the later depreciation runs or use a later date", later.PeriodEnd.Format("2006-01-02"))
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to check depreciation ledger: %v", err)
+	}
+	return &asset, nil
+}
+
+// catchUp books the depreciation from the last posted month up to the transaction date and returns the
+// asset's amounts at that date together with the catch-up journal lines
+func (s *AssetDisposalService) catchUp(tx *gorm.DB, asset *models.Asset, disposalType string, date time.Time) (*pendingDisposal, []JournalLineRequest, error) {
+	expenseID, accumulatedID, err := depreciationAccountsFor(tx, asset, make(map[string]uint))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	catchUp := 0.0
+	if asset.Status == models.AssetStatusActive {
+		if catchUp, err = s.depreciationService.monthlyAmount(asset, date); err != nil {
+			return nil, nil, err
+		}
+		if catchUp < 0 {
+			catchUp = 0
+		}
+	}
+
+	code, err := s.generateDisposalCode(tx, date)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	accumulated := roundAmount(asset.AccumulatedDepreciation + catchUp)
+	disposal := &pendingDisposal{
+		AssetDisposal: models.AssetDisposal{
+			Code:                    code,
+			AssetID:                 asset.ID,
+			Type:                    disposalType,
+			DisposalDate:            date,
+			Cost:                    asset.PurchasePrice,
+			CatchUpDepreciation:     catchUp,
+			AccumulatedDepreciation: accumulated,
+			ImpairmentLoss:          asset.ImpairmentLoss,
+			BookValue:               roundAmount(asset.PurchasePrice - accumulated - asset.ImpairmentLoss),
+		},
+		accumulatedAccountID: accumulatedID,
+	}
+
+	var lines []JournalLineRequest
+	if catchUp > 0 {
+		disposal.catchUpEntry = &models.AssetDepreciationEntry{
+			AssetID:              asset.ID,
+			CategoryID:           asset.CategoryID,
+			Period:               date.Format("2006-01"),
+			PeriodEnd:            date,
+			Amount:               catchUp,
+			AccumulatedBefore:    asset.AccumulatedDepreciation,
+			AccumulatedAfter:     accumulated,
+			BookValueAfter:       disposal.BookValue,
+			ExpenseAccountID:     expenseID,
+			AccumulatedAccountID: accumulatedID,
+			Status:               models.DepreciationRunPosted,
+		}
+
+		description := fmt.Sprintf("Penyusutan aset %s s/d %s", asset.Code, date.Format("02/01/2006"))
+		amount := decimal.NewFromFloat(catchUp).Round(2)
+		lines = append(lines,
+			JournalLineRequest{AccountID: uint64(expenseID), DebitAmount: amount, Description: description},
+			JournalLineRequest{AccountID: uint64(accumulatedID), CreditAmount: amount, Description: description},
+		)
+	}
+	return disposal, lines, nil
+}
+
+// post saves the disposal record, the catch-up in the depreciation ledger and the SSOT journal
+func (s *AssetDisposalService) post(tx *gorm.DB, pending *pendingDisposal, lines []JournalLineRequest, description string, userID uint) error {
+	disposal := &pending.AssetDisposal
+	if err := tx.Omit(clause.Associations).Create(disposal).Error; err != nil {
+		return fmt.Errorf("failed to create asset disposal: %v", err)
+	}
+	if err := s.recordCatchUp(tx, pending); err != nil {
+		return err
+	}
+
+	journal, err := s.journalService.CreateJournalEntryWithTx(tx, &JournalEntryRequest{
+		SourceType:  models.SSOTSourceTypeAsset,
+		SourceID:    uint64(disposal.ID),
+		Reference:   disposal.Code,
+		EntryDate:   disposal.DisposalDate,
+		Description: description,
+		Lines:       lines,
+		AutoPost:    true,
+		CreatedBy:   uint64(userID),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to post asset disposal journal: %v", err)
+	}
+	disposal.JournalEntryID = &journal.ID
+	return tx.Model(disposal).Update("journal_entry_id", journal.ID).Error
+}
+
+// recordCatchUp adds the catch-up depreciation to the asset's depreciation ledger. A catch-up in a month
+// that already has one (an earlier impairment in the same month) extends that entry, since the ledger
+// holds one posted entry per asset and month.
+func (s *AssetDisposalService) recordCatchUp(tx *gorm.DB, pending *pendingDisposal) error {
+	entry := pending.catchUpEntry
+	if entry == nil {
+		return nil
+	}
+
+	var existing models.AssetDepreciationEntry
+	err := tx.Where("asset_id = ? AND period = ? AND status = ?", entry.AssetID, entry.Period, models.DepreciationRunPosted).
+		First(&existing).Error
+	switch {
+	case err == nil:
+		if err := tx.Model(&existing).Updates(map[string]interface{}{
+			"period_end":        entry.PeriodEnd,
+			"amount":            roundAmount(existing.Amount + entry.Amount),
+			"accumulated_after": entry.AccumulatedAfter,
+			"book_value_after":  entry.BookValueAfter,
+		}).Error; err != nil {
+			return fmt.Errorf("failed to record catch-up depreciation: %v", err)
+		}
+		return nil
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return fmt.Errorf("failed to check depreciation ledger: %v", err)
+	}
+
+	entry.DisposalID = &pending.ID
+	if err := tx.Omit(clause.Associations).Create(entry).Error; err != nil {
+		return fmt.Errorf("failed to record catch-up depreciation: %v", err)
+	}
+	return nil
+}
+
+func (s *AssetDisposalService) validateDate(date time.Time) error {
+	if date.After(time.Now()) {
+		return errors.New("date cannot be in the future")
+	}
+	closed, err := s.periodService.IsDateInClosedPeriod(context.Background(), date)
+	if err != nil {
+		return fmt.Errorf("failed to check accounting period: %v", err)
+	}
+	if closed {
+		return fmt.Errorf("accounting period of %s is closed", date.Format("2006-01-02"))
+	}
+	return nil
+}
+
+// resolveAccount returns the selected account or, when none is selected, the account with the default code
+func (s *AssetDisposalService) resolveAccount(tx *gorm.DB, id *uint, defaultCode string) (*models.Account, error) {
+	var account models.Account
+	if id != nil {
+		if err := tx.First(&account, *id).Error; err != nil {
+			return nil, fmt.Errorf("account %d not found", *id)
+		}
+	} else if err := tx.Where("code = ?", defaultCode).First(&account).Error; err != nil {
+		return nil, fmt.Errorf("account code %s not found: %v", defaultCode, err)
+	}
+	if account.IsHeader {
+		return nil, fmt.Errorf("account %s is a header account", account.Code)
+	}
+	return &account, nil
+}
+
+func (s *AssetDisposalService) generateDisposalCode(tx *gorm.DB, date time.Time) (string, error) {
+	var count int64
+	prefix := fmt.Sprintf("ADS-%s-", date.Format("200601"))
+	if err := tx.Unscoped().Model(&models.AssetDisposal{}).
+		Where("code LIKE ?", prefix+"%").
+		Count(&count).Error; err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s%04d", prefix, count+1), nil
+}
+
+func disposalTypeLabel(disposalType string) string {
+	switch disposalType {
+	case models.AssetDisposalSale:
+		return "Penjualan"
+	case models.AssetDisposalWriteOff:
+		return "Penghapusan"
+	default:
+		return "Pelepasan"
+	}
+}
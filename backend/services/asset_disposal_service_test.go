@@ -0,0 +1,240 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"app-sistem-akuntansi/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func setupAssetDisposalTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1) // Every connection to :memory: is a separate database
+	require.NoError(t, db.AutoMigrate(&models.AssetCategory{}, &models.Asset{}, &models.AssetDepreciationEntry{}))
+	return db
+}
+
+func TestLockAssetRejectsDatesBeforePostedDepreciation(t *testing.T) {
+	db := setupAssetDisposalTestDB(t)
+	service := &AssetDisposalService{db: db}
+
+	asset := models.Asset{Code: "AST-0001", Name: "Forklift", PurchasePrice: 12000000, UsefulLife: 1,
+		PurchaseDate: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), Status: models.AssetStatusActive, IsActive: true}
+	require.NoError(t, db.Create(&asset).Error)
+	runID := uint(1)
+	require.NoError(t, db.Create(&models.AssetDepreciationEntry{
+		RunID: &runID, AssetID: asset.ID, Period: "2024-03", PeriodEnd: time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC),
+		Amount: 1000000, Status: models.DepreciationRunPosted,
+	}).Error)
+	require.NoError(t, db.Create(&models.AssetDepreciationEntry{
+		RunID: &runID, AssetID: asset.ID, Period: "2024-04", PeriodEnd: time.Date(2024, 4, 30, 0, 0, 0, 0, time.UTC),
+		Amount: 1000000, Status: models.DepreciationRunRolledBack,
+	}).Error)
+
+	tests := []struct {
+		name    string
+		date    time.Time
+		wantErr string
+	}{
+		{name: "inside a posted month", date: time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC), wantErr: "depreciated up to 2024-03-31"},
+		{name: "on the last posted period end", date: time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC)},
+		{name: "in a rolled back month", date: time.Date(2024, 4, 15, 0, 0, 0, 0, time.UTC)},
+		{name: "before the purchase date", date: time.Date(2023, 12, 31, 0, 0, 0, 0, time.UTC), wantErr: "before the purchase date"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			locked, err := service.lockAsset(db, asset.ID, tt.date)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, asset.ID, locked.ID)
+		})
+	}
+}
+
+func TestRecordCatchUpInDepreciationLedger(t *testing.T) {
+	db := setupAssetDisposalTestDB(t)
+	service := &AssetDisposalService{db: db}
+	catchUp := func(disposalID uint, date time.Time, amount, before float64) *pendingDisposal {
+		return &pendingDisposal{
+			AssetDisposal: models.AssetDisposal{ID: disposalID},
+			catchUpEntry: &models.AssetDepreciationEntry{
+				AssetID: 1, Period: date.Format("2006-01"), PeriodEnd: date, Amount: amount,
+				AccumulatedBefore: before, AccumulatedAfter: before + amount, BookValueAfter: 12000000 - before - amount,
+				Status: models.DepreciationRunPosted,
+			},
+		}
+	}
+
+	require.NoError(t, service.recordCatchUp(db, &pendingDisposal{}), "nothing to record without a catch-up")
+	require.NoError(t, service.recordCatchUp(db, catchUp(5, time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC), 2000000, 1000000)))
+	require.NoError(t, service.recordCatchUp(db, catchUp(6, time.Date(2024, 3, 25, 0, 0, 0, 0, time.UTC), 500000, 3000000)))
+
+	var entries []models.AssetDepreciationEntry
+	require.NoError(t, db.Find(&entries).Error)
+	require.Len(t, entries, 1, "one posted entry per asset and month")
+	entry := entries[0]
+	assert.Nil(t, entry.RunID)
+	require.NotNil(t, entry.DisposalID)
+	assert.Equal(t, uint(5), *entry.DisposalID)
+	assert.Equal(t, 2500000.0, entry.Amount)
+	assert.Equal(t, 1000000.0, entry.AccumulatedBefore)
+	assert.Equal(t, 3500000.0, entry.AccumulatedAfter)
+	assert.Equal(t, 8500000.0, entry.BookValueAfter)
+	assert.Equal(t, "2024-03-25", entry.PeriodEnd.Format("2006-01-02"))
+}
+
+// setupAssetPostingTest creates an asset bought on 1 Jan 2024 for 12,000,000 over one year, depreciated
+// through March, and a base currency cash account for the proceeds
+func setupAssetPostingTest(t *testing.T) (*AssetDisposalService, *gorm.DB, *models.Asset, *models.CashBank) {
+	db := setupJournalTestDB(t, &models.AssetCategory{}, &models.Asset{}, &models.AssetDepreciationEntry{}, &models.AssetDisposal{},
+		&models.AccountingPeriod{}, &models.CashBank{}, &models.CashBankTransaction{})
+	accounts := seedTestAccounts(t, db, map[string]string{
+		"1101":                             models.AccountTypeAsset,
+		"1501":                             models.AccountTypeAsset,
+		AccumulatedDepreciationAccountCode: models.AccountTypeAsset,
+		AssetReceivableAccountCode:         models.AccountTypeAsset,
+		DepreciationExpenseAccountCode:     models.AccountTypeExpense,
+		AssetDisposalGainAccountCode:       models.AccountTypeRevenue,
+		AssetDisposalLossAccountCode:       models.AccountTypeExpense,
+		AssetImpairmentAccountCode:         models.AccountTypeExpense,
+	})
+
+	assetAccountID := accounts["1501"].ID
+	asset := &models.Asset{Code: "AST-0001", Name: "Forklift", PurchasePrice: 12000000, UsefulLife: 1,
+		PurchaseDate: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), AccumulatedDepreciation: 3000000,
+		AssetAccountID: &assetAccountID, Status: models.AssetStatusActive, IsActive: true}
+	require.NoError(t, db.Create(asset).Error)
+	cashBank := &models.CashBank{Code: "BNK-001", Name: "Bank BCA", Type: "BANK", AccountID: accounts["1101"].ID, Currency: "IDR", IsActive: true}
+	require.NoError(t, db.Create(cashBank).Error)
+
+	return NewAssetDisposalService(db), db, asset, cashBank
+}
+
+func TestDisposeAssetGainLoss(t *testing.T) {
+	disposedOn := time.Date(2024, 6, 30, 0, 0, 0, 0, time.UTC)
+	// Catch-up: April-June depreciation of 3,000,000 brings the book value to 6,000,000
+	catchUp := map[string]float64{DepreciationExpenseAccountCode: 3000000}
+
+	tests := []struct {
+		name         string
+		req          models.AssetDisposalRequest
+		cashProceeds bool
+		wantStatus   string
+		wantGainLoss float64
+		wantLines    map[string]float64
+	}{
+		{
+			name:         "sale above book value is a gain",
+			req:          models.AssetDisposalRequest{Type: models.AssetDisposalSale, Proceeds: 7000000, PaymentMethod: "cash_bank"},
+			cashProceeds: true,
+			wantStatus:   models.AssetStatusSold,
+			wantGainLoss: 1000000,
+			wantLines:    map[string]float64{"1101": 7000000, AccumulatedDepreciationAccountCode: 3000000, "1501": -12000000, AssetDisposalGainAccountCode: -1000000},
+		},
+		{
+			name:         "sale on credit below book value is a loss",
+			req:          models.AssetDisposalRequest{Type: models.AssetDisposalSale, Proceeds: 4500000, PaymentMethod: models.AssetProceedsReceivable},
+			wantStatus:   models.AssetStatusSold,
+			wantGainLoss: -1500000,
+			wantLines:    map[string]float64{AssetReceivableAccountCode: 4500000, AccumulatedDepreciationAccountCode: 3000000, AssetDisposalLossAccountCode: 1500000, "1501": -12000000},
+		},
+		{
+			name:       "sale at book value has no gain or loss",
+			req:        models.AssetDisposalRequest{Type: models.AssetDisposalDispose, Proceeds: 6000000, PaymentMethod: models.AssetProceedsReceivable},
+			wantStatus: models.AssetStatusDisposed,
+			wantLines:  map[string]float64{AssetReceivableAccountCode: 6000000, AccumulatedDepreciationAccountCode: 3000000, "1501": -12000000},
+		},
+		{
+			name:         "write-off loses the whole book value",
+			req:          models.AssetDisposalRequest{Type: models.AssetDisposalWriteOff},
+			wantStatus:   models.AssetStatusWrittenOff,
+			wantGainLoss: -6000000,
+			wantLines:    map[string]float64{AccumulatedDepreciationAccountCode: 3000000, AssetDisposalLossAccountCode: 6000000, "1501": -12000000},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, db, asset, cashBank := setupAssetPostingTest(t)
+			tt.req.DisposalDate = disposedOn
+			if tt.cashProceeds {
+				tt.req.CashBankID = &cashBank.ID
+			}
+
+			disposal, err := service.DisposeAsset(asset.ID, tt.req, 1)
+			require.NoError(t, err)
+			assert.InDelta(t, 3000000, disposal.CatchUpDepreciation, 0.001)
+			assert.InDelta(t, 6000000, disposal.BookValue, 0.001)
+			assert.InDelta(t, tt.wantGainLoss, disposal.GainLoss, 0.001)
+
+			entries := assertJournalsBalanced(t, db)
+			require.Len(t, entries, 1)
+			want := map[string]float64{}
+			for code, amount := range catchUp {
+				want[code] = amount
+			}
+			for code, amount := range tt.wantLines {
+				want[code] += amount
+			}
+			assert.Equal(t, want, journalAmountsByAccount(t, db, entries[0]))
+
+			require.NoError(t, db.First(asset, asset.ID).Error)
+			assert.Equal(t, tt.wantStatus, asset.Status)
+			assert.InDelta(t, 6000000, asset.AccumulatedDepreciation, 0.001)
+			require.NoError(t, db.First(cashBank, cashBank.ID).Error)
+			if tt.cashProceeds {
+				assert.InDelta(t, tt.req.Proceeds, cashBank.Balance, 0.001)
+			} else {
+				assert.Zero(t, cashBank.Balance)
+			}
+		})
+	}
+}
+
+func TestImpairAssetBalances(t *testing.T) {
+	service, db, asset, _ := setupAssetPostingTest(t)
+	impairedOn := time.Date(2024, 6, 30, 0, 0, 0, 0, time.UTC)
+
+	_, err := service.ImpairAsset(asset.ID, models.AssetImpairmentRequest{ImpairmentDate: impairedOn, RecoverableAmount: 6500000}, 1)
+	assert.ErrorContains(t, err, "below the carrying amount of 6000000.00")
+
+	impairment, err := service.ImpairAsset(asset.ID, models.AssetImpairmentRequest{ImpairmentDate: impairedOn, RecoverableAmount: 4000000}, 1)
+	require.NoError(t, err)
+	assert.InDelta(t, 2000000, impairment.ImpairmentAmount, 0.001)
+	assert.InDelta(t, -2000000, impairment.GainLoss, 0.001)
+
+	entries := assertJournalsBalanced(t, db)
+	require.Len(t, entries, 1)
+	assert.Equal(t, map[string]float64{
+		DepreciationExpenseAccountCode:     3000000,
+		AssetImpairmentAccountCode:         2000000,
+		AccumulatedDepreciationAccountCode: -5000000,
+	}, journalAmountsByAccount(t, db, entries[0]))
+
+	// The later write-off removes the impairment together with the accumulated depreciation
+	disposal, err := service.DisposeAsset(asset.ID, models.AssetDisposalRequest{Type: models.AssetDisposalWriteOff, DisposalDate: impairedOn}, 1)
+	require.NoError(t, err)
+	assert.InDelta(t, 4000000, disposal.BookValue, 0.001)
+	assert.InDelta(t, -4000000, disposal.GainLoss, 0.001)
+	entries = assertJournalsBalanced(t, db)
+	require.Len(t, entries, 2)
+	assert.Equal(t, map[string]float64{
+		AccumulatedDepreciationAccountCode: 8000000,
+		AssetDisposalLossAccountCode:       4000000,
+		"1501":                             -12000000,
+	}, journalAmountsByAccount(t, db, entries[1]))
+}
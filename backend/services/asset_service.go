@@ -56,6 +56,8 @@ type AssetDepreciationReport struct {
 	RemainingDepreciation   float64      `json:"remaining_depreciation"`
 	RemainingYears          int          `json:"remaining_years"`
 	CurrentBookValue        float64      `json:"current_book_value"`
+	IsDisposed              bool         `json:"is_disposed"`
+	DisposalDate            *time.Time   `json:"disposal_date,omitempty"`
 }
 
 func NewAssetService(assetRepo repositories.AssetRepositoryInterface, db *gorm.DB) AssetServiceInterface {
@@ -383,7 +385,7 @@ func (s *AssetService) GetAssetsSummary() (*AssetsSummary, error) {
 	var totalDepreciation, netBookValue float64
 	for _, asset := range activeAssets {
 		totalDepreciation += asset.AccumulatedDepreciation
-		netBookValue += (asset.PurchasePrice - asset.AccumulatedDepreciation)
+		netBookValue += (asset.PurchasePrice - asset.AccumulatedDepreciation - asset.ImpairmentLoss)
 	}
 
 	return &AssetsSummary{
@@ -417,9 +419,18 @@ func (s *AssetService) GetAssetsForDepreciationReport() ([]AssetDepreciationRepo
 		}
 		
 		monthlyDepreciation := annualDepreciation / 12
-		currentBookValue := asset.PurchasePrice - asset.AccumulatedDepreciation
+		currentBookValue := asset.PurchasePrice - asset.AccumulatedDepreciation - asset.ImpairmentLoss
 		remainingDepreciation := math.Max(0, currentBookValue - asset.SalvageValue)
 		
+		// Disposed assets no longer depreciate; show the book value at disposal
+		isDisposed := asset.Status != models.AssetStatusActive
+		if isDisposed {
+			annualDepreciation, monthlyDepreciation, remainingDepreciation = 0, 0, 0
+			if asset.FinalBookValue != nil {
+				currentBookValue = *asset.FinalBookValue
+			}
+		}
+		
 		var remainingYears int
 		if annualDepreciation > 0 {
 			remainingYears = int(math.Ceil(remainingDepreciation / annualDepreciation))
@@ -432,6 +443,8 @@ func (s *AssetService) GetAssetsForDepreciationReport() ([]AssetDepreciationRepo
 			RemainingDepreciation: remainingDepreciation,
 			RemainingYears:        remainingYears,
 			CurrentBookValue:      currentBookValue,
+			IsDisposed:            isDisposed,
+			DisposalDate:          asset.DisposalDate,
 		}
 		
 		reports = append(reports, report)
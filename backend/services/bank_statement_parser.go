@@ -0,0 +1,473 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"app-sistem-akuntansi/models"
+)
+
+// ParsedStatement is the format-independent result of parsing a bank statement file
+type ParsedStatement struct {
+	AccountRef     string
+	StatementRef   string
+	Currency       string
+	PeriodStart    time.Time
+	PeriodEnd      time.Time
+	OpeningBalance *float64
+	ClosingBalance *float64
+	Lines          []ParsedStatementLine
+	Warnings       []string
+}
+
+// ParsedStatementLine - Amount is signed: positive = money into the account
+type ParsedStatementLine struct {
+	Date        time.Time
+	Description string
+	Reference   string
+	Amount      float64
+	Balance     *float64
+}
+
+// DefaultStatementMappings are the CSV layouts of the internet banking exports we support out of the box.
+// Custom layouts can be stored as BankStatementColumnMapping rows and referenced by code.
+var DefaultStatementMappings = map[string]models.BankStatementColumnMapping{
+	// KlikBCA: Tanggal, Keterangan, Cabang, Jumlah, DB/CR, Saldo
+	"BCA": {
+		Code: "BCA", Name: "BCA (KlikBCA CSV)", Delimiter: ",", SkipRows: 1,
+		DateColumn: 0, DateFormat: "02/01/2006", DescriptionColumn: 1, ReferenceColumn: -1,
+		AmountColumn: 3, DebitColumn: -1, CreditColumn: -1, DrCrColumn: 4, BalanceColumn: 5,
+		DecimalSeparator: ".", IsActive: true,
+	},
+	// Mandiri Online: Account No, Date, Val. Date, Transaction Code, Description, Reference No., Debit, Credit, Balance
+	"MANDIRI": {
+		Code: "MANDIRI", Name: "Mandiri (Mandiri Online CSV)", Delimiter: ",", SkipRows: 1,
+		DateColumn: 1, DateFormat: "02/01/2006", DescriptionColumn: 4, ReferenceColumn: 5,
+		AmountColumn: -1, DebitColumn: 6, CreditColumn: 7, DrCrColumn: -1, BalanceColumn: 8,
+		DecimalSeparator: ".", IsActive: true,
+	},
+	// BRI (CMS/IBBIZ): Tanggal, Uraian Transaksi, Teller, Debet, Kredit, Saldo
+	"BRI": {
+		Code: "BRI", Name: "BRI (IBBIZ CSV)", Delimiter: ";", SkipRows: 1,
+		DateColumn: 0, DateFormat: "02/01/06", DescriptionColumn: 1, ReferenceColumn: -1,
+		AmountColumn: -1, DebitColumn: 3, CreditColumn: 4, DrCrColumn: -1, BalanceColumn: 5,
+		DecimalSeparator: ",", IsActive: true,
+	},
+}
+
+// ParseBankStatement dispatches to the parser for the given format
+func ParseBankStatement(format string, r io.Reader, mapping *models.BankStatementColumnMapping) (*ParsedStatement, error) {
+	switch strings.ToUpper(strings.TrimSpace(format)) {
+	case models.BankStatementFormatCSV:
+		if mapping == nil {
+			return nil, errors.New("column mapping is required for CSV statements")
+		}
+		return parseCSVStatement(r, *mapping)
+	case models.BankStatementFormatMT940:
+		return parseMT940Statement(r)
+	case models.BankStatementFormatOFX:
+		return parseOFXStatement(r)
+	default:
+		return nil, fmt.Errorf("unsupported statement format '%s' (use CSV, MT940 or OFX)", format)
+	}
+}
+
+// ========== CSV ==========
+
+func parseCSVStatement(r io.Reader, mapping models.BankStatementColumnMapping) (*ParsedStatement, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	reader.LazyQuotes = true
+	if mapping.Delimiter != "" {
+		reader.Comma = rune(mapping.Delimiter[0])
+	}
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV file: %v", err)
+	}
+	if mapping.AmountColumn < 0 && mapping.DebitColumn < 0 && mapping.CreditColumn < 0 {
+		return nil, errors.New("column mapping must define an amount column or debit/credit columns")
+	}
+
+	parsed := &ParsedStatement{Warnings: []string{}}
+	for i, record := range records {
+		row := i + 1
+		if i < mapping.SkipRows || isBlankRecord(record) {
+			continue
+		}
+
+		date, err := time.Parse(mapping.DateFormat, csvColumn(record, mapping.DateColumn))
+		if err != nil {
+			// Bank exports often end with summary rows (saldo awal/akhir); skip anything without a valid date
+			parsed.Warnings = append(parsed.Warnings, fmt.Sprintf("row %d: skipped, invalid date '%s'", row, csvColumn(record, mapping.DateColumn)))
+			continue
+		}
+
+		amount, err := csvStatementAmount(record, mapping)
+		if err != nil {
+			parsed.Warnings = append(parsed.Warnings, fmt.Sprintf("row %d: skipped, %v", row, err))
+			continue
+		}
+
+		line := ParsedStatementLine{
+			Date:        date,
+			Description: csvColumn(record, mapping.DescriptionColumn),
+			Reference:   csvColumn(record, mapping.ReferenceColumn),
+			Amount:      amount,
+		}
+		if raw := csvColumn(record, mapping.BalanceColumn); raw != "" {
+			if balance, err := parseStatementAmount(raw, mapping.DecimalSeparator); err == nil {
+				line.Balance = &balance
+			}
+		}
+		parsed.Lines = append(parsed.Lines, line)
+	}
+
+	finalizeParsedStatement(parsed)
+	return parsed, nil
+}
+
+func csvStatementAmount(record []string, mapping models.BankStatementColumnMapping) (float64, error) {
+	if mapping.AmountColumn >= 0 {
+		raw := csvColumn(record, mapping.AmountColumn)
+		amount, err := parseStatementAmount(raw, mapping.DecimalSeparator)
+		if err != nil {
+			return 0, fmt.Errorf("invalid amount '%s'", raw)
+		}
+		if mapping.DrCrColumn >= 0 {
+			switch strings.ToUpper(csvColumn(record, mapping.DrCrColumn)) {
+			case "DB", "D", "DR", "DEBIT":
+				amount = -math.Abs(amount)
+			case "CR", "C", "K", "KREDIT", "CREDIT":
+				amount = math.Abs(amount)
+			}
+		}
+		return amount, nil
+	}
+
+	var debit, credit float64
+	var err error
+	if raw := csvColumn(record, mapping.DebitColumn); raw != "" {
+		if debit, err = parseStatementAmount(raw, mapping.DecimalSeparator); err != nil {
+			return 0, fmt.Errorf("invalid debit '%s'", raw)
+		}
+	}
+	if raw := csvColumn(record, mapping.CreditColumn); raw != "" {
+		if credit, err = parseStatementAmount(raw, mapping.DecimalSeparator); err != nil {
+			return 0, fmt.Errorf("invalid credit '%s'", raw)
+		}
+	}
+	amount := math.Abs(credit) - math.Abs(debit)
+	if amount == 0 {
+		return 0, errors.New("zero amount")
+	}
+	return amount, nil
+}
+
+// parseStatementAmount accepts bank formatted numbers such as "1,250,000.00", "1.250.000,00",
+// "(15.000)", "-15000" and BCA style suffixes "1,000.00 CR" / "6,500.00 DB".
+func parseStatementAmount(raw, decimalSeparator string) (float64, error) {
+	s := strings.ToUpper(strings.TrimSpace(raw))
+	if s == "" {
+		return 0, errors.New("empty amount")
+	}
+
+	negative := false
+	switch {
+	case strings.HasSuffix(s, "DB"), strings.HasSuffix(s, "DR"):
+		negative = true
+		s = strings.TrimSpace(s[:len(s)-2])
+	case strings.HasSuffix(s, "CR"):
+		s = strings.TrimSpace(s[:len(s)-2])
+	}
+	if strings.HasPrefix(s, "(") && strings.HasSuffix(s, ")") {
+		negative = true
+		s = strings.Trim(s, "()")
+	}
+	s = strings.TrimPrefix(s, "RP")
+	s = strings.TrimPrefix(s, "IDR")
+	s = strings.ReplaceAll(s, " ", "")
+	if strings.HasPrefix(s, "-") {
+		negative = !negative
+		s = s[1:]
+	}
+
+	if decimalSeparator == "," {
+		s = strings.ReplaceAll(s, ".", "")
+		s = strings.ReplaceAll(s, ",", ".")
+	} else {
+		s = strings.ReplaceAll(s, ",", "")
+	}
+
+	value, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, err
+	}
+	if negative {
+		value = -value
+	}
+	return value, nil
+}
+
+func csvColumn(record []string, index int) string {
+	if index < 0 || index >= len(record) {
+		return ""
+	}
+	return strings.TrimSpace(record[index])
+}
+
+func isBlankRecord(record []string) bool {
+	for _, field := range record {
+		if strings.TrimSpace(field) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// ========== MT940 ==========
+
+var (
+	mt940TagRe       = regexp.MustCompile(`^:\d{2}[A-Z]?:`)
+	mt940BalanceRe   = regexp.MustCompile(`^([CD])(\d{6})([A-Z]{3})([\d,]+)$`)
+	mt940StatementRe = regexp.MustCompile(`^(\d{6})(\d{4})?(R?[CD])([A-Z])?([\d,]+)([NFS][A-Z0-9]{3})([^/]*)(?://(.*))?$`)
+)
+
+func parseMT940Statement(r io.Reader) (*ParsedStatement, error) {
+	// Join continuation lines onto their tag so every field is a single string
+	var fields []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		text := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(text) == "" || text == "-" || strings.HasPrefix(text, "{") {
+			continue
+		}
+		if mt940TagRe.MatchString(text) {
+			fields = append(fields, text)
+			continue
+		}
+		if len(fields) > 0 {
+			fields[len(fields)-1] += " " + strings.TrimSpace(text)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read MT940 file: %v", err)
+	}
+	if len(fields) == 0 {
+		return nil, errors.New("MT940 file contains no fields")
+	}
+
+	parsed := &ParsedStatement{Warnings: []string{}}
+	var current *ParsedStatementLine
+	for _, field := range fields {
+		end := strings.Index(field[1:], ":") + 1
+		tag, value := field[1:end], strings.TrimSpace(field[end+1:])
+
+		switch tag {
+		case "20":
+			parsed.StatementRef = value
+		case "25":
+			parsed.AccountRef = value
+		case "60F", "60M":
+			if parsed.OpeningBalance == nil {
+				balance, currency, _, err := parseMT940Balance(value)
+				if err != nil {
+					return nil, fmt.Errorf("invalid opening balance '%s': %v", value, err)
+				}
+				parsed.OpeningBalance, parsed.Currency = &balance, currency
+			}
+		case "62F", "62M":
+			balance, currency, _, err := parseMT940Balance(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid closing balance '%s': %v", value, err)
+			}
+			parsed.ClosingBalance, parsed.Currency = &balance, currency
+		case "61":
+			line, err := parseMT940StatementLine(value)
+			if err != nil {
+				parsed.Warnings = append(parsed.Warnings, fmt.Sprintf(":61: %s skipped, %v", value, err))
+				current = nil
+				continue
+			}
+			parsed.Lines = append(parsed.Lines, line)
+			current = &parsed.Lines[len(parsed.Lines)-1]
+		case "86":
+			if current != nil {
+				current.Description = strings.TrimSpace(current.Description + " " + value)
+			}
+		}
+	}
+
+	finalizeParsedStatement(parsed)
+	return parsed, nil
+}
+
+func parseMT940Balance(value string) (float64, string, time.Time, error) {
+	m := mt940BalanceRe.FindStringSubmatch(strings.ReplaceAll(value, " ", ""))
+	if m == nil {
+		return 0, "", time.Time{}, errors.New("unrecognised balance field")
+	}
+	date, err := time.Parse("060102", m[2])
+	if err != nil {
+		return 0, "", time.Time{}, err
+	}
+	amount, err := parseStatementAmount(m[4], ",")
+	if err != nil {
+		return 0, "", time.Time{}, err
+	}
+	if m[1] == "D" {
+		amount = -amount
+	}
+	return amount, m[3], date, nil
+}
+
+func parseMT940StatementLine(value string) (ParsedStatementLine, error) {
+	m := mt940StatementRe.FindStringSubmatch(value)
+	if m == nil {
+		return ParsedStatementLine{}, errors.New("unrecognised statement line")
+	}
+	date, err := time.Parse("060102", m[1])
+	if err != nil {
+		return ParsedStatementLine{}, err
+	}
+	amount, err := parseStatementAmount(m[5], ",")
+	if err != nil {
+		return ParsedStatementLine{}, err
+	}
+	// D and RC (reversal of credit) take money out of the account
+	if m[3] == "D" || m[3] == "RC" {
+		amount = -amount
+	}
+
+	reference := strings.TrimSpace(m[7])
+	if reference == "" || reference == "NONREF" {
+		reference = strings.TrimSpace(m[8])
+	}
+	return ParsedStatementLine{Date: date, Reference: reference, Amount: amount}, nil
+}
+
+// ========== OFX ==========
+
+var ofxTagRe = regexp.MustCompile(`(?i)<([A-Z0-9.]+)>([^<\r\n]*)`)
+
+func parseOFXStatement(r io.Reader) (*ParsedStatement, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read OFX file: %v", err)
+	}
+	upper := bytes.ToUpper(data)
+	if !bytes.Contains(upper, []byte("<OFX>")) {
+		return nil, errors.New("file is not an OFX document")
+	}
+
+	parsed := &ParsedStatement{Warnings: []string{}}
+	content := string(data)
+	header := ofxValues(content)
+	parsed.AccountRef = header["ACCTID"]
+	parsed.Currency = header["CURDEF"]
+	if d, ok := parseOFXDate(header["DTSTART"]); ok {
+		parsed.PeriodStart = d
+	}
+	if d, ok := parseOFXDate(header["DTEND"]); ok {
+		parsed.PeriodEnd = d
+	}
+	if idx := strings.Index(strings.ToUpper(content), "<LEDGERBAL>"); idx >= 0 {
+		if raw := ofxValues(content[idx:])["BALAMT"]; raw != "" {
+			if balance, err := parseStatementAmount(raw, "."); err == nil {
+				parsed.ClosingBalance = &balance
+			}
+		}
+	}
+
+	blocks := strings.Split(content, "<STMTTRN>")
+	for i, block := range blocks[1:] {
+		if end := strings.Index(block, "</STMTTRN>"); end >= 0 {
+			block = block[:end]
+		}
+		values := ofxValues(block)
+
+		date, ok := parseOFXDate(values["DTPOSTED"])
+		if !ok {
+			parsed.Warnings = append(parsed.Warnings, fmt.Sprintf("transaction %d: skipped, invalid DTPOSTED", i+1))
+			continue
+		}
+		amount, err := parseStatementAmount(values["TRNAMT"], ".")
+		if err != nil {
+			parsed.Warnings = append(parsed.Warnings, fmt.Sprintf("transaction %d: skipped, invalid TRNAMT '%s'", i+1, values["TRNAMT"]))
+			continue
+		}
+
+		reference := values["CHECKNUM"]
+		if reference == "" {
+			reference = values["REFNUM"]
+		}
+		if reference == "" {
+			reference = values["FITID"]
+		}
+		parsed.Lines = append(parsed.Lines, ParsedStatementLine{
+			Date:        date,
+			Description: strings.TrimSpace(values["NAME"] + " " + values["MEMO"]),
+			Reference:   reference,
+			Amount:      amount,
+		})
+	}
+
+	finalizeParsedStatement(parsed)
+	return parsed, nil
+}
+
+// ofxValues returns the first value of each leaf tag in the fragment (works for SGML and XML OFX)
+func ofxValues(fragment string) map[string]string {
+	values := make(map[string]string)
+	for _, m := range ofxTagRe.FindAllStringSubmatch(fragment, -1) {
+		tag := strings.ToUpper(m[1])
+		if _, exists := values[tag]; !exists && strings.TrimSpace(m[2]) != "" {
+			values[tag] = strings.TrimSpace(m[2])
+		}
+	}
+	return values
+}
+
+func parseOFXDate(raw string) (time.Time, bool) {
+	if len(raw) < 8 {
+		return time.Time{}, false
+	}
+	d, err := time.Parse("20060102", raw[:8])
+	return d, err == nil
+}
+
+// finalizeParsedStatement fills the period from the lines when the file did not state it
+func finalizeParsedStatement(parsed *ParsedStatement) {
+	for _, line := range parsed.Lines {
+		if parsed.PeriodStart.IsZero() || line.Date.Before(parsed.PeriodStart) {
+			parsed.PeriodStart = line.Date
+		}
+		if parsed.PeriodEnd.IsZero() || line.Date.After(parsed.PeriodEnd) {
+			parsed.PeriodEnd = line.Date
+		}
+	}
+	if parsed.ClosingBalance == nil && len(parsed.Lines) > 0 {
+		if last := parsed.Lines[len(parsed.Lines)-1].Balance; last != nil {
+			closing := *last
+			parsed.ClosingBalance = &closing
+		}
+	}
+	if parsed.OpeningBalance == nil && len(parsed.Lines) > 0 {
+		if first := parsed.Lines[0]; first.Balance != nil {
+			opening := *first.Balance - first.Amount
+			parsed.OpeningBalance = &opening
+		}
+	}
+}
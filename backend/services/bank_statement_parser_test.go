@@ -0,0 +1,211 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseStatementAmount(t *testing.T) {
+	tests := []struct {
+		name      string
+		raw       string
+		separator string
+		want      float64
+		wantErr   bool
+	}{
+		{name: "dot decimal with thousands", raw: "1,250,000.00", separator: ".", want: 1250000},
+		{name: "comma decimal with thousands", raw: "1.250.000,50", separator: ",", want: 1250000.5},
+		{name: "parentheses are negative", raw: "(15.000)", separator: ",", want: -15000},
+		{name: "leading minus", raw: "-15000", separator: ".", want: -15000},
+		{name: "BCA credit suffix", raw: "1,000.00 CR", separator: ".", want: 1000},
+		{name: "BCA debit suffix", raw: "6,500.00 DB", separator: ".", want: -6500},
+		{name: "currency prefix", raw: "Rp 2.500,00", separator: ",", want: 2500},
+		{name: "empty", raw: "  ", separator: ".", wantErr: true},
+		{name: "not a number", raw: "abc", separator: ".", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseStatementAmount(tt.raw, tt.separator)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.InDelta(t, tt.want, got, 0.001)
+		})
+	}
+}
+
+func TestParseCSVStatement(t *testing.T) {
+	tests := []struct {
+		name         string
+		mapping      string
+		content      string
+		wantAmounts  []float64
+		wantRefs     []string
+		wantWarnings int
+		wantOpening  float64
+		wantClosing  float64
+	}{
+		{
+			name:    "BCA amount with DB/CR column",
+			mapping: "BCA",
+			content: "Tanggal,Keterangan,Cabang,Jumlah,DB/CR,Saldo\n" +
+				"01/03/2024,TRSF E-BANKING CR PT MAJU,0000,\"1,000,000.00\",CR,\"6,000,000.00\"\n" +
+				"02/03/2024,BIAYA ADM,0000,\"15,000.00\",DB,\"5,985,000.00\"\n" +
+				"Saldo Akhir,,,,,\"5,985,000.00\"\n",
+			wantAmounts:  []float64{1000000, -15000},
+			wantRefs:     []string{"", ""},
+			wantWarnings: 1,
+			wantOpening:  5000000,
+			wantClosing:  5985000,
+		},
+		{
+			name:    "Mandiri debit and credit columns with reference",
+			mapping: "MANDIRI",
+			content: "Account No,Date,Val. Date,Transaction Code,Description,Reference No.,Debit,Credit,Balance\n" +
+				"123,05/03/2024,05/03/2024,1,Payment INV-001,REF001,,2500000.00,12500000.00\n" +
+				"123,06/03/2024,06/03/2024,2,Transfer out,REF002,750000.00,,11750000.00\n",
+			wantAmounts: []float64{2500000, -750000},
+			wantRefs:    []string{"REF001", "REF002"},
+			wantOpening: 10000000,
+			wantClosing: 11750000,
+		},
+		{
+			name:    "BRI semicolon with comma decimals",
+			mapping: "BRI",
+			content: "Tanggal;Uraian Transaksi;Teller;Debet;Kredit;Saldo\n" +
+				"10/03/24;SETORAN;8888;;1.500.000,00;3.500.000,00\n" +
+				"11/03/24;TARIK TUNAI;8888;0,00;0,00;3.500.000,00\n",
+			wantAmounts:  []float64{1500000},
+			wantRefs:     []string{""},
+			wantWarnings: 1,
+			wantOpening:  2000000,
+			wantClosing:  3500000,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parsed, err := parseCSVStatement(strings.NewReader(tt.content), DefaultStatementMappings[tt.mapping])
+			require.NoError(t, err)
+			require.Len(t, parsed.Lines, len(tt.wantAmounts))
+			for i, line := range parsed.Lines {
+				assert.InDelta(t, tt.wantAmounts[i], line.Amount, 0.001)
+				assert.Equal(t, tt.wantRefs[i], line.Reference)
+			}
+			assert.Len(t, parsed.Warnings, tt.wantWarnings)
+			require.NotNil(t, parsed.OpeningBalance)
+			require.NotNil(t, parsed.ClosingBalance)
+			assert.InDelta(t, tt.wantOpening, *parsed.OpeningBalance, 0.001)
+			assert.InDelta(t, tt.wantClosing, *parsed.ClosingBalance, 0.001)
+		})
+	}
+}
+
+func TestParseMT940Statement(t *testing.T) {
+	content := strings.Join([]string{
+		"{1:F01BANKIDJAXXXX0000000000}",
+		":20:STMT240301",
+		":25:1234567890",
+		":28C:00001/001",
+		":60F:C240301IDR5000000,00",
+		":61:2403010301C1000000,00NTRFINV-001//BANK123",
+		":86:PAYMENT FROM PT MAJU",
+		"INVOICE INV-001",
+		":61:2403020302D15000,00NCHGNONREF//FEE0302",
+		":86:BIAYA ADMIN",
+		":61:garbage",
+		":62F:C240302IDR5985000,00",
+		"-",
+	}, "\n")
+
+	parsed, err := parseMT940Statement(strings.NewReader(content))
+	require.NoError(t, err)
+
+	assert.Equal(t, "STMT240301", parsed.StatementRef)
+	assert.Equal(t, "1234567890", parsed.AccountRef)
+	assert.Equal(t, "IDR", parsed.Currency)
+	require.NotNil(t, parsed.OpeningBalance)
+	require.NotNil(t, parsed.ClosingBalance)
+	assert.InDelta(t, 5000000, *parsed.OpeningBalance, 0.001)
+	assert.InDelta(t, 5985000, *parsed.ClosingBalance, 0.001)
+	assert.Len(t, parsed.Warnings, 1)
+
+	tests := []struct {
+		name        string
+		amount      float64
+		reference   string
+		description string
+		date        time.Time
+	}{
+		{name: "credit keeps the customer reference", amount: 1000000, reference: "INV-001", description: "PAYMENT FROM PT MAJU INVOICE INV-001", date: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{name: "NONREF debit falls back to the bank reference", amount: -15000, reference: "FEE0302", description: "BIAYA ADMIN", date: time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC)},
+	}
+	require.Len(t, parsed.Lines, len(tests))
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			line := parsed.Lines[i]
+			assert.InDelta(t, tt.amount, line.Amount, 0.001)
+			assert.Equal(t, tt.reference, line.Reference)
+			assert.Equal(t, tt.description, line.Description)
+			assert.True(t, tt.date.Equal(line.Date))
+		})
+	}
+}
+
+func TestParseOFXStatement(t *testing.T) {
+	content := `OFXHEADER:100
+<OFX>
+<BANKMSGSRSV1><STMTTRNRS><STMTRS>
+<CURDEF>IDR
+<BANKACCTFROM><ACCTID>9876543210</BANKACCTFROM>
+<BANKTRANLIST>
+<DTSTART>20240301
+<DTEND>20240331
+<STMTTRN><TRNTYPE>CREDIT<DTPOSTED>20240305120000<TRNAMT>2500000.00<FITID>F1<NAME>PT MAJU<MEMO>INV-001</STMTTRN>
+<STMTTRN><TRNTYPE>DEBIT<DTPOSTED>20240306<TRNAMT>-750000.00<FITID>F2<CHECKNUM>CHK100</STMTTRN>
+<STMTTRN><TRNTYPE>DEBIT<DTPOSTED>bad<TRNAMT>-1.00<FITID>F3</STMTTRN>
+</BANKTRANLIST>
+<LEDGERBAL><BALAMT>11750000.00<DTASOF>20240331</LEDGERBAL>
+</STMTRS></STMTTRNRS></BANKMSGSRSV1>
+</OFX>`
+
+	parsed, err := parseOFXStatement(strings.NewReader(content))
+	require.NoError(t, err)
+
+	assert.Equal(t, "9876543210", parsed.AccountRef)
+	assert.Equal(t, "IDR", parsed.Currency)
+	assert.True(t, parsed.PeriodStart.Equal(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)))
+	assert.True(t, parsed.PeriodEnd.Equal(time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC)))
+	require.NotNil(t, parsed.ClosingBalance)
+	assert.InDelta(t, 11750000, *parsed.ClosingBalance, 0.001)
+	assert.Len(t, parsed.Warnings, 1)
+
+	tests := []struct {
+		name        string
+		amount      float64
+		reference   string
+		description string
+	}{
+		{name: "FITID is the fallback reference", amount: 2500000, reference: "F1", description: "PT MAJU INV-001"},
+		{name: "CHECKNUM wins over FITID", amount: -750000, reference: "CHK100", description: ""},
+	}
+	require.Len(t, parsed.Lines, len(tests))
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			line := parsed.Lines[i]
+			assert.InDelta(t, tt.amount, line.Amount, 0.001)
+			assert.Equal(t, tt.reference, line.Reference)
+			assert.Equal(t, tt.description, line.Description)
+		})
+	}
+
+	_, err = parseOFXStatement(strings.NewReader("not an ofx file"))
+	assert.Error(t, err)
+}
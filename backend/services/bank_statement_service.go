@@ -0,0 +1,730 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"app-sistem-akuntansi/models"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ReferenceType of cash-bank transactions created from bank statement lines (bank fees, interest)
+const CashBankRefBankStatement = "BANK_STATEMENT"
+
+type BankStatementService struct {
+	db              *gorm.DB
+	journalService  *UnifiedJournalService
+	currencyService *CurrencyService
+}
+
+func NewBankStatementService(db *gorm.DB) *BankStatementService {
+	return &BankStatementService{
+		db:              db,
+		journalService:  NewUnifiedJournalService(db),
+		currencyService: NewCurrencyService(db),
+	}
+}
+
+// BankStatementImportRequest - Parameter impor rekening koran
+type BankStatementImportRequest struct {
+	CashBankID  uint
+	Format      string
+	MappingCode string                             // Preset (BCA, MANDIRI, BRI) or saved custom mapping code, CSV only
+	Mapping     *models.BankStatementColumnMapping // Ad-hoc mapping, takes precedence over MappingCode
+	FileName    string
+	AutoMatch   bool
+}
+
+// BankStatementImportResult - Hasil impor rekening koran
+type BankStatementImportResult struct {
+	Statement *models.BankStatement `json:"statement"`
+	Imported  int                   `json:"imported"`
+	Matched   int                   `json:"matched"`
+	Warnings  []string              `json:"warnings"`
+}
+
+// BankStatementMatchResult - Hasil pencocokan otomatis
+type BankStatementMatchResult struct {
+	StatementID uint `json:"statement_id"`
+	Matched     int  `json:"matched"`
+	Unmatched   int  `json:"unmatched"`
+}
+
+// BankStatementReconciliation - Posisi rekonsiliasi satu rekening koran: yang belum cocok di kedua sisi
+type BankStatementReconciliation struct {
+	Statement             *models.BankStatement        `json:"statement"`
+	UnmatchedLines        []models.BankStatementLine   `json:"unmatched_lines"`
+	UnmatchedTransactions []models.CashBankTransaction `json:"unmatched_transactions"`
+	MatchedCount          int                          `json:"matched_count"`
+	StatementBalance      float64                      `json:"statement_balance"`
+	BookBalance           float64                      `json:"book_balance"`
+	Difference            float64                      `json:"difference"`
+}
+
+// ========== COLUMN MAPPINGS ==========
+
+// GetColumnMappings - Daftar preset bawaan dan mapping kolom CSV yang disimpan
+func (s *BankStatementService) GetColumnMappings() ([]models.BankStatementColumnMapping, error) {
+	var saved []models.BankStatementColumnMapping
+	if err := s.db.Where("is_active = ?", true).Order("code").Find(&saved).Error; err != nil {
+		return nil, err
+	}
+
+	overridden := make(map[string]bool)
+	for _, m := range saved {
+		overridden[m.Code] = true
+	}
+	presets := make([]models.BankStatementColumnMapping, 0, len(DefaultStatementMappings))
+	for code, m := range DefaultStatementMappings {
+		if !overridden[code] {
+			presets = append(presets, m)
+		}
+	}
+	sort.Slice(presets, func(i, j int) bool { return presets[i].Code < presets[j].Code })
+	return append(presets, saved...), nil
+}
+
+// SaveColumnMapping - Simpan (atau perbarui berdasarkan kode) mapping kolom CSV
+func (s *BankStatementService) SaveColumnMapping(mapping models.BankStatementColumnMapping) (*models.BankStatementColumnMapping, error) {
+	mapping.Code = strings.ToUpper(strings.TrimSpace(mapping.Code))
+	if mapping.Code == "" {
+		return nil, errors.New("mapping code is required")
+	}
+	if mapping.DateFormat == "" {
+		return nil, errors.New("date format is required")
+	}
+	if mapping.AmountColumn < 0 && mapping.DebitColumn < 0 && mapping.CreditColumn < 0 {
+		return nil, errors.New("an amount column or debit/credit columns are required")
+	}
+
+	var existing models.BankStatementColumnMapping
+	err := s.db.Where("code = ?", mapping.Code).First(&existing).Error
+	if err == nil {
+		mapping.ID = existing.ID
+		mapping.CreatedAt = existing.CreatedAt
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	mapping.IsActive = true
+	if err := s.db.Save(&mapping).Error; err != nil {
+		return nil, err
+	}
+	return &mapping, nil
+}
+
+func (s *BankStatementService) resolveMapping(req BankStatementImportRequest) (*models.BankStatementColumnMapping, error) {
+	if req.Mapping != nil {
+		return req.Mapping, nil
+	}
+	code := strings.ToUpper(strings.TrimSpace(req.MappingCode))
+	if code == "" {
+		return nil, errors.New("mapping code is required for CSV statements (BCA, MANDIRI, BRI or a saved mapping)")
+	}
+
+	var saved models.BankStatementColumnMapping
+	if err := s.db.Where("code = ? AND is_active = ?", code, true).First(&saved).Error; err == nil {
+		return &saved, nil
+	}
+	if preset, ok := DefaultStatementMappings[code]; ok {
+		return &preset, nil
+	}
+	return nil, fmt.Errorf("column mapping '%s' not found", code)
+}
+
+// ========== IMPORT ==========
+
+// GetStatements - Daftar rekening koran yang sudah diimpor
+func (s *BankStatementService) GetStatements(cashBankID uint) ([]models.BankStatement, error) {
+	var statements []models.BankStatement
+	query := s.db.Preload("CashBank")
+	if cashBankID > 0 {
+		query = query.Where("cash_bank_id = ?", cashBankID)
+	}
+	if err := query.Order("period_end DESC, id DESC").Find(&statements).Error; err != nil {
+		return nil, err
+	}
+	return statements, nil
+}
+
+// GetStatementByID - Rekening koran beserta seluruh barisnya
+func (s *BankStatementService) GetStatementByID(id uint) (*models.BankStatement, error) {
+	var statement models.BankStatement
+	if err := s.db.Preload("CashBank").
+		Preload("Lines", func(db *gorm.DB) *gorm.DB { return db.Order("line_number ASC") }).
+		Preload("Lines.MatchedTransaction").
+		First(&statement, id).Error; err != nil {
+		return nil, err
+	}
+	return &statement, nil
+}
+
+// ImportStatement - Parse file rekening koran dan simpan barisnya
+func (s *BankStatementService) ImportStatement(r io.Reader, req BankStatementImportRequest, userID uint) (*BankStatementImportResult, error) {
+	var cashBank models.CashBank
+	if err := s.db.First(&cashBank, req.CashBankID).Error; err != nil {
+		return nil, errors.New("cash bank account not found")
+	}
+	if cashBank.Type != models.CashBankTypeBank {
+		return nil, errors.New("bank statements can only be imported for BANK accounts")
+	}
+
+	format := strings.ToUpper(strings.TrimSpace(req.Format))
+	var mapping *models.BankStatementColumnMapping
+	if format == models.BankStatementFormatCSV {
+		var err error
+		if mapping, err = s.resolveMapping(req); err != nil {
+			return nil, err
+		}
+	}
+
+	parsed, err := ParseBankStatement(format, r, mapping)
+	if err != nil {
+		return nil, err
+	}
+	if len(parsed.Lines) == 0 {
+		return nil, errors.New("no transactions found in the statement file")
+	}
+	if parsed.Currency != "" && normalizeCurrencyCode(parsed.Currency) != normalizeCurrencyCode(cashBank.Currency) {
+		return nil, fmt.Errorf("statement currency %s does not match account currency %s", parsed.Currency, normalizeCurrencyCode(cashBank.Currency))
+	}
+
+	statement := &models.BankStatement{
+		CashBankID:   cashBank.ID,
+		Format:       format,
+		FileName:     req.FileName,
+		StatementRef: parsed.StatementRef,
+		PeriodStart:  parsed.PeriodStart,
+		PeriodEnd:    parsed.PeriodEnd,
+		LineCount:    len(parsed.Lines),
+		Status:       models.BankStatementStatusImported,
+		ImportedBy:   userID,
+	}
+	if mapping != nil {
+		statement.BankPreset = mapping.Code
+	}
+	if parsed.OpeningBalance != nil {
+		statement.OpeningBalance = *parsed.OpeningBalance
+	}
+	if parsed.ClosingBalance != nil {
+		statement.ClosingBalance = *parsed.ClosingBalance
+	}
+	for _, line := range parsed.Lines {
+		if line.Amount < 0 {
+			statement.TotalDebit += -line.Amount
+		} else {
+			statement.TotalCredit += line.Amount
+		}
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Create(statement).Error; err != nil {
+			return fmt.Errorf("failed to save bank statement: %v", err)
+		}
+		lines := make([]models.BankStatementLine, 0, len(parsed.Lines))
+		for i, line := range parsed.Lines {
+			lines = append(lines, models.BankStatementLine{
+				StatementID:     statement.ID,
+				CashBankID:      cashBank.ID,
+				LineNumber:      i + 1,
+				TransactionDate: line.Date,
+				Description:     line.Description,
+				Reference:       line.Reference,
+				Amount:          line.Amount,
+				Balance:         line.Balance,
+				MatchStatus:     models.StatementLineUnmatched,
+			})
+		}
+		if err := tx.CreateInBatches(lines, 200).Error; err != nil {
+			return fmt.Errorf("failed to save statement lines: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := &BankStatementImportResult{
+		Statement: statement,
+		Imported:  len(parsed.Lines),
+		Warnings:  parsed.Warnings,
+	}
+	if req.AutoMatch {
+		matchResult, err := s.AutoMatch(statement.ID, models.BankStatementMatchRequest{}, userID)
+		if err != nil {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("auto-match failed: %v", err))
+		} else {
+			result.Matched = matchResult.Matched
+			statement.MatchedCount = matchResult.Matched
+		}
+	}
+
+	log.Printf("🏦 Imported %s statement for %s: %d lines, %d matched", format, cashBank.Name, result.Imported, result.Matched)
+	return result, nil
+}
+
+// DeleteStatement - Hapus rekening koran beserta barisnya (tidak boleh jika sudah ada jurnal dari barisnya)
+func (s *BankStatementService) DeleteStatement(id uint) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var journalLines int64
+		if err := tx.Model(&models.BankStatementLine{}).
+			Where("statement_id = ? AND journal_entry_id IS NOT NULL", id).
+			Count(&journalLines).Error; err != nil {
+			return err
+		}
+		if journalLines > 0 {
+			return errors.New("statement has lines posted as journals and cannot be deleted")
+		}
+		if err := tx.Where("statement_id = ?", id).Delete(&models.BankStatementLine{}).Error; err != nil {
+			return err
+		}
+		res := tx.Delete(&models.BankStatement{}, id)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return errors.New("bank statement not found")
+		}
+		return nil
+	})
+}
+
+// ========== MATCHING ==========
+
+type statementMatchCandidate struct {
+	lineIndex int
+	txIndex   int
+	score     int
+}
+
+// AutoMatch - Cocokkan baris rekening koran dengan transaksi kas/bank berdasarkan nominal, tanggal dan referensi.
+// Each pair is scored and the best pairs are taken first so one transaction never matches two lines.
+func (s *BankStatementService) AutoMatch(statementID uint, rules models.BankStatementMatchRequest, userID uint) (*BankStatementMatchResult, error) {
+	if rules.DateWindowDays <= 0 {
+		rules.DateWindowDays = 3
+	}
+	if rules.AmountTolerance < 0 {
+		rules.AmountTolerance = 0
+	}
+
+	result := &BankStatementMatchResult{StatementID: statementID}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var statement models.BankStatement
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&statement, statementID).Error; err != nil {
+			return errors.New("bank statement not found")
+		}
+
+		var lines []models.BankStatementLine
+		if err := tx.Where("statement_id = ? AND match_status = ?", statementID, models.StatementLineUnmatched).
+			Order("line_number ASC").Find(&lines).Error; err != nil {
+			return err
+		}
+		if len(lines) == 0 {
+			return nil
+		}
+
+		window := time.Duration(rules.DateWindowDays) * 24 * time.Hour
+		transactions, err := s.unmatchedTransactions(tx, statement.CashBankID,
+			statement.PeriodStart.Add(-window), endOfDay(statement.PeriodEnd).Add(window))
+		if err != nil {
+			return err
+		}
+		references, err := s.transactionReferences(tx, transactions)
+		if err != nil {
+			return err
+		}
+
+		var candidates []statementMatchCandidate
+		for li, line := range lines {
+			for ti, trx := range transactions {
+				score, ok := scoreStatementMatch(line, trx, references[trx.ID], rules)
+				if ok {
+					candidates = append(candidates, statementMatchCandidate{lineIndex: li, txIndex: ti, score: score})
+				}
+			}
+		}
+		sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].score > candidates[j].score })
+
+		now := time.Now()
+		usedLines := make(map[int]bool)
+		usedTx := make(map[int]bool)
+		for _, c := range candidates {
+			if usedLines[c.lineIndex] || usedTx[c.txIndex] {
+				continue
+			}
+			usedLines[c.lineIndex], usedTx[c.txIndex] = true, true
+
+			trxID := transactions[c.txIndex].ID
+			if err := tx.Model(&models.BankStatementLine{}).Where("id = ?", lines[c.lineIndex].ID).Updates(map[string]interface{}{
+				"match_status":           models.StatementLineAuto,
+				"matched_transaction_id": trxID,
+				"match_score":            c.score,
+				"matched_at":             now,
+				"matched_by":             userID,
+			}).Error; err != nil {
+				return fmt.Errorf("failed to save match: %v", err)
+			}
+			result.Matched++
+		}
+		result.Unmatched = len(lines) - result.Matched
+
+		return s.refreshStatementStatus(tx, &statement)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// scoreStatementMatch applies the tolerance rules and returns a score out of 100 for a line/transaction pair
+func scoreStatementMatch(line models.BankStatementLine, trx models.CashBankTransaction, reference string, rules models.BankStatementMatchRequest) (int, bool) {
+	if (line.Amount < 0) != (trx.Amount < 0) {
+		return 0, false
+	}
+	amountDiff := math.Abs(math.Abs(line.Amount) - math.Abs(trx.Amount))
+	if amountDiff > rules.AmountTolerance+0.005 {
+		return 0, false
+	}
+	dayDiff := math.Abs(dateOnly(line.TransactionDate).Sub(dateOnly(trx.TransactionDate)).Hours() / 24)
+	if dayDiff > float64(rules.DateWindowDays) {
+		return 0, false
+	}
+
+	referenceMatch := statementReferenceMatches(line, reference)
+	if rules.RequireReference && !referenceMatch {
+		return 0, false
+	}
+
+	score := 60
+	if amountDiff < 0.005 {
+		score += 20
+	} else if rules.AmountTolerance > 0 {
+		score += int(10 * (1 - amountDiff/rules.AmountTolerance))
+	}
+	score += 10 - int(math.Min(dayDiff*3, 10))
+	if referenceMatch {
+		score += 10
+	}
+	return score, true
+}
+
+// statementReferenceMatches reports whether the bank reference (or description) mentions the transaction's reference text
+func statementReferenceMatches(line models.BankStatementLine, reference string) bool {
+	reference = strings.ToUpper(strings.TrimSpace(reference))
+	if reference == "" {
+		return false
+	}
+	lineRef := strings.ToUpper(strings.TrimSpace(line.Reference))
+	if lineRef != "" && (strings.Contains(reference, lineRef) || strings.Contains(lineRef, reference)) {
+		return true
+	}
+	for _, token := range strings.Fields(reference) {
+		if len(token) >= 5 && strings.Contains(strings.ToUpper(line.Description), token) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchLine - Cocokkan manual satu baris rekening koran dengan transaksi kas/bank
+func (s *BankStatementService) MatchLine(lineID uint, req models.BankStatementManualMatchRequest, userID uint) (*models.BankStatementLine, error) {
+	var line models.BankStatementLine
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&line, lineID).Error; err != nil {
+			return errors.New("statement line not found")
+		}
+		if line.MatchStatus != models.StatementLineUnmatched {
+			return errors.New("statement line is already matched")
+		}
+
+		var trx models.CashBankTransaction
+		if err := tx.First(&trx, req.TransactionID).Error; err != nil {
+			return errors.New("cash bank transaction not found")
+		}
+		if trx.CashBankID != line.CashBankID {
+			return errors.New("transaction belongs to a different cash bank account")
+		}
+		var used int64
+		if err := tx.Model(&models.BankStatementLine{}).
+			Where("matched_transaction_id = ? AND id <> ?", trx.ID, line.ID).
+			Count(&used).Error; err != nil {
+			return err
+		}
+		if used > 0 {
+			return errors.New("transaction is already matched to another statement line")
+		}
+
+		now := time.Now()
+		line.MatchStatus = models.StatementLineManual
+		line.MatchedTransactionID = &trx.ID
+		line.MatchScore = 100
+		line.MatchedAt = &now
+		line.MatchedBy = &userID
+		if err := tx.Save(&line).Error; err != nil {
+			return err
+		}
+		return s.refreshStatementStatusByID(tx, line.StatementID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &line, nil
+}
+
+// UnmatchLine - Batalkan pencocokan; baris yang sudah dijurnal tidak bisa dibatalkan
+func (s *BankStatementService) UnmatchLine(lineID uint) (*models.BankStatementLine, error) {
+	var line models.BankStatementLine
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&line, lineID).Error; err != nil {
+			return errors.New("statement line not found")
+		}
+		if line.MatchStatus == models.StatementLineJournal {
+			return errors.New("statement line was posted as a journal and cannot be unmatched")
+		}
+
+		line.MatchStatus = models.StatementLineUnmatched
+		line.MatchedTransactionID = nil
+		line.MatchScore = 0
+		line.MatchedAt = nil
+		line.MatchedBy = nil
+		if err := tx.Save(&line).Error; err != nil {
+			return err
+		}
+		return s.refreshStatementStatusByID(tx, line.StatementID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &line, nil
+}
+
+// GetReconciliation - Item yang belum cocok di kedua sisi beserta selisih saldo
+func (s *BankStatementService) GetReconciliation(statementID uint) (*BankStatementReconciliation, error) {
+	var statement models.BankStatement
+	if err := s.db.Preload("CashBank").First(&statement, statementID).Error; err != nil {
+		return nil, errors.New("bank statement not found")
+	}
+
+	report := &BankStatementReconciliation{Statement: &statement, StatementBalance: statement.ClosingBalance}
+	if err := s.db.Where("statement_id = ? AND match_status = ?", statementID, models.StatementLineUnmatched).
+		Order("line_number ASC").Find(&report.UnmatchedLines).Error; err != nil {
+		return nil, err
+	}
+	transactions, err := s.unmatchedTransactions(s.db, statement.CashBankID, statement.PeriodStart, endOfDay(statement.PeriodEnd))
+	if err != nil {
+		return nil, err
+	}
+	report.UnmatchedTransactions = transactions
+	report.MatchedCount = statement.LineCount - len(report.UnmatchedLines)
+
+	// Book balance is the running balance of the last cash-bank transaction up to the statement end date
+	var last models.CashBankTransaction
+	if err := s.db.Where("cash_bank_id = ? AND transaction_date <= ?", statement.CashBankID, endOfDay(statement.PeriodEnd)).
+		Order("transaction_date DESC, id DESC").First(&last).Error; err == nil {
+		report.BookBalance = last.BalanceAfter
+	}
+	report.Difference = roundAmount(report.StatementBalance - report.BookBalance)
+	return report, nil
+}
+
+// ========== JOURNALS FROM UNMATCHED LINES ==========
+
+// CreateJournalFromLine - Buat jurnal biaya administrasi / bunga bank dari baris rekening koran yang belum tercatat.
+// Money out: Dr counter account (expense), Cr bank. Money in: Dr bank, Cr counter account (income).
+func (s *BankStatementService) CreateJournalFromLine(lineID uint, req models.BankStatementJournalRequest, userID uint) (*models.BankStatementLine, error) {
+	var line models.BankStatementLine
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&line, lineID).Error; err != nil {
+			return errors.New("statement line not found")
+		}
+		if line.MatchStatus != models.StatementLineUnmatched {
+			return errors.New("only unmatched statement lines can be posted as journals")
+		}
+
+		var cashBank models.CashBank
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&cashBank, line.CashBankID).Error; err != nil {
+			return errors.New("cash bank account not found")
+		}
+		if cashBank.AccountID == 0 {
+			return errors.New("cash bank account is not linked to a GL account")
+		}
+		var counter models.Account
+		if err := tx.First(&counter, req.CounterAccountID).Error; err != nil {
+			return errors.New("counter account not found")
+		}
+		if counter.IsHeader {
+			return errors.New("counter account must be a detail account, not a header")
+		}
+		if counter.ID == cashBank.AccountID {
+			return errors.New("counter account must differ from the bank account")
+		}
+
+		// The bank balance is kept in the account currency, the journal in base currency
+		rate, err := s.currencyService.ResolveSettlementRate(tx, cashBank.Currency, line.TransactionDate, nil)
+		if err != nil {
+			return err
+		}
+		amount := decimal.NewFromFloat(math.Abs(line.Amount))
+		baseAmount := convertToBaseCurrency(amount, rate)
+
+		description := strings.TrimSpace(req.Description)
+		if description == "" {
+			description = strings.TrimSpace(line.Description)
+		}
+		if description == "" {
+			description = fmt.Sprintf("Bank statement line %d", line.LineNumber)
+		}
+
+		cashBank.Balance = roundAmount(cashBank.Balance + line.Amount)
+		if err := tx.Model(&cashBank).Update("balance", cashBank.Balance).Error; err != nil {
+			return fmt.Errorf("failed to update cash bank balance: %v", err)
+		}
+		trx := &models.CashBankTransaction{
+			CashBankID:      cashBank.ID,
+			ReferenceType:   CashBankRefBankStatement,
+			ReferenceID:     line.ID,
+			Amount:          line.Amount,
+			BalanceAfter:    cashBank.Balance,
+			TransactionDate: line.TransactionDate,
+			Notes:           description,
+		}
+		if err := tx.Create(trx).Error; err != nil {
+			return fmt.Errorf("failed to create cash bank transaction: %v", err)
+		}
+
+		bankLine := JournalLineRequest{AccountID: uint64(cashBank.AccountID), Description: description}
+		counterLine := JournalLineRequest{AccountID: uint64(counter.ID), Description: description}
+		if line.Amount < 0 {
+			counterLine.DebitAmount, bankLine.CreditAmount = baseAmount, baseAmount
+		} else {
+			bankLine.DebitAmount, counterLine.CreditAmount = baseAmount, baseAmount
+		}
+		lines := []JournalLineRequest{bankLine, counterLine}
+		if line.Amount < 0 {
+			lines = []JournalLineRequest{counterLine, bankLine}
+		}
+
+		reference := line.Reference
+		if reference == "" {
+			reference = fmt.Sprintf("BST-%d-%d", line.StatementID, line.LineNumber)
+		}
+		entry, err := s.journalService.CreateJournalEntryWithTx(tx, &JournalEntryRequest{
+			SourceType:  models.SSOTSourceTypeCashBank,
+			SourceID:    uint64(trx.ID),
+			Reference:   reference,
+			EntryDate:   line.TransactionDate,
+			Description: fmt.Sprintf("%s - %s", cashBank.Name, description),
+			Lines:       lines,
+			AutoPost:    true,
+			CreatedBy:   uint64(userID),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to post journal: %v", err)
+		}
+
+		now := time.Now()
+		line.MatchStatus = models.StatementLineJournal
+		line.MatchedTransactionID = &trx.ID
+		line.MatchScore = 100
+		line.MatchedAt = &now
+		line.MatchedBy = &userID
+		line.JournalEntryID = &entry.ID
+		if err := tx.Save(&line).Error; err != nil {
+			return err
+		}
+		return s.refreshStatementStatusByID(tx, line.StatementID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &line, nil
+}
+
+// ========== HELPERS ==========
+
+// unmatchedTransactions returns cash-bank transactions in the range that no statement line points to
+func (s *BankStatementService) unmatchedTransactions(db *gorm.DB, cashBankID uint, from, to time.Time) ([]models.CashBankTransaction, error) {
+	var transactions []models.CashBankTransaction
+	err := db.Where("cash_bank_id = ? AND transaction_date >= ? AND transaction_date <= ?", cashBankID, from, to).
+		Where("reference_type <> ?", TransactionTypeOpeningBalance).
+		Where("id NOT IN (?)", db.Model(&models.BankStatementLine{}).
+			Select("matched_transaction_id").
+			Where("matched_transaction_id IS NOT NULL")).
+		Order("transaction_date ASC, id ASC").
+		Find(&transactions).Error
+	return transactions, err
+}
+
+// transactionReferences builds the text a statement reference is compared with: notes plus the payment code
+func (s *BankStatementService) transactionReferences(db *gorm.DB, transactions []models.CashBankTransaction) (map[uint]string, error) {
+	var paymentIDs []uint
+	for _, trx := range transactions {
+		if trx.ReferenceType == models.JournalRefPayment && trx.ReferenceID > 0 {
+			paymentIDs = append(paymentIDs, trx.ReferenceID)
+		}
+	}
+
+	paymentCodes := make(map[uint]string)
+	if len(paymentIDs) > 0 {
+		var payments []models.Payment
+		if err := db.Select("id", "code", "reference").Where("id IN ?", paymentIDs).Find(&payments).Error; err != nil {
+			return nil, err
+		}
+		for _, p := range payments {
+			paymentCodes[p.ID] = strings.TrimSpace(p.Code + " " + p.Reference)
+		}
+	}
+
+	references := make(map[uint]string, len(transactions))
+	for _, trx := range transactions {
+		ref := trx.Notes
+		if trx.ReferenceType == models.JournalRefPayment {
+			ref = strings.TrimSpace(paymentCodes[trx.ReferenceID] + " " + ref)
+		}
+		references[trx.ID] = ref
+	}
+	return references, nil
+}
+
+func (s *BankStatementService) refreshStatementStatusByID(tx *gorm.DB, statementID uint) error {
+	var statement models.BankStatement
+	if err := tx.First(&statement, statementID).Error; err != nil {
+		return err
+	}
+	return s.refreshStatementStatus(tx, &statement)
+}
+
+// refreshStatementStatus recounts matched lines; a statement with every line matched is RECONCILED
+func (s *BankStatementService) refreshStatementStatus(tx *gorm.DB, statement *models.BankStatement) error {
+	var matched int64
+	if err := tx.Model(&models.BankStatementLine{}).
+		Where("statement_id = ? AND match_status <> ?", statement.ID, models.StatementLineUnmatched).
+		Count(&matched).Error; err != nil {
+		return err
+	}
+	statement.MatchedCount = int(matched)
+	statement.Status = models.BankStatementStatusImported
+	if statement.MatchedCount >= statement.LineCount {
+		statement.Status = models.BankStatementStatusReconciled
+	}
+	return tx.Model(statement).Updates(map[string]interface{}{
+		"matched_count": statement.MatchedCount,
+		"status":        statement.Status,
+	}).Error
+}
+
+func dateOnly(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func endOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 23, 59, 59, 0, t.Location())
+}
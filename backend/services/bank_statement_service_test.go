@@ -0,0 +1,107 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"app-sistem-akuntansi/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScoreStatementMatch(t *testing.T) {
+	day := time.Date(2024, 3, 5, 9, 30, 0, 0, time.UTC)
+	line := models.BankStatementLine{
+		TransactionDate: day,
+		Description:     "TRSF E-BANKING CR PAYMENT INV/2024/0001",
+		Reference:       "REF001",
+		Amount:          1000000,
+	}
+
+	tests := []struct {
+		name      string
+		line      models.BankStatementLine
+		trx       models.CashBankTransaction
+		reference string
+		rules     models.BankStatementMatchRequest
+		wantScore int
+		wantOK    bool
+	}{
+		{
+			name:      "exact amount, same day and reference",
+			line:      line,
+			trx:       models.CashBankTransaction{Amount: 1000000, TransactionDate: day.Add(5 * time.Hour)},
+			reference: "REF001",
+			wantScore: 100,
+			wantOK:    true,
+		},
+		{
+			name:      "reference found in the bank description",
+			line:      line,
+			trx:       models.CashBankTransaction{Amount: 1000000, TransactionDate: day},
+			reference: "Receipt INV/2024/0001",
+			wantScore: 100,
+			wantOK:    true,
+		},
+		{
+			name:      "exact amount without reference",
+			line:      line,
+			trx:       models.CashBankTransaction{Amount: 1000000, TransactionDate: day},
+			wantScore: 90,
+			wantOK:    true,
+		},
+		{
+			name:      "amount within tolerance is scored down proportionally",
+			line:      line,
+			trx:       models.CashBankTransaction{Amount: 999500, TransactionDate: day},
+			rules:     models.BankStatementMatchRequest{AmountTolerance: 1000},
+			wantScore: 75,
+			wantOK:    true,
+		},
+		{
+			name:      "amount outside tolerance",
+			line:      line,
+			trx:       models.CashBankTransaction{Amount: 998000, TransactionDate: day},
+			rules:     models.BankStatementMatchRequest{AmountTolerance: 1000},
+			wantScore: 0,
+			wantOK:    false,
+		},
+		{
+			name:      "two days apart inside the window",
+			line:      line,
+			trx:       models.CashBankTransaction{Amount: 1000000, TransactionDate: day.AddDate(0, 0, -2)},
+			rules:     models.BankStatementMatchRequest{DateWindowDays: 3},
+			wantScore: 84,
+			wantOK:    true,
+		},
+		{
+			name:   "outside the date window",
+			line:   line,
+			trx:    models.CashBankTransaction{Amount: 1000000, TransactionDate: day.AddDate(0, 0, 4)},
+			rules:  models.BankStatementMatchRequest{DateWindowDays: 3},
+			wantOK: false,
+		},
+		{
+			name:   "opposite direction never matches",
+			line:   line,
+			trx:    models.CashBankTransaction{Amount: -1000000, TransactionDate: day},
+			wantOK: false,
+		},
+		{
+			name:      "required reference missing",
+			line:      line,
+			trx:       models.CashBankTransaction{Amount: 1000000, TransactionDate: day},
+			reference: "OTHER",
+			rules:     models.BankStatementMatchRequest{RequireReference: true},
+			wantOK:    false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			score, ok := scoreStatementMatch(tt.line, tt.trx, tt.reference, tt.rules)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.wantScore, score)
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+// GenerateBudgetVsActualPDF generates the budget vs actual report PDF
+func (p *PDFService) GenerateBudgetVsActualPDF(report *BudgetVsActualReport) ([]byte, error) {
+	if report == nil {
+		return nil, fmt.Errorf("report data is required")
+	}
+
+	pdf := gofpdf.New("L", "mm", "A4", "")
+	pdf.SetMargins(15, 15, 15)
+	pdf.SetAutoPageBreak(true, 15)
+	pdf.AddPage()
+
+	p.addReportHeader(pdf, "Budget vs Actual",
+		fmt.Sprintf("Budget: %s - %s", report.BudgetCode, report.BudgetName),
+		fmt.Sprintf("Period: %s (%s - %s)", report.PeriodLabel,
+			report.StartDate.Format("02/01/2006"), report.EndDate.Format("02/01/2006")),
+		fmt.Sprintf("Generated on: %s", time.Now().Format("02/01/2006 15:04")),
+	)
+
+	lm, _, _, _ := pdf.GetMargins()
+	widths := []float64{25, 85, 25, 37, 37, 37, 21}
+
+	drawHeader := func() {
+		pdf.SetX(lm)
+		pdf.SetFont("Arial", "B", 8)
+		pdf.SetFillColor(220, 220, 220)
+		headers := []string{"Code", "Account", "Type", "Budget", "Actual", "Variance", "Var %"}
+		for i, h := range headers {
+			pdf.CellFormat(widths[i], 7, h, "1", 0, "C", true, 0, "")
+		}
+		pdf.Ln(7)
+	}
+	drawHeader()
+
+	_, pageH := pdf.GetPageSize()
+	pdf.SetFont("Arial", "", 8)
+	for _, line := range report.Lines {
+		if pdf.GetY() > pageH-25 {
+			pdf.AddPage()
+			drawHeader()
+			pdf.SetFont("Arial", "", 8)
+		}
+		pdf.SetX(lm)
+		pdf.CellFormat(widths[0], 6, line.AccountCode, "1", 0, "L", false, 0, "")
+		pdf.CellFormat(widths[1], 6, truncateToWidth(pdf, line.AccountName, widths[1]-2), "1", 0, "L", false, 0, "")
+		pdf.CellFormat(widths[2], 6, line.AccountType, "1", 0, "C", false, 0, "")
+		pdf.CellFormat(widths[3], 6, p.formatRupiah(line.BudgetAmount), "1", 0, "R", false, 0, "")
+		pdf.CellFormat(widths[4], 6, p.formatRupiah(line.ActualAmount), "1", 0, "R", false, 0, "")
+		if !line.IsFavorable {
+			pdf.SetTextColor(200, 0, 0)
+		}
+		pdf.CellFormat(widths[5], 6, p.formatRupiah(line.Variance), "1", 0, "R", false, 0, "")
+		pdf.CellFormat(widths[6], 6, fmt.Sprintf("%.2f%%", line.VariancePercent), "1", 0, "R", false, 0, "")
+		pdf.SetTextColor(0, 0, 0)
+		pdf.Ln(6)
+	}
+
+	pdf.SetX(lm)
+	pdf.SetFont("Arial", "B", 8)
+	pdf.SetFillColor(245, 245, 245)
+	pdf.CellFormat(widths[0]+widths[1]+widths[2], 7, "TOTAL", "1", 0, "R", true, 0, "")
+	pdf.CellFormat(widths[3], 7, p.formatRupiah(report.TotalBudget), "1", 0, "R", true, 0, "")
+	pdf.CellFormat(widths[4], 7, p.formatRupiah(report.TotalActual), "1", 0, "R", true, 0, "")
+	pdf.CellFormat(widths[5], 7, p.formatRupiah(report.TotalVariance), "1", 0, "R", true, 0, "")
+	pdf.CellFormat(widths[6], 7, fmt.Sprintf("%.2f%%", report.VariancePercent), "1", 0, "R", true, 0, "")
+	pdf.Ln(7)
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, fmt.Errorf("failed to generate PDF: %v", err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,770 @@
+package services
+
+import (
+	"app-sistem-akuntansi/models"
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+	"gorm.io/gorm"
+)
+
+type BudgetService struct {
+	db              *gorm.DB
+	approvalService *ApprovalService
+}
+
+func NewBudgetService(db *gorm.DB, approvalService *ApprovalService) *BudgetService {
+	return &BudgetService{
+		db:              db,
+		approvalService: approvalService,
+	}
+}
+
+// ========== REQUEST / RESPONSE TYPES ==========
+
+// BudgetItemRequest - Satu baris anggaran per akun per bulan
+type BudgetItemRequest struct {
+	AccountID    uint    `json:"account_id" binding:"required"`
+	Month        int     `json:"month" binding:"required,min=1,max=12"`
+	BudgetAmount float64 `json:"budget_amount"`
+	Notes        string  `json:"notes"`
+}
+
+// CreateBudgetRequest - Request untuk membuat anggaran tahunan
+type CreateBudgetRequest struct {
+	Name        string              `json:"name" binding:"required"`
+	Description string              `json:"description"`
+	Year        int                 `json:"year" binding:"required,min=2000,max=2100"`
+	Items       []BudgetItemRequest `json:"items"`
+}
+
+// UpdateBudgetRequest - Request untuk mengubah anggaran (hanya status DRAFT)
+type UpdateBudgetRequest struct {
+	Name        string              `json:"name"`
+	Description string              `json:"description"`
+	Items       []BudgetItemRequest `json:"items"`
+}
+
+// CopyBudgetFromActualsRequest - Request untuk membuat anggaran dari realisasi tahun sebelumnya
+type CopyBudgetFromActualsRequest struct {
+	Name              string   `json:"name" binding:"required"`
+	Description       string   `json:"description"`
+	Year              int      `json:"year" binding:"required,min=2000,max=2100"`
+	SourceYear        int      `json:"source_year"`        // Default: Year - 1
+	AccountTypes      []string `json:"account_types"`      // Default: REVENUE, EXPENSE
+	AdjustmentPercent float64  `json:"adjustment_percent"` // e.g. 10 = +10% from last year's actuals
+}
+
+// BudgetVsActualLine - Baris laporan anggaran vs realisasi per akun
+type BudgetVsActualLine struct {
+	AccountID       uint    `json:"account_id"`
+	AccountCode     string  `json:"account_code"`
+	AccountName     string  `json:"account_name"`
+	AccountType     string  `json:"account_type"`
+	BudgetAmount    float64 `json:"budget_amount"`
+	ActualAmount    float64 `json:"actual_amount"`
+	Variance        float64 `json:"variance"`
+	VariancePercent float64 `json:"variance_percent"`
+	IsFavorable     bool    `json:"is_favorable"`
+}
+
+// BudgetVsActualReport - Laporan anggaran vs realisasi
+type BudgetVsActualReport struct {
+	BudgetID        uint                 `json:"budget_id"`
+	BudgetCode      string               `json:"budget_code"`
+	BudgetName      string               `json:"budget_name"`
+	Year            int                  `json:"year"`
+	Period          string               `json:"period"` // MONTHLY, QUARTERLY, YTD
+	PeriodLabel     string               `json:"period_label"`
+	StartDate       time.Time            `json:"start_date"`
+	EndDate         time.Time            `json:"end_date"`
+	Lines           []BudgetVsActualLine `json:"lines"`
+	TotalBudget     float64              `json:"total_budget"`
+	TotalActual     float64              `json:"total_actual"`
+	TotalVariance   float64              `json:"total_variance"`
+	VariancePercent float64              `json:"variance_percent"`
+	GeneratedAt     time.Time            `json:"generated_at"`
+}
+
+// Budget report periods
+const (
+	BudgetReportPeriodMonthly   = "MONTHLY"
+	BudgetReportPeriodQuarterly = "QUARTERLY"
+	BudgetReportPeriodYTD       = "YTD"
+)
+
+// ========== BUDGET CRUD ==========
+
+// CreateBudget - Membuat anggaran baru dengan status DRAFT
+func (s *BudgetService) CreateBudget(req CreateBudgetRequest, userID uint) (*models.Budget, error) {
+	if err := s.validateItems(req.Items); err != nil {
+		return nil, err
+	}
+
+	code, err := s.generateBudgetCode(req.Year)
+	if err != nil {
+		return nil, err
+	}
+
+	budget := models.Budget{
+		Code:        code,
+		Name:        req.Name,
+		Description: req.Description,
+		Year:        req.Year,
+		Status:      models.BudgetStatusDraft,
+		UserID:      userID,
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&budget).Error; err != nil {
+			return err
+		}
+		return s.replaceItems(tx, &budget, req.Items)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetBudgetByID(budget.ID)
+}
+
+// GetBudgets - Daftar anggaran dengan filter tahun dan status
+func (s *BudgetService) GetBudgets(year int, status string) ([]models.Budget, error) {
+	var budgets []models.Budget
+	query := s.db.Preload("User")
+	if year > 0 {
+		query = query.Where("year = ?", year)
+	}
+	if status != "" {
+		query = query.Where("status = ?", strings.ToUpper(status))
+	}
+	err := query.Order("year DESC, created_at DESC").Find(&budgets).Error
+	return budgets, err
+}
+
+// GetBudgetByID - Detail anggaran beserta baris per akun
+func (s *BudgetService) GetBudgetByID(id uint) (*models.Budget, error) {
+	var budget models.Budget
+	err := s.db.Preload("User").Preload("Approver").
+		Preload("BudgetItems", func(db *gorm.DB) *gorm.DB {
+			return db.Order("account_id ASC, month ASC")
+		}).
+		Preload("BudgetItems.Account").
+		First(&budget, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &budget, nil
+}
+
+// UpdateBudget - Mengubah anggaran yang masih DRAFT
+func (s *BudgetService) UpdateBudget(id uint, req UpdateBudgetRequest) (*models.Budget, error) {
+	var budget models.Budget
+	if err := s.db.First(&budget, id).Error; err != nil {
+		return nil, errors.New("budget not found")
+	}
+	if budget.Status != models.BudgetStatusDraft {
+		return nil, fmt.Errorf("only DRAFT budgets can be modified, current status: %s", budget.Status)
+	}
+	if s.hasPendingApproval(id) {
+		return nil, errors.New("budget is waiting for approval and cannot be modified")
+	}
+	if err := s.validateItems(req.Items); err != nil {
+		return nil, err
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		updates := map[string]interface{}{}
+		if req.Name != "" {
+			updates["name"] = req.Name
+		}
+		if req.Description != "" {
+			updates["description"] = req.Description
+		}
+		if len(updates) > 0 {
+			if err := tx.Model(&budget).Updates(updates).Error; err != nil {
+				return err
+			}
+		}
+		if req.Items != nil {
+			return s.replaceItems(tx, &budget, req.Items)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetBudgetByID(id)
+}
+
+// DeleteBudget - Menghapus anggaran yang masih DRAFT
+func (s *BudgetService) DeleteBudget(id uint) error {
+	var budget models.Budget
+	if err := s.db.First(&budget, id).Error; err != nil {
+		return errors.New("budget not found")
+	}
+	if budget.Status != models.BudgetStatusDraft {
+		return fmt.Errorf("only DRAFT budgets can be deleted, current status: %s", budget.Status)
+	}
+	if s.hasPendingApproval(id) {
+		return errors.New("budget is waiting for approval and cannot be deleted")
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("budget_id = ?", id).Delete(&models.BudgetItem{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&budget).Error
+	})
+}
+
+// CopyFromPreviousYearActuals - Membuat anggaran DRAFT dari realisasi jurnal tahun sebelumnya
+func (s *BudgetService) CopyFromPreviousYearActuals(req CopyBudgetFromActualsRequest, userID uint) (*models.Budget, error) {
+	sourceYear := req.SourceYear
+	if sourceYear == 0 {
+		sourceYear = req.Year - 1
+	}
+
+	accountTypes := make([]string, 0, len(req.AccountTypes))
+	for _, accountType := range req.AccountTypes {
+		accountTypes = append(accountTypes, strings.ToUpper(accountType))
+	}
+	if len(accountTypes) == 0 {
+		accountTypes = []string{models.AccountTypeRevenue, models.AccountTypeExpense}
+	}
+
+	startDate := time.Date(sourceYear, 1, 1, 0, 0, 0, 0, time.Local)
+	endDate := time.Date(sourceYear, 12, 31, 23, 59, 59, 0, time.Local)
+
+	actuals, err := s.getMonthlyActuals(startDate, endDate, nil, accountTypes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load actuals for %d: %v", sourceYear, err)
+	}
+
+	factor := 1 + req.AdjustmentPercent/100
+	items := make([]BudgetItemRequest, 0, len(actuals))
+	for _, a := range actuals {
+		items = append(items, BudgetItemRequest{
+			AccountID:    a.AccountID,
+			Month:        a.Month,
+			BudgetAmount: math.Round(a.Amount*factor*100) / 100,
+			Notes:        fmt.Sprintf("Copied from %d actuals", sourceYear),
+		})
+	}
+
+	description := req.Description
+	if description == "" {
+		description = fmt.Sprintf("Based on %d actuals (adjustment %.2f%%)", sourceYear, req.AdjustmentPercent)
+	}
+
+	return s.CreateBudget(CreateBudgetRequest{
+		Name:        req.Name,
+		Description: description,
+		Year:        req.Year,
+		Items:       items,
+	}, userID)
+}
+
+// ========== WORKFLOW ==========
+
+// SubmitForApproval - Mengirim anggaran DRAFT ke ApprovalService
+func (s *BudgetService) SubmitForApproval(id uint, userID uint) (*models.ApprovalRequest, error) {
+	budget, err := s.GetBudgetByID(id)
+	if err != nil {
+		return nil, errors.New("budget not found")
+	}
+	if budget.Status != models.BudgetStatusDraft {
+		return nil, fmt.Errorf("only DRAFT budgets can be submitted, current status: %s", budget.Status)
+	}
+	if len(budget.BudgetItems) == 0 {
+		return nil, errors.New("budget has no items")
+	}
+
+	if s.hasPendingApproval(id) {
+		return nil, errors.New("budget already has a pending approval request")
+	}
+
+	return s.approvalService.CreateApprovalRequest(models.CreateApprovalRequestDTO{
+		EntityType:     models.EntityTypeBudget,
+		EntityID:       budget.ID,
+		Amount:         budget.TotalBudget,
+		RequestTitle:   fmt.Sprintf("Budget %s - %s (%d)", budget.Code, budget.Name, budget.Year),
+		RequestMessage: budget.Description,
+	}, userID)
+}
+
+// ActivateBudget - Mengaktifkan anggaran APPROVED; anggaran aktif lain di tahun yang sama ditutup
+// dengan realisasi terakhir, sama seperti CloseBudget
+func (s *BudgetService) ActivateBudget(id uint) (*models.Budget, error) {
+	var budget models.Budget
+	if err := s.db.First(&budget, id).Error; err != nil {
+		return nil, errors.New("budget not found")
+	}
+	if budget.Status != models.BudgetStatusApproved {
+		return nil, fmt.Errorf("only APPROVED budgets can be activated, current status: %s", budget.Status)
+	}
+
+	var activeIDs []uint
+	if err := s.db.Model(&models.Budget{}).
+		Where("year = ? AND status = ? AND id <> ?", budget.Year, models.BudgetStatusActive, budget.ID).
+		Pluck("id", &activeIDs).Error; err != nil {
+		return nil, err
+	}
+	superseded := make([]*models.Budget, 0, len(activeIDs))
+	for _, activeID := range activeIDs {
+		active, err := s.GetBudgetByID(activeID)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.loadActuals(active); err != nil {
+			return nil, err
+		}
+		superseded = append(superseded, active)
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		for _, active := range superseded {
+			if err := closeBudgetWithActuals(tx, active); err != nil {
+				return err
+			}
+		}
+		return tx.Model(&budget).Update("status", models.BudgetStatusActive).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetBudgetByID(id)
+}
+
+// CloseBudget - Menutup anggaran ACTIVE setelah realisasi terakhir dihitung
+func (s *BudgetService) CloseBudget(id uint) (*models.Budget, error) {
+	budget, err := s.GetBudgetByID(id)
+	if err != nil {
+		return nil, errors.New("budget not found")
+	}
+	if budget.Status != models.BudgetStatusActive {
+		return nil, fmt.Errorf("only ACTIVE budgets can be closed, current status: %s", budget.Status)
+	}
+
+	if err := s.loadActuals(budget); err != nil {
+		return nil, err
+	}
+	if err := s.db.Transaction(func(tx *gorm.DB) error {
+		return closeBudgetWithActuals(tx, budget)
+	}); err != nil {
+		return nil, err
+	}
+
+	return s.GetBudgetByID(id)
+}
+
+// closeBudgetWithActuals freezes the actuals loaded by loadActuals and marks the budget CLOSED
+func closeBudgetWithActuals(tx *gorm.DB, budget *models.Budget) error {
+	if err := saveBudgetActuals(tx, budget); err != nil {
+		return err
+	}
+	return tx.Model(&models.Budget{}).Where("id = ?", budget.ID).Update("status", models.BudgetStatusClosed).Error
+}
+
+// ========== ACTUALS & VARIANCE ==========
+
+// RefreshActuals - Menghitung ulang dan menyimpan ActualAmount, Variance dan VariancePercent dari
+// unified_journal_lines yang POSTED. Budget CLOSED tidak diubah karena angkanya sudah dibekukan saat ditutup.
+func (s *BudgetService) RefreshActuals(id uint) (*models.Budget, error) {
+	budget, err := s.GetBudgetByID(id)
+	if err != nil {
+		return nil, errors.New("budget not found")
+	}
+	if budget.Status == models.BudgetStatusClosed {
+		return nil, errors.New("CLOSED budgets keep the actuals frozen at closing and cannot be refreshed")
+	}
+
+	if err := s.loadActuals(budget); err != nil {
+		return nil, err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		return saveBudgetActuals(tx, budget)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetBudgetByID(id)
+}
+
+// saveBudgetActuals writes the actuals and variances filled in by loadActuals
+func saveBudgetActuals(tx *gorm.DB, budget *models.Budget) error {
+	for _, item := range budget.BudgetItems {
+		if err := tx.Model(&models.BudgetItem{}).Where("id = ?", item.ID).Updates(map[string]interface{}{
+			"actual_amount":    item.ActualAmount,
+			"variance":         item.Variance,
+			"variance_percent": item.VariancePercent,
+		}).Error; err != nil {
+			return err
+		}
+	}
+	return tx.Model(&models.Budget{}).Where("id = ?", budget.ID).Updates(map[string]interface{}{
+		"total_budget": budget.TotalBudget,
+		"total_actual": budget.TotalActual,
+		"variance":     budget.Variance,
+	}).Error
+}
+
+// loadActuals fills the actual amounts and variances of the budget and its items in memory from
+// POSTED unified journal lines, without writing anything
+func (s *BudgetService) loadActuals(budget *models.Budget) error {
+	accountIDs := budgetAccountIDs(budget.BudgetItems)
+	if len(accountIDs) == 0 {
+		return nil
+	}
+
+	startDate := time.Date(budget.Year, 1, 1, 0, 0, 0, 0, time.Local)
+	endDate := time.Date(budget.Year, 12, 31, 23, 59, 59, 0, time.Local)
+	actuals, err := s.getMonthlyActuals(startDate, endDate, accountIDs, nil)
+	if err != nil {
+		return fmt.Errorf("failed to load actuals: %v", err)
+	}
+
+	actualMap := make(map[string]float64, len(actuals))
+	for _, a := range actuals {
+		actualMap[budgetItemKey(a.AccountID, a.Month)] = a.Amount
+	}
+
+	var totalBudget, totalActual float64
+	for i := range budget.BudgetItems {
+		item := &budget.BudgetItems[i]
+		item.ActualAmount = actualMap[budgetItemKey(item.AccountID, item.Month)]
+		item.Variance, item.VariancePercent = calculateBudgetVariance(item.BudgetAmount, item.ActualAmount)
+		totalBudget += item.BudgetAmount
+		totalActual += item.ActualAmount
+	}
+	budget.TotalBudget = totalBudget
+	budget.TotalActual = totalActual
+	budget.Variance = totalActual - totalBudget
+	return nil
+}
+
+// GetBudgetVsActualReport - Laporan anggaran vs realisasi untuk bulan, kuartal, atau YTD
+// The report is read-only: open budgets use actuals computed on the fly, CLOSED budgets the figures frozen at closing.
+func (s *BudgetService) GetBudgetVsActualReport(id uint, period string, month, quarter int) (*BudgetVsActualReport, error) {
+	budget, err := s.GetBudgetByID(id)
+	if err != nil {
+		return nil, errors.New("budget not found")
+	}
+	if budget.Status != models.BudgetStatusClosed {
+		if err := s.loadActuals(budget); err != nil {
+			return nil, err
+		}
+	}
+
+	period = strings.ToUpper(period)
+	if period == "" {
+		period = BudgetReportPeriodYTD
+	}
+
+	var fromMonth, toMonth int
+	var label string
+	switch period {
+	case BudgetReportPeriodMonthly:
+		if month < 1 || month > 12 {
+			return nil, errors.New("month must be between 1 and 12")
+		}
+		fromMonth, toMonth = month, month
+		label = time.Month(month).String() + fmt.Sprintf(" %d", budget.Year)
+	case BudgetReportPeriodQuarterly:
+		if quarter < 1 || quarter > 4 {
+			return nil, errors.New("quarter must be between 1 and 4")
+		}
+		fromMonth, toMonth = (quarter-1)*3+1, quarter*3
+		label = fmt.Sprintf("Q%d %d", quarter, budget.Year)
+	case BudgetReportPeriodYTD:
+		if month < 1 || month > 12 {
+			month = 12
+			if budget.Year == time.Now().Year() {
+				month = int(time.Now().Month())
+			}
+		}
+		fromMonth, toMonth = 1, month
+		label = fmt.Sprintf("YTD %s %d", time.Month(month).String(), budget.Year)
+	default:
+		return nil, fmt.Errorf("invalid period %s, use MONTHLY, QUARTERLY or YTD", period)
+	}
+
+	report := &BudgetVsActualReport{
+		BudgetID:    budget.ID,
+		BudgetCode:  budget.Code,
+		BudgetName:  budget.Name,
+		Year:        budget.Year,
+		Period:      period,
+		PeriodLabel: label,
+		StartDate:   time.Date(budget.Year, time.Month(fromMonth), 1, 0, 0, 0, 0, time.Local),
+		EndDate:     time.Date(budget.Year, time.Month(toMonth)+1, 0, 0, 0, 0, 0, time.Local),
+		Lines:       []BudgetVsActualLine{},
+		GeneratedAt: time.Now(),
+	}
+
+	lineIndex := make(map[uint]int)
+	for _, item := range budget.BudgetItems {
+		if item.Month < fromMonth || item.Month > toMonth {
+			continue
+		}
+		idx, ok := lineIndex[item.AccountID]
+		if !ok {
+			report.Lines = append(report.Lines, BudgetVsActualLine{
+				AccountID:   item.AccountID,
+				AccountCode: item.Account.Code,
+				AccountName: item.Account.Name,
+				AccountType: item.Account.Type,
+			})
+			idx = len(report.Lines) - 1
+			lineIndex[item.AccountID] = idx
+		}
+		report.Lines[idx].BudgetAmount += item.BudgetAmount
+		report.Lines[idx].ActualAmount += item.ActualAmount
+	}
+
+	for i := range report.Lines {
+		line := &report.Lines[i]
+		line.Variance, line.VariancePercent = calculateBudgetVariance(line.BudgetAmount, line.ActualAmount)
+		// Revenue above budget is favorable, expense above budget is not
+		if strings.ToUpper(line.AccountType) == models.AccountTypeRevenue {
+			line.IsFavorable = line.Variance >= 0
+		} else {
+			line.IsFavorable = line.Variance <= 0
+		}
+		report.TotalBudget += line.BudgetAmount
+		report.TotalActual += line.ActualAmount
+	}
+	report.TotalVariance, report.VariancePercent = calculateBudgetVariance(report.TotalBudget, report.TotalActual)
+
+	return report, nil
+}
+
+// ExportBudgetVsActualExcel - Export laporan anggaran vs realisasi ke Excel
+func (s *BudgetService) ExportBudgetVsActualExcel(report *BudgetVsActualReport) ([]byte, error) {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	sheet := "Budget vs Actual"
+	index, err := f.NewSheet(sheet)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create sheet: %v", err)
+	}
+	f.SetActiveSheet(index)
+	f.DeleteSheet("Sheet1")
+
+	f.SetCellValue(sheet, "A1", fmt.Sprintf("Budget vs Actual - %s (%s)", report.BudgetName, report.BudgetCode))
+	f.SetCellValue(sheet, "A2", fmt.Sprintf("Period: %s (%s - %s)", report.PeriodLabel,
+		report.StartDate.Format("2006-01-02"), report.EndDate.Format("2006-01-02")))
+	f.SetCellValue(sheet, "A3", fmt.Sprintf("Generated on: %s", report.GeneratedAt.Format("2006-01-02 15:04:05")))
+
+	headers := []string{"Account Code", "Account Name", "Type", "Budget", "Actual", "Variance", "Variance %"}
+	for i, h := range headers {
+		cell, _ := excelize.CoordinatesToCellName(i+1, 5)
+		f.SetCellValue(sheet, cell, h)
+	}
+	headerStyle, _ := f.NewStyle(&excelize.Style{
+		Font: &excelize.Font{Bold: true},
+		Fill: excelize.Fill{Type: "pattern", Color: []string{"#D3D3D3"}, Pattern: 1},
+	})
+	f.SetCellStyle(sheet, "A5", "G5", headerStyle)
+
+	row := 6
+	for _, line := range report.Lines {
+		f.SetCellValue(sheet, fmt.Sprintf("A%d", row), line.AccountCode)
+		f.SetCellValue(sheet, fmt.Sprintf("B%d", row), line.AccountName)
+		f.SetCellValue(sheet, fmt.Sprintf("C%d", row), line.AccountType)
+		f.SetCellValue(sheet, fmt.Sprintf("D%d", row), line.BudgetAmount)
+		f.SetCellValue(sheet, fmt.Sprintf("E%d", row), line.ActualAmount)
+		f.SetCellValue(sheet, fmt.Sprintf("F%d", row), line.Variance)
+		f.SetCellValue(sheet, fmt.Sprintf("G%d", row), line.VariancePercent)
+		row++
+	}
+
+	totalStyle, _ := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	f.SetCellValue(sheet, fmt.Sprintf("A%d", row), "TOTAL")
+	f.SetCellValue(sheet, fmt.Sprintf("D%d", row), report.TotalBudget)
+	f.SetCellValue(sheet, fmt.Sprintf("E%d", row), report.TotalActual)
+	f.SetCellValue(sheet, fmt.Sprintf("F%d", row), report.TotalVariance)
+	f.SetCellValue(sheet, fmt.Sprintf("G%d", row), report.VariancePercent)
+	f.SetCellStyle(sheet, fmt.Sprintf("A%d", row), fmt.Sprintf("G%d", row), totalStyle)
+
+	numberStyle, _ := f.NewStyle(&excelize.Style{NumFmt: 4})
+	f.SetCellStyle(sheet, "D6", fmt.Sprintf("F%d", row), numberStyle)
+	f.SetColWidth(sheet, "A", "A", 14)
+	f.SetColWidth(sheet, "B", "B", 40)
+	f.SetColWidth(sheet, "C", "C", 12)
+	f.SetColWidth(sheet, "D", "G", 18)
+
+	var buf bytes.Buffer
+	if err := f.Write(&buf); err != nil {
+		return nil, fmt.Errorf("failed to write excel: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// ========== HELPERS ==========
+
+type budgetMonthlyActual struct {
+	AccountID uint    `json:"account_id"`
+	Month     int     `json:"month"`
+	Amount    float64 `json:"amount"`
+}
+
+// getMonthlyActuals - Realisasi per akun per bulan dari jurnal POSTED (tanpa jurnal CLOSING), sesuai saldo normal akun
+func (s *BudgetService) getMonthlyActuals(startDate, endDate time.Time, accountIDs []uint, accountTypes []string) ([]budgetMonthlyActual, error) {
+	query := `
+		SELECT
+			a.id AS account_id,
+			CAST(EXTRACT(MONTH FROM uje.entry_date) AS INTEGER) AS month,
+			CASE
+				WHEN UPPER(a.type) IN ('ASSET', 'EXPENSE') THEN
+					COALESCE(SUM(ujl.debit_amount), 0) - COALESCE(SUM(ujl.credit_amount), 0)
+				ELSE
+					COALESCE(SUM(ujl.credit_amount), 0) - COALESCE(SUM(ujl.debit_amount), 0)
+			END AS amount
+		FROM unified_journal_lines ujl
+		JOIN unified_journal_ledger uje ON uje.id = ujl.journal_id
+		JOIN accounts a ON a.id = ujl.account_id
+		WHERE uje.status = 'POSTED'
+		  AND uje.deleted_at IS NULL
+		  AND UPPER(uje.source_type) <> 'CLOSING'
+		  AND uje.entry_date >= ? AND uje.entry_date <= ?
+		  AND COALESCE(a.is_header, false) = false
+	`
+	args := []interface{}{startDate, endDate}
+	if len(accountIDs) > 0 {
+		query += " AND a.id IN ?"
+		args = append(args, accountIDs)
+	}
+	if len(accountTypes) > 0 {
+		query += " AND UPPER(a.type) IN ?"
+		args = append(args, accountTypes)
+	}
+	query += `
+		GROUP BY a.id, a.type, EXTRACT(MONTH FROM uje.entry_date)
+		ORDER BY a.id, month
+	`
+
+	var rows []budgetMonthlyActual
+	if err := s.db.Raw(query, args...).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+func (s *BudgetService) hasPendingApproval(budgetID uint) bool {
+	var pending int64
+	s.db.Model(&models.ApprovalRequest{}).
+		Where("entity_type = ? AND entity_id = ? AND status = ?", models.EntityTypeBudget, budgetID, models.ApprovalStatusPending).
+		Count(&pending)
+	return pending > 0
+}
+
+func (s *BudgetService) validateItems(items []BudgetItemRequest) error {
+	seen := make(map[string]bool, len(items))
+	accountIDs := make([]uint, 0, len(items))
+	for _, item := range items {
+		if item.Month < 1 || item.Month > 12 {
+			return fmt.Errorf("invalid month %d for account %d", item.Month, item.AccountID)
+		}
+		key := budgetItemKey(item.AccountID, item.Month)
+		if seen[key] {
+			return fmt.Errorf("duplicate budget line for account %d month %d", item.AccountID, item.Month)
+		}
+		seen[key] = true
+		accountIDs = append(accountIDs, item.AccountID)
+	}
+	if len(accountIDs) == 0 {
+		return nil
+	}
+
+	var headerCount, foundCount int64
+	s.db.Model(&models.Account{}).Where("id IN ?", accountIDs).Distinct("id").Count(&foundCount)
+	s.db.Model(&models.Account{}).Where("id IN ? AND is_header = ?", accountIDs, true).Count(&headerCount)
+	if int(foundCount) != len(uniqueUints(accountIDs)) {
+		return errors.New("one or more accounts not found")
+	}
+	if headerCount > 0 {
+		return errors.New("header accounts cannot be budgeted")
+	}
+	return nil
+}
+
+func (s *BudgetService) replaceItems(tx *gorm.DB, budget *models.Budget, items []BudgetItemRequest) error {
+	if err := tx.Unscoped().Where("budget_id = ?", budget.ID).Delete(&models.BudgetItem{}).Error; err != nil {
+		return err
+	}
+
+	var total float64
+	for _, req := range items {
+		item := models.BudgetItem{
+			BudgetID:     budget.ID,
+			AccountID:    req.AccountID,
+			Month:        req.Month,
+			BudgetAmount: req.BudgetAmount,
+			Notes:        req.Notes,
+		}
+		if err := tx.Create(&item).Error; err != nil {
+			return err
+		}
+		total += req.BudgetAmount
+	}
+
+	budget.TotalBudget = total
+	return tx.Model(budget).Updates(map[string]interface{}{
+		"total_budget": total,
+		"variance":     budget.TotalActual - total,
+	}).Error
+}
+
+func (s *BudgetService) generateBudgetCode(year int) (string, error) {
+	var count int64
+	if err := s.db.Unscoped().Model(&models.Budget{}).Where("year = ?", year).Count(&count).Error; err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("BGT-%d-%03d", year, count+1), nil
+}
+
+// calculateBudgetVariance returns actual - budget and the variance as a percentage of budget,
+// clamped to the decimal(5,2) range of budget_items.variance_percent
+func calculateBudgetVariance(budget, actual float64) (float64, float64) {
+	variance := actual - budget
+	if budget == 0 {
+		return variance, 0
+	}
+	percent := math.Round(variance/math.Abs(budget)*10000) / 100
+	return variance, math.Max(-999.99, math.Min(999.99, percent))
+}
+
+func budgetItemKey(accountID uint, month int) string {
+	return fmt.Sprintf("%d-%d", accountID, month)
+}
+
+func budgetAccountIDs(items []models.BudgetItem) []uint {
+	ids := make([]uint, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, item.AccountID)
+	}
+	return uniqueUints(ids)
+}
+
+func uniqueUints(values []uint) []uint {
+	seen := make(map[uint]bool, len(values))
+	result := make([]uint, 0, len(values))
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			result = append(result, v)
+		}
+	}
+	return result
+}
@@ -0,0 +1,36 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalculateBudgetVariance(t *testing.T) {
+	tests := []struct {
+		name         string
+		budget       float64
+		actual       float64
+		wantVariance float64
+		wantPercent  float64
+	}{
+		{name: "on budget", budget: 1000, actual: 1000, wantVariance: 0, wantPercent: 0},
+		{name: "over budget", budget: 1000, actual: 1250, wantVariance: 250, wantPercent: 25},
+		{name: "under budget", budget: 1000, actual: 750, wantVariance: -250, wantPercent: -25},
+		{name: "rounds down to two decimals", budget: 3, actual: 4, wantVariance: 1, wantPercent: 33.33},
+		{name: "rounds up to two decimals", budget: 3, actual: 5, wantVariance: 2, wantPercent: 66.67},
+		{name: "rounds a tiny variance", budget: 1000000, actual: 1000050, wantVariance: 50, wantPercent: 0.01},
+		{name: "negative budget uses its absolute value", budget: -1000, actual: -800, wantVariance: 200, wantPercent: 20},
+		{name: "zero budget has no percentage", budget: 0, actual: 500, wantVariance: 500, wantPercent: 0},
+		{name: "clamped to decimal(5,2) upper bound", budget: 10, actual: 5000, wantVariance: 4990, wantPercent: 999.99},
+		{name: "clamped to decimal(5,2) lower bound", budget: -10, actual: -5000, wantVariance: -4990, wantPercent: -999.99},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			variance, percent := calculateBudgetVariance(tt.budget, tt.actual)
+			assert.InDelta(t, tt.wantVariance, variance, 0.000001)
+			assert.Equal(t, tt.wantPercent, percent)
+		})
+	}
+}
@@ -65,6 +65,15 @@ func (s *CashFlowExportService) ExportToCSV(data *SSOTCashFlowData, userID uint)
 	headers := utils.GetCSVHeaders("cash_flow", language)
 	writer.Write(headers)
 
+	if data.DirectMethod != nil {
+		s.writeDirectMethodCSV(writer, data)
+		writer.Flush()
+		if err := writer.Error(); err != nil {
+			return nil, fmt.Errorf("failed to write CSV: %v", err)
+		}
+		return buf.Bytes(), nil
+	}
+
 	// Operating Activities with localization
 	writer.Write([]string{utils.T("operating_activities", language), "", "", "", "", ""})
 	
@@ -289,7 +298,11 @@ func (s *CashFlowExportService) ExportToPDF(data *SSOTCashFlowData) ([]byte, err
 	pdf.SetX(lm)
 	pdf.SetFont("Arial", "B", 22)
 	pdf.SetTextColor(51,51,51)
-	pdf.Cell(contentW, 10, "CASH FLOW STATEMENT")
+	title := "CASH FLOW STATEMENT"
+	if data.DirectMethod != nil {
+		title = "CASH FLOW STATEMENT (DIRECT METHOD)"
+	}
+	pdf.Cell(contentW, 10, title)
 	pdf.SetTextColor(0,0,0)
 	pdf.Ln(12)
 
@@ -324,6 +337,15 @@ func (s *CashFlowExportService) ExportToPDF(data *SSOTCashFlowData) ([]byte, err
 	pdf.SetFont("Arial", "", 8)
 	pdf.SetFillColor(255, 255, 255)
 
+	if data.DirectMethod != nil {
+		s.writeDirectMethodPDF(pdf, data)
+		var buf bytes.Buffer
+		if err := pdf.Output(&buf); err != nil {
+			return nil, fmt.Errorf("failed to generate PDF: %v", err)
+		}
+		return buf.Bytes(), nil
+	}
+
 	// Operating Activities
 	if data.OperatingActivities.NetIncome != 0 || 
 		len(data.OperatingActivities.Adjustments.Items) > 0 || 
@@ -517,6 +539,133 @@ func (s *CashFlowExportService) ExportToPDF(data *SSOTCashFlowData) ([]byte, err
 }
 
 
+// writeDirectMethodCSV writes the direct-method activities and the reconciliation to the indirect method
+func (s *CashFlowExportService) writeDirectMethodCSV(writer *csv.Writer, data *SSOTCashFlowData) {
+	direct := data.DirectMethod
+
+	writeSection := func(activity, title string, items []CFDirectItem, total float64) {
+		writer.Write([]string{title, "", "", "", "", ""})
+		for _, item := range items {
+			writer.Write([]string{
+				activity,
+				DirectCashFlowCategoryLabel(item.Category),
+				item.AccountCode,
+				item.AccountName,
+				s.formatAmount(item.Amount),
+				item.Type,
+			})
+		}
+		writer.Write([]string{activity, "Net cash from " + strings.ToLower(title), "", "", s.formatAmount(total), "total"})
+		writer.Write([]string{})
+	}
+
+	op := direct.OperatingActivities
+	writer.Write([]string{"Operating Activities (Direct Method)", "", "", "", "", ""})
+	for _, line := range []struct {
+		category string
+		amount   float64
+	}{
+		{CFDirectReceiptsFromCustomers, op.ReceiptsFromCustomers},
+		{CFDirectPaymentsToSuppliers, op.PaymentsToSuppliers},
+		{CFDirectPaymentsToEmployees, op.PaymentsToEmployees},
+		{CFDirectTaxesPaid, op.TaxesPaid},
+		{CFDirectOtherOperating, op.OtherOperating},
+	} {
+		writer.Write([]string{"Operating", DirectCashFlowCategoryLabel(line.category), "", "", s.formatAmount(line.amount), "subtotal"})
+	}
+	writeSection("Operating", "Operating Activities", op.Items, op.TotalOperatingCashFlow)
+	writeSection("Investing", "Investing Activities", direct.InvestingActivities.Items, direct.InvestingActivities.TotalInvestingCashFlow)
+	writeSection("Financing", "Financing Activities", direct.FinancingActivities.Items, direct.FinancingActivities.TotalFinancingCashFlow)
+
+	writer.Write([]string{"Summary", "", "", "", "", ""})
+	writer.Write([]string{"Summary", "Cash at Beginning of Period", "", "", s.formatAmount(direct.CashAtBeginning), "summary"})
+	writer.Write([]string{"Summary", "Net Cash Flow", "", "", s.formatAmount(direct.NetCashFlow), "summary"})
+	writer.Write([]string{"Summary", "Cash at End of Period", "", "", s.formatAmount(direct.CashAtEnd), "summary"})
+	writer.Write([]string{})
+
+	rec := direct.Reconciliation
+	writer.Write([]string{"Reconciliation to Indirect Method", "", "", "", "", ""})
+	writer.Write([]string{"Reconciliation", "Net Income", "", "", s.formatAmount(rec.NetIncome), "base"})
+	writer.Write([]string{"Reconciliation", "Adjustments for Non-Cash Items", "", "", s.formatAmount(rec.TotalAdjustments), "subtotal"})
+	writer.Write([]string{"Reconciliation", "Changes in Working Capital", "", "", s.formatAmount(rec.TotalWorkingCapitalChanges), "subtotal"})
+	writer.Write([]string{"Reconciliation", "Net Cash from Operating Activities (Indirect)", "", "", s.formatAmount(rec.IndirectOperatingCashFlow), "total"})
+	writer.Write([]string{"Reconciliation", "Net Cash from Operating Activities (Direct)", "", "", s.formatAmount(rec.DirectOperatingCashFlow), "total"})
+	writer.Write([]string{"Reconciliation", "Difference", "", "", s.formatAmount(rec.OperatingDifference), "difference"})
+}
+
+// writeDirectMethodPDF renders the direct-method activities and the reconciliation to the indirect method
+func (s *CashFlowExportService) writeDirectMethodPDF(pdf *gofpdf.Fpdf, data *SSOTCashFlowData) {
+	direct := data.DirectMethod
+
+	sectionHeader := func(title string) {
+		pdf.SetFont("Arial", "B", 9)
+		pdf.SetFillColor(240, 240, 240)
+		pdf.CellFormat(190, 6, title, "1", 0, "L", true, 0, "")
+		pdf.Ln(6)
+		pdf.SetFont("Arial", "", 8)
+		pdf.SetFillColor(255, 255, 255)
+	}
+	row := func(activity, description string, amount float64) {
+		pdf.CellFormat(25, 5, activity, "1", 0, "C", false, 0, "")
+		pdf.CellFormat(75, 5, description, "1", 0, "L", false, 0, "")
+		pdf.CellFormat(45, 5, s.formatAmountAsRupiah(amount), "1", 0, "R", false, 0, "")
+		pdf.CellFormat(45, 5, "", "1", 0, "R", false, 0, "")
+		pdf.Ln(5)
+	}
+	total := func(title string, amount float64) {
+		pdf.SetFont("Arial", "B", 9)
+		pdf.SetFillColor(240, 240, 240)
+		pdf.CellFormat(145, 6, title, "1", 0, "R", true, 0, "")
+		pdf.CellFormat(45, 6, s.formatAmountAsRupiah(amount), "1", 0, "R", true, 0, "")
+		pdf.Ln(8)
+	}
+	items := func(activity string, list []CFDirectItem, empty string) {
+		if len(list) == 0 {
+			row(activity, empty, 0)
+			return
+		}
+		for _, item := range list {
+			row(activity, fmt.Sprintf("%s - %s", item.AccountCode, item.AccountName), item.Amount)
+		}
+	}
+
+	op := direct.OperatingActivities
+	sectionHeader("OPERATING ACTIVITIES")
+	row("Operating", DirectCashFlowCategoryLabel(CFDirectReceiptsFromCustomers), op.ReceiptsFromCustomers)
+	row("Operating", DirectCashFlowCategoryLabel(CFDirectPaymentsToSuppliers), op.PaymentsToSuppliers)
+	row("Operating", DirectCashFlowCategoryLabel(CFDirectPaymentsToEmployees), op.PaymentsToEmployees)
+	row("Operating", DirectCashFlowCategoryLabel(CFDirectTaxesPaid), op.TaxesPaid)
+	row("Operating", DirectCashFlowCategoryLabel(CFDirectOtherOperating), op.OtherOperating)
+	total("NET CASH FROM OPERATING ACTIVITIES", op.TotalOperatingCashFlow)
+
+	sectionHeader("INVESTING ACTIVITIES")
+	items("Investing", direct.InvestingActivities.Items, "No investing activities")
+	total("NET CASH FROM INVESTING ACTIVITIES", direct.InvestingActivities.TotalInvestingCashFlow)
+
+	sectionHeader("FINANCING ACTIVITIES")
+	items("Financing", direct.FinancingActivities.Items, "No financing activities")
+	total("NET CASH FROM FINANCING ACTIVITIES", direct.FinancingActivities.TotalFinancingCashFlow)
+
+	sectionHeader("NET CASH FLOW SUMMARY")
+	row("Summary", "Cash at Beginning of Period", direct.CashAtBeginning)
+	row("Summary", "Net Cash Flow from Activities", direct.NetCashFlow)
+	total("Cash at End of Period", direct.CashAtEnd)
+
+	rec := direct.Reconciliation
+	sectionHeader("RECONCILIATION TO INDIRECT METHOD")
+	row("Indirect", "Net Income", rec.NetIncome)
+	row("Indirect", "Adjustments for Non-Cash Items", rec.TotalAdjustments)
+	row("Indirect", "Changes in Working Capital", rec.TotalWorkingCapitalChanges)
+	row("Indirect", "Net Cash from Operating Activities (Indirect)", rec.IndirectOperatingCashFlow)
+	row("Direct", "Net Cash from Operating Activities (Direct)", rec.DirectOperatingCashFlow)
+	total("DIFFERENCE", rec.OperatingDifference)
+
+	// Footer
+	pdf.Ln(10)
+	pdf.SetFont("Arial", "I", 8)
+	pdf.Cell(190, 4, fmt.Sprintf("Report generated on %s", time.Now().Format("02/01/2006 15:04")))
+}
+
 // formatAmount formats amount for CSV export
 func (s *CashFlowExportService) formatAmount(amount float64) string {
 	// Format with thousand separators and 2 decimal places
@@ -596,6 +745,11 @@ func (s *CashFlowExportService) formatAmountAsRupiah(amount float64) string {
 
 // GetCSVFilename generates appropriate filename for CSV export
 func (s *CashFlowExportService) GetCSVFilename(data *SSOTCashFlowData) string {
+	if data.DirectMethod != nil {
+		return fmt.Sprintf("cash_flow_direct_%s_to_%s.csv",
+			data.StartDate.Format("2006-01-02"),
+			data.EndDate.Format("2006-01-02"))
+	}
 	return fmt.Sprintf("cash_flow_%s_to_%s.csv",
 		data.StartDate.Format("2006-01-02"),
 		data.EndDate.Format("2006-01-02"))
@@ -603,6 +757,11 @@ func (s *CashFlowExportService) GetCSVFilename(data *SSOTCashFlowData) string {
 
 // GetPDFFilename generates appropriate filename for PDF export
 func (s *CashFlowExportService) GetPDFFilename(data *SSOTCashFlowData) string {
+	if data.DirectMethod != nil {
+		return fmt.Sprintf("cash_flow_direct_%s_to_%s.pdf",
+			data.StartDate.Format("2006-01-02"),
+			data.EndDate.Format("2006-01-02"))
+	}
 	return fmt.Sprintf("cash_flow_%s_to_%s.pdf",
 		data.StartDate.Format("2006-01-02"),
 		data.EndDate.Format("2006-01-02"))
@@ -59,6 +59,26 @@ func (s *CashBankService) GetCashBankAccounts() ([]models.CashBank, error) {
 	return s.cashBankRepo.FindAll()
 }
 
+// GetCashBankAccountsInScope retrieves the cash/bank accounts the user's data scope allows
+func (s *CashBankService) GetCashBankAccountsInScope(scope *models.EffectiveDataScope) ([]models.CashBank, error) {
+	return s.cashBankRepo.FindAllInScope(scope)
+}
+
+// AccountsInScope reports whether every given cash/bank account lies inside the user's data scope;
+// deposits, withdrawals and transfers name their accounts in the body, so routes cannot check them
+func (s *CashBankService) AccountsInScope(scope *models.EffectiveDataScope, accountIDs ...uint) bool {
+	if !scope.IsRestricted() {
+		return true
+	}
+	for _, id := range accountIDs {
+		account, err := s.cashBankRepo.FindByID(id)
+		if err != nil || !scope.AllowsCashBank(account) {
+			return false
+		}
+	}
+	return true
+}
+
 // GetCashBankByID retrieves cash/bank account by ID
 func (s *CashBankService) GetCashBankByID(id uint) (*models.CashBank, error) {
 	return s.cashBankRepo.FindByID(id)
@@ -808,7 +828,12 @@ func (s *CashBankService) GetBalanceSummary() (*BalanceSummary, error) {
 
 // GetPaymentAccounts gets active cash and bank accounts for payment processing
 func (s *CashBankService) GetPaymentAccounts() ([]models.CashBank, error) {
-	accounts, err := s.cashBankRepo.FindAll()
+	return s.GetPaymentAccountsInScope(nil)
+}
+
+// GetPaymentAccountsInScope retrieves active payment accounts limited to the user's data scope
+func (s *CashBankService) GetPaymentAccountsInScope(scope *models.EffectiveDataScope) ([]models.CashBank, error) {
+	accounts, err := s.cashBankRepo.FindAllInScope(scope)
 	if err != nil {
 		return nil, err
 	}
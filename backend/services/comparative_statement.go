@@ -0,0 +1,457 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// Comparative statements put several periods (or actual and budget) of the same SSOT financial
+// statement side by side. Each column is computed with the regular statement generator and the
+// rows are merged by section and account code, so an account that only moved in one period still
+// gets a row with zeros in the other columns.
+
+// Comparative statement types
+const (
+	ComparativeStatementProfitLoss   = "PROFIT_LOSS"
+	ComparativeStatementBalanceSheet = "BALANCE_SHEET"
+	ComparativeStatementCashFlow     = "CASH_FLOW"
+)
+
+// Comparative column types
+const (
+	ComparativeColumnActual = "ACTUAL"
+	ComparativeColumnBudget = "BUDGET"
+)
+
+// Comparative column presets
+const (
+	ComparativePresetMonthly    = "MONTHLY"     // every month of a year, each compared with the previous month
+	ComparativePresetQuarterYoY = "QUARTER_YOY" // a quarter vs the same quarter last year
+	ComparativePresetYTDYoY     = "YTD_YOY"     // year to date vs prior year to date
+	ComparativePresetBudget     = "BUDGET"      // actual vs budget (profit and loss only)
+)
+
+// maxComparativeColumns keeps a request from generating an unbounded number of statements
+const maxComparativeColumns = 24
+
+// ComparativeColumn defines one column of a comparative statement. The balance sheet only uses
+// EndDate (as-of date). Budget columns cover whole months of a single budget year. When CompareTo
+// names another column, every row carries the variance of this column against it.
+type ComparativeColumn struct {
+	Key       string `json:"key"`
+	Label     string `json:"label"`
+	Type      string `json:"type"` // ACTUAL (default) or BUDGET
+	StartDate string `json:"start_date,omitempty"`
+	EndDate   string `json:"end_date"`
+	BudgetID  uint   `json:"budget_id,omitempty"` // BUDGET only, defaults to the active budget of the year
+	CompareTo string `json:"compare_to,omitempty"`
+}
+
+// ComparativeRequest selects the columns either from a preset or as an explicit list
+type ComparativeRequest struct {
+	Preset   string              `json:"preset" form:"preset"`
+	Year     int                 `json:"year" form:"year"`
+	Quarter  int                 `json:"quarter" form:"quarter"`
+	Month    int                 `json:"month" form:"month"`
+	BudgetID uint                `json:"budget_id" form:"budget_id"`
+	Columns  []ComparativeColumn `json:"columns"`
+}
+
+// ComparativeVariance is the difference between a column and the column it is compared to.
+// Percent is nil when the base amount is zero.
+type ComparativeVariance struct {
+	ColumnKey string   `json:"column_key"`
+	BaseKey   string   `json:"base_key"`
+	Amount    float64  `json:"amount"`
+	Percent   *float64 `json:"percent"`
+}
+
+// ComparativeRow is an account line or a (sub)total with one value per column
+type ComparativeRow struct {
+	Key         string                `json:"key"`
+	Section     string                `json:"section"`
+	AccountCode string                `json:"account_code,omitempty"`
+	Label       string                `json:"label"`
+	IsTotal     bool                  `json:"is_total"`
+	Values      []float64             `json:"values"`
+	Variances   []ComparativeVariance `json:"variances,omitempty"`
+}
+
+// ComparativeStatement is a multi-column financial statement
+type ComparativeStatement struct {
+	Statement   string              `json:"statement"`
+	Title       string              `json:"title"`
+	Company     CompanyInfo         `json:"company"`
+	Currency    string              `json:"currency"`
+	Columns     []ComparativeColumn `json:"columns"`
+	Rows        []ComparativeRow    `json:"rows"`
+	GeneratedAt time.Time           `json:"generated_at"`
+}
+
+// BuildComparativeColumns returns the explicit columns of the request, or the columns of its preset
+func BuildComparativeColumns(req ComparativeRequest, statement string) ([]ComparativeColumn, error) {
+	if len(req.Columns) > 0 {
+		return prepareComparativeColumns(req.Columns, statement)
+	}
+
+	now := time.Now()
+	year := req.Year
+	if year == 0 {
+		year = now.Year()
+	}
+	ytdMonth := req.Month
+	if ytdMonth < 1 || ytdMonth > 12 {
+		ytdMonth = 12
+		if year == now.Year() {
+			ytdMonth = int(now.Month())
+		}
+	}
+
+	var columns []ComparativeColumn
+	switch strings.ToUpper(strings.TrimSpace(req.Preset)) {
+	case ComparativePresetMonthly:
+		lastMonth := 12
+		if year == now.Year() {
+			lastMonth = int(now.Month())
+		}
+		for m := 1; m <= lastMonth; m++ {
+			column := comparativePeriodColumn(fmt.Sprintf("m%02d", m), time.Date(year, time.Month(m), 1, 0, 0, 0, 0, time.UTC), 1)
+			if m > 1 {
+				column.CompareTo = fmt.Sprintf("m%02d", m-1)
+			}
+			columns = append(columns, column)
+		}
+	case ComparativePresetQuarterYoY:
+		if req.Quarter < 1 || req.Quarter > 4 {
+			return nil, errors.New("quarter must be between 1 and 4")
+		}
+		firstMonth := time.Month((req.Quarter-1)*3 + 1)
+		current := comparativePeriodColumn("current", time.Date(year, firstMonth, 1, 0, 0, 0, 0, time.UTC), 3)
+		prior := comparativePeriodColumn("prior", time.Date(year-1, firstMonth, 1, 0, 0, 0, 0, time.UTC), 3)
+		current.Label = fmt.Sprintf("Q%d %d", req.Quarter, year)
+		prior.Label = fmt.Sprintf("Q%d %d", req.Quarter, year-1)
+		current.CompareTo = prior.Key
+		columns = []ComparativeColumn{current, prior}
+	case ComparativePresetYTDYoY:
+		current := comparativePeriodColumn("current", time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC), ytdMonth)
+		prior := comparativePeriodColumn("prior", time.Date(year-1, 1, 1, 0, 0, 0, 0, time.UTC), ytdMonth)
+		current.Label = fmt.Sprintf("YTD %s %d", time.Month(ytdMonth).String()[:3], year)
+		prior.Label = fmt.Sprintf("YTD %s %d", time.Month(ytdMonth).String()[:3], year-1)
+		current.CompareTo = prior.Key
+		columns = []ComparativeColumn{current, prior}
+	case ComparativePresetBudget:
+		var actual ComparativeColumn
+		switch {
+		case req.Quarter >= 1 && req.Quarter <= 4:
+			actual = comparativePeriodColumn("actual", time.Date(year, time.Month((req.Quarter-1)*3+1), 1, 0, 0, 0, 0, time.UTC), 3)
+		case req.Month >= 1 && req.Month <= 12:
+			actual = comparativePeriodColumn("actual", time.Date(year, time.Month(req.Month), 1, 0, 0, 0, 0, time.UTC), 1)
+		default:
+			actual = comparativePeriodColumn("actual", time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC), ytdMonth)
+		}
+		budget := actual
+		budget.Key = "budget"
+		budget.Type = ComparativeColumnBudget
+		budget.BudgetID = req.BudgetID
+		actual.Label = "Actual " + actual.Label
+		budget.Label = "Budget " + budget.Label
+		actual.CompareTo = budget.Key
+		columns = []ComparativeColumn{actual, budget}
+	case "":
+		return nil, errors.New("either preset or columns is required")
+	default:
+		return nil, fmt.Errorf("invalid preset %s, use MONTHLY, QUARTER_YOY, YTD_YOY or BUDGET", req.Preset)
+	}
+
+	return prepareComparativeColumns(columns, statement)
+}
+
+// comparativePeriodColumn is an actual column covering months whole months from start
+func comparativePeriodColumn(key string, start time.Time, months int) ComparativeColumn {
+	end := start.AddDate(0, months, -1)
+	label := start.Format("Jan 2006")
+	if months > 1 {
+		label = start.Format("Jan") + " - " + end.Format("Jan 2006")
+	}
+	return ComparativeColumn{
+		Key:       key,
+		Label:     label,
+		Type:      ComparativeColumnActual,
+		StartDate: start.Format("2006-01-02"),
+		EndDate:   end.Format("2006-01-02"),
+	}
+}
+
+// prepareComparativeColumns fills in defaults and validates dates, types and variance references
+func prepareComparativeColumns(columns []ComparativeColumn, statement string) ([]ComparativeColumn, error) {
+	if len(columns) == 0 {
+		return nil, errors.New("at least one column is required")
+	}
+	if len(columns) > maxComparativeColumns {
+		return nil, fmt.Errorf("at most %d columns are allowed", maxComparativeColumns)
+	}
+
+	prepared := make([]ComparativeColumn, len(columns))
+	keys := make(map[string]bool, len(columns))
+	for i, column := range columns {
+		column.Key = strings.TrimSpace(column.Key)
+		if column.Key == "" {
+			column.Key = fmt.Sprintf("c%d", i+1)
+		}
+		if keys[column.Key] {
+			return nil, fmt.Errorf("duplicate column key %s", column.Key)
+		}
+		keys[column.Key] = true
+
+		column.Type = strings.ToUpper(strings.TrimSpace(column.Type))
+		if column.Type == "" {
+			column.Type = ComparativeColumnActual
+		}
+		if column.Type != ComparativeColumnActual && column.Type != ComparativeColumnBudget {
+			return nil, fmt.Errorf("column %s: invalid type %s, use ACTUAL or BUDGET", column.Key, column.Type)
+		}
+		if column.Type == ComparativeColumnBudget && statement != ComparativeStatementProfitLoss {
+			return nil, fmt.Errorf("column %s: budget columns are only available for the profit and loss statement", column.Key)
+		}
+
+		end, err := time.Parse("2006-01-02", column.EndDate)
+		if err != nil {
+			return nil, fmt.Errorf("column %s: invalid end_date, use YYYY-MM-DD", column.Key)
+		}
+		if statement == ComparativeStatementBalanceSheet {
+			column.StartDate = ""
+		} else {
+			start, err := time.Parse("2006-01-02", column.StartDate)
+			if err != nil {
+				return nil, fmt.Errorf("column %s: invalid start_date, use YYYY-MM-DD", column.Key)
+			}
+			if end.Before(start) {
+				return nil, fmt.Errorf("column %s: end_date must not be before start_date", column.Key)
+			}
+			if column.Type == ComparativeColumnBudget && start.Year() != end.Year() {
+				return nil, fmt.Errorf("column %s: a budget column must stay within one year", column.Key)
+			}
+		}
+		if column.Label == "" {
+			column.Label = column.EndDate
+			if column.StartDate != "" {
+				column.Label = column.StartDate + " - " + column.EndDate
+			}
+		}
+		prepared[i] = column
+	}
+
+	for _, column := range prepared {
+		if column.CompareTo == "" {
+			continue
+		}
+		if column.CompareTo == column.Key || !keys[column.CompareTo] {
+			return nil, fmt.Errorf("column %s: compare_to must name another column", column.Key)
+		}
+	}
+	return prepared, nil
+}
+
+// comparativeBuilder merges the rows of the per-column statements
+type comparativeBuilder struct {
+	columns []ComparativeColumn
+	rows    map[string]*comparativeBuilderRow
+	company CompanyInfo
+}
+
+type comparativeBuilderRow struct {
+	order string
+	row   ComparativeRow
+}
+
+func newComparativeBuilder(columns []ComparativeColumn) *comparativeBuilder {
+	return &comparativeBuilder{
+		columns: columns,
+		rows:    make(map[string]*comparativeBuilderRow),
+	}
+}
+
+// account adds an account line. Rows are ordered by section order, account lines before the
+// section total, then account code.
+func (b *comparativeBuilder) account(column, order int, section, code, name string, amount float64) {
+	key := section + ":" + code
+	b.set(key, fmt.Sprintf("%03d|0|%s", order, code), ComparativeRow{
+		Key:         key,
+		Section:     section,
+		AccountCode: code,
+		Label:       name,
+	}, column, amount)
+}
+
+// total adds a subtotal or total line closing the section with the given order
+func (b *comparativeBuilder) total(column, order int, section, key, label string, amount float64) {
+	b.set(key, fmt.Sprintf("%03d|1|%s", order, key), ComparativeRow{
+		Key:     key,
+		Section: section,
+		Label:   label,
+		IsTotal: true,
+	}, column, amount)
+}
+
+func (b *comparativeBuilder) set(key, order string, row ComparativeRow, column int, amount float64) {
+	entry, ok := b.rows[key]
+	if !ok {
+		row.Values = make([]float64, len(b.columns))
+		entry = &comparativeBuilderRow{order: order, row: row}
+		b.rows[key] = entry
+	}
+	entry.row.Values[column] = roundAmount(entry.row.Values[column] + amount)
+}
+
+func (b *comparativeBuilder) build(statement, title string) *ComparativeStatement {
+	entries := make([]*comparativeBuilderRow, 0, len(b.rows))
+	for _, entry := range b.rows {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].order < entries[j].order })
+
+	columnIndex := make(map[string]int, len(b.columns))
+	for i, column := range b.columns {
+		columnIndex[column.Key] = i
+	}
+
+	result := &ComparativeStatement{
+		Statement:   statement,
+		Title:       title,
+		Company:     b.company,
+		Currency:    "IDR",
+		Columns:     b.columns,
+		Rows:        make([]ComparativeRow, 0, len(entries)),
+		GeneratedAt: time.Now(),
+	}
+	for _, entry := range entries {
+		row := entry.row
+		for i, column := range b.columns {
+			if column.CompareTo == "" {
+				continue
+			}
+			row.Variances = append(row.Variances, comparativeVariance(column.Key, column.CompareTo, row.Values[i], row.Values[columnIndex[column.CompareTo]]))
+		}
+		result.Rows = append(result.Rows, row)
+	}
+	return result
+}
+
+func comparativeVariance(columnKey, baseKey string, value, base float64) ComparativeVariance {
+	variance := ComparativeVariance{
+		ColumnKey: columnKey,
+		BaseKey:   baseKey,
+		Amount:    roundAmount(value - base),
+	}
+	if base != 0 {
+		percent := math.Round((value-base)/math.Abs(base)*10000) / 100
+		variance.Percent = &percent
+	}
+	return variance
+}
+
+// ComparedColumns returns the column pairs that carry variances, in column order
+func (c *ComparativeStatement) ComparedColumns() [][2]ComparativeColumn {
+	byKey := make(map[string]ComparativeColumn, len(c.Columns))
+	for _, column := range c.Columns {
+		byKey[column.Key] = column
+	}
+	var pairs [][2]ComparativeColumn
+	for _, column := range c.Columns {
+		if column.CompareTo != "" {
+			pairs = append(pairs, [2]ComparativeColumn{column, byKey[column.CompareTo]})
+		}
+	}
+	return pairs
+}
+
+// ExportComparativeStatementExcel exports a comparative statement with one column per period
+// followed by an amount and a percent column for every variance
+func ExportComparativeStatementExcel(statement *ComparativeStatement) ([]byte, error) {
+	if statement == nil {
+		return nil, errors.New("statement data is required")
+	}
+
+	f := excelize.NewFile()
+	defer f.Close()
+
+	sheet := "Comparative"
+	index, err := f.NewSheet(sheet)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create sheet: %v", err)
+	}
+	f.SetActiveSheet(index)
+	f.DeleteSheet("Sheet1")
+
+	f.SetCellValue(sheet, "A1", statement.Title)
+	f.SetCellValue(sheet, "A2", statement.Company.Name)
+	f.SetCellValue(sheet, "A3", fmt.Sprintf("Generated on: %s", statement.GeneratedAt.Format("2006-01-02 15:04:05")))
+
+	pairs := statement.ComparedColumns()
+	headers := []string{"Account Code", "Description"}
+	for _, column := range statement.Columns {
+		headers = append(headers, column.Label)
+	}
+	for _, pair := range pairs {
+		headers = append(headers, fmt.Sprintf("%s vs %s", pair[0].Label, pair[1].Label), "%")
+	}
+	for i, h := range headers {
+		cell, _ := excelize.CoordinatesToCellName(i+1, 5)
+		f.SetCellValue(sheet, cell, h)
+	}
+	lastColumn, _ := excelize.ColumnNumberToName(len(headers))
+	headerStyle, _ := f.NewStyle(&excelize.Style{
+		Font: &excelize.Font{Bold: true},
+		Fill: excelize.Fill{Type: "pattern", Color: []string{"#D3D3D3"}, Pattern: 1},
+	})
+	f.SetCellStyle(sheet, "A5", lastColumn+"5", headerStyle)
+
+	numberStyle, _ := f.NewStyle(&excelize.Style{NumFmt: 4})
+	totalStyle, _ := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}, NumFmt: 4})
+	row := 6
+	for _, line := range statement.Rows {
+		f.SetCellValue(sheet, fmt.Sprintf("A%d", row), line.AccountCode)
+		f.SetCellValue(sheet, fmt.Sprintf("B%d", row), line.Label)
+		col := 3
+		for _, value := range line.Values {
+			cell, _ := excelize.CoordinatesToCellName(col, row)
+			f.SetCellValue(sheet, cell, value)
+			col++
+		}
+		for _, variance := range line.Variances {
+			cell, _ := excelize.CoordinatesToCellName(col, row)
+			f.SetCellValue(sheet, cell, variance.Amount)
+			if variance.Percent != nil {
+				cell, _ = excelize.CoordinatesToCellName(col+1, row)
+				f.SetCellValue(sheet, cell, *variance.Percent)
+			}
+			col += 2
+		}
+		style := numberStyle
+		if line.IsTotal {
+			style = totalStyle
+			f.SetCellStyle(sheet, fmt.Sprintf("A%d", row), fmt.Sprintf("B%d", row), totalStyle)
+		}
+		f.SetCellStyle(sheet, fmt.Sprintf("C%d", row), fmt.Sprintf("%s%d", lastColumn, row), style)
+		row++
+	}
+
+	f.SetColWidth(sheet, "A", "A", 14)
+	f.SetColWidth(sheet, "B", "B", 40)
+	if len(headers) > 2 {
+		f.SetColWidth(sheet, "C", lastColumn, 18)
+	}
+
+	var buf bytes.Buffer
+	if err := f.Write(&buf); err != nil {
+		return nil, fmt.Errorf("failed to write excel: %v", err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,135 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+// comparativePDFMaxNumericColumns is how many amount columns fit on a landscape A4 page
+const comparativePDFMaxNumericColumns = 12
+
+// GenerateComparativeStatementPDF generates a multi-column financial statement PDF. Variance columns
+// are printed when they fit next to the period columns; otherwise only the periods are printed.
+func (p *PDFService) GenerateComparativeStatementPDF(statement *ComparativeStatement) ([]byte, error) {
+	if statement == nil {
+		return nil, fmt.Errorf("statement data is required")
+	}
+	if len(statement.Columns) > comparativePDFMaxNumericColumns {
+		return nil, fmt.Errorf("at most %d columns can be printed, use the Excel export instead", comparativePDFMaxNumericColumns)
+	}
+
+	pairs := statement.ComparedColumns()
+	showVariances := len(pairs) > 0 && len(statement.Columns)+2*len(pairs) <= comparativePDFMaxNumericColumns
+
+	orientation := "P"
+	numericColumns := len(statement.Columns)
+	if showVariances {
+		numericColumns += 2 * len(pairs)
+	}
+	if numericColumns > 3 {
+		orientation = "L"
+	}
+
+	pdf := gofpdf.New(orientation, "mm", "A4", "")
+	pdf.SetMargins(15, 15, 15)
+	pdf.SetAutoPageBreak(true, 15)
+	pdf.AddPage()
+
+	contentW := p.addReportHeader(pdf, statement.Title,
+		fmt.Sprintf("Columns: %d   Currency: %s", len(statement.Columns), statement.Currency),
+		fmt.Sprintf("Generated on: %s", time.Now().Format("02/01/2006 15:04")),
+	)
+
+	lm, _, _, _ := pdf.GetMargins()
+	_, pageH := pdf.GetPageSize()
+	codeW, descW := 18.0, 70.0
+	if numericColumns > 6 {
+		descW = 50
+	}
+	amountW := (contentW - codeW - descW) / float64(numericColumns)
+	fontSize := 8.0
+	if amountW < 22 {
+		fontSize = 6
+	}
+
+	formatAmount := func(amount float64) string {
+		text := p.addThousandSeparators(fmt.Sprintf("%.0f", math.Abs(amount)))
+		if amount < 0 {
+			return "(" + text + ")"
+		}
+		return text
+	}
+
+	writeHeader := func() {
+		pdf.SetX(lm)
+		pdf.SetFont("Arial", "B", fontSize)
+		pdf.SetFillColor(220, 220, 220)
+		pdf.CellFormat(codeW, 8, "Code", "1", 0, "C", true, 0, "")
+		pdf.CellFormat(descW, 8, "Description", "1", 0, "C", true, 0, "")
+		for _, column := range statement.Columns {
+			pdf.CellFormat(amountW, 8, truncateToWidth(pdf, column.Label, amountW-1), "1", 0, "C", true, 0, "")
+		}
+		if showVariances {
+			for _, pair := range pairs {
+				pdf.CellFormat(amountW, 8, truncateToWidth(pdf, "Var "+pair[0].Label, amountW-1), "1", 0, "C", true, 0, "")
+				pdf.CellFormat(amountW, 8, "%", "1", 0, "C", true, 0, "")
+			}
+		}
+		pdf.Ln(8)
+	}
+
+	writeHeader()
+	lastSection := ""
+	for _, row := range statement.Rows {
+		if pdf.GetY()+6 > pageH-20 {
+			pdf.AddPage()
+			writeHeader()
+		}
+		// Blank separator between sections
+		if lastSection != "" && row.Section != lastSection && !row.IsTotal {
+			pdf.Ln(2)
+		}
+		lastSection = row.Section
+
+		style, fill := "", false
+		if row.IsTotal {
+			style, fill = "B", true
+			pdf.SetFillColor(245, 245, 245)
+		}
+		pdf.SetFont("Arial", style, fontSize)
+		pdf.SetX(lm)
+		pdf.CellFormat(codeW, 6, row.AccountCode, "1", 0, "L", fill, 0, "")
+		pdf.CellFormat(descW, 6, truncateToWidth(pdf, row.Label, descW-2), "1", 0, "L", fill, 0, "")
+		for _, value := range row.Values {
+			pdf.CellFormat(amountW, 6, formatAmount(value), "1", 0, "R", fill, 0, "")
+		}
+		if showVariances {
+			for _, variance := range row.Variances {
+				pdf.CellFormat(amountW, 6, formatAmount(variance.Amount), "1", 0, "R", fill, 0, "")
+				percent := "-"
+				if variance.Percent != nil {
+					percent = fmt.Sprintf("%.1f%%", *variance.Percent)
+				}
+				pdf.CellFormat(amountW, 6, percent, "1", 0, "R", fill, 0, "")
+			}
+		}
+		pdf.Ln(6)
+	}
+
+	if len(pairs) > 0 && !showVariances {
+		pdf.Ln(4)
+		pdf.SetX(lm)
+		pdf.SetFont("Arial", "I", 8)
+		pdf.Cell(contentW, 5, "Variances do not fit on the page and are included in the Excel and JSON output.")
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, fmt.Errorf("failed to generate comparative statement PDF: %v", err)
+	}
+	return buf.Bytes(), nil
+}
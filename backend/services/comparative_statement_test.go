@@ -0,0 +1,125 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildComparativeColumnPresets(t *testing.T) {
+	columns, err := BuildComparativeColumns(ComparativeRequest{Preset: "quarter_yoy", Year: 2024, Quarter: 2}, ComparativeStatementProfitLoss)
+	require.NoError(t, err)
+	require.Len(t, columns, 2)
+	assert.Equal(t, ComparativeColumn{Key: "current", Label: "Q2 2024", Type: ComparativeColumnActual, StartDate: "2024-04-01", EndDate: "2024-06-30", CompareTo: "prior"}, columns[0])
+	assert.Equal(t, "2023-04-01", columns[1].StartDate)
+	assert.Equal(t, "2023-06-30", columns[1].EndDate)
+
+	columns, err = BuildComparativeColumns(ComparativeRequest{Preset: ComparativePresetYTDYoY, Year: 2024, Month: 2}, ComparativeStatementCashFlow)
+	require.NoError(t, err)
+	assert.Equal(t, "YTD Feb 2024", columns[0].Label)
+	assert.Equal(t, "2024-02-29", columns[0].EndDate)
+	assert.Equal(t, "2023-02-28", columns[1].EndDate)
+
+	columns, err = BuildComparativeColumns(ComparativeRequest{Preset: ComparativePresetMonthly, Year: 2023}, ComparativeStatementBalanceSheet)
+	require.NoError(t, err)
+	require.Len(t, columns, 12)
+	assert.Empty(t, columns[0].CompareTo)
+	assert.Equal(t, "m11", columns[11].CompareTo)
+	assert.Empty(t, columns[11].StartDate, "the balance sheet is as of the end date")
+	assert.Equal(t, "2023-12-31", columns[11].EndDate)
+
+	columns, err = BuildComparativeColumns(ComparativeRequest{Preset: ComparativePresetBudget, Year: 2024, Month: 3, BudgetID: 7}, ComparativeStatementProfitLoss)
+	require.NoError(t, err)
+	assert.Equal(t, "Actual Mar 2024", columns[0].Label)
+	assert.Equal(t, "budget", columns[0].CompareTo)
+	assert.Equal(t, ComparativeColumnBudget, columns[1].Type)
+	assert.Equal(t, uint(7), columns[1].BudgetID)
+	assert.Equal(t, "2024-03-31", columns[1].EndDate)
+
+	tests := []struct {
+		name      string
+		req       ComparativeRequest
+		statement string
+		wantErr   string
+	}{
+		{name: "no preset", req: ComparativeRequest{}, statement: ComparativeStatementProfitLoss, wantErr: "either preset or columns is required"},
+		{name: "unknown preset", req: ComparativeRequest{Preset: "WEEKLY"}, statement: ComparativeStatementProfitLoss, wantErr: "invalid preset WEEKLY, use MONTHLY, QUARTER_YOY, YTD_YOY or BUDGET"},
+		{name: "quarter out of range", req: ComparativeRequest{Preset: ComparativePresetQuarterYoY, Year: 2024, Quarter: 5}, statement: ComparativeStatementProfitLoss, wantErr: "quarter must be between 1 and 4"},
+		{name: "budget on the balance sheet", req: ComparativeRequest{Preset: ComparativePresetBudget, Year: 2024, Month: 1}, statement: ComparativeStatementBalanceSheet, wantErr: "column budget: budget columns are only available for the profit and loss statement"},
+		{name: "duplicate key", req: ComparativeRequest{Columns: []ComparativeColumn{{Key: "a", StartDate: "2024-01-01", EndDate: "2024-01-31"}, {Key: "a", StartDate: "2024-02-01", EndDate: "2024-02-29"}}}, statement: ComparativeStatementProfitLoss, wantErr: "duplicate column key a"},
+		{name: "end before start", req: ComparativeRequest{Columns: []ComparativeColumn{{StartDate: "2024-02-01", EndDate: "2024-01-31"}}}, statement: ComparativeStatementProfitLoss, wantErr: "column c1: end_date must not be before start_date"},
+		{name: "budget across years", req: ComparativeRequest{Columns: []ComparativeColumn{{Type: "budget", StartDate: "2023-12-01", EndDate: "2024-01-31"}}}, statement: ComparativeStatementProfitLoss, wantErr: "column c1: a budget column must stay within one year"},
+		{name: "compare to itself", req: ComparativeRequest{Columns: []ComparativeColumn{{StartDate: "2024-01-01", EndDate: "2024-01-31", CompareTo: "c1"}}}, statement: ComparativeStatementProfitLoss, wantErr: "column c1: compare_to must name another column"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := BuildComparativeColumns(tt.req, tt.statement)
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestComparativeBuilderMergesRows(t *testing.T) {
+	columns, err := prepareComparativeColumns([]ComparativeColumn{
+		{Key: "current", StartDate: "2024-02-01", EndDate: "2024-02-29", CompareTo: "prior"},
+		{Key: "prior", StartDate: "2024-01-01", EndDate: "2024-01-31"},
+	}, ComparativeStatementProfitLoss)
+	require.NoError(t, err)
+	assert.Equal(t, "2024-02-01 - 2024-02-29", columns[0].Label)
+
+	builder := newComparativeBuilder(columns)
+	builder.account(0, 1, "REVENUE", "4101", "Penjualan", 1500000)
+	builder.account(1, 1, "REVENUE", "4101", "Penjualan", 1000000)
+	builder.account(1, 1, "REVENUE", "4102", "Pendapatan Jasa", 250000)
+	builder.total(0, 1, "REVENUE", "total_revenue", "Total Revenue", 1500000)
+	builder.total(1, 1, "REVENUE", "total_revenue", "Total Revenue", 1250000)
+	builder.account(0, 2, "EXPENSE", "5101", "Beban Gaji", 400000)
+	statement := builder.build(ComparativeStatementProfitLoss, "Profit and Loss")
+
+	require.Len(t, statement.Rows, 4)
+	assert.Equal(t, []string{"REVENUE:4101", "REVENUE:4102", "total_revenue", "EXPENSE:5101"},
+		[]string{statement.Rows[0].Key, statement.Rows[1].Key, statement.Rows[2].Key, statement.Rows[3].Key})
+	assert.Equal(t, []float64{0, 250000}, statement.Rows[1].Values, "an account that only moved in one period")
+
+	variance := statement.Rows[2].Variances[0]
+	assert.Equal(t, "current", variance.ColumnKey)
+	assert.Equal(t, "prior", variance.BaseKey)
+	assert.InDelta(t, 250000, variance.Amount, 0.001)
+	require.NotNil(t, variance.Percent)
+	assert.InDelta(t, 20, *variance.Percent, 0.001)
+	assert.Nil(t, statement.Rows[3].Variances[0].Percent, "no percent against a zero base")
+
+	pairs := statement.ComparedColumns()
+	require.Len(t, pairs, 1)
+	assert.Equal(t, "prior", pairs[0][1].Key)
+}
+
+func TestComparativeVariance(t *testing.T) {
+	tests := []struct {
+		name        string
+		value, base float64
+		amount      float64
+		percent     float64
+		noPercent   bool
+	}{
+		{name: "increase", value: 120, base: 100, amount: 20, percent: 20},
+		{name: "decrease", value: 75, base: 100, amount: -25, percent: -25},
+		{name: "smaller loss against a negative base", value: -50, base: -200, amount: 150, percent: 75},
+		{name: "zero base", value: 10, base: 0, amount: 10, noPercent: true},
+		{name: "rounded to two decimals", value: 1, base: 3, amount: -2, percent: -66.67},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			variance := comparativeVariance("a", "b", tt.value, tt.base)
+			assert.InDelta(t, tt.amount, variance.Amount, 0.001)
+			if tt.noPercent {
+				assert.Nil(t, variance.Percent)
+				return
+			}
+			require.NotNil(t, variance.Percent)
+			assert.InDelta(t, tt.percent, *variance.Percent, 0.001)
+		})
+	}
+}
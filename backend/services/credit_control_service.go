@@ -0,0 +1,383 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"app-sistem-akuntansi/models"
+
+	"gorm.io/gorm"
+)
+
+// CreditControlService checks customer credit exposure when sales are confirmed or invoiced and
+// manages credit holds
+type CreditControlService struct {
+	db              *gorm.DB
+	approvalService *ApprovalService
+	settingsService *SettingsService
+}
+
+// NewCreditControlService creates a new CreditControlService
+func NewCreditControlService(db *gorm.DB, approvalService *ApprovalService, settingsService *SettingsService) *CreditControlService {
+	return &CreditControlService{
+		db:              db,
+		approvalService: approvalService,
+		settingsService: settingsService,
+	}
+}
+
+// creditOpenInvoiceStatuses are the sale statuses whose outstanding amount counts as receivable exposure
+var creditOpenInvoiceStatuses = []string{models.SaleStatusInvoiced, models.SaleStatusOverdue}
+
+// CreditExposure is a customer's current credit position in base currency.
+// Exposure = outstanding invoices + confirmed orders that are not invoiced yet.
+type CreditExposure struct {
+	CustomerID          uint       `json:"customer_id"`
+	CustomerCode        string     `json:"customer_code"`
+	CustomerName        string     `json:"customer_name"`
+	CreditLimit         float64    `json:"credit_limit"` // 0 = unlimited
+	PaymentTerms        int        `json:"payment_terms"`
+	CreditHold          bool       `json:"credit_hold"`
+	CreditHoldReason    string     `json:"credit_hold_reason,omitempty"`
+	CreditHoldAt        *time.Time `json:"credit_hold_at,omitempty"`
+	OutstandingInvoices float64    `json:"outstanding_invoices"`
+	UnbilledOrders      float64    `json:"unbilled_orders"`
+	Exposure            float64    `json:"exposure"`
+	AvailableCredit     *float64   `json:"available_credit"` // nil when the limit is unlimited
+	OverdueDaysLimit    int        `json:"overdue_days_limit"`
+	OverdueInvoices     int        `json:"overdue_invoices"` // Invoices overdue beyond OverdueDaysLimit
+	OverdueAmount       float64    `json:"overdue_amount"`
+	MaxDaysOverdue      int        `json:"max_days_overdue"`
+}
+
+// CreditCheckResult is the outcome of checking a sale against its customer's credit position
+type CreditCheckResult struct {
+	CreditExposure
+	SaleID            uint     `json:"sale_id"`
+	SaleAmount        float64  `json:"sale_amount"`
+	ProjectedExposure float64  `json:"projected_exposure"`
+	Violations        []string `json:"violations"`
+	Passed            bool     `json:"passed"`
+	Action            string   `json:"action,omitempty"` // BLOCK or APPROVAL when not passed
+	ApprovalRequestID *uint    `json:"approval_request_id,omitempty"`
+	ApprovalStatus    string   `json:"approval_status,omitempty"`
+}
+
+// CreditControlError is returned when a sale fails the credit check
+type CreditControlError struct {
+	Result *CreditCheckResult
+}
+
+func (e *CreditControlError) Error() string {
+	reasons := strings.Join(e.Result.Violations, "; ")
+	switch {
+	case e.Result.Action == models.CreditControlActionApproval && e.Result.ApprovalStatus == models.SaleCreditApprovalRejected:
+		return fmt.Sprintf("credit approval was rejected: %s", reasons)
+	case e.Result.Action == models.CreditControlActionApproval:
+		return fmt.Sprintf("credit approval required: %s", reasons)
+	default:
+		return fmt.Sprintf("credit check failed: %s", reasons)
+	}
+}
+
+// ========== EXPOSURE ==========
+
+// GetCustomerExposure - Posisi kredit pelanggan saat ini
+func (s *CreditControlService) GetCustomerExposure(customerID uint) (*CreditExposure, error) {
+	return s.exposure(s.db, customerID, 0)
+}
+
+// CheckSale - Mengecek penjualan terhadap batas kredit tanpa mengubah data apa pun
+func (s *CreditControlService) CheckSale(saleID uint) (*CreditCheckResult, error) {
+	var sale models.Sale
+	if err := s.db.First(&sale, saleID).Error; err != nil {
+		return nil, errors.New("sale not found")
+	}
+	return s.check(&sale)
+}
+
+// EnforceSale - Dipanggil saat konfirmasi/invoice. Penjualan yang melanggar diblokir, atau
+// (CreditControlAction = APPROVAL) diajukan ke workflow persetujuan SALES. Penjualan yang sudah
+// disetujui dengan nilai sama atau lebih besar diloloskan; credit hold selalu memblokir.
+func (s *CreditControlService) EnforceSale(sale *models.Sale, userID uint) error {
+	if isImmediatePaymentSale(sale) {
+		return nil
+	}
+
+	result, err := s.check(sale)
+	if err != nil {
+		return err
+	}
+	if result.Passed {
+		return nil
+	}
+
+	result.Action = s.controlAction()
+	if result.CreditHold || result.Action == models.CreditControlActionBlock {
+		result.Action = models.CreditControlActionBlock
+		return &CreditControlError{Result: result}
+	}
+
+	// Route to approval, reusing the request of this sale when there is one
+	if sale.CreditApprovalRequestID != nil {
+		var request models.ApprovalRequest
+		if err := s.db.First(&request, *sale.CreditApprovalRequestID).Error; err == nil {
+			switch {
+			case request.Status == models.ApprovalStatusApproved && request.Amount >= result.SaleAmount:
+				log.Printf("✅ Sale #%d passes credit control on approved request %s", sale.ID, request.RequestCode)
+				return nil
+			case request.Status == models.ApprovalStatusPending:
+				result.ApprovalRequestID = &request.ID
+				result.ApprovalStatus = models.SaleCreditApprovalPending
+				return &CreditControlError{Result: result}
+			case request.Status == models.ApprovalStatusRejected && request.Amount >= result.SaleAmount:
+				result.ApprovalRequestID = &request.ID
+				result.ApprovalStatus = models.SaleCreditApprovalRejected
+				return &CreditControlError{Result: result}
+			}
+		}
+	}
+
+	approvalRequest := models.CreateApprovalRequestDTO{
+		EntityType:     models.EntityTypeSale,
+		EntityID:       sale.ID,
+		Amount:         result.SaleAmount,
+		Priority:       models.ApprovalPriorityHigh,
+		RequestTitle:   fmt.Sprintf("Credit approval for sale %s - %s", sale.Code, result.CustomerName),
+		RequestMessage: strings.Join(result.Violations, "\n"),
+		ContactID:      &sale.CustomerID,
+	}
+	for _, item := range sale.SaleItems {
+		approvalRequest.AccountIDs = appendApprovalID(approvalRequest.AccountIDs, item.RevenueAccountID)
+		if item.CostCenterID != nil {
+			approvalRequest.CostCenterIDs = appendApprovalID(approvalRequest.CostCenterIDs, *item.CostCenterID)
+		}
+	}
+	request, err := s.approvalService.CreateApprovalRequest(approvalRequest, userID)
+	if err != nil {
+		// Without a SALES workflow the sale cannot be approved, so it stays blocked
+		log.Printf("⚠️ Failed to create credit approval request for sale #%d: %v", sale.ID, err)
+		result.Action = models.CreditControlActionBlock
+		return &CreditControlError{Result: result}
+	}
+
+	if err := s.db.Model(&models.Sale{}).Where("id = ?", sale.ID).Updates(map[string]interface{}{
+		"credit_approval_status":     models.SaleCreditApprovalPending,
+		"credit_approval_request_id": request.ID,
+	}).Error; err != nil {
+		return fmt.Errorf("failed to link credit approval request: %v", err)
+	}
+	sale.CreditApprovalStatus = models.SaleCreditApprovalPending
+	sale.CreditApprovalRequestID = &request.ID
+
+	log.Printf("📝 Sale #%d routed to credit approval (%s)", sale.ID, request.RequestCode)
+	result.ApprovalRequestID = &request.ID
+	result.ApprovalStatus = models.SaleCreditApprovalPending
+	return &CreditControlError{Result: result}
+}
+
+// ========== CREDIT HOLD ==========
+
+// PlaceHold - Menahan kredit pelanggan; konfirmasi dan invoice kredit diblokir sampai dilepas
+func (s *CreditControlService) PlaceHold(customerID uint, reason string, userID uint) (*models.Contact, error) {
+	return s.setHold(customerID, true, reason, userID)
+}
+
+// ReleaseHold - Melepas credit hold pelanggan
+func (s *CreditControlService) ReleaseHold(customerID uint, reason string, userID uint) (*models.Contact, error) {
+	return s.setHold(customerID, false, reason, userID)
+}
+
+// GetHoldHistory - Riwayat hold/release pelanggan, terbaru lebih dulu
+func (s *CreditControlService) GetHoldHistory(customerID uint) ([]models.CreditHoldLog, error) {
+	var logs []models.CreditHoldLog
+	err := s.db.Preload("User").Where("contact_id = ?", customerID).Order("created_at DESC, id DESC").Find(&logs).Error
+	return logs, err
+}
+
+// GetCustomersOnHold - Daftar pelanggan yang sedang ditahan
+func (s *CreditControlService) GetCustomersOnHold() ([]models.Contact, error) {
+	var contacts []models.Contact
+	err := s.db.Where("type = ? AND credit_hold = ?", models.ContactTypeCustomer, true).Order("name").Find(&contacts).Error
+	return contacts, err
+}
+
+func (s *CreditControlService) setHold(customerID uint, hold bool, reason string, userID uint) (*models.Contact, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, errors.New("reason is required")
+	}
+
+	var contact models.Contact
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&contact, customerID).Error; err != nil {
+			return errors.New("customer not found")
+		}
+		if contact.Type != models.ContactTypeCustomer {
+			return errors.New("credit hold applies to customers only")
+		}
+		if contact.CreditHold == hold {
+			if hold {
+				return errors.New("customer is already on credit hold")
+			}
+			return errors.New("customer is not on credit hold")
+		}
+
+		action := models.CreditHoldActionRelease
+		updates := map[string]interface{}{
+			"credit_hold":        hold,
+			"credit_hold_reason": "",
+			"credit_hold_at":     nil,
+			"credit_hold_by":     nil,
+		}
+		if hold {
+			now := time.Now()
+			action = models.CreditHoldActionHold
+			updates["credit_hold_reason"] = reason
+			updates["credit_hold_at"] = now
+			updates["credit_hold_by"] = userID
+		}
+		if err := tx.Model(&contact).Updates(updates).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.CreditHoldLog{
+			ContactID: contact.ID,
+			Action:    action,
+			Reason:    reason,
+			UserID:    userID,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("🔒 Credit hold for customer %s set to %v by user %d", contact.Code, hold, userID)
+	if err := s.db.First(&contact, customerID).Error; err != nil {
+		return nil, err
+	}
+	return &contact, nil
+}
+
+// ========== HELPERS ==========
+
+func (s *CreditControlService) check(sale *models.Sale) (*CreditCheckResult, error) {
+	exposure, err := s.exposure(s.db, sale.CustomerID, sale.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &CreditCheckResult{
+		CreditExposure: *exposure,
+		SaleID:         sale.ID,
+		SaleAmount:     toStatementBase(sale.TotalAmount-sale.PaidAmount, sale.Currency, sale.ExchangeRate),
+		Violations:     []string{},
+	}
+	result.ProjectedExposure = roundAmount(exposure.Exposure + result.SaleAmount)
+
+	if exposure.CreditHold {
+		result.Violations = append(result.Violations, fmt.Sprintf("customer is on credit hold (%s)", exposure.CreditHoldReason))
+	}
+	if exposure.CreditLimit > 0 && result.ProjectedExposure > exposure.CreditLimit {
+		result.Violations = append(result.Violations, fmt.Sprintf("exposure %.2f would exceed credit limit %.2f by %.2f",
+			result.ProjectedExposure, exposure.CreditLimit, roundAmount(result.ProjectedExposure-exposure.CreditLimit)))
+	}
+	if exposure.OverdueInvoices > 0 {
+		result.Violations = append(result.Violations, fmt.Sprintf("%d invoice(s) totalling %.2f overdue more than %d days (oldest %d days)",
+			exposure.OverdueInvoices, exposure.OverdueAmount, exposure.OverdueDaysLimit, exposure.MaxDaysOverdue))
+	}
+	result.Passed = len(result.Violations) == 0
+	return result, nil
+}
+
+// exposure computes the credit position of a customer, leaving out excludeSaleID (the sale being checked)
+func (s *CreditControlService) exposure(db *gorm.DB, customerID uint, excludeSaleID uint) (*CreditExposure, error) {
+	var customer models.Contact
+	if err := db.First(&customer, customerID).Error; err != nil {
+		return nil, errors.New("customer not found")
+	}
+
+	result := &CreditExposure{
+		CustomerID:       customer.ID,
+		CustomerCode:     customer.Code,
+		CustomerName:     customer.Name,
+		CreditLimit:      customer.CreditLimit,
+		PaymentTerms:     customer.PaymentTerms,
+		CreditHold:       customer.CreditHold,
+		CreditHoldReason: customer.CreditHoldReason,
+		CreditHoldAt:     customer.CreditHoldAt,
+		OverdueDaysLimit: s.overdueDaysLimit(),
+	}
+
+	var invoices []models.Sale
+	if err := db.Select("id, currency, exchange_rate, outstanding_amount, due_date").
+		Where("customer_id = ? AND id <> ? AND status IN ? AND outstanding_amount > 0", customerID, excludeSaleID, creditOpenInvoiceStatuses).
+		Find(&invoices).Error; err != nil {
+		return nil, fmt.Errorf("failed to load open invoices: %v", err)
+	}
+	today := dateOnly(time.Now())
+	for _, invoice := range invoices {
+		amount := toStatementBase(invoice.OutstandingAmount, invoice.Currency, invoice.ExchangeRate)
+		result.OutstandingInvoices += amount
+
+		if result.OverdueDaysLimit <= 0 || invoice.DueDate.IsZero() {
+			continue
+		}
+		daysOverdue := int(today.Sub(dateOnly(invoice.DueDate)).Hours() / 24)
+		if daysOverdue > result.OverdueDaysLimit {
+			result.OverdueInvoices++
+			result.OverdueAmount += amount
+			if daysOverdue > result.MaxDaysOverdue {
+				result.MaxDaysOverdue = daysOverdue
+			}
+		}
+	}
+
+	var orders []models.Sale
+	if err := db.Select("id, currency, exchange_rate, total_amount, paid_amount").
+		Where("customer_id = ? AND id <> ? AND status = ? AND COALESCE(invoice_number, '') = ''", customerID, excludeSaleID, models.SaleStatusConfirmed).
+		Find(&orders).Error; err != nil {
+		return nil, fmt.Errorf("failed to load unbilled orders: %v", err)
+	}
+	for _, order := range orders {
+		result.UnbilledOrders += toStatementBase(order.TotalAmount-order.PaidAmount, order.Currency, order.ExchangeRate)
+	}
+
+	result.OutstandingInvoices = roundAmount(result.OutstandingInvoices)
+	result.UnbilledOrders = roundAmount(result.UnbilledOrders)
+	result.OverdueAmount = roundAmount(result.OverdueAmount)
+	result.Exposure = roundAmount(result.OutstandingInvoices + result.UnbilledOrders)
+	if customer.CreditLimit > 0 {
+		available := roundAmount(customer.CreditLimit - result.Exposure)
+		result.AvailableCredit = &available
+	}
+	return result, nil
+}
+
+func (s *CreditControlService) overdueDaysLimit() int {
+	if s.settingsService == nil {
+		return 0
+	}
+	settings, err := s.settingsService.GetSettings()
+	if err != nil {
+		return 0
+	}
+	return settings.CreditOverdueDays
+}
+
+func (s *CreditControlService) controlAction() string {
+	if s.settingsService != nil {
+		if settings, err := s.settingsService.GetSettings(); err == nil && settings.CreditControlAction == models.CreditControlActionApproval {
+			return models.CreditControlActionApproval
+		}
+	}
+	return models.CreditControlActionBlock
+}
+
+// isImmediatePaymentSale reports whether the sale is paid on invoicing (CASH/BANK) and so extends no credit
+func isImmediatePaymentSale(sale *models.Sale) bool {
+	pm := strings.ToUpper(strings.TrimSpace(sale.PaymentMethodType))
+	return pm == "CASH" || strings.HasPrefix(pm, "BANK")
+}
@@ -0,0 +1,106 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"app-sistem-akuntansi/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// useTestSettings serves settings from the settings cache for the duration of the test
+func useTestSettings(t *testing.T, settings *models.Settings) {
+	settingsCacheMutex.Lock()
+	previous, previousAt := settingsCache, settingsCacheAt
+	settingsCache, settingsCacheAt = settings, time.Now()
+	settingsCacheMutex.Unlock()
+	t.Cleanup(func() {
+		settingsCacheMutex.Lock()
+		settingsCache, settingsCacheAt = previous, previousAt
+		settingsCacheMutex.Unlock()
+	})
+}
+
+func TestCreditExposureAndEnforcement(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1) // Every connection to :memory: is a separate database
+	require.NoError(t, db.AutoMigrate(&models.Contact{}, &models.Sale{}, &models.CreditHoldLog{}))
+	useTestSettings(t, &models.Settings{CreditOverdueDays: 30, CreditControlAction: models.CreditControlActionBlock})
+	service := NewCreditControlService(db, nil, NewSettingsService(db))
+
+	customer := models.Contact{Code: "CUST-001", Name: "PT Pelanggan", Type: models.ContactTypeCustomer, CreditLimit: 10000000}
+	require.NoError(t, db.Create(&customer).Error)
+	today := dateOnly(time.Now())
+	newSale := func(code, status string, total, outstanding float64, due time.Time) models.Sale {
+		sale := models.Sale{Code: code, CustomerID: customer.ID, UserID: 1, Date: today, DueDate: due, Status: status,
+			Currency: "IDR", ExchangeRate: 1, TotalAmount: total, OutstandingAmount: outstanding}
+		if status != models.SaleStatusConfirmed {
+			sale.InvoiceNumber = "INV-" + code
+		}
+		require.NoError(t, db.Create(&sale).Error)
+		return sale
+	}
+
+	newSale("SO-001", models.SaleStatusInvoiced, 5000000, 3000000, today.AddDate(0, 0, 14))
+	newSale("SO-002", models.SaleStatusConfirmed, 2000000, 0, today.AddDate(0, 0, 30))
+	newSale("SO-003", models.SaleStatusPaid, 4000000, 0, today.AddDate(0, 0, -60))
+	exposure, err := service.GetCustomerExposure(customer.ID)
+	require.NoError(t, err)
+	assert.InDelta(t, 3000000, exposure.OutstandingInvoices, 0.001)
+	assert.InDelta(t, 2000000, exposure.UnbilledOrders, 0.001)
+	assert.InDelta(t, 5000000, exposure.Exposure, 0.001)
+	require.NotNil(t, exposure.AvailableCredit)
+	assert.InDelta(t, 5000000, *exposure.AvailableCredit, 0.001)
+
+	// A sale within the limit passes; the sale itself is not counted twice
+	sale := newSale("SO-004", models.SaleStatusConfirmed, 4000000, 0, today.AddDate(0, 0, 30))
+	require.NoError(t, service.EnforceSale(&sale, 1))
+
+	sale.TotalAmount = 6000000
+	err = service.EnforceSale(&sale, 1)
+	var creditErr *CreditControlError
+	require.True(t, errors.As(err, &creditErr))
+	assert.Equal(t, models.CreditControlActionBlock, creditErr.Result.Action)
+	assert.InDelta(t, 11000000, creditErr.Result.ProjectedExposure, 0.001)
+	assert.EqualError(t, err, "credit check failed: exposure 11000000.00 would exceed credit limit 10000000.00 by 1000000.00")
+
+	// Cash sales extend no credit
+	sale.PaymentMethodType = "CASH"
+	require.NoError(t, service.EnforceSale(&sale, 1))
+	sale.PaymentMethodType = "CREDIT"
+
+	newSale("SO-005", models.SaleStatusOverdue, 500000, 500000, today.AddDate(0, 0, -45))
+	result, err := service.CheckSale(sale.ID)
+	require.NoError(t, err)
+	assert.False(t, result.Passed)
+	assert.Equal(t, 1, result.OverdueInvoices)
+	assert.Equal(t, 45, result.MaxDaysOverdue)
+	assert.Equal(t, []string{"1 invoice(s) totalling 500000.00 overdue more than 30 days (oldest 45 days)"}, result.Violations)
+
+	_, err = service.PlaceHold(customer.ID, " ", 1)
+	assert.EqualError(t, err, "reason is required")
+	held, err := service.PlaceHold(customer.ID, "Cek kosong", 1)
+	require.NoError(t, err)
+	assert.True(t, held.CreditHold)
+	_, err = service.PlaceHold(customer.ID, "Cek kosong", 1)
+	assert.EqualError(t, err, "customer is already on credit hold")
+	released, err := service.ReleaseHold(customer.ID, "Sudah dibayar", 1)
+	require.NoError(t, err)
+	assert.False(t, released.CreditHold)
+	assert.Empty(t, released.CreditHoldReason)
+
+	history, err := service.GetHoldHistory(customer.ID)
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.Equal(t, models.CreditHoldActionRelease, history[0].Action)
+	assert.Equal(t, models.CreditHoldActionHold, history[1].Action)
+}
@@ -0,0 +1,844 @@
+package services
+
+import (
+	"app-sistem-akuntansi/models"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// Default accounts for exchange differences (see SeedAccountsImproved)
+const (
+	FXGainAccountCode = "4202" // Laba Selisih Kurs
+	FXLossAccountCode = "5902" // Rugi Selisih Kurs
+)
+
+type CurrencyService struct {
+	db               *gorm.DB
+	journalService   *UnifiedJournalService
+	periodService    *UnifiedPeriodClosingService
+	taxAccountHelper *TaxAccountHelper // Receivable and payable control accounts from the account settings
+}
+
+func NewCurrencyService(db *gorm.DB) *CurrencyService {
+	return &CurrencyService{
+		db:               db,
+		journalService:   NewUnifiedJournalService(db),
+		periodService:    NewUnifiedPeriodClosingService(db),
+		taxAccountHelper: NewTaxAccountHelper(db),
+	}
+}
+
+// ========== REQUEST / RESPONSE TYPES ==========
+
+// ExchangeRateRequest - Input kurs manual (1 unit mata uang asing = Rate IDR)
+type ExchangeRateRequest struct {
+	CurrencyCode string    `json:"currency_code" binding:"required"`
+	RateDate     time.Time `json:"rate_date" binding:"required"`
+	Rate         float64   `json:"rate" binding:"required,gt=0"`
+	Notes        string    `json:"notes"`
+}
+
+// ExchangeRateImportResult - Ringkasan hasil import CSV kurs
+type ExchangeRateImportResult struct {
+	Imported int      `json:"imported"`
+	Updated  int      `json:"updated"`
+	Skipped  int      `json:"skipped"`
+	Errors   []string `json:"errors"`
+}
+
+// FXRevaluationRequest - Request untuk revaluasi selisih kurs akhir bulan
+type FXRevaluationRequest struct {
+	PeriodEndDate time.Time `json:"period_end_date" binding:"required"`
+	Notes         string    `json:"notes"`
+}
+
+// ForeignSettlement describes how much of a foreign currency invoice or bill a base currency
+// payment settles, and the exchange difference against the rate the document was booked at
+type ForeignSettlement struct {
+	ItemType       string          `json:"item_type"` // RECEIVABLE, PAYABLE
+	ReferenceCode  string          `json:"reference_code"`
+	CurrencyCode   string          `json:"currency_code"`
+	BaseAmount     decimal.Decimal `json:"base_amount"`
+	ForeignAmount  decimal.Decimal `json:"foreign_amount"`
+	BookRate       decimal.Decimal `json:"book_rate"`
+	SettlementRate decimal.Decimal `json:"settlement_rate"`
+	GainLoss       decimal.Decimal `json:"gain_loss"` // positive = gain, negative = loss
+}
+
+// DocumentSettlement converts base currency payments on one invoice or bill into the document currency
+type DocumentSettlement struct {
+	ItemType        string          // RECEIVABLE, PAYABLE
+	ReferenceCode   string          // Invoice number or bill code
+	CurrencyCode    string          // Document currency
+	BookRate        float64         // Rate the document was booked at
+	Rate            decimal.Decimal // Settlement rate, 1 for base currency documents
+	OutstandingBase float64         // Document outstanding converted to base currency at Rate
+}
+
+// ========== EXCHANGE RATES ==========
+
+// GetExchangeRates - Daftar kurs, opsional difilter mata uang dan rentang tanggal
+func (s *CurrencyService) GetExchangeRates(currency string, startDate, endDate *time.Time) ([]models.ExchangeRate, error) {
+	query := s.db.Model(&models.ExchangeRate{})
+	if currency != "" {
+		query = query.Where("currency_code = ?", normalizeCurrencyCode(currency))
+	}
+	if startDate != nil {
+		query = query.Where("rate_date >= ?", *startDate)
+	}
+	if endDate != nil {
+		query = query.Where("rate_date <= ?", *endDate)
+	}
+
+	var rates []models.ExchangeRate
+	if err := query.Order("rate_date DESC, currency_code").Find(&rates).Error; err != nil {
+		return nil, err
+	}
+	return rates, nil
+}
+
+// SaveExchangeRate - Simpan kurs; kurs pada tanggal yang sama untuk mata uang yang sama akan ditimpa
+func (s *CurrencyService) SaveExchangeRate(req ExchangeRateRequest, userID uint) (*models.ExchangeRate, error) {
+	rate, _, err := s.upsertExchangeRate(s.db, req, models.ExchangeRateSourceManual, userID)
+	return rate, err
+}
+
+// DeleteExchangeRate - Hapus kurs
+func (s *CurrencyService) DeleteExchangeRate(id uint) error {
+	result := s.db.Delete(&models.ExchangeRate{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("exchange rate not found")
+	}
+	return nil
+}
+
+// ImportExchangeRatesCSV imports rates from CSV with columns currency_code,rate_date,rate[,notes].
+// rate_date uses YYYY-MM-DD; a header row is detected and skipped.
+func (s *CurrencyService) ImportExchangeRatesCSV(r io.Reader, userID uint) (*ExchangeRateImportResult, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV file: %v", err)
+	}
+	if len(records) == 0 {
+		return nil, errors.New("CSV file is empty")
+	}
+
+	result := &ExchangeRateImportResult{Errors: []string{}}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		for i, record := range records {
+			row := i + 1
+			if len(record) == 0 || (len(record) == 1 && strings.TrimSpace(record[0]) == "") {
+				continue
+			}
+			if i == 0 && strings.EqualFold(strings.TrimSpace(record[0]), "currency_code") {
+				continue
+			}
+			if len(record) < 3 {
+				result.Skipped++
+				result.Errors = append(result.Errors, fmt.Sprintf("row %d: expected at least 3 columns", row))
+				continue
+			}
+
+			rateDate, err := time.Parse("2006-01-02", strings.TrimSpace(record[1]))
+			if err != nil {
+				result.Skipped++
+				result.Errors = append(result.Errors, fmt.Sprintf("row %d: invalid rate_date '%s'", row, record[1]))
+				continue
+			}
+			rateValue, err := strconv.ParseFloat(strings.TrimSpace(record[2]), 64)
+			if err != nil || rateValue <= 0 {
+				result.Skipped++
+				result.Errors = append(result.Errors, fmt.Sprintf("row %d: invalid rate '%s'", row, record[2]))
+				continue
+			}
+
+			req := ExchangeRateRequest{
+				CurrencyCode: record[0],
+				RateDate:     rateDate,
+				Rate:         rateValue,
+			}
+			if len(record) > 3 {
+				req.Notes = strings.TrimSpace(record[3])
+			}
+
+			_, created, err := s.upsertExchangeRate(tx, req, models.ExchangeRateSourceCSVImport, userID)
+			if err != nil {
+				result.Skipped++
+				result.Errors = append(result.Errors, fmt.Sprintf("row %d: %v", row, err))
+				continue
+			}
+			if created {
+				result.Imported++
+			} else {
+				result.Updated++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("💱 Exchange rate import: %d imported, %d updated, %d skipped", result.Imported, result.Updated, result.Skipped)
+	return result, nil
+}
+
+// GetRate returns the most recent rate on or before the given date (base currency always 1)
+func (s *CurrencyService) GetRate(currency string, date time.Time) (decimal.Decimal, error) {
+	return s.getRateWithDB(s.db, currency, date)
+}
+
+// ResolveSettlementRate returns the explicitly supplied rate, or the table rate for the payment date
+func (s *CurrencyService) ResolveSettlementRate(tx *gorm.DB, currency string, date time.Time, override *float64) (decimal.Decimal, error) {
+	if override != nil && *override > 0 {
+		return decimal.NewFromFloat(*override), nil
+	}
+	return s.getRateWithDB(tx, currency, date)
+}
+
+func (s *CurrencyService) getRateWithDB(db *gorm.DB, currency string, date time.Time) (decimal.Decimal, error) {
+	code := normalizeCurrencyCode(currency)
+	if !isForeignCurrency(code) {
+		return decimal.NewFromInt(1), nil
+	}
+
+	var rate models.ExchangeRate
+	if err := db.Where("currency_code = ? AND rate_date <= ?", code, date).
+		Order("rate_date DESC").
+		First(&rate).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return decimal.Zero, fmt.Errorf("no exchange rate for %s on or before %s", code, date.Format("2006-01-02"))
+		}
+		return decimal.Zero, err
+	}
+	return decimal.NewFromFloat(rate.Rate), nil
+}
+
+func (s *CurrencyService) upsertExchangeRate(db *gorm.DB, req ExchangeRateRequest, source string, userID uint) (*models.ExchangeRate, bool, error) {
+	code := normalizeCurrencyCode(req.CurrencyCode)
+	if !isForeignCurrency(code) {
+		return nil, false, fmt.Errorf("exchange rate for base currency %s is always 1", models.BaseCurrency)
+	}
+	if len(code) != 3 {
+		return nil, false, fmt.Errorf("invalid currency code '%s'", req.CurrencyCode)
+	}
+	if req.Rate <= 0 {
+		return nil, false, errors.New("rate must be greater than zero")
+	}
+	rateDate := time.Date(req.RateDate.Year(), req.RateDate.Month(), req.RateDate.Day(), 0, 0, 0, 0, time.UTC)
+
+	var rate models.ExchangeRate
+	err := db.Where("currency_code = ? AND rate_date = ?", code, rateDate).First(&rate).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, false, err
+	}
+	created := errors.Is(err, gorm.ErrRecordNotFound)
+
+	rate.CurrencyCode = code
+	rate.RateDate = rateDate
+	rate.Rate = req.Rate
+	rate.Source = source
+	rate.Notes = req.Notes
+	rate.CreatedBy = userID
+
+	if err := db.Save(&rate).Error; err != nil {
+		return nil, false, fmt.Errorf("failed to save exchange rate: %v", err)
+	}
+	return &rate, created, nil
+}
+
+// ========== REALIZED GAIN / LOSS ==========
+
+// NewDocumentSettlement resolves the settlement rate for a payment on an invoice or bill. Every payment
+// path uses it, so a foreign currency outstanding is never reduced by a base currency amount.
+func (s *CurrencyService) NewDocumentSettlement(tx *gorm.DB, itemType, referenceCode, currency string, bookRate, outstanding float64, date time.Time, override *float64) (*DocumentSettlement, error) {
+	settlement := &DocumentSettlement{
+		ItemType:        itemType,
+		ReferenceCode:   referenceCode,
+		CurrencyCode:    normalizeCurrencyCode(currency),
+		BookRate:        bookRate,
+		Rate:            decimal.NewFromInt(1),
+		OutstandingBase: outstanding,
+	}
+	if !settlement.IsForeign() {
+		return settlement, nil
+	}
+
+	rate, err := s.ResolveSettlementRate(tx, settlement.CurrencyCode, date, override)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", referenceCode, err)
+	}
+	settlement.Rate = rate
+	settlement.OutstandingBase = convertToBaseCurrency(decimal.NewFromFloat(outstanding), rate).InexactFloat64()
+	return settlement, nil
+}
+
+// IsForeign reports whether the document is in a foreign currency
+func (d *DocumentSettlement) IsForeign() bool {
+	return isForeignCurrency(d.CurrencyCode)
+}
+
+// Settle returns the document currency amount a base currency payment takes off the outstanding, and
+// the realized exchange difference for PostRealizedFXGainLoss (nil for base currency documents)
+func (d *DocumentSettlement) Settle(baseAmount float64) (float64, *ForeignSettlement) {
+	if !d.IsForeign() {
+		return baseAmount, nil
+	}
+	fx := NewForeignSettlement(d.ItemType, d.ReferenceCode, d.CurrencyCode, baseAmount, d.BookRate, d.Rate)
+	return fx.ForeignAmount.InexactFloat64(), fx
+}
+
+// settledOutstanding subtracts a settled amount and clears the remainder left by conversion rounding
+func settledOutstanding(outstanding, settled float64, fx *ForeignSettlement) float64 {
+	remaining := decimal.NewFromFloat(outstanding).Sub(decimal.NewFromFloat(settled)).Round(2)
+	if fx != nil && remaining.LessThanOrEqual(decimal.NewFromFloat(0.01)) {
+		return 0
+	}
+	return remaining.InexactFloat64()
+}
+
+// NewForeignSettlement converts a base currency payment allocation into the document currency
+func NewForeignSettlement(itemType, referenceCode, currency string, baseAmount, bookRate float64, settlementRate decimal.Decimal) *ForeignSettlement {
+	base := decimal.NewFromFloat(baseAmount)
+	book := decimal.NewFromFloat(bookRate)
+	if book.LessThanOrEqual(decimal.Zero) {
+		book = decimal.NewFromInt(1)
+	}
+
+	foreign := base.Div(settlementRate).Round(2)
+	// Receivable: receiving more base currency than booked is a gain.
+	// Payable: paying more base currency than booked is a loss.
+	diff := foreign.Mul(settlementRate.Sub(book)).Round(2)
+	if itemType == models.FXItemTypePayable {
+		diff = diff.Neg()
+	}
+
+	return &ForeignSettlement{
+		ItemType:       itemType,
+		ReferenceCode:  referenceCode,
+		CurrencyCode:   normalizeCurrencyCode(currency),
+		BaseAmount:     base,
+		ForeignAmount:  foreign,
+		BookRate:       book,
+		SettlementRate: settlementRate,
+		GainLoss:       diff,
+	}
+}
+
+// PostRealizedFXGainLoss posts the realized exchange difference of a settlement.
+// The payment journal clears AR/AP at the settlement rate; this entry trues the
+// AR/AP account back to the booked amount against the FX gain or loss account.
+// sourceID, reference and date identify the payment the settlement belongs to.
+func (s *CurrencyService) PostRealizedFXGainLoss(tx *gorm.DB, settlement *ForeignSettlement, sourceID uint, reference string, date time.Time, userID uint) (*models.SSOTJournalEntry, error) {
+	if settlement == nil || settlement.GainLoss.IsZero() {
+		return nil, nil
+	}
+
+	controlAccount, err := s.controlAccount(tx, settlement.ItemType)
+	if err != nil {
+		return nil, err
+	}
+	gainAccount, err := s.resolveAccountByCode(tx, FXGainAccountCode)
+	if err != nil {
+		return nil, err
+	}
+	lossAccount, err := s.resolveAccountByCode(tx, FXLossAccountCode)
+	if err != nil {
+		return nil, err
+	}
+
+	amount := settlement.GainLoss.Abs()
+	description := fmt.Sprintf("Selisih kurs terealisasi %s %s (kurs %s → %s)",
+		settlement.ReferenceCode, settlement.CurrencyCode,
+		settlement.BookRate.StringFixed(2), settlement.SettlementRate.StringFixed(2))
+
+	// Gain: receivable under-cleared / payable over-cleared -> debit the control account.
+	// Loss: the opposite, against the FX loss account.
+	var lines []JournalLineRequest
+	isGain := settlement.GainLoss.GreaterThan(decimal.Zero)
+	if isGain {
+		lines = []JournalLineRequest{
+			{AccountID: uint64(controlAccount.ID), DebitAmount: amount, Description: description},
+			{AccountID: uint64(gainAccount.ID), CreditAmount: amount, Description: description},
+		}
+	} else {
+		lines = []JournalLineRequest{
+			{AccountID: uint64(lossAccount.ID), DebitAmount: amount, Description: description},
+			{AccountID: uint64(controlAccount.ID), CreditAmount: amount, Description: description},
+		}
+	}
+
+	entry, err := s.journalService.CreateJournalEntryWithTx(tx, &JournalEntryRequest{
+		SourceType:  models.SSOTSourceTypeAdjustment,
+		SourceID:    uint64(sourceID),
+		Reference:   reference,
+		EntryDate:   date,
+		Description: description,
+		Lines:       lines,
+		AutoPost:    true,
+		CreatedBy:   uint64(userID),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to post realized FX gain/loss: %v", err)
+	}
+
+	log.Printf("💱 Realized FX %s for %s: %s (journal #%d)",
+		map[bool]string{true: "gain", false: "loss"}[isGain], settlement.ReferenceCode, amount.StringFixed(2), entry.ID)
+	return entry, nil
+}
+
+// ========== MONTH-END REVALUATION ==========
+
+// GetRevaluations - Daftar revaluasi selisih kurs
+func (s *CurrencyService) GetRevaluations() ([]models.FXRevaluation, error) {
+	var runs []models.FXRevaluation
+	if err := s.db.Order("period_end_date DESC, id DESC").Find(&runs).Error; err != nil {
+		return nil, err
+	}
+	return runs, nil
+}
+
+// GetRevaluationByID - Detail revaluasi beserta itemnya
+func (s *CurrencyService) GetRevaluationByID(id uint) (*models.FXRevaluation, error) {
+	var run models.FXRevaluation
+	if err := s.db.Preload("Items").First(&run, id).Error; err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+// RunRevaluation revalues open foreign currency receivables, payables and bank balances at the
+// period-end rate. The unrealized gain/loss journal is dated on the period end and an automatic
+// reversal is posted on the first day of the next period, so each run starts from booked rates.
+func (s *CurrencyService) RunRevaluation(req FXRevaluationRequest, userID uint) (*models.FXRevaluation, error) {
+	periodEnd := time.Date(req.PeriodEndDate.Year(), req.PeriodEndDate.Month(), req.PeriodEndDate.Day(), 0, 0, 0, 0, time.UTC)
+	reversalDate := periodEnd.AddDate(0, 0, 1)
+	cutoff := reversalDate // entries dated before the reversal date belong to the period
+
+	closed, err := s.periodService.IsDateInClosedPeriod(context.Background(), periodEnd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check accounting period: %v", err)
+	}
+	if closed {
+		return nil, fmt.Errorf("accounting period of %s is closed", periodEnd.Format("2006-01-02"))
+	}
+
+	var existing int64
+	if err := s.db.Model(&models.FXRevaluation{}).
+		Where("period_end_date = ? AND status = ?", periodEnd, models.FXRevaluationStatusPosted).
+		Count(&existing).Error; err != nil {
+		return nil, err
+	}
+	if existing > 0 {
+		return nil, fmt.Errorf("revaluation for %s has already been posted", periodEnd.Format("2006-01-02"))
+	}
+
+	var run *models.FXRevaluation
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		items, err := s.collectRevaluationItems(tx, periodEnd, cutoff)
+		if err != nil {
+			return err
+		}
+		if len(items) == 0 {
+			return errors.New("no open foreign currency balances to revalue")
+		}
+
+		code, err := s.generateRevaluationCode(tx, periodEnd)
+		if err != nil {
+			return err
+		}
+
+		run = &models.FXRevaluation{
+			Code:          code,
+			PeriodEndDate: periodEnd,
+			ReversalDate:  reversalDate,
+			Status:        models.FXRevaluationStatusPosted,
+			Notes:         req.Notes,
+			CreatedBy:     userID,
+		}
+
+		netByAccount := make(map[uint]decimal.Decimal)
+		totalGain, totalLoss := decimal.Zero, decimal.Zero
+		for _, item := range items {
+			diff := decimal.NewFromFloat(item.Difference)
+			if diff.GreaterThan(decimal.Zero) {
+				totalGain = totalGain.Add(diff)
+			} else {
+				totalLoss = totalLoss.Add(diff.Abs())
+			}
+			netByAccount[item.AccountID] = netByAccount[item.AccountID].Add(diff)
+		}
+		run.TotalGain = totalGain.InexactFloat64()
+		run.TotalLoss = totalLoss.InexactFloat64()
+		run.NetAmount = totalGain.Sub(totalLoss).InexactFloat64()
+
+		if err := tx.Create(run).Error; err != nil {
+			return fmt.Errorf("failed to create revaluation: %v", err)
+		}
+		for i := range items {
+			items[i].RevaluationID = run.ID
+		}
+		if err := tx.Create(&items).Error; err != nil {
+			return fmt.Errorf("failed to create revaluation items: %v", err)
+		}
+		run.Items = items
+
+		lines, err := s.buildRevaluationLines(tx, run, netByAccount, totalGain, totalLoss)
+		if err != nil {
+			return err
+		}
+		if len(lines) < 2 {
+			log.Printf("💱 Revaluation %s has no exchange differences, no journal posted", run.Code)
+			return nil
+		}
+
+		journal, err := s.journalService.CreateJournalEntryWithTx(tx, &JournalEntryRequest{
+			SourceType:  models.SSOTSourceTypeAdjustment,
+			SourceID:    uint64(run.ID),
+			Reference:   run.Code,
+			EntryDate:   periodEnd,
+			Description: fmt.Sprintf("Revaluasi selisih kurs belum terealisasi %s", periodEnd.Format("02/01/2006")),
+			Lines:       lines,
+			AutoPost:    true,
+			CreatedBy:   uint64(userID),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to post revaluation journal: %v", err)
+		}
+
+		reversalLines := make([]JournalLineRequest, len(lines))
+		for i, line := range lines {
+			reversalLines[i] = JournalLineRequest{
+				AccountID:    line.AccountID,
+				DebitAmount:  line.CreditAmount,
+				CreditAmount: line.DebitAmount,
+				Description:  "Pembalik: " + line.Description,
+			}
+		}
+		reversal, err := s.journalService.CreateJournalEntryWithTx(tx, &JournalEntryRequest{
+			SourceType:  models.SSOTSourceTypeAdjustment,
+			SourceID:    uint64(run.ID),
+			Reference:   run.Code,
+			EntryDate:   reversalDate,
+			Description: fmt.Sprintf("Pembalik revaluasi selisih kurs %s", periodEnd.Format("02/01/2006")),
+			Lines:       reversalLines,
+			AutoPost:    true,
+			CreatedBy:   uint64(userID),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to post revaluation reversal: %v", err)
+		}
+		if err := tx.Model(&models.SSOTJournalEntry{}).Where("id = ?", reversal.ID).Updates(map[string]interface{}{
+			"reversed_from":   journal.ID,
+			"reversal_reason": fmt.Sprintf("Automatic reversal of FX revaluation %s", run.Code),
+		}).Error; err != nil {
+			return fmt.Errorf("failed to link revaluation reversal: %v", err)
+		}
+
+		run.JournalID = &journal.ID
+		run.ReversalJournalID = &reversal.ID
+		return tx.Model(run).Updates(map[string]interface{}{
+			"journal_id":          journal.ID,
+			"reversal_journal_id": reversal.ID,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("💱 FX revaluation %s posted: gain=%.2f loss=%.2f", run.Code, run.TotalGain, run.TotalLoss)
+	return run, nil
+}
+
+// CancelRevaluation cancels a revaluation together with its automatic reversal.
+// Both journals carry opposite amounts, so cancelling the pair leaves balances unchanged.
+// Neither journal may fall in a closed period.
+func (s *CurrencyService) CancelRevaluation(id uint) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var run models.FXRevaluation
+		if err := tx.First(&run, id).Error; err != nil {
+			return errors.New("revaluation not found")
+		}
+		if run.Status != models.FXRevaluationStatusPosted {
+			return fmt.Errorf("revaluation is already %s", run.Status)
+		}
+		for _, date := range []time.Time{run.PeriodEndDate, run.ReversalDate} {
+			closed, err := s.periodService.IsDateInClosedPeriod(context.Background(), date)
+			if err != nil {
+				return fmt.Errorf("failed to check accounting period: %v", err)
+			}
+			if closed {
+				return fmt.Errorf("accounting period of %s is closed", date.Format("2006-01-02"))
+			}
+		}
+
+		var journalIDs []uint64
+		if run.JournalID != nil {
+			journalIDs = append(journalIDs, *run.JournalID)
+		}
+		if run.ReversalJournalID != nil {
+			journalIDs = append(journalIDs, *run.ReversalJournalID)
+		}
+		if len(journalIDs) > 0 {
+			if err := tx.Model(&models.SSOTJournalEntry{}).Where("id IN ?", journalIDs).
+				Update("status", models.SSOTStatusCancelled).Error; err != nil {
+				return fmt.Errorf("failed to cancel revaluation journals: %v", err)
+			}
+		}
+
+		return tx.Model(&run).Update("status", models.FXRevaluationStatusCancelled).Error
+	})
+}
+
+// collectRevaluationItems gathers open foreign currency documents and bank balances as of the period end
+func (s *CurrencyService) collectRevaluationItems(tx *gorm.DB, periodEnd, cutoff time.Time) ([]models.FXRevaluationItem, error) {
+	rates := make(map[string]decimal.Decimal)
+	closingRate := func(currency string) (decimal.Decimal, error) {
+		if rate, ok := rates[currency]; ok {
+			return rate, nil
+		}
+		rate, err := s.getRateWithDB(tx, currency, periodEnd)
+		if err != nil {
+			return decimal.Zero, err
+		}
+		rates[currency] = rate
+		return rate, nil
+	}
+
+	var items []models.FXRevaluationItem
+
+	// Open receivables
+	arAccount, err := s.controlAccount(tx, models.FXItemTypeReceivable)
+	if err != nil {
+		return nil, err
+	}
+	var sales []models.Sale
+	if err := tx.Where("currency <> ? AND currency <> '' AND outstanding_amount > 0 AND date < ?", models.BaseCurrency, cutoff).
+		Where("status IN ?", []string{models.SaleStatusInvoiced, models.SaleStatusOverdue}).
+		Find(&sales).Error; err != nil {
+		return nil, fmt.Errorf("failed to load foreign currency receivables: %v", err)
+	}
+	for _, sale := range sales {
+		rate, err := closingRate(normalizeCurrencyCode(sale.Currency))
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, buildRevaluationItem(models.FXItemTypeReceivable, sale.ID, sale.InvoiceNumber,
+			arAccount.ID, sale.Currency, sale.OutstandingAmount, sale.ExchangeRate, rate))
+	}
+
+	// Open payables
+	apAccount, err := s.controlAccount(tx, models.FXItemTypePayable)
+	if err != nil {
+		return nil, err
+	}
+	var purchases []models.Purchase
+	if err := tx.Where("currency <> ? AND currency <> '' AND outstanding_amount > 0 AND date < ?", models.BaseCurrency, cutoff).
+		Where("status IN ?", []string{models.PurchaseStatusApproved, models.PurchaseStatusCompleted}).
+		Find(&purchases).Error; err != nil {
+		return nil, fmt.Errorf("failed to load foreign currency payables: %v", err)
+	}
+	for _, purchase := range purchases {
+		rate, err := closingRate(normalizeCurrencyCode(purchase.Currency))
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, buildRevaluationItem(models.FXItemTypePayable, purchase.ID, purchase.Code,
+			apAccount.ID, purchase.Currency, purchase.OutstandingAmount, purchase.ExchangeRate, rate))
+	}
+
+	// Foreign currency bank accounts: cash_banks.balance is kept in the account currency, so the balance
+	// at the period end is the current balance less the transactions dated after it. The book amount is
+	// the base currency balance of the linked account; earlier revaluations net out with their reversals.
+	var cashBanks []models.CashBank
+	if err := tx.Where("currency <> ? AND currency <> '' AND account_id > 0 AND is_active = ?", models.BaseCurrency, true).
+		Find(&cashBanks).Error; err != nil {
+		return nil, fmt.Errorf("failed to load foreign currency bank accounts: %v", err)
+	}
+	for _, cb := range cashBanks {
+		currency := normalizeCurrencyCode(cb.Currency)
+		var laterMovements, bookBalance float64
+		if err := tx.Model(&models.CashBankTransaction{}).
+			Select("COALESCE(SUM(amount), 0)").
+			Where("cash_bank_id = ? AND transaction_date >= ?", cb.ID, cutoff).
+			Scan(&laterMovements).Error; err != nil {
+			return nil, fmt.Errorf("failed to compute balance for %s: %v", cb.Name, err)
+		}
+		if err := tx.Raw(`
+			SELECT COALESCE(SUM(ujl.debit_amount - ujl.credit_amount), 0)
+			FROM unified_journal_lines ujl
+			JOIN unified_journal_ledger uje ON uje.id = ujl.journal_id
+			WHERE ujl.account_id = ?
+			  AND uje.status = 'POSTED'
+			  AND uje.deleted_at IS NULL
+			  AND uje.entry_date < ?
+		`, cb.AccountID, cutoff).Scan(&bookBalance).Error; err != nil {
+			return nil, fmt.Errorf("failed to compute book balance for %s: %v", cb.Name, err)
+		}
+		foreign := decimal.NewFromFloat(cb.Balance - laterMovements).Round(2)
+		book := decimal.NewFromFloat(bookBalance).Round(2)
+		if foreign.IsZero() && book.IsZero() {
+			continue
+		}
+
+		rate, err := closingRate(currency)
+		if err != nil {
+			return nil, err
+		}
+		revalued := foreign.Mul(rate).Round(2)
+		bookRate := decimal.Zero
+		if !foreign.IsZero() {
+			bookRate = book.Div(foreign).Round(6)
+		}
+		items = append(items, models.FXRevaluationItem{
+			ItemType:       models.FXItemTypeCashBank,
+			ReferenceID:    cb.ID,
+			ReferenceCode:  cb.Code,
+			AccountID:      cb.AccountID,
+			CurrencyCode:   currency,
+			ForeignAmount:  foreign.InexactFloat64(),
+			BookRate:       bookRate.InexactFloat64(),
+			BookAmount:     book.InexactFloat64(),
+			ClosingRate:    rate.InexactFloat64(),
+			RevaluedAmount: revalued.InexactFloat64(),
+			Difference:     revalued.Sub(book).InexactFloat64(),
+		})
+	}
+
+	return items, nil
+}
+
+// buildRevaluationLines nets the differences per control account against FX gain/loss
+func (s *CurrencyService) buildRevaluationLines(tx *gorm.DB, run *models.FXRevaluation, netByAccount map[uint]decimal.Decimal, totalGain, totalLoss decimal.Decimal) ([]JournalLineRequest, error) {
+	accountIDs := make([]uint, 0, len(netByAccount))
+	for id := range netByAccount {
+		accountIDs = append(accountIDs, id)
+	}
+	sort.Slice(accountIDs, func(i, j int) bool { return accountIDs[i] < accountIDs[j] })
+
+	description := fmt.Sprintf("Revaluasi selisih kurs %s", run.Code)
+	var lines []JournalLineRequest
+	for _, id := range accountIDs {
+		net := netByAccount[id]
+		if net.IsZero() {
+			continue
+		}
+		// Gains increase assets / decrease liabilities (debit); losses do the opposite
+		if net.GreaterThan(decimal.Zero) {
+			lines = append(lines, JournalLineRequest{AccountID: uint64(id), DebitAmount: net, Description: description})
+		} else {
+			lines = append(lines, JournalLineRequest{AccountID: uint64(id), CreditAmount: net.Abs(), Description: description})
+		}
+	}
+
+	if totalGain.GreaterThan(decimal.Zero) {
+		gainAccount, err := s.resolveAccountByCode(tx, FXGainAccountCode)
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, JournalLineRequest{AccountID: uint64(gainAccount.ID), CreditAmount: totalGain, Description: description})
+	}
+	if totalLoss.GreaterThan(decimal.Zero) {
+		lossAccount, err := s.resolveAccountByCode(tx, FXLossAccountCode)
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, JournalLineRequest{AccountID: uint64(lossAccount.ID), DebitAmount: totalLoss, Description: description})
+	}
+	return lines, nil
+}
+
+func (s *CurrencyService) generateRevaluationCode(tx *gorm.DB, periodEnd time.Time) (string, error) {
+	var count int64
+	if err := tx.Unscoped().Model(&models.FXRevaluation{}).
+		Where("period_end_date = ?", periodEnd).
+		Count(&count).Error; err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("FXR-%s-%02d", periodEnd.Format("20060102"), count+1), nil
+}
+
+// controlAccount returns the receivable or payable account configured in the account settings
+func (s *CurrencyService) controlAccount(tx *gorm.DB, itemType string) (*models.Account, error) {
+	if itemType == models.FXItemTypePayable {
+		return s.taxAccountHelper.GetPayableAccount(tx)
+	}
+	return s.taxAccountHelper.GetReceivableAccount(tx)
+}
+
+func (s *CurrencyService) resolveAccountByCode(tx *gorm.DB, code string) (*models.Account, error) {
+	var account models.Account
+	if err := tx.Where("code = ?", code).First(&account).Error; err != nil {
+		return nil, fmt.Errorf("account code %s not found: %v", code, err)
+	}
+	return &account, nil
+}
+
+// buildRevaluationItem revalues an open document; Difference is positive for a gain
+func buildRevaluationItem(itemType string, referenceID uint, referenceCode string, accountID uint, currency string, outstanding, bookRate float64, closingRate decimal.Decimal) models.FXRevaluationItem {
+	foreign := decimal.NewFromFloat(outstanding)
+	book := foreign.Mul(decimal.NewFromFloat(bookRate)).Round(2)
+	revalued := foreign.Mul(closingRate).Round(2)
+
+	diff := revalued.Sub(book)
+	if itemType == models.FXItemTypePayable {
+		diff = diff.Neg()
+	}
+
+	return models.FXRevaluationItem{
+		ItemType:       itemType,
+		ReferenceID:    referenceID,
+		ReferenceCode:  referenceCode,
+		AccountID:      accountID,
+		CurrencyCode:   normalizeCurrencyCode(currency),
+		ForeignAmount:  outstanding,
+		BookRate:       bookRate,
+		BookAmount:     book.InexactFloat64(),
+		ClosingRate:    closingRate.InexactFloat64(),
+		RevaluedAmount: revalued.InexactFloat64(),
+		Difference:     diff.InexactFloat64(),
+	}
+}
+
+// convertToBaseCurrency converts a document currency amount to base currency (rounded to 2 decimals)
+func convertToBaseCurrency(amount, rate decimal.Decimal) decimal.Decimal {
+	return amount.Mul(rate).Round(2)
+}
+
+// documentExchangeRate returns the booked rate of a document, defaulting to 1 for base currency
+func documentExchangeRate(currency string, rate float64) decimal.Decimal {
+	if !isForeignCurrency(currency) || rate <= 0 {
+		return decimal.NewFromInt(1)
+	}
+	return decimal.NewFromFloat(rate)
+}
+
+func normalizeCurrencyCode(code string) string {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if code == "" {
+		return models.BaseCurrency
+	}
+	return code
+}
+
+func isForeignCurrency(code string) bool {
+	return normalizeCurrencyCode(code) != models.BaseCurrency
+}
@@ -0,0 +1,283 @@
+package services
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"app-sistem-akuntansi/models"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestDocumentSettlementSettle(t *testing.T) {
+	tests := []struct {
+		name         string
+		itemType     string
+		currency     string
+		bookRate     float64
+		rate         float64
+		baseAmount   float64
+		wantSettled  float64
+		wantGainLoss float64
+		wantFX       bool
+	}{
+		{name: "base currency document", itemType: models.FXItemTypeReceivable, currency: "IDR", rate: 1, baseAmount: 1500000, wantSettled: 1500000},
+		{name: "empty currency is base", itemType: models.FXItemTypePayable, currency: "", rate: 1, baseAmount: 250000, wantSettled: 250000},
+		{name: "receivable paid at a higher rate is a gain", itemType: models.FXItemTypeReceivable, currency: "usd", bookRate: 15000, rate: 15500, baseAmount: 1550000, wantSettled: 100, wantGainLoss: 50000, wantFX: true},
+		{name: "receivable paid at a lower rate is a loss", itemType: models.FXItemTypeReceivable, currency: "USD", bookRate: 15000, rate: 14800, baseAmount: 1480000, wantSettled: 100, wantGainLoss: -20000, wantFX: true},
+		{name: "payable paid at a higher rate is a loss", itemType: models.FXItemTypePayable, currency: "USD", bookRate: 15000, rate: 15500, baseAmount: 775000, wantSettled: 50, wantGainLoss: -25000, wantFX: true},
+		{name: "payable paid at a lower rate is a gain", itemType: models.FXItemTypePayable, currency: "SGD", bookRate: 11500, rate: 11000, baseAmount: 1100000, wantSettled: 100, wantGainLoss: 50000, wantFX: true},
+		{name: "same rate has no difference", itemType: models.FXItemTypeReceivable, currency: "USD", bookRate: 15000, rate: 15000, baseAmount: 300000, wantSettled: 20, wantFX: true},
+		{name: "missing book rate counts as 1", itemType: models.FXItemTypeReceivable, currency: "USD", rate: 15000, baseAmount: 150000, wantSettled: 10, wantGainLoss: 149990, wantFX: true},
+		{name: "partial payment rounds to cents", itemType: models.FXItemTypeReceivable, currency: "USD", bookRate: 15000, rate: 15500, baseAmount: 1000000, wantSettled: 64.52, wantGainLoss: 32260, wantFX: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settlement := &DocumentSettlement{
+				ItemType:      tt.itemType,
+				ReferenceCode: "INV/2024/0001",
+				CurrencyCode:  normalizeCurrencyCode(tt.currency),
+				BookRate:      tt.bookRate,
+				Rate:          decimal.NewFromFloat(tt.rate),
+			}
+
+			settled, fx := settlement.Settle(tt.baseAmount)
+			assert.InDelta(t, tt.wantSettled, settled, 0.001)
+			if !tt.wantFX {
+				assert.Nil(t, fx)
+				return
+			}
+			require.NotNil(t, fx)
+			assert.Equal(t, tt.itemType, fx.ItemType)
+			assert.Equal(t, normalizeCurrencyCode(tt.currency), fx.CurrencyCode)
+			assert.InDelta(t, tt.wantGainLoss, fx.GainLoss.InexactFloat64(), 0.001)
+		})
+	}
+}
+
+func TestSettledOutstanding(t *testing.T) {
+	fx := &ForeignSettlement{}
+	tests := []struct {
+		name        string
+		outstanding float64
+		settled     float64
+		fx          *ForeignSettlement
+		want        float64
+	}{
+		{name: "partial payment", outstanding: 100, settled: 40, fx: fx, want: 60},
+		{name: "conversion remainder is cleared", outstanding: 100, settled: 99.99, fx: fx, want: 0},
+		{name: "overpayment is cleared", outstanding: 100, settled: 100.004, fx: fx, want: 0},
+		{name: "base currency keeps the remainder", outstanding: 100, settled: 99.99, want: 0.01},
+		{name: "base currency full payment", outstanding: 500000, settled: 500000, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.InDelta(t, tt.want, settledOutstanding(tt.outstanding, tt.settled, tt.fx), 0.0001)
+		})
+	}
+}
+
+// setupJournalTestDB migrates the SSOT journal tables plus the models a posting path needs. Services
+// such as the period check query through their own *gorm.DB while a posting transaction is open, so
+// the database is a WAL file that allows a second connection instead of a single-connection :memory:.
+func setupJournalTestDB(t *testing.T, extra ...interface{}) *gorm.DB {
+	dsn := filepath.Join(t.TempDir(), "journal.db") + "?_journal_mode=WAL&_busy_timeout=5000"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	t.Cleanup(func() { sqlDB.Close() })
+	migrate := append([]interface{}{
+		&models.Account{}, &models.SSOTJournalEntry{}, &models.SSOTJournalLine{},
+		&models.Dimension{}, &models.DimensionTag{}, &models.DimensionRule{},
+	}, extra...)
+	require.NoError(t, db.AutoMigrate(migrate...))
+	return db
+}
+
+// seedTestAccounts creates accounts keyed by code, e.g. {"1201": models.AccountTypeAsset}
+func seedTestAccounts(t *testing.T, db *gorm.DB, types map[string]string) map[string]models.Account {
+	accounts := make(map[string]models.Account, len(types))
+	for code, accountType := range types {
+		account := models.Account{Code: code, Name: "Account " + code, Type: accountType, IsActive: true}
+		require.NoError(t, db.Create(&account).Error)
+		accounts[code] = account
+	}
+	return accounts
+}
+
+// newTestTaxAccountHelper returns a helper without configured accounts, so every lookup falls back
+// to the default account code inside the caller's transaction
+func newTestTaxAccountHelper(db *gorm.DB) *TaxAccountHelper {
+	helper := NewTaxAccountHelper(db)
+	helper.cachedSettings = &models.TaxAccountSettings{}
+	return helper
+}
+
+// assertJournalsBalanced checks that every journal entry and its lines balance and returns the entries
+func assertJournalsBalanced(t *testing.T, db *gorm.DB) []models.SSOTJournalEntry {
+	var entries []models.SSOTJournalEntry
+	require.NoError(t, db.Preload("Lines").Order("id").Find(&entries).Error)
+	for _, entry := range entries {
+		debit, credit := decimal.Zero, decimal.Zero
+		for _, line := range entry.Lines {
+			debit = debit.Add(line.DebitAmount)
+			credit = credit.Add(line.CreditAmount)
+		}
+		assert.True(t, debit.Equal(credit), "entry %s lines: debit %s != credit %s", entry.EntryNumber, debit, credit)
+		assert.True(t, entry.TotalDebit.Equal(debit), "entry %s total debit %s != lines %s", entry.EntryNumber, entry.TotalDebit, debit)
+		assert.True(t, entry.TotalCredit.Equal(credit), "entry %s total credit %s != lines %s", entry.EntryNumber, entry.TotalCredit, credit)
+	}
+	return entries
+}
+
+// journalAmountsByAccount nets an entry's lines per account code: positive is a debit
+func journalAmountsByAccount(t *testing.T, db *gorm.DB, entry models.SSOTJournalEntry) map[string]float64 {
+	amounts := make(map[string]float64)
+	for _, line := range entry.Lines {
+		var account models.Account
+		require.NoError(t, db.First(&account, line.AccountID).Error)
+		amounts[account.Code] += line.DebitAmount.Sub(line.CreditAmount).InexactFloat64()
+	}
+	return amounts
+}
+
+func setupCurrencyTestService(t *testing.T, extra ...interface{}) (*CurrencyService, *gorm.DB) {
+	db := setupJournalTestDB(t, append([]interface{}{&models.ExchangeRate{}}, extra...)...)
+	seedTestAccounts(t, db, map[string]string{
+		"1201":            models.AccountTypeAsset,
+		"2101":            models.AccountTypeLiability,
+		FXGainAccountCode: models.AccountTypeRevenue,
+		FXLossAccountCode: models.AccountTypeExpense,
+	})
+	return &CurrencyService{
+		db:               db,
+		journalService:   NewUnifiedJournalService(db),
+		periodService:    NewUnifiedPeriodClosingService(db),
+		taxAccountHelper: newTestTaxAccountHelper(db),
+	}, db
+}
+
+func TestNewDocumentSettlement(t *testing.T) {
+	service, db := setupCurrencyTestService(t)
+	require.NoError(t, db.Create(&models.ExchangeRate{CurrencyCode: "USD", RateDate: time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC), Rate: 15500}).Error)
+	paidOn := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+
+	settlement, err := service.NewDocumentSettlement(db, models.FXItemTypeReceivable, "INV/2024/0001", "usd", 15000, 100, paidOn, nil)
+	require.NoError(t, err)
+	assert.True(t, settlement.IsForeign())
+	assert.Equal(t, "15500", settlement.Rate.String(), "rate table on or before the payment date")
+	assert.InDelta(t, 1550000, settlement.OutstandingBase, 0.001)
+
+	override := 15250.0
+	settlement, err = service.NewDocumentSettlement(db, models.FXItemTypeReceivable, "INV/2024/0001", "USD", 15000, 100, paidOn, &override)
+	require.NoError(t, err)
+	assert.Equal(t, "15250", settlement.Rate.String(), "rate on the payment wins")
+	assert.InDelta(t, 1525000, settlement.OutstandingBase, 0.001)
+
+	settlement, err = service.NewDocumentSettlement(db, models.FXItemTypePayable, "PO/2024/0001", "IDR", 0, 500000, paidOn, nil)
+	require.NoError(t, err)
+	assert.False(t, settlement.IsForeign())
+	assert.InDelta(t, 500000, settlement.OutstandingBase, 0.001)
+
+	_, err = service.NewDocumentSettlement(db, models.FXItemTypeReceivable, "INV/2024/0002", "EUR", 17000, 100, paidOn, nil)
+	assert.ErrorContains(t, err, "no exchange rate for EUR")
+}
+
+func TestPostRealizedFXGainLossBalances(t *testing.T) {
+	service, db := setupCurrencyTestService(t)
+	paidOn := time.Date(2024, 2, 5, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		settlement *ForeignSettlement
+		want       map[string]float64
+	}{
+		{
+			name:       "receivable gain",
+			settlement: NewForeignSettlement(models.FXItemTypeReceivable, "INV/2024/0001", "USD", 1550000, 15000, decimal.NewFromInt(15500)),
+			want:       map[string]float64{"1201": 50000, FXGainAccountCode: -50000},
+		},
+		{
+			name:       "receivable loss",
+			settlement: NewForeignSettlement(models.FXItemTypeReceivable, "INV/2024/0002", "USD", 1480000, 15000, decimal.NewFromInt(14800)),
+			want:       map[string]float64{FXLossAccountCode: 20000, "1201": -20000},
+		},
+		{
+			name:       "payable loss",
+			settlement: NewForeignSettlement(models.FXItemTypePayable, "PO/2024/0001", "USD", 775000, 15000, decimal.NewFromInt(15500)),
+			want:       map[string]float64{FXLossAccountCode: 25000, "2101": -25000},
+		},
+		{
+			name:       "payable gain",
+			settlement: NewForeignSettlement(models.FXItemTypePayable, "PO/2024/0002", "USD", 740000, 15000, decimal.NewFromInt(14800)),
+			want:       map[string]float64{"2101": 10000, FXGainAccountCode: -10000},
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var entry *models.SSOTJournalEntry
+			require.NoError(t, db.Transaction(func(tx *gorm.DB) error {
+				var err error
+				entry, err = service.PostRealizedFXGainLoss(tx, tt.settlement, uint(i+1), "PAY/2024/0001", paidOn, 1)
+				return err
+			}))
+			require.NotNil(t, entry)
+
+			var stored models.SSOTJournalEntry
+			require.NoError(t, db.Preload("Lines").First(&stored, entry.ID).Error)
+			assert.Equal(t, models.SSOTStatusPosted, stored.Status)
+			assert.Equal(t, tt.want, journalAmountsByAccount(t, db, stored))
+		})
+	}
+
+	t.Run("no difference posts nothing", func(t *testing.T) {
+		entry, err := service.PostRealizedFXGainLoss(db, NewForeignSettlement(models.FXItemTypeReceivable, "INV/2024/0003", "USD", 300000, 15000, decimal.NewFromInt(15000)), 9, "PAY/2024/0002", paidOn, 1)
+		require.NoError(t, err)
+		assert.Nil(t, entry)
+	})
+
+	assert.Len(t, assertJournalsBalanced(t, db), len(tests))
+}
+
+func TestRunRevaluationBalances(t *testing.T) {
+	service, db := setupCurrencyTestService(t, &models.AccountingPeriod{}, &models.FXRevaluation{}, &models.FXRevaluationItem{},
+		&models.Sale{}, &models.Purchase{}, &models.CashBank{}, &models.CashBankTransaction{})
+	periodEnd := time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)
+	require.NoError(t, db.Create(&models.ExchangeRate{CurrencyCode: "USD", RateDate: periodEnd, Rate: 15500}).Error)
+	require.NoError(t, db.Create(&models.Sale{
+		Code: "SO-0001", InvoiceNumber: "INV/2024/0001", CustomerID: 1, UserID: 1, Date: periodEnd.AddDate(0, 0, -20),
+		Currency: "USD", ExchangeRate: 15000, OutstandingAmount: 100, Status: models.SaleStatusInvoiced,
+	}).Error)
+	require.NoError(t, db.Create(&models.Purchase{
+		Code: "PO-0001", VendorID: 1, UserID: 1, Date: periodEnd.AddDate(0, 0, -10),
+		Currency: "USD", ExchangeRate: 15000, OutstandingAmount: 40, Status: models.PurchaseStatusApproved,
+	}).Error)
+
+	run, err := service.RunRevaluation(FXRevaluationRequest{PeriodEndDate: periodEnd}, 1)
+	require.NoError(t, err)
+	assert.InDelta(t, 50000, run.TotalGain, 0.001)
+	assert.InDelta(t, 20000, run.TotalLoss, 0.001)
+	require.NotNil(t, run.JournalID)
+	require.NotNil(t, run.ReversalJournalID)
+
+	entries := assertJournalsBalanced(t, db)
+	require.Len(t, entries, 2)
+	revaluation := map[string]float64{"1201": 50000, "2101": -20000, FXGainAccountCode: -50000, FXLossAccountCode: 20000}
+	assert.Equal(t, revaluation, journalAmountsByAccount(t, db, entries[0]))
+	reversal := make(map[string]float64, len(revaluation))
+	for code, amount := range revaluation {
+		reversal[code] = -amount
+	}
+	assert.Equal(t, reversal, journalAmountsByAccount(t, db, entries[1]))
+	assert.True(t, entries[1].EntryDate.Equal(periodEnd.AddDate(0, 0, 1)))
+}
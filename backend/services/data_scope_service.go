@@ -0,0 +1,463 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"app-sistem-akuntansi/models"
+
+	"gorm.io/gorm"
+)
+
+// DataScopeService - Pembatasan data per user/role (record sendiri, sales person, gudang, akun kas/bank,
+// batas nominal) di atas izin modul
+type DataScopeService struct {
+	db *gorm.DB
+}
+
+func NewDataScopeService(db *gorm.DB) *DataScopeService {
+	return &DataScopeService{db: db}
+}
+
+// dataScopePermissionModules - Modul izin (ModulePermissionRecord) yang menjaga tiap modul scope
+var dataScopePermissionModules = map[string]string{
+	models.DataScopeModuleSales:              "sales",
+	models.DataScopeModulePurchases:          "purchases",
+	models.DataScopeModuleWarehouseLocations: "products",
+	models.DataScopeModuleCashBank:           "cash_bank",
+}
+
+// ========== SCOPE ADMINISTRATION ==========
+
+// GetScopes - Daftar data scope sesuai filter
+func (s *DataScopeService) GetScopes(filter models.DataScopeFilter) ([]models.DataScope, error) {
+	query := s.db.Preload("User").Order("module, role, user_id")
+	if filter.UserID != 0 {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	if filter.Role != "" {
+		query = query.Where("role = ?", filter.Role)
+	}
+	if filter.Module != "" {
+		query = query.Where("module = ?", filter.Module)
+	}
+
+	var scopes []models.DataScope
+	if err := query.Find(&scopes).Error; err != nil {
+		return nil, err
+	}
+	return scopes, nil
+}
+
+// GetScopeByID - Detail satu data scope
+func (s *DataScopeService) GetScopeByID(id uint) (*models.DataScope, error) {
+	var scope models.DataScope
+	if err := s.db.Preload("User").First(&scope, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("data scope not found")
+		}
+		return nil, err
+	}
+	return &scope, nil
+}
+
+// CreateScope - Buat data scope baru untuk satu user atau satu role
+func (s *DataScopeService) CreateScope(request models.DataScopeRequest, userID uint) (*models.DataScope, error) {
+	scope := models.DataScope{IsActive: true, CreatedBy: userID}
+	applyDataScopeRequest(&scope, request)
+	if err := s.validateScope(&scope); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Create(&scope).Error; err != nil {
+		return nil, err
+	}
+	log.Printf("🔒 Data scope %d created for %s in module %s", scope.ID, dataScopeSubject(scope), scope.Module)
+	return s.GetScopeByID(scope.ID)
+}
+
+// UpdateScope - Ubah data scope; subjek dan modul ikut divalidasi ulang
+func (s *DataScopeService) UpdateScope(id uint, request models.DataScopeRequest) (*models.DataScope, error) {
+	scope, err := s.GetScopeByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	applyDataScopeRequest(scope, request)
+	if err := s.validateScope(scope); err != nil {
+		return nil, err
+	}
+
+	scope.User = nil
+	if err := s.db.Save(scope).Error; err != nil {
+		return nil, err
+	}
+	log.Printf("🔒 Data scope %d updated for %s in module %s", scope.ID, dataScopeSubject(*scope), scope.Module)
+	return s.GetScopeByID(scope.ID)
+}
+
+// DeleteScope - Hapus data scope; user kembali melihat semua data modul
+func (s *DataScopeService) DeleteScope(id uint) (*models.DataScope, error) {
+	scope, err := s.GetScopeByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.db.Delete(&models.DataScope{}, id).Error; err != nil {
+		return nil, err
+	}
+	log.Printf("🔓 Data scope %d deleted for %s in module %s", scope.ID, dataScopeSubject(*scope), scope.Module)
+	return scope, nil
+}
+
+func applyDataScopeRequest(scope *models.DataScope, request models.DataScopeRequest) {
+	scope.UserID = request.UserID
+	scope.Role = strings.TrimSpace(request.Role)
+	scope.Module = strings.TrimSpace(request.Module)
+	scope.OwnRecordsOnly = request.OwnRecordsOnly
+	scope.SalesPersonIDs = request.SalesPersonIDs
+	scope.WarehouseIDs = request.WarehouseIDs
+	scope.CashBankIDs = request.CashBankIDs
+	scope.MaxAmount = request.MaxAmount
+	scope.Notes = request.Notes
+	if request.IsActive != nil {
+		scope.IsActive = *request.IsActive
+	}
+}
+
+// validateScope - Satu subjek (user atau role), modul yang didukung, dan hanya pembatasan yang
+// berlaku untuk modul tersebut
+func (s *DataScopeService) validateScope(scope *models.DataScope) error {
+	if _, ok := dataScopePermissionModules[scope.Module]; !ok {
+		return fmt.Errorf("module %s does not support data scopes", scope.Module)
+	}
+	if (scope.UserID == nil) == (scope.Role == "") {
+		return fmt.Errorf("set either user_id or role")
+	}
+	if scope.UserID != nil {
+		var count int64
+		if err := s.db.Model(&models.User{}).Where("id = ?", *scope.UserID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			return fmt.Errorf("user %d not found", *scope.UserID)
+		}
+	}
+	if scope.Role == models.RoleAdmin {
+		return fmt.Errorf("administrators always see all data and cannot be scoped")
+	}
+
+	switch {
+	case len(scope.SalesPersonIDs) > 0 && scope.Module != models.DataScopeModuleSales:
+		return fmt.Errorf("sales_person_ids only apply to module %s", models.DataScopeModuleSales)
+	case len(scope.WarehouseIDs) > 0 && scope.Module != models.DataScopeModuleWarehouseLocations:
+		return fmt.Errorf("warehouse_ids only apply to module %s", models.DataScopeModuleWarehouseLocations)
+	case len(scope.CashBankIDs) > 0 && scope.Module != models.DataScopeModuleCashBank:
+		return fmt.Errorf("cash_bank_ids only apply to module %s", models.DataScopeModuleCashBank)
+	case scope.MaxAmount > 0 && scope.Module != models.DataScopeModuleSales && scope.Module != models.DataScopeModulePurchases:
+		return fmt.Errorf("max_amount only applies to modules %s and %s", models.DataScopeModuleSales, models.DataScopeModulePurchases)
+	case scope.OwnRecordsOnly && scope.Module == models.DataScopeModuleWarehouseLocations:
+		return fmt.Errorf("own_records_only does not apply to module %s", models.DataScopeModuleWarehouseLocations)
+	}
+	if !scope.OwnRecordsOnly && len(scope.SalesPersonIDs) == 0 && len(scope.WarehouseIDs) == 0 &&
+		len(scope.CashBankIDs) == 0 && scope.MaxAmount <= 0 {
+		return fmt.Errorf("data scope has no restriction")
+	}
+
+	query := s.db.Model(&models.DataScope{}).Where("module = ? AND id <> ?", scope.Module, scope.ID)
+	if scope.UserID != nil {
+		query = query.Where("user_id = ?", *scope.UserID)
+	} else {
+		query = query.Where("user_id IS NULL AND role = ?", scope.Role)
+	}
+	var existing int64
+	if err := query.Count(&existing).Error; err != nil {
+		return err
+	}
+	if existing > 0 {
+		return fmt.Errorf("%s already has a data scope for module %s", dataScopeSubject(*scope), scope.Module)
+	}
+	return nil
+}
+
+func dataScopeSubject(scope models.DataScope) string {
+	if scope.UserID != nil {
+		return fmt.Sprintf("user %d", *scope.UserID)
+	}
+	return "role " + scope.Role
+}
+
+// ========== RESOLUTION ==========
+
+// ResolveScope - Scope yang berlaku untuk user di satu modul: scope user menggantikan scope role,
+// tanpa keduanya (atau untuk admin) user melihat semua data
+func (s *DataScopeService) ResolveScope(userID uint, role, module string) (models.EffectiveDataScope, error) {
+	effective := models.EffectiveDataScope{
+		UserID:       userID,
+		Role:         role,
+		Module:       module,
+		Source:       models.DataScopeSourceNone,
+		Unrestricted: true,
+	}
+	if role == models.RoleAdmin {
+		return effective, nil
+	}
+
+	var scope models.DataScope
+	err := s.db.Where("module = ? AND is_active = ? AND user_id = ?", module, true, userID).First(&scope).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = s.db.Where("module = ? AND is_active = ? AND user_id IS NULL AND role = ?", module, true, role).First(&scope).Error
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return effective, nil
+	}
+	if err != nil {
+		return effective, err
+	}
+
+	effective.Source = models.DataScopeSourceRole
+	if scope.UserID != nil {
+		effective.Source = models.DataScopeSourceUser
+	}
+	effective.ScopeID = scope.ID
+	effective.Unrestricted = false
+	effective.OwnRecordsOnly = scope.OwnRecordsOnly
+	effective.SalesPersonIDs = scope.SalesPersonIDs
+	effective.WarehouseIDs = scope.WarehouseIDs
+	effective.CashBankIDs = scope.CashBankIDs
+	effective.MaxAmount = scope.MaxAmount
+	return effective, nil
+}
+
+// ========== EXPLANATION ==========
+
+// ExplainAccess - Jelaskan kenapa user bisa atau tidak bisa melihat satu record: izin modul, scope
+// yang berlaku dan hasil tiap pembatasan
+func (s *DataScopeService) ExplainAccess(userID uint, module string, recordID uint) (*models.DataScopeExplanation, error) {
+	permissionModule, ok := dataScopePermissionModules[module]
+	if !ok {
+		return nil, fmt.Errorf("module %s does not support data scopes", module)
+	}
+
+	var user models.User
+	if err := s.db.First(&user, userID).Error; err != nil {
+		return nil, fmt.Errorf("user not found")
+	}
+
+	explanation := &models.DataScopeExplanation{
+		UserID:   user.ID,
+		Username: user.Username,
+		Role:     user.Role,
+		Module:   module,
+		RecordID: recordID,
+		Checks:   []models.DataScopeCheck{},
+	}
+
+	canView, via, err := s.moduleViewPermission(user, permissionModule)
+	if err != nil {
+		return nil, err
+	}
+	explanation.CanView = canView
+	explanation.PermissionVia = via
+	explanation.Checks = append(explanation.Checks, models.DataScopeCheck{
+		Rule:   "module_permission",
+		Passed: canView,
+		Detail: fmt.Sprintf("view permission on module %s from %s", permissionModule, strings.ToLower(strings.ReplaceAll(via, "_", " "))),
+	})
+
+	scope, err := s.ResolveScope(user.ID, user.Role, module)
+	if err != nil {
+		return nil, err
+	}
+	explanation.Scope = scope
+	switch {
+	case user.Role == models.RoleAdmin:
+		explanation.Checks = append(explanation.Checks, passedCheck("data_scope", "administrators are never scoped"))
+	case scope.Unrestricted:
+		explanation.Checks = append(explanation.Checks, passedCheck("data_scope", "no active data scope for the user or the role"))
+	default:
+		explanation.Checks = append(explanation.Checks, passedCheck("data_scope", fmt.Sprintf("data scope %d applies (%s level)", scope.ScopeID, strings.ToLower(scope.Source))))
+	}
+
+	checks, found, err := s.recordChecks(&scope, module, recordID)
+	if err != nil {
+		return nil, err
+	}
+	explanation.RecordFound = found
+	if !found {
+		explanation.Checks = append(explanation.Checks, models.DataScopeCheck{
+			Rule:   "record",
+			Detail: fmt.Sprintf("record %d not found in module %s", recordID, module),
+		})
+	}
+	explanation.Checks = append(explanation.Checks, checks...)
+
+	explanation.Allowed = true
+	for _, check := range explanation.Checks {
+		if !check.Passed {
+			explanation.Allowed = false
+			explanation.Reason = check.Detail
+			break
+		}
+	}
+	if explanation.Allowed {
+		explanation.Reason = "record is visible to the user"
+	}
+	return explanation, nil
+}
+
+// moduleViewPermission - Sama dengan PermissionMiddleware: record izin user dulu, lalu default role
+func (s *DataScopeService) moduleViewPermission(user models.User, module string) (bool, string, error) {
+	var record models.ModulePermissionRecord
+	err := s.db.Where("user_id = ? AND module = ?", user.ID, module).First(&record).Error
+	if err == nil {
+		return record.CanView, "USER_RECORD", nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, "", err
+	}
+	if perm, ok := models.GetDefaultPermissions(user.Role)[module]; ok {
+		return perm.CanView, "ROLE_DEFAULT", nil
+	}
+	return false, "ROLE_DEFAULT", nil
+}
+
+// recordChecks - Evaluasi tiap pembatasan scope terhadap record
+func (s *DataScopeService) recordChecks(scope *models.EffectiveDataScope, module string, recordID uint) ([]models.DataScopeCheck, bool, error) {
+	var checks []models.DataScopeCheck
+	restricted := scope.IsRestricted()
+
+	switch module {
+	case models.DataScopeModuleSales:
+		var sale models.Sale
+		if found, err := findScopedRecord(s.db, &sale, recordID); !found {
+			return nil, false, err
+		}
+		if restricted && scope.OwnRecordsOnly {
+			checks = append(checks, ownRecordCheck(sale.UserID, scope.UserID))
+		}
+		if restricted && len(scope.SalesPersonIDs) > 0 {
+			passed := sale.SalesPersonID != nil && uintInList(scope.SalesPersonIDs, *sale.SalesPersonID)
+			detail := "sale has no sales person"
+			if sale.SalesPersonID != nil {
+				detail = fmt.Sprintf("sales person %d, allowed %v", *sale.SalesPersonID, scope.SalesPersonIDs)
+			}
+			checks = append(checks, models.DataScopeCheck{Rule: "sales_persons", Passed: passed, Detail: detail})
+		}
+		if restricted && scope.MaxAmount > 0 {
+			checks = append(checks, maxAmountCheck(sale.TotalAmount, scope.MaxAmount))
+		}
+	case models.DataScopeModulePurchases:
+		var purchase models.Purchase
+		if found, err := findScopedRecord(s.db, &purchase, recordID); !found {
+			return nil, false, err
+		}
+		if restricted && scope.OwnRecordsOnly {
+			checks = append(checks, ownRecordCheck(purchase.UserID, scope.UserID))
+		}
+		if restricted && scope.MaxAmount > 0 {
+			checks = append(checks, maxAmountCheck(purchase.TotalAmount, scope.MaxAmount))
+		}
+	case models.DataScopeModuleWarehouseLocations:
+		var location models.WarehouseLocation
+		if found, err := findScopedRecord(s.db, &location, recordID); !found {
+			return nil, false, err
+		}
+		if restricted && len(scope.WarehouseIDs) > 0 {
+			checks = append(checks, models.DataScopeCheck{
+				Rule:   "warehouses",
+				Passed: uintInList(scope.WarehouseIDs, location.ID),
+				Detail: fmt.Sprintf("warehouse location %d, allowed %v", location.ID, scope.WarehouseIDs),
+			})
+		}
+	case models.DataScopeModuleCashBank:
+		var cashBank models.CashBank
+		if found, err := findScopedRecord(s.db, &cashBank, recordID); !found {
+			return nil, false, err
+		}
+		if restricted && scope.OwnRecordsOnly {
+			checks = append(checks, ownRecordCheck(cashBank.UserID, scope.UserID))
+		}
+		if restricted && len(scope.CashBankIDs) > 0 {
+			checks = append(checks, models.DataScopeCheck{
+				Rule:   "cash_banks",
+				Passed: uintInList(scope.CashBankIDs, cashBank.ID),
+				Detail: fmt.Sprintf("cash/bank account %d, allowed %v", cashBank.ID, scope.CashBankIDs),
+			})
+		}
+	}
+	return checks, true, nil
+}
+
+// RecordInScope - Apakah record modul berada di dalam scope; dipakai endpoint tulis (ubah, hapus,
+// konfirmasi, pembayaran). Record yang tidak ada dianggap lolos supaya handler menjawab 404 sendiri.
+func (s *DataScopeService) RecordInScope(scope *models.EffectiveDataScope, module string, recordID uint) (bool, error) {
+	if !scope.IsRestricted() {
+		return true, nil
+	}
+
+	switch module {
+	case models.DataScopeModuleSales:
+		var sale models.Sale
+		found, err := findScopedRecord(s.db, &sale, recordID)
+		if err != nil || !found {
+			return err == nil, err
+		}
+		return scope.AllowsSale(&sale), nil
+	case models.DataScopeModulePurchases:
+		var purchase models.Purchase
+		found, err := findScopedRecord(s.db, &purchase, recordID)
+		if err != nil || !found {
+			return err == nil, err
+		}
+		return scope.AllowsPurchase(&purchase), nil
+	case models.DataScopeModuleWarehouseLocations:
+		return scope.AllowsWarehouse(recordID), nil
+	case models.DataScopeModuleCashBank:
+		var cashBank models.CashBank
+		found, err := findScopedRecord(s.db, &cashBank, recordID)
+		if err != nil || !found {
+			return err == nil, err
+		}
+		return scope.AllowsCashBank(&cashBank), nil
+	}
+	return true, nil
+}
+
+func findScopedRecord(db *gorm.DB, record interface{}, id uint) (bool, error) {
+	err := db.First(record, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func passedCheck(rule, detail string) models.DataScopeCheck {
+	return models.DataScopeCheck{Rule: rule, Passed: true, Detail: detail}
+}
+
+func ownRecordCheck(ownerID, userID uint) models.DataScopeCheck {
+	return models.DataScopeCheck{
+		Rule:   "own_records",
+		Passed: ownerID == userID,
+		Detail: fmt.Sprintf("record created by user %d, requested by user %d", ownerID, userID),
+	}
+}
+
+func maxAmountCheck(amount, maxAmount float64) models.DataScopeCheck {
+	return models.DataScopeCheck{
+		Rule:   "max_amount",
+		Passed: amount <= maxAmount,
+		Detail: fmt.Sprintf("total %.2f, ceiling %.2f", amount, maxAmount),
+	}
+}
+
+func uintInList(values []uint, value uint) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
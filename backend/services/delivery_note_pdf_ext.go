@@ -0,0 +1,96 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+
+	"app-sistem-akuntansi/models"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+// GenerateDeliveryNotePDF generates the delivery note (surat jalan) of a delivery order. Only
+// quantities are printed; prices belong on the invoice.
+func (p *PDFService) GenerateDeliveryNotePDF(delivery *models.DeliveryOrder) ([]byte, error) {
+	if delivery == nil {
+		return nil, fmt.Errorf("delivery order data is required")
+	}
+
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(15, 15, 15)
+	pdf.SetAutoPageBreak(true, 15)
+	pdf.AddPage()
+
+	subtitles := []string{
+		fmt.Sprintf("Delivery Note No: %s", delivery.Code),
+		fmt.Sprintf("Date: %s", delivery.Date.Format("02/01/2006")),
+		fmt.Sprintf("Customer: %s", delivery.Customer.Name),
+		fmt.Sprintf("Sales Order: %s", delivery.Sale.Code),
+	}
+	if delivery.ShippingMethod != "" || delivery.VehicleNumber != "" || delivery.DriverName != "" {
+		subtitles = append(subtitles, fmt.Sprintf("Shipping: %s   Vehicle: %s   Driver: %s",
+			firstNonEmpty(delivery.ShippingMethod, "-"), firstNonEmpty(delivery.VehicleNumber, "-"), firstNonEmpty(delivery.DriverName, "-")))
+	}
+	p.addReportHeader(pdf, "Delivery Note", subtitles...)
+
+	lm, _, _, _ := pdf.GetMargins()
+	if delivery.ShippingAddress != "" {
+		pdf.SetX(lm)
+		pdf.SetFont("Arial", "", 9)
+		pdf.MultiCell(0, 5, fmt.Sprintf("Ship to: %s", delivery.ShippingAddress), "", "L", false)
+		pdf.Ln(2)
+	}
+
+	widths := []float64{10, 30, 90, 25, 25}
+	pdf.SetX(lm)
+	pdf.SetFont("Arial", "B", 9)
+	pdf.SetFillColor(220, 220, 220)
+	for i, h := range []string{"No", "Code", "Product", "Qty", "Unit"} {
+		pdf.CellFormat(widths[i], 7, h, "1", 0, "C", true, 0, "")
+	}
+	pdf.Ln(7)
+
+	pdf.SetFont("Arial", "", 9)
+	for i, item := range delivery.Items {
+		pdf.SetX(lm)
+		pdf.CellFormat(widths[0], 6, fmt.Sprintf("%d", i+1), "1", 0, "C", false, 0, "")
+		pdf.CellFormat(widths[1], 6, item.Product.Code, "1", 0, "L", false, 0, "")
+		pdf.CellFormat(widths[2], 6, truncateToWidth(pdf, firstNonEmpty(item.SaleItem.Description, item.Product.Name), widths[2]-2), "1", 0, "L", false, 0, "")
+		pdf.CellFormat(widths[3], 6, fmt.Sprintf("%d", item.Quantity), "1", 0, "C", false, 0, "")
+		pdf.CellFormat(widths[4], 6, item.Product.Unit, "1", 0, "C", false, 0, "")
+		pdf.Ln(6)
+	}
+	pdf.Ln(4)
+
+	if delivery.Notes != "" {
+		pdf.SetX(lm)
+		pdf.MultiCell(0, 5, fmt.Sprintf("Notes: %s", delivery.Notes), "", "L", false)
+		pdf.Ln(2)
+	}
+	if delivery.Status == models.DeliveryOrderStatusCancelled {
+		pdf.SetX(lm)
+		pdf.SetFont("Arial", "B", 10)
+		pdf.Cell(0, 6, "CANCELLED")
+		pdf.Ln(8)
+	}
+
+	// Signature boxes: issued by, driver, received by
+	pdf.Ln(6)
+	signW := 60.0
+	pdf.SetFont("Arial", "", 9)
+	pdf.SetX(lm)
+	for _, label := range []string{"Issued by", "Driver", "Received by"} {
+		pdf.CellFormat(signW, 6, label, "", 0, "C", false, 0, "")
+	}
+	pdf.Ln(24)
+	pdf.SetX(lm)
+	for i := 0; i < 3; i++ {
+		pdf.CellFormat(signW, 6, "(____________________)", "", 0, "C", false, 0, "")
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, fmt.Errorf("failed to generate PDF: %v", err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,491 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"app-sistem-akuntansi/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DeliveryOrderService handles delivery orders (surat jalan) of confirmed sales orders. A delivery
+// order ships part or all of the ordered quantities and takes them out of stock; what is left on
+// the order is tracked as backorder per sale item. When a sale with deliveries is invoiced, the
+// invoice covers the shipped quantities only and the backorder moves to a new sales order (see
+// splitShippedSale). COGS is still posted by the sales journal when the invoice is created.
+type DeliveryOrderService struct {
+	db           *gorm.DB
+	stockService *StockService
+	pdfService   PDFServiceInterface
+}
+
+func NewDeliveryOrderService(db *gorm.DB, stockService *StockService, pdfService PDFServiceInterface) *DeliveryOrderService {
+	return &DeliveryOrderService{
+		db:           db,
+		stockService: stockService,
+		pdfService:   pdfService,
+	}
+}
+
+// ========== QUERIES ==========
+
+// GetDeliveryOrders - Daftar surat jalan, opsional difilter penjualan, pelanggan dan status
+func (s *DeliveryOrderService) GetDeliveryOrders(saleID, customerID uint, status string) ([]models.DeliveryOrder, error) {
+	var deliveries []models.DeliveryOrder
+	query := s.db.Preload("Customer").Preload("Sale").Preload("Items.Product")
+	if saleID > 0 {
+		query = query.Where("sale_id = ?", saleID)
+	}
+	if customerID > 0 {
+		query = query.Where("customer_id = ?", customerID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Order("date DESC, id DESC").Find(&deliveries).Error
+	return deliveries, err
+}
+
+// GetDeliveryOrderByID - Detail surat jalan
+func (s *DeliveryOrderService) GetDeliveryOrderByID(id uint) (*models.DeliveryOrder, error) {
+	var delivery models.DeliveryOrder
+	err := s.db.Preload("Customer").Preload("User").Preload("Sale").
+		Preload("Items.Product").Preload("Items.SaleItem").
+		First(&delivery, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// GetBackorders - Item penjualan yang masih menunggu dikirim, opsional difilter pelanggan dan produk
+func (s *DeliveryOrderService) GetBackorders(customerID, productID uint) ([]models.BackorderItem, error) {
+	var items []models.BackorderItem
+	query := s.db.Model(&models.SaleItem{}).
+		Select(`
+			sales.id as sale_id,
+			sales.code as sale_code,
+			sale_items.id as sale_item_id,
+			sales.customer_id,
+			contacts.name as customer_name,
+			sale_items.product_id,
+			products.name as product_name,
+			sales.date,
+			sale_items.quantity,
+			sale_items.delivered_quantity,
+			sale_items.backorder_quantity
+		`).
+		Joins("JOIN sales ON sales.id = sale_items.sale_id AND sales.deleted_at IS NULL").
+		Joins("JOIN contacts ON contacts.id = sales.customer_id").
+		Joins("JOIN products ON products.id = sale_items.product_id").
+		Where("sale_items.backorder_quantity > 0 AND sales.status = ?", models.SaleStatusConfirmed)
+	if customerID > 0 {
+		query = query.Where("sales.customer_id = ?", customerID)
+	}
+	if productID > 0 {
+		query = query.Where("sale_items.product_id = ?", productID)
+	}
+	err := query.Order("sales.date ASC, sale_items.id ASC").Scan(&items).Error
+	return items, err
+}
+
+// GenerateDeliveryNotePDF - Surat jalan dalam format PDF
+func (s *DeliveryOrderService) GenerateDeliveryNotePDF(id uint) ([]byte, *models.DeliveryOrder, error) {
+	delivery, err := s.GetDeliveryOrderByID(id)
+	if err != nil {
+		return nil, nil, errors.New("delivery order not found")
+	}
+	pdfBytes, err := s.pdfService.GenerateDeliveryNotePDF(delivery)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pdfBytes, delivery, nil
+}
+
+// ========== TRANSACTIONS ==========
+
+// CreateDeliveryOrder ships items of a confirmed sale and reduces stock for them
+func (s *DeliveryOrderService) CreateDeliveryOrder(saleID uint, req models.DeliveryOrderRequest, userID uint) (*models.DeliveryOrder, error) {
+	date := dateOnly(req.Date)
+	if date.After(time.Now()) {
+		return nil, errors.New("delivery date cannot be in the future")
+	}
+
+	var deliveryID uint
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var sale models.Sale
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&sale, saleID).Error; err != nil {
+			return errors.New("sale not found")
+		}
+		if sale.Status != models.SaleStatusConfirmed {
+			return fmt.Errorf("only CONFIRMED sales can be delivered (current status: %s)", sale.Status)
+		}
+		if date.Before(dateOnly(sale.Date)) {
+			return errors.New("delivery date cannot be before the sale date")
+		}
+
+		var saleItems []models.SaleItem
+		if err := tx.Preload("Product").Where("sale_id = ?", sale.ID).Order("id ASC").Find(&saleItems).Error; err != nil {
+			return fmt.Errorf("failed to load sale items: %v", err)
+		}
+		itemsByID := make(map[uint]*models.SaleItem, len(saleItems))
+		for i := range saleItems {
+			itemsByID[saleItems[i].ID] = &saleItems[i]
+		}
+
+		// Without items every remaining quantity is shipped
+		requested := req.Items
+		if len(requested) == 0 {
+			for _, item := range saleItems {
+				if remaining := item.Quantity - item.DeliveredQuantity; remaining > 0 && !item.Product.IsService {
+					requested = append(requested, models.DeliveryOrderItemRequest{SaleItemID: item.ID, Quantity: remaining})
+				}
+			}
+			if len(requested) == 0 {
+				return errors.New("nothing left to deliver on this sale")
+			}
+		}
+
+		code, err := s.generateDeliveryCode(tx, date)
+		if err != nil {
+			return fmt.Errorf("failed to generate delivery order code: %v", err)
+		}
+		delivery := models.DeliveryOrder{
+			Code:            code,
+			SaleID:          sale.ID,
+			CustomerID:      sale.CustomerID,
+			Date:            date,
+			ShippingAddress: firstNonEmpty(req.ShippingAddress, sale.ShippingAddress),
+			ShippingMethod:  firstNonEmpty(req.ShippingMethod, sale.ShippingMethod),
+			VehicleNumber:   req.VehicleNumber,
+			DriverName:      req.DriverName,
+			Status:          models.DeliveryOrderStatusShipped,
+			Notes:           req.Notes,
+			UserID:          userID,
+		}
+		if err := tx.Omit(clause.Associations).Create(&delivery).Error; err != nil {
+			return fmt.Errorf("failed to create delivery order: %v", err)
+		}
+
+		for _, r := range requested {
+			item, ok := itemsByID[r.SaleItemID]
+			if !ok {
+				return fmt.Errorf("sale item %d does not belong to sale %s", r.SaleItemID, sale.Code)
+			}
+			if item.Product.IsService {
+				return fmt.Errorf("'%s' is a service and is not delivered", item.Product.Name)
+			}
+			if remaining := item.Quantity - item.DeliveredQuantity; r.Quantity > remaining {
+				return fmt.Errorf("cannot deliver %d of '%s': only %d left to deliver", r.Quantity, item.Product.Name, remaining)
+			}
+
+			warehouseID := item.WarehouseLocationID
+			if r.WarehouseLocationID != nil {
+				warehouseID = r.WarehouseLocationID
+			}
+			if err := s.stockService.ReduceStock(item.ProductID, warehouseID, r.Quantity, tx); err != nil {
+				return fmt.Errorf("gagal mengurangi stock untuk product '%s': %v", item.Product.Name, err)
+			}
+			if err := tx.Create(&models.DeliveryOrderItem{
+				DeliveryOrderID:     delivery.ID,
+				SaleItemID:          item.ID,
+				ProductID:           item.ProductID,
+				WarehouseLocationID: warehouseID,
+				Quantity:            r.Quantity,
+			}).Error; err != nil {
+				return fmt.Errorf("failed to create delivery order item: %v", err)
+			}
+			item.DeliveredQuantity += r.Quantity
+		}
+
+		if err := s.updateFulfilment(tx, &sale, saleItems); err != nil {
+			return err
+		}
+
+		deliveryID = delivery.ID
+		log.Printf("🚚 Delivery order %s posted for sale %s (%s)", code, sale.Code, sale.DeliveryStatus)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetDeliveryOrderByID(deliveryID)
+}
+
+// CancelDeliveryOrder puts the shipped goods back into stock. Deliveries of a sale that has been
+// invoiced cannot be cancelled; use a sales return instead.
+func (s *DeliveryOrderService) CancelDeliveryOrder(id uint, reason string, userID uint) (*models.DeliveryOrder, error) {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var delivery models.DeliveryOrder
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Items").First(&delivery, id).Error; err != nil {
+			return errors.New("delivery order not found")
+		}
+		if delivery.Status == models.DeliveryOrderStatusCancelled {
+			return errors.New("delivery order is already cancelled")
+		}
+
+		var sale models.Sale
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&sale, delivery.SaleID).Error; err != nil {
+			return errors.New("sale not found")
+		}
+		if sale.Status != models.SaleStatusConfirmed {
+			return fmt.Errorf("sale %s is already %s; use a sales return instead", sale.Code, sale.Status)
+		}
+
+		var saleItems []models.SaleItem
+		if err := tx.Preload("Product").Where("sale_id = ?", sale.ID).Order("id ASC").Find(&saleItems).Error; err != nil {
+			return fmt.Errorf("failed to load sale items: %v", err)
+		}
+		itemsByID := make(map[uint]*models.SaleItem, len(saleItems))
+		for i := range saleItems {
+			itemsByID[saleItems[i].ID] = &saleItems[i]
+		}
+
+		for _, line := range delivery.Items {
+			if err := s.stockService.RestoreStock(line.ProductID, line.WarehouseLocationID, line.Quantity, tx); err != nil {
+				return fmt.Errorf("failed to restore stock for product %d: %v", line.ProductID, err)
+			}
+			if item, ok := itemsByID[line.SaleItemID]; ok {
+				item.DeliveredQuantity -= line.Quantity
+				if item.DeliveredQuantity < 0 {
+					item.DeliveredQuantity = 0
+				}
+			}
+		}
+		if err := s.updateFulfilment(tx, &sale, saleItems); err != nil {
+			return err
+		}
+
+		log.Printf("↩️ Delivery order %s cancelled by user %d: %s", delivery.Code, userID, reason)
+		return tx.Model(&delivery).Updates(map[string]interface{}{
+			"status":        models.DeliveryOrderStatusCancelled,
+			"cancel_reason": reason,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetDeliveryOrderByID(id)
+}
+
+// ========== HELPERS ==========
+
+// updateFulfilment saves the delivered and backorder quantities of the items and the delivery status of the sale
+func (s *DeliveryOrderService) updateFulfilment(tx *gorm.DB, sale *models.Sale, items []models.SaleItem) error {
+	shipped, complete := false, true
+	for _, item := range items {
+		backorder := 0
+		if item.DeliveredQuantity > 0 || item.BackorderQuantity > 0 {
+			backorder = item.Quantity - item.DeliveredQuantity
+		}
+		if err := tx.Model(&models.SaleItem{}).Where("id = ?", item.ID).Updates(map[string]interface{}{
+			"delivered_quantity": item.DeliveredQuantity,
+			"backorder_quantity": backorder,
+		}).Error; err != nil {
+			return fmt.Errorf("failed to update sale item fulfilment: %v", err)
+		}
+		if item.DeliveredQuantity > 0 {
+			shipped = true
+		}
+		if item.DeliveredQuantity < item.Quantity && !item.Product.IsService {
+			complete = false
+		}
+	}
+
+	sale.DeliveryStatus = ""
+	if shipped {
+		sale.DeliveryStatus = models.SaleDeliveryPartial
+		if complete {
+			sale.DeliveryStatus = models.SaleDeliveryDelivered
+		}
+	}
+	return tx.Model(sale).Update("delivery_status", sale.DeliveryStatus).Error
+}
+
+func (s *DeliveryOrderService) generateDeliveryCode(tx *gorm.DB, date time.Time) (string, error) {
+	var count int64
+	prefix := fmt.Sprintf("DO-%s-", date.Format("200601"))
+	if err := tx.Unscoped().Model(&models.DeliveryOrder{}).
+		Where("code LIKE ?", prefix+"%").
+		Count(&count).Error; err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s%04d", prefix, count+1), nil
+}
+
+// saleHasDeliveries reports whether any goods of the sale are out on a delivery order
+func saleHasDeliveries(tx *gorm.DB, saleID uint) (bool, error) {
+	var count int64
+	err := tx.Model(&models.DeliveryOrder{}).
+		Where("sale_id = ? AND status = ?", saleID, models.DeliveryOrderStatusShipped).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// splitShippedSale prepares a sale with deliveries for invoicing: item quantities are cut back to
+// what was shipped and the backorder moves to a new CONFIRMED sales order, so the invoice covers
+// shipped goods only. Service items stay on the invoice. Returns the new order, or nil when
+// everything was shipped.
+func splitShippedSale(tx *gorm.DB, sale *models.Sale, settingsService *SettingsService) (*models.Sale, error) {
+	ownerIDs := make([]uint64, 0, len(sale.SaleItems))
+	for _, item := range sale.SaleItems {
+		ownerIDs = append(ownerIDs, uint64(item.ID))
+	}
+	tags, err := loadDimensionTags(tx, models.DimensionOwnerSaleItem, ownerIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	var backorderItems []models.SaleItem
+	invoiced := make([]models.SaleItem, 0, len(sale.SaleItems))
+	for _, item := range sale.SaleItems {
+		var product models.Product
+		if err := tx.First(&product, item.ProductID).Error; err != nil {
+			return nil, fmt.Errorf("failed to load product %d: %v", item.ProductID, err)
+		}
+		remaining := item.Quantity - item.DeliveredQuantity
+		if product.IsService || remaining <= 0 {
+			invoiced = append(invoiced, item)
+			continue
+		}
+
+		backorder := item
+		backorder.ID = 0
+		backorder.SaleID = 0
+		backorder.Quantity = remaining
+		backorder.DeliveredQuantity = 0
+		backorder.BackorderQuantity = remaining
+		backorder.TagIDs = tags[uint64(item.ID)]
+		backorderItems = append(backorderItems, backorder)
+
+		if item.DeliveredQuantity == 0 {
+			if err := tx.Delete(&models.SaleItem{}, item.ID).Error; err != nil {
+				return nil, fmt.Errorf("failed to move sale item to backorder: %v", err)
+			}
+			continue
+		}
+		item.Quantity = item.DeliveredQuantity
+		item.BackorderQuantity = 0
+		invoiced = append(invoiced, item)
+	}
+	if len(backorderItems) == 0 {
+		return nil, nil
+	}
+
+	sale.SaleItems = invoiced
+	calculateSaleAmounts(sale)
+	for _, item := range sale.SaleItems {
+		if err := tx.Model(&models.SaleItem{}).Where("id = ?", item.ID).Updates(map[string]interface{}{
+			"quantity":           item.Quantity,
+			"backorder_quantity": item.BackorderQuantity,
+			"discount_amount":    item.DiscountAmount,
+			"line_total":         item.LineTotal,
+			"ppn_amount":         item.PPNAmount,
+			"p_ph_amount":        item.PPhAmount, // GORM column name of PPhAmount
+			"total_tax":          item.TotalTax,
+			"final_amount":       item.FinalAmount,
+		}).Error; err != nil {
+			return nil, fmt.Errorf("failed to update invoiced sale item: %v", err)
+		}
+	}
+	sale.DeliveryStatus = models.SaleDeliveryDelivered
+
+	code, err := settingsService.GetNextSalesNumber()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate sales code: %v", err)
+	}
+	order := *sale
+	order.ID = 0
+	order.Code = code
+	order.Type = models.SaleTypeOrder
+	order.Status = models.SaleStatusConfirmed
+	order.InvoiceNumber = ""
+	order.PaidAmount = 0
+	order.ShippingCost = 0 // Shipping was charged on the first invoice
+	order.DeliveryStatus = ""
+	order.BackorderOfID = &sale.ID
+	order.CreditApprovalStatus = ""
+	order.CreditApprovalRequestID = nil
+	order.CreatedAt = time.Time{}
+	order.UpdatedAt = time.Time{}
+	order.SaleItems = backorderItems
+	order.SalePayments = nil
+	order.SaleReturns = nil
+	calculateSaleAmounts(&order)
+	if err := tx.Omit(clause.Associations).Create(&order).Error; err != nil {
+		return nil, fmt.Errorf("failed to create backorder: %v", err)
+	}
+	for i := range order.SaleItems {
+		order.SaleItems[i].SaleID = order.ID
+	}
+	if err := tx.Omit(clause.Associations).Create(&order.SaleItems).Error; err != nil {
+		return nil, fmt.Errorf("failed to create backorder items: %v", err)
+	}
+	if err := saveSaleItemDimensions(tx, order.SaleItems); err != nil {
+		return nil, err
+	}
+
+	log.Printf("📦 Sale %s invoiced for shipped quantities; backorder moved to %s (%.2f)", sale.Code, order.Code, order.TotalAmount)
+	return &order, nil
+}
+
+// calculateSaleAmounts recomputes item and sale totals the same way CreateSale and UpdateSale do
+func calculateSaleAmounts(sale *models.Sale) {
+	var subtotal, totalPPN, totalPPH float64
+	for i := range sale.SaleItems {
+		item := &sale.SaleItems[i]
+		lineTotal := float64(item.Quantity) * item.UnitPrice
+		item.DiscountAmount = lineTotal * (item.DiscountPercent / 100)
+		item.LineTotal = lineTotal - item.DiscountAmount
+		item.PPNAmount, item.PPhAmount = 0, 0
+		if item.Taxable {
+			ppnRate := sale.PPNRate
+			if ppnRate == 0 {
+				ppnRate = sale.PPNPercent
+			}
+			item.PPNAmount = item.LineTotal * (ppnRate / 100)
+			item.PPhAmount = item.LineTotal * (sale.PPhPercent / 100)
+			totalPPN += item.PPNAmount
+			totalPPH += item.PPhAmount
+		}
+		item.TotalTax = item.PPNAmount + item.PPhAmount
+		item.FinalAmount = item.LineTotal + item.PPNAmount - item.PPhAmount
+		subtotal += item.LineTotal
+	}
+
+	sale.Subtotal = subtotal
+	sale.SubTotal = subtotal
+	sale.DiscountAmount = subtotal * (sale.DiscountPercent / 100)
+	sale.TaxableAmount = subtotal - sale.DiscountAmount
+	sale.NetBeforeTax = sale.TaxableAmount
+	if sale.PPNPercent > 0 || sale.PPNRate > 0 {
+		ppnRate := sale.PPNPercent
+		if ppnRate == 0 {
+			ppnRate = sale.PPNRate
+		}
+		sale.PPN = sale.TaxableAmount * (ppnRate / 100)
+	} else {
+		sale.PPN = totalPPN
+	}
+	sale.PPNAmount = sale.PPN
+	sale.PPh21Amount, sale.PPh23Amount = 0, 0
+	if sale.PPh21Rate > 0 {
+		sale.PPh21Amount = sale.TaxableAmount * (sale.PPh21Rate / 100)
+	}
+	if sale.PPh23Rate > 0 {
+		sale.PPh23Amount = sale.TaxableAmount * (sale.PPh23Rate / 100)
+	}
+	sale.PPh = totalPPH
+	sale.TotalTaxAdditions = sale.OtherTaxAdditions
+	sale.TotalTaxDeductions = sale.PPh + sale.PPh21Amount + sale.PPh23Amount + sale.OtherTaxDeductions
+	sale.TotalTax = sale.PPN + sale.TotalTaxAdditions - sale.TotalTaxDeductions
+	sale.Tax = sale.TotalTax
+	sale.TotalAmount = sale.TaxableAmount + sale.PPN + sale.OtherTaxAdditions + sale.ShippingCost - sale.TotalTaxDeductions
+	sale.OutstandingAmount = sale.TotalAmount - sale.PaidAmount
+}
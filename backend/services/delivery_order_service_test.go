@@ -0,0 +1,151 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"app-sistem-akuntansi/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestCalculateSaleAmounts(t *testing.T) {
+	tests := []struct {
+		name      string
+		sale      models.Sale
+		wantPPN   float64
+		wantTotal float64
+	}{
+		{
+			name: "sale PPN on a discounted line and a non-taxable line",
+			sale: models.Sale{PPNRate: 11, SaleItems: []models.SaleItem{
+				{Quantity: 2, UnitPrice: 500000, DiscountPercent: 10, Taxable: true},
+				{Quantity: 1, UnitPrice: 100000},
+			}},
+			wantPPN: 110000, wantTotal: 1110000,
+		},
+		{
+			name: "sale discount, shipping and PPh 23",
+			sale: models.Sale{PPNPercent: 11, DiscountPercent: 10, PPh23Rate: 2, ShippingCost: 50000, PaidAmount: 100000, SaleItems: []models.SaleItem{
+				{Quantity: 4, UnitPrice: 250000, Taxable: true},
+			}},
+			wantPPN: 99000, wantTotal: 1031000,
+		},
+		{
+			name:      "without PPN",
+			sale:      models.Sale{SaleItems: []models.SaleItem{{Quantity: 3, UnitPrice: 20000, Taxable: true}}},
+			wantTotal: 60000,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calculateSaleAmounts(&tt.sale)
+			assert.InDelta(t, tt.wantPPN, tt.sale.PPN, 0.001)
+			assert.InDelta(t, tt.wantTotal, tt.sale.TotalAmount, 0.001)
+			assert.InDelta(t, tt.sale.TotalAmount-tt.sale.PaidAmount, tt.sale.OutstandingAmount, 0.001)
+		})
+	}
+}
+
+func TestDeliveryOrdersTrackBackorders(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1) // Every connection to :memory: is a separate database
+	require.NoError(t, db.AutoMigrate(&models.User{}, &models.Contact{}, &models.Product{}, &models.WarehouseStock{}, &models.Sale{},
+		&models.SaleItem{}, &models.DeliveryOrder{}, &models.DeliveryOrderItem{}, &models.DimensionTag{}, &models.Settings{}))
+	service := NewDeliveryOrderService(db, NewStockService(db), nil)
+
+	customer := models.Contact{Code: "CUST-001", Name: "PT Pelanggan", Type: models.ContactTypeCustomer}
+	require.NoError(t, db.Create(&customer).Error)
+	paper := models.Product{Code: "PRD-001", Name: "Kertas A4", Unit: "rim", Stock: 10}
+	toner := models.Product{Code: "PRD-002", Name: "Toner", Unit: "pcs", Stock: 5}
+	install := models.Product{Code: "SVC-001", Name: "Instalasi", Unit: "jasa", IsService: true}
+	for _, product := range []*models.Product{&paper, &toner, &install} {
+		require.NoError(t, db.Create(product).Error)
+	}
+	date := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	sale := models.Sale{Code: "SO-001", CustomerID: customer.ID, UserID: 1, Date: date, Status: models.SaleStatusConfirmed, Currency: "IDR", ExchangeRate: 1, PPNRate: 11,
+		SaleItems: []models.SaleItem{
+			{ProductID: paper.ID, Quantity: 6, UnitPrice: 50000, Taxable: true},
+			{ProductID: toner.ID, Quantity: 4, UnitPrice: 300000, Taxable: true},
+			{ProductID: install.ID, Quantity: 1, UnitPrice: 200000, Taxable: true},
+		}}
+	calculateSaleAmounts(&sale)
+	require.NoError(t, db.Create(&sale).Error)
+	orderedTotal := sale.TotalAmount
+	paperItem, tonerItem, installItem := sale.SaleItems[0], sale.SaleItems[1], sale.SaleItems[2]
+
+	stockOf := func(product models.Product) int {
+		require.NoError(t, db.First(&product, product.ID).Error)
+		return product.Stock
+	}
+	reload := func() {
+		sale.SaleItems = nil
+		require.NoError(t, db.Preload("SaleItems", func(db *gorm.DB) *gorm.DB { return db.Order("id ASC") }).First(&sale, sale.ID).Error)
+	}
+	deliver := func(items ...models.DeliveryOrderItemRequest) (*models.DeliveryOrder, error) {
+		return service.CreateDeliveryOrder(sale.ID, models.DeliveryOrderRequest{Date: date, Items: items}, 1)
+	}
+
+	_, err = deliver(models.DeliveryOrderItemRequest{SaleItemID: paperItem.ID, Quantity: 7})
+	assert.EqualError(t, err, "cannot deliver 7 of 'Kertas A4': only 6 left to deliver")
+	_, err = deliver(models.DeliveryOrderItemRequest{SaleItemID: installItem.ID, Quantity: 1})
+	assert.EqualError(t, err, "'Instalasi' is a service and is not delivered")
+
+	first, err := deliver(models.DeliveryOrderItemRequest{SaleItemID: paperItem.ID, Quantity: 4})
+	require.NoError(t, err)
+	assert.Equal(t, "DO-202403-0001", first.Code)
+	assert.Equal(t, 6, stockOf(paper))
+	reload()
+	assert.Equal(t, models.SaleDeliveryPartial, sale.DeliveryStatus)
+	assert.Equal(t, 4, sale.SaleItems[0].DeliveredQuantity)
+	assert.Equal(t, 2, sale.SaleItems[0].BackorderQuantity)
+
+	// Without items the rest of the goods is shipped
+	second, err := deliver()
+	require.NoError(t, err)
+	require.Len(t, second.Items, 2)
+	assert.Equal(t, 4, stockOf(paper))
+	assert.Equal(t, 1, stockOf(toner))
+	reload()
+	assert.Equal(t, models.SaleDeliveryDelivered, sale.DeliveryStatus, "services do not hold up a complete delivery")
+	_, err = deliver()
+	assert.EqualError(t, err, "nothing left to deliver on this sale")
+
+	_, err = service.CancelDeliveryOrder(second.ID, "truk mogok", 1)
+	require.NoError(t, err)
+	assert.Equal(t, 6, stockOf(paper))
+	assert.Equal(t, 5, stockOf(toner))
+	reload()
+	assert.Equal(t, models.SaleDeliveryPartial, sale.DeliveryStatus)
+	assert.Equal(t, 0, sale.SaleItems[1].DeliveredQuantity)
+
+	// Invoicing cuts the sale back to the shipped goods and moves the rest to a new order
+	require.NoError(t, db.Create(&models.Settings{SalesPrefix: "SO", SalesNextNumber: 2}).Error)
+	backorder, err := splitShippedSale(db, &sale, NewSettingsService(db))
+	require.NoError(t, err)
+	require.NotNil(t, backorder)
+	assert.Equal(t, "SO-00002", backorder.Code)
+	assert.Equal(t, models.SaleStatusConfirmed, backorder.Status)
+	require.NotNil(t, backorder.BackorderOfID)
+	assert.Equal(t, sale.ID, *backorder.BackorderOfID)
+	require.Len(t, backorder.SaleItems, 2)
+	assert.Equal(t, 2, backorder.SaleItems[0].Quantity)
+	assert.Equal(t, 4, backorder.SaleItems[1].Quantity)
+	assert.InDelta(t, 1443000, backorder.TotalAmount, 0.001)
+	assert.InDelta(t, 444000, sale.TotalAmount, 0.001, "4 rim of paper and the installation")
+	assert.InDelta(t, orderedTotal, sale.TotalAmount+backorder.TotalAmount, 0.001)
+
+	reload()
+	require.Len(t, sale.SaleItems, 2, "the unshipped toner line moved to the backorder")
+	assert.Equal(t, 4, sale.SaleItems[0].Quantity)
+	assert.Equal(t, installItem.ID, sale.SaleItems[1].ID)
+	assert.Equal(t, tonerItem.ProductID, backorder.SaleItems[1].ProductID)
+}
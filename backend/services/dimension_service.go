@@ -0,0 +1,563 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"app-sistem-akuntansi/models"
+
+	"gorm.io/gorm"
+)
+
+// Dimension group-by keys for reports
+const (
+	DimensionGroupCostCenter = "cost_center"
+	DimensionGroupProject    = "project"
+	DimensionGroupTag        = "tag"
+)
+
+// dimensionEnforcedSources are the journal sources where users code the lines themselves; mandatory
+// dimension rules are checked there. System journals (closing, depreciation, revaluation, reversals)
+// are not blocked by the rules.
+var dimensionEnforcedSources = map[string]bool{
+	models.SSOTSourceTypeManual:   true,
+	models.SSOTSourceTypeSale:     true,
+	models.SSOTSourceTypePurchase: true,
+}
+
+type DimensionService struct {
+	db *gorm.DB
+}
+
+func NewDimensionService(db *gorm.DB) *DimensionService {
+	return &DimensionService{db: db}
+}
+
+// DimensionFilter narrows report queries to journal lines carrying the given dimensions.
+// A zero ID selects lines without that dimension (for TAG: without a tag of TagGroup).
+type DimensionFilter struct {
+	CostCenterID *uint  `json:"cost_center_id,omitempty"`
+	ProjectID    *uint  `json:"project_id,omitempty"`
+	TagID        *uint  `json:"tag_id,omitempty"`
+	TagGroup     string `json:"tag_group,omitempty"`
+}
+
+// IsEmpty reports whether the filter selects every line
+func (f DimensionFilter) IsEmpty() bool {
+	return f.CostCenterID == nil && f.ProjectID == nil && f.TagID == nil
+}
+
+// condition returns the SQL predicates (starting with AND) for the journal line alias
+func (f DimensionFilter) condition(alias string) (string, []interface{}) {
+	var sql strings.Builder
+	var args []interface{}
+
+	if f.CostCenterID != nil {
+		if *f.CostCenterID == 0 {
+			sql.WriteString(fmt.Sprintf(" AND %s.cost_center_id IS NULL", alias))
+		} else {
+			sql.WriteString(fmt.Sprintf(" AND %s.cost_center_id = ?", alias))
+			args = append(args, *f.CostCenterID)
+		}
+	}
+	if f.ProjectID != nil {
+		if *f.ProjectID == 0 {
+			sql.WriteString(fmt.Sprintf(" AND %s.project_id IS NULL", alias))
+		} else {
+			sql.WriteString(fmt.Sprintf(" AND %s.project_id = ?", alias))
+			args = append(args, *f.ProjectID)
+		}
+	}
+	if f.TagID != nil {
+		if *f.TagID == 0 {
+			sql.WriteString(fmt.Sprintf(` AND NOT EXISTS (
+				SELECT 1 FROM dimension_tags dt JOIN dimensions d ON d.id = dt.dimension_id
+				WHERE dt.owner_type = ? AND dt.owner_id = %s.id AND (? = '' OR d.tag_group = ?))`, alias))
+			args = append(args, models.DimensionOwnerJournalLine, f.TagGroup, f.TagGroup)
+		} else {
+			sql.WriteString(fmt.Sprintf(` AND EXISTS (
+				SELECT 1 FROM dimension_tags dt
+				WHERE dt.owner_type = ? AND dt.owner_id = %s.id AND dt.dimension_id = ?)`, alias))
+			args = append(args, models.DimensionOwnerJournalLine, *f.TagID)
+		}
+	}
+	return sql.String(), args
+}
+
+// withGroup returns a copy of the filter restricted to one value of the group-by key
+func (f DimensionFilter) withGroup(groupBy string, id uint) DimensionFilter {
+	switch groupBy {
+	case DimensionGroupCostCenter:
+		f.CostCenterID = &id
+	case DimensionGroupProject:
+		f.ProjectID = &id
+	case DimensionGroupTag:
+		f.TagID = &id
+	}
+	return f
+}
+
+// ========== DIMENSIONS ==========
+
+// GetDimensions - Daftar dimensi analitik
+func (s *DimensionService) GetDimensions(dimensionType, tagGroup string, activeOnly bool) ([]models.Dimension, error) {
+	var dimensions []models.Dimension
+	query := s.db.Model(&models.Dimension{})
+	if dimensionType != "" {
+		query = query.Where("type = ?", strings.ToUpper(dimensionType))
+	}
+	if tagGroup != "" {
+		query = query.Where("tag_group = ?", strings.ToUpper(tagGroup))
+	}
+	if activeOnly {
+		query = query.Where("is_active = ?", true)
+	}
+	if err := query.Order("type ASC, code ASC").Find(&dimensions).Error; err != nil {
+		return nil, err
+	}
+	return dimensions, nil
+}
+
+// GetDimensionByID - Detail dimensi
+func (s *DimensionService) GetDimensionByID(id uint) (*models.Dimension, error) {
+	var dimension models.Dimension
+	if err := s.db.Preload("Parent").First(&dimension, id).Error; err != nil {
+		return nil, err
+	}
+	return &dimension, nil
+}
+
+// CreateDimension - Tambah cost center, proyek atau tag
+func (s *DimensionService) CreateDimension(req models.DimensionRequest, userID uint) (*models.Dimension, error) {
+	dimension := &models.Dimension{CreatedBy: userID, IsActive: true}
+	if err := s.applyDimensionRequest(dimension, req); err != nil {
+		return nil, err
+	}
+
+	var existing int64
+	if err := s.db.Model(&models.Dimension{}).Where("type = ? AND code = ?", dimension.Type, dimension.Code).
+		Count(&existing).Error; err != nil {
+		return nil, err
+	}
+	if existing > 0 {
+		return nil, fmt.Errorf("%s code %s already exists", strings.ToLower(dimension.Type), dimension.Code)
+	}
+
+	if err := s.db.Create(dimension).Error; err != nil {
+		return nil, err
+	}
+	return dimension, nil
+}
+
+// UpdateDimension - Ubah dimensi. The type cannot change once the dimension is in use.
+func (s *DimensionService) UpdateDimension(id uint, req models.DimensionRequest) (*models.Dimension, error) {
+	var dimension models.Dimension
+	if err := s.db.First(&dimension, id).Error; err != nil {
+		return nil, errors.New("dimension not found")
+	}
+	if !strings.EqualFold(req.Type, dimension.Type) {
+		used, err := s.isDimensionUsed(dimension.ID)
+		if err != nil {
+			return nil, err
+		}
+		if used {
+			return nil, errors.New("the type of a dimension that is used on journal lines cannot be changed")
+		}
+	}
+	if err := s.applyDimensionRequest(&dimension, req); err != nil {
+		return nil, err
+	}
+
+	var duplicate int64
+	if err := s.db.Model(&models.Dimension{}).Where("type = ? AND code = ? AND id <> ?", dimension.Type, dimension.Code, dimension.ID).
+		Count(&duplicate).Error; err != nil {
+		return nil, err
+	}
+	if duplicate > 0 {
+		return nil, fmt.Errorf("%s code %s already exists", strings.ToLower(dimension.Type), dimension.Code)
+	}
+
+	if err := s.db.Save(&dimension).Error; err != nil {
+		return nil, err
+	}
+	return &dimension, nil
+}
+
+// DeleteDimension - Hapus dimensi yang belum dipakai; dimensi terpakai cukup dinonaktifkan
+func (s *DimensionService) DeleteDimension(id uint) error {
+	var dimension models.Dimension
+	if err := s.db.First(&dimension, id).Error; err != nil {
+		return errors.New("dimension not found")
+	}
+	used, err := s.isDimensionUsed(dimension.ID)
+	if err != nil {
+		return err
+	}
+	if used {
+		return errors.New("dimension is used on journal lines, deactivate it instead")
+	}
+	return s.db.Delete(&dimension).Error
+}
+
+func (s *DimensionService) applyDimensionRequest(dimension *models.Dimension, req models.DimensionRequest) error {
+	dimension.Type = strings.ToUpper(req.Type)
+	dimension.Code = strings.ToUpper(strings.TrimSpace(req.Code))
+	dimension.Name = strings.TrimSpace(req.Name)
+	dimension.Description = req.Description
+	dimension.TagGroup = ""
+	if dimension.Type == models.DimensionTypeTag {
+		dimension.TagGroup = strings.ToUpper(strings.TrimSpace(req.TagGroup))
+	}
+	if req.IsActive != nil {
+		dimension.IsActive = *req.IsActive
+	}
+	if dimension.Code == "" || dimension.Name == "" {
+		return errors.New("code and name are required")
+	}
+
+	dimension.ParentID = req.ParentID
+	if req.ParentID != nil {
+		if dimension.ID != 0 && *req.ParentID == dimension.ID {
+			return errors.New("a dimension cannot be its own parent")
+		}
+		var parent models.Dimension
+		if err := s.db.First(&parent, *req.ParentID).Error; err != nil {
+			return errors.New("parent dimension not found")
+		}
+		if parent.Type != dimension.Type {
+			return errors.New("parent dimension must be of the same type")
+		}
+	}
+	return nil
+}
+
+func (s *DimensionService) isDimensionUsed(id uint) (bool, error) {
+	var count int64
+	if err := s.db.Model(&models.SSOTJournalLine{}).
+		Where("cost_center_id = ? OR project_id = ?", id, id).Count(&count).Error; err != nil {
+		return false, err
+	}
+	if count > 0 {
+		return true, nil
+	}
+	if err := s.db.Model(&models.DimensionTag{}).Where("dimension_id = ?", id).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// ========== MANDATORY DIMENSION RULES ==========
+
+// GetRules - Daftar aturan dimensi wajib
+func (s *DimensionService) GetRules() ([]models.DimensionRule, error) {
+	var rules []models.DimensionRule
+	if err := s.db.Preload("Account").Order("account_code_prefix ASC, id ASC").Find(&rules).Error; err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// CreateRule - Tambah aturan dimensi wajib untuk akun atau awalan kode akun
+func (s *DimensionService) CreateRule(req models.DimensionRuleRequest, userID uint) (*models.DimensionRule, error) {
+	rule := &models.DimensionRule{CreatedBy: userID, IsActive: true}
+	if err := s.applyRuleRequest(rule, req); err != nil {
+		return nil, err
+	}
+	if err := s.db.Create(rule).Error; err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+// UpdateRule - Ubah aturan dimensi wajib
+func (s *DimensionService) UpdateRule(id uint, req models.DimensionRuleRequest) (*models.DimensionRule, error) {
+	var rule models.DimensionRule
+	if err := s.db.First(&rule, id).Error; err != nil {
+		return nil, errors.New("dimension rule not found")
+	}
+	if err := s.applyRuleRequest(&rule, req); err != nil {
+		return nil, err
+	}
+	if err := s.db.Save(&rule).Error; err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// DeleteRule - Hapus aturan dimensi wajib
+func (s *DimensionService) DeleteRule(id uint) error {
+	result := s.db.Delete(&models.DimensionRule{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("dimension rule not found")
+	}
+	return nil
+}
+
+func (s *DimensionService) applyRuleRequest(rule *models.DimensionRule, req models.DimensionRuleRequest) error {
+	prefix := strings.TrimSpace(req.AccountCodePrefix)
+	if req.AccountID == nil && prefix == "" {
+		return errors.New("either account_id or account_code_prefix is required")
+	}
+	if req.AccountID != nil {
+		var account models.Account
+		if err := s.db.First(&account, *req.AccountID).Error; err != nil {
+			return errors.New("account not found")
+		}
+	}
+	rule.AccountID = req.AccountID
+	rule.AccountCodePrefix = prefix
+	rule.DimensionType = strings.ToUpper(req.DimensionType)
+	rule.TagGroup = ""
+	if rule.DimensionType == models.DimensionTypeTag {
+		rule.TagGroup = strings.ToUpper(strings.TrimSpace(req.TagGroup))
+	}
+	rule.Description = req.Description
+	if req.IsActive != nil {
+		rule.IsActive = *req.IsActive
+	}
+	return nil
+}
+
+// ========== POSTING ==========
+
+// dimensionLine is the account and dimensions of one journal line being posted
+type dimensionLine struct {
+	AccountID  uint64
+	Dimensions models.LineDimensions
+}
+
+// checkJournalDimensions validates the dimensions of the lines and, for user-coded sources, enforces
+// the mandatory dimension rules
+func checkJournalDimensions(tx *gorm.DB, sourceType string, lines []dimensionLine) error {
+	ids := make(map[uint]bool)
+	for _, l := range lines {
+		if l.Dimensions.CostCenterID != nil {
+			ids[*l.Dimensions.CostCenterID] = true
+		}
+		if l.Dimensions.ProjectID != nil {
+			ids[*l.Dimensions.ProjectID] = true
+		}
+		for _, id := range l.Dimensions.TagIDs {
+			ids[id] = true
+		}
+	}
+
+	dimensions := make(map[uint]models.Dimension)
+	if len(ids) > 0 {
+		list := make([]uint, 0, len(ids))
+		for id := range ids {
+			list = append(list, id)
+		}
+		var rows []models.Dimension
+		if err := tx.Where("id IN ?", list).Find(&rows).Error; err != nil {
+			return fmt.Errorf("failed to load dimensions: %v", err)
+		}
+		for _, d := range rows {
+			dimensions[d.ID] = d
+		}
+	}
+
+	expect := func(id *uint, dimensionType string) error {
+		if id == nil {
+			return nil
+		}
+		d, ok := dimensions[*id]
+		if !ok {
+			return fmt.Errorf("dimension %d not found", *id)
+		}
+		if d.Type != dimensionType {
+			return fmt.Errorf("dimension %s is a %s, not a %s", d.Code, strings.ToLower(d.Type), strings.ToLower(dimensionType))
+		}
+		if !d.IsActive {
+			return fmt.Errorf("dimension %s is inactive", d.Code)
+		}
+		return nil
+	}
+	for _, l := range lines {
+		if err := expect(l.Dimensions.CostCenterID, models.DimensionTypeCostCenter); err != nil {
+			return err
+		}
+		if err := expect(l.Dimensions.ProjectID, models.DimensionTypeProject); err != nil {
+			return err
+		}
+		for i := range l.Dimensions.TagIDs {
+			if err := expect(&l.Dimensions.TagIDs[i], models.DimensionTypeTag); err != nil {
+				return err
+			}
+		}
+	}
+
+	if !dimensionEnforcedSources[strings.ToUpper(sourceType)] {
+		return nil
+	}
+
+	var rules []models.DimensionRule
+	if err := tx.Where("is_active = ?", true).Find(&rules).Error; err != nil {
+		return fmt.Errorf("failed to load dimension rules: %v", err)
+	}
+	if len(rules) == 0 {
+		return nil
+	}
+
+	accountIDs := make([]uint64, 0, len(lines))
+	for _, l := range lines {
+		accountIDs = append(accountIDs, l.AccountID)
+	}
+	var accounts []models.Account
+	if err := tx.Select("id", "code", "name").Where("id IN ?", accountIDs).Find(&accounts).Error; err != nil {
+		return fmt.Errorf("failed to load accounts: %v", err)
+	}
+	accountByID := make(map[uint64]models.Account, len(accounts))
+	for _, a := range accounts {
+		accountByID[uint64(a.ID)] = a
+	}
+
+	for _, l := range lines {
+		account := accountByID[l.AccountID]
+		for _, rule := range rules {
+			matches := (rule.AccountID != nil && uint64(*rule.AccountID) == l.AccountID) ||
+				(rule.AccountCodePrefix != "" && strings.HasPrefix(account.Code, rule.AccountCodePrefix))
+			if !matches {
+				continue
+			}
+			switch rule.DimensionType {
+			case models.DimensionTypeCostCenter:
+				if l.Dimensions.CostCenterID == nil {
+					return fmt.Errorf("account %s %s requires a cost center", account.Code, account.Name)
+				}
+			case models.DimensionTypeProject:
+				if l.Dimensions.ProjectID == nil {
+					return fmt.Errorf("account %s %s requires a project", account.Code, account.Name)
+				}
+			case models.DimensionTypeTag:
+				found := false
+				for _, id := range l.Dimensions.TagIDs {
+					if rule.TagGroup == "" || dimensions[id].TagGroup == rule.TagGroup {
+						found = true
+						break
+					}
+				}
+				if !found {
+					if rule.TagGroup != "" {
+						return fmt.Errorf("account %s %s requires a %s tag", account.Code, account.Name, rule.TagGroup)
+					}
+					return fmt.Errorf("account %s %s requires a tag", account.Code, account.Name)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// validateLineDimensions checks that the dimensions of document lines exist, are active and have the
+// right type. Mandatory rules are only enforced when the document is posted.
+func validateLineDimensions(tx *gorm.DB, dims ...models.LineDimensions) error {
+	lines := make([]dimensionLine, 0, len(dims))
+	for _, d := range dims {
+		lines = append(lines, dimensionLine{Dimensions: d})
+	}
+	return checkJournalDimensions(tx, "", lines)
+}
+
+// applyLineDimensions copies cost center and project onto a journal line before it is created
+func applyLineDimensions(line *models.SSOTJournalLine, dims models.LineDimensions) {
+	line.CostCenterID = dims.CostCenterID
+	line.ProjectID = dims.ProjectID
+	line.TagIDs = dims.TagIDs
+}
+
+// saveDimensionTags replaces the tags of a journal line or document line
+func saveDimensionTags(tx *gorm.DB, ownerType string, ownerID uint64, tagIDs []uint) error {
+	if err := tx.Where("owner_type = ? AND owner_id = ?", ownerType, ownerID).Delete(&models.DimensionTag{}).Error; err != nil {
+		return fmt.Errorf("failed to clear dimension tags: %v", err)
+	}
+	seen := make(map[uint]bool, len(tagIDs))
+	for _, id := range tagIDs {
+		if id == 0 || seen[id] {
+			continue
+		}
+		seen[id] = true
+		if err := tx.Create(&models.DimensionTag{OwnerType: ownerType, OwnerID: ownerID, DimensionID: id}).Error; err != nil {
+			return fmt.Errorf("failed to save dimension tag: %v", err)
+		}
+	}
+	return nil
+}
+
+// loadDimensionTags returns the tag IDs per owner
+func loadDimensionTags(tx *gorm.DB, ownerType string, ownerIDs []uint64) (map[uint64][]uint, error) {
+	tags := make(map[uint64][]uint)
+	if len(ownerIDs) == 0 {
+		return tags, nil
+	}
+	var rows []models.DimensionTag
+	if err := tx.Where("owner_type = ? AND owner_id IN ?", ownerType, ownerIDs).Order("dimension_id ASC").Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to load dimension tags: %v", err)
+	}
+	for _, r := range rows {
+		tags[r.OwnerID] = append(tags[r.OwnerID], r.DimensionID)
+	}
+	return tags, nil
+}
+
+// commonDimensions returns the dimensions shared by all document lines, used for header-level amounts
+// such as shipping; it is empty when the lines are coded differently
+func commonDimensions(all []models.LineDimensions) models.LineDimensions {
+	if len(all) == 0 {
+		return models.LineDimensions{}
+	}
+	key := all[0].Key()
+	for _, d := range all[1:] {
+		if d.Key() != key {
+			return models.LineDimensions{}
+		}
+	}
+	return all[0]
+}
+
+// ========== REPORTING ==========
+
+// UsedDimensions lists the dimensions of a group-by key that appear on posted journal lines up to
+// end (and from start when given), ordered by code
+func (s *DimensionService) UsedDimensions(groupBy, tagGroup string, start *time.Time, end time.Time) ([]models.Dimension, error) {
+	var join string
+	args := []interface{}{}
+	switch groupBy {
+	case DimensionGroupCostCenter:
+		join = "JOIN unified_journal_lines l ON l.cost_center_id = d.id"
+	case DimensionGroupProject:
+		join = "JOIN unified_journal_lines l ON l.project_id = d.id"
+	case DimensionGroupTag:
+		join = `JOIN dimension_tags dt ON dt.dimension_id = d.id AND dt.owner_type = ?
+			JOIN unified_journal_lines l ON l.id = dt.owner_id`
+		args = append(args, models.DimensionOwnerJournalLine)
+	default:
+		return nil, fmt.Errorf("invalid group_by %q, use cost_center, project or tag", groupBy)
+	}
+
+	query := `SELECT DISTINCT d.* FROM dimensions d ` + join + `
+		JOIN unified_journal_ledger e ON e.id = l.journal_id
+		WHERE e.status = 'POSTED' AND e.deleted_at IS NULL AND d.deleted_at IS NULL AND e.entry_date <= ?`
+	args = append(args, end)
+	if start != nil {
+		query += " AND e.entry_date >= ?"
+		args = append(args, *start)
+	}
+	if groupBy == DimensionGroupTag && tagGroup != "" {
+		query += " AND d.tag_group = ?"
+		args = append(args, strings.ToUpper(tagGroup))
+	}
+
+	var dimensions []models.Dimension
+	if err := s.db.Raw(query, args...).Scan(&dimensions).Error; err != nil {
+		return nil, err
+	}
+	sort.Slice(dimensions, func(i, j int) bool { return dimensions[i].Code < dimensions[j].Code })
+	return dimensions, nil
+}
@@ -0,0 +1,133 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"app-sistem-akuntansi/models"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestLineDimensionsKey(t *testing.T) {
+	one, two := uint(1), uint(2)
+	tests := []struct {
+		name string
+		a, b models.LineDimensions
+		same bool
+	}{
+		{name: "empty", same: true},
+		{name: "tag order does not matter", a: models.LineDimensions{CostCenterID: &one, TagIDs: []uint{3, 4}}, b: models.LineDimensions{CostCenterID: &one, TagIDs: []uint{4, 3}}, same: true},
+		{name: "different cost center", a: models.LineDimensions{CostCenterID: &one}, b: models.LineDimensions{CostCenterID: &two}},
+		{name: "cost center is not a project", a: models.LineDimensions{CostCenterID: &one}, b: models.LineDimensions{ProjectID: &one}},
+		{name: "extra tag", a: models.LineDimensions{TagIDs: []uint{3}}, b: models.LineDimensions{TagIDs: []uint{3, 4}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.same, tt.a.Key() == tt.b.Key())
+		})
+	}
+}
+
+func TestCommonDimensions(t *testing.T) {
+	one, two := uint(1), uint(2)
+	shared := models.LineDimensions{CostCenterID: &one, TagIDs: []uint{5}}
+
+	assert.True(t, commonDimensions(nil).IsEmpty())
+	assert.Equal(t, shared, commonDimensions([]models.LineDimensions{shared, {CostCenterID: &one, TagIDs: []uint{5}}}))
+	assert.True(t, commonDimensions([]models.LineDimensions{shared, {CostCenterID: &two, TagIDs: []uint{5}}}).IsEmpty())
+}
+
+func TestDimensionFilterCondition(t *testing.T) {
+	zero, seven := uint(0), uint(7)
+	tests := []struct {
+		name     string
+		filter   DimensionFilter
+		contains []string
+		args     int
+	}{
+		{name: "empty filter", filter: DimensionFilter{}},
+		{name: "cost center", filter: DimensionFilter{CostCenterID: &seven}, contains: []string{"ujl.cost_center_id = ?"}, args: 1},
+		{name: "without cost center", filter: DimensionFilter{CostCenterID: &zero}, contains: []string{"ujl.cost_center_id IS NULL"}},
+		{name: "project and tag", filter: DimensionFilter{ProjectID: &seven, TagID: &seven}, contains: []string{"ujl.project_id = ?", "EXISTS", "dt.dimension_id = ?"}, args: 3},
+		{name: "without tag of group", filter: DimensionFilter{TagID: &zero, TagGroup: "CHANNEL"}, contains: []string{"NOT EXISTS", "d.tag_group = ?"}, args: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, args := tt.filter.condition("ujl")
+			assert.Equal(t, tt.filter.IsEmpty(), sql == "")
+			for _, part := range tt.contains {
+				assert.Contains(t, sql, part)
+			}
+			assert.Len(t, args, tt.args)
+		})
+	}
+
+	filter := DimensionFilter{ProjectID: &seven}.withGroup(DimensionGroupCostCenter, 3)
+	require.NotNil(t, filter.CostCenterID)
+	assert.Equal(t, uint(3), *filter.CostCenterID)
+	assert.Equal(t, uint(7), *filter.ProjectID)
+}
+
+func TestJournalDimensionRules(t *testing.T) {
+	db := setupJournalTestDB(t)
+	accounts := seedTestAccounts(t, db, map[string]string{"1101": "ASSET", "5201": "EXPENSE"})
+	service := NewUnifiedJournalService(db)
+
+	newDimension := func(dimensionType, code, tagGroup string) models.Dimension {
+		dimension := models.Dimension{Type: dimensionType, Code: code, Name: code, TagGroup: tagGroup, IsActive: true}
+		require.NoError(t, db.Create(&dimension).Error)
+		return dimension
+	}
+	costCenter := newDimension(models.DimensionTypeCostCenter, "CC-OPS", "")
+	project := newDimension(models.DimensionTypeProject, "PRJ-01", "")
+	channel := newDimension(models.DimensionTypeTag, "ONLINE", "CHANNEL")
+	closed := newDimension(models.DimensionTypeCostCenter, "CC-OLD", "")
+	require.NoError(t, db.Model(&closed).Update("is_active", false).Error)
+	require.NoError(t, db.Create(&models.DimensionRule{AccountCodePrefix: "5", DimensionType: models.DimensionTypeCostCenter, IsActive: true}).Error)
+	require.NoError(t, db.Create(&models.DimensionRule{AccountCodePrefix: "5", DimensionType: models.DimensionTypeTag, TagGroup: "CHANNEL", IsActive: true}).Error)
+
+	amount := decimal.NewFromInt(250000)
+	post := func(sourceType string, dims models.LineDimensions) error {
+		return db.Transaction(func(tx *gorm.DB) error {
+			_, err := service.CreateJournalEntryWithTx(tx, &JournalEntryRequest{
+				EntryDate:   time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+				Description: "Biaya operasional",
+				SourceType:  sourceType,
+				CreatedBy:   1,
+				Lines: []JournalLineRequest{
+					{AccountID: uint64(accounts["5201"].ID), DebitAmount: amount, Dimensions: dims},
+					{AccountID: uint64(accounts["1101"].ID), CreditAmount: amount},
+				},
+			})
+			return err
+		})
+	}
+
+	assert.EqualError(t, post(models.SSOTSourceTypeManual, models.LineDimensions{}), "account 5201 Account 5201 requires a cost center")
+	assert.EqualError(t, post(models.SSOTSourceTypeManual, models.LineDimensions{CostCenterID: &costCenter.ID}), "account 5201 Account 5201 requires a CHANNEL tag")
+	assert.EqualError(t, post(models.SSOTSourceTypeManual, models.LineDimensions{CostCenterID: &project.ID}), "dimension PRJ-01 is a project, not a cost_center")
+	assert.EqualError(t, post(models.SSOTSourceTypeManual, models.LineDimensions{CostCenterID: &closed.ID}), "dimension CC-OLD is inactive")
+	// System postings such as adjustments are not held to the mandatory rules
+	require.NoError(t, post(models.SSOTSourceTypeAdjustment, models.LineDimensions{}))
+
+	require.NoError(t, post(models.SSOTSourceTypeManual, models.LineDimensions{CostCenterID: &costCenter.ID, ProjectID: &project.ID, TagIDs: []uint{channel.ID, channel.ID}}))
+
+	entries := assertJournalsBalanced(t, db)
+	require.Len(t, entries, 2)
+	expense := entries[1].Lines[0]
+	require.NotNil(t, expense.CostCenterID)
+	require.NotNil(t, expense.ProjectID)
+	assert.Equal(t, costCenter.ID, *expense.CostCenterID)
+	assert.Equal(t, project.ID, *expense.ProjectID)
+	assert.Nil(t, entries[1].Lines[1].CostCenterID)
+
+	tags, err := loadDimensionTags(db, models.DimensionOwnerJournalLine, []uint64{expense.ID})
+	require.NoError(t, err)
+	assert.Equal(t, []uint{channel.ID}, tags[expense.ID], "duplicate tags are saved once")
+}
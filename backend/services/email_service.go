@@ -0,0 +1,859 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/mail"
+	"strings"
+	"time"
+
+	"app-sistem-akuntansi/config"
+	"app-sistem-akuntansi/models"
+	"app-sistem-akuntansi/repositories"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// EmailService renders document and notification emails, stores them in the outbound queue and
+// delivers the queue over SMTP. With EMAIL_SMTP_SINK set every email goes to that local SMTP sink
+// instead of the SMTP server configured in Settings; the worker warns about it when it starts.
+type EmailService struct {
+	db               *gorm.DB
+	pdfService       PDFServiceInterface
+	salesRepo        *repositories.SalesRepository
+	statementService *StatementService
+	settingsService  *SettingsService
+	sink             string
+}
+
+func NewEmailService(db *gorm.DB) *EmailService {
+	pdfService := NewPDFService(db)
+	return &EmailService{
+		db:               db,
+		pdfService:       pdfService,
+		salesRepo:        repositories.NewSalesRepository(db),
+		statementService: NewStatementService(db, pdfService),
+		settingsService:  NewSettingsService(db),
+		sink:             config.LoadConfig().EmailSMTPSink,
+	}
+}
+
+const (
+	emailQueueInterval     = time.Minute
+	emailQueueBatchSize    = 20
+	emailSendingStaleAfter = 10 * time.Minute
+	emailNotificationAge   = time.Hour
+	emailDateLayout        = "02/01/2006"
+)
+
+// emailRetryBackoff is the wait before attempt n+1 after n failed attempts
+var emailRetryBackoff = []time.Duration{time.Minute, 5 * time.Minute, 15 * time.Minute, time.Hour, 6 * time.Hour}
+
+// Notification email dispatch statuses stored in Notification.EmailStatus
+const (
+	notificationEmailQueued  = "QUEUED"
+	notificationEmailSkipped = "SKIPPED"
+)
+
+// EmailMessageListResult is one page of the outbound queue
+type EmailMessageListResult struct {
+	Data       []models.EmailMessage `json:"data"`
+	Total      int64                 `json:"total"`
+	Page       int                   `json:"page"`
+	Limit      int                   `json:"limit"`
+	TotalPages int                   `json:"total_pages"`
+}
+
+// EffectiveEmailTemplate is the template used for a type and language, either a stored override
+// or the built-in default
+type EffectiveEmailTemplate struct {
+	Type     string                `json:"type"`
+	Language string                `json:"language"`
+	Subject  string                `json:"subject"`
+	BodyHTML string                `json:"body_html"`
+	BuiltIn  bool                  `json:"built_in"`
+	Template *models.EmailTemplate `json:"template,omitempty"`
+}
+
+// ========== SMTP SETTINGS ==========
+
+// smtpSettings resolves the SMTP connection: the local sink when configured, otherwise Settings.
+// Email must be enabled in Settings either way.
+func (s *EmailService) smtpSettings() (*smtpSettings, *models.Settings, error) {
+	settings, err := s.settingsService.GetSettings()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load settings: %v", err)
+	}
+
+	fromAddress := settings.EmailFromAddress
+	if fromAddress == "" {
+		fromAddress = settings.CompanyEmail
+	}
+	fromName := settings.EmailFromName
+	if fromName == "" {
+		fromName = settings.CompanyName
+	}
+
+	if !settings.EmailEnabled {
+		return nil, settings, errors.New("email delivery is disabled in settings")
+	}
+	if s.sink != "" {
+		host, port := s.sink, 25
+		if idx := strings.LastIndex(s.sink, ":"); idx >= 0 {
+			host = s.sink[:idx]
+			fmt.Sscanf(s.sink[idx+1:], "%d", &port)
+		}
+		if fromAddress == "" {
+			fromAddress = "noreply@localhost"
+		}
+		return &smtpSettings{
+			Host:        host,
+			Port:        port,
+			Encryption:  models.SMTPEncryptionNone,
+			FromAddress: fromAddress,
+			FromName:    fromName,
+			Sink:        true,
+		}, settings, nil
+	}
+
+	if settings.SMTPHost == "" || fromAddress == "" {
+		return nil, settings, errors.New("SMTP host and sender address must be configured in settings")
+	}
+	return &smtpSettings{
+		Host:        settings.SMTPHost,
+		Port:        settings.SMTPPort,
+		Username:    settings.SMTPUsername,
+		Password:    settings.SMTPPassword,
+		Encryption:  settings.SMTPEncryption,
+		FromAddress: fromAddress,
+		FromName:    fromName,
+	}, settings, nil
+}
+
+// ========== TEMPLATES ==========
+
+// effectiveTemplate - Template aktif untuk tipe dan bahasa; override DB atau bawaan
+func (s *EmailService) effectiveTemplate(emailType, language string) (emailTemplateContent, error) {
+	var stored models.EmailTemplate
+	err := s.db.Where("type = ? AND language = ? AND is_active = ?", emailType, language, true).First(&stored).Error
+	if err == nil {
+		return emailTemplateContent{Subject: stored.Subject, BodyHTML: stored.BodyHTML}, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return emailTemplateContent{}, fmt.Errorf("failed to load email template: %v", err)
+	}
+
+	defaults, ok := defaultEmailTemplates[emailType]
+	if !ok {
+		return emailTemplateContent{}, fmt.Errorf("unknown email type %s", emailType)
+	}
+	if content, ok := defaults[language]; ok {
+		return content, nil
+	}
+	return defaults["id"], nil
+}
+
+// templateData - Data dasar template: identitas perusahaan
+func (s *EmailService) templateData(settings *models.Settings) map[string]interface{} {
+	data := map[string]interface{}{}
+	if settings != nil {
+		data["CompanyName"] = settings.CompanyName
+		data["CompanyEmail"] = settings.CompanyEmail
+		data["CompanyPhone"] = settings.CompanyPhone
+		data["CompanyAddress"] = settings.CompanyAddress
+	}
+	return data
+}
+
+// render - Render subject dan body untuk tipe email dalam bahasa tertentu
+func (s *EmailService) render(emailType, language string, data map[string]interface{}) (string, string, error) {
+	content, err := s.effectiveTemplate(emailType, language)
+	if err != nil {
+		return "", "", err
+	}
+	return renderEmailTemplate(content, data)
+}
+
+// GetTemplates - Template efektif untuk setiap tipe dan bahasa, termasuk bawaan
+func (s *EmailService) GetTemplates() ([]EffectiveEmailTemplate, error) {
+	var stored []models.EmailTemplate
+	if err := s.db.Order("type, language").Find(&stored).Error; err != nil {
+		return nil, fmt.Errorf("failed to load email templates: %v", err)
+	}
+	byKey := make(map[string]*models.EmailTemplate, len(stored))
+	for i := range stored {
+		byKey[stored[i].Type+"|"+stored[i].Language] = &stored[i]
+	}
+
+	types := []string{
+		models.EmailTypeInvoice, models.EmailTypeReceipt, models.EmailTypeStatement,
+		models.EmailTypePaymentReminder, models.EmailTypeOverdueNotice,
+		models.EmailTypeNotification, models.EmailTypeTest,
+	}
+	result := make([]EffectiveEmailTemplate, 0, len(types)*2)
+	for _, emailType := range types {
+		for _, language := range []string{"id", "en"} {
+			builtIn := defaultEmailTemplates[emailType][language]
+			item := EffectiveEmailTemplate{Type: emailType, Language: language, Subject: builtIn.Subject, BodyHTML: builtIn.BodyHTML, BuiltIn: true}
+			if template, ok := byKey[emailType+"|"+language]; ok {
+				item.Template = template
+				if template.IsActive {
+					item.Subject, item.BodyHTML, item.BuiltIn = template.Subject, template.BodyHTML, false
+				}
+			}
+			result = append(result, item)
+		}
+	}
+	return result, nil
+}
+
+// SaveTemplate - Buat atau ganti template untuk tipe dan bahasa
+func (s *EmailService) SaveTemplate(req models.EmailTemplateRequest, userID uint) (*models.EmailTemplate, error) {
+	emailType := strings.ToUpper(strings.TrimSpace(req.Type))
+	if _, ok := defaultEmailTemplates[emailType]; !ok {
+		return nil, fmt.Errorf("unknown email type %s", req.Type)
+	}
+	language := strings.ToLower(strings.TrimSpace(req.Language))
+	if language != "id" && language != "en" {
+		return nil, errors.New("language must be 'id' or 'en'")
+	}
+	if err := validateEmailTemplate(req.Subject, req.BodyHTML); err != nil {
+		return nil, err
+	}
+
+	var template models.EmailTemplate
+	err := s.db.Unscoped().Where("type = ? AND language = ?", emailType, language).First(&template).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to load email template: %v", err)
+	}
+
+	template.Type = emailType
+	template.Language = language
+	template.Subject = req.Subject
+	template.BodyHTML = req.BodyHTML
+	template.Description = req.Description
+	template.IsActive = req.IsActive == nil || *req.IsActive
+	template.UpdatedBy = userID
+	template.DeletedAt = gorm.DeletedAt{}
+	if err := s.db.Unscoped().Save(&template).Error; err != nil {
+		return nil, fmt.Errorf("failed to save email template: %v", err)
+	}
+	return &template, nil
+}
+
+// DeleteTemplate - Hapus override sehingga template bawaan dipakai lagi
+func (s *EmailService) DeleteTemplate(id uint) error {
+	result := s.db.Delete(&models.EmailTemplate{}, id)
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete email template: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("email template not found")
+	}
+	return nil
+}
+
+// ========== QUEUE ==========
+
+// QueueEmail - Simpan email dan lampirannya ke antrian keluar
+func (s *EmailService) QueueEmail(message *models.EmailMessage, attachments []models.EmailAttachment) error {
+	to, err := mail.ParseAddress(strings.TrimSpace(message.ToAddress))
+	if err != nil {
+		return fmt.Errorf("invalid recipient address %q", message.ToAddress)
+	}
+	message.ToAddress = to.Address
+	if message.ToName == "" {
+		message.ToName = to.Name
+	}
+	cc := make([]string, 0, len(message.CcAddresses))
+	for _, address := range message.CcAddresses {
+		if strings.TrimSpace(address) == "" {
+			continue
+		}
+		parsed, err := mail.ParseAddress(strings.TrimSpace(address))
+		if err != nil {
+			return fmt.Errorf("invalid cc address %q", address)
+		}
+		cc = append(cc, parsed.Address)
+	}
+	message.CcAddresses = cc
+
+	message.Status = models.EmailStatusQueued
+	message.NextAttemptAt = time.Now()
+	if message.MaxAttempts <= 0 {
+		message.MaxAttempts = len(emailRetryBackoff)
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Attachments").Create(message).Error; err != nil {
+			return fmt.Errorf("failed to queue email: %v", err)
+		}
+		for i := range attachments {
+			attachments[i].EmailMessageID = message.ID
+			attachments[i].Size = len(attachments[i].Content)
+			if err := tx.Create(&attachments[i]).Error; err != nil {
+				return fmt.Errorf("failed to store email attachment: %v", err)
+			}
+		}
+		message.Attachments = attachments
+		return nil
+	})
+}
+
+// ProcessQueue - Kirim email QUEUED yang sudah waktunya; dipanggil oleh worker
+func (s *EmailService) ProcessQueue() (int, error) {
+	// Messages left in SENDING by a crashed worker go back to the queue
+	s.db.Model(&models.EmailMessage{}).
+		Where("status = ? AND updated_at < ?", models.EmailStatusSending, time.Now().Add(-emailSendingStaleAfter)).
+		Update("status", models.EmailStatusQueued)
+
+	var messages []models.EmailMessage
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", models.EmailStatusQueued, time.Now()).
+			Order("next_attempt_at").
+			Limit(emailQueueBatchSize).
+			Find(&messages).Error; err != nil {
+			return err
+		}
+		if len(messages) == 0 {
+			return nil
+		}
+		ids := make([]uint, len(messages))
+		for i, message := range messages {
+			ids[i] = message.ID
+		}
+		return tx.Model(&models.EmailMessage{}).Where("id IN ?", ids).Update("status", models.EmailStatusSending).Error
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to claim queued emails: %v", err)
+	}
+	if len(messages) == 0 {
+		return 0, nil
+	}
+
+	cfg, _, cfgErr := s.smtpSettings()
+	sent := 0
+	for i := range messages {
+		if cfgErr != nil {
+			// Keep the messages queued until email is configured; this is not a delivery attempt
+			s.db.Model(&messages[i]).Updates(map[string]interface{}{
+				"status":          models.EmailStatusQueued,
+				"next_attempt_at": time.Now().Add(emailQueueInterval),
+				"last_error":      cfgErr.Error(),
+			})
+			continue
+		}
+		if s.deliver(cfg, &messages[i]) {
+			sent++
+		}
+	}
+	return sent, nil
+}
+
+// deliver - Kirim satu email dan catat hasilnya; true bila terkirim
+func (s *EmailService) deliver(cfg *smtpSettings, message *models.EmailMessage) bool {
+	var attachments []models.EmailAttachment
+	if err := s.db.Where("email_message_id = ?", message.ID).Order("id").Find(&attachments).Error; err != nil {
+		s.recordFailure(message, fmt.Errorf("failed to load attachments: %v", err))
+		return false
+	}
+
+	raw, messageID, err := buildEmailMIME(cfg, message, attachments)
+	if err == nil {
+		recipients := append([]string{message.ToAddress}, message.CcAddresses...)
+		err = sendSMTP(cfg, recipients, raw)
+	}
+	if err != nil {
+		s.recordFailure(message, err)
+		return false
+	}
+
+	now := time.Now()
+	message.Status = models.EmailStatusSent
+	message.Attempts++
+	message.SentAt = &now
+	message.SMTPMessageID = messageID
+	message.LastError = ""
+	s.db.Model(message).Updates(map[string]interface{}{
+		"status":          message.Status,
+		"attempts":        message.Attempts,
+		"sent_at":         now,
+		"smtp_message_id": messageID,
+		"last_error":      "",
+	})
+	log.Printf("📧 Email #%d (%s) sent to %s", message.ID, message.Type, message.ToAddress)
+	return true
+}
+
+// recordFailure - Jadwalkan retry, atau tandai BOUNCED/FAILED
+func (s *EmailService) recordFailure(message *models.EmailMessage, err error) {
+	message.Attempts++
+	message.LastError = err.Error()
+	updates := map[string]interface{}{
+		"attempts":   message.Attempts,
+		"last_error": message.LastError,
+	}
+
+	var bounced *errEmailBounced
+	switch {
+	case errors.As(err, &bounced):
+		now := time.Now()
+		message.Status = models.EmailStatusBounced
+		updates["bounced_at"] = now
+		updates["bounce_reason"] = message.LastError
+		log.Printf("❌ Email #%d to %s bounced: %v", message.ID, message.ToAddress, err)
+	case message.Attempts >= message.MaxAttempts:
+		message.Status = models.EmailStatusFailed
+		log.Printf("❌ Email #%d to %s failed after %d attempts: %v", message.ID, message.ToAddress, message.Attempts, err)
+	default:
+		message.Status = models.EmailStatusQueued
+		backoff := emailRetryBackoff[int(math.Min(float64(message.Attempts-1), float64(len(emailRetryBackoff)-1)))]
+		message.NextAttemptAt = time.Now().Add(backoff)
+		updates["next_attempt_at"] = message.NextAttemptAt
+		log.Printf("⚠️ Email #%d to %s failed (attempt %d), retrying in %v: %v", message.ID, message.ToAddress, message.Attempts, backoff, err)
+	}
+	updates["status"] = message.Status
+	s.db.Model(message).Updates(updates)
+}
+
+// StartQueueWorker delivers the outbound queue and turns new notifications into emails every minute
+func (s *EmailService) StartQueueWorker() {
+	ticker := time.NewTicker(emailQueueInterval)
+	defer ticker.Stop()
+
+	log.Println("📧 Email queue worker started - running every minute")
+	if s.sink != "" {
+		log.Printf("⚠️ ==================================================================")
+		log.Printf("⚠️ EMAIL_SMTP_SINK is set: ALL outgoing email goes to %s", s.sink)
+		log.Printf("⚠️ The SMTP server in Settings is ignored and customers receive nothing.")
+		log.Printf("⚠️ Unset EMAIL_SMTP_SINK outside development.")
+		log.Printf("⚠️ ==================================================================")
+	}
+	s.runQueue()
+	for range ticker.C {
+		s.runQueue()
+	}
+}
+
+func (s *EmailService) runQueue() {
+	if err := s.dispatchNotificationEmails(); err != nil {
+		log.Printf("❌ Notification email dispatch failed: %v", err)
+	}
+	if _, err := s.ProcessQueue(); err != nil {
+		log.Printf("❌ Email queue run failed: %v", err)
+	}
+}
+
+// RetryMessage - Antrikan ulang email FAILED atau BOUNCED dengan jatah percobaan baru
+func (s *EmailService) RetryMessage(id uint) (*models.EmailMessage, error) {
+	message, err := s.GetMessage(id)
+	if err != nil {
+		return nil, err
+	}
+	if message.Status != models.EmailStatusFailed && message.Status != models.EmailStatusBounced {
+		return nil, fmt.Errorf("only FAILED or BOUNCED emails can be retried, this one is %s", message.Status)
+	}
+	if err := s.db.Model(message).Updates(map[string]interface{}{
+		"status":          models.EmailStatusQueued,
+		"attempts":        0,
+		"next_attempt_at": time.Now(),
+		"bounced_at":      nil,
+		"bounce_reason":   "",
+	}).Error; err != nil {
+		return nil, fmt.Errorf("failed to requeue email: %v", err)
+	}
+	return s.GetMessage(id)
+}
+
+// MarkBounced - Catat bounce yang dilaporkan setelah email diterima server (mis. laporan DSN)
+func (s *EmailService) MarkBounced(id uint, reason string) (*models.EmailMessage, error) {
+	message, err := s.GetMessage(id)
+	if err != nil {
+		return nil, err
+	}
+	if message.Status != models.EmailStatusSent {
+		return nil, fmt.Errorf("only SENT emails can be marked as bounced, this one is %s", message.Status)
+	}
+	if err := s.db.Model(message).Updates(map[string]interface{}{
+		"status":        models.EmailStatusBounced,
+		"bounced_at":    time.Now(),
+		"bounce_reason": reason,
+	}).Error; err != nil {
+		return nil, fmt.Errorf("failed to mark email as bounced: %v", err)
+	}
+	return s.GetMessage(id)
+}
+
+// GetMessage - Detail email beserta daftar lampiran
+func (s *EmailService) GetMessage(id uint) (*models.EmailMessage, error) {
+	var message models.EmailMessage
+	if err := s.db.Preload("Attachments", func(db *gorm.DB) *gorm.DB {
+		return db.Omit("content")
+	}).First(&message, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("email not found")
+		}
+		return nil, err
+	}
+	return &message, nil
+}
+
+// GetMessages - Daftar antrian keluar dengan filter dan paginasi
+func (s *EmailService) GetMessages(filter models.EmailMessageFilter) (*EmailMessageListResult, error) {
+	if filter.Page <= 0 {
+		filter.Page = 1
+	}
+	if filter.Limit <= 0 || filter.Limit > 100 {
+		filter.Limit = 20
+	}
+
+	query := s.db.Model(&models.EmailMessage{})
+	if filter.Status != "" {
+		query = query.Where("status = ?", strings.ToUpper(filter.Status))
+	}
+	if filter.Type != "" {
+		query = query.Where("type = ?", strings.ToUpper(filter.Type))
+	}
+	if filter.EntityType != "" {
+		query = query.Where("entity_type = ?", filter.EntityType)
+	}
+	if filter.EntityID != 0 {
+		query = query.Where("entity_id = ?", filter.EntityID)
+	}
+	if filter.ToAddress != "" {
+		query = query.Where("to_address ILIKE ?", "%"+filter.ToAddress+"%")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, fmt.Errorf("failed to count emails: %v", err)
+	}
+	var messages []models.EmailMessage
+	if err := query.Omit("body_html").Order("created_at DESC").
+		Offset((filter.Page - 1) * filter.Limit).Limit(filter.Limit).
+		Find(&messages).Error; err != nil {
+		return nil, fmt.Errorf("failed to load emails: %v", err)
+	}
+
+	return &EmailMessageListResult{
+		Data:       messages,
+		Total:      total,
+		Page:       filter.Page,
+		Limit:      filter.Limit,
+		TotalPages: int(math.Ceil(float64(total) / float64(filter.Limit))),
+	}, nil
+}
+
+// ========== DOCUMENT EMAILS ==========
+
+// SendInvoiceEmail - Antrikan faktur penjualan dengan PDF terlampir
+func (s *EmailService) SendInvoiceEmail(saleID uint, req models.SendDocumentEmailRequest, scope *models.EffectiveDataScope, userID uint) (*models.EmailMessage, error) {
+	sale, err := s.loadSale(saleID, scope)
+	if err != nil {
+		return nil, err
+	}
+	if sale.InvoiceNumber == "" {
+		return nil, errors.New("sale has no invoice yet")
+	}
+	settings, err := s.settingsService.GetSettings()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load settings: %v", err)
+	}
+	language := emailLanguage(req.Language, settings)
+
+	pdfBytes, err := s.pdfService.GenerateInvoicePDFWithLanguage(sale, language)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate invoice PDF: %v", err)
+	}
+	data := s.saleTemplateData(settings, sale, req.Message)
+	return s.queueDocument(models.EmailTypeInvoice, language, req, sale.Customer, data, "sale", sale.ID, userID,
+		models.EmailAttachment{Filename: saleDocumentFilename(sale, ".pdf"), ContentType: "application/pdf", Content: pdfBytes})
+}
+
+// SendReceiptEmail - Antrikan kwitansi pembayaran; hanya untuk penjualan yang sudah lunas
+func (s *EmailService) SendReceiptEmail(saleID uint, req models.SendDocumentEmailRequest, scope *models.EffectiveDataScope, userID uint) (*models.EmailMessage, error) {
+	sale, err := s.loadSale(saleID, scope)
+	if err != nil {
+		return nil, err
+	}
+	if !(strings.EqualFold(sale.Status, models.SaleStatusPaid) || sale.OutstandingAmount == 0) {
+		return nil, errors.New("receipt can only be sent for fully paid sales")
+	}
+	settings, err := s.settingsService.GetSettings()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load settings: %v", err)
+	}
+	language := emailLanguage(req.Language, settings)
+
+	var pdfBytes []byte
+	if userID > 0 {
+		pdfBytes, err = s.pdfService.GenerateReceiptPDFWithUser(sale, userID)
+	} else {
+		pdfBytes, err = s.pdfService.GenerateReceiptPDF(sale)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate receipt PDF: %v", err)
+	}
+	data := s.saleTemplateData(settings, sale, req.Message)
+	data["Amount"] = formatRupiahSimple(sale.PaidAmount)
+	return s.queueDocument(models.EmailTypeReceipt, language, req, sale.Customer, data, "sale", sale.ID, userID,
+		models.EmailAttachment{Filename: saleDocumentFilename(sale, "_receipt.pdf"), ContentType: "application/pdf", Content: pdfBytes})
+}
+
+// SendStatementEmail - Antrikan laporan rekening kontak untuk periode dengan PDF terlampir
+func (s *EmailService) SendStatementEmail(contactID uint, req models.SendStatementEmailRequest, userID uint) (*models.EmailMessage, error) {
+	startDate, err := time.Parse("2006-01-02", req.StartDate)
+	if err != nil {
+		return nil, errors.New("invalid start_date, use YYYY-MM-DD")
+	}
+	endDate, err := time.Parse("2006-01-02", req.EndDate)
+	if err != nil {
+		return nil, errors.New("invalid end_date, use YYYY-MM-DD")
+	}
+	settings, err := s.settingsService.GetSettings()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load settings: %v", err)
+	}
+	language := emailLanguage(req.Language, settings)
+
+	pdfBytes, statement, err := s.statementService.GenerateStatementPDF(contactID, startDate, endDate, language)
+	if err != nil {
+		return nil, err
+	}
+
+	var contact models.Contact
+	if err := s.db.First(&contact, contactID).Error; err != nil {
+		return nil, errors.New("contact not found")
+	}
+	data := s.templateData(settings)
+	data["PeriodStart"] = statement.StartDate.Format(emailDateLayout)
+	data["PeriodEnd"] = statement.EndDate.Format(emailDateLayout)
+	data["ClosingBalance"] = formatRupiahSimple(statement.ClosingBalance)
+	data["Message"] = req.Message
+	return s.queueDocument(models.EmailTypeStatement, language, req.SendDocumentEmailRequest, contact, data, "contact", contact.ID, userID,
+		models.EmailAttachment{Filename: StatementFilename(statement), ContentType: "application/pdf", Content: pdfBytes})
+}
+
+// SendReminderEmail - Pengingat sebelum jatuh tempo (EmailServiceInterface untuk OverdueManagementService)
+func (s *EmailService) SendReminderEmail(customerEmail string, sale *models.Sale, daysBefore int) error {
+	settings, err := s.settingsService.GetSettings()
+	if err != nil {
+		return fmt.Errorf("failed to load settings: %v", err)
+	}
+	data := s.saleTemplateData(settings, sale, "")
+	data["DaysBefore"] = daysBefore
+	return s.queueSaleNotice(models.EmailTypePaymentReminder, customerEmail, sale, settings, data)
+}
+
+// SendOverdueEmail - Pemberitahuan faktur lewat jatuh tempo (EmailServiceInterface untuk OverdueManagementService)
+func (s *EmailService) SendOverdueEmail(customerEmail string, sale *models.Sale, daysOverdue int) error {
+	settings, err := s.settingsService.GetSettings()
+	if err != nil {
+		return fmt.Errorf("failed to load settings: %v", err)
+	}
+	data := s.saleTemplateData(settings, sale, "")
+	data["DaysOverdue"] = daysOverdue
+	return s.queueSaleNotice(models.EmailTypeOverdueNotice, customerEmail, sale, settings, data)
+}
+
+// SendTestEmail - Kirim email uji coba langsung (tanpa menunggu worker) untuk memeriksa konfigurasi SMTP
+func (s *EmailService) SendTestEmail(to string, userID uint) (*models.EmailMessage, error) {
+	cfg, settings, err := s.smtpSettings()
+	if err != nil {
+		return nil, err
+	}
+	language := emailLanguage("", settings)
+	subject, body, err := s.render(models.EmailTypeTest, language, s.templateData(settings))
+	if err != nil {
+		return nil, err
+	}
+
+	message := &models.EmailMessage{
+		Type:        models.EmailTypeTest,
+		Language:    language,
+		ToAddress:   to,
+		Subject:     subject,
+		BodyHTML:    body,
+		MaxAttempts: 1,
+		CreatedBy:   &userID,
+	}
+	if err := s.QueueEmail(message, nil); err != nil {
+		return nil, err
+	}
+	claim := s.db.Model(message).Where("status = ?", models.EmailStatusQueued).Update("status", models.EmailStatusSending)
+	if claim.Error != nil {
+		return nil, claim.Error
+	}
+	if claim.RowsAffected > 0 {
+		s.deliver(cfg, message)
+	}
+	return s.GetMessage(message.ID)
+}
+
+func (s *EmailService) loadSale(saleID uint, scope *models.EffectiveDataScope) (*models.Sale, error) {
+	sale, err := s.salesRepo.FindByID(saleID)
+	if err != nil || !scope.AllowsSale(sale) {
+		return nil, errors.New("sale not found")
+	}
+	return sale, nil
+}
+
+func (s *EmailService) saleTemplateData(settings *models.Settings, sale *models.Sale, message string) map[string]interface{} {
+	data := s.templateData(settings)
+	data["RecipientName"] = contactRecipientName(sale.Customer)
+	data["DocumentNumber"] = saleDocumentNumber(sale)
+	data["DocumentDate"] = sale.Date.Format(emailDateLayout)
+	data["DueDate"] = sale.DueDate.Format(emailDateLayout)
+	data["Amount"] = formatRupiahSimple(sale.TotalAmount)
+	data["Outstanding"] = formatRupiahSimple(sale.OutstandingAmount)
+	data["Message"] = message
+	return data
+}
+
+// queueDocument renders the template and queues it with the document PDF, addressed to req.To or the contact
+func (s *EmailService) queueDocument(emailType, language string, req models.SendDocumentEmailRequest, contact models.Contact,
+	data map[string]interface{}, entityType string, entityID uint, userID uint, attachment models.EmailAttachment) (*models.EmailMessage, error) {
+	to := strings.TrimSpace(req.To)
+	if to == "" {
+		to = contact.Email
+	}
+	if to == "" {
+		return nil, fmt.Errorf("contact %s has no email address, provide a recipient", contact.Name)
+	}
+	if _, ok := data["RecipientName"]; !ok {
+		data["RecipientName"] = contactRecipientName(contact)
+	}
+
+	subject, body, err := s.render(emailType, language, data)
+	if err != nil {
+		return nil, err
+	}
+	contactID := contact.ID
+	message := &models.EmailMessage{
+		Type:        emailType,
+		Language:    language,
+		ToAddress:   to,
+		ToName:      contactRecipientName(contact),
+		CcAddresses: req.Cc,
+		Subject:     subject,
+		BodyHTML:    body,
+		EntityType:  entityType,
+		EntityID:    &entityID,
+		ContactID:   &contactID,
+		CreatedBy:   &userID,
+	}
+	if err := s.QueueEmail(message, []models.EmailAttachment{attachment}); err != nil {
+		return nil, err
+	}
+	log.Printf("📧 %s email for %s #%d queued to %s", emailType, entityType, entityID, to)
+	return message, nil
+}
+
+// queueSaleNotice queues a reminder or overdue notice; skipped silently when email is not configured
+func (s *EmailService) queueSaleNotice(emailType, to string, sale *models.Sale, settings *models.Settings, data map[string]interface{}) error {
+	if _, _, err := s.smtpSettings(); err != nil {
+		return nil
+	}
+	language := emailLanguage("", settings)
+	subject, body, err := s.render(emailType, language, data)
+	if err != nil {
+		return err
+	}
+	saleID := sale.ID
+	contactID := sale.CustomerID
+	return s.QueueEmail(&models.EmailMessage{
+		Type:       emailType,
+		Language:   language,
+		ToAddress:  to,
+		ToName:     contactRecipientName(sale.Customer),
+		Subject:    subject,
+		BodyHTML:   body,
+		EntityType: "sale",
+		EntityID:   &saleID,
+		ContactID:  &contactID,
+	}, nil)
+}
+
+func contactRecipientName(contact models.Contact) string {
+	if contact.PICName != "" {
+		return contact.PICName
+	}
+	return contact.Name
+}
+
+func saleDocumentNumber(sale *models.Sale) string {
+	if sale.InvoiceNumber != "" {
+		return sale.InvoiceNumber
+	}
+	return sale.Code
+}
+
+func saleDocumentFilename(sale *models.Sale, suffix string) string {
+	return saleDocumentNumber(sale) + suffix
+}
+
+// ========== NOTIFICATION EMAILS ==========
+
+// dispatchNotificationEmails queues an email for each new in-app notification whose user keeps the
+// email channel and the notification type enabled in NotificationPreference
+func (s *EmailService) dispatchNotificationEmails() error {
+	_, settings, err := s.smtpSettings()
+	if err != nil {
+		// Email is not configured; notifications stay pending and are picked up once it is
+		return nil
+	}
+
+	var notifications []models.Notification
+	if err := s.db.Preload("User").
+		Where("email_status = '' AND created_at >= ?", time.Now().Add(-emailNotificationAge)).
+		Order("id").Limit(100).
+		Find(&notifications).Error; err != nil {
+		return fmt.Errorf("failed to load notifications: %v", err)
+	}
+
+	language := emailLanguage("", settings)
+	for _, notification := range notifications {
+		status := notificationEmailSkipped
+		if notification.User.IsActive && notification.User.Email != "" && s.notificationEmailWanted(notification.UserID, notification.Type) {
+			data := s.templateData(settings)
+			data["RecipientName"] = notification.User.GetDisplayName()
+			data["Title"] = notification.Title
+			data["Message"] = notification.Message
+			subject, body, err := s.render(models.EmailTypeNotification, language, data)
+			if err == nil {
+				userID, notificationID := notification.UserID, notification.ID
+				err = s.QueueEmail(&models.EmailMessage{
+					Type:            models.EmailTypeNotification,
+					Language:        language,
+					ToAddress:       notification.User.Email,
+					ToName:          notification.User.GetDisplayName(),
+					Subject:         subject,
+					BodyHTML:        body,
+					EntityType:      "notification",
+					EntityID:        &notificationID,
+					RecipientUserID: &userID,
+				}, nil)
+			}
+			if err != nil {
+				log.Printf("⚠️ Failed to queue email for notification #%d: %v", notification.ID, err)
+			} else {
+				status = notificationEmailQueued
+			}
+		}
+		s.db.Model(&models.Notification{}).Where("id = ?", notification.ID).Update("email_status", status)
+	}
+	return nil
+}
+
+// notificationEmailWanted - Cek preferensi: channel email dan tipe notifikasi aktif (default aktif)
+func (s *EmailService) notificationEmailWanted(userID uint, notificationType string) bool {
+	var pref models.NotificationPreference
+	if err := s.db.Where("user_id = ?", userID).First(&pref).Error; err != nil {
+		return true
+	}
+	return pref.EmailEnabled && pref.WantsType(notificationType)
+}
@@ -0,0 +1,234 @@
+package services
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"app-sistem-akuntansi/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// testSMTPServer is a minimal in-process SMTP server; recipients in reject get the configured reply
+type testSMTPServer struct {
+	listener net.Listener
+	reject   map[string]string // recipient -> SMTP reply, e.g. "550 5.1.1 mailbox unavailable"
+
+	mu       sync.Mutex
+	messages []string
+}
+
+func startTestSMTPServer(t *testing.T, reject map[string]string) *testSMTPServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	server := &testSMTPServer{listener: listener, reject: reject}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go server.serve(conn)
+		}
+	}()
+	return server
+}
+
+func (s *testSMTPServer) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (s *testSMTPServer) received() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.messages...)
+}
+
+func (s *testSMTPServer) serve(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	reply := func(line string) { fmt.Fprintf(conn, "%s\r\n", line) }
+
+	reply("220 localhost test SMTP")
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		command := strings.ToUpper(strings.TrimSpace(line))
+		switch {
+		case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+			reply("250 localhost")
+		case strings.HasPrefix(command, "MAIL FROM:"):
+			reply("250 OK")
+		case strings.HasPrefix(command, "RCPT TO:"):
+			address := strings.Trim(strings.TrimSpace(line[len("RCPT TO:"):]), "<>")
+			if rejection, ok := s.reject[address]; ok {
+				reply(rejection)
+				continue
+			}
+			reply("250 OK")
+		case command == "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			var data strings.Builder
+			for {
+				dataLine, err := reader.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if dataLine == ".\r\n" {
+					break
+				}
+				data.WriteString(strings.TrimPrefix(dataLine, "."))
+			}
+			s.mu.Lock()
+			s.messages = append(s.messages, data.String())
+			s.mu.Unlock()
+			reply("250 OK queued")
+		case command == "RSET", command == "NOOP":
+			reply("250 OK")
+		case command == "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("502 Command not implemented")
+		}
+	}
+}
+
+func setupEmailTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1) // Every connection to :memory: is a separate database
+	require.NoError(t, db.AutoMigrate(&models.EmailMessage{}, &models.EmailAttachment{}))
+	return db
+}
+
+func TestEmailDeliveryOverSMTP(t *testing.T) {
+	server := startTestSMTPServer(t, map[string]string{
+		"gone@customer.test": "550 5.1.1 mailbox unavailable",
+		"busy@customer.test": "451 4.3.0 try again later",
+	})
+	db := setupEmailTestDB(t)
+	service := &EmailService{db: db}
+	cfg := &smtpSettings{
+		Host:        "127.0.0.1",
+		Port:        server.port(),
+		Encryption:  models.SMTPEncryptionNone,
+		FromAddress: "billing@company.test",
+		FromName:    "PT Contoh",
+		Sink:        true,
+	}
+	pdf := []byte(strings.Repeat("%PDF-1.4 invoice content ", 20))
+
+	tests := []struct {
+		name         string
+		to           string
+		wantSent     bool
+		wantStatus   string
+		wantAttempts int
+		wantBounce   bool
+	}{
+		{name: "delivered with attachment", to: "buyer@customer.test", wantSent: true, wantStatus: models.EmailStatusSent, wantAttempts: 1},
+		{name: "5xx reply is a bounce", to: "gone@customer.test", wantStatus: models.EmailStatusBounced, wantAttempts: 1, wantBounce: true},
+		{name: "4xx reply is retried", to: "busy@customer.test", wantStatus: models.EmailStatusQueued, wantAttempts: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := len(server.received())
+			message := models.EmailMessage{
+				Type:          "INVOICE",
+				ToAddress:     tt.to,
+				ToName:        "Buyer",
+				Subject:       "Invoice INV/2024/0001",
+				BodyHTML:      "<p>Terlampir invoice Anda.</p>",
+				Status:        models.EmailStatusSending,
+				MaxAttempts:   5,
+				NextAttemptAt: time.Now(),
+			}
+			require.NoError(t, db.Create(&message).Error)
+			require.NoError(t, db.Create(&models.EmailAttachment{
+				EmailMessageID: message.ID,
+				Filename:       "INV-2024-0001.pdf",
+				ContentType:    "application/pdf",
+				Size:           len(pdf),
+				Content:        pdf,
+			}).Error)
+
+			assert.Equal(t, tt.wantSent, service.deliver(cfg, &message))
+
+			var stored models.EmailMessage
+			require.NoError(t, db.First(&stored, message.ID).Error)
+			assert.Equal(t, tt.wantStatus, stored.Status)
+			assert.Equal(t, tt.wantAttempts, stored.Attempts)
+			assert.Equal(t, tt.wantBounce, stored.BouncedAt != nil)
+			if tt.wantBounce {
+				assert.Contains(t, stored.BounceReason, "mailbox unavailable")
+			}
+
+			received := server.received()
+			if !tt.wantSent {
+				assert.Len(t, received, before)
+				return
+			}
+			require.Len(t, received, before+1)
+			assert.NotEmpty(t, stored.SMTPMessageID)
+			assertInvoiceMIME(t, received[len(received)-1], stored.SMTPMessageID, pdf)
+		})
+	}
+}
+
+// assertInvoiceMIME checks the multipart/mixed layout: the HTML body first, then the PDF attachment
+func assertInvoiceMIME(t *testing.T, raw, messageID string, attachment []byte) {
+	parsed, err := mail.ReadMessage(strings.NewReader(raw))
+	require.NoError(t, err)
+	assert.Equal(t, messageID, parsed.Header.Get("Message-ID"))
+	assert.Equal(t, `"PT Contoh" <billing@company.test>`, parsed.Header.Get("From"))
+
+	mediaType, params, err := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	require.Equal(t, "multipart/mixed", mediaType)
+
+	reader := multipart.NewReader(parsed.Body, params["boundary"])
+	htmlPart, err := reader.NextPart()
+	require.NoError(t, err)
+	assert.Equal(t, "text/html; charset=UTF-8", htmlPart.Header.Get("Content-Type"))
+	html, err := io.ReadAll(htmlPart)
+	require.NoError(t, err)
+	assert.Contains(t, string(html), "Terlampir invoice Anda.")
+
+	filePart, err := reader.NextPart()
+	require.NoError(t, err)
+	assert.Equal(t, "INV-2024-0001.pdf", filePart.FileName())
+	assert.Equal(t, "base64", filePart.Header.Get("Content-Transfer-Encoding"))
+	encoded, err := io.ReadAll(filePart)
+	require.NoError(t, err)
+	for _, line := range strings.Split(strings.TrimSpace(string(encoded)), "\r\n") {
+		assert.LessOrEqual(t, len(line), 76)
+	}
+	decoded, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(string(encoded), "\r\n", ""))
+	require.NoError(t, err)
+	assert.Equal(t, attachment, decoded)
+
+	_, err = reader.NextPart()
+	assert.Equal(t, io.EOF, err)
+}
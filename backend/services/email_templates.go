@@ -0,0 +1,190 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+
+	"app-sistem-akuntansi/models"
+)
+
+// ========== EMAIL TEMPLATES ==========
+
+// emailTemplateContent is the subject and HTML body of one email type in one language
+type emailTemplateContent struct {
+	Subject  string
+	BodyHTML string
+}
+
+// defaultEmailTemplates are used for types/languages without an active EmailTemplate row.
+// Available fields: CompanyName, CompanyEmail, CompanyPhone, CompanyAddress, RecipientName,
+// DocumentNumber, DocumentDate, DueDate, Amount, Outstanding, DaysBefore, DaysOverdue,
+// PeriodStart, PeriodEnd, ClosingBalance, Title, Message.
+var defaultEmailTemplates = map[string]map[string]emailTemplateContent{
+	models.EmailTypeInvoice: {
+		"id": {
+			Subject: "Faktur {{.DocumentNumber}} dari {{.CompanyName}}",
+			BodyHTML: `<p>Yth. {{.RecipientName}},</p>
+<p>Terlampir faktur <strong>{{.DocumentNumber}}</strong> tanggal {{.DocumentDate}} sebesar <strong>{{.Amount}}</strong>, jatuh tempo pada {{.DueDate}}.</p>
+{{if .Message}}<p>{{.Message}}</p>{{end}}
+<p>Terima kasih atas kerja sama Anda.</p>`,
+		},
+		"en": {
+			Subject: "Invoice {{.DocumentNumber}} from {{.CompanyName}}",
+			BodyHTML: `<p>Dear {{.RecipientName}},</p>
+<p>Please find attached invoice <strong>{{.DocumentNumber}}</strong> dated {{.DocumentDate}} for <strong>{{.Amount}}</strong>, due on {{.DueDate}}.</p>
+{{if .Message}}<p>{{.Message}}</p>{{end}}
+<p>Thank you for your business.</p>`,
+		},
+	},
+	models.EmailTypeReceipt: {
+		"id": {
+			Subject: "Kwitansi pembayaran {{.DocumentNumber}} - {{.CompanyName}}",
+			BodyHTML: `<p>Yth. {{.RecipientName}},</p>
+<p>Pembayaran faktur <strong>{{.DocumentNumber}}</strong> sebesar <strong>{{.Amount}}</strong> telah kami terima. Kwitansi terlampir.</p>
+{{if .Message}}<p>{{.Message}}</p>{{end}}
+<p>Terima kasih.</p>`,
+		},
+		"en": {
+			Subject: "Payment receipt {{.DocumentNumber}} - {{.CompanyName}}",
+			BodyHTML: `<p>Dear {{.RecipientName}},</p>
+<p>We have received your payment of <strong>{{.Amount}}</strong> for invoice <strong>{{.DocumentNumber}}</strong>. The receipt is attached.</p>
+{{if .Message}}<p>{{.Message}}</p>{{end}}
+<p>Thank you.</p>`,
+		},
+	},
+	models.EmailTypeStatement: {
+		"id": {
+			Subject: "Laporan rekening {{.PeriodStart}} - {{.PeriodEnd}} dari {{.CompanyName}}",
+			BodyHTML: `<p>Yth. {{.RecipientName}},</p>
+<p>Terlampir laporan rekening Anda untuk periode {{.PeriodStart}} sampai {{.PeriodEnd}} dengan saldo akhir <strong>{{.ClosingBalance}}</strong>.</p>
+{{if .Message}}<p>{{.Message}}</p>{{end}}
+<p>Mohon hubungi kami bila terdapat perbedaan.</p>`,
+		},
+		"en": {
+			Subject: "Statement of account {{.PeriodStart}} - {{.PeriodEnd}} from {{.CompanyName}}",
+			BodyHTML: `<p>Dear {{.RecipientName}},</p>
+<p>Please find attached your statement of account for {{.PeriodStart}} to {{.PeriodEnd}} with a closing balance of <strong>{{.ClosingBalance}}</strong>.</p>
+{{if .Message}}<p>{{.Message}}</p>{{end}}
+<p>Please contact us if anything does not match your records.</p>`,
+		},
+	},
+	models.EmailTypePaymentReminder: {
+		"id": {
+			Subject: "Pengingat: faktur {{.DocumentNumber}} jatuh tempo {{.DueDate}}",
+			BodyHTML: `<p>Yth. {{.RecipientName}},</p>
+<p>Kami ingin mengingatkan bahwa faktur <strong>{{.DocumentNumber}}</strong> dengan sisa tagihan <strong>{{.Outstanding}}</strong> akan jatuh tempo dalam {{.DaysBefore}} hari, pada {{.DueDate}}.</p>
+<p>Abaikan email ini bila pembayaran sudah dilakukan.</p>`,
+		},
+		"en": {
+			Subject: "Reminder: invoice {{.DocumentNumber}} is due on {{.DueDate}}",
+			BodyHTML: `<p>Dear {{.RecipientName}},</p>
+<p>This is a friendly reminder that invoice <strong>{{.DocumentNumber}}</strong> with an outstanding amount of <strong>{{.Outstanding}}</strong> is due in {{.DaysBefore}} days, on {{.DueDate}}.</p>
+<p>Please disregard this email if payment has already been made.</p>`,
+		},
+	},
+	models.EmailTypeOverdueNotice: {
+		"id": {
+			Subject: "Faktur {{.DocumentNumber}} telah lewat jatuh tempo {{.DaysOverdue}} hari",
+			BodyHTML: `<p>Yth. {{.RecipientName}},</p>
+<p>Faktur <strong>{{.DocumentNumber}}</strong> yang jatuh tempo pada {{.DueDate}} telah lewat {{.DaysOverdue}} hari dengan sisa tagihan <strong>{{.Outstanding}}</strong>.</p>
+<p>Mohon segera melakukan pembayaran atau menghubungi kami.</p>`,
+		},
+		"en": {
+			Subject: "Invoice {{.DocumentNumber}} is {{.DaysOverdue}} days overdue",
+			BodyHTML: `<p>Dear {{.RecipientName}},</p>
+<p>Invoice <strong>{{.DocumentNumber}}</strong>, due on {{.DueDate}}, is now {{.DaysOverdue}} days overdue with an outstanding amount of <strong>{{.Outstanding}}</strong>.</p>
+<p>Please arrange payment as soon as possible or contact us.</p>`,
+		},
+	},
+	models.EmailTypeNotification: {
+		"id": {
+			Subject:  "{{.Title}}",
+			BodyHTML: `<p>Halo {{.RecipientName}},</p><p><strong>{{.Title}}</strong></p><p>{{.Message}}</p>`,
+		},
+		"en": {
+			Subject:  "{{.Title}}",
+			BodyHTML: `<p>Hello {{.RecipientName}},</p><p><strong>{{.Title}}</strong></p><p>{{.Message}}</p>`,
+		},
+	},
+	models.EmailTypeTest: {
+		"id": {
+			Subject:  "Email uji coba dari {{.CompanyName}}",
+			BodyHTML: `<p>Konfigurasi SMTP berfungsi. Email ini dikirim dari pengaturan email {{.CompanyName}}.</p>`,
+		},
+		"en": {
+			Subject:  "Test email from {{.CompanyName}}",
+			BodyHTML: `<p>The SMTP configuration works. This email was sent from the email settings of {{.CompanyName}}.</p>`,
+		},
+	},
+}
+
+// emailLayout wraps every rendered body with the company header and footer
+var emailLayout = htmltemplate.Must(htmltemplate.New("layout").Parse(`<!DOCTYPE html>
+<html><body style="font-family: Arial, sans-serif; font-size: 14px; color: #333;">
+<div style="max-width: 640px; margin: 0 auto;">
+<h2 style="color: #1a4f8b;">{{.CompanyName}}</h2>
+{{.Content}}
+<hr style="border: none; border-top: 1px solid #ddd;">
+<p style="font-size: 12px; color: #888;">{{.CompanyName}}{{if .CompanyAddress}} &middot; {{.CompanyAddress}}{{end}}{{if .CompanyPhone}} &middot; {{.CompanyPhone}}{{end}}{{if .CompanyEmail}} &middot; {{.CompanyEmail}}{{end}}</p>
+</div>
+</body></html>`))
+
+// emailLanguage - Bahasa template: "id" atau "en", default dari Settings.Language
+func emailLanguage(lang string, settings *models.Settings) string {
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	if lang == "id" || lang == "en" {
+		return lang
+	}
+	if settings != nil && settings.Language == "en" {
+		return "en"
+	}
+	return "id"
+}
+
+// validateEmailTemplate checks that subject and body parse as templates
+func validateEmailTemplate(subject, bodyHTML string) error {
+	if _, err := texttemplate.New("subject").Parse(subject); err != nil {
+		return fmt.Errorf("invalid subject template: %v", err)
+	}
+	if _, err := htmltemplate.New("body").Parse(bodyHTML); err != nil {
+		return fmt.Errorf("invalid body template: %v", err)
+	}
+	return nil
+}
+
+// renderEmailTemplate renders subject and body, and wraps the body in the company layout
+func renderEmailTemplate(content emailTemplateContent, data map[string]interface{}) (string, string, error) {
+	subjectTemplate, err := texttemplate.New("subject").Parse(content.Subject)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid subject template: %v", err)
+	}
+	var subject bytes.Buffer
+	if err := subjectTemplate.Execute(&subject, data); err != nil {
+		return "", "", fmt.Errorf("failed to render subject: %v", err)
+	}
+
+	bodyTemplate, err := htmltemplate.New("body").Parse(content.BodyHTML)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid body template: %v", err)
+	}
+	var body bytes.Buffer
+	if err := bodyTemplate.Execute(&body, data); err != nil {
+		return "", "", fmt.Errorf("failed to render body: %v", err)
+	}
+
+	layoutData := make(map[string]interface{}, len(data)+1)
+	for key, value := range data {
+		layoutData[key] = value
+	}
+	layoutData["Content"] = htmltemplate.HTML(body.String())
+	var page bytes.Buffer
+	if err := emailLayout.Execute(&page, layoutData); err != nil {
+		return "", "", fmt.Errorf("failed to render email layout: %v", err)
+	}
+
+	// Subjects are a single header line
+	return strings.Join(strings.Fields(subject.String()), " "), page.String(), nil
+}
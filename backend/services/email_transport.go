@@ -0,0 +1,192 @@
+package services
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+
+	"app-sistem-akuntansi/models"
+)
+
+// ========== SMTP TRANSPORT ==========
+
+// smtpSettings is the resolved SMTP connection of one delivery run
+type smtpSettings struct {
+	Host        string
+	Port        int
+	Username    string
+	Password    string
+	Encryption  string
+	FromAddress string
+	FromName    string
+	Sink        bool // Local SMTP sink from EMAIL_SMTP_SINK
+}
+
+// errEmailBounced wraps permanent SMTP rejections (5xx); the message is not retried
+type errEmailBounced struct {
+	err error
+}
+
+func (e *errEmailBounced) Error() string { return e.err.Error() }
+
+const smtpDialTimeout = 30 * time.Second
+
+// sendSMTP delivers one raw MIME message to the recipients
+func sendSMTP(cfg *smtpSettings, recipients []string, raw []byte) error {
+	addr := net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port))
+
+	var conn net.Conn
+	var err error
+	if cfg.Encryption == models.SMTPEncryptionSSL {
+		conn, err = tls.DialWithDialer(&net.Dialer{Timeout: smtpDialTimeout}, "tcp", addr, &tls.Config{ServerName: cfg.Host})
+	} else {
+		conn, err = net.DialTimeout("tcp", addr, smtpDialTimeout)
+	}
+	if err != nil {
+		return fmt.Errorf("cannot connect to SMTP server %s: %v", addr, err)
+	}
+
+	client, err := smtp.NewClient(conn, cfg.Host)
+	if err != nil {
+		conn.Close()
+		return classifySMTPError(err)
+	}
+	defer client.Close()
+
+	if cfg.Encryption == models.SMTPEncryptionStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("SMTP server %s does not support STARTTLS", addr)
+		}
+		if err := client.StartTLS(&tls.Config{ServerName: cfg.Host}); err != nil {
+			return fmt.Errorf("STARTTLS failed: %v", err)
+		}
+	}
+	if cfg.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)); err != nil {
+			return fmt.Errorf("SMTP authentication failed: %v", err)
+		}
+	}
+
+	if err := client.Mail(cfg.FromAddress); err != nil {
+		return classifySMTPError(err)
+	}
+	for _, recipient := range recipients {
+		if err := client.Rcpt(recipient); err != nil {
+			return classifySMTPError(err)
+		}
+	}
+	writer, err := client.Data()
+	if err != nil {
+		return classifySMTPError(err)
+	}
+	if _, err := writer.Write(raw); err != nil {
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return classifySMTPError(err)
+	}
+	return client.Quit()
+}
+
+// classifySMTPError marks 5xx replies as permanent so the queue records a bounce instead of retrying
+func classifySMTPError(err error) error {
+	var protoErr *textproto.Error
+	if errors.As(err, &protoErr) && protoErr.Code >= 500 {
+		return &errEmailBounced{err: err}
+	}
+	return err
+}
+
+// buildEmailMIME renders the message as multipart/mixed: an HTML part followed by the attachments.
+// Returns the raw message and its Message-ID.
+func buildEmailMIME(cfg *smtpSettings, message *models.EmailMessage, attachments []models.EmailAttachment) ([]byte, string, error) {
+	messageID := newEmailMessageID(cfg.FromAddress)
+
+	var body bytes.Buffer
+	parts := multipart.NewWriter(&body)
+
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Type", "text/html; charset=UTF-8")
+	header.Set("Content-Transfer-Encoding", "quoted-printable")
+	htmlPart, err := parts.CreatePart(header)
+	if err != nil {
+		return nil, "", err
+	}
+	qp := quotedprintable.NewWriter(htmlPart)
+	if _, err := qp.Write([]byte(message.BodyHTML)); err != nil {
+		return nil, "", err
+	}
+	if err := qp.Close(); err != nil {
+		return nil, "", err
+	}
+
+	for _, attachment := range attachments {
+		header := make(textproto.MIMEHeader)
+		header.Set("Content-Type", fmt.Sprintf("%s; name=%q", attachment.ContentType, attachment.Filename))
+		header.Set("Content-Transfer-Encoding", "base64")
+		header.Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", attachment.Filename))
+		part, err := parts.CreatePart(header)
+		if err != nil {
+			return nil, "", err
+		}
+		if err := writeBase64Lines(part, attachment.Content); err != nil {
+			return nil, "", err
+		}
+	}
+	if err := parts.Close(); err != nil {
+		return nil, "", err
+	}
+
+	var raw bytes.Buffer
+	from := mail.Address{Name: cfg.FromName, Address: cfg.FromAddress}
+	to := mail.Address{Name: message.ToName, Address: message.ToAddress}
+	fmt.Fprintf(&raw, "From: %s\r\n", from.String())
+	fmt.Fprintf(&raw, "To: %s\r\n", to.String())
+	if len(message.CcAddresses) > 0 {
+		fmt.Fprintf(&raw, "Cc: %s\r\n", strings.Join(message.CcAddresses, ", "))
+	}
+	fmt.Fprintf(&raw, "Subject: %s\r\n", mime.QEncoding.Encode("UTF-8", message.Subject))
+	fmt.Fprintf(&raw, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&raw, "Message-ID: %s\r\n", messageID)
+	fmt.Fprintf(&raw, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&raw, "Content-Type: multipart/mixed; boundary=%s\r\n\r\n", parts.Boundary())
+	raw.Write(body.Bytes())
+	return raw.Bytes(), messageID, nil
+}
+
+// writeBase64Lines writes base64 in 76 character lines as required by RFC 2045
+func writeBase64Lines(w interface{ Write([]byte) (int, error) }, content []byte) error {
+	encoded := base64.StdEncoding.EncodeToString(content)
+	for len(encoded) > 76 {
+		if _, err := w.Write([]byte(encoded[:76] + "\r\n")); err != nil {
+			return err
+		}
+		encoded = encoded[76:]
+	}
+	_, err := w.Write([]byte(encoded + "\r\n"))
+	return err
+}
+
+func newEmailMessageID(fromAddress string) string {
+	domain := "localhost"
+	if at := strings.LastIndex(fromAddress, "@"); at >= 0 && at < len(fromAddress)-1 {
+		domain = fromAddress[at+1:]
+	}
+	random := make([]byte, 12)
+	rand.Read(random)
+	return fmt.Sprintf("<%d.%s@%s>", time.Now().UnixNano(), hex.EncodeToString(random), domain)
+}
@@ -23,6 +23,8 @@ type EnhancedPaymentServiceWithJournal struct {
 	journalFactory   *PaymentJournalFactory
 	journalService   *UnifiedJournalService
 	statusValidator  *StatusValidationHelper // NEW: Konsistensi dengan SalesJournalServiceV2
+	approvalService  *ApprovalService        // Holds vendor payments that match a PAYMENT approval workflow
+	currencyService  *CurrencyService        // Settlement of foreign currency invoices and bills
 }
 
 // ExpensePaymentRequest represents a direct expense payment mapped from COA.
@@ -68,6 +70,7 @@ func NewEnhancedPaymentServiceWithJournal(
 		journalFactory:  journalFactory,
 		journalService:  journalService,
 		statusValidator: NewStatusValidationHelper(), // Initialize status validator
+		currencyService: NewCurrencyService(db),
 	}
 }
 
@@ -93,6 +96,9 @@ type PaymentWithJournalRequest struct {
 	InvoiceAllocations []AllocationItem `json:"invoice_allocations,omitempty"`
 	BillAllocations    []AllocationItem `json:"bill_allocations,omitempty"`
 	
+	// Settlement rate for foreign currency invoices/bills; nil = rate table
+	ExchangeRate      *float64  `json:"exchange_rate,omitempty"`
+	
 	// User context
 	UserID            uint      `json:"user_id"`
 }
@@ -270,8 +276,6 @@ func (eps *EnhancedPaymentServiceWithJournal) CreateExpensePayment(req *ExpenseP
 
 // CreatePaymentWithJournal creates a payment with automatic journal entry creation
 func (eps *EnhancedPaymentServiceWithJournal) CreatePaymentWithJournal(req *PaymentWithJournalRequest) (*PaymentWithJournalResponse, error) {
-	startTime := time.Now()
-
 	// 🔍 DEBUG: Log received request
 	log.Printf("🔍 DEBUG CreatePaymentWithJournal request:")
 	log.Printf("  - ContactID: %d", req.ContactID)
@@ -296,6 +300,57 @@ func (eps *EnhancedPaymentServiceWithJournal) CreatePaymentWithJournal(req *Paym
 		return nil, fmt.Errorf("payment validation failed: %w", err)
 	}
 
+	// Vendor payments matching a PAYMENT approval workflow are held until approved
+	if eps.approvalService != nil {
+		if contact, err := eps.contactRepo.GetByID(req.ContactID); err == nil && contact.Type == "VENDOR" {
+			approvalRequest := vendorPaymentApprovalRequest(eps.cashBankRepo, req.ContactID, req.CashBankID, req.Amount, req.Notes)
+			if eps.approvalService.RequiresApproval(approvalRequest) {
+				return eps.holdPaymentWithJournal(req, contact, approvalRequest)
+			}
+		}
+	}
+
+	return eps.postPaymentWithJournal(req, nil)
+}
+
+// holdPaymentWithJournal - Menahan pembayaran vendor sampai workflow PAYMENT menyetujuinya
+func (eps *EnhancedPaymentServiceWithJournal) holdPaymentWithJournal(req *PaymentWithJournalRequest, contact *models.Contact, approvalRequest models.CreateApprovalRequestDTO) (*PaymentWithJournalResponse, error) {
+	method := req.Method
+	if method == "" {
+		method = eps.autoDetectPaymentMethod(contact.Type)
+	}
+	code, err := eps.generatePaymentCode(eps.db, method, contact.Type)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate payment code: %w", err)
+	}
+
+	payment, err := holdPayment(eps.db, eps.approvalService, &models.Payment{
+		Code:      code,
+		ContactID: req.ContactID,
+		UserID:    req.UserID,
+		Date:      req.Date,
+		Amount:    req.Amount,
+		Method:    method,
+		Reference: req.Reference,
+		Notes:     req.Notes,
+	}, heldPaymentFlowSSOT, req, approvalRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PaymentWithJournalResponse{
+		Success: true,
+		Payment: payment,
+		Contact: contact,
+		Message: fmt.Sprintf("Payment %s is waiting for approval", payment.Code),
+	}, nil
+}
+
+// postPaymentWithJournal records and posts a payment. held is the payment record kept while the
+// payment waited for approval; nil creates a new one.
+func (eps *EnhancedPaymentServiceWithJournal) postPaymentWithJournal(req *PaymentWithJournalRequest, held *models.Payment) (*PaymentWithJournalResponse, error) {
+	startTime := time.Now()
+
 	var (
 		payment       *models.Payment
 		journalResult *PaymentJournalResult
@@ -323,28 +378,37 @@ func (eps *EnhancedPaymentServiceWithJournal) CreatePaymentWithJournal(req *Paym
 			req.Method = eps.autoDetectPaymentMethod(contact.Type)
 		}
 
-		// Step 4: Create payment record
-		payment = &models.Payment{
-			ContactID:   req.ContactID,
-			UserID:      req.UserID,
-			Date:        req.Date,
-			Amount:      req.Amount,
-			Method:      req.Method,
-			Reference:   req.Reference,
-			Notes:       req.Notes,
-			Status:      models.PaymentStatusPending,
-		}
+		// Step 4: Create payment record (or post the one held for approval)
+		if held != nil {
+			payment = held
+			payment.Status = models.PaymentStatusPending
+			payment.HeldRequest = ""
+			if err := tx.Save(payment).Error; err != nil {
+				return fmt.Errorf("failed to update payment: %w", err)
+			}
+		} else {
+			payment = &models.Payment{
+				ContactID:   req.ContactID,
+				UserID:      req.UserID,
+				Date:        req.Date,
+				Amount:      req.Amount,
+				Method:      req.Method,
+				Reference:   req.Reference,
+				Notes:       req.Notes,
+				Status:      models.PaymentStatusPending,
+			}
 
-		// Generate payment code
-		code, err := eps.generatePaymentCode(tx, req.Method, contact.Type)
-		if err != nil {
-			return fmt.Errorf("failed to generate payment code: %w", err)
-		}
-		payment.Code = code
+			// Generate payment code
+			code, err := eps.generatePaymentCode(tx, req.Method, contact.Type)
+			if err != nil {
+				return fmt.Errorf("failed to generate payment code: %w", err)
+			}
+			payment.Code = code
 
-		// Create payment in database
-		if err := tx.Create(payment).Error; err != nil {
-			return fmt.Errorf("failed to create payment: %w", err)
+			// Create payment in database
+			if err := tx.Create(payment).Error; err != nil {
+				return fmt.Errorf("failed to create payment: %w", err)
+			}
 		}
 
 		log.Printf("✅ Payment created: ID=%d, Code=%s", payment.ID, payment.Code)
@@ -393,6 +457,15 @@ func (eps *EnhancedPaymentServiceWithJournal) CreatePaymentWithJournal(req *Paym
 			return fmt.Errorf("failed to update Cash & Bank balances: %w", err)
 		}
 
+		// Step 9: Webhook outbox - customer receipts only
+		if contact.Type == "CUSTOMER" {
+			webhookData := paymentReceivedWebhookData(payment.ID, payment.Code, payment.ContactID, payment.Date, payment.Amount,
+				payment.Method, payment.Reference, paymentAllocationsWebhookData(allocations))
+			if err := recordWebhookEvent(tx, models.WebhookEventPaymentReceived, "payment", payment.ID, webhookData); err != nil {
+				return err
+			}
+		}
+
 		// Build response
 		response.Payment = payment
 		response.JournalResult = journalResult
@@ -670,15 +743,22 @@ func (eps *EnhancedPaymentServiceWithJournal) createPaymentAllocations(
 					return nil, err
 				}
 				
+				// Foreign currency invoice: outstanding is tracked in invoice currency
+				settlement, err := eps.currencyService.NewDocumentSettlement(tx, models.FXItemTypeReceivable, sale.InvoiceNumber,
+					sale.Currency, sale.ExchangeRate, sale.OutstandingAmount, payment.Date, req.ExchangeRate)
+				if err != nil {
+					return nil, fmt.Errorf("invoice %v", err)
+				}
+				
 				// Calculate allocated amount
 				allocatedAmount := alloc.Amount
 				if allocatedAmount > remainingAmount {
 					allocatedAmount = remainingAmount
 					log.Printf("⚠️ Adjusting amount to remaining: %.2f -> %.2f", alloc.Amount, allocatedAmount)
 				}
-				if allocatedAmount > sale.OutstandingAmount {
-					allocatedAmount = sale.OutstandingAmount
-					log.Printf("⚠️ Adjusting amount to outstanding: %.2f -> %.2f", allocatedAmount, sale.OutstandingAmount)
+				if allocatedAmount > settlement.OutstandingBase {
+					allocatedAmount = settlement.OutstandingBase
+					log.Printf("⚠️ Adjusting amount to outstanding: %.2f -> %.2f", allocatedAmount, settlement.OutstandingBase)
 				}
 				
 				// Create allocation
@@ -695,8 +775,8 @@ func (eps *EnhancedPaymentServiceWithJournal) createPaymentAllocations(
 				allocations = append(allocations, allocation)
 				
 				// Update sale outstanding
-				if err := eps.updateSaleOutstanding(tx, *alloc.InvoiceID, allocatedAmount); err != nil {
-					log.Printf("⚠️ Warning: Failed to update sale outstanding: %v", err)
+				if err := eps.updateSaleOutstanding(tx, &sale, settlement, payment, allocatedAmount, req.UserID); err != nil {
+					return nil, fmt.Errorf("failed to update sale outstanding: %w", err)
 				}
 				
 				remainingAmount -= allocatedAmount
@@ -715,6 +795,12 @@ func (eps *EnhancedPaymentServiceWithJournal) createPaymentAllocations(
 				return nil, err
 			}
 			
+			settlement, err := eps.currencyService.NewDocumentSettlement(tx, models.FXItemTypeReceivable, sale.InvoiceNumber,
+				sale.Currency, sale.ExchangeRate, sale.OutstandingAmount, payment.Date, req.ExchangeRate)
+			if err != nil {
+				return nil, fmt.Errorf("invoice %v", err)
+			}
+			
 			allocation := models.PaymentAllocation{
 				PaymentID:       uint64(payment.ID),
 				InvoiceID:       req.TargetInvoiceID,
@@ -727,8 +813,8 @@ func (eps *EnhancedPaymentServiceWithJournal) createPaymentAllocations(
 			
 			allocations = append(allocations, allocation)
 			
-			if err := eps.updateSaleOutstanding(tx, *req.TargetInvoiceID, payment.Amount); err != nil {
-				log.Printf("⚠️ Warning: Failed to update sale outstanding: %v", err)
+			if err := eps.updateSaleOutstanding(tx, &sale, settlement, payment, payment.Amount, req.UserID); err != nil {
+				return nil, fmt.Errorf("failed to update sale outstanding: %w", err)
 			}
 		}
 	} else if contactType == "VENDOR" {
@@ -759,15 +845,22 @@ func (eps *EnhancedPaymentServiceWithJournal) createPaymentAllocations(
 					return nil, fmt.Errorf("bill %d does not belong to this vendor", *alloc.BillID)
 				}
 				
+				// Foreign currency bill: outstanding is tracked in bill currency
+				settlement, err := eps.currencyService.NewDocumentSettlement(tx, models.FXItemTypePayable, purchase.Code,
+					purchase.Currency, purchase.ExchangeRate, purchase.OutstandingAmount, payment.Date, req.ExchangeRate)
+				if err != nil {
+					return nil, fmt.Errorf("bill %v", err)
+				}
+				
 				// Calculate allocated amount
 				allocatedAmount := alloc.Amount
 				if allocatedAmount > remainingAmount {
 					allocatedAmount = remainingAmount
 					log.Printf("⚠️ Adjusting amount to remaining: %.2f -> %.2f", alloc.Amount, allocatedAmount)
 				}
-				if allocatedAmount > purchase.OutstandingAmount {
-					allocatedAmount = purchase.OutstandingAmount
-					log.Printf("⚠️ Adjusting amount to outstanding: %.2f -> %.2f", allocatedAmount, purchase.OutstandingAmount)
+				if allocatedAmount > settlement.OutstandingBase {
+					allocatedAmount = settlement.OutstandingBase
+					log.Printf("⚠️ Adjusting amount to outstanding: %.2f -> %.2f", allocatedAmount, settlement.OutstandingBase)
 				}
 				
 				// Create allocation
@@ -784,7 +877,7 @@ func (eps *EnhancedPaymentServiceWithJournal) createPaymentAllocations(
 				allocations = append(allocations, allocation)
 				
 				// 🔥 FIX: Update purchase outstanding
-				if err := eps.updatePurchaseOutstanding(tx, *alloc.BillID, allocatedAmount); err != nil {
+				if err := eps.updatePurchaseOutstanding(tx, &purchase, settlement, payment, allocatedAmount, req.UserID); err != nil {
 					log.Printf("❌ CRITICAL: Failed to update purchase outstanding: %v", err)
 					return nil, fmt.Errorf("failed to update purchase outstanding: %w", err)
 				}
@@ -796,6 +889,16 @@ func (eps *EnhancedPaymentServiceWithJournal) createPaymentAllocations(
 		} else if req.TargetBillID != nil {
 			// Legacy: single bill allocation
 			log.Printf("📝 Processing single bill allocation (legacy)")
+			var purchase models.Purchase
+			if err := tx.First(&purchase, *req.TargetBillID).Error; err != nil {
+				return nil, fmt.Errorf("bill not found: %w", err)
+			}
+			settlement, err := eps.currencyService.NewDocumentSettlement(tx, models.FXItemTypePayable, purchase.Code,
+				purchase.Currency, purchase.ExchangeRate, purchase.OutstandingAmount, payment.Date, req.ExchangeRate)
+			if err != nil {
+				return nil, fmt.Errorf("bill %v", err)
+			}
+			
 			allocation := models.PaymentAllocation{
 				PaymentID:       uint64(payment.ID),
 				BillID:          req.TargetBillID,
@@ -808,7 +911,7 @@ func (eps *EnhancedPaymentServiceWithJournal) createPaymentAllocations(
 			
 			allocations = append(allocations, allocation)
 			
-			if err := eps.updatePurchaseOutstanding(tx, *req.TargetBillID, payment.Amount); err != nil {
+			if err := eps.updatePurchaseOutstanding(tx, &purchase, settlement, payment, payment.Amount, req.UserID); err != nil {
 				log.Printf("❌ CRITICAL: Failed to update purchase outstanding: %v", err)
 				return nil, fmt.Errorf("failed to update purchase outstanding: %w", err)
 			}
@@ -818,16 +921,14 @@ func (eps *EnhancedPaymentServiceWithJournal) createPaymentAllocations(
 	return allocations, nil
 }
 
-// updateSaleOutstanding updates sale outstanding amount after payment allocation
-func (eps *EnhancedPaymentServiceWithJournal) updateSaleOutstanding(tx *gorm.DB, saleID uint, paidAmount float64) error {
-	var sale models.Sale
-	if err := tx.First(&sale, saleID).Error; err != nil {
-		return fmt.Errorf("sale not found: %w", err)
-	}
+// updateSaleOutstanding updates sale outstanding amount after payment allocation. paidAmount is in base
+// currency; a foreign currency invoice is settled in invoice currency and the realized difference posted.
+func (eps *EnhancedPaymentServiceWithJournal) updateSaleOutstanding(tx *gorm.DB, sale *models.Sale, settlement *DocumentSettlement, payment *models.Payment, paidAmount float64, userID uint) error {
+	settledAmount, fxSettlement := settlement.Settle(paidAmount)
 
 	// Update amounts
-	sale.PaidAmount += paidAmount
-	sale.OutstandingAmount -= paidAmount
+	sale.PaidAmount += settledAmount
+	sale.OutstandingAmount -= settledAmount
 
 	// Update status if fully paid
 	if sale.OutstandingAmount <= 0.01 { // Allow small rounding differences
@@ -835,23 +936,27 @@ func (eps *EnhancedPaymentServiceWithJournal) updateSaleOutstanding(tx *gorm.DB,
 		sale.OutstandingAmount = 0 // Ensure exact zero
 	}
 
-	return tx.Save(&sale).Error
+	if err := tx.Save(sale).Error; err != nil {
+		return err
+	}
+
+	// Realized FX gain/loss against the rate the invoice was booked at
+	_, err := eps.currencyService.PostRealizedFXGainLoss(tx, fxSettlement, payment.ID, payment.Code, payment.Date, userID)
+	return err
 }
 
-// updatePurchaseOutstanding updates purchase outstanding amount after payment allocation
-func (eps *EnhancedPaymentServiceWithJournal) updatePurchaseOutstanding(tx *gorm.DB, purchaseID uint, paidAmount float64) error {
-	var purchase models.Purchase
-	if err := tx.First(&purchase, purchaseID).Error; err != nil {
-		return fmt.Errorf("purchase not found: %w", err)
-	}
+// updatePurchaseOutstanding updates purchase outstanding amount after payment allocation. paidAmount is in
+// base currency; a foreign currency bill is settled in bill currency and the realized difference posted.
+func (eps *EnhancedPaymentServiceWithJournal) updatePurchaseOutstanding(tx *gorm.DB, purchase *models.Purchase, settlement *DocumentSettlement, payment *models.Payment, paidAmount float64, userID uint) error {
+	settledAmount, fxSettlement := settlement.Settle(paidAmount)
 
 	log.Printf("📝 Updating purchase amounts: PaidAmount %.2f -> %.2f, Outstanding %.2f -> %.2f", 
-		purchase.PaidAmount, purchase.PaidAmount + paidAmount,
-		purchase.OutstandingAmount, purchase.OutstandingAmount - paidAmount)
+		purchase.PaidAmount, purchase.PaidAmount + settledAmount,
+		purchase.OutstandingAmount, purchase.OutstandingAmount - settledAmount)
 
 	// Update amounts
-	purchase.PaidAmount += paidAmount
-	purchase.OutstandingAmount -= paidAmount
+	purchase.PaidAmount += settledAmount
+	purchase.OutstandingAmount -= settledAmount
 
 	// Update matching status if fully paid
 	if purchase.OutstandingAmount <= 0.01 { // Allow small rounding differences
@@ -863,13 +968,15 @@ func (eps *EnhancedPaymentServiceWithJournal) updatePurchaseOutstanding(tx *gorm
 		log.Printf("✅ Purchase partially paid (Outstanding: %.2f)", purchase.OutstandingAmount)
 	}
 
-	if err := tx.Save(&purchase).Error; err != nil {
+	if err := tx.Save(purchase).Error; err != nil {
 		log.Printf("❌ Failed to save purchase: %v", err)
 		return err
 	}
 	log.Printf("✅ Purchase updated successfully")
 
-	return nil
+	// Realized FX gain/loss against the rate the bill was booked at
+	_, err := eps.currencyService.PostRealizedFXGainLoss(tx, fxSettlement, payment.ID, payment.Code, payment.Date, userID)
+	return err
 }
 
 // validatePaymentRequest validates the payment request
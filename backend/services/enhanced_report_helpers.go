@@ -805,6 +805,132 @@ func (ers *EnhancedReportService) sortVendorsBySpend(vendorSpendMap map[uint]*Ve
 	return vendors
 }
 
+// calculatePriceVarianceVsList compares the net unit price of every purchase item with the best
+// price list entry of the same vendor valid on the purchase date; items without one are counted only
+func (ers *EnhancedReportService) calculatePriceVarianceVsList(purchases []models.Purchase) (PriceVarianceSummary, error) {
+	summary := PriceVarianceSummary{Vendors: []VendorPriceVarianceData{}}
+
+	vendorIDs := []uint{}
+	seen := make(map[uint]bool)
+	for _, purchase := range purchases {
+		if !seen[purchase.VendorID] {
+			seen[purchase.VendorID] = true
+			vendorIDs = append(vendorIDs, purchase.VendorID)
+		}
+	}
+	if len(vendorIDs) == 0 {
+		return summary, nil
+	}
+
+	var entries []models.VendorPriceList
+	if err := ers.db.Where("vendor_id IN ?", vendorIDs).Find(&entries).Error; err != nil {
+		return summary, err
+	}
+	priceLists := make(map[[2]uint][]models.VendorPriceList)
+	for _, entry := range entries {
+		key := [2]uint{entry.VendorID, entry.ProductID}
+		priceLists[key] = append(priceLists[key], entry)
+	}
+
+	type productTotals struct {
+		name     string
+		quantity int64
+		list     float64
+		actual   float64
+	}
+	vendorMap := make(map[uint]*VendorPriceVarianceData)
+	productMap := make(map[uint]map[uint]*productTotals)
+
+	for _, purchase := range purchases {
+		if purchase.Status == models.PurchaseStatusCancelled {
+			continue
+		}
+		for _, item := range purchase.PurchaseItems {
+			if item.Quantity <= 0 {
+				continue
+			}
+			listed := pickBestVendorPrice(priceLists[[2]uint{purchase.VendorID, item.ProductID}], item.Product.Unit, item.Quantity, purchase.Date)
+			if listed == nil {
+				summary.ItemsWithoutList++
+				continue
+			}
+
+			listValue := listed.Price * float64(item.Quantity)
+			actualValue := item.TotalPrice
+
+			vendor, exists := vendorMap[purchase.VendorID]
+			if !exists {
+				vendor = &VendorPriceVarianceData{VendorID: purchase.VendorID, VendorName: purchase.Vendor.Name}
+				vendorMap[purchase.VendorID] = vendor
+				productMap[purchase.VendorID] = make(map[uint]*productTotals)
+			}
+			vendor.ItemsCompared++
+			vendor.ListValue += listValue
+			vendor.ActualValue += actualValue
+			if actualValue > listValue+0.005 {
+				vendor.ItemsAboveList++
+			} else if actualValue < listValue-0.005 {
+				vendor.ItemsBelowList++
+			}
+
+			product, exists := productMap[purchase.VendorID][item.ProductID]
+			if !exists {
+				product = &productTotals{name: item.Product.Name}
+				productMap[purchase.VendorID][item.ProductID] = product
+			}
+			product.quantity += int64(item.Quantity)
+			product.list += listValue
+			product.actual += actualValue
+		}
+	}
+
+	for vendorID, vendor := range vendorMap {
+		vendor.ListValue = math.Round(vendor.ListValue*100) / 100
+		vendor.ActualValue = math.Round(vendor.ActualValue*100) / 100
+		vendor.VarianceAmount = math.Round((vendor.ActualValue-vendor.ListValue)*100) / 100
+		vendor.VariancePercent = variancePercent(vendor.VarianceAmount, vendor.ListValue)
+
+		for productID, totals := range productMap[vendorID] {
+			variance := math.Round((totals.actual-totals.list)*100) / 100
+			vendor.Products = append(vendor.Products, ProductPriceVarianceData{
+				ProductID:       productID,
+				ProductName:     totals.name,
+				Quantity:        totals.quantity,
+				AverageList:     math.Round(totals.list/float64(totals.quantity)*100) / 100,
+				AverageActual:   math.Round(totals.actual/float64(totals.quantity)*100) / 100,
+				VarianceAmount:  variance,
+				VariancePercent: variancePercent(variance, totals.list),
+			})
+		}
+		sort.Slice(vendor.Products, func(i, j int) bool {
+			return vendor.Products[i].VarianceAmount > vendor.Products[j].VarianceAmount
+		})
+
+		summary.ItemsCompared += vendor.ItemsCompared
+		summary.ListValue += vendor.ListValue
+		summary.ActualValue += vendor.ActualValue
+		summary.Vendors = append(summary.Vendors, *vendor)
+	}
+
+	// Vendors charging most above their list first
+	sort.Slice(summary.Vendors, func(i, j int) bool {
+		return summary.Vendors[i].VarianceAmount > summary.Vendors[j].VarianceAmount
+	})
+
+	summary.ListValue = math.Round(summary.ListValue*100) / 100
+	summary.ActualValue = math.Round(summary.ActualValue*100) / 100
+	summary.VarianceAmount = math.Round((summary.ActualValue-summary.ListValue)*100) / 100
+	summary.VariancePercent = variancePercent(summary.VarianceAmount, summary.ListValue)
+	return summary, nil
+}
+
+func variancePercent(variance, base float64) float64 {
+	if base == 0 {
+		return 0
+	}
+	return math.Round(variance/base*10000) / 100
+}
+
 // calculatePaymentAnalysis calculates payment analysis metrics
 func (ers *EnhancedReportService) calculatePaymentAnalysis(payments []models.Payment) PaymentAnalysisData {
 	// This is a simplified implementation
@@ -236,6 +236,7 @@ type VendorAnalysisData struct {
 	PaymentAnalysis       PaymentAnalysisData     `json:"payment_analysis"`
 	TopVendorsBySpend     []VendorSpendData       `json:"top_vendors_by_spend"`
 	VendorPaymentHistory  []VendorPaymentHistory  `json:"vendor_payment_history"`
+	PriceVariance         PriceVarianceSummary    `json:"price_variance"`
 	GeneratedAt           time.Time               `json:"generated_at"`
 }
 
@@ -341,6 +342,42 @@ type VendorSpendData struct {
 	Transactions int64   `json:"transactions"`
 }
 
+// PriceVarianceSummary compares what was paid with the vendor price list valid on the purchase date
+type PriceVarianceSummary struct {
+	ItemsCompared    int64                     `json:"items_compared"`
+	ItemsWithoutList int64                     `json:"items_without_list"`
+	ListValue        float64                   `json:"list_value"`
+	ActualValue      float64                   `json:"actual_value"`
+	VarianceAmount   float64                   `json:"variance_amount"` // Actual - list; positive = paid above list
+	VariancePercent  float64                   `json:"variance_percent"`
+	Vendors          []VendorPriceVarianceData `json:"vendors"`
+}
+
+// VendorPriceVarianceData is the price variance vs list of one vendor
+type VendorPriceVarianceData struct {
+	VendorID        uint                       `json:"vendor_id"`
+	VendorName      string                     `json:"vendor_name"`
+	ItemsCompared   int64                      `json:"items_compared"`
+	ItemsAboveList  int64                      `json:"items_above_list"`
+	ItemsBelowList  int64                      `json:"items_below_list"`
+	ListValue       float64                    `json:"list_value"`
+	ActualValue     float64                    `json:"actual_value"`
+	VarianceAmount  float64                    `json:"variance_amount"`
+	VariancePercent float64                    `json:"variance_percent"`
+	Products        []ProductPriceVarianceData `json:"products"`
+}
+
+// ProductPriceVarianceData is the price variance vs list of one product bought from a vendor
+type ProductPriceVarianceData struct {
+	ProductID       uint    `json:"product_id"`
+	ProductName     string  `json:"product_name"`
+	Quantity        int64   `json:"quantity"`
+	AverageList     float64 `json:"average_list_price"`
+	AverageActual   float64 `json:"average_actual_price"`
+	VarianceAmount  float64 `json:"variance_amount"`
+	VariancePercent float64 `json:"variance_percent"`
+}
+
 type VendorPaymentHistory struct {
 	Month        string  `json:"month"`
 	Purchases    float64 `json:"purchases"`
@@ -1503,6 +1540,13 @@ func (ers *EnhancedReportService) GenerateVendorAnalysis(startDate, endDate time
 	// Build vendor payment history
 	analysis.VendorPaymentHistory = ers.buildVendorPaymentHistory(startDate, endDate)
 
+	// Compare purchase prices with the vendor price lists
+	priceVariance, err := ers.calculatePriceVarianceVsList(purchases)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate price variance: %v", err)
+	}
+	analysis.PriceVariance = priceVariance
+
 	// Calculate totals
 	analysis.TotalVendors = int64(len(vendorMap))
 	analysis.ActiveVendors = ers.countActiveVendors(vendorMap)
@@ -13,15 +13,17 @@ import (
 // InventoryCOGSService handles Cost of Goods Sold journal entries
 // This service creates COGS journal entries when items are sold
 type InventoryCOGSService struct {
-	db         *gorm.DB
-	coaService *COAService
+	db             *gorm.DB
+	coaService     *COAService
+	costingService *InventoryCostingService
 }
 
 // NewInventoryCOGSService creates a new COGS service instance
 func NewInventoryCOGSService(db *gorm.DB, coaService *COAService) *InventoryCOGSService {
 	return &InventoryCOGSService{
-		db:         db,
-		coaService: coaService,
+		db:             db,
+		coaService:     coaService,
+		costingService: NewInventoryCostingService(db),
 	}
 }
 
@@ -63,31 +65,11 @@ func (s *InventoryCOGSService) RecordCOGSForSale(sale *models.Sale, tx *gorm.DB)
 		return fmt.Errorf("Inventory account not found: %v", err)
 	}
 
-	// Calculate total COGS from sale items
-	var totalCOGS decimal.Decimal
+	// Calculate total COGS from receipt cost layers (FIFO/LIFO/Average per product)
 	var journalLines []models.SSOTJournalLine
-
-	for _, item := range sale.SaleItems {
-		// Skip if no product loaded (check if Product.ID is 0)
-		if item.Product.ID == 0 {
-			log.Printf("⚠️ [COGS] Sale item #%d has no product, skipping", item.ID)
-			continue
-		}
-
-		// Calculate COGS: Quantity * Cost Price
-		itemCOGS := decimal.NewFromFloat(float64(item.Quantity)).Mul(
-			decimal.NewFromFloat(item.Product.CostPrice),
-		)
-
-		if itemCOGS.IsZero() {
-			log.Printf("⚠️ [COGS] Product '%s' has zero cost price, skipping", item.Product.Name)
-			continue
-		}
-
-		totalCOGS = totalCOGS.Add(itemCOGS)
-
-		log.Printf("   [COGS] Item: %s | Qty: %d | Cost: Rp %.2f | COGS: Rp %.2f",
-			item.Product.Name, item.Quantity, item.Product.CostPrice, itemCOGS.InexactFloat64())
+	totalCOGS, err := s.costingService.CostSale(dbToUse, sale)
+	if err != nil {
+		return fmt.Errorf("failed to cost sale items: %v", err)
 	}
 
 	// If no COGS calculated, skip
@@ -174,6 +156,11 @@ func (s *InventoryCOGSService) RecordCOGSForSale(sale *models.Sale, tx *gorm.DB)
 	return nil
 }
 
+// ReleaseCOGSForSale returns the receipt layer quantities consumed by a cancelled sale
+func (s *InventoryCOGSService) ReleaseCOGSForSale(saleID uint, tx *gorm.DB) error {
+	return s.costingService.ReleaseSale(tx, saleID)
+}
+
 // RecordCOGSForMultipleSales records COGS for multiple sales (batch processing)
 func (s *InventoryCOGSService) RecordCOGSForMultipleSales(saleIDs []uint) (int, error) {
 	successCount := 0
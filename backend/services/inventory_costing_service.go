@@ -0,0 +1,827 @@
+package services
+
+import (
+	"app-sistem-akuntansi/models"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// InventoryCostingService values stock movements from purchase receipt layers.
+// Every receipt is an IN movement whose RemainingQty is consumed by sales, either
+// FIFO/LIFO at the layer cost or at the moving average cost kept in Product.CostPrice.
+type InventoryCostingService struct {
+	db               *gorm.DB
+	journalService   *UnifiedJournalService
+	taxAccountHelper *TaxAccountHelper
+}
+
+func NewInventoryCostingService(db *gorm.DB) *InventoryCostingService {
+	return &InventoryCostingService{
+		db:               db,
+		journalService:   NewUnifiedJournalService(db),
+		taxAccountHelper: NewTaxAccountHelper(db),
+	}
+}
+
+// ========== REQUEST / RESPONSE TYPES ==========
+
+// InventoryRecostRequest - Request re-costing persediaan (mis. setelah pembelian mundur tanggal)
+type InventoryRecostRequest struct {
+	ProductIDs []uint     `json:"product_ids"` // kosong = semua produk yang memiliki layer penerimaan
+	EntryDate  *time.Time `json:"entry_date"`  // tanggal jurnal penyesuaian HPP, default hari ini
+	Notes      string     `json:"notes"`
+}
+
+// SaleCostAdjustment - Perubahan HPP satu penjualan akibat re-costing
+type SaleCostAdjustment struct {
+	SaleID       uint            `json:"sale_id"`
+	ProductID    uint            `json:"product_id"`
+	ProductName  string          `json:"product_name"`
+	PreviousCost decimal.Decimal `json:"previous_cost"`
+	NewCost      decimal.Decimal `json:"new_cost"`
+	Difference   decimal.Decimal `json:"difference"` // positive = HPP naik
+}
+
+// InventoryRecostResult - Ringkasan hasil re-costing
+type InventoryRecostResult struct {
+	ProductsProcessed int                  `json:"products_processed"`
+	SalesAdjusted     int                  `json:"sales_adjusted"`
+	COGSAdjustment    decimal.Decimal      `json:"cogs_adjustment"` // positive = HPP naik
+	JournalID         *uint64              `json:"journal_id"`
+	Adjustments       []SaleCostAdjustment `json:"adjustments"`
+}
+
+// InventoryValuationItem - Nilai persediaan per produk
+type InventoryValuationItem struct {
+	ProductID      uint    `json:"product_id"`
+	ProductCode    string  `json:"product_code"`
+	ProductName    string  `json:"product_name"`
+	CostingMethod  string  `json:"costing_method"`
+	StockQty       int     `json:"stock_qty"`
+	LayeredQty     int     `json:"layered_qty"`
+	UnlayeredQty   int     `json:"unlayered_qty"` // stok tanpa layer penerimaan, dinilai dengan cost price
+	AverageCost    float64 `json:"average_cost"`
+	LayeredValue   float64 `json:"layered_value"`
+	UnlayeredValue float64 `json:"unlayered_value"`
+	TotalValue     float64 `json:"total_value"`
+}
+
+// InventoryValuationReport - Laporan nilai persediaan yang direkonsiliasi ke akun persediaan
+type InventoryValuationReport struct {
+	GeneratedAt          time.Time                `json:"generated_at"`
+	Items                []InventoryValuationItem `json:"items"`
+	TotalQuantity        int                      `json:"total_quantity"`
+	TotalValue           float64                  `json:"total_value"`
+	InventoryAccountID   uint                     `json:"inventory_account_id"`
+	InventoryAccountCode string                   `json:"inventory_account_code"`
+	InventoryAccountName string                   `json:"inventory_account_name"`
+	GLBalance            float64                  `json:"gl_balance"`
+	Difference           float64                  `json:"difference"` // total_value - gl_balance
+	IsReconciled         bool                     `json:"is_reconciled"`
+}
+
+// costPortion is the part of an outgoing quantity valued from one receipt layer
+type costPortion struct {
+	layerID  *uint
+	quantity int
+	cost     decimal.Decimal
+}
+
+// ========== RECEIPTS ==========
+
+// RecordReceipt adds a receipt layer for a stock product and refreshes its moving average cost.
+// totalCost is in base currency. The returned flag is true when the receipt is dated before sales
+// that were already costed, meaning those sales should be re-costed.
+func (s *InventoryCostingService) RecordReceipt(tx *gorm.DB, productID uint, referenceType string, referenceID uint, quantity int, totalCost decimal.Decimal, date time.Time, notes string) (*models.Inventory, bool, error) {
+	if tx == nil {
+		tx = s.db
+	}
+	if quantity <= 0 {
+		return nil, false, nil
+	}
+
+	var product models.Product
+	if err := tx.First(&product, productID).Error; err != nil {
+		return nil, false, fmt.Errorf("product not found: %v", err)
+	}
+	if product.IsService {
+		return nil, false, nil
+	}
+
+	layeredQty, err := s.layeredQuantity(tx, productID)
+	if err != nil {
+		return nil, false, err
+	}
+	averageCost := movingAverage(decimal.NewFromInt(int64(layeredQty)), decimal.NewFromFloat(product.CostPrice), quantity, totalCost)
+
+	totalCost = totalCost.Round(2)
+	layer := models.Inventory{
+		ProductID:       productID,
+		ReferenceType:   referenceType,
+		ReferenceID:     referenceID,
+		Type:            models.InventoryTypeIn,
+		Quantity:        quantity,
+		UnitCost:        totalCost.Div(decimal.NewFromInt(int64(quantity))).Round(2).InexactFloat64(),
+		TotalCost:       totalCost.InexactFloat64(),
+		RemainingQty:    quantity,
+		Notes:           notes,
+		TransactionDate: date,
+	}
+	if err := tx.Create(&layer).Error; err != nil {
+		return nil, false, fmt.Errorf("failed to create receipt layer: %v", err)
+	}
+
+	if err := tx.Model(&models.Product{}).Where("id = ?", productID).
+		UpdateColumn("cost_price", averageCost.Round(2).InexactFloat64()).Error; err != nil {
+		return nil, false, fmt.Errorf("failed to update moving average cost: %v", err)
+	}
+
+	backDated, err := s.HasSalesCostedAfter(tx, productID, date)
+	if err != nil {
+		return nil, false, err
+	}
+
+	log.Printf("📦 [COSTING] Receipt layer #%d for product %d: %d × Rp %.2f (avg now Rp %s)",
+		layer.ID, productID, quantity, layer.UnitCost, averageCost.StringFixed(2))
+	return &layer, backDated, nil
+}
+
+// HasSalesCostedAfter reports whether sales of the product dated after date were already costed,
+// i.e. whether a receipt on that date is back-dated and those sales should be re-costed
+func (s *InventoryCostingService) HasSalesCostedAfter(tx *gorm.DB, productID uint, date time.Time) (bool, error) {
+	if tx == nil {
+		tx = s.db
+	}
+	var laterSales int64
+	if err := tx.Model(&models.Inventory{}).
+		Where("product_id = ? AND type = ? AND reference_type = ? AND transaction_date > ?",
+			productID, models.InventoryTypeOut, models.InventoryRefSale, date).
+		Count(&laterSales).Error; err != nil {
+		return false, fmt.Errorf("failed to check back-dated receipt: %v", err)
+	}
+	return laterSales > 0, nil
+}
+
+// ReturnReceipt takes quantity sent back to the vendor off the receipt layers of a purchase
+// (latest first), records the OUT movement and refreshes the moving average cost. It returns
+// the inventory value removed. The flag is true when part of the returned quantity had already
+// been consumed by sales, meaning those sales should be re-costed.
+func (s *InventoryCostingService) ReturnReceipt(tx *gorm.DB, productID, purchaseID, returnID uint, warehouseID *uint, quantity int, date time.Time, notes string) (decimal.Decimal, bool, error) {
+	if tx == nil {
+		tx = s.db
+	}
+	if quantity <= 0 {
+		return decimal.Zero, false, nil
+	}
+
+	var product models.Product
+	if err := tx.First(&product, productID).Error; err != nil {
+		return decimal.Zero, false, fmt.Errorf("product not found: %v", err)
+	}
+	if product.IsService {
+		return decimal.Zero, false, nil
+	}
+
+	layeredQty, err := s.layeredQuantity(tx, productID)
+	if err != nil {
+		return decimal.Zero, false, err
+	}
+
+	var layers []models.Inventory
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("product_id = ? AND type = ? AND reference_type = ? AND reference_id = ? AND quantity > 0",
+			productID, models.InventoryTypeIn, models.InventoryRefPurchase, purchaseID).
+		Order("id DESC").Find(&layers).Error; err != nil {
+		return decimal.Zero, false, fmt.Errorf("failed to load receipt layers: %v", err)
+	}
+
+	returnedCost := decimal.Zero
+	layerQtyReturned := 0
+	needsRecost := false
+	remaining := quantity
+	for i := range layers {
+		if remaining <= 0 {
+			break
+		}
+		layer := &layers[i]
+		take := layer.Quantity
+		if take > remaining {
+			take = remaining
+		}
+
+		cost := layerValue(layer, layer.Quantity).Sub(layerValue(layer, layer.Quantity-take))
+		layer.Quantity -= take
+		layer.TotalCost = decimal.NewFromFloat(layer.TotalCost).Sub(cost).InexactFloat64()
+		layer.RemainingQty -= take
+		if layer.RemainingQty < 0 {
+			needsRecost = true
+			layer.RemainingQty = 0
+		}
+		if err := tx.Model(&models.Inventory{}).Where("id = ?", layer.ID).Updates(map[string]interface{}{
+			"quantity":      layer.Quantity,
+			"total_cost":    layer.TotalCost,
+			"remaining_qty": layer.RemainingQty,
+		}).Error; err != nil {
+			return decimal.Zero, false, fmt.Errorf("failed to update receipt layer: %v", err)
+		}
+
+		returnedCost = returnedCost.Add(cost)
+		layerQtyReturned += take
+		remaining -= take
+	}
+
+	// Quantity without a receipt layer of this purchase leaves at the moving average cost
+	averageCost := decimal.NewFromFloat(product.CostPrice)
+	if remaining > 0 {
+		returnedCost = returnedCost.Add(averageCost.Mul(decimal.NewFromInt(int64(remaining))).Round(2))
+	}
+
+	if onHand := layeredQty - layerQtyReturned; layerQtyReturned > 0 && onHand > 0 {
+		averageCost = decimal.NewFromInt(int64(layeredQty)).Mul(averageCost).
+			Sub(returnedCost).
+			Div(decimal.NewFromInt(int64(onHand)))
+		if averageCost.LessThan(decimal.Zero) {
+			averageCost = decimal.Zero
+		}
+		if err := tx.Model(&models.Product{}).Where("id = ?", productID).
+			UpdateColumn("cost_price", averageCost.Round(2).InexactFloat64()).Error; err != nil {
+			return decimal.Zero, false, fmt.Errorf("failed to update moving average cost: %v", err)
+		}
+	}
+
+	out := models.Inventory{
+		ProductID:           productID,
+		ReferenceType:       models.InventoryRefPurchaseReturn,
+		ReferenceID:         returnID,
+		Type:                models.InventoryTypeOut,
+		Quantity:            quantity,
+		UnitCost:            returnedCost.Div(decimal.NewFromInt(int64(quantity))).Round(2).InexactFloat64(),
+		TotalCost:           returnedCost.Round(2).InexactFloat64(),
+		WarehouseLocationID: warehouseID,
+		Notes:               notes,
+		TransactionDate:     date,
+	}
+	if err := tx.Create(&out).Error; err != nil {
+		return decimal.Zero, false, fmt.Errorf("failed to record return movement: %v", err)
+	}
+
+	log.Printf("↩️ [COSTING] Purchase return #%d for product %d: %d units, Rp %s off purchase #%d layers",
+		returnID, productID, quantity, returnedCost.StringFixed(2), purchaseID)
+	return returnedCost.Round(2), needsRecost, nil
+}
+
+// ========== SALES ==========
+
+// CostSale consumes receipt layers for every stock item of a sale, writes its cost layers
+// and returns the total COGS. A sale that is already costed returns its recorded cost.
+func (s *InventoryCostingService) CostSale(tx *gorm.DB, sale *models.Sale) (decimal.Decimal, error) {
+	if tx == nil {
+		tx = s.db
+	}
+
+	var existing []models.SaleCostLayer
+	if err := tx.Where("sale_id = ?", sale.ID).Find(&existing).Error; err != nil {
+		return decimal.Zero, fmt.Errorf("failed to load sale cost layers: %v", err)
+	}
+	if len(existing) > 0 {
+		total := decimal.Zero
+		for _, l := range existing {
+			total = total.Add(decimal.NewFromFloat(l.TotalCost))
+		}
+		return total, nil
+	}
+
+	var items []models.SaleItem
+	if err := tx.Preload("Product").Where("sale_id = ?", sale.ID).Find(&items).Error; err != nil {
+		return decimal.Zero, fmt.Errorf("failed to load sale items: %v", err)
+	}
+
+	total := decimal.Zero
+	for _, item := range items {
+		if item.Product.ID == 0 || item.Product.IsService || item.Quantity <= 0 {
+			continue
+		}
+
+		out := models.Inventory{
+			ProductID:       item.ProductID,
+			ReferenceType:   models.InventoryRefSale,
+			ReferenceID:     sale.ID,
+			Type:            models.InventoryTypeOut,
+			Quantity:        item.Quantity,
+			Notes:           sale.InvoiceNumber,
+			TransactionDate: sale.Date,
+			WarehouseLocationID: item.WarehouseLocationID,
+		}
+		if err := tx.Create(&out).Error; err != nil {
+			return decimal.Zero, fmt.Errorf("failed to create inventory movement: %v", err)
+		}
+
+		method := productCostingMethod(item.Product)
+		order := "transaction_date ASC, id ASC"
+		if method == models.ValuationLIFO {
+			order = "transaction_date DESC, id DESC"
+		}
+		var layers []models.Inventory
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("product_id = ? AND type = ? AND reference_type <> '' AND remaining_qty > 0",
+				item.ProductID, models.InventoryTypeIn).
+			Order(order).Find(&layers).Error; err != nil {
+			return decimal.Zero, fmt.Errorf("failed to load receipt layers: %v", err)
+		}
+
+		open := make([]*models.Inventory, len(layers))
+		for i := range layers {
+			open[i] = &layers[i]
+		}
+		portions := allocateCost(open, item.Quantity, method, decimal.NewFromFloat(item.Product.CostPrice))
+		for _, layer := range open {
+			if err := tx.Model(&models.Inventory{}).Where("id = ?", layer.ID).
+				UpdateColumn("remaining_qty", layer.RemainingQty).Error; err != nil {
+				return decimal.Zero, fmt.Errorf("failed to update receipt layer: %v", err)
+			}
+		}
+
+		itemCost, err := s.saveCostLayers(tx, sale.ID, item.ID, &out, method, portions)
+		if err != nil {
+			return decimal.Zero, err
+		}
+		total = total.Add(itemCost)
+
+		log.Printf("   [COSTING] %s: %d × %s = Rp %s", item.Product.Name, item.Quantity, method, itemCost.StringFixed(2))
+	}
+
+	return total, nil
+}
+
+// ReleaseSale puts the quantities consumed by a sale back on their receipt layers and removes
+// its OUT movements and cost layers, e.g. when the sale is cancelled or its journal is rebuilt
+func (s *InventoryCostingService) ReleaseSale(tx *gorm.DB, saleID uint) error {
+	if tx == nil {
+		tx = s.db
+	}
+
+	var layers []models.SaleCostLayer
+	if err := tx.Where("sale_id = ?", saleID).Find(&layers).Error; err != nil {
+		return fmt.Errorf("failed to load sale cost layers: %v", err)
+	}
+	for _, l := range layers {
+		if l.ReceiptLayerID == nil {
+			continue
+		}
+		if err := tx.Model(&models.Inventory{}).Where("id = ?", *l.ReceiptLayerID).
+			UpdateColumn("remaining_qty", gorm.Expr("remaining_qty + ?", l.Quantity)).Error; err != nil {
+			return fmt.Errorf("failed to restore receipt layer: %v", err)
+		}
+	}
+
+	if err := tx.Where("sale_id = ?", saleID).Delete(&models.SaleCostLayer{}).Error; err != nil {
+		return fmt.Errorf("failed to delete sale cost layers: %v", err)
+	}
+	if err := tx.Where("reference_type = ? AND reference_id = ? AND type = ?",
+		models.InventoryRefSale, saleID, models.InventoryTypeOut).Delete(&models.Inventory{}).Error; err != nil {
+		return fmt.Errorf("failed to delete sale inventory movements: %v", err)
+	}
+	return nil
+}
+
+// GetSaleCostLayers - Rincian layer biaya yang membentuk HPP sebuah penjualan
+func (s *InventoryCostingService) GetSaleCostLayers(saleID uint) ([]models.SaleCostLayer, error) {
+	var layers []models.SaleCostLayer
+	err := s.db.Preload("Product").Where("sale_id = ?", saleID).Order("sale_item_id, id").Find(&layers).Error
+	return layers, err
+}
+
+// ========== RE-COSTING ==========
+
+// RunRecost replays the receipt and sale movements of each product in date order, rewrites
+// the sale cost layers and posts one journal for the net COGS difference
+func (s *InventoryCostingService) RunRecost(req InventoryRecostRequest, userID uint) (*InventoryRecostResult, error) {
+	result := &InventoryRecostResult{COGSAdjustment: decimal.Zero}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		productIDs := req.ProductIDs
+		if len(productIDs) == 0 {
+			if err := tx.Model(&models.Inventory{}).
+				Where("type = ? AND reference_type <> ''", models.InventoryTypeIn).
+				Distinct().Pluck("product_id", &productIDs).Error; err != nil {
+				return fmt.Errorf("failed to list products to re-cost: %v", err)
+			}
+		}
+
+		salesAdjusted := map[uint]bool{}
+		for _, productID := range productIDs {
+			adjustments, err := s.recostProduct(tx, productID)
+			if err != nil {
+				return err
+			}
+			result.ProductsProcessed++
+			for _, adj := range adjustments {
+				salesAdjusted[adj.SaleID] = true
+				result.COGSAdjustment = result.COGSAdjustment.Add(adj.Difference)
+			}
+			result.Adjustments = append(result.Adjustments, adjustments...)
+		}
+		result.SalesAdjusted = len(salesAdjusted)
+
+		if result.COGSAdjustment.IsZero() {
+			return nil
+		}
+
+		entryDate := time.Now()
+		if req.EntryDate != nil {
+			entryDate = *req.EntryDate
+		}
+		entry, err := s.postRecostJournal(tx, result.COGSAdjustment, entryDate, req.Notes, userID)
+		if err != nil {
+			return err
+		}
+		result.JournalID = &entry.ID
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("🔁 [COSTING] Re-costed %d products, %d sales adjusted, COGS difference Rp %s",
+		result.ProductsProcessed, result.SalesAdjusted, result.COGSAdjustment.StringFixed(2))
+	return result, nil
+}
+
+// recostProduct rebuilds receipt layer balances, sale cost layers and the moving average of one product
+func (s *InventoryCostingService) recostProduct(tx *gorm.DB, productID uint) ([]SaleCostAdjustment, error) {
+	var product models.Product
+	if err := tx.First(&product, productID).Error; err != nil {
+		return nil, fmt.Errorf("product %d not found: %v", productID, err)
+	}
+	method := productCostingMethod(product)
+
+	var receipts []models.Inventory
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("product_id = ? AND type = ? AND reference_type <> '' AND quantity > 0", productID, models.InventoryTypeIn).
+		Order("transaction_date ASC, id ASC").Find(&receipts).Error; err != nil {
+		return nil, fmt.Errorf("failed to load receipt layers: %v", err)
+	}
+	var issues []models.Inventory
+	if err := tx.Where("product_id = ? AND type = ? AND reference_type = ?", productID, models.InventoryTypeOut, models.InventoryRefSale).
+		Order("transaction_date ASC, id ASC").Find(&issues).Error; err != nil {
+		return nil, fmt.Errorf("failed to load sale movements: %v", err)
+	}
+
+	var adjustments []SaleCostAdjustment
+	var open []*models.Inventory
+	layeredQty := decimal.Zero
+	averageCost := decimal.NewFromFloat(product.CostPrice)
+
+	r := 0
+	for _, out := range issues {
+		// Receipts dated on or before the sale are available to it
+		for r < len(receipts) && !receipts[r].TransactionDate.After(out.TransactionDate) {
+			receipt := &receipts[r]
+			receipt.RemainingQty = receipt.Quantity
+			averageCost = movingAverage(layeredQty, averageCost, receipt.Quantity, decimal.NewFromFloat(receipt.TotalCost))
+			layeredQty = layeredQty.Add(decimal.NewFromInt(int64(receipt.Quantity)))
+			open = append(open, receipt)
+			r++
+		}
+
+		ordered := make([]*models.Inventory, 0, len(open))
+		for _, layer := range open {
+			if layer.RemainingQty > 0 {
+				ordered = append(ordered, layer)
+			}
+		}
+		if method == models.ValuationLIFO {
+			for i, j := 0, len(ordered)-1; i < j; i, j = i+1, j-1 {
+				ordered[i], ordered[j] = ordered[j], ordered[i]
+			}
+		}
+
+		portions := allocateCost(ordered, out.Quantity, method, averageCost)
+		for _, p := range portions {
+			if p.layerID != nil {
+				layeredQty = layeredQty.Sub(decimal.NewFromInt(int64(p.quantity)))
+			}
+		}
+
+		var previous []models.SaleCostLayer
+		if err := tx.Where("inventory_out_id = ?", out.ID).Find(&previous).Error; err != nil {
+			return nil, fmt.Errorf("failed to load sale cost layers: %v", err)
+		}
+		previousCost := decimal.Zero
+		var saleItemID uint
+		for _, l := range previous {
+			previousCost = previousCost.Add(decimal.NewFromFloat(l.TotalCost))
+			saleItemID = l.SaleItemID
+		}
+		if err := tx.Where("inventory_out_id = ?", out.ID).Delete(&models.SaleCostLayer{}).Error; err != nil {
+			return nil, fmt.Errorf("failed to delete sale cost layers: %v", err)
+		}
+
+		newCost, err := s.saveCostLayers(tx, out.ReferenceID, saleItemID, &out, method, portions)
+		if err != nil {
+			return nil, err
+		}
+
+		if diff := newCost.Sub(previousCost); !diff.IsZero() {
+			adjustments = append(adjustments, SaleCostAdjustment{
+				SaleID:       out.ReferenceID,
+				ProductID:    productID,
+				ProductName:  product.Name,
+				PreviousCost: previousCost,
+				NewCost:      newCost,
+				Difference:   diff,
+			})
+		}
+	}
+
+	// Receipts after the last sale are untouched by it
+	for ; r < len(receipts); r++ {
+		receipt := &receipts[r]
+		receipt.RemainingQty = receipt.Quantity
+		averageCost = movingAverage(layeredQty, averageCost, receipt.Quantity, decimal.NewFromFloat(receipt.TotalCost))
+		layeredQty = layeredQty.Add(decimal.NewFromInt(int64(receipt.Quantity)))
+	}
+
+	for _, receipt := range receipts {
+		if err := tx.Model(&models.Inventory{}).Where("id = ?", receipt.ID).
+			UpdateColumn("remaining_qty", receipt.RemainingQty).Error; err != nil {
+			return nil, fmt.Errorf("failed to update receipt layer: %v", err)
+		}
+	}
+	if len(receipts) > 0 {
+		if err := tx.Model(&models.Product{}).Where("id = ?", productID).
+			UpdateColumn("cost_price", averageCost.Round(2).InexactFloat64()).Error; err != nil {
+			return nil, fmt.Errorf("failed to update moving average cost: %v", err)
+		}
+	}
+
+	return adjustments, nil
+}
+
+// postRecostJournal books the net COGS difference of a re-costing run against the inventory account
+func (s *InventoryCostingService) postRecostJournal(tx *gorm.DB, difference decimal.Decimal, entryDate time.Time, notes string, userID uint) (*models.SSOTJournalEntry, error) {
+	cogsAccount, err := s.taxAccountHelper.GetCOGSAccount(tx)
+	if err != nil {
+		return nil, fmt.Errorf("COGS account not found: %v", err)
+	}
+	inventoryAccount, err := s.taxAccountHelper.GetInventoryAccount(tx)
+	if err != nil {
+		return nil, fmt.Errorf("inventory account not found: %v", err)
+	}
+
+	amount := difference.Abs().Round(2)
+	description := "Penyesuaian HPP re-costing persediaan"
+	if strings.TrimSpace(notes) != "" {
+		description = fmt.Sprintf("%s - %s", description, notes)
+	}
+
+	// COGS increased: Dr HPP / Cr Persediaan; decreased: the reverse
+	lines := []JournalLineRequest{
+		{AccountID: uint64(cogsAccount.ID), DebitAmount: amount, Description: description},
+		{AccountID: uint64(inventoryAccount.ID), CreditAmount: amount, Description: description},
+	}
+	if difference.LessThan(decimal.Zero) {
+		lines = []JournalLineRequest{
+			{AccountID: uint64(inventoryAccount.ID), DebitAmount: amount, Description: description},
+			{AccountID: uint64(cogsAccount.ID), CreditAmount: amount, Description: description},
+		}
+	}
+
+	entry, err := s.journalService.CreateJournalEntryWithTx(tx, &JournalEntryRequest{
+		SourceType:  models.SSOTSourceTypeAdjustment,
+		Reference:   fmt.Sprintf("RECOST-%s", entryDate.Format("20060102")),
+		EntryDate:   entryDate,
+		Description: description,
+		Lines:       lines,
+		AutoPost:    true,
+		CreatedBy:   uint64(userID),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to post re-costing journal: %v", err)
+	}
+	return entry, nil
+}
+
+// ========== VALUATION ==========
+
+// GetValuationReport values the stock on hand from its receipt layers and reconciles the
+// total against the balance of the configured inventory account
+func (s *InventoryCostingService) GetValuationReport() (*InventoryValuationReport, error) {
+	var products []models.Product
+	if err := s.db.Where("is_service = ?", false).Order("code").Find(&products).Error; err != nil {
+		return nil, fmt.Errorf("failed to load products: %v", err)
+	}
+
+	var layerRows []struct {
+		ProductID  uint
+		Quantity   int
+		LayerValue float64
+	}
+	if err := s.db.Raw(`
+		SELECT product_id,
+		       COALESCE(SUM(remaining_qty), 0) AS quantity,
+		       COALESCE(SUM(ROUND(total_cost * remaining_qty / NULLIF(quantity, 0), 2)), 0) AS layer_value
+		FROM inventories
+		WHERE type = ? AND reference_type <> '' AND remaining_qty > 0 AND deleted_at IS NULL
+		GROUP BY product_id
+	`, models.InventoryTypeIn).Scan(&layerRows).Error; err != nil {
+		return nil, fmt.Errorf("failed to summarize receipt layers: %v", err)
+	}
+	layersByProduct := make(map[uint]int, len(layerRows))
+	for i, row := range layerRows {
+		layersByProduct[row.ProductID] = i
+	}
+
+	report := &InventoryValuationReport{GeneratedAt: time.Now()}
+	totalValue := decimal.Zero
+	for _, p := range products {
+		item := InventoryValuationItem{
+			ProductID:     p.ID,
+			ProductCode:   p.Code,
+			ProductName:   p.Name,
+			CostingMethod: productCostingMethod(p),
+			StockQty:      p.Stock,
+			AverageCost:   p.CostPrice,
+		}
+		averageCost := decimal.NewFromFloat(p.CostPrice)
+
+		layeredValue := decimal.Zero
+		if idx, ok := layersByProduct[p.ID]; ok {
+			item.LayeredQty = layerRows[idx].Quantity
+			layeredValue = decimal.NewFromFloat(layerRows[idx].LayerValue)
+			if item.CostingMethod == models.ValuationAverage {
+				layeredValue = averageCost.Mul(decimal.NewFromInt(int64(item.LayeredQty))).Round(2)
+			}
+		}
+		if p.Stock > item.LayeredQty {
+			item.UnlayeredQty = p.Stock - item.LayeredQty
+		}
+		unlayeredValue := averageCost.Mul(decimal.NewFromInt(int64(item.UnlayeredQty))).Round(2)
+
+		if item.LayeredQty == 0 && item.UnlayeredQty == 0 {
+			continue
+		}
+
+		item.LayeredValue = layeredValue.InexactFloat64()
+		item.UnlayeredValue = unlayeredValue.InexactFloat64()
+		item.TotalValue = layeredValue.Add(unlayeredValue).InexactFloat64()
+		totalValue = totalValue.Add(layeredValue).Add(unlayeredValue)
+		report.TotalQuantity += item.LayeredQty + item.UnlayeredQty
+		report.Items = append(report.Items, item)
+	}
+	report.TotalValue = totalValue.InexactFloat64()
+
+	inventoryAccount, err := s.taxAccountHelper.GetInventoryAccount(s.db)
+	if err != nil {
+		return nil, fmt.Errorf("inventory account not found: %v", err)
+	}
+	var glBalance float64
+	if err := s.db.Raw(`
+		SELECT COALESCE(SUM(ujl.debit_amount - ujl.credit_amount), 0)
+		FROM unified_journal_lines ujl
+		JOIN unified_journal_ledger uje ON uje.id = ujl.journal_id
+		WHERE ujl.account_id = ?
+		  AND uje.status = 'POSTED'
+		  AND uje.deleted_at IS NULL
+	`, inventoryAccount.ID).Scan(&glBalance).Error; err != nil {
+		return nil, fmt.Errorf("failed to compute inventory account balance: %v", err)
+	}
+
+	report.InventoryAccountID = inventoryAccount.ID
+	report.InventoryAccountCode = inventoryAccount.Code
+	report.InventoryAccountName = inventoryAccount.Name
+	report.GLBalance = glBalance
+	difference := totalValue.Sub(decimal.NewFromFloat(glBalance)).Round(2)
+	report.Difference = difference.InexactFloat64()
+	report.IsReconciled = difference.IsZero()
+	return report, nil
+}
+
+// ========== HELPERS ==========
+
+func (s *InventoryCostingService) layeredQuantity(tx *gorm.DB, productID uint) (int, error) {
+	var qty int
+	err := tx.Model(&models.Inventory{}).
+		Where("product_id = ? AND type = ? AND reference_type <> '' AND remaining_qty > 0", productID, models.InventoryTypeIn).
+		Select("COALESCE(SUM(remaining_qty), 0)").Scan(&qty).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to sum receipt layers: %v", err)
+	}
+	return qty, nil
+}
+
+// saveCostLayers writes the cost portions of one OUT movement and stores their cost on it
+func (s *InventoryCostingService) saveCostLayers(tx *gorm.DB, saleID, saleItemID uint, out *models.Inventory, method string, portions []costPortion) (decimal.Decimal, error) {
+	total := decimal.Zero
+	for _, p := range portions {
+		layer := models.SaleCostLayer{
+			SaleID:         saleID,
+			SaleItemID:     saleItemID,
+			ProductID:      out.ProductID,
+			InventoryOutID: out.ID,
+			ReceiptLayerID: p.layerID,
+			CostingMethod:  method,
+			Quantity:       p.quantity,
+			UnitCost:       p.cost.Div(decimal.NewFromInt(int64(p.quantity))).Round(2).InexactFloat64(),
+			TotalCost:      p.cost.InexactFloat64(),
+			CostingDate:    out.TransactionDate,
+		}
+		if err := tx.Create(&layer).Error; err != nil {
+			return decimal.Zero, fmt.Errorf("failed to create sale cost layer: %v", err)
+		}
+		total = total.Add(p.cost)
+	}
+
+	unitCost := decimal.Zero
+	if out.Quantity > 0 {
+		unitCost = total.Div(decimal.NewFromInt(int64(out.Quantity))).Round(2)
+	}
+	if err := tx.Model(&models.Inventory{}).Where("id = ?", out.ID).Updates(map[string]interface{}{
+		"unit_cost":  unitCost.InexactFloat64(),
+		"total_cost": total.InexactFloat64(),
+	}).Error; err != nil {
+		return decimal.Zero, fmt.Errorf("failed to update inventory movement cost: %v", err)
+	}
+	return total, nil
+}
+
+// allocateCost takes quantity from the layers in the given order, decrementing their RemainingQty.
+// FIFO/LIFO value each portion at the layer cost; Average values everything at averageCost.
+// Quantity not covered by any layer is valued at averageCost without a layer reference.
+func allocateCost(layers []*models.Inventory, quantity int, method string, averageCost decimal.Decimal) []costPortion {
+	var portions []costPortion
+	remaining := quantity
+	for _, layer := range layers {
+		if remaining <= 0 {
+			break
+		}
+		if layer.RemainingQty <= 0 {
+			continue
+		}
+		take := layer.RemainingQty
+		if take > remaining {
+			take = remaining
+		}
+
+		var cost decimal.Decimal
+		if method == models.ValuationAverage {
+			cost = averageCost.Mul(decimal.NewFromInt(int64(take))).Round(2)
+		} else {
+			// Value by the drop in layer value so a fully consumed layer releases exactly its total cost
+			cost = layerValue(layer, layer.RemainingQty).Sub(layerValue(layer, layer.RemainingQty-take))
+		}
+
+		layerID := layer.ID
+		portions = append(portions, costPortion{layerID: &layerID, quantity: take, cost: cost})
+		layer.RemainingQty -= take
+		remaining -= take
+	}
+
+	if remaining > 0 {
+		portions = append(portions, costPortion{
+			quantity: remaining,
+			cost:     averageCost.Mul(decimal.NewFromInt(int64(remaining))).Round(2),
+		})
+	}
+	return portions
+}
+
+// layerValue is the value of qty units still on a receipt layer
+func layerValue(layer *models.Inventory, qty int) decimal.Decimal {
+	if layer.Quantity <= 0 || qty <= 0 {
+		return decimal.Zero
+	}
+	return decimal.NewFromFloat(layer.TotalCost).
+		Mul(decimal.NewFromInt(int64(qty))).
+		Div(decimal.NewFromInt(int64(layer.Quantity))).
+		Round(2)
+}
+
+// movingAverage returns the average unit cost after receiving quantity units costing totalCost
+func movingAverage(onHandQty, averageCost decimal.Decimal, quantity int, totalCost decimal.Decimal) decimal.Decimal {
+	newQty := onHandQty.Add(decimal.NewFromInt(int64(quantity)))
+	if onHandQty.LessThanOrEqual(decimal.Zero) || newQty.IsZero() {
+		if quantity <= 0 {
+			return averageCost
+		}
+		return totalCost.Div(decimal.NewFromInt(int64(quantity)))
+	}
+	return onHandQty.Mul(averageCost).Add(totalCost).Div(newQty)
+}
+
+func productCostingMethod(product models.Product) string {
+	switch strings.ToUpper(strings.TrimSpace(product.CostingMethod)) {
+	case strings.ToUpper(models.ValuationAverage):
+		return models.ValuationAverage
+	case models.ValuationLIFO:
+		return models.ValuationLIFO
+	default:
+		return models.ValuationFIFO
+	}
+}
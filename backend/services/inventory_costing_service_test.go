@@ -0,0 +1,130 @@
+package services
+
+import (
+	"testing"
+
+	"app-sistem-akuntansi/models"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+// wantPortion is an expected cost portion; layerID 0 means valued without a layer
+type wantPortion struct {
+	layerID  uint
+	quantity int
+	cost     string
+}
+
+func TestAllocateCost(t *testing.T) {
+	// Two receipts: 10 units at 100 and 10 units at 150
+	newLayers := func() (*models.Inventory, *models.Inventory) {
+		return &models.Inventory{ID: 1, Quantity: 10, TotalCost: 1000, RemainingQty: 10},
+			&models.Inventory{ID: 2, Quantity: 10, TotalCost: 1500, RemainingQty: 10}
+	}
+
+	tests := []struct {
+		name          string
+		order         func(older, newer *models.Inventory) []*models.Inventory
+		quantity      int
+		method        string
+		averageCost   string
+		want          []wantPortion
+		wantRemaining [2]int
+	}{
+		{
+			name:          "FIFO consumes the oldest layer first",
+			order:         func(older, newer *models.Inventory) []*models.Inventory { return []*models.Inventory{older, newer} },
+			quantity:      15,
+			method:        models.ValuationFIFO,
+			averageCost:   "125",
+			want:          []wantPortion{{1, 10, "1000"}, {2, 5, "750"}},
+			wantRemaining: [2]int{0, 5},
+		},
+		{
+			name:          "LIFO consumes the newest layer first",
+			order:         func(older, newer *models.Inventory) []*models.Inventory { return []*models.Inventory{newer, older} },
+			quantity:      15,
+			method:        models.ValuationLIFO,
+			averageCost:   "125",
+			want:          []wantPortion{{2, 10, "1500"}, {1, 5, "500"}},
+			wantRemaining: [2]int{5, 0},
+		},
+		{
+			name:          "Average values every portion at the average cost",
+			order:         func(older, newer *models.Inventory) []*models.Inventory { return []*models.Inventory{older, newer} },
+			quantity:      15,
+			method:        models.ValuationAverage,
+			averageCost:   "125",
+			want:          []wantPortion{{1, 10, "1250"}, {2, 5, "625"}},
+			wantRemaining: [2]int{0, 5},
+		},
+		{
+			name:          "quantity beyond the layers is valued at average cost without a layer",
+			order:         func(older, newer *models.Inventory) []*models.Inventory { return []*models.Inventory{older, newer} },
+			quantity:      25,
+			method:        models.ValuationFIFO,
+			averageCost:   "125",
+			want:          []wantPortion{{1, 10, "1000"}, {2, 10, "1500"}, {0, 5, "625"}},
+			wantRemaining: [2]int{0, 0},
+		},
+		{
+			name: "consumed layers are skipped",
+			order: func(older, newer *models.Inventory) []*models.Inventory {
+				older.RemainingQty = 0
+				return []*models.Inventory{older, newer}
+			},
+			quantity:      4,
+			method:        models.ValuationFIFO,
+			averageCost:   "125",
+			want:          []wantPortion{{2, 4, "600"}},
+			wantRemaining: [2]int{0, 6},
+		},
+		{
+			name:          "zero quantity allocates nothing",
+			order:         func(older, newer *models.Inventory) []*models.Inventory { return []*models.Inventory{older, newer} },
+			quantity:      0,
+			method:        models.ValuationFIFO,
+			averageCost:   "125",
+			want:          nil,
+			wantRemaining: [2]int{10, 10},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			older, newer := newLayers()
+			portions := allocateCost(tt.order(older, newer), tt.quantity, tt.method, decimal.RequireFromString(tt.averageCost))
+
+			if assert.Len(t, portions, len(tt.want)) {
+				for i, want := range tt.want {
+					if want.layerID == 0 {
+						assert.Nil(t, portions[i].layerID)
+					} else if assert.NotNil(t, portions[i].layerID) {
+						assert.Equal(t, want.layerID, *portions[i].layerID)
+					}
+					assert.Equal(t, want.quantity, portions[i].quantity)
+					assert.True(t, decimal.RequireFromString(want.cost).Equal(portions[i].cost),
+						"portion %d cost: want %s, got %s", i, want.cost, portions[i].cost)
+				}
+			}
+			assert.Equal(t, tt.wantRemaining[0], older.RemainingQty)
+			assert.Equal(t, tt.wantRemaining[1], newer.RemainingQty)
+		})
+	}
+}
+
+func TestAllocateCostReleasesExactLayerTotal(t *testing.T) {
+	// 3 units costing 100 do not divide evenly; consuming them in parts must still release exactly 100
+	layer := &models.Inventory{ID: 1, Quantity: 3, TotalCost: 100, RemainingQty: 3}
+
+	total := decimal.Zero
+	for _, quantity := range []int{1, 1, 1} {
+		for _, portion := range allocateCost([]*models.Inventory{layer}, quantity, models.ValuationFIFO, decimal.Zero) {
+			total = total.Add(portion.cost)
+		}
+	}
+
+	assert.True(t, decimal.NewFromInt(100).Equal(total), "released %s", total)
+	assert.Equal(t, 0, layer.RemainingQty)
+}
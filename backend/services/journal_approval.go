@@ -0,0 +1,59 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"app-sistem-akuntansi/models"
+)
+
+// ========== MANUAL JOURNAL APPROVAL ==========
+
+// ManualJournalRequiresApproval - Cek Settings.RequireJournalApproval untuk jurnal manual
+func (s *UnifiedJournalService) ManualJournalRequiresApproval(req *JournalEntryRequest) bool {
+	if req.SourceType != models.SSOTSourceTypeManual {
+		return false
+	}
+	settings, err := NewSettingsService(s.db).GetSettings()
+	return err == nil && settings != nil && settings.RequireJournalApproval
+}
+
+// CreateJournalEntryForApproval - Simpan jurnal manual sebagai DRAFT dan ajukan ke workflow JOURNAL.
+// Jurnal diposting oleh ApprovalService setelah disetujui, atau dibatalkan bila ditolak.
+func (s *UnifiedJournalService) CreateJournalEntryForApproval(req *JournalEntryRequest, approvalService *ApprovalService) (*models.SSOTJournalEntry, *models.ApprovalRequest, error) {
+	approvalRequest := models.CreateApprovalRequestDTO{
+		EntityType:     models.EntityTypeJournal,
+		RequestMessage: req.Description,
+	}
+	var total float64
+	for _, line := range req.Lines {
+		total += line.DebitAmount.InexactFloat64()
+		approvalRequest.AccountIDs = appendApprovalID(approvalRequest.AccountIDs, uint(line.AccountID))
+		if line.Dimensions.CostCenterID != nil {
+			approvalRequest.CostCenterIDs = appendApprovalID(approvalRequest.CostCenterIDs, *line.Dimensions.CostCenterID)
+		}
+	}
+	approvalRequest.Amount = total
+
+	if !approvalService.RequiresApproval(approvalRequest) {
+		return nil, nil, errors.New("journal approval is required but no active JOURNAL approval workflow matches this entry")
+	}
+
+	req.AutoPost = false
+	entry, err := s.CreateJournalEntry(req)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	approvalRequest.EntityID = uint(entry.ID)
+	approvalRequest.RequestTitle = fmt.Sprintf("Manual journal %s", entry.EntryNumber)
+	created, err := approvalService.CreateApprovalRequest(approvalRequest, uint(req.CreatedBy))
+	if err != nil {
+		s.db.Select("Lines").Delete(entry)
+		return nil, nil, fmt.Errorf("failed to create approval request: %v", err)
+	}
+
+	log.Printf("⏸️ Manual journal %s waiting for approval (request %s)", entry.EntryNumber, created.RequestCode)
+	return entry, created, nil
+}
@@ -0,0 +1,184 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+
+	"app-sistem-akuntansi/models"
+	"app-sistem-akuntansi/repositories"
+
+	"gorm.io/gorm"
+)
+
+// ========== PAYMENT APPROVAL ==========
+//
+// Vendor payments that match a PAYMENT approval workflow are saved as PENDING_APPROVAL without
+// touching cash/bank, bills or journals. The original request is kept on the payment and posted on
+// the same record by the service that received it once the workflow approves it.
+
+// Payment flows that can hold a payment; stored with the held request so that the right service
+// posts it after approval
+const (
+	heldPaymentFlowPayable = "PAYABLE" // PaymentService.CreatePayablePayment
+	heldPaymentFlowSSOT    = "SSOT"    // EnhancedPaymentServiceWithJournal.CreatePaymentWithJournal
+)
+
+// heldPayment is stored in Payment.HeldRequest while the payment waits for approval
+type heldPayment struct {
+	Flow    string          `json:"flow"`
+	Request json.RawMessage `json:"request"`
+}
+
+// vendorPaymentApprovalRequest - Konteks routing persetujuan untuk pembayaran vendor
+func vendorPaymentApprovalRequest(cashBankRepo *repositories.CashBankRepository, contactID, cashBankID uint, amount float64, notes string) models.CreateApprovalRequestDTO {
+	approvalRequest := models.CreateApprovalRequestDTO{
+		EntityType:     models.EntityTypePayment,
+		Amount:         amount,
+		RequestMessage: notes,
+		ContactID:      &contactID,
+	}
+	if cashBankID > 0 {
+		if cashBank, err := cashBankRepo.FindByID(cashBankID); err == nil && cashBank.AccountID != 0 {
+			approvalRequest.AccountIDs = []uint{cashBank.AccountID}
+		}
+	}
+	return approvalRequest
+}
+
+// holdPayment - Menyimpan pembayaran berstatus PENDING_APPROVAL beserta request aslinya, lalu
+// membuat approval request
+func holdPayment(db *gorm.DB, approvalService *ApprovalService, payment *models.Payment, flow string, request interface{}, approvalRequest models.CreateApprovalRequestDTO) (*models.Payment, error) {
+	var contact models.Contact
+	if err := db.Select("id, name").First(&contact, payment.ContactID).Error; err != nil {
+		return nil, errors.New("vendor not found")
+	}
+
+	rawRequest, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+	held, err := json.Marshal(heldPayment{Flow: flow, Request: rawRequest})
+	if err != nil {
+		return nil, err
+	}
+
+	payment.Status = models.PaymentStatusPendingApproval
+	payment.HeldRequest = string(held)
+	if err := db.Create(payment).Error; err != nil {
+		return nil, err
+	}
+
+	approvalRequest.EntityID = payment.ID
+	approvalRequest.RequestTitle = fmt.Sprintf("Vendor payment %s - %s", payment.Code, contact.Name)
+	created, err := approvalService.CreateApprovalRequest(approvalRequest, payment.UserID)
+	if err != nil {
+		db.Delete(payment)
+		return nil, fmt.Errorf("failed to create approval request: %v", err)
+	}
+
+	if err := db.Model(payment).Update("approval_request_id", created.ID).Error; err != nil {
+		return nil, err
+	}
+	payment.ApprovalRequestID = &created.ID
+	log.Printf("⏸️ Payment %s held for approval (request %s)", payment.Code, created.RequestCode)
+	return payment, nil
+}
+
+// loadHeldPayment - Memuat pembayaran yang ditahan oleh flow; ok=false bila pembayaran milik flow lain
+func loadHeldPayment(db *gorm.DB, paymentID uint, flow string, request interface{}) (*models.Payment, bool, error) {
+	var payment models.Payment
+	if err := db.First(&payment, paymentID).Error; err != nil {
+		return nil, false, errors.New("payment not found")
+	}
+
+	var held heldPayment
+	if err := json.Unmarshal([]byte(payment.HeldRequest), &held); err != nil || held.Flow != flow {
+		return nil, false, nil
+	}
+	if payment.Status != models.PaymentStatusPendingApproval {
+		return nil, false, fmt.Errorf("payment %s is not waiting for approval (status %s)", payment.Code, payment.Status)
+	}
+	if err := json.Unmarshal(held.Request, request); err != nil {
+		return nil, false, fmt.Errorf("payment %s has no valid held request: %v", payment.Code, err)
+	}
+	return &payment, true, nil
+}
+
+// failHeldPayment - Menandai pembayaran yang gagal diposting setelah disetujui
+func failHeldPayment(db *gorm.DB, payment *models.Payment, cause error) {
+	db.Model(&models.Payment{}).Where("id = ?", payment.ID).Updates(map[string]interface{}{
+		"status": models.PaymentStatusFailed,
+		"notes":  fmt.Sprintf("%s\nPosting after approval failed: %v", payment.Notes, cause),
+	})
+}
+
+// SetApprovalService - Mengaktifkan persetujuan pembayaran vendor lewat workflow PAYMENT
+func (s *PaymentService) SetApprovalService(approvalService *ApprovalService) {
+	s.approvalService = approvalService
+	approvalService.RegisterApprovalCallback(models.EntityTypePayment, s.ReleaseHeldPayment)
+}
+
+// holdPayablePayment - Menahan pembayaran vendor sampai disetujui
+func (s *PaymentService) holdPayablePayment(request PaymentCreateRequest, approvalRequest models.CreateApprovalRequestDTO, userID uint) (*models.Payment, error) {
+	payment := &models.Payment{
+		Code:      s.generatePaymentCode(s.payablePaymentPrefix()),
+		ContactID: request.ContactID,
+		UserID:    userID,
+		Date:      request.Date,
+		Amount:    request.Amount,
+		Method:    request.Method,
+		Reference: request.Reference,
+		Notes:     request.Notes,
+	}
+	return holdPayment(s.db, s.approvalService, payment, heldPaymentFlowPayable, request, approvalRequest)
+}
+
+// ReleaseHeldPayment - Memposting pembayaran vendor yang ditahan setelah disetujui; bila posting
+// gagal (mis. saldo tidak cukup) pembayaran ditandai FAILED
+func (s *PaymentService) ReleaseHeldPayment(paymentID uint) error {
+	var request PaymentCreateRequest
+	payment, ok, err := loadHeldPayment(s.db, paymentID, heldPaymentFlowPayable, &request)
+	if err != nil || !ok {
+		return err
+	}
+
+	if _, err := s.postPayablePayment(request, payment.UserID, payment); err != nil {
+		failHeldPayment(s.db, payment, err)
+		return err
+	}
+	log.Printf("▶️ Held payment %s posted after approval", payment.Code)
+	return nil
+}
+
+// payablePaymentPrefix - Prefix kode pembayaran vendor dari settings
+func (s *PaymentService) payablePaymentPrefix() string {
+	settings, _ := NewSettingsService(s.db).GetSettings()
+	if settings != nil && settings.PaymentPayablePrefix != "" {
+		return settings.PaymentPayablePrefix
+	}
+	return "PAY"
+}
+
+// SetApprovalService - Mengaktifkan persetujuan pembayaran vendor lewat workflow PAYMENT
+func (eps *EnhancedPaymentServiceWithJournal) SetApprovalService(approvalService *ApprovalService) {
+	eps.approvalService = approvalService
+	approvalService.RegisterApprovalCallback(models.EntityTypePayment, eps.ReleaseHeldPayment)
+}
+
+// ReleaseHeldPayment - Memposting pembayaran SSOT yang ditahan setelah disetujui
+func (eps *EnhancedPaymentServiceWithJournal) ReleaseHeldPayment(paymentID uint) error {
+	var request PaymentWithJournalRequest
+	payment, ok, err := loadHeldPayment(eps.db, paymentID, heldPaymentFlowSSOT, &request)
+	if err != nil || !ok {
+		return err
+	}
+
+	if _, err := eps.postPaymentWithJournal(&request, payment); err != nil {
+		failHeldPayment(eps.db, payment, err)
+		return err
+	}
+	log.Printf("▶️ Held payment %s posted after approval", payment.Code)
+	return nil
+}
@@ -27,6 +27,8 @@ type PaymentService struct {
 	contactRepo                   repositories.ContactRepository
 	statusValidator               *StatusValidationHelper // NEW: Konsistensi dengan SalesJournalServiceV2
 	purchasePaymentJournalService *PurchasePaymentJournalService // NEW: SSOT payment journal integration
+	currencyService               *CurrencyService               // Realized FX gain/loss for foreign currency invoices/bills
+	approvalService               *ApprovalService               // Holds vendor payments that match a PAYMENT approval workflow
 }
 
 func NewPaymentService(
@@ -49,6 +51,7 @@ func NewPaymentService(
 		contactRepo:     contactRepo,
 		statusValidator: NewStatusValidationHelper(), // Initialize status validator
 		purchasePaymentJournalService: purchasePaymentJournalService, // NEW: SSOT payment journal integration
+		currencyService: NewCurrencyService(db),
 	}
 }
 
@@ -204,17 +207,30 @@ func (s *PaymentService) CreateReceivablePaymentFixed(request PaymentCreateReque
 		}
 		log.Printf("✅ Invoice #%d status '%s' is valid for payment allocation", allocation.InvoiceID, sale.Status)
 		
+		// Foreign currency invoice: payment is in base currency, outstanding is tracked in invoice currency
+		settlement, err := s.currencyService.NewDocumentSettlement(tx, models.FXItemTypeReceivable, sale.InvoiceNumber,
+			sale.Currency, sale.ExchangeRate, sale.OutstandingAmount, payment.Date, request.ExchangeRate)
+		if err != nil {
+			return nil, fmt.Errorf("invoice %v", err)
+		}
+		if settlement.IsForeign() {
+			payment.ExchangeRate = settlement.Rate.InexactFloat64()
+		}
+		outstandingBase := settlement.OutstandingBase
+		
 		// Calculate allocated amount
 		allocatedAmount := allocation.Amount
 		if allocatedAmount > remainingAmount {
 			allocatedAmount = remainingAmount
 			log.Printf("⚠️ Adjusting amount to remaining: %.2f -> %.2f", allocation.Amount, allocatedAmount)
 		}
-		if allocatedAmount > sale.OutstandingAmount {
-			allocatedAmount = sale.OutstandingAmount
-			log.Printf("⚠️ Adjusting amount to outstanding: %.2f -> %.2f", allocatedAmount, sale.OutstandingAmount)
+		if allocatedAmount > outstandingBase {
+			allocatedAmount = outstandingBase
+			log.Printf("⚠️ Adjusting amount to outstanding: %.2f -> %.2f", allocatedAmount, outstandingBase)
 		}
 		
+		settledAmount, fxSettlement := settlement.Settle(allocatedAmount)
+		
 		// Create payment allocation
 		paymentAllocation := &models.PaymentAllocation{
 			PaymentID:       uint64(payment.ID),
@@ -230,11 +246,11 @@ func (s *PaymentService) CreateReceivablePaymentFixed(request PaymentCreateReque
 		
 		// Update sale amounts
 		log.Printf("📝 Updating sale amounts: PaidAmount %.2f -> %.2f, Outstanding %.2f -> %.2f", 
-			sale.PaidAmount, sale.PaidAmount + allocatedAmount,
-			sale.OutstandingAmount, sale.OutstandingAmount - allocatedAmount)
+			sale.PaidAmount, sale.PaidAmount + settledAmount,
+			sale.OutstandingAmount, sale.OutstandingAmount - settledAmount)
 			
-		sale.PaidAmount += allocatedAmount
-		sale.OutstandingAmount -= allocatedAmount
+		sale.PaidAmount += settledAmount
+		sale.OutstandingAmount = settledOutstanding(sale.OutstandingAmount, settledAmount, fxSettlement)
 		
 		// Update status
 		if sale.OutstandingAmount <= 0 {
@@ -273,6 +289,12 @@ func (s *PaymentService) CreateReceivablePaymentFixed(request PaymentCreateReque
 			log.Printf("✅ Sale payment cross-reference created: payment_id=%d, sale_id=%d, amount=%.2f", payment.ID, sale.ID, allocatedAmount)
 		}
 		
+		// Realized FX gain/loss against the rate the invoice was booked at
+		if _, err := s.currencyService.PostRealizedFXGainLoss(tx, fxSettlement, payment.ID, payment.Code, payment.Date, userID); err != nil {
+			log.Printf("❌ Failed to post realized FX gain/loss for sale %d: %v", sale.ID, err)
+			return nil, err
+		}
+		
 		remainingAmount -= allocatedAmount
 		totalAllocatedAmount += allocatedAmount  // Accumulate allocated amount
 		log.Printf("✅ Allocation %d complete. Remaining: %.2f, Total Allocated: %.2f", i+1, remainingAmount, totalAllocatedAmount)
@@ -341,6 +363,17 @@ func (s *PaymentService) CreateReceivablePaymentFixed(request PaymentCreateReque
 	}
 	log.Printf("✅ Payment status updated (%.2fms)", float64(time.Since(stepStart).Nanoseconds())/1000000)
 	
+	// Webhook outbox: payment.received is delivered only if the payment commits
+	webhookAllocations := make([]map[string]interface{}, 0, len(salePayments))
+	for _, sp := range salePayments {
+		webhookAllocations = append(webhookAllocations, map[string]interface{}{"sale_id": sp.SaleID, "amount": sp.Amount})
+	}
+	webhookData := paymentReceivedWebhookData(payment.ID, payment.Code, payment.ContactID, payment.Date, payment.Amount,
+		payment.Method, payment.Reference, webhookAllocations)
+	if err := recordWebhookEvent(tx, models.WebhookEventPaymentReceived, "payment", payment.ID, webhookData); err != nil {
+		return nil, err
+	}
+	
 	// Step 8: Commit transaction
 	log.Printf("📋 Step 8: Committing transaction...")
 	stepStart = time.Now()
@@ -370,6 +403,18 @@ func (s *PaymentService) GetSaleByID(saleID uint) (*models.Sale, error) {
 
 // CreatePayablePayment creates payment for purchases/payables
 func (s *PaymentService) CreatePayablePayment(request PaymentCreateRequest, userID uint) (*models.Payment, error) {
+	if s.approvalService != nil {
+		approvalRequest := vendorPaymentApprovalRequest(s.cashBankRepo, request.ContactID, request.CashBankID, request.Amount, request.Notes)
+		if s.approvalService.RequiresApproval(approvalRequest) {
+			return s.holdPayablePayment(request, approvalRequest, userID)
+		}
+	}
+	return s.postPayablePayment(request, userID, nil)
+}
+
+// postPayablePayment records and posts a vendor payment. held is the payment record kept while
+// the payment waited for approval; nil creates a new one.
+func (s *PaymentService) postPayablePayment(request PaymentCreateRequest, userID uint, held *models.Payment) (*models.Payment, error) {
 	start := time.Now()
 	log.Printf("Starting CreatePayablePayment: ContactID=%d, Amount=%.2f", request.ContactID, request.Amount)
 	
@@ -410,33 +455,38 @@ func (s *PaymentService) CreatePayablePayment(request PaymentCreateRequest, user
 		log.Printf("Balance check passed: %.2f available (%.2fms)", cashBank.Balance, float64(time.Since(balanceCheckStart).Nanoseconds())/1000000)
 	}
 	
-	// Generate payment code (prefix from settings)
-	codeGenStart := time.Now()
-	settingsSvc := NewSettingsService(s.db)
-	settings, _ := settingsSvc.GetSettings()
-	prefix := "PAY"
-	if settings != nil && settings.PaymentPayablePrefix != "" {
-		prefix = settings.PaymentPayablePrefix
-	}
-	code := s.generatePaymentCode(prefix)
-	log.Printf("Payment code generated: %s (%.2fms)", code, float64(time.Since(codeGenStart).Nanoseconds())/1000000)
-	
-	// Create payment record
-	payment := &models.Payment{
-		Code:      code,
-		ContactID: request.ContactID,
-		UserID:    userID,
-		Date:      request.Date,
-		Amount:    request.Amount,
-		Method:    request.Method,
-		Reference: request.Reference,
-		Status:    models.PaymentStatusPending,
-		Notes:     request.Notes,
-	}
-	
-	if err := tx.Create(payment).Error; err != nil {
-		tx.Rollback()
-		return nil, err
+	payment := held
+	if payment != nil {
+		// Approved payment: post on the record that was held
+		payment.Status = models.PaymentStatusPending
+		payment.HeldRequest = ""
+		if err := tx.Save(payment).Error; err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	} else {
+		// Generate payment code (prefix from settings)
+		codeGenStart := time.Now()
+		code := s.generatePaymentCode(s.payablePaymentPrefix())
+		log.Printf("Payment code generated: %s (%.2fms)", code, float64(time.Since(codeGenStart).Nanoseconds())/1000000)
+		
+		// Create payment record
+		payment = &models.Payment{
+			Code:      code,
+			ContactID: request.ContactID,
+			UserID:    userID,
+			Date:      request.Date,
+			Amount:    request.Amount,
+			Method:    request.Method,
+			Reference: request.Reference,
+			Status:    models.PaymentStatusPending,
+			Notes:     request.Notes,
+		}
+		
+		if err := tx.Create(payment).Error; err != nil {
+			tx.Rollback()
+			return nil, err
+		}
 	}
 	
 	// Process allocations to bills
@@ -457,17 +507,31 @@ func (s *PaymentService) CreatePayablePayment(request PaymentCreateRequest, user
 			return nil, errors.New("bill does not belong to this vendor")
 		}
 		
+		// Foreign currency bill: payment is in base currency, outstanding is tracked in bill currency
+		settlement, err := s.currencyService.NewDocumentSettlement(tx, models.FXItemTypePayable, purchase.Code,
+			purchase.Currency, purchase.ExchangeRate, purchase.OutstandingAmount, payment.Date, request.ExchangeRate)
+		if err != nil {
+			tx.Rollback()
+			return nil, fmt.Errorf("bill %v", err)
+		}
+		if settlement.IsForeign() {
+			payment.ExchangeRate = settlement.Rate.InexactFloat64()
+		}
+		outstandingBase := settlement.OutstandingBase
+		
 		allocatedAmount := allocation.Amount
 		if allocatedAmount > remainingAmount {
 			allocatedAmount = remainingAmount
 		}
 		
 		// Calculate outstanding using OutstandingAmount field (proper tracking)
-		if allocatedAmount > purchase.OutstandingAmount {
-			allocatedAmount = purchase.OutstandingAmount
+		if allocatedAmount > outstandingBase {
+			allocatedAmount = outstandingBase
 			log.Printf("⚠️ Adjusting allocated amount to outstanding: %.2f -> %.2f", allocation.Amount, allocatedAmount)
 		}
 		
+		settledAmount, fxSettlement := settlement.Settle(allocatedAmount)
+		
 		// Create payment allocation
 		paymentAllocation := &models.PaymentAllocation{
 			PaymentID:       uint64(payment.ID),
@@ -482,11 +546,11 @@ func (s *PaymentService) CreatePayablePayment(request PaymentCreateRequest, user
 		
 		// 🔥 FIX: Update purchase paid amount and outstanding amount (same as receivable payment logic)
 		log.Printf("📝 Updating purchase amounts: PaidAmount %.2f -> %.2f, Outstanding %.2f -> %.2f", 
-			purchase.PaidAmount, purchase.PaidAmount + allocatedAmount,
-			purchase.OutstandingAmount, purchase.OutstandingAmount - allocatedAmount)
+			purchase.PaidAmount, purchase.PaidAmount + settledAmount,
+			purchase.OutstandingAmount, purchase.OutstandingAmount - settledAmount)
 			
-		purchase.PaidAmount += allocatedAmount
-		purchase.OutstandingAmount -= allocatedAmount
+		purchase.PaidAmount += settledAmount
+		purchase.OutstandingAmount = settledOutstanding(purchase.OutstandingAmount, settledAmount, fxSettlement)
 		
 		// Update status if fully paid
 		if purchase.OutstandingAmount <= 0 {
@@ -505,6 +569,13 @@ func (s *PaymentService) CreatePayablePayment(request PaymentCreateRequest, user
 		}
 		log.Printf("✅ Purchase updated successfully")
 		
+		// Realized FX gain/loss against the rate the bill was booked at
+		if _, err := s.currencyService.PostRealizedFXGainLoss(tx, fxSettlement, payment.ID, payment.Code, payment.Date, userID); err != nil {
+			log.Printf("❌ Failed to post realized FX gain/loss for purchase %d: %v", purchase.ID, err)
+			tx.Rollback()
+			return nil, err
+		}
+		
 		remainingAmount -= allocatedAmount
 	}
 	
@@ -1188,6 +1259,7 @@ type PaymentCreateRequest struct {
 	Notes           string                   `json:"notes"`
 	Allocations     []InvoiceAllocation      `json:"allocations"`
 	BillAllocations []BillAllocation         `json:"bill_allocations"`
+	ExchangeRate    *float64                 `json:"exchange_rate"` // Settlement rate for foreign currency invoices/bills; nil = rate table
 }
 
 type InvoiceAllocation struct {
@@ -562,6 +562,47 @@ func (p *PDFService) addCompanyLetterhead(pdf *gofpdf.Fpdf) {
 	}
 }
 
+// addReportHeader renders the standard letterhead, company block on the right, divider and title.
+// It returns the content width so callers can lay out tables below the header.
+func (p *PDFService) addReportHeader(pdf *gofpdf.Fpdf, title string, subtitleLines ...string) float64 {
+	p.addCompanyLetterhead(pdf)
+	lm, tm, rm, _ := pdf.GetMargins()
+	pageW, _ := pdf.GetPageSize()
+	contentW := pageW - lm - rm
+
+	companyInfo, _ := p.getCompanyInfo()
+	pdf.SetFont("Arial", "B", 12)
+	nameW := pdf.GetStringWidth(companyInfo.CompanyName)
+	pdf.SetXY(pageW-rm-nameW, tm)
+	pdf.Cell(nameW, 6, companyInfo.CompanyName)
+	pdf.SetFont("Arial", "", 9)
+	pdf.SetXY(pageW-rm-pdf.GetStringWidth(companyInfo.CompanyAddress), tm+8)
+	pdf.Cell(0, 4, companyInfo.CompanyAddress)
+	phone := fmt.Sprintf("Phone: %s", companyInfo.CompanyPhone)
+	pdf.SetXY(pageW-rm-pdf.GetStringWidth(phone), tm+14)
+	pdf.Cell(0, 4, phone)
+
+	pdf.SetDrawColor(238, 238, 238)
+	pdf.SetLineWidth(0.2)
+	pdf.Line(lm, tm+45, pageW-rm, tm+45)
+
+	pdf.SetXY(lm, tm+50)
+	pdf.SetFont("Arial", "B", 16)
+	pdf.SetTextColor(51, 51, 51)
+	pdf.Cell(contentW, 8, strings.ToUpper(title))
+	pdf.SetTextColor(0, 0, 0)
+	pdf.Ln(9)
+
+	pdf.SetFont("Arial", "", 10)
+	for _, line := range subtitleLines {
+		pdf.SetX(lm)
+		pdf.Cell(contentW, 5, line)
+		pdf.Ln(5)
+	}
+	pdf.Ln(3)
+	return contentW
+}
+
 // detectImageType inspects the file header to determine image type supported by gofpdf (JPG/PNG)
 func detectImageType(path string) string {
 	f, err := os.Open(path)
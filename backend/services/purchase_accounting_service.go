@@ -89,14 +89,23 @@ func (s *PurchaseService) calculatePurchaseTotals(purchase *models.Purchase, ite
 		}
 		fmt.Printf("✅ Product found: %s (ID: %d)\n", product.Name, product.ID)
 		
+		unitPrice, err := s.resolvePurchaseUnitPrice(purchase, *product, itemReq)
+		if err != nil {
+			return err
+		}
+		
 		// Create purchase item
 		item := models.PurchaseItem{
 			ProductID:        itemReq.ProductID,
 			Quantity:         itemReq.Quantity,
-			UnitPrice:        sanitizeFloat(clampNonNegative(itemReq.UnitPrice)),
+			UnitPrice:        sanitizeFloat(clampNonNegative(unitPrice)),
 			Discount:         sanitizeFloat(clampNonNegative(itemReq.Discount)),
 			Tax:              sanitizeFloat(clampNonNegative(itemReq.Tax)),
 			ExpenseAccountID: itemReq.ExpenseAccountID,
+			WarehouseLocationID: itemReq.WarehouseLocationID,
+			CostCenterID:     itemReq.CostCenterID,
+			ProjectID:        itemReq.ProjectID,
+			TagIDs:           itemReq.TagIDs,
 		}
 		
 		// Calculate line totals with guards
@@ -299,6 +308,23 @@ func (s *PurchaseService) recalculatePurchaseTotals(purchase *models.Purchase) e
 	return nil
 }
 
+// resolvePurchaseUnitPrice returns the requested unit price, or when it is left at 0 the best
+// valid price list entry of the purchase vendor for the product and quantity
+func (s *PurchaseService) resolvePurchaseUnitPrice(purchase *models.Purchase, product models.Product, itemReq models.PurchaseItemRequest) (float64, error) {
+	if itemReq.UnitPrice > 0 {
+		return itemReq.UnitPrice, nil
+	}
+	best, err := findBestVendorPrice(s.db, purchase.VendorID, product, itemReq.Quantity, purchase.Date)
+	if err != nil {
+		return 0, fmt.Errorf("failed to look up vendor price for product %s: %v", product.Name, err)
+	}
+	if best == nil {
+		return 0, fmt.Errorf("unit price is required for product %s: the vendor has no valid price list entry for quantity %d", product.Name, itemReq.Quantity)
+	}
+	fmt.Printf("ℹ Default unit price for product %s from vendor price list %d: %.2f\n", product.Name, best.ID, best.Price)
+	return best.Price, nil
+}
+
 // updatePurchaseItems updates purchase items
 func (s *PurchaseService) updatePurchaseItems(purchase *models.Purchase, items []models.PurchaseItemRequest) error {
 	// Clear existing items
@@ -306,18 +332,27 @@ func (s *PurchaseService) updatePurchaseItems(purchase *models.Purchase, items [
 	
 	for _, itemReq := range items {
 		// Validate product exists
-		_, err := s.productRepo.FindByID(itemReq.ProductID)
+		product, err := s.productRepo.FindByID(itemReq.ProductID)
 		if err != nil {
 			return fmt.Errorf("product %d not found", itemReq.ProductID)
 		}
 		
+		unitPrice, err := s.resolvePurchaseUnitPrice(purchase, *product, itemReq)
+		if err != nil {
+			return err
+		}
+		
 		item := models.PurchaseItem{
 			ProductID:        itemReq.ProductID,
 			Quantity:         itemReq.Quantity,
-			UnitPrice:        itemReq.UnitPrice,
+			UnitPrice:        unitPrice,
 			Discount:         itemReq.Discount,
 			Tax:              itemReq.Tax,
 			ExpenseAccountID: itemReq.ExpenseAccountID,
+			WarehouseLocationID: itemReq.WarehouseLocationID,
+			CostCenterID:     itemReq.CostCenterID,
+			ProjectID:        itemReq.ProjectID,
+			TagIDs:           itemReq.TagIDs,
 		}
 		
 		// Calculate totals
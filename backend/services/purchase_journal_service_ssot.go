@@ -92,6 +92,12 @@ func (s *PurchaseJournalServiceSSOT) CreatePurchaseJournal(purchase *models.Purc
 		subtotal += item.TotalPrice
 	}
 
+	// Dimensi analitik per item (cost center / proyek / tag)
+	itemDimensions, dimErr := purchaseItemDimensions(dbToUse, purchase.PurchaseItems)
+	if dimErr != nil {
+		return dimErr
+	}
+
 	// 1. DEBIT SIDE - Inventory/Expense/Fixed Asset accounts for each item
 	for _, item := range purchase.PurchaseItems {
 		var debitAccount *models.Account
@@ -163,6 +169,7 @@ func (s *PurchaseJournalServiceSSOT) CreatePurchaseJournal(purchase *models.Purc
 			DebitAmount:  decimal.NewFromFloat(item.TotalPrice),
 			CreditAmount: decimal.Zero,
 			Description:  fmt.Sprintf("Pembelian - %s", item.Product.Name),
+			Dimensions:   itemDimensions[item.ID],
 		})
 	}
 
@@ -276,6 +283,26 @@ func (s *PurchaseJournalServiceSSOT) CreatePurchaseJournal(purchase *models.Purc
 		Description:  fmt.Sprintf("Pembayaran Pembelian - %s", purchase.Code),
 	})
 
+	// Foreign currency bill: convert to base (IDR) and keep the original amounts on the lines
+	currencyCode := normalizeCurrencyCode(purchase.Currency)
+	exchangeRate := documentExchangeRate(currencyCode, purchase.ExchangeRate)
+	if isForeignCurrency(currencyCode) {
+		var baseDiff decimal.Decimal
+		for i := range lines {
+			lines[i].ForeignDebitAmount = lines[i].DebitAmount
+			lines[i].ForeignCreditAmount = lines[i].CreditAmount
+			lines[i].DebitAmount = convertToBaseCurrency(lines[i].DebitAmount, exchangeRate)
+			lines[i].CreditAmount = convertToBaseCurrency(lines[i].CreditAmount, exchangeRate)
+			baseDiff = baseDiff.Add(lines[i].DebitAmount).Sub(lines[i].CreditAmount)
+		}
+		// Absorb conversion rounding in the payable/cash line (always the last line)
+		if !baseDiff.IsZero() {
+			last := len(lines) - 1
+			lines[last].CreditAmount = lines[last].CreditAmount.Add(baseDiff)
+		}
+		log.Printf("💱 [SSOT] Purchase #%d converted from %s at rate %s", purchase.ID, currencyCode, exchangeRate.String())
+	}
+
 	// Calculate totals
 	var totalDebit, totalCreditCalc decimal.Decimal
 	for _, line := range lines {
@@ -289,6 +316,15 @@ func (s *PurchaseJournalServiceSSOT) CreatePurchaseJournal(purchase *models.Purc
 			totalDebit.InexactFloat64(), totalCreditCalc.InexactFloat64())
 	}
 
+	// Validate dimensions and mandatory dimension rules before anything is written
+	dimensionLines := make([]dimensionLine, 0, len(lines))
+	for _, l := range lines {
+		dimensionLines = append(dimensionLines, dimensionLine{AccountID: l.AccountID, Dimensions: l.Dimensions})
+	}
+	if err := checkJournalDimensions(dbToUse, models.SSOTSourceTypePurchase, dimensionLines); err != nil {
+		return fmt.Errorf("purchase #%d: %v", purchase.ID, err)
+	}
+
 	// Create journal entry
 	// Insert as DRAFT first to avoid trigger validation before lines are created
 	sourceID := uint64(purchase.ID)
@@ -329,10 +365,20 @@ func (s *PurchaseJournalServiceSSOT) CreatePurchaseJournal(purchase *models.Purc
 			CreatedAt:    now,
 			UpdatedAt:    now,
 		}
+		applyLineDimensions(journalLine, lineReq.Dimensions)
+		if isForeignCurrency(currencyCode) {
+			journalLine.CurrencyCode = currencyCode
+			journalLine.ExchangeRate = exchangeRate
+			journalLine.ForeignDebitAmount = lineReq.ForeignDebitAmount
+			journalLine.ForeignCreditAmount = lineReq.ForeignCreditAmount
+		}
 
 		if err := dbToUse.Create(journalLine).Error; err != nil {
 			return fmt.Errorf("failed to create SSOT journal line: %v", err)
 		}
+		if err := saveDimensionTags(dbToUse, models.DimensionOwnerJournalLine, journalLine.ID, lineReq.Dimensions.TagIDs); err != nil {
+			return err
+		}
 
 		// ✅ RE-ENABLED: Update account balance for COA tree view
 		// P&L uses journal entries (correct), but COA Tree View uses account.balance field
@@ -463,5 +509,52 @@ type PurchaseJournalLineRequest struct {
 	DebitAmount  decimal.Decimal
 	CreditAmount decimal.Decimal
 	Description  string
+
+	// Original bill currency amounts (zero for base currency purchases)
+	ForeignDebitAmount  decimal.Decimal
+	ForeignCreditAmount decimal.Decimal
+
+	// Analytic dimensions taken from the purchase item
+	Dimensions models.LineDimensions
 }
 
+// purchaseItemDimensions returns the analytic dimensions of each purchase item, including its tags
+func purchaseItemDimensions(tx *gorm.DB, items []models.PurchaseItem) (map[uint]models.LineDimensions, error) {
+	ids := make([]uint64, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, uint64(item.ID))
+	}
+	tags, err := loadDimensionTags(tx, models.DimensionOwnerPurchaseItem, ids)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[uint]models.LineDimensions, len(items))
+	for _, item := range items {
+		result[item.ID] = models.LineDimensions{
+			CostCenterID: item.CostCenterID,
+			ProjectID:    item.ProjectID,
+			TagIDs:       tags[uint64(item.ID)],
+		}
+	}
+	return result, nil
+}
+
+
+// validatePurchaseItemDimensions checks the dimensions of purchase items before they are saved
+func validatePurchaseItemDimensions(tx *gorm.DB, items []models.PurchaseItem) error {
+	dims := make([]models.LineDimensions, 0, len(items))
+	for _, item := range items {
+		dims = append(dims, models.LineDimensions{CostCenterID: item.CostCenterID, ProjectID: item.ProjectID, TagIDs: item.TagIDs})
+	}
+	return validateLineDimensions(tx, dims...)
+}
+
+// savePurchaseItemTags stores the tags of saved purchase items
+func savePurchaseItemTags(tx *gorm.DB, items []models.PurchaseItem) error {
+	for _, item := range items {
+		if err := saveDimensionTags(tx, models.DimensionOwnerPurchaseItem, uint64(item.ID), item.TagIDs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -5,6 +5,7 @@ import (
 	"testing"
 	"time"
 	"app-sistem-akuntansi/models"
+	"app-sistem-akuntansi/repositories"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -14,16 +15,19 @@ import (
 // MockAccountRepository for testing
 type MockAccountRepository struct {
 	mock.Mock
+	repositories.AccountRepository
 }
 
 func (m *MockAccountRepository) FindByCode(ctx context.Context, code string) (*models.Account, error) {
 	args := m.Called(ctx, code)
-	return args.Get(0).(*models.Account), args.Error(1)
+	account, _ := args.Get(0).(*models.Account)
+	return account, args.Error(1)
 }
 
 func (m *MockAccountRepository) FindByID(ctx context.Context, id uint) (*models.Account, error) {
 	args := m.Called(ctx, id)
-	return args.Get(0).(*models.Account), args.Error(1)
+	account, _ := args.Get(0).(*models.Account)
+	return account, args.Error(1)
 }
 
 // Test case for purchase journal lines creation with PPh
@@ -126,7 +130,7 @@ func TestCreatePurchaseJournalLines_WithPPh(t *testing.T) {
 	// Verify credit lines
 	assert.Equal(t, uint(201), lines[3].AccountID) // Accounts Payable
 	assert.Equal(t, 0.0, lines[3].DebitAmount)
-	assert.Equal(t, 1110000.0, lines[3].CreditAmount) // Net + PPN = 1,110,000
+	assert.Equal(t, 1065000.0, lines[3].CreditAmount) // Net + PPN - PPh = 1,065,000 (PPh is withheld from the vendor)
 
 	assert.Equal(t, uint(202), lines[4].AccountID) // PPh 21 Payable
 	assert.Equal(t, 0.0, lines[4].DebitAmount)
@@ -139,6 +143,7 @@ func TestCreatePurchaseJournalLines_WithPPh(t *testing.T) {
 	// Verify balance
 	totalDebit := 800000.0 + 200000.0 + 110000.0  // = 1,110,000
 	totalCredit := 1110000.0 + 25000.0 + 20000.0  // = 1,155,000
+	_, _ = totalDebit, totalCredit
 
 	// Wait, this should be balanced. Let me fix the calculation:
 	// Gross Payable should be NetBeforeTax + TotalTaxAdditions = 1,000,000 + 110,000 = 1,110,000
@@ -303,6 +308,8 @@ func TestCreatePurchaseJournalLines_ZeroPPN(t *testing.T) {
 		},
 	}
 
+	_ = purchase
+
 	// Since PPN account doesn't exist and PPN is 0, we should handle this gracefully
 	// For this test, let's make the function more robust
 	_, err := service.getPurchaseAccountIDs()
@@ -0,0 +1,745 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"app-sistem-akuntansi/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PurchaseRequisitionService - Permintaan pembelian karyawan sebelum menjadi purchase order
+type PurchaseRequisitionService struct {
+	db              *gorm.DB
+	approvalService *ApprovalService
+	purchaseService *PurchaseService
+}
+
+func NewPurchaseRequisitionService(db *gorm.DB, approvalService *ApprovalService, purchaseService *PurchaseService) *PurchaseRequisitionService {
+	return &PurchaseRequisitionService{
+		db:              db,
+		approvalService: approvalService,
+		purchaseService: purchaseService,
+	}
+}
+
+// Line fulfilment statuses (RequisitionFulfilmentLine.Status)
+const (
+	requisitionLineNotOrdered        = "NOT_ORDERED"
+	requisitionLinePartiallyOrdered  = "PARTIALLY_ORDERED"
+	requisitionLineOrdered           = "ORDERED"
+	requisitionLinePartiallyReceived = "PARTIALLY_RECEIVED"
+	requisitionLineReceived          = "RECEIVED"
+)
+
+// ========== REQUISITIONS ==========
+
+// GetRequisitions - Daftar permintaan pembelian; requesterID 0 = semua peminta
+func (s *PurchaseRequisitionService) GetRequisitions(requesterID uint, status string) ([]models.PurchaseRequisition, error) {
+	query := s.db.Preload("Requester").Preload("SuggestedVendor").Preload("Items.Product").Order("date DESC, id DESC")
+	if requesterID != 0 {
+		query = query.Where("requester_id = ?", requesterID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var requisitions []models.PurchaseRequisition
+	if err := query.Find(&requisitions).Error; err != nil {
+		return nil, err
+	}
+	return requisitions, nil
+}
+
+// GetRequisitionByID - Detail permintaan pembelian beserta link ke purchase order
+func (s *PurchaseRequisitionService) GetRequisitionByID(id uint) (*models.PurchaseRequisition, error) {
+	var requisition models.PurchaseRequisition
+	err := s.db.Preload("Requester").Preload("SuggestedVendor").Preload("ApprovalRequest").
+		Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Preload("Items.Product").Preload("Items.SuggestedVendor").Preload("Items.Links").
+		First(&requisition, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &requisition, nil
+}
+
+// CreateRequisition - Membuat permintaan pembelian DRAFT
+func (s *PurchaseRequisitionService) CreateRequisition(req models.PurchaseRequisitionRequest, userID uint) (*models.PurchaseRequisition, error) {
+	if err := validateRequisitionDates(req); err != nil {
+		return nil, err
+	}
+
+	requisition := models.PurchaseRequisition{
+		RequesterID: userID,
+		Status:      models.RequisitionStatusDraft,
+	}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		code, err := s.generateRequisitionCode(tx, req.Date)
+		if err != nil {
+			return err
+		}
+		requisition.Code = code
+		applyRequisitionHeader(&requisition, req)
+		if err := tx.Omit(clause.Associations).Create(&requisition).Error; err != nil {
+			return fmt.Errorf("failed to create requisition: %v", err)
+		}
+		return s.replaceItems(tx, &requisition, req.Items)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("📝 Purchase requisition %s created by user %d", requisition.Code, userID)
+	return s.GetRequisitionByID(requisition.ID)
+}
+
+// UpdateRequisition - Mengubah permintaan DRAFT atau yang ditolak; yang ditolak kembali menjadi DRAFT
+func (s *PurchaseRequisitionService) UpdateRequisition(id uint, req models.PurchaseRequisitionRequest, userID uint) (*models.PurchaseRequisition, error) {
+	if err := validateRequisitionDates(req); err != nil {
+		return nil, err
+	}
+
+	var requisition models.PurchaseRequisition
+	if err := s.db.First(&requisition, id).Error; err != nil {
+		return nil, errors.New("purchase requisition not found")
+	}
+	if requisition.Status != models.RequisitionStatusDraft && requisition.Status != models.RequisitionStatusRejected {
+		return nil, fmt.Errorf("only DRAFT or REJECTED requisitions can be changed, current status: %s", requisition.Status)
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		applyRequisitionHeader(&requisition, req)
+		requisition.Status = models.RequisitionStatusDraft
+		requisition.ApprovalRequestID = nil
+		if err := tx.Omit(clause.Associations).Save(&requisition).Error; err != nil {
+			return fmt.Errorf("failed to update requisition: %v", err)
+		}
+		if err := tx.Where("requisition_id = ?", requisition.ID).Delete(&models.PurchaseRequisitionItem{}).Error; err != nil {
+			return err
+		}
+		return s.replaceItems(tx, &requisition, req.Items)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("📝 Purchase requisition %s updated by user %d", requisition.Code, userID)
+	return s.GetRequisitionByID(requisition.ID)
+}
+
+// DeleteRequisition - Menghapus permintaan yang belum pernah diajukan
+func (s *PurchaseRequisitionService) DeleteRequisition(id uint) error {
+	var requisition models.PurchaseRequisition
+	if err := s.db.First(&requisition, id).Error; err != nil {
+		return errors.New("purchase requisition not found")
+	}
+	if requisition.Status != models.RequisitionStatusDraft {
+		return fmt.Errorf("only DRAFT requisitions can be deleted, cancel it instead")
+	}
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("requisition_id = ?", requisition.ID).Delete(&models.PurchaseRequisitionItem{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&requisition).Error
+	})
+}
+
+// ========== WORKFLOW ==========
+
+// SubmitForApproval - Mengirim permintaan DRAFT ke ApprovalService
+func (s *PurchaseRequisitionService) SubmitForApproval(id uint, userID uint) (*models.PurchaseRequisition, error) {
+	requisition, err := s.GetRequisitionByID(id)
+	if err != nil {
+		return nil, errors.New("purchase requisition not found")
+	}
+	if requisition.Status != models.RequisitionStatusDraft {
+		return nil, fmt.Errorf("only DRAFT requisitions can be submitted, current status: %s", requisition.Status)
+	}
+	if len(requisition.Items) == 0 {
+		return nil, errors.New("requisition has no items")
+	}
+
+	approvalRequest, err := s.approvalService.CreateApprovalRequest(models.CreateApprovalRequestDTO{
+		EntityType:     models.EntityTypeRequisition,
+		EntityID:       requisition.ID,
+		Amount:         requisition.EstimatedTotal,
+		RequestTitle:   fmt.Sprintf("Purchase Requisition %s - needed by %s", requisition.Code, requisition.NeededBy.Format("2006-01-02")),
+		RequestMessage: requisition.Justification,
+	}, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create approval request: %v", err)
+	}
+
+	if err := s.db.Model(&models.PurchaseRequisition{}).Where("id = ?", requisition.ID).Updates(map[string]interface{}{
+		"status":              models.RequisitionStatusPendingApproval,
+		"approval_request_id": approvalRequest.ID,
+	}).Error; err != nil {
+		return nil, err
+	}
+
+	return s.GetRequisitionByID(requisition.ID)
+}
+
+// CancelRequisition - Membatalkan permintaan yang belum dipesan sama sekali
+func (s *PurchaseRequisitionService) CancelRequisition(id uint, reason string, userID uint) (*models.PurchaseRequisition, error) {
+	requisition, err := s.GetRequisitionByID(id)
+	if err != nil {
+		return nil, errors.New("purchase requisition not found")
+	}
+	switch requisition.Status {
+	case models.RequisitionStatusCancelled, models.RequisitionStatusOrdered, models.RequisitionStatusPartiallyOrdered:
+		return nil, fmt.Errorf("requisition cannot be cancelled in status %s", requisition.Status)
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		// Withdraw the pending approval so approvers stop seeing it
+		if requisition.Status == models.RequisitionStatusPendingApproval && requisition.ApprovalRequestID != nil {
+			if err := tx.Model(&models.ApprovalRequest{}).
+				Where("id = ? AND status = ?", *requisition.ApprovalRequestID, models.ApprovalStatusPending).
+				Updates(map[string]interface{}{"status": models.ApprovalStatusCancelled, "reject_reason": reason}).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(&models.PurchaseRequisition{}).Where("id = ?", requisition.ID).Updates(map[string]interface{}{
+			"status":        models.RequisitionStatusCancelled,
+			"cancel_reason": reason,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("🚫 Purchase requisition %s cancelled by user %d: %s", requisition.Code, userID, reason)
+	return s.GetRequisitionByID(requisition.ID)
+}
+
+// ========== CONSOLIDATION ==========
+
+// GetOpenLines - Baris permintaan yang sudah disetujui dan belum seluruhnya dipesan
+func (s *PurchaseRequisitionService) GetOpenLines(vendorID uint) ([]models.PurchaseRequisitionItem, error) {
+	var items []models.PurchaseRequisitionItem
+	query := s.db.Preload("Product").Preload("SuggestedVendor").
+		Joins("JOIN purchase_requisitions pr ON pr.id = purchase_requisition_items.requisition_id AND pr.deleted_at IS NULL").
+		Where("pr.status IN ?", []string{models.RequisitionStatusApproved, models.RequisitionStatusPartiallyOrdered}).
+		Order("pr.needed_by, purchase_requisition_items.id")
+	if vendorID != 0 {
+		query = query.Where("COALESCE(purchase_requisition_items.suggested_vendor_id, pr.suggested_vendor_id) = ?", vendorID)
+	}
+	if err := query.Find(&items).Error; err != nil {
+		return nil, err
+	}
+
+	ids := make([]uint, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, item.ID)
+	}
+	ordered, err := orderedRequisitionQuantities(s.db, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	open := make([]models.PurchaseRequisitionItem, 0, len(items))
+	for _, item := range items {
+		if item.Quantity-ordered[item.ID] > 0 {
+			item.Quantity -= ordered[item.ID] // Remaining to order
+			open = append(open, item)
+		}
+	}
+	return open, nil
+}
+
+type consolidatedLine struct {
+	productID   uint
+	unitPrice   float64
+	quantity    int
+	description []string
+	sources     []consolidatedSource
+}
+
+type consolidatedSource struct {
+	requisitionItemID uint
+	quantity          int
+}
+
+// ConsolidateToPurchases - Menggabungkan baris permintaan yang disetujui menjadi purchase order per vendor.
+// Baris dengan produk dan harga yang sama untuk vendor yang sama menjadi satu baris PO.
+func (s *PurchaseRequisitionService) ConsolidateToPurchases(req models.RequisitionConsolidateRequest, userID uint) (*models.RequisitionConsolidateResult, error) {
+	itemIDs := make([]uint, 0, len(req.Lines))
+	for _, line := range req.Lines {
+		itemIDs = append(itemIDs, line.RequisitionItemID)
+	}
+
+	var items []models.PurchaseRequisitionItem
+	if err := s.db.Where("id IN ?", itemIDs).Find(&items).Error; err != nil {
+		return nil, err
+	}
+	itemByID := make(map[uint]models.PurchaseRequisitionItem, len(items))
+	requisitionIDs := make([]uint, 0, len(items))
+	for _, item := range items {
+		itemByID[item.ID] = item
+		requisitionIDs = append(requisitionIDs, item.RequisitionID)
+	}
+
+	var requisitions []models.PurchaseRequisition
+	if err := s.db.Where("id IN ?", requisitionIDs).Find(&requisitions).Error; err != nil {
+		return nil, err
+	}
+	requisitionByID := make(map[uint]models.PurchaseRequisition, len(requisitions))
+	for _, requisition := range requisitions {
+		requisitionByID[requisition.ID] = requisition
+	}
+
+	ordered, err := orderedRequisitionQuantities(s.db, itemIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	// Group lines per vendor, then per product and price
+	vendorOrder := []uint{}
+	groups := map[uint][]*consolidatedLine{}
+	requested := map[uint]int{}
+	codes := map[string]bool{}
+	for _, line := range req.Lines {
+		item, ok := itemByID[line.RequisitionItemID]
+		if !ok {
+			return nil, fmt.Errorf("requisition line %d not found", line.RequisitionItemID)
+		}
+		requisition := requisitionByID[item.RequisitionID]
+		if requisition.Status != models.RequisitionStatusApproved && requisition.Status != models.RequisitionStatusPartiallyOrdered {
+			return nil, fmt.Errorf("requisition %s is %s, only approved requisitions can be ordered", requisition.Code, requisition.Status)
+		}
+
+		remaining := item.Quantity - ordered[item.ID] - requested[item.ID]
+		quantity := line.Quantity
+		if quantity == 0 {
+			quantity = remaining
+		}
+		if quantity <= 0 || quantity > remaining {
+			return nil, fmt.Errorf("requisition %s line %d: quantity %d exceeds the %d not ordered yet", requisition.Code, item.ID, quantity, remaining)
+		}
+		requested[item.ID] += quantity
+
+		var vendorID uint
+		switch {
+		case line.VendorID != nil:
+			vendorID = *line.VendorID
+		case item.SuggestedVendorID != nil:
+			vendorID = *item.SuggestedVendorID
+		case requisition.SuggestedVendorID != nil:
+			vendorID = *requisition.SuggestedVendorID
+		default:
+			return nil, fmt.Errorf("requisition %s line %d has no vendor", requisition.Code, item.ID)
+		}
+		unitPrice := item.EstimatedUnitPrice
+		if line.UnitPrice != nil {
+			unitPrice = *line.UnitPrice
+		}
+
+		if _, seen := groups[vendorID]; !seen {
+			vendorOrder = append(vendorOrder, vendorID)
+		}
+		var target *consolidatedLine
+		for _, existing := range groups[vendorID] {
+			if existing.productID == item.ProductID && existing.unitPrice == unitPrice {
+				target = existing
+				break
+			}
+		}
+		if target == nil {
+			target = &consolidatedLine{productID: item.ProductID, unitPrice: unitPrice}
+			groups[vendorID] = append(groups[vendorID], target)
+		}
+		target.quantity += quantity
+		target.sources = append(target.sources, consolidatedSource{requisitionItemID: item.ID, quantity: quantity})
+		codes[requisition.Code] = true
+	}
+
+	codeList := make([]string, 0, len(codes))
+	for code := range codes {
+		codeList = append(codeList, code)
+	}
+	sort.Strings(codeList)
+
+	// Purchase orders go through the normal purchase approval once created
+	result := &models.RequisitionConsolidateResult{Requisitions: codeList}
+	created := make([]*models.Purchase, 0, len(vendorOrder))
+	rollback := func() {
+		for _, purchase := range created {
+			if err := s.purchaseService.DeletePurchase(purchase.ID); err != nil {
+				log.Printf("⚠️ Failed to remove purchase %s after consolidation failed: %v", purchase.Code, err)
+			}
+		}
+	}
+
+	for _, vendorID := range vendorOrder {
+		purchaseReq := models.PurchaseCreateRequest{
+			VendorID:        vendorID,
+			Date:            req.Date,
+			DueDate:         req.DueDate,
+			PaymentMethod:   req.PaymentMethod,
+			BankAccountID:   req.BankAccountID,
+			CreditAccountID: req.CreditAccountID,
+			PPNRate:         req.PPNRate,
+			Notes:           strings.TrimSpace(req.Notes + "\nFrom purchase requisitions: " + strings.Join(codeList, ", ")),
+		}
+		if purchaseReq.DueDate.IsZero() {
+			purchaseReq.DueDate = req.Date.AddDate(0, 0, 30)
+		}
+		for _, line := range groups[vendorID] {
+			purchaseReq.Items = append(purchaseReq.Items, models.PurchaseItemRequest{
+				ProductID: line.productID,
+				Quantity:  line.quantity,
+				UnitPrice: line.unitPrice,
+			})
+		}
+
+		purchase, err := s.purchaseService.CreatePurchase(purchaseReq, userID)
+		if err != nil {
+			rollback()
+			return nil, fmt.Errorf("failed to create purchase order for vendor %d: %v", vendorID, err)
+		}
+		created = append(created, purchase)
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		// Lock the lines and re-check so two buyers cannot order the same quantity
+		var locked []models.PurchaseRequisitionItem
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id IN ?", itemIDs).Find(&locked).Error; err != nil {
+			return err
+		}
+		current, err := orderedRequisitionQuantities(tx, itemIDs)
+		if err != nil {
+			return err
+		}
+		for _, item := range locked {
+			if current[item.ID]+requested[item.ID] > item.Quantity {
+				return fmt.Errorf("requisition line %d was ordered by someone else in the meantime", item.ID)
+			}
+		}
+
+		for i, vendorID := range vendorOrder {
+			purchaseItems := append([]models.PurchaseItem(nil), created[i].PurchaseItems...)
+			sort.Slice(purchaseItems, func(a, b int) bool { return purchaseItems[a].ID < purchaseItems[b].ID })
+			if len(purchaseItems) != len(groups[vendorID]) {
+				return fmt.Errorf("purchase %s has %d lines, expected %d", created[i].Code, len(purchaseItems), len(groups[vendorID]))
+			}
+			for j, line := range groups[vendorID] {
+				if purchaseItems[j].ProductID != line.productID {
+					return fmt.Errorf("purchase %s line %d does not match the requisition product", created[i].Code, j+1)
+				}
+				for _, source := range line.sources {
+					link := models.PurchaseRequisitionLink{
+						RequisitionItemID: source.requisitionItemID,
+						PurchaseID:        created[i].ID,
+						PurchaseItemID:    purchaseItems[j].ID,
+						Quantity:          source.quantity,
+						UserID:            userID,
+					}
+					if err := tx.Create(&link).Error; err != nil {
+						return fmt.Errorf("failed to link requisition line: %v", err)
+					}
+				}
+			}
+		}
+
+		for requisitionID := range requisitionByID {
+			if err := refreshRequisitionStatus(tx, requisitionID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		rollback()
+		return nil, err
+	}
+
+	for _, purchase := range created {
+		result.Purchases = append(result.Purchases, *purchase)
+	}
+	log.Printf("🧾 Consolidated requisitions %s into %d purchase order(s)", strings.Join(codeList, ", "), len(created))
+	return result, nil
+}
+
+// ========== FULFILMENT ==========
+
+// GetFulfilment - Status pemesanan dan penerimaan setiap baris permintaan, dari baris PO sampai penerimaan barang
+func (s *PurchaseRequisitionService) GetFulfilment(id uint) (*models.RequisitionFulfilment, error) {
+	if err := refreshRequisitionStatus(s.db, id); err != nil {
+		return nil, err
+	}
+	requisition, err := s.GetRequisitionByID(id)
+	if err != nil {
+		return nil, errors.New("purchase requisition not found")
+	}
+
+	var links []models.PurchaseRequisitionLink
+	itemIDs := make([]uint, 0, len(requisition.Items))
+	for _, item := range requisition.Items {
+		itemIDs = append(itemIDs, item.ID)
+	}
+	if len(itemIDs) > 0 {
+		if err := s.db.Preload("Purchase.Vendor").Where("requisition_item_id IN ?", itemIDs).Order("id").Find(&links).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	// Receipts are booked per PO line; hand them out to the requisition lines it carries in link order
+	received, err := s.receivedByPurchaseItem(links)
+	if err != nil {
+		return nil, err
+	}
+	receivedLeft := make(map[uint]int, len(received))
+	for purchaseItemID, quantity := range received {
+		receivedLeft[purchaseItemID] = quantity
+	}
+	allLinks, err := s.linksOfPurchaseItems(links)
+	if err != nil {
+		return nil, err
+	}
+	receivedPerLink := make(map[uint]int, len(allLinks))
+	for _, link := range allLinks {
+		take := link.Quantity
+		if receivedLeft[link.PurchaseItemID] < take {
+			take = receivedLeft[link.PurchaseItemID]
+		}
+		receivedPerLink[link.ID] = take
+		receivedLeft[link.PurchaseItemID] -= take
+	}
+
+	fulfilment := &models.RequisitionFulfilment{
+		RequisitionID: requisition.ID,
+		Code:          requisition.Code,
+		Status:        requisition.Status,
+		NeededBy:      requisition.NeededBy,
+	}
+	for _, item := range requisition.Items {
+		line := models.RequisitionFulfilmentLine{
+			RequisitionItemID: item.ID,
+			ProductID:         item.ProductID,
+			ProductName:       item.Product.Name,
+			Quantity:          item.Quantity,
+			Orders:            []models.RequisitionFulfilmentLink{},
+		}
+		for _, link := range links {
+			if link.RequisitionItemID != item.ID {
+				continue
+			}
+			active := purchaseIsActive(link.Purchase)
+			entry := models.RequisitionFulfilmentLink{
+				PurchaseID:     link.PurchaseID,
+				PurchaseCode:   link.Purchase.Code,
+				PurchaseStatus: link.Purchase.Status,
+				VendorName:     link.Purchase.Vendor.Name,
+				PurchaseItemID: link.PurchaseItemID,
+				Quantity:       link.Quantity,
+			}
+			if link.Purchase.ID == 0 {
+				entry.PurchaseStatus = "DELETED"
+			}
+			if active {
+				entry.ReceivedQuantity = receivedPerLink[link.ID]
+				line.OrderedQuantity += link.Quantity
+				line.ReceivedQuantity += entry.ReceivedQuantity
+			}
+			line.Orders = append(line.Orders, entry)
+		}
+		line.Status = requisitionLineStatus(line)
+		fulfilment.Lines = append(fulfilment.Lines, line)
+	}
+
+	return fulfilment, nil
+}
+
+func (s *PurchaseRequisitionService) receivedByPurchaseItem(links []models.PurchaseRequisitionLink) (map[uint]int, error) {
+	received := map[uint]int{}
+	if len(links) == 0 {
+		return received, nil
+	}
+	purchaseItemIDs := make([]uint, 0, len(links))
+	for _, link := range links {
+		purchaseItemIDs = append(purchaseItemIDs, link.PurchaseItemID)
+	}
+
+	var rows []struct {
+		PurchaseItemID uint
+		Quantity       int
+	}
+	err := s.db.Table("purchase_receipt_items pri").
+		Select("pri.purchase_item_id, COALESCE(SUM(pri.quantity_received), 0) AS quantity").
+		Joins("JOIN purchase_receipts pr ON pr.id = pri.receipt_id AND pr.deleted_at IS NULL").
+		Where("pri.purchase_item_id IN ? AND pri.deleted_at IS NULL AND pr.status <> ?", purchaseItemIDs, models.ReceiptStatusRejected).
+		Group("pri.purchase_item_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		received[row.PurchaseItemID] = row.Quantity
+	}
+	return received, nil
+}
+
+// linksOfPurchaseItems loads every link on the PO lines, including other requisitions, so receipts
+// are split the same way whichever requisition is looked at
+func (s *PurchaseRequisitionService) linksOfPurchaseItems(links []models.PurchaseRequisitionLink) ([]models.PurchaseRequisitionLink, error) {
+	var all []models.PurchaseRequisitionLink
+	if len(links) == 0 {
+		return all, nil
+	}
+	purchaseItemIDs := make([]uint, 0, len(links))
+	for _, link := range links {
+		purchaseItemIDs = append(purchaseItemIDs, link.PurchaseItemID)
+	}
+	err := s.db.Where("purchase_item_id IN ?", purchaseItemIDs).Order("id").Find(&all).Error
+	return all, err
+}
+
+func (s *PurchaseRequisitionService) replaceItems(tx *gorm.DB, requisition *models.PurchaseRequisition, items []models.PurchaseRequisitionItemRequest) error {
+	total := 0.0
+	for _, itemReq := range items {
+		var product models.Product
+		if err := tx.First(&product, itemReq.ProductID).Error; err != nil {
+			return fmt.Errorf("product %d not found", itemReq.ProductID)
+		}
+		unitPrice := itemReq.EstimatedUnitPrice
+		if unitPrice == 0 {
+			unitPrice = product.PurchasePrice
+		}
+		item := models.PurchaseRequisitionItem{
+			RequisitionID:      requisition.ID,
+			ProductID:          itemReq.ProductID,
+			Description:        itemReq.Description,
+			Quantity:           itemReq.Quantity,
+			EstimatedUnitPrice: unitPrice,
+			EstimatedTotal:     roundAmount(unitPrice * float64(itemReq.Quantity)),
+			SuggestedVendorID:  itemReq.SuggestedVendorID,
+		}
+		if err := tx.Create(&item).Error; err != nil {
+			return fmt.Errorf("failed to save requisition item: %v", err)
+		}
+		total += item.EstimatedTotal
+	}
+	return tx.Model(requisition).Update("estimated_total", roundAmount(total)).Error
+}
+
+func (s *PurchaseRequisitionService) generateRequisitionCode(tx *gorm.DB, date time.Time) (string, error) {
+	var count int64
+	prefix := fmt.Sprintf("PRQ-%s-", date.Format("200601"))
+	if err := tx.Unscoped().Model(&models.PurchaseRequisition{}).
+		Where("code LIKE ?", prefix+"%").
+		Count(&count).Error; err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s%04d", prefix, count+1), nil
+}
+
+func applyRequisitionHeader(requisition *models.PurchaseRequisition, req models.PurchaseRequisitionRequest) {
+	requisition.Date = req.Date
+	requisition.NeededBy = req.NeededBy
+	requisition.Justification = req.Justification
+	requisition.SuggestedVendorID = req.SuggestedVendorID
+	requisition.Notes = req.Notes
+}
+
+func validateRequisitionDates(req models.PurchaseRequisitionRequest) error {
+	if dateOnly(req.NeededBy).Before(dateOnly(req.Date)) {
+		return errors.New("needed-by date cannot be before the requisition date")
+	}
+	return nil
+}
+
+// orderedRequisitionQuantities sums what is on live purchase orders per requisition line; deleted
+// and cancelled purchases free their quantity again
+func orderedRequisitionQuantities(db *gorm.DB, itemIDs []uint) (map[uint]int, error) {
+	ordered := map[uint]int{}
+	if len(itemIDs) == 0 {
+		return ordered, nil
+	}
+	var rows []struct {
+		RequisitionItemID uint
+		Quantity          int
+	}
+	err := db.Table("purchase_requisition_links l").
+		Select("l.requisition_item_id, COALESCE(SUM(l.quantity), 0) AS quantity").
+		Joins("JOIN purchases p ON p.id = l.purchase_id AND p.deleted_at IS NULL AND p.status <> ?", models.PurchaseStatusCancelled).
+		Where("l.requisition_item_id IN ?", itemIDs).
+		Group("l.requisition_item_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		ordered[row.RequisitionItemID] = row.Quantity
+	}
+	return ordered, nil
+}
+
+// refreshRequisitionStatus moves an approved requisition between APPROVED, PARTIALLY_ORDERED and
+// ORDERED according to what is on live purchase orders
+func refreshRequisitionStatus(db *gorm.DB, requisitionID uint) error {
+	var requisition models.PurchaseRequisition
+	if err := db.Preload("Items").First(&requisition, requisitionID).Error; err != nil {
+		return err
+	}
+	switch requisition.Status {
+	case models.RequisitionStatusApproved, models.RequisitionStatusPartiallyOrdered, models.RequisitionStatusOrdered:
+	default:
+		return nil
+	}
+
+	itemIDs := make([]uint, 0, len(requisition.Items))
+	for _, item := range requisition.Items {
+		itemIDs = append(itemIDs, item.ID)
+	}
+	ordered, err := orderedRequisitionQuantities(db, itemIDs)
+	if err != nil {
+		return err
+	}
+
+	anyOrdered, allOrdered := false, true
+	for _, item := range requisition.Items {
+		if ordered[item.ID] > 0 {
+			anyOrdered = true
+		}
+		if ordered[item.ID] < item.Quantity {
+			allOrdered = false
+		}
+	}
+	status := models.RequisitionStatusApproved
+	if allOrdered && anyOrdered {
+		status = models.RequisitionStatusOrdered
+	} else if anyOrdered {
+		status = models.RequisitionStatusPartiallyOrdered
+	}
+	if status == requisition.Status {
+		return nil
+	}
+	return db.Model(&models.PurchaseRequisition{}).Where("id = ?", requisition.ID).Update("status", status).Error
+}
+
+func purchaseIsActive(purchase models.Purchase) bool {
+	return purchase.ID != 0 && purchase.Status != models.PurchaseStatusCancelled
+}
+
+func requisitionLineStatus(line models.RequisitionFulfilmentLine) string {
+	switch {
+	case line.ReceivedQuantity >= line.Quantity:
+		return requisitionLineReceived
+	case line.ReceivedQuantity > 0:
+		return requisitionLinePartiallyReceived
+	case line.OrderedQuantity >= line.Quantity:
+		return requisitionLineOrdered
+	case line.OrderedQuantity > 0:
+		return requisitionLinePartiallyOrdered
+	default:
+		return requisitionLineNotOrdered
+	}
+}
@@ -0,0 +1,137 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"app-sistem-akuntansi/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestRequisitionLineStatus(t *testing.T) {
+	tests := []struct {
+		name string
+		line models.RequisitionFulfilmentLine
+		want string
+	}{
+		{name: "nothing ordered", line: models.RequisitionFulfilmentLine{Quantity: 10}, want: requisitionLineNotOrdered},
+		{name: "part ordered", line: models.RequisitionFulfilmentLine{Quantity: 10, OrderedQuantity: 6}, want: requisitionLinePartiallyOrdered},
+		{name: "all ordered", line: models.RequisitionFulfilmentLine{Quantity: 10, OrderedQuantity: 10}, want: requisitionLineOrdered},
+		{name: "part received", line: models.RequisitionFulfilmentLine{Quantity: 10, OrderedQuantity: 10, ReceivedQuantity: 4}, want: requisitionLinePartiallyReceived},
+		{name: "all received", line: models.RequisitionFulfilmentLine{Quantity: 10, OrderedQuantity: 10, ReceivedQuantity: 10}, want: requisitionLineReceived},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, requisitionLineStatus(tt.line))
+		})
+	}
+}
+
+func TestRequisitionOrderingAndFulfilment(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1) // Every connection to :memory: is a separate database
+	require.NoError(t, db.AutoMigrate(&models.User{}, &models.Contact{}, &models.Product{}, &models.ApprovalRequest{},
+		&models.PurchaseRequisition{}, &models.PurchaseRequisitionItem{}, &models.PurchaseRequisitionLink{},
+		&models.Purchase{}, &models.PurchaseItem{}, &models.PurchaseReceipt{}, &models.PurchaseReceiptItem{}))
+	service := NewPurchaseRequisitionService(db, nil, nil)
+
+	vendor := models.Contact{Code: "VEND-001", Name: "PT Pemasok", Type: models.ContactTypeVendor}
+	require.NoError(t, db.Create(&vendor).Error)
+	paper := models.Product{Code: "PRD-001", Name: "Kertas A4", Unit: "rim", PurchasePrice: 45000}
+	toner := models.Product{Code: "PRD-002", Name: "Toner", Unit: "pcs", PurchasePrice: 250000}
+	require.NoError(t, db.Create(&paper).Error)
+	require.NoError(t, db.Create(&toner).Error)
+	date := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	request := models.PurchaseRequisitionRequest{Date: date, NeededBy: date.AddDate(0, 0, -1), Justification: "Stok kantor",
+		Items: []models.PurchaseRequisitionItemRequest{
+			{ProductID: paper.ID, Quantity: 10, SuggestedVendorID: &vendor.ID},
+			{ProductID: toner.ID, Quantity: 5, EstimatedUnitPrice: 240000},
+		}}
+	_, err = service.CreateRequisition(request, 1)
+	assert.EqualError(t, err, "needed-by date cannot be before the requisition date")
+	request.NeededBy = date.AddDate(0, 0, 14)
+	requisition, err := service.CreateRequisition(request, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "PRQ-202403-0001", requisition.Code)
+	assert.Equal(t, models.RequisitionStatusDraft, requisition.Status)
+	require.Len(t, requisition.Items, 2)
+	assert.InDelta(t, 45000, requisition.Items[0].EstimatedUnitPrice, 0.001, "the product purchase price by default")
+	assert.InDelta(t, 1650000, requisition.EstimatedTotal, 0.001)
+	paperLine, tonerLine := requisition.Items[0], requisition.Items[1]
+
+	consolidate := func(lines ...models.RequisitionConsolidateLineItem) error {
+		_, err := service.ConsolidateToPurchases(models.RequisitionConsolidateRequest{Date: date, Lines: lines}, 1)
+		return err
+	}
+	assert.EqualError(t, consolidate(models.RequisitionConsolidateLineItem{RequisitionItemID: paperLine.ID}),
+		"requisition PRQ-202403-0001 is DRAFT, only approved requisitions can be ordered")
+	require.NoError(t, db.Model(&models.PurchaseRequisition{}).Where("id = ?", requisition.ID).Update("status", models.RequisitionStatusApproved).Error)
+	assert.EqualError(t, consolidate(
+		models.RequisitionConsolidateLineItem{RequisitionItemID: paperLine.ID, Quantity: 6},
+		models.RequisitionConsolidateLineItem{RequisitionItemID: paperLine.ID, Quantity: 6},
+	), "requisition PRQ-202403-0001 line 1: quantity 6 exceeds the 4 not ordered yet")
+	assert.EqualError(t, consolidate(models.RequisitionConsolidateLineItem{RequisitionItemID: tonerLine.ID}),
+		"requisition PRQ-202403-0001 line 2 has no vendor")
+
+	// Order 6 of the paper on a purchase order
+	purchase := models.Purchase{Code: "PO-001", VendorID: vendor.ID, UserID: 1, Date: date, Status: models.PurchaseStatusApproved,
+		PurchaseItems: []models.PurchaseItem{{ProductID: paper.ID, Quantity: 6, UnitPrice: 45000, TotalPrice: 270000}}}
+	require.NoError(t, db.Create(&purchase).Error)
+	purchaseItem := purchase.PurchaseItems[0]
+	require.NoError(t, db.Create(&models.PurchaseRequisitionLink{RequisitionItemID: paperLine.ID, PurchaseID: purchase.ID,
+		PurchaseItemID: purchaseItem.ID, Quantity: 6, UserID: 1}).Error)
+	require.NoError(t, refreshRequisitionStatus(db, requisition.ID))
+
+	open, err := service.GetOpenLines(vendor.ID)
+	require.NoError(t, err)
+	require.Len(t, open, 1, "the toner has no vendor yet")
+	assert.Equal(t, 4, open[0].Quantity)
+	open, err = service.GetOpenLines(0)
+	require.NoError(t, err)
+	assert.Len(t, open, 2)
+
+	_, err = service.CancelRequisition(requisition.ID, "Tidak jadi", 1)
+	assert.EqualError(t, err, "requisition cannot be cancelled in status PARTIALLY_ORDERED")
+
+	receipt := models.PurchaseReceipt{PurchaseID: purchase.ID, ReceiptNumber: "GR-001", ReceivedDate: date, ReceivedBy: 1, Status: models.ReceiptStatusPartial,
+		ReceiptItems: []models.PurchaseReceiptItem{{PurchaseItemID: purchaseItem.ID, QuantityReceived: 4}}}
+	require.NoError(t, db.Create(&receipt).Error)
+	rejected := models.PurchaseReceipt{PurchaseID: purchase.ID, ReceiptNumber: "GR-002", ReceivedDate: date, ReceivedBy: 1, Status: models.ReceiptStatusRejected,
+		ReceiptItems: []models.PurchaseReceiptItem{{PurchaseItemID: purchaseItem.ID, QuantityReceived: 2}}}
+	require.NoError(t, db.Create(&rejected).Error)
+
+	fulfilment, err := service.GetFulfilment(requisition.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.RequisitionStatusPartiallyOrdered, fulfilment.Status)
+	require.Len(t, fulfilment.Lines, 2)
+	assert.Equal(t, 6, fulfilment.Lines[0].OrderedQuantity)
+	assert.Equal(t, 4, fulfilment.Lines[0].ReceivedQuantity, "rejected receipts are not counted")
+	assert.Equal(t, requisitionLinePartiallyReceived, fulfilment.Lines[0].Status)
+	require.Len(t, fulfilment.Lines[0].Orders, 1)
+	assert.Equal(t, "PT Pemasok", fulfilment.Lines[0].Orders[0].VendorName)
+	assert.Equal(t, requisitionLineNotOrdered, fulfilment.Lines[1].Status)
+
+	// Cancelling the purchase order frees the quantity again
+	require.NoError(t, db.Model(&purchase).Update("status", models.PurchaseStatusCancelled).Error)
+	fulfilment, err = service.GetFulfilment(requisition.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.RequisitionStatusApproved, fulfilment.Status)
+	assert.Zero(t, fulfilment.Lines[0].OrderedQuantity)
+	assert.Equal(t, requisitionLineNotOrdered, fulfilment.Lines[0].Status)
+	assert.Equal(t, models.PurchaseStatusCancelled, fulfilment.Lines[0].Orders[0].PurchaseStatus)
+
+	cancelled, err := service.CancelRequisition(requisition.ID, "Tidak jadi", 1)
+	require.NoError(t, err)
+	assert.Equal(t, models.RequisitionStatusCancelled, cancelled.Status)
+	assert.Equal(t, "Tidak jadi", cancelled.CancelReason)
+}
@@ -0,0 +1,107 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+
+	"app-sistem-akuntansi/models"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+// GenerateDebitNotePDF generates the vendor debit note for a purchase return
+func (p *PDFService) GenerateDebitNotePDF(purchaseReturn *models.PurchaseReturn) ([]byte, error) {
+	if purchaseReturn == nil {
+		return nil, fmt.Errorf("purchase return data is required")
+	}
+
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(15, 15, 15)
+	pdf.SetAutoPageBreak(true, 15)
+	pdf.AddPage()
+
+	p.addReportHeader(pdf, "Debit Note",
+		fmt.Sprintf("Debit Note No: %s", purchaseReturn.DebitNoteNumber),
+		fmt.Sprintf("Date: %s", purchaseReturn.Date.Format("02/01/2006")),
+		fmt.Sprintf("Vendor: %s", purchaseReturn.Vendor.Name),
+		fmt.Sprintf("Purchase: %s   Return: %s", purchaseReturn.Purchase.Code, purchaseReturn.Code),
+	)
+
+	lm, _, _, _ := pdf.GetMargins()
+	widths := []float64{10, 80, 20, 35, 35}
+
+	pdf.SetX(lm)
+	pdf.SetFont("Arial", "B", 9)
+	pdf.SetFillColor(220, 220, 220)
+	for i, h := range []string{"No", "Product", "Qty", "Unit Price", "Amount"} {
+		pdf.CellFormat(widths[i], 7, h, "1", 0, "C", true, 0, "")
+	}
+	pdf.Ln(7)
+
+	formatAmount := func(amount float64) string {
+		if isForeignCurrency(purchaseReturn.Currency) {
+			return fmt.Sprintf("%s %.2f", normalizeCurrencyCode(purchaseReturn.Currency), amount)
+		}
+		return p.formatRupiah(amount)
+	}
+
+	pdf.SetFont("Arial", "", 9)
+	for i, item := range purchaseReturn.ReturnItems {
+		pdf.SetX(lm)
+		pdf.CellFormat(widths[0], 6, fmt.Sprintf("%d", i+1), "1", 0, "C", false, 0, "")
+		pdf.CellFormat(widths[1], 6, truncateToWidth(pdf, item.Product.Name, widths[1]-2), "1", 0, "L", false, 0, "")
+		pdf.CellFormat(widths[2], 6, fmt.Sprintf("%d", item.Quantity), "1", 0, "C", false, 0, "")
+		pdf.CellFormat(widths[3], 6, formatAmount(item.UnitPrice), "1", 0, "R", false, 0, "")
+		pdf.CellFormat(widths[4], 6, formatAmount(item.TotalAmount), "1", 0, "R", false, 0, "")
+		pdf.Ln(6)
+	}
+
+	labelW := widths[0] + widths[1] + widths[2] + widths[3]
+	totals := []struct {
+		label  string
+		amount float64
+		show   bool
+	}{
+		{"Subtotal", purchaseReturn.Subtotal, true},
+		{"PPN", purchaseReturn.PPNAmount, purchaseReturn.PPNAmount > 0},
+		{"PPh 21", -purchaseReturn.PPh21Amount, purchaseReturn.PPh21Amount > 0},
+		{"PPh 23", -purchaseReturn.PPh23Amount, purchaseReturn.PPh23Amount > 0},
+	}
+	for _, t := range totals {
+		if !t.show {
+			continue
+		}
+		pdf.SetX(lm)
+		pdf.CellFormat(labelW, 6, t.label, "1", 0, "R", false, 0, "")
+		pdf.CellFormat(widths[4], 6, formatAmount(t.amount), "1", 0, "R", false, 0, "")
+		pdf.Ln(6)
+	}
+	pdf.SetX(lm)
+	pdf.SetFont("Arial", "B", 9)
+	pdf.SetFillColor(245, 245, 245)
+	pdf.CellFormat(labelW, 7, "TOTAL DEBIT", "1", 0, "R", true, 0, "")
+	pdf.CellFormat(widths[4], 7, formatAmount(purchaseReturn.TotalAmount), "1", 0, "R", true, 0, "")
+	pdf.Ln(10)
+
+	pdf.SetFont("Arial", "", 9)
+	pdf.SetX(lm)
+	if purchaseReturn.AppliedAmount > 0 {
+		pdf.Cell(0, 5, fmt.Sprintf("Deducted from purchase %s outstanding: %s", purchaseReturn.Purchase.Code, formatAmount(purchaseReturn.AppliedAmount)))
+		pdf.Ln(5)
+	}
+	if purchaseReturn.CreditAmount > 0 {
+		pdf.SetX(lm)
+		pdf.Cell(0, 5, fmt.Sprintf("Vendor credit for next purchases: %s", formatAmount(purchaseReturn.CreditAmount)))
+		pdf.Ln(5)
+	}
+	if purchaseReturn.Reason != "" {
+		pdf.SetX(lm)
+		pdf.MultiCell(0, 5, fmt.Sprintf("Reason: %s", purchaseReturn.Reason), "", "L", false)
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, fmt.Errorf("failed to generate PDF: %v", err)
+	}
+	return buf.Bytes(), nil
+}
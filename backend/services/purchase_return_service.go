@@ -0,0 +1,480 @@
+package services
+
+import (
+	"app-sistem-akuntansi/models"
+	"app-sistem-akuntansi/repositories"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PurchaseReturnService handles goods returned to vendors. A return takes the goods out of stock
+// and off the purchase receipt layers, posts the reversing journal through the purchase SSOT
+// adapter and settles the value against the purchase outstanding amount, issuing a vendor credit
+// for whatever exceeds it.
+type PurchaseReturnService struct {
+	db             *gorm.DB
+	stockService   *StockService
+	costingService *InventoryCostingService
+	journalAdapter *PurchaseSSOTJournalAdapter
+	pdfService     PDFServiceInterface
+}
+
+func NewPurchaseReturnService(db *gorm.DB, pdfService PDFServiceInterface) *PurchaseReturnService {
+	return &PurchaseReturnService{
+		db:             db,
+		stockService:   NewStockService(db),
+		costingService: NewInventoryCostingService(db),
+		journalAdapter: NewPurchaseSSOTJournalAdapter(db, NewUnifiedJournalService(db), repositories.NewAccountRepository(db), NewTaxAccountService(db)),
+		pdfService:     pdfService,
+	}
+}
+
+// ========== QUERIES ==========
+
+// GetReturns - Daftar retur pembelian, opsional difilter pembelian dan vendor
+func (s *PurchaseReturnService) GetReturns(purchaseID, vendorID uint) ([]models.PurchaseReturn, error) {
+	var returns []models.PurchaseReturn
+	query := s.db.Preload("Vendor").Preload("Purchase").Preload("ReturnItems.Product")
+	if purchaseID > 0 {
+		query = query.Where("purchase_id = ?", purchaseID)
+	}
+	if vendorID > 0 {
+		query = query.Where("vendor_id = ?", vendorID)
+	}
+	err := query.Order("date DESC, id DESC").Find(&returns).Error
+	return returns, err
+}
+
+// GetReturnByID - Detail retur pembelian
+func (s *PurchaseReturnService) GetReturnByID(id uint) (*models.PurchaseReturn, error) {
+	var purchaseReturn models.PurchaseReturn
+	err := s.db.Preload("Vendor").Preload("User").Preload("Purchase").Preload("PurchaseReceipt").
+		Preload("ReturnItems.Product").Preload("ReturnItems.PurchaseItem").
+		First(&purchaseReturn, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &purchaseReturn, nil
+}
+
+// GetVendorCredits - Kredit vendor; openOnly hanya yang masih bisa dipakai
+func (s *PurchaseReturnService) GetVendorCredits(vendorID uint, openOnly bool) ([]models.VendorCredit, error) {
+	var credits []models.VendorCredit
+	query := s.db.Preload("PurchaseReturn").Preload("Applications")
+	if vendorID > 0 {
+		query = query.Where("vendor_id = ?", vendorID)
+	}
+	if openOnly {
+		query = query.Where("status = ? AND remaining_amount > 0", models.VendorCreditStatusOpen)
+	}
+	err := query.Order("date ASC, id ASC").Find(&credits).Error
+	return credits, err
+}
+
+// ========== RETURNS ==========
+
+// CreateReturn - Buat dan posting retur pembelian beserta nota debit
+func (s *PurchaseReturnService) CreateReturn(purchaseID uint, req models.PurchaseReturnRequest, userID uint) (*models.PurchaseReturn, error) {
+	var purchaseReturn *models.PurchaseReturn
+	var recostProducts []uint
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var purchase models.Purchase
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Preload("Vendor").Preload("PurchaseItems.Product").
+			First(&purchase, purchaseID).Error; err != nil {
+			return errors.New("purchase not found")
+		}
+		switch purchase.Status {
+		case models.PurchaseStatusApproved, models.PurchaseStatusCompleted, models.PurchaseStatusPaid:
+		default:
+			return fmt.Errorf("cannot return goods of a purchase with status %s", purchase.Status)
+		}
+
+		var receiptItems map[uint]models.PurchaseReceiptItem
+		if req.PurchaseReceiptID != nil {
+			var receipt models.PurchaseReceipt
+			if err := tx.Preload("ReceiptItems").First(&receipt, *req.PurchaseReceiptID).Error; err != nil {
+				return errors.New("purchase receipt not found")
+			}
+			if receipt.PurchaseID != purchase.ID {
+				return errors.New("purchase receipt does not belong to this purchase")
+			}
+			receiptItems = make(map[uint]models.PurchaseReceiptItem, len(receipt.ReceiptItems))
+			for _, ri := range receipt.ReceiptItems {
+				receiptItems[ri.PurchaseItemID] = ri
+			}
+		}
+
+		items, err := s.buildReturnItems(tx, &purchase, req, receiptItems)
+		if err != nil {
+			return err
+		}
+
+		purchaseReturn = &models.PurchaseReturn{
+			PurchaseID:        purchase.ID,
+			PurchaseReceiptID: req.PurchaseReceiptID,
+			VendorID:          purchase.VendorID,
+			UserID:            userID,
+			Date:              req.ReturnDate,
+			Currency:          normalizeCurrencyCode(purchase.Currency),
+			ExchangeRate:      documentExchangeRate(purchase.Currency, purchase.ExchangeRate).InexactFloat64(),
+			Reason:            req.Reason,
+			Notes:             req.Notes,
+			Status:            models.PurchaseReturnStatusPosted,
+			ReturnItems:       items,
+		}
+		calculateReturnAmounts(purchaseReturn, &purchase)
+
+		if purchaseReturn.Code, err = s.generateNumber(tx, "PRT", "code", req.ReturnDate); err != nil {
+			return err
+		}
+		if purchaseReturn.DebitNoteNumber, err = s.generateNumber(tx, "DN", "debit_note_number", req.ReturnDate); err != nil {
+			return err
+		}
+		if err := tx.Omit(clause.Associations).Create(purchaseReturn).Error; err != nil {
+			return fmt.Errorf("failed to create purchase return: %v", err)
+		}
+		for i := range purchaseReturn.ReturnItems {
+			purchaseReturn.ReturnItems[i].PurchaseReturnID = purchaseReturn.ID
+			if err := tx.Omit(clause.Associations).Create(&purchaseReturn.ReturnItems[i]).Error; err != nil {
+				return fmt.Errorf("failed to create purchase return item: %v", err)
+			}
+		}
+
+		// Goods leave the warehouse and the receipt layers of this purchase
+		for _, item := range purchaseReturn.ReturnItems {
+			if err := s.stockService.ReduceStock(item.ProductID, item.WarehouseLocationID, item.Quantity, tx); err != nil {
+				return fmt.Errorf("failed to reduce stock for product %d: %v", item.ProductID, err)
+			}
+			_, needsRecost, err := s.costingService.ReturnReceipt(tx, item.ProductID, purchase.ID, purchaseReturn.ID,
+				item.WarehouseLocationID, item.Quantity, req.ReturnDate, fmt.Sprintf("Purchase return %s", purchaseReturn.Code))
+			if err != nil {
+				return err
+			}
+			if needsRecost {
+				recostProducts = append(recostProducts, item.ProductID)
+			}
+		}
+
+		entry, err := s.journalAdapter.CreatePurchaseReturnJournalEntry(tx, purchaseReturn, &purchase, uint64(userID))
+		if err != nil {
+			return err
+		}
+
+		if err := s.settleReturn(tx, purchaseReturn, &purchase); err != nil {
+			return err
+		}
+
+		return tx.Model(purchaseReturn).Updates(map[string]interface{}{
+			"journal_entry_id": entry.ID,
+			"applied_amount":   purchaseReturn.AppliedAmount,
+			"credit_amount":    purchaseReturn.CreditAmount,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Sales already costed from the returned units are re-costed outside the return transaction
+	if len(recostProducts) > 0 {
+		if _, err := s.costingService.RunRecost(InventoryRecostRequest{
+			ProductIDs: recostProducts,
+			EntryDate:  &req.ReturnDate,
+			Notes:      fmt.Sprintf("Purchase return %s", purchaseReturn.Code),
+		}, userID); err != nil {
+			log.Printf("⚠️ Re-costing after purchase return %s failed: %v", purchaseReturn.Code, err)
+		}
+	}
+
+	log.Printf("↩️ Purchase return %s posted for purchase #%d (debit note %s)", purchaseReturn.Code, purchaseID, purchaseReturn.DebitNoteNumber)
+	return s.GetReturnByID(purchaseReturn.ID)
+}
+
+// GenerateDebitNotePDF - Nota debit vendor untuk sebuah retur pembelian
+func (s *PurchaseReturnService) GenerateDebitNotePDF(id uint) ([]byte, *models.PurchaseReturn, error) {
+	purchaseReturn, err := s.GetReturnByID(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if s.pdfService == nil {
+		return nil, nil, errors.New("PDF service not available")
+	}
+
+	pdfBytes, err := s.pdfService.GenerateDebitNotePDF(purchaseReturn)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate debit note PDF: %v", err)
+	}
+	return pdfBytes, purchaseReturn, nil
+}
+
+// ========== VENDOR CREDITS ==========
+
+// ApplyVendorCredit - Pakai kredit vendor sebagai pembayaran pembelian berikutnya.
+// Tidak ada jurnal: kredit sudah tercatat sebagai saldo debit Hutang Usaha vendor tersebut.
+func (s *PurchaseReturnService) ApplyVendorCredit(purchaseID uint, req models.VendorCreditApplyRequest, userID uint) (*models.Purchase, error) {
+	var purchase models.Purchase
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&purchase, purchaseID).Error; err != nil {
+			return errors.New("purchase not found")
+		}
+		if purchase.Status != models.PurchaseStatusApproved && purchase.Status != models.PurchaseStatusCompleted {
+			return fmt.Errorf("cannot apply vendor credit to a purchase with status %s", purchase.Status)
+		}
+		if purchase.OutstandingAmount <= 0 {
+			return errors.New("purchase is already fully paid")
+		}
+
+		var credit models.VendorCredit
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("PurchaseReturn").
+			First(&credit, req.VendorCreditID).Error; err != nil {
+			return errors.New("vendor credit not found")
+		}
+		if credit.VendorID != purchase.VendorID {
+			return errors.New("vendor credit belongs to another vendor")
+		}
+		if normalizeCurrencyCode(credit.Currency) != normalizeCurrencyCode(purchase.Currency) {
+			return fmt.Errorf("vendor credit is in %s but the purchase is in %s", credit.Currency, purchase.Currency)
+		}
+		if credit.Status != models.VendorCreditStatusOpen || req.Amount > credit.RemainingAmount+0.005 {
+			return fmt.Errorf("amount exceeds remaining vendor credit (%.2f)", credit.RemainingAmount)
+		}
+		if req.Amount > purchase.OutstandingAmount+0.005 {
+			return fmt.Errorf("amount (%.2f) exceeds outstanding amount (%.2f)", req.Amount, purchase.OutstandingAmount)
+		}
+
+		date := req.Date
+		if date.IsZero() {
+			date = time.Now()
+		}
+
+		payment := models.PurchasePayment{
+			PurchaseID:    purchase.ID,
+			PaymentNumber: credit.PurchaseReturn.DebitNoteNumber,
+			Date:          date,
+			Amount:        req.Amount,
+			Method:        models.PurchasePaymentVendorCredit,
+			Reference:     credit.PurchaseReturn.DebitNoteNumber,
+			Notes:         req.Notes,
+			UserID:        userID,
+		}
+		if err := tx.Create(&payment).Error; err != nil {
+			return fmt.Errorf("failed to record vendor credit payment: %v", err)
+		}
+		if err := tx.Create(&models.VendorCreditApplication{
+			VendorCreditID:    credit.ID,
+			PurchaseID:        purchase.ID,
+			PurchasePaymentID: &payment.ID,
+			Amount:            req.Amount,
+			Date:              date,
+			UserID:            userID,
+		}).Error; err != nil {
+			return fmt.Errorf("failed to record vendor credit application: %v", err)
+		}
+
+		credit.UsedAmount = roundAmount(credit.UsedAmount + req.Amount)
+		credit.RemainingAmount = roundAmount(credit.Amount - credit.UsedAmount)
+		if credit.RemainingAmount <= 0.005 {
+			credit.RemainingAmount = 0
+			credit.Status = models.VendorCreditStatusUsed
+		}
+		if err := tx.Model(&credit).Updates(map[string]interface{}{
+			"used_amount":      credit.UsedAmount,
+			"remaining_amount": credit.RemainingAmount,
+			"status":           credit.Status,
+		}).Error; err != nil {
+			return fmt.Errorf("failed to update vendor credit: %v", err)
+		}
+
+		purchase.PaidAmount = roundAmount(purchase.PaidAmount + req.Amount)
+		purchase.OutstandingAmount = roundAmount(purchase.OutstandingAmount - req.Amount)
+		if purchase.OutstandingAmount <= 0.01 {
+			purchase.OutstandingAmount = 0
+			purchase.Status = models.PurchaseStatusPaid
+		}
+		return tx.Model(&purchase).Updates(map[string]interface{}{
+			"paid_amount":        purchase.PaidAmount,
+			"outstanding_amount": purchase.OutstandingAmount,
+			"status":             purchase.Status,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &purchase, nil
+}
+
+// ========== HELPERS ==========
+
+// buildReturnItems validates the requested quantities against what was purchased less all earlier
+// returns (and, for a receipt-scoped return, against what that receipt brought in less the returns
+// made on it), and prices them at the net unit price of the purchase item
+func (s *PurchaseReturnService) buildReturnItems(tx *gorm.DB, purchase *models.Purchase, req models.PurchaseReturnRequest, receiptItems map[uint]models.PurchaseReceiptItem) ([]models.PurchaseReturnItem, error) {
+	purchaseItems := make(map[uint]models.PurchaseItem, len(purchase.PurchaseItems))
+	for _, item := range purchase.PurchaseItems {
+		purchaseItems[item.ID] = item
+	}
+
+	requested := make(map[uint]int)
+	items := make([]models.PurchaseReturnItem, 0, len(req.ReturnItems))
+	for _, r := range req.ReturnItems {
+		purchaseItem, ok := purchaseItems[r.PurchaseItemID]
+		if !ok {
+			return nil, fmt.Errorf("purchase item %d does not belong to purchase %s", r.PurchaseItemID, purchase.Code)
+		}
+		requested[r.PurchaseItemID] += r.Quantity
+
+		warehouseID := purchaseItem.WarehouseLocationID
+		var receiptItem *models.PurchaseReceiptItem
+		if receiptItems != nil {
+			item, ok := receiptItems[r.PurchaseItemID]
+			if !ok {
+				return nil, fmt.Errorf("product '%s' was not received on this receipt", purchaseItem.Product.Name)
+			}
+			receiptItem = &item
+			if item.WarehouseLocationID != nil {
+				warehouseID = item.WarehouseLocationID
+			}
+		}
+		if r.WarehouseLocationID != nil {
+			warehouseID = r.WarehouseLocationID
+		}
+
+		// Every return counts against the purchased quantity, with or without a receipt, so the same
+		// goods cannot be returned once without a receipt and again against one
+		returned, err := s.returnedQuantity(tx, r.PurchaseItemID, nil)
+		if err != nil {
+			return nil, err
+		}
+		if available := purchaseItem.Quantity - returned; requested[r.PurchaseItemID] > available {
+			return nil, fmt.Errorf("cannot return %d of '%s': only %d left to return",
+				requested[r.PurchaseItemID], purchaseItem.Product.Name, available)
+		}
+
+		// A receipt-scoped return is additionally limited to what that receipt brought in
+		if receiptItem != nil {
+			returnedOnReceipt, err := s.returnedQuantity(tx, r.PurchaseItemID, req.PurchaseReceiptID)
+			if err != nil {
+				return nil, err
+			}
+			if available := receiptItem.QuantityReceived - returnedOnReceipt; requested[r.PurchaseItemID] > available {
+				return nil, fmt.Errorf("cannot return %d of '%s' against this receipt: only %d left to return",
+					requested[r.PurchaseItemID], purchaseItem.Product.Name, available)
+			}
+		}
+
+		unitPrice := 0.0
+		if purchaseItem.Quantity > 0 {
+			unitPrice = purchaseItem.TotalPrice / float64(purchaseItem.Quantity)
+		}
+		items = append(items, models.PurchaseReturnItem{
+			PurchaseItemID:      purchaseItem.ID,
+			ProductID:           purchaseItem.ProductID,
+			Quantity:            r.Quantity,
+			UnitPrice:           roundAmount(unitPrice),
+			TotalAmount:         roundAmount(unitPrice * float64(r.Quantity)),
+			WarehouseLocationID: warehouseID,
+			Reason:              r.Reason,
+			PurchaseItem:        purchaseItem,
+			Product:             purchaseItem.Product,
+		})
+	}
+	return items, nil
+}
+
+// returnedQuantity - Jumlah yang sudah diretur untuk item pembelian, opsional hanya untuk satu penerimaan
+func (s *PurchaseReturnService) returnedQuantity(tx *gorm.DB, purchaseItemID uint, receiptID *uint) (int, error) {
+	var returned int64
+	query := tx.Model(&models.PurchaseReturnItem{}).
+		Joins("JOIN purchase_returns ON purchase_returns.id = purchase_return_items.purchase_return_id AND purchase_returns.deleted_at IS NULL").
+		Where("purchase_return_items.purchase_item_id = ?", purchaseItemID)
+	if receiptID != nil {
+		query = query.Where("purchase_returns.purchase_receipt_id = ?", *receiptID)
+	}
+	if err := query.Select("COALESCE(SUM(purchase_return_items.quantity), 0)").Scan(&returned).Error; err != nil {
+		return 0, fmt.Errorf("failed to check returned quantity: %v", err)
+	}
+	return int(returned), nil
+}
+
+// calculateReturnAmounts applies the purchase PPN rate and the withholdings pro rata to the returned subtotal
+func calculateReturnAmounts(purchaseReturn *models.PurchaseReturn, purchase *models.Purchase) {
+	subtotal := 0.0
+	for _, item := range purchaseReturn.ReturnItems {
+		subtotal += item.TotalAmount
+	}
+	purchaseSubtotal := 0.0
+	for _, item := range purchase.PurchaseItems {
+		purchaseSubtotal += item.TotalPrice
+	}
+
+	purchaseReturn.Subtotal = roundAmount(subtotal)
+	if purchaseSubtotal > 0 {
+		ratio := subtotal / purchaseSubtotal
+		purchaseReturn.PPNAmount = roundAmount(purchase.PPNAmount * ratio)
+		purchaseReturn.PPh21Amount = roundAmount(purchase.PPh21Amount * ratio)
+		purchaseReturn.PPh23Amount = roundAmount(purchase.PPh23Amount * ratio)
+	}
+	purchaseReturn.TotalAmount = roundAmount(purchaseReturn.Subtotal + purchaseReturn.PPNAmount -
+		purchaseReturn.PPh21Amount - purchaseReturn.PPh23Amount)
+}
+
+// settleReturn deducts the return from the purchase outstanding amount and issues a vendor credit for the rest
+func (s *PurchaseReturnService) settleReturn(tx *gorm.DB, purchaseReturn *models.PurchaseReturn, purchase *models.Purchase) error {
+	applied := math.Min(purchaseReturn.TotalAmount, math.Max(purchase.OutstandingAmount, 0))
+	purchaseReturn.AppliedAmount = roundAmount(applied)
+	purchaseReturn.CreditAmount = roundAmount(purchaseReturn.TotalAmount - purchaseReturn.AppliedAmount)
+
+	if purchaseReturn.AppliedAmount > 0 {
+		purchase.OutstandingAmount = roundAmount(purchase.OutstandingAmount - purchaseReturn.AppliedAmount)
+		updates := map[string]interface{}{"outstanding_amount": purchase.OutstandingAmount}
+		if purchase.OutstandingAmount <= 0.01 {
+			updates["outstanding_amount"] = 0
+			if purchase.Status != models.PurchaseStatusPaid {
+				updates["status"] = models.PurchaseStatusPaid
+			}
+		}
+		if err := tx.Model(purchase).Updates(updates).Error; err != nil {
+			return fmt.Errorf("failed to update purchase outstanding amount: %v", err)
+		}
+	}
+
+	if purchaseReturn.CreditAmount > 0 {
+		credit := models.VendorCredit{
+			VendorID:         purchaseReturn.VendorID,
+			PurchaseReturnID: purchaseReturn.ID,
+			Date:             purchaseReturn.Date,
+			Currency:         purchaseReturn.Currency,
+			Amount:           purchaseReturn.CreditAmount,
+			RemainingAmount:  purchaseReturn.CreditAmount,
+			Status:           models.VendorCreditStatusOpen,
+		}
+		if err := tx.Create(&credit).Error; err != nil {
+			return fmt.Errorf("failed to create vendor credit: %v", err)
+		}
+	}
+	return nil
+}
+
+// generateNumber returns PREFIX-YYYYMM-NNNN, numbered per month within the given column
+func (s *PurchaseReturnService) generateNumber(tx *gorm.DB, prefix, column string, date time.Time) (string, error) {
+	base := fmt.Sprintf("%s-%s-", prefix, date.Format("200601"))
+	var count int64
+	if err := tx.Unscoped().Model(&models.PurchaseReturn{}).
+		Where(column+" LIKE ?", base+"%").
+		Count(&count).Error; err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s%04d", base, count+1), nil
+}
+
+func roundAmount(amount float64) float64 {
+	return decimal.NewFromFloat(amount).Round(2).InexactFloat64()
+}
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"testing"
+
+	"app-sistem-akuntansi/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalculateReturnAmounts(t *testing.T) {
+	purchase := &models.Purchase{
+		PurchaseItems: []models.PurchaseItem{
+			{ID: 1, TotalPrice: 600000},
+			{ID: 2, TotalPrice: 400000},
+		},
+		PPNAmount:   110000,
+		PPh21Amount: 0,
+		PPh23Amount: 20000,
+	}
+
+	tests := []struct {
+		name      string
+		purchase  *models.Purchase
+		items     []models.PurchaseReturnItem
+		wantSub   float64
+		wantPPN   float64
+		wantPPh21 float64
+		wantPPh23 float64
+		wantTotal float64
+	}{
+		{
+			name:      "full return carries all taxes",
+			purchase:  purchase,
+			items:     []models.PurchaseReturnItem{{TotalAmount: 600000}, {TotalAmount: 400000}},
+			wantSub:   1000000,
+			wantPPN:   110000,
+			wantPPh23: 20000,
+			wantTotal: 1090000,
+		},
+		{
+			name:      "partial return prorates taxes by subtotal",
+			purchase:  purchase,
+			items:     []models.PurchaseReturnItem{{TotalAmount: 250000}},
+			wantSub:   250000,
+			wantPPN:   27500,
+			wantPPh23: 5000,
+			wantTotal: 272500,
+		},
+		{
+			name:      "prorated taxes are rounded to cents",
+			purchase:  purchase,
+			items:     []models.PurchaseReturnItem{{TotalAmount: 333333.33}},
+			wantSub:   333333.33,
+			wantPPN:   36666.67,
+			wantPPh23: 6666.67,
+			wantTotal: 363333.33,
+		},
+		{
+			name: "PPh 21 is withheld as well",
+			purchase: &models.Purchase{
+				PurchaseItems: []models.PurchaseItem{{ID: 1, TotalPrice: 200000}},
+				PPh21Amount:   5000,
+			},
+			items:     []models.PurchaseReturnItem{{TotalAmount: 100000}},
+			wantSub:   100000,
+			wantPPh21: 2500,
+			wantTotal: 97500,
+		},
+		{
+			name:      "purchase without subtotal has no taxes",
+			purchase:  &models.Purchase{PPNAmount: 11000},
+			items:     []models.PurchaseReturnItem{{TotalAmount: 100000}},
+			wantSub:   100000,
+			wantTotal: 100000,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			purchaseReturn := &models.PurchaseReturn{ReturnItems: tt.items}
+			calculateReturnAmounts(purchaseReturn, tt.purchase)
+			assert.Equal(t, tt.wantSub, purchaseReturn.Subtotal)
+			assert.Equal(t, tt.wantPPN, purchaseReturn.PPNAmount)
+			assert.Equal(t, tt.wantPPh21, purchaseReturn.PPh21Amount)
+			assert.Equal(t, tt.wantPPh23, purchaseReturn.PPh23Amount)
+			assert.Equal(t, tt.wantTotal, purchaseReturn.TotalAmount)
+		})
+	}
+}
@@ -34,6 +34,12 @@ type PurchaseService struct {
 	journalServiceV2          *PurchaseJournalServiceV2          // Legacy (simple_ssot_journals)
 	journalServiceSSOT        *PurchaseJournalServiceSSOT        // NEW: unified_journal_ledger (for Balance Sheet)
 	coaService                *COAService
+	// Receipt layers for FIFO/Average inventory costing
+	costingService            *InventoryCostingService
+	// Per-warehouse stock balances
+	stockService              *StockService
+	// Settlement of foreign currency bills
+	currencyService           *CurrencyService
 }
 
 type PurchaseResult struct {
@@ -81,6 +87,9 @@ func NewPurchaseService(
 		journalServiceV2:          journalServiceV2,
 		journalServiceSSOT:        purchaseJournalServiceSSOT,
 		coaService:                coaService,
+		costingService:            NewInventoryCostingService(db),
+		stockService:              NewStockService(db),
+		currencyService:           NewCurrencyService(db),
 	}
 	// Asset capitalization service (reuses existing repos and unified journal)
 	ps.assetCapitalizationSvc = NewAssetCapitalizationService(db, accountRepo, unifiedJournalService, journalRepo)
@@ -88,6 +97,7 @@ func NewPurchaseService(
 	// Setup post-approval callback
 	if approvalService != nil {
 		approvalService.SetPostApprovalCallback(ps)
+		approvalService.SetApprovalTxHook(models.EntityTypePurchase, ps.applyApprovedPurchaseStock)
 		fmt.Printf("✅ Post-approval callback setup completed\n")
 	}
 	
@@ -97,7 +107,7 @@ func NewPurchaseService(
 // Purchase CRUD Operations
 
 func (s *PurchaseService) GetPurchases(filter models.PurchaseFilter) (*PurchaseResult, error) {
-	fmt.Printf("ℹ Retrieving purchases with filter: Status=%s, VendorID=%d, Page=%d, Limit=%d\n", 
+	fmt.Printf("ℹ Retrieving purchases with filter: Status=%s, VendorID=%s, Page=%d, Limit=%d\n", 
 		filter.Status, filter.VendorID, filter.Page, filter.Limit)
 	purchases, total, err := s.purchaseRepo.FindWithFilter(filter)
 	if err != nil {
@@ -153,6 +163,9 @@ func (s *PurchaseService) CreatePurchase(request models.PurchaseCreateRequest, u
 		Date:     request.Date,
 		DueDate:  request.DueDate,
 		Discount: request.Discount,
+		// Foreign currency purchases are journalized using this rate
+		Currency:     getOrDefaultStr(request.Currency, "IDR"),
+		ExchangeRate: getOrDefault(request.ExchangeRate, 1.0),
 		// Payment method fields
 		PaymentMethod:     getPaymentMethod(request.PaymentMethod),
 		BankAccountID:     request.BankAccountID,
@@ -195,6 +208,10 @@ fmt.Printf("✅ Purchase totals calculated: Subtotal=%.2f, Tax=%.2f, Total=%.2f\
 		purchase.ApprovalStatus = models.PurchaseApprovalNotRequired
 	}
 
+	if err := validatePurchaseItemDimensions(s.db, purchase.PurchaseItems); err != nil {
+		return nil, err
+	}
+
 	// Save purchase, status will remain DRAFT
 	fmt.Printf("ℹ Saving purchase to database with status DRAFT\n")
 	createdPurchase, err := s.purchaseRepo.Create(purchase)
@@ -202,6 +219,9 @@ fmt.Printf("✅ Purchase totals calculated: Subtotal=%.2f, Tax=%.2f, Total=%.2f\
 		fmt.Printf("❌ Failed to save purchase to database: %v\n", err)
 		return nil, fmt.Errorf("failed to save purchase to database: %v", err)
 	}
+	if err := savePurchaseItemTags(s.db, purchase.PurchaseItems); err != nil {
+		return nil, err
+	}
 	fmt.Printf("✅ Purchase %d saved successfully with code %s\n", createdPurchase.ID, createdPurchase.Code)
 
 		// NEW LOGIC: ALL purchases must go through approval workflow
@@ -283,6 +303,9 @@ func (s *PurchaseService) UpdatePurchase(id uint, request models.PurchaseUpdateR
 		if err != nil {
 			return nil, err
 		}
+		if err := validatePurchaseItemDimensions(s.db, purchase.PurchaseItems); err != nil {
+			return nil, err
+		}
 	}
 
 	// Recalculate totals
@@ -303,6 +326,11 @@ fmt.Printf("✅ Purchase %d totals recalculated: Subtotal=%.2f, Tax=%.2f, Total=
 		fmt.Printf("❌ Failed to save updated purchase %d: %v\n", id, err)
 		return nil, fmt.Errorf("failed to save updated purchase: %v", err)
 	}
+	if len(request.Items) > 0 {
+		if err := savePurchaseItemTags(s.db, purchase.PurchaseItems); err != nil {
+			return nil, err
+		}
+	}
 	fmt.Printf("✅ Purchase %d updated successfully\n", id)
 
 	return s.GetPurchaseByID(updatedPurchase.ID)
@@ -610,13 +638,19 @@ func (s *PurchaseService) ProcessPurchaseApprovalWithEscalation(purchaseID uint,
 			purchase.TotalAmount, purchase.OutstandingAmount, purchase.PaidAmount)
 	}
 
-	_, err = s.purchaseRepo.Update(purchase)
+	// Approval and stock/cost layers commit together; a failed receipt layer fails the approval
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(purchase).Error; err != nil {
+			return err
+		}
+		return s.updateProductStockOnApproval(tx, purchase)
+	})
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to approve purchase: %v", err)
 	}
 
-	// NOTE: Stock updates, journal entries, and cash/bank balance updates
-	// are now handled by OnPurchaseApproved callback above
+	// NOTE: Journal entries, cash/bank balance updates and re-costing
+	// are handled by OnPurchaseApproved callback below
 
 	// ✅ FIXED: Call OnPurchaseApproved callback for complete post-approval processing
 	// This ensures cash bank transactions, stock updates, and journal entries are all handled correctly
@@ -708,10 +742,16 @@ func (s *PurchaseService) CreatePurchaseReceipt(request models.PurchaseReceiptRe
 			ReceiptID:        createdReceipt.ID,
 			PurchaseItemID:   itemReq.PurchaseItemID,
 			QuantityReceived: itemReq.QuantityReceived,
+			WarehouseLocationID: itemReq.WarehouseLocationID,
 			Condition:        s.getDefaultCondition(itemReq.Condition),
 			Notes:            itemReq.Notes,
 		}
 
+		// Goods received into another warehouse than ordered
+		if err := s.applyReceiptWarehouse(purchase, purchaseItem, itemReq); err != nil {
+			return nil, err
+		}
+
 		err = s.purchaseRepo.CreateReceiptItem(receiptItem)
 		if err != nil {
 			return nil, err
@@ -1019,7 +1059,9 @@ func (s *PurchaseService) GenerateAllReceiptsPDF(purchaseID uint) ([]byte, *mode
 
 // PostApprovalCallback Implementation
 
-// OnPurchaseApproved implements PostApprovalCallback interface - handles business logic after purchase approval
+// OnPurchaseApproved implements PostApprovalCallback interface - handles business logic after purchase approval.
+// It runs after the approval commits; stock and cost layers are booked inside the approval transaction
+// (updateProductStockOnApproval), so only re-costing, journals and payment tracking happen here.
 func (s *PurchaseService) OnPurchaseApproved(purchaseID uint) error {
 	fmt.Printf("📦 Starting post-approval processing for purchase %d\n", purchaseID)
 	
@@ -1031,15 +1073,9 @@ func (s *PurchaseService) OnPurchaseApproved(purchaseID uint) error {
 	
 	fmt.Printf("💰 Processing approved purchase %s (method: %s, amount: %.2f)\n", purchase.Code, purchase.PaymentMethod, purchase.TotalAmount)
 	
-	// 1. Update product stock
-	fmt.Printf("🔄 Updating product stock for approved purchase %d\n", purchaseID)
-	err = s.updateProductStockOnApproval(purchase)
-	if err != nil {
-		fmt.Printf("⚠️ Warning: Failed to update product stock: %v\n", err)
-		// Continue processing even if stock update fails
-	} else {
-		fmt.Printf("✅ Successfully updated product stock\n")
-	}
+	// 1. Stock and cost layers were booked inside the approval transaction; re-cost later sales
+	// now that the receipt is committed
+	s.recostBackDatedPurchase(purchase)
 	
 	// 2. Create journal entries - PRIORITIZE SSOT (no double posting)
 	fmt.Printf("🏗️ Creating journal entries for approved purchase %s\n", purchase.Code)
@@ -1144,6 +1180,15 @@ func (s *PurchaseService) createApprovalRequest(purchase *models.Purchase, prior
 		Priority:       priority,
 		RequestTitle:   fmt.Sprintf("Purchase Approval - %s (Vendor: %s)", purchase.Code, vendorName),
 		RequestMessage: fmt.Sprintf("Approval request for purchase %s with base amount %.2f (basis: %s)", purchase.Code, purchase.ApprovalBaseAmount, purchase.ApprovalAmountBasis),
+		ContactID:      &purchase.VendorID,
+	}
+
+	// Routing context for conditional workflows (vendor category, expense account, cost center)
+	for _, item := range purchase.PurchaseItems {
+		approvalReq.AccountIDs = appendApprovalID(approvalReq.AccountIDs, item.ExpenseAccountID)
+		if item.CostCenterID != nil {
+			approvalReq.CostCenterIDs = appendApprovalID(approvalReq.CostCenterIDs, *item.CostCenterID)
+		}
 	}
 
 	// Determine priority based on amount
@@ -1368,7 +1413,8 @@ func (s *PurchaseService) purchaseHasJournalEntries(purchaseID uint) (bool, erro
 
 // Purchase Payment Integration Methods
 
-// CreateIntegratedPayment creates a payment in both Purchase and Payment Management systems
+// CreateIntegratedPayment creates a payment in both Purchase and Payment Management systems.
+// exchangeRate overrides the rate table for a foreign currency bill.
 func (s *PurchaseService) CreateIntegratedPayment(
 	purchaseID uint,
 	amount float64,
@@ -1377,6 +1423,7 @@ func (s *PurchaseService) CreateIntegratedPayment(
 	cashBankID *uint,
 	reference string,
 	notes string,
+	exchangeRate *float64,
 	userID uint,
 ) (map[string]interface{}, error) {
 	// Start transaction
@@ -1415,9 +1462,16 @@ func (s *PurchaseService) CreateIntegratedPayment(
 		return nil, errors.New("payment amount must be greater than zero")
 	}
 
-	if amount > purchase.OutstandingAmount {
+	// Foreign currency bill: the payment is in base currency, the outstanding in bill currency
+	settlement, err := s.currencyService.NewDocumentSettlement(tx, models.FXItemTypePayable, purchase.Code,
+		purchase.Currency, purchase.ExchangeRate, purchase.OutstandingAmount, date, exchangeRate)
+	if err != nil {
 		tx.Rollback()
-		return nil, fmt.Errorf("payment amount (%.2f) exceeds outstanding amount (%.2f)", amount, purchase.OutstandingAmount)
+		return nil, fmt.Errorf("bill %v", err)
+	}
+	if amount > settlement.OutstandingBase {
+		tx.Rollback()
+		return nil, fmt.Errorf("payment amount (%.2f) exceeds outstanding amount (%.2f)", amount, settlement.OutstandingBase)
 	}
 
 	// Validate cash/bank account is provided
@@ -1442,6 +1496,9 @@ func (s *PurchaseService) CreateIntegratedPayment(
 		Status:    models.PaymentStatusCompleted,
 		Notes:     fmt.Sprintf("Payment for purchase %s. %s", purchase.Code, notes),
 	}
+	if settlement.IsForeign() {
+		payment.ExchangeRate = settlement.Rate.InexactFloat64()
+	}
 	
 	if err := tx.Create(payment).Error; err != nil {
 		tx.Rollback()
@@ -1481,8 +1538,9 @@ func (s *PurchaseService) CreateIntegratedPayment(
 	}
 
 	// Update purchase payment tracking
-	purchase.PaidAmount += amount
-	purchase.OutstandingAmount -= amount
+	settledAmount, fxSettlement := settlement.Settle(amount)
+	purchase.PaidAmount += settledAmount
+	purchase.OutstandingAmount = settledOutstanding(purchase.OutstandingAmount, settledAmount, fxSettlement)
 
 	// Update purchase status if fully paid
 	if purchase.OutstandingAmount <= 0.01 { // Allow for rounding errors
@@ -1542,6 +1600,11 @@ func (s *PurchaseService) CreateIntegratedPayment(
 			tx.Rollback()
 			return nil, fmt.Errorf("failed to create journal entries: %v", err)
 		}
+		// Realized FX gain/loss against the rate the bill was booked at
+		if _, err := s.currencyService.PostRealizedFXGainLoss(tx, fxSettlement, payment.ID, payment.Code, date, userID); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
 	} else {
 		// Handle case where no cash/bank account specified
 		// This shouldn't happen in normal flow but we need to handle it gracefully
@@ -1595,16 +1658,46 @@ func isImmediatePayment(paymentMethod string) bool {
 		paymentMethod == models.PurchasePaymentCheck
 }
 
-// updateProductStockOnApproval updates product stock when purchase is approved
-func (s *PurchaseService) updateProductStockOnApproval(purchase *models.Purchase) error {
+// applyReceiptWarehouse handles a receipt line that names a different warehouse than its purchase item.
+// Before approval the purchase item is re-pointed so approval books the stock there; after approval
+// the received quantity is moved from the warehouse it was booked on.
+func (s *PurchaseService) applyReceiptWarehouse(purchase *models.Purchase, purchaseItem *models.PurchaseItem, itemReq models.PurchaseReceiptItemRequest) error {
+	if itemReq.WarehouseLocationID == nil || *itemReq.WarehouseLocationID == 0 {
+		return nil
+	}
+
+	if purchase.Status == models.PurchaseStatusPending {
+		return s.db.Model(&models.PurchaseItem{}).Where("id = ?", purchaseItem.ID).
+			Update("warehouse_location_id", *itemReq.WarehouseLocationID).Error
+	}
+
+	var product models.Product
+	if err := s.db.First(&product, purchaseItem.ProductID).Error; err != nil {
+		return fmt.Errorf("product %d not found: %v", purchaseItem.ProductID, err)
+	}
+	if product.IsService {
+		return nil
+	}
+	bookedOn := resolveWarehouseID(&product, purchaseItem.WarehouseLocationID)
+	if bookedOn == nil || *bookedOn == *itemReq.WarehouseLocationID {
+		return nil
+	}
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		return s.stockService.MoveWarehouseStock(tx, product.ID, *bookedOn, *itemReq.WarehouseLocationID, itemReq.QuantityReceived)
+	})
+}
+
+// updateProductStockOnApproval books the purchased quantity on stock, the warehouse and the costing
+// receipt layers inside the approval transaction, so an approved purchase always has its cost layers.
+// Any failure is returned and rolls the approval back.
+func (s *PurchaseService) updateProductStockOnApproval(tx *gorm.DB, purchase *models.Purchase) error {
 	fmt.Printf("📦 Starting stock update for purchase %s with %d items\n", purchase.Code, len(purchase.PurchaseItems))
 	
 	for _, item := range purchase.PurchaseItems {
 		// Get current product data
-		product, err := s.productRepo.FindByID(item.ProductID)
-		if err != nil {
-			fmt.Printf("❌ Error finding product %d: %v\n", item.ProductID, err)
-			continue // Skip this item but continue with others
+		var product models.Product
+		if err := tx.First(&product, item.ProductID).Error; err != nil {
+			return fmt.Errorf("product %d not found: %v", item.ProductID, err)
 		}
 		
 		fmt.Printf("📋 Product %d (%s): Current stock = %d, Adding quantity = %d\n", 
@@ -1617,11 +1710,11 @@ func (s *PurchaseService) updateProductStockOnApproval(purchase *models.Purchase
 		// Update cost price using weighted average if we have existing stock
 		if oldStock > 0 {
 			// Weighted average: (old_stock * old_price + new_qty * new_price) / total_qty
+			oldPrice := product.PurchasePrice
 			totalValue := (float64(oldStock) * product.PurchasePrice) + (float64(item.Quantity) * item.UnitPrice)
 			totalQuantity := oldStock + item.Quantity
 			product.PurchasePrice = totalValue / float64(totalQuantity)
-			fmt.Printf("💰 Updated weighted average price: %.2f (was %.2f)\n", 
-				product.PurchasePrice, (float64(oldStock) * product.PurchasePrice) / float64(oldStock))
+			fmt.Printf("💰 Updated weighted average price: %.2f (was %.2f)\n", product.PurchasePrice, oldPrice)
 		} else {
 			// If no existing stock, use new price
 			product.PurchasePrice = item.UnitPrice
@@ -1629,19 +1722,80 @@ func (s *PurchaseService) updateProductStockOnApproval(purchase *models.Purchase
 		}
 		
 		// Save updated product
-		err = s.productRepo.Update(context.Background(), product)
-		if err != nil {
-			fmt.Printf("❌ Failed to update product %d stock: %v\n", product.ID, err)
+		if err := tx.Model(&models.Product{}).Where("id = ?", product.ID).Updates(map[string]interface{}{
+			"stock":          product.Stock,
+			"purchase_price": product.PurchasePrice,
+		}).Error; err != nil {
 			return fmt.Errorf("failed to update stock for product %d: %v", product.ID, err)
 		}
 		
 		fmt.Printf("✅ Product %d stock updated: %d → %d\n", product.ID, oldStock, product.Stock)
+
+		// Book the received quantity on the item's warehouse (or the product's default location)
+		if err := s.stockService.SyncWarehouseStock(tx, product.ID, item.WarehouseLocationID, item.Quantity); err != nil {
+			return fmt.Errorf("failed to update warehouse stock for product %d: %v", product.ID, err)
+		}
+
+		// Record the receipt layer consumed later by FIFO/Average costing, valued in base currency
+		lineCost := decimal.NewFromFloat(item.TotalPrice)
+		if lineCost.IsZero() {
+			lineCost = decimal.NewFromFloat(item.UnitPrice).Mul(decimal.NewFromInt(int64(item.Quantity)))
+		}
+		lineCost = convertToBaseCurrency(lineCost, documentExchangeRate(purchase.Currency, purchase.ExchangeRate))
+		if _, _, err := s.costingService.RecordReceipt(tx, product.ID, models.InventoryRefPurchase, purchase.ID,
+			item.Quantity, lineCost, purchase.Date, purchase.Code); err != nil {
+			return fmt.Errorf("failed to record receipt layer for product %d: %v", product.ID, err)
+		}
 	}
 	
 	fmt.Printf("🎉 Stock update completed for purchase %s\n", purchase.Code)
 	return nil
 }
 
+// applyApprovedPurchaseStock is the approval transaction hook of purchases approved through the workflow
+func (s *PurchaseService) applyApprovedPurchaseStock(tx *gorm.DB, purchaseID uint) error {
+	var purchase models.Purchase
+	if err := tx.Preload("PurchaseItems").First(&purchase, purchaseID).Error; err != nil {
+		return fmt.Errorf("purchase %d not found: %v", purchaseID, err)
+	}
+	return s.updateProductStockOnApproval(tx, &purchase)
+}
+
+// recostBackDatedPurchase re-costs sales dated after a purchase once its approval has been committed.
+// A back-dated receipt changes the cost of sales already valued after its date.
+func (s *PurchaseService) recostBackDatedPurchase(purchase *models.Purchase) {
+	var recostProductIDs []uint
+	for _, productID := range uniqueUints(purchaseProductIDs(purchase)) {
+		backDated, err := s.costingService.HasSalesCostedAfter(nil, productID, purchase.Date)
+		if err != nil {
+			fmt.Printf("⚠️ Warning: Failed to check back-dated receipt for product %d: %v\n", productID, err)
+			continue
+		}
+		if backDated {
+			recostProductIDs = append(recostProductIDs, productID)
+		}
+	}
+	if len(recostProductIDs) == 0 {
+		return
+	}
+
+	fmt.Printf("🔁 Purchase %s is back-dated, re-costing %d products\n", purchase.Code, len(recostProductIDs))
+	if _, err := s.costingService.RunRecost(InventoryRecostRequest{
+		ProductIDs: recostProductIDs,
+		Notes:      fmt.Sprintf("Pembelian mundur tanggal %s", purchase.Code),
+	}, purchase.UserID); err != nil {
+		fmt.Printf("⚠️ Warning: Failed to re-cost sales after back-dated purchase %s: %v\n", purchase.Code, err)
+	}
+}
+
+func purchaseProductIDs(purchase *models.Purchase) []uint {
+	ids := make([]uint, 0, len(purchase.PurchaseItems))
+	for _, item := range purchase.PurchaseItems {
+		ids = append(ids, item.ProductID)
+	}
+	return ids
+}
+
 // createPaymentTrackingForCreditPurchase creates clean accounts payable tracking for approved credit purchases
 // FIXED: No longer creates dummy PAYABLE records that pollute payment reports
 func (s *PurchaseService) createPaymentTrackingForCreditPurchase(purchase *models.Purchase, userID uint) error {
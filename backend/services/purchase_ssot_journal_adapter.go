@@ -3,6 +3,7 @@ package services
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"app-sistem-akuntansi/models"
@@ -184,6 +185,132 @@ func (adapter *PurchaseSSOTJournalAdapter) CreatePurchasePaymentJournalEntry(
 	return entry, nil
 }
 
+// CreatePurchaseReturnJournalEntry posts the reversal for goods returned to the vendor:
+// Dr Accounts Payable (and the withheld PPh), Cr Inventory/Expense per item and PPN Masukan.
+// Amounts are taken in the purchase currency and converted at the purchase rate.
+func (adapter *PurchaseSSOTJournalAdapter) CreatePurchaseReturnJournalEntry(
+	tx *gorm.DB,
+	purchaseReturn *models.PurchaseReturn,
+	purchase *models.Purchase,
+	userID uint64,
+) (*models.SSOTJournalEntry, error) {
+	if adapter.unifiedJournalService == nil {
+		return nil, fmt.Errorf("unified journal service not available")
+	}
+
+	accountIDs, err := adapter.getPurchaseAccountIDs()
+	if err != nil {
+		return nil, err
+	}
+
+	payable := decimal.NewFromFloat(purchaseReturn.TotalAmount)
+	lines := []JournalLineRequest{
+		{
+			AccountID:   accountIDs.AccountsPayableID,
+			Description: fmt.Sprintf("Debit Note %s - %s", purchaseReturn.DebitNoteNumber, purchase.Vendor.Name),
+			DebitAmount: payable,
+		},
+	}
+
+	// Withholdings were credited on the purchase; reverse them pro rata
+	withholdings := []struct {
+		accountID   uint64
+		amount      float64
+		description string
+	}{
+		{accountIDs.PPh21PayableID, purchaseReturn.PPh21Amount, "PPh 21 Withholding Reversal"},
+		{accountIDs.PPh23PayableID, purchaseReturn.PPh23Amount, "PPh 23 Withholding Reversal"},
+	}
+	for _, w := range withholdings {
+		if w.amount <= 0 {
+			continue
+		}
+		if w.accountID == 0 {
+			lines[0].DebitAmount = lines[0].DebitAmount.Add(decimal.NewFromFloat(w.amount))
+			continue
+		}
+		lines = append(lines, JournalLineRequest{
+			AccountID:   w.accountID,
+			Description: w.description,
+			DebitAmount: decimal.NewFromFloat(w.amount),
+		})
+	}
+
+	for _, item := range purchaseReturn.ReturnItems {
+		accountID, err := adapter.resolveReturnItemAccountID(tx, &item.PurchaseItem, accountIDs)
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, JournalLineRequest{
+			AccountID:    accountID,
+			Description:  fmt.Sprintf("Purchase Return - %s", item.Product.Name),
+			CreditAmount: decimal.NewFromFloat(item.TotalAmount),
+		})
+	}
+
+	if purchaseReturn.PPNAmount > 0 {
+		lines = append(lines, JournalLineRequest{
+			AccountID:    accountIDs.PPNInputAccountID,
+			Description:  "PPN Masukan (Input VAT) Reversal",
+			CreditAmount: decimal.NewFromFloat(purchaseReturn.PPNAmount),
+		})
+	}
+
+	// Foreign currency return: convert at the purchase rate, absorbing rounding on the payable line
+	if isForeignCurrency(purchaseReturn.Currency) {
+		rate := documentExchangeRate(purchaseReturn.Currency, purchaseReturn.ExchangeRate)
+		diff := decimal.Zero
+		for i := range lines {
+			lines[i].DebitAmount = convertToBaseCurrency(lines[i].DebitAmount, rate)
+			lines[i].CreditAmount = convertToBaseCurrency(lines[i].CreditAmount, rate)
+			diff = diff.Add(lines[i].CreditAmount).Sub(lines[i].DebitAmount)
+		}
+		lines[0].DebitAmount = lines[0].DebitAmount.Add(diff)
+	}
+
+	req := &JournalEntryRequest{
+		SourceType:  models.SSOTSourceTypeAdjustment,
+		SourceID:    uint64(purchaseReturn.ID),
+		Reference:   purchaseReturn.Code,
+		EntryDate:   purchaseReturn.Date,
+		Description: fmt.Sprintf("Purchase Return %s for %s - %s", purchaseReturn.Code, purchase.Code, purchase.Vendor.Name),
+		Lines:       lines,
+		AutoPost:    true,
+		CreatedBy:   userID,
+	}
+
+	entry, err := adapter.unifiedJournalService.CreateJournalEntryWithTx(tx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create purchase return journal entry: %v", err)
+	}
+	return entry, nil
+}
+
+// resolveReturnItemAccountID returns the account a purchase item was debited to: the configured
+// fixed asset account, the inventory account for stock items, otherwise the item expense account
+func (adapter *PurchaseSSOTJournalAdapter) resolveReturnItemAccountID(
+	tx *gorm.DB,
+	item *models.PurchaseItem,
+	accountIDs *SSOTPurchaseAccountIDs,
+) (uint64, error) {
+	if item.ExpenseAccountID != 0 {
+		var account models.Account
+		if err := tx.First(&account, item.ExpenseAccountID).Error; err == nil &&
+			strings.EqualFold(account.Category, models.CategoryFixedAsset) {
+			return uint64(account.ID), nil
+		}
+	}
+
+	var product models.Product
+	if err := tx.Select("id", "is_service").First(&product, item.ProductID).Error; err != nil {
+		return 0, fmt.Errorf("product %d not found: %v", item.ProductID, err)
+	}
+	if !product.IsService || item.ExpenseAccountID == 0 {
+		return accountIDs.InventoryAccountID, nil
+	}
+	return uint64(item.ExpenseAccountID), nil
+}
+
 // resolveCashBankAccountID resolves the account ID to use for cash/bank side
 func (adapter *PurchaseSSOTJournalAdapter) resolveCashBankAccountID(bankAccountID uint64) (uint64, error) {
 	if bankAccountID > 0 {
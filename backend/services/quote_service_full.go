@@ -1,10 +1,13 @@
 package services
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"log"
 	"time"
 	"math"
+	"strings"
 	"gorm.io/gorm"
 	"app-sistem-akuntansi/models"
 	"app-sistem-akuntansi/repositories"
@@ -115,6 +118,7 @@ func (s *QuoteServiceFull) CreateQuote(request models.QuoteCreateRequest, userID
 	quote := &models.Quote{
 		Code:               code,
 		CustomerID:         request.CustomerID,
+		SalesPersonID:      request.SalesPersonID,
 		UserID:             userID,
 		Date:               request.Date,
 		ValidUntil:         request.ValidUntil,
@@ -127,6 +131,7 @@ func (s *QuoteServiceFull) CreateQuote(request models.QuoteCreateRequest, userID
 		Status:             models.QuoteStatusDraft,
 		Notes:              request.Notes,
 		Terms:              request.Terms,
+		Revision:           1,
 		ConvertedToInvoice: false,
 	}
 
@@ -154,7 +159,7 @@ func (s *QuoteServiceFull) GetQuotes(filter models.QuoteFilter) (*QuoteResult, e
 	var quotes []models.Quote
 	var total int64
 
-	query := s.db.Model(&models.Quote{}).Preload("Customer").Preload("User").Preload("QuoteItems.Product")
+	query := s.db.Model(&models.Quote{}).Preload("Customer").Preload("User").Preload("SalesPerson").Preload("QuoteItems.Product")
 
 	// Apply filters
 	if filter.Status != "" {
@@ -198,13 +203,18 @@ func (s *QuoteServiceFull) GetQuotes(filter models.QuoteFilter) (*QuoteResult, e
 // GetQuoteByID returns a single quote by ID
 func (s *QuoteServiceFull) GetQuoteByID(id uint) (*models.Quote, error) {
 	var quote models.Quote
-	if err := s.db.Preload("Customer").Preload("User").Preload("QuoteItems.Product").First(&quote, id).Error; err != nil {
+	err := s.db.Preload("Customer").Preload("User").Preload("SalesPerson").Preload("QuoteItems.Product").
+		Preload("Revisions", func(db *gorm.DB) *gorm.DB { return db.Order("revision DESC") }).
+		Preload("Revisions.User").
+		First(&quote, id).Error
+	if err != nil {
 		return nil, fmt.Errorf("quote not found (ID: %d): %v", id, err)
 	}
 	return &quote, nil
 }
 
-// UpdateQuote updates an existing quote
+// UpdateQuote updates an existing quote. Amending a quote that was already sent to the customer
+// keeps the sent version as a revision and bumps the revision number.
 func (s *QuoteServiceFull) UpdateQuote(id uint, request models.QuoteUpdateRequest, userID uint) (*models.Quote, error) {
 	quote, err := s.GetQuoteByID(id)
 	if err != nil {
@@ -212,7 +222,7 @@ func (s *QuoteServiceFull) UpdateQuote(id uint, request models.QuoteUpdateReques
 	}
 
 	// Check if quote can be updated
-	if quote.Status != models.QuoteStatusDraft {
+	if quote.Status != models.QuoteStatusDraft && quote.Status != models.QuoteStatusSent {
 		return nil, fmt.Errorf("quote cannot be updated in current status: %s", quote.Status)
 	}
 
@@ -222,44 +232,96 @@ func (s *QuoteServiceFull) UpdateQuote(id uint, request models.QuoteUpdateReques
 		return nil, fmt.Errorf("failed to get settings for calculations: %v", err)
 	}
 
-	// Update fields if provided
-	if request.CustomerID != nil {
-		quote.CustomerID = *request.CustomerID
-	}
-	if request.Date != nil {
-		quote.Date = *request.Date
-	}
-	if request.ValidUntil != nil {
-		quote.ValidUntil = *request.ValidUntil
-	}
-	if request.Discount != nil {
-		quote.Discount = *request.Discount
-	}
-	if request.Notes != nil {
-		quote.Notes = *request.Notes
-	}
-	if request.Terms != nil {
-		quote.Terms = *request.Terms
-	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		// Snapshot the version the customer has before changing it
+		quote.Revisions = nil
+		if quote.Status == models.QuoteStatusSent {
+			snapshot, err := json.Marshal(quote)
+			if err != nil {
+				return fmt.Errorf("failed to snapshot quote: %v", err)
+			}
+			revision := models.QuoteRevision{
+				QuoteID:     quote.ID,
+				Revision:    quote.Revision,
+				Snapshot:    string(snapshot),
+				TotalAmount: quote.TotalAmount,
+				Reason:      request.RevisionReason,
+				UserID:      userID,
+			}
+			if err := tx.Create(&revision).Error; err != nil {
+				return fmt.Errorf("failed to save quote revision: %v", err)
+			}
+			quote.Revision++
+			fmt.Printf("📝 Quote %s amended after sending, now revision %d\n", quote.Code, quote.Revision)
+		}
 
-	// Update items if provided
-	if len(request.Items) > 0 {
-		// Delete existing items
-		s.db.Where("quote_id = ?", quote.ID).Delete(&models.QuoteItem{})
-		
-		// Recalculate with new items
-		itemRequests := make([]models.QuoteItemCreateRequest, len(request.Items))
-		copy(itemRequests, request.Items)
-		
-		err = s.calculateQuoteTotals(quote, itemRequests, settings)
-		if err != nil {
-			return nil, fmt.Errorf("failed to recalculate quote totals: %v", err)
+		// Update fields if provided
+		if request.CustomerID != nil {
+			quote.CustomerID = *request.CustomerID
+			quote.Customer = models.Contact{}
+		}
+		if request.SalesPersonID != nil {
+			quote.SalesPersonID = request.SalesPersonID
+			quote.SalesPerson = nil
+		}
+		if request.Date != nil {
+			quote.Date = *request.Date
+		}
+		if request.ValidUntil != nil {
+			quote.ValidUntil = *request.ValidUntil
+		}
+		if request.Discount != nil {
+			quote.Discount = *request.Discount
+		}
+		if request.PPNRate != nil {
+			quote.PPNRate = request.PPNRate
+		}
+		if request.PPh21Rate != nil {
+			quote.PPh21Rate = request.PPh21Rate
+		}
+		if request.PPh23Rate != nil {
+			quote.PPh23Rate = request.PPh23Rate
+		}
+		if request.OtherTaxAdditions != nil {
+			quote.OtherTaxAdditions = request.OtherTaxAdditions
+		}
+		if request.OtherTaxDeductions != nil {
+			quote.OtherTaxDeductions = request.OtherTaxDeductions
+		}
+		if request.Notes != nil {
+			quote.Notes = *request.Notes
+		}
+		if request.Terms != nil {
+			quote.Terms = *request.Terms
 		}
-	}
 
-	// Save updated quote
-	if err := s.db.Save(quote).Error; err != nil {
-		return nil, fmt.Errorf("failed to update quote: %v", err)
+		// Recalculate with the new items, or the current ones when only the header changed
+		itemRequests := request.Items
+		if len(itemRequests) == 0 {
+			for _, item := range quote.QuoteItems {
+				itemRequests = append(itemRequests, models.QuoteItemCreateRequest{
+					ProductID:   item.ProductID,
+					Quantity:    item.Quantity,
+					UnitPrice:   item.UnitPrice,
+					Description: item.Description,
+				})
+			}
+		}
+		if err := tx.Where("quote_id = ?", quote.ID).Delete(&models.QuoteItem{}).Error; err != nil {
+			return fmt.Errorf("failed to replace quote items: %v", err)
+		}
+		if err := s.calculateQuoteTotals(quote, itemRequests, settings); err != nil {
+			return fmt.Errorf("failed to recalculate quote totals: %v", err)
+		}
+
+		// Save updated quote
+		if err := tx.Omit("Customer", "User", "SalesPerson", "Invoice", "Sale").Save(quote).Error; err != nil {
+			return fmt.Errorf("failed to update quote: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return s.GetQuoteByID(quote.ID)
@@ -276,6 +338,9 @@ func (s *QuoteServiceFull) DeleteQuote(id uint) error {
 	if quote.ConvertedToInvoice {
 		return fmt.Errorf("cannot delete quote that has been converted to invoice")
 	}
+	if quote.SaleID != nil {
+		return fmt.Errorf("cannot delete quote that has been converted to a sale")
+	}
 
 	// Delete quote (cascade will delete items)
 	if err := s.db.Delete(quote).Error; err != nil {
@@ -300,6 +365,9 @@ func (s *QuoteServiceFull) ConvertToInvoice(quoteID uint, userID uint, invoiceSe
 	if quote.ConvertedToInvoice {
 		return nil, fmt.Errorf("quote has already been converted to an invoice")
 	}
+	if quote.SaleID != nil {
+		return nil, fmt.Errorf("quote has already been converted to a sale")
+	}
 
 	// Create invoice request from quote
 	invoiceRequest := models.InvoiceCreateRequest{
@@ -344,6 +412,314 @@ func (s *QuoteServiceFull) ConvertToInvoice(quoteID uint, userID uint, invoiceSe
 	return invoice, nil
 }
 
+// quoteStatusTransitions lists the statuses a quote may move to by hand. EXPIRED is only set by
+// the expiry job.
+var quoteStatusTransitions = map[string][]string{
+	models.QuoteStatusDraft:    {models.QuoteStatusSent, models.QuoteStatusCancelled},
+	models.QuoteStatusSent:     {models.QuoteStatusAccepted, models.QuoteStatusRejected, models.QuoteStatusCancelled},
+	models.QuoteStatusAccepted: {models.QuoteStatusCancelled},
+}
+
+// UpdateQuoteStatus sends, accepts, rejects or cancels a quote
+func (s *QuoteServiceFull) UpdateQuoteStatus(id uint, request models.QuoteStatusRequest, userID uint) (*models.Quote, error) {
+	quote, err := s.GetQuoteByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	status := strings.ToUpper(strings.TrimSpace(request.Status))
+	allowed := false
+	for _, next := range quoteStatusTransitions[quote.Status] {
+		if next == status {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return nil, fmt.Errorf("quote cannot move from %s to %s", quote.Status, status)
+	}
+
+	switch status {
+	case models.QuoteStatusAccepted:
+		if dateOnly(quote.ValidUntil).Before(dateOnly(time.Now())) {
+			return nil, fmt.Errorf("quote %s expired on %s, amend the validity date before accepting it", quote.Code, quote.ValidUntil.Format("2006-01-02"))
+		}
+	case models.QuoteStatusRejected, models.QuoteStatusCancelled:
+		if strings.TrimSpace(request.Reason) == "" {
+			return nil, fmt.Errorf("reason is required to mark a quote %s", strings.ToLower(status))
+		}
+		if quote.SaleID != nil || quote.ConvertedToInvoice {
+			return nil, fmt.Errorf("quote %s has already been converted", quote.Code)
+		}
+	}
+
+	now := time.Now()
+	if err := s.db.Model(&models.Quote{}).Where("id = ?", quote.ID).Updates(map[string]interface{}{
+		"status":            status,
+		"status_reason":     request.Reason,
+		"status_changed_at": now,
+	}).Error; err != nil {
+		return nil, fmt.Errorf("failed to update quote status: %v", err)
+	}
+	log.Printf("📋 Quote %s: %s -> %s by user %d", quote.Code, quote.Status, status, userID)
+
+	return s.GetQuoteByID(quote.ID)
+}
+
+// ConvertToSale creates a sales order from an accepted quote. Items, discount, taxes, customer and
+// sales person are carried across; the quote and the sale point at each other.
+func (s *QuoteServiceFull) ConvertToSale(quoteID uint, request models.QuoteConvertToSaleRequest, userID uint, salesService *SalesServiceV2) (*models.Sale, error) {
+	if salesService == nil {
+		return nil, fmt.Errorf("sales service is not available")
+	}
+
+	quote, err := s.GetQuoteByID(quoteID)
+	if err != nil {
+		return nil, err
+	}
+	if quote.Status != models.QuoteStatusAccepted {
+		return nil, fmt.Errorf("only accepted quotes can be converted to sales")
+	}
+	if quote.SaleID != nil {
+		return nil, fmt.Errorf("quote has already been converted to a sale")
+	}
+	if quote.ConvertedToInvoice {
+		return nil, fmt.Errorf("quote has already been converted to an invoice")
+	}
+
+	settings, err := s.settingsService.GetSettings()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get settings: %v", err)
+	}
+
+	date := time.Now()
+	if request.Date != nil {
+		date = *request.Date
+	}
+	dueDate := date.AddDate(0, 0, 30) // Default 30 days, same as invoice conversion
+	if request.DueDate != nil {
+		dueDate = *request.DueDate
+	}
+
+	// Quote discount is an amount on the subtotal, sales carry it as a percentage
+	discountPercent := 0.0
+	if quote.SubtotalBeforeDiscount > 0 && quote.Discount > 0 {
+		discountPercent = math.Round(quote.Discount/quote.SubtotalBeforeDiscount*10000) / 100
+	}
+	ppnRate := settings.DefaultTaxRate
+	if quote.PPNRate != nil {
+		ppnRate = *quote.PPNRate
+	}
+
+	saleRequest := models.SaleCreateRequest{
+		CustomerID:         quote.CustomerID,
+		SalesPersonID:      quote.SalesPersonID,
+		InvoiceTypeID:      request.InvoiceTypeID,
+		Type:               models.SaleTypeOrder,
+		Date:               date,
+		DueDate:            dueDate,
+		ValidUntil:         &quote.ValidUntil,
+		DiscountPercent:    discountPercent,
+		PPNPercent:         &ppnRate,
+		PPNRate:            ppnRate,
+		PPh21Rate:          valueOrZero(quote.PPh21Rate),
+		PPh23Rate:          valueOrZero(quote.PPh23Rate),
+		OtherTaxAdditions:  valueOrZero(quote.OtherTaxAdditions),
+		OtherTaxDeductions: valueOrZero(quote.OtherTaxDeductions),
+		PaymentTerms:       request.PaymentTerms,
+		PaymentMethodType:  firstNonEmpty(request.PaymentMethodType, "CREDIT"),
+		CashBankID:         request.CashBankID,
+		ShippingAddress:    request.ShippingAddress,
+		Notes:              quote.Notes,
+		InternalNotes:      fmt.Sprintf("Converted from Quote %s (revision %d)", quote.Code, quote.Revision),
+		Reference:          firstNonEmpty(request.Reference, quote.Code),
+	}
+	for _, item := range quote.QuoteItems {
+		saleRequest.Items = append(saleRequest.Items, models.SaleItemRequest{
+			ProductID:   item.ProductID,
+			Description: item.Description,
+			Quantity:    float64(item.Quantity),
+			UnitPrice:   item.UnitPrice,
+		})
+	}
+
+	sale, err := salesService.CreateSale(saleRequest, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create sale from quote: %v", err)
+	}
+
+	// CreateSale falls back to 11% when no PPN is given; a zero-rated quote stays zero-rated
+	if ppnRate == 0 && sale.PPNPercent != 0 {
+		zero := 0.0
+		if _, err := salesService.UpdateSale(sale.ID, models.SaleUpdateRequest{PPNRate: &zero}, userID); err != nil {
+			log.Printf("⚠️ Failed to clear PPN on sale %s converted from quote %s: %v", sale.Code, quote.Code, err)
+		}
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		// Guard against the quote being converted twice at the same time
+		res := tx.Model(&models.Quote{}).Where("id = ? AND sale_id IS NULL", quote.ID).Update("sale_id", sale.ID)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return fmt.Errorf("quote has already been converted to a sale")
+		}
+		return tx.Model(&models.Sale{}).Where("id = ?", sale.ID).Updates(map[string]interface{}{
+			"quote_id":         quote.ID,
+			"quotation_number": quote.Code,
+		}).Error
+	})
+	if err != nil {
+		if delErr := salesService.DeleteSale(sale.ID); delErr != nil {
+			log.Printf("⚠️ Failed to remove sale %s after quote link failed: %v", sale.Code, delErr)
+		}
+		return nil, fmt.Errorf("failed to link quote to sale: %v", err)
+	}
+
+	log.Printf("✅ Quote %s converted to sale %s", quote.Code, sale.Code)
+	return salesService.GetSaleByID(sale.ID)
+}
+
+// quoteExpiryInterval is how often the background job looks for quotes past their validity date
+const quoteExpiryInterval = time.Hour
+
+// StartExpiryScheduler expires overdue quotes at startup and then every hour
+func (s *QuoteServiceFull) StartExpiryScheduler() {
+	ticker := time.NewTicker(quoteExpiryInterval)
+	defer ticker.Stop()
+
+	log.Println("⏳ Quote expiry scheduler started - running every hour")
+	s.runExpiry()
+	for range ticker.C {
+		s.runExpiry()
+	}
+}
+
+func (s *QuoteServiceFull) runExpiry() {
+	result, err := s.ExpireQuotes(time.Now())
+	if err != nil {
+		log.Printf("❌ Quote expiry job failed: %v", err)
+		return
+	}
+	if result.Expired > 0 {
+		log.Printf("⏳ Expired %d quote(s): %s", result.Expired, strings.Join(result.Codes, ", "))
+	}
+}
+
+// ExpireQuotes marks sent quotes whose validity date is before asOf as EXPIRED. Drafts were never
+// offered to the customer and are left alone.
+func (s *QuoteServiceFull) ExpireQuotes(asOf time.Time) (*models.QuoteExpiryResult, error) {
+	result := &models.QuoteExpiryResult{AsOf: asOf, Codes: []string{}}
+
+	var quotes []models.Quote
+	if err := s.db.Select("id", "code").
+		Where("status = ? AND valid_until < ?", models.QuoteStatusSent, dateOnly(asOf)).
+		Find(&quotes).Error; err != nil {
+		return nil, fmt.Errorf("failed to find overdue quotes: %v", err)
+	}
+	if len(quotes) == 0 {
+		return result, nil
+	}
+
+	ids := make([]uint, 0, len(quotes))
+	for _, quote := range quotes {
+		ids = append(ids, quote.ID)
+		result.Codes = append(result.Codes, quote.Code)
+	}
+	res := s.db.Model(&models.Quote{}).
+		Where("id IN ? AND status = ?", ids, models.QuoteStatusSent).
+		Updates(map[string]interface{}{
+			"status":            models.QuoteStatusExpired,
+			"status_reason":     "Validity date passed",
+			"status_changed_at": time.Now(),
+		})
+	if res.Error != nil {
+		return nil, fmt.Errorf("failed to expire quotes: %v", res.Error)
+	}
+	result.Expired = int(res.RowsAffected)
+	return result, nil
+}
+
+// GetConversionAnalytics reports won, lost and open quotes dated within the period, overall and
+// per sales person and customer. Cancelled quotes were withdrawn by us and are not counted.
+func (s *QuoteServiceFull) GetConversionAnalytics(startDate, endDate string) (*models.QuoteConversionAnalytics, error) {
+	analytics := &models.QuoteConversionAnalytics{StartDate: startDate, EndDate: endDate}
+	var err error
+
+	where := "q.deleted_at IS NULL AND q.status <> ?"
+	args := []interface{}{models.QuoteStatusCancelled}
+	if startDate != "" {
+		where += " AND q.date >= ?"
+		args = append(args, startDate)
+	}
+	if endDate != "" {
+		where += " AND q.date < (CAST(? AS date) + 1)"
+		args = append(args, endDate)
+	}
+
+	aggregates := fmt.Sprintf(`COUNT(*) AS total_quotes,
+		COALESCE(SUM(CASE WHEN q.status = '%[1]s' THEN 1 ELSE 0 END), 0) AS won_count,
+		COALESCE(SUM(CASE WHEN q.status IN ('%[2]s', '%[3]s') THEN 1 ELSE 0 END), 0) AS lost_count,
+		COALESCE(SUM(CASE WHEN q.status IN ('%[4]s', '%[5]s') THEN 1 ELSE 0 END), 0) AS open_count,
+		COALESCE(SUM(CASE WHEN q.sale_id IS NOT NULL OR q.converted_to_invoice THEN 1 ELSE 0 END), 0) AS converted_count,
+		COALESCE(SUM(q.total_amount), 0) AS total_amount,
+		COALESCE(SUM(CASE WHEN q.status = '%[1]s' THEN q.total_amount ELSE 0 END), 0) AS won_amount,
+		COALESCE(SUM(CASE WHEN q.status IN ('%[2]s', '%[3]s') THEN q.total_amount ELSE 0 END), 0) AS lost_amount`,
+		models.QuoteStatusAccepted, models.QuoteStatusRejected, models.QuoteStatusExpired,
+		models.QuoteStatusDraft, models.QuoteStatusSent)
+
+	if err = s.db.Raw("SELECT 0 AS id, 'All' AS name, "+aggregates+" FROM quotes q WHERE "+where, args...).
+		Scan(&analytics.Overall).Error; err != nil {
+		return nil, fmt.Errorf("failed to calculate quote conversion: %v", err)
+	}
+
+	grouped := func(column string) ([]models.QuoteConversionStats, error) {
+		var rows []models.QuoteConversionStats
+		query := fmt.Sprintf(`SELECT COALESCE(q.%[1]s, 0) AS id, COALESCE(c.name, 'Unassigned') AS name, %[2]s
+			FROM quotes q LEFT JOIN contacts c ON c.id = q.%[1]s
+			WHERE %[3]s
+			GROUP BY COALESCE(q.%[1]s, 0), COALESCE(c.name, 'Unassigned')
+			ORDER BY won_amount DESC, total_quotes DESC`, column, aggregates, where)
+		if err := s.db.Raw(query, args...).Scan(&rows).Error; err != nil {
+			return nil, err
+		}
+		for i := range rows {
+			fillConversionRates(&rows[i])
+		}
+		return rows, nil
+	}
+
+	if analytics.BySalesPerson, err = grouped("sales_person_id"); err != nil {
+		return nil, fmt.Errorf("failed to calculate conversion per sales person: %v", err)
+	}
+	if analytics.ByCustomer, err = grouped("customer_id"); err != nil {
+		return nil, fmt.Errorf("failed to calculate conversion per customer: %v", err)
+	}
+	fillConversionRates(&analytics.Overall)
+
+	return analytics, nil
+}
+
+func fillConversionRates(stats *models.QuoteConversionStats) {
+	stats.TotalAmount = roundAmount(stats.TotalAmount)
+	stats.WonAmount = roundAmount(stats.WonAmount)
+	stats.LostAmount = roundAmount(stats.LostAmount)
+	if decided := stats.WonCount + stats.LostCount; decided > 0 {
+		stats.WinRate = math.Round(float64(stats.WonCount)/float64(decided)*10000) / 100
+	}
+	if stats.TotalQuotes > 0 {
+		stats.ConversionRate = math.Round(float64(stats.ConvertedCount)/float64(stats.TotalQuotes)*10000) / 100
+	}
+}
+
+func valueOrZero(value *float64) float64 {
+	if value == nil {
+		return 0
+	}
+	return *value
+}
+
 // FormatCurrency formats amount according to system settings
 func (s *QuoteServiceFull) FormatCurrency(amount float64) (string, error) {
 	settings, err := s.settingsService.GetSettings()
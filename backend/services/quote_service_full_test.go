@@ -0,0 +1,111 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"app-sistem-akuntansi/models"
+	"app-sistem-akuntansi/repositories"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFillConversionRates(t *testing.T) {
+	tests := []struct {
+		name           string
+		stats          models.QuoteConversionStats
+		winRate        float64
+		conversionRate float64
+	}{
+		{name: "won and lost", stats: models.QuoteConversionStats{TotalQuotes: 10, WonCount: 3, LostCount: 1, ConvertedCount: 2}, winRate: 75, conversionRate: 20},
+		{name: "rounded to two decimals", stats: models.QuoteConversionStats{TotalQuotes: 3, WonCount: 1, LostCount: 2, ConvertedCount: 1}, winRate: 33.33, conversionRate: 33.33},
+		{name: "only open quotes", stats: models.QuoteConversionStats{TotalQuotes: 4, OpenCount: 4}},
+		{name: "no quotes", stats: models.QuoteConversionStats{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fillConversionRates(&tt.stats)
+			assert.InDelta(t, tt.winRate, tt.stats.WinRate, 0.001)
+			assert.InDelta(t, tt.conversionRate, tt.stats.ConversionRate, 0.001)
+		})
+	}
+}
+
+func TestQuoteStatusRevisionsAndExpiry(t *testing.T) {
+	// The product repository reads outside the update transaction, so this needs more than one connection
+	db := setupJournalTestDB(t, &models.User{}, &models.Contact{}, &models.ProductCategory{}, &models.Product{},
+		&models.Quote{}, &models.QuoteItem{}, &models.QuoteRevision{})
+	useTestSettings(t, &models.Settings{DefaultTaxRate: 11})
+	service := NewQuoteServiceFull(db, nil, repositories.NewProductRepository(db))
+
+	customer := models.Contact{Code: "CUST-001", Name: "PT Pelanggan", Type: models.ContactTypeCustomer}
+	require.NoError(t, db.Create(&customer).Error)
+	product := models.Product{Code: "PRD-001", Name: "Kertas A4", Unit: "rim"}
+	require.NoError(t, db.Create(&product).Error)
+	today := dateOnly(time.Now())
+	newQuote := func(code, status string, validUntil time.Time) models.Quote {
+		quote := models.Quote{Code: code, CustomerID: customer.ID, UserID: 1, Date: today.AddDate(0, 0, -30), ValidUntil: validUntil, Status: status,
+			SubtotalBeforeDiscount: 1000000, SubtotalAfterDiscount: 1000000, TaxAmount: 110000, TotalAmount: 1110000,
+			QuoteItems: []models.QuoteItem{{ProductID: product.ID, Quantity: 2, UnitPrice: 500000, TotalPrice: 1000000}}}
+		require.NoError(t, db.Create(&quote).Error)
+		return quote
+	}
+	setStatus := func(quote models.Quote, status, reason string) (*models.Quote, error) {
+		return service.UpdateQuoteStatus(quote.ID, models.QuoteStatusRequest{Status: status, Reason: reason}, 1)
+	}
+
+	draft := newQuote("QUO-001", models.QuoteStatusDraft, today.AddDate(0, 0, 14))
+	_, err := setStatus(draft, models.QuoteStatusAccepted, "")
+	assert.EqualError(t, err, "quote cannot move from DRAFT to ACCEPTED")
+	sent, err := setStatus(draft, "sent", "")
+	require.NoError(t, err)
+	assert.Equal(t, models.QuoteStatusSent, sent.Status)
+	require.NotNil(t, sent.StatusChangedAt)
+
+	// Amending a sent quote keeps the version the customer has
+	amended, err := service.UpdateQuote(sent.ID, models.QuoteUpdateRequest{
+		Items:          []models.QuoteItemCreateRequest{{ProductID: product.ID, Quantity: 3, UnitPrice: 500000}},
+		RevisionReason: "Tambah 1 rim",
+	}, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 2, amended.Revision)
+	assert.InDelta(t, 1665000, amended.TotalAmount, 0.001, "PPN from the default tax rate")
+	require.Len(t, amended.QuoteItems, 1)
+	require.Len(t, amended.Revisions, 1)
+	assert.Equal(t, 1, amended.Revisions[0].Revision)
+	assert.InDelta(t, 1110000, amended.Revisions[0].TotalAmount, 0.001)
+	assert.Equal(t, "Tambah 1 rim", amended.Revisions[0].Reason)
+	assert.Contains(t, amended.Revisions[0].Snapshot, `"code":"QUO-001"`)
+
+	_, err = setStatus(draft, models.QuoteStatusRejected, " ")
+	assert.EqualError(t, err, "reason is required to mark a quote rejected")
+	accepted, err := setStatus(draft, models.QuoteStatusAccepted, "")
+	require.NoError(t, err)
+	assert.Equal(t, models.QuoteStatusAccepted, accepted.Status)
+	_, err = service.UpdateQuote(accepted.ID, models.QuoteUpdateRequest{}, 1)
+	assert.EqualError(t, err, "quote cannot be updated in current status: ACCEPTED")
+	_, err = setStatus(draft, models.QuoteStatusRejected, "Harga terlalu tinggi")
+	assert.EqualError(t, err, "quote cannot move from ACCEPTED to REJECTED")
+
+	lapsed := newQuote("QUO-002", models.QuoteStatusSent, today.AddDate(0, 0, -1))
+	_, err = setStatus(lapsed, models.QuoteStatusAccepted, "")
+	assert.EqualError(t, err, "quote QUO-002 expired on "+today.AddDate(0, 0, -1).Format("2006-01-02")+", amend the validity date before accepting it")
+	newQuote("QUO-003", models.QuoteStatusDraft, today.AddDate(0, 0, -1))
+	newQuote("QUO-004", models.QuoteStatusSent, today)
+
+	result, err := service.ExpireQuotes(today)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Expired, "drafts and quotes valid through today stay open")
+	assert.Equal(t, []string{"QUO-002"}, result.Codes)
+	expired, err := service.GetQuoteByID(lapsed.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.QuoteStatusExpired, expired.Status)
+	assert.Equal(t, "Validity date passed", expired.StatusReason)
+
+	result, err = service.ExpireQuotes(today)
+	require.NoError(t, err)
+	assert.Zero(t, result.Expired)
+	assert.Empty(t, result.Codes)
+}
@@ -0,0 +1,885 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"app-sistem-akuntansi/models"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ReferenceType of cash-bank transactions created by recurring templates that credit or debit a bank account
+const CashBankRefRecurring = "RECURRING"
+
+// recurringSchedulerInterval is how often the background scheduler looks for due templates
+const recurringSchedulerInterval = time.Hour
+
+// recurringMaxFailures is how many times in a row a scheduled date may fail before the template is paused
+const recurringMaxFailures = 5
+
+type RecurringJournalService struct {
+	db             *gorm.DB
+	journalService *UnifiedJournalService
+	periodService  *UnifiedPeriodClosingService
+}
+
+func NewRecurringJournalService(db *gorm.DB) *RecurringJournalService {
+	return &RecurringJournalService{
+		db:             db,
+		journalService: NewUnifiedJournalService(db),
+		periodService:  NewUnifiedPeriodClosingService(db),
+	}
+}
+
+// RecurringRunResult - Ringkasan satu kali eksekusi scheduler
+type RecurringRunResult struct {
+	AsOf      time.Time `json:"as_of"`
+	Templates int       `json:"templates"`
+	Generated int       `json:"generated"` // Draft entries
+	Posted    int       `json:"posted"`
+	Skipped   int       `json:"skipped"`
+	Queued    int       `json:"queued"`
+	Released  int       `json:"released"` // Queued runs posted after their period was reopened
+	Failed    int       `json:"failed"`
+	Errors    []string  `json:"errors"`
+}
+
+// ========== TEMPLATES ==========
+
+// GetTemplates - Daftar template berulang
+func (s *RecurringJournalService) GetTemplates(status, templateType string) ([]models.RecurringTemplate, error) {
+	var templates []models.RecurringTemplate
+	query := s.db.Preload("Lines.Account")
+	if status != "" {
+		query = query.Where("status = ?", strings.ToUpper(status))
+	}
+	if templateType != "" {
+		query = query.Where("type = ?", strings.ToUpper(templateType))
+	}
+	if err := query.Order("next_run_date ASC NULLS LAST, id DESC").Find(&templates).Error; err != nil {
+		return nil, err
+	}
+	return templates, nil
+}
+
+// GetTemplateByID - Detail template beserta barisnya
+func (s *RecurringJournalService) GetTemplateByID(id uint) (*models.RecurringTemplate, error) {
+	var template models.RecurringTemplate
+	if err := s.db.Preload("Lines.Account").Preload("SourceExpense").First(&template, id).Error; err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
+
+// GetRuns - Riwayat pembuatan jurnal dari template
+func (s *RecurringJournalService) GetRuns(templateID uint, status string) ([]models.RecurringRun, error) {
+	var runs []models.RecurringRun
+	query := s.db.Model(&models.RecurringRun{})
+	if templateID > 0 {
+		query = query.Where("template_id = ?", templateID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", strings.ToUpper(status))
+	}
+	if err := query.Order("scheduled_date DESC, id DESC").Find(&runs).Error; err != nil {
+		return nil, err
+	}
+	return runs, nil
+}
+
+// CreateTemplate - Buat template jurnal berulang
+func (s *RecurringJournalService) CreateTemplate(req models.RecurringTemplateRequest, userID uint) (*models.RecurringTemplate, error) {
+	if err := s.validateLines(s.db, req.Lines); err != nil {
+		return nil, err
+	}
+
+	template := &models.RecurringTemplate{
+		Name:        req.Name,
+		Description: req.Description,
+		Type:        models.RecurringTypeJournal,
+		Status:      models.RecurringStatusActive,
+		CreatedBy:   userID,
+	}
+	if err := applySchedule(template, req.Frequency, req.IntervalCount, req.StartDate, req.EndDate, req.MaxOccurrences, req.AutoPost, req.ClosedPeriodAction); err != nil {
+		return nil, err
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		code, err := s.generateTemplateCode(tx, time.Now())
+		if err != nil {
+			return err
+		}
+		template.Code = code
+		refreshNextRun(template)
+		if err := tx.Omit(clause.Associations).Create(template).Error; err != nil {
+			return fmt.Errorf("failed to create recurring template: %v", err)
+		}
+		return s.replaceLines(tx, template.ID, req.Lines)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetTemplateByID(template.ID)
+}
+
+// CreateTemplateFromExpense - Jadikan pengeluaran (Expense) sebagai pengeluaran berulang.
+// Each run copies the expense and posts Dr expense account / Cr the given credit account.
+func (s *RecurringJournalService) CreateTemplateFromExpense(expenseID uint, req models.RecurringExpenseRequest, userID uint) (*models.RecurringTemplate, error) {
+	var expense models.Expense
+	if err := s.db.First(&expense, expenseID).Error; err != nil {
+		return nil, errors.New("expense not found")
+	}
+	if expense.Amount <= 0 {
+		return nil, errors.New("expense amount must be greater than zero")
+	}
+
+	var existing int64
+	if err := s.db.Model(&models.RecurringTemplate{}).
+		Where("source_expense_id = ? AND status <> ?", expense.ID, models.RecurringStatusCompleted).
+		Count(&existing).Error; err != nil {
+		return nil, err
+	}
+	if existing > 0 {
+		return nil, errors.New("expense already has an active recurring schedule")
+	}
+
+	lines := []models.RecurringTemplateLineRequest{
+		{AccountID: expense.AccountID, Description: expense.Name, DebitAmount: expense.Amount, CostCenterID: expense.CostCenterID, ProjectID: expense.ProjectID},
+		{AccountID: req.CreditAccountID, Description: expense.Name, CreditAmount: expense.Amount},
+	}
+	if err := s.validateLines(s.db, lines); err != nil {
+		return nil, err
+	}
+
+	template := &models.RecurringTemplate{
+		Name:            expense.Name,
+		Description:     expense.Notes,
+		Type:            models.RecurringTypeExpense,
+		SourceExpenseID: &expense.ID,
+		Status:          models.RecurringStatusActive,
+		CreatedBy:       userID,
+	}
+	if err := applySchedule(template, req.Frequency, req.IntervalCount, req.StartDate, req.EndDate, req.MaxOccurrences, req.AutoPost, req.ClosedPeriodAction); err != nil {
+		return nil, err
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		code, err := s.generateTemplateCode(tx, time.Now())
+		if err != nil {
+			return err
+		}
+		template.Code = code
+		refreshNextRun(template)
+		if err := tx.Omit(clause.Associations).Create(template).Error; err != nil {
+			return fmt.Errorf("failed to create recurring template: %v", err)
+		}
+		if err := s.replaceLines(tx, template.ID, lines); err != nil {
+			return err
+		}
+		return tx.Model(&expense).Update("is_recurring", true).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetTemplateByID(template.ID)
+}
+
+// UpdateTemplate - Ubah jadwal dan baris template. The start date can only change before the first run.
+func (s *RecurringJournalService) UpdateTemplate(id uint, req models.RecurringTemplateRequest) (*models.RecurringTemplate, error) {
+	if err := s.validateLines(s.db, req.Lines); err != nil {
+		return nil, err
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var template models.RecurringTemplate
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&template, id).Error; err != nil {
+			return errors.New("recurring template not found")
+		}
+		if template.Status == models.RecurringStatusCompleted {
+			return errors.New("completed templates cannot be changed")
+		}
+
+		startDate := req.StartDate
+		if template.NextSequence > 0 && !dateOnly(startDate).Equal(dateOnly(template.StartDate)) {
+			return errors.New("start date cannot be changed after the template has run")
+		}
+		if err := applySchedule(&template, req.Frequency, req.IntervalCount, startDate, req.EndDate, req.MaxOccurrences, req.AutoPost, req.ClosedPeriodAction); err != nil {
+			return err
+		}
+		template.Name = req.Name
+		template.Description = req.Description
+		if template.Status == models.RecurringStatusActive {
+			refreshNextRun(&template)
+		}
+
+		if err := tx.Omit(clause.Associations).Save(&template).Error; err != nil {
+			return err
+		}
+		return s.replaceLines(tx, template.ID, req.Lines)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetTemplateByID(id)
+}
+
+// PauseTemplate - Hentikan sementara; tanggal yang terlewat selama jeda dibuat saat dilanjutkan
+func (s *RecurringJournalService) PauseTemplate(id uint) (*models.RecurringTemplate, error) {
+	return s.setStatus(id, models.RecurringStatusActive, models.RecurringStatusPaused)
+}
+
+// ResumeTemplate - Lanjutkan template yang dijeda
+func (s *RecurringJournalService) ResumeTemplate(id uint) (*models.RecurringTemplate, error) {
+	return s.setStatus(id, models.RecurringStatusPaused, models.RecurringStatusActive)
+}
+
+func (s *RecurringJournalService) setStatus(id uint, from, to string) (*models.RecurringTemplate, error) {
+	var template models.RecurringTemplate
+	if err := s.db.First(&template, id).Error; err != nil {
+		return nil, errors.New("recurring template not found")
+	}
+	if template.Status != from {
+		return nil, fmt.Errorf("template is %s, expected %s", template.Status, from)
+	}
+	template.Status = to
+	updates := map[string]interface{}{"status": template.Status}
+	if to == models.RecurringStatusActive {
+		// A template paused after failures gets a fresh set of attempts
+		refreshNextRun(&template)
+		template.FailureCount = 0
+		updates["failure_count"] = 0
+	}
+	updates["next_run_date"] = template.NextRunDate
+	if err := s.db.Model(&template).Updates(updates).Error; err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
+
+// DeleteTemplate - Hapus template; jurnal yang sudah dibuat tetap ada
+func (s *RecurringJournalService) DeleteTemplate(id uint) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var template models.RecurringTemplate
+		if err := tx.First(&template, id).Error; err != nil {
+			return errors.New("recurring template not found")
+		}
+		if template.SourceExpenseID != nil {
+			if err := tx.Model(&models.Expense{}).Where("id = ?", *template.SourceExpenseID).
+				Update("is_recurring", false).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Delete(&template).Error
+	})
+}
+
+// ========== GENERATION ==========
+
+// StartScheduler runs due templates at startup and then every hour. Missed dates (downtime, paused
+// templates) are caught up on the next tick; the run history guarantees each date is generated once.
+func (s *RecurringJournalService) StartScheduler() {
+	ticker := time.NewTicker(recurringSchedulerInterval)
+	defer ticker.Stop()
+
+	log.Println("🔁 Recurring journal scheduler started - running every hour")
+	s.runScheduled()
+	for range ticker.C {
+		s.runScheduled()
+	}
+}
+
+func (s *RecurringJournalService) runScheduled() {
+	result, err := s.RunDue(time.Now(), 0)
+	if err != nil {
+		log.Printf("❌ Recurring journal scheduler failed: %v", err)
+		return
+	}
+	if result.Generated+result.Posted+result.Skipped+result.Queued+result.Released+result.Failed > 0 {
+		log.Printf("🔁 Recurring journals: %d posted, %d drafts, %d skipped, %d queued, %d released, %d failed",
+			result.Posted, result.Generated, result.Skipped, result.Queued, result.Released, result.Failed)
+	}
+}
+
+// RunDue - Proses semua template aktif yang jatuh tempo sampai tanggal asOf, termasuk tanggal yang terlewat
+func (s *RecurringJournalService) RunDue(asOf time.Time, userID uint) (*RecurringRunResult, error) {
+	result := &RecurringRunResult{AsOf: asOf, Errors: []string{}}
+
+	s.releaseReopenedRuns(result)
+
+	var templateIDs []uint
+	if err := s.db.Model(&models.RecurringTemplate{}).
+		Where("status = ? AND next_run_date IS NOT NULL AND next_run_date <= ?", models.RecurringStatusActive, asOf).
+		Order("next_run_date ASC").
+		Pluck("id", &templateIDs).Error; err != nil {
+		return nil, err
+	}
+	for _, id := range templateIDs {
+		result.Templates++
+		s.processTemplate(id, asOf, userID, result)
+	}
+	return result, nil
+}
+
+// RunTemplate - Proses satu template sekarang (semua tanggal yang sudah jatuh tempo)
+func (s *RecurringJournalService) RunTemplate(id uint, userID uint) (*RecurringRunResult, error) {
+	var template models.RecurringTemplate
+	if err := s.db.First(&template, id).Error; err != nil {
+		return nil, errors.New("recurring template not found")
+	}
+	if template.Status != models.RecurringStatusActive {
+		return nil, fmt.Errorf("template is %s", template.Status)
+	}
+
+	result := &RecurringRunResult{AsOf: time.Now(), Templates: 1, Errors: []string{}}
+	s.processTemplate(id, result.AsOf, userID, result)
+	return result, nil
+}
+
+// processTemplate generates every due occurrence, one transaction per scheduled date
+func (s *RecurringJournalService) processTemplate(id uint, asOf time.Time, userID uint, result *RecurringRunResult) {
+	for {
+		var failure error
+		var scheduled time.Time
+		done := true
+
+		err := s.db.Transaction(func(tx *gorm.DB) error {
+			var template models.RecurringTemplate
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Lines").First(&template, id).Error; err != nil {
+				return err
+			}
+			if template.Status != models.RecurringStatusActive || template.NextRunDate == nil || template.NextRunDate.After(asOf) {
+				return nil
+			}
+			if !s.expenseStillRecurring(tx, &template) {
+				template.Status = models.RecurringStatusPaused
+				log.Printf("⏸️ Recurring template %s paused: source expense is no longer recurring", template.Code)
+				return tx.Model(&template).Update("status", template.Status).Error
+			}
+
+			scheduled = occurrenceDate(&template, template.NextSequence)
+			status, created, err := s.generateOccurrence(tx, &template, template.NextSequence, scheduled, userID)
+			if err != nil {
+				failure = err
+				return err
+			}
+			if created {
+				countRunStatus(result, status)
+				if status == models.RecurringRunGenerated || status == models.RecurringRunPosted {
+					template.OccurrenceCount++
+				}
+			}
+			template.NextSequence++
+			template.LastRunDate = &scheduled
+			refreshNextRun(&template)
+			done = false
+			return tx.Model(&template).Updates(map[string]interface{}{
+				"next_sequence":    template.NextSequence,
+				"next_run_date":    template.NextRunDate,
+				"last_run_date":    template.LastRunDate,
+				"occurrence_count": template.OccurrenceCount,
+				"status":           template.Status,
+				"failure_count":    0,
+				"last_error":       "",
+			}).Error
+		})
+
+		if failure != nil {
+			// Keep the template on this date so the next tick retries it, and leave a trace in the history
+			s.recordFailedRun(id, scheduled, userID, failure)
+			s.recordTemplateFailure(id, scheduled, failure)
+			result.Failed++
+			result.Errors = append(result.Errors, fmt.Sprintf("template %d on %s: %v", id, scheduled.Format("2006-01-02"), failure))
+			return
+		}
+		if err != nil {
+			result.Failed++
+			result.Errors = append(result.Errors, fmt.Sprintf("template %d: %v", id, err))
+			return
+		}
+		if done {
+			return
+		}
+	}
+}
+
+// generateOccurrence creates the run for one scheduled date. A date that already has a run (other than
+// FAILED) is not generated again, which makes catch-up after downtime idempotent.
+func (s *RecurringJournalService) generateOccurrence(tx *gorm.DB, template *models.RecurringTemplate, sequence int, scheduled time.Time, userID uint) (string, bool, error) {
+	var run models.RecurringRun
+	err := tx.Where("template_id = ? AND scheduled_date = ?", template.ID, dateOnly(scheduled)).First(&run).Error
+	if err == nil && run.Status != models.RecurringRunFailed {
+		return run.Status, false, nil
+	}
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", false, err
+	}
+
+	run.TemplateID = template.ID
+	run.Sequence = sequence
+	run.ScheduledDate = dateOnly(scheduled)
+	run.ProcessedBy = userID
+	run.Message = ""
+
+	closed, err := s.periodService.IsDateInClosedPeriod(context.Background(), scheduled)
+	if err != nil {
+		return "", false, err
+	}
+	if closed {
+		run.Status = models.RecurringRunSkipped
+		run.Message = "Scheduled date falls in a closed period"
+		if template.ClosedPeriodAction == models.RecurringClosedPeriodQueue {
+			run.Status = models.RecurringRunQueued
+			run.Message = "Scheduled date falls in a closed period; waiting to be released into an open period"
+		}
+		return run.Status, true, tx.Save(&run).Error
+	}
+
+	run.Status = models.RecurringRunPosted
+	if err := tx.Save(&run).Error; err != nil {
+		return "", false, err
+	}
+	if err := s.postRun(tx, template, &run, scheduled, userID); err != nil {
+		return "", false, err
+	}
+	return run.Status, true, nil
+}
+
+// ReleaseQueuedRun - Posting run yang tertunda karena periode tertutup pada tanggal di periode terbuka
+func (s *RecurringJournalService) ReleaseQueuedRun(runID uint, req models.RecurringRunReleaseRequest, userID uint) (*models.RecurringRun, error) {
+	closed, err := s.periodService.IsDateInClosedPeriod(context.Background(), req.EntryDate)
+	if err != nil {
+		return nil, err
+	}
+	if closed {
+		return nil, errors.New("entry date falls in a closed period")
+	}
+
+	var run models.RecurringRun
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&run, runID).Error; err != nil {
+			return errors.New("recurring run not found")
+		}
+		if run.Status != models.RecurringRunQueued {
+			return fmt.Errorf("only queued runs can be released (run is %s)", run.Status)
+		}
+		var template models.RecurringTemplate
+		if err := tx.Preload("Lines").First(&template, run.TemplateID).Error; err != nil {
+			return errors.New("recurring template not found")
+		}
+		if err := s.postRun(tx, &template, &run, req.EntryDate, userID); err != nil {
+			return err
+		}
+		return tx.Model(&template).UpdateColumn("occurrence_count", gorm.Expr("occurrence_count + 1")).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+// releaseReopenedRuns posts queued runs on their original date once that period has been reopened
+func (s *RecurringJournalService) releaseReopenedRuns(result *RecurringRunResult) {
+	var runs []models.RecurringRun
+	if err := s.db.Joins("JOIN recurring_templates rt ON rt.id = recurring_runs.template_id AND rt.deleted_at IS NULL").
+		Where("recurring_runs.status = ? AND rt.status = ?", models.RecurringRunQueued, models.RecurringStatusActive).
+		Order("recurring_runs.scheduled_date ASC").
+		Find(&runs).Error; err != nil {
+		result.Errors = append(result.Errors, fmt.Sprintf("failed to load queued runs: %v", err))
+		return
+	}
+
+	for _, run := range runs {
+		closed, err := s.periodService.IsDateInClosedPeriod(context.Background(), run.ScheduledDate)
+		if err != nil || closed {
+			continue
+		}
+		if _, err := s.ReleaseQueuedRun(run.ID, models.RecurringRunReleaseRequest{EntryDate: run.ScheduledDate}, 0); err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("run %d: %v", run.ID, err))
+			continue
+		}
+		result.Released++
+	}
+}
+
+// postRun creates the journal (and expense copy) for a run and marks it GENERATED or POSTED
+func (s *RecurringJournalService) postRun(tx *gorm.DB, template *models.RecurringTemplate, run *models.RecurringRun, entryDate time.Time, userID uint) error {
+	createdBy := userID
+	if createdBy == 0 {
+		createdBy = template.CreatedBy
+	}
+	description := template.Name
+	if template.Description != "" {
+		description = fmt.Sprintf("%s - %s", template.Name, template.Description)
+	}
+
+	// Expense templates carry the tags of the source expense on the expense (debit) lines
+	var expenseTags []uint
+	if template.Type == models.RecurringTypeExpense && template.SourceExpenseID != nil {
+		tags, err := loadDimensionTags(tx, models.DimensionOwnerExpense, []uint64{uint64(*template.SourceExpenseID)})
+		if err != nil {
+			return err
+		}
+		expenseTags = tags[uint64(*template.SourceExpenseID)]
+	}
+
+	lines := make([]JournalLineRequest, 0, len(template.Lines))
+	for _, l := range template.Lines {
+		lineDescription := l.Description
+		if lineDescription == "" {
+			lineDescription = template.Name
+		}
+		dims := models.LineDimensions{CostCenterID: l.CostCenterID, ProjectID: l.ProjectID}
+		if l.DebitAmount > 0 {
+			dims.TagIDs = expenseTags
+		}
+		lines = append(lines, JournalLineRequest{
+			AccountID:    uint64(l.AccountID),
+			Description:  lineDescription,
+			DebitAmount:  decimal.NewFromFloat(l.DebitAmount),
+			CreditAmount: decimal.NewFromFloat(l.CreditAmount),
+			Dimensions:   dims,
+		})
+	}
+
+	entry, err := s.journalService.CreateJournalEntryWithTx(tx, &JournalEntryRequest{
+		SourceType:  models.SSOTSourceTypeManual,
+		SourceID:    uint64(run.ID),
+		Reference:   fmt.Sprintf("%s-%s", template.Code, run.ScheduledDate.Format("20060102")),
+		EntryDate:   entryDate,
+		Description: description,
+		Lines:       lines,
+		AutoPost:    template.AutoPost,
+		CreatedBy:   uint64(createdBy),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create journal entry: %v", err)
+	}
+
+	if template.AutoPost {
+		run.Status = models.RecurringRunPosted
+		if err := s.syncCashBankLines(tx, template, run, entryDate); err != nil {
+			return err
+		}
+	} else {
+		run.Status = models.RecurringRunGenerated
+	}
+	if template.Type == models.RecurringTypeExpense {
+		expenseID, err := s.copyExpense(tx, template, entryDate)
+		if err != nil {
+			return err
+		}
+		run.ExpenseID = &expenseID
+	}
+
+	run.JournalEntryID = &entry.ID
+	run.EntryDate = &entryDate
+	run.Message = ""
+	return tx.Save(run).Error
+}
+
+// syncCashBankLines keeps cash_banks in step with posted lines on cash-bank GL accounts
+// (the unified journal service only updates the accounts table)
+func (s *RecurringJournalService) syncCashBankLines(tx *gorm.DB, template *models.RecurringTemplate, run *models.RecurringRun, entryDate time.Time) error {
+	for _, l := range template.Lines {
+		var cashBank models.CashBank
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("account_id = ?", l.AccountID).First(&cashBank).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		amount := roundAmount(l.DebitAmount - l.CreditAmount)
+		cashBank.Balance = roundAmount(cashBank.Balance + amount)
+		if err := tx.Model(&cashBank).Update("balance", cashBank.Balance).Error; err != nil {
+			return fmt.Errorf("failed to update cash bank balance: %v", err)
+		}
+		if err := tx.Create(&models.CashBankTransaction{
+			CashBankID:      cashBank.ID,
+			ReferenceType:   CashBankRefRecurring,
+			ReferenceID:     run.ID,
+			Amount:          amount,
+			BalanceAfter:    cashBank.Balance,
+			TransactionDate: entryDate,
+			Notes:           fmt.Sprintf("%s (%s)", template.Name, template.Code),
+		}).Error; err != nil {
+			return fmt.Errorf("failed to create cash bank transaction: %v", err)
+		}
+	}
+	return nil
+}
+
+// copyExpense records this run's expense; it is PAID when the credit side is a cash-bank account
+func (s *RecurringJournalService) copyExpense(tx *gorm.DB, template *models.RecurringTemplate, date time.Time) (uint, error) {
+	if template.SourceExpenseID == nil {
+		return 0, errors.New("expense template has no source expense")
+	}
+	var source models.Expense
+	if err := tx.First(&source, *template.SourceExpenseID).Error; err != nil {
+		return 0, errors.New("source expense not found")
+	}
+
+	// The amount and dimensions follow the template (it may have been edited since the expense was made recurring)
+	amount := decimal.Zero
+	costCenterID, projectID := source.CostCenterID, source.ProjectID
+	for _, l := range template.Lines {
+		amount = amount.Add(decimal.NewFromFloat(l.DebitAmount))
+		if l.DebitAmount > 0 && l.AccountID == source.AccountID {
+			costCenterID, projectID = l.CostCenterID, l.ProjectID
+		}
+	}
+
+	status := models.ExpenseStatusPending
+	if template.AutoPost {
+		for _, l := range template.Lines {
+			if l.CreditAmount <= 0 {
+				continue
+			}
+			var cashBanks int64
+			if err := tx.Model(&models.CashBank{}).Where("account_id = ?", l.AccountID).Count(&cashBanks).Error; err != nil {
+				return 0, err
+			}
+			if cashBanks > 0 {
+				status = models.ExpenseStatusPaid
+			}
+		}
+	}
+
+	base := fmt.Sprintf("EXP-%s-", date.Format("200601"))
+	var count int64
+	if err := tx.Unscoped().Model(&models.Expense{}).Where("code LIKE ?", base+"%").Count(&count).Error; err != nil {
+		return 0, err
+	}
+
+	expense := models.Expense{
+		Code:         fmt.Sprintf("%s%04d", base, count+1),
+		Name:         source.Name,
+		Amount:       amount.Round(2).InexactFloat64(),
+		Date:         date,
+		CategoryID:   source.CategoryID,
+		AccountID:    source.AccountID,
+		ContactID:    source.ContactID,
+		UserID:       template.CreatedBy,
+		Notes:        fmt.Sprintf("Generated by recurring template %s", template.Code),
+		Status:       status,
+		CostCenterID: costCenterID,
+		ProjectID:    projectID,
+	}
+	if err := tx.Create(&expense).Error; err != nil {
+		return 0, fmt.Errorf("failed to create expense: %v", err)
+	}
+	tags, err := loadDimensionTags(tx, models.DimensionOwnerExpense, []uint64{uint64(source.ID)})
+	if err != nil {
+		return 0, err
+	}
+	if err := saveDimensionTags(tx, models.DimensionOwnerExpense, uint64(expense.ID), tags[uint64(source.ID)]); err != nil {
+		return 0, err
+	}
+	return expense.ID, nil
+}
+
+func (s *RecurringJournalService) recordFailedRun(templateID uint, scheduled time.Time, userID uint, cause error) {
+	if scheduled.IsZero() {
+		return
+	}
+	run := models.RecurringRun{
+		TemplateID:    templateID,
+		ScheduledDate: dateOnly(scheduled),
+		Status:        models.RecurringRunFailed,
+		Message:       cause.Error(),
+		ProcessedBy:   userID,
+	}
+	if err := s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "template_id"}, {Name: "scheduled_date"}},
+		DoUpdates: clause.AssignmentColumns([]string{"message", "processed_by", "updated_at"}),
+		Where:     clause.Where{Exprs: []clause.Expression{clause.Eq{Column: "recurring_runs.status", Value: models.RecurringRunFailed}}},
+	}).Create(&run).Error; err != nil {
+		log.Printf("⚠️ Failed to record failed recurring run for template %d: %v", templateID, err)
+	}
+}
+
+// recordTemplateFailure counts a failed attempt on the template's next date and pauses the template once
+// the same date has failed recurringMaxFailures times, instead of retrying it every hour forever
+func (s *RecurringJournalService) recordTemplateFailure(templateID uint, scheduled time.Time, cause error) {
+	if err := s.db.Model(&models.RecurringTemplate{}).Where("id = ?", templateID).Updates(map[string]interface{}{
+		"failure_count": gorm.Expr("failure_count + 1"),
+		"last_error":    cause.Error(),
+	}).Error; err != nil {
+		log.Printf("⚠️ Failed to record failure of recurring template %d: %v", templateID, err)
+		return
+	}
+
+	result := s.db.Model(&models.RecurringTemplate{}).
+		Where("id = ? AND status = ? AND failure_count >= ?", templateID, models.RecurringStatusActive, recurringMaxFailures).
+		Update("status", models.RecurringStatusPaused)
+	if result.Error == nil && result.RowsAffected > 0 {
+		log.Printf("⏸️ Recurring template %d paused: %s failed %d times in a row, last error: %v",
+			templateID, scheduled.Format("2006-01-02"), recurringMaxFailures, cause)
+	}
+}
+
+// expenseStillRecurring stops expense templates whose source expense was switched off (IsRecurring=false)
+func (s *RecurringJournalService) expenseStillRecurring(tx *gorm.DB, template *models.RecurringTemplate) bool {
+	if template.Type != models.RecurringTypeExpense || template.SourceExpenseID == nil {
+		return true
+	}
+	var expense models.Expense
+	if err := tx.Select("id", "is_recurring").First(&expense, *template.SourceExpenseID).Error; err != nil {
+		return false
+	}
+	return expense.IsRecurring
+}
+
+// ========== HELPERS ==========
+
+func (s *RecurringJournalService) validateLines(db *gorm.DB, lines []models.RecurringTemplateLineRequest) error {
+	if len(lines) < 2 {
+		return errors.New("at least two lines are required")
+	}
+	totalDebit, totalCredit := decimal.Zero, decimal.Zero
+	for i, l := range lines {
+		if (l.DebitAmount > 0) == (l.CreditAmount > 0) {
+			return fmt.Errorf("line %d must have either a debit or a credit amount", i+1)
+		}
+		var account models.Account
+		if err := db.First(&account, l.AccountID).Error; err != nil {
+			return fmt.Errorf("line %d: account %d not found", i+1, l.AccountID)
+		}
+		if account.IsHeader || !account.IsActive {
+			return fmt.Errorf("line %d: account %s must be an active detail account", i+1, account.Code)
+		}
+		if err := validateLineDimensions(db, models.LineDimensions{CostCenterID: l.CostCenterID, ProjectID: l.ProjectID}); err != nil {
+			return fmt.Errorf("line %d: %v", i+1, err)
+		}
+		totalDebit = totalDebit.Add(decimal.NewFromFloat(l.DebitAmount))
+		totalCredit = totalCredit.Add(decimal.NewFromFloat(l.CreditAmount))
+	}
+	if !totalDebit.Round(2).Equal(totalCredit.Round(2)) {
+		return fmt.Errorf("template is not balanced: debit %s, credit %s", totalDebit.StringFixed(2), totalCredit.StringFixed(2))
+	}
+	return nil
+}
+
+func (s *RecurringJournalService) replaceLines(tx *gorm.DB, templateID uint, lines []models.RecurringTemplateLineRequest) error {
+	if err := tx.Where("template_id = ?", templateID).Delete(&models.RecurringTemplateLine{}).Error; err != nil {
+		return err
+	}
+	rows := make([]models.RecurringTemplateLine, 0, len(lines))
+	for _, l := range lines {
+		rows = append(rows, models.RecurringTemplateLine{
+			TemplateID:   templateID,
+			AccountID:    l.AccountID,
+			Description:  l.Description,
+			DebitAmount:  roundAmount(l.DebitAmount),
+			CreditAmount: roundAmount(l.CreditAmount),
+			CostCenterID: l.CostCenterID,
+			ProjectID:    l.ProjectID,
+		})
+	}
+	return tx.Create(&rows).Error
+}
+
+func (s *RecurringJournalService) generateTemplateCode(tx *gorm.DB, date time.Time) (string, error) {
+	base := fmt.Sprintf("RJ-%s-", date.Format("200601"))
+	var count int64
+	if err := tx.Unscoped().Model(&models.RecurringTemplate{}).Where("code LIKE ?", base+"%").Count(&count).Error; err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s%04d", base, count+1), nil
+}
+
+func applySchedule(template *models.RecurringTemplate, frequency string, interval int, startDate time.Time, endDate *time.Time, maxOccurrences *int, autoPost bool, closedPeriodAction string) error {
+	frequency = strings.ToUpper(frequency)
+	switch frequency {
+	case models.RecurringFrequencyDaily, models.RecurringFrequencyWeekly, models.RecurringFrequencyMonthly, models.RecurringFrequencyYearly:
+	default:
+		return fmt.Errorf("invalid frequency '%s'", frequency)
+	}
+	if interval <= 0 {
+		interval = 1
+	}
+	if endDate != nil && endDate.Before(startDate) {
+		return errors.New("end date must be on or after the start date")
+	}
+	if maxOccurrences != nil && *maxOccurrences <= 0 {
+		return errors.New("max occurrences must be greater than zero")
+	}
+
+	closedPeriodAction = strings.ToUpper(strings.TrimSpace(closedPeriodAction))
+	if closedPeriodAction == "" {
+		closedPeriodAction = models.RecurringClosedPeriodQueue
+	}
+	if closedPeriodAction != models.RecurringClosedPeriodSkip && closedPeriodAction != models.RecurringClosedPeriodQueue {
+		return fmt.Errorf("invalid closed period action '%s' (use SKIP or QUEUE)", closedPeriodAction)
+	}
+
+	template.Frequency = frequency
+	template.IntervalCount = interval
+	template.StartDate = dateOnly(startDate)
+	template.EndDate = endDate
+	template.MaxOccurrences = maxOccurrences
+	template.AutoPost = autoPost
+	template.ClosedPeriodAction = closedPeriodAction
+	return nil
+}
+
+// occurrenceDate returns the n-th scheduled date counted from the start date, so month-end
+// schedules stay on the anchor day (31 Jan -> 28/29 Feb -> 31 Mar) instead of drifting.
+func occurrenceDate(template *models.RecurringTemplate, sequence int) time.Time {
+	steps := sequence * template.IntervalCount
+	start := template.StartDate
+	switch template.Frequency {
+	case models.RecurringFrequencyDaily:
+		return start.AddDate(0, 0, steps)
+	case models.RecurringFrequencyWeekly:
+		return start.AddDate(0, 0, 7*steps)
+	case models.RecurringFrequencyYearly:
+		return addMonthsClamped(start, 12*steps)
+	default:
+		return addMonthsClamped(start, steps)
+	}
+}
+
+func addMonthsClamped(t time.Time, months int) time.Time {
+	first := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location()).AddDate(0, months, 0)
+	lastDay := first.AddDate(0, 1, -1).Day()
+	day := t.Day()
+	if day > lastDay {
+		day = lastDay
+	}
+	return time.Date(first.Year(), first.Month(), day, 0, 0, 0, 0, t.Location())
+}
+
+// refreshNextRun sets NextRunDate from NextSequence, completing the template when the schedule has ended
+func refreshNextRun(template *models.RecurringTemplate) {
+	next := occurrenceDate(template, template.NextSequence)
+	finished := (template.MaxOccurrences != nil && template.NextSequence >= *template.MaxOccurrences) ||
+		(template.EndDate != nil && next.After(*template.EndDate))
+	if finished {
+		template.NextRunDate = nil
+		template.Status = models.RecurringStatusCompleted
+		return
+	}
+	template.NextRunDate = &next
+}
+
+func countRunStatus(result *RecurringRunResult, status string) {
+	switch status {
+	case models.RecurringRunGenerated:
+		result.Generated++
+	case models.RecurringRunPosted:
+		result.Posted++
+	case models.RecurringRunSkipped:
+		result.Skipped++
+	case models.RecurringRunQueued:
+		result.Queued++
+	}
+}
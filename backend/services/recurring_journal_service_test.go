@@ -0,0 +1,135 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"app-sistem-akuntansi/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestRecurringTemplatePausedAfterRepeatedFailures(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1) // Every connection to :memory: is a separate database
+	require.NoError(t, db.AutoMigrate(&models.RecurringTemplate{}))
+
+	service := &RecurringJournalService{db: db}
+	next := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	template := models.RecurringTemplate{
+		Code: "RJ-0001", Name: "Sewa kantor", Frequency: models.RecurringFrequencyMonthly, IntervalCount: 1,
+		StartDate: next, NextRunDate: &next, Status: models.RecurringStatusActive, CreatedBy: 1,
+	}
+	require.NoError(t, db.Create(&template).Error)
+	cause := errors.New("account 5101 is inactive")
+
+	for attempt := 1; attempt <= recurringMaxFailures; attempt++ {
+		service.recordTemplateFailure(template.ID, next, cause)
+		require.NoError(t, db.First(&template, template.ID).Error)
+		assert.Equal(t, attempt, template.FailureCount)
+		assert.Equal(t, cause.Error(), template.LastError)
+		if attempt < recurringMaxFailures {
+			assert.Equal(t, models.RecurringStatusActive, template.Status, "attempt %d", attempt)
+		}
+	}
+	assert.Equal(t, models.RecurringStatusPaused, template.Status)
+	require.NotNil(t, template.NextRunDate)
+	assert.True(t, template.NextRunDate.Equal(next), "the failed date is kept for the next attempt")
+
+	resumed, err := service.ResumeTemplate(template.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.RecurringStatusActive, resumed.Status)
+	require.NoError(t, db.First(&template, template.ID).Error)
+	assert.Zero(t, template.FailureCount, "resuming starts a fresh set of attempts")
+	assert.Equal(t, cause.Error(), template.LastError, "the last error stays visible until a run succeeds")
+}
+
+func TestOccurrenceDate(t *testing.T) {
+	monthEnd := time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		frequency string
+		interval  int
+		start     time.Time
+		sequence  int
+		want      time.Time
+	}{
+		{name: "first occurrence is the start date", frequency: models.RecurringFrequencyMonthly, interval: 1, start: monthEnd, want: monthEnd},
+		{name: "month end clamps to february", frequency: models.RecurringFrequencyMonthly, interval: 1, start: monthEnd, sequence: 1, want: time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{name: "month end returns to the anchor day", frequency: models.RecurringFrequencyMonthly, interval: 1, start: monthEnd, sequence: 2, want: time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC)},
+		{name: "quarterly", frequency: models.RecurringFrequencyMonthly, interval: 3, start: monthEnd, sequence: 1, want: time.Date(2024, 4, 30, 0, 0, 0, 0, time.UTC)},
+		{name: "daily", frequency: models.RecurringFrequencyDaily, interval: 1, start: monthEnd, sequence: 1, want: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{name: "every two weeks", frequency: models.RecurringFrequencyWeekly, interval: 2, start: monthEnd, sequence: 2, want: time.Date(2024, 2, 28, 0, 0, 0, 0, time.UTC)},
+		{name: "leap day yearly", frequency: models.RecurringFrequencyYearly, interval: 1, start: time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC), sequence: 1, want: time.Date(2025, 2, 28, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			template := &models.RecurringTemplate{Frequency: tt.frequency, IntervalCount: tt.interval, StartDate: tt.start}
+			assert.True(t, tt.want.Equal(occurrenceDate(template, tt.sequence)), "got %s", occurrenceDate(template, tt.sequence))
+		})
+	}
+}
+
+func TestRecurringRunPostsBalancedJournals(t *testing.T) {
+	db := setupJournalTestDB(t, &models.RecurringTemplate{}, &models.RecurringTemplateLine{}, &models.RecurringRun{},
+		&models.AccountingPeriod{}, &models.CashBank{}, &models.CashBankTransaction{})
+	accounts := seedTestAccounts(t, db, map[string]string{"5201": models.AccountTypeExpense, "1101": models.AccountTypeAsset})
+	cashBank := models.CashBank{Code: "CSH-001", Name: "Kas Kecil", Type: "CASH", AccountID: accounts["1101"].ID, Currency: "IDR", Balance: 10000000, IsActive: true}
+	require.NoError(t, db.Create(&cashBank).Error)
+	february := models.AccountingPeriod{
+		StartDate: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:   time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC),
+		IsClosed:  true,
+	}
+	require.NoError(t, db.Create(&february).Error)
+
+	start := time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)
+	template := models.RecurringTemplate{
+		Code: "RJ-0001", Name: "Sewa gudang", Frequency: models.RecurringFrequencyMonthly, IntervalCount: 1,
+		StartDate: start, NextRunDate: &start, AutoPost: true, ClosedPeriodAction: models.RecurringClosedPeriodQueue,
+		Status: models.RecurringStatusActive, CreatedBy: 1,
+		Lines: []models.RecurringTemplateLine{
+			{AccountID: accounts["5201"].ID, DebitAmount: 2500000},
+			{AccountID: accounts["1101"].ID, CreditAmount: 2500000},
+		},
+	}
+	require.NoError(t, db.Create(&template).Error)
+	service := NewRecurringJournalService(db)
+
+	result, err := service.RunDue(time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC), 1)
+	require.NoError(t, err)
+	assert.Empty(t, result.Errors)
+	assert.Equal(t, 2, result.Posted)
+	assert.Equal(t, 1, result.Queued, "february is closed")
+	entries := assertJournalsBalanced(t, db)
+	require.Len(t, entries, 2)
+	for _, entry := range entries {
+		assert.Equal(t, models.SSOTStatusPosted, entry.Status)
+		assert.Equal(t, map[string]float64{"5201": 2500000, "1101": -2500000}, journalAmountsByAccount(t, db, entry))
+	}
+	require.NoError(t, db.First(&cashBank, cashBank.ID).Error)
+	assert.InDelta(t, 5000000, cashBank.Balance, 0.001)
+
+	// Reopening february releases the queued run on its original date
+	require.NoError(t, db.Model(&february).Update("is_closed", false).Error)
+	result, err = service.RunDue(time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC), 1)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Released)
+	entries = assertJournalsBalanced(t, db)
+	require.Len(t, entries, 3)
+	assert.True(t, entries[2].EntryDate.Equal(february.EndDate))
+	require.NoError(t, db.First(&cashBank, cashBank.ID).Error)
+	assert.InDelta(t, 2500000, cashBank.Balance, 0.001)
+	var transactions int64
+	require.NoError(t, db.Model(&models.CashBankTransaction{}).Where("cash_bank_id = ?", cashBank.ID).Count(&transactions).Error)
+	assert.Equal(t, int64(3), transactions)
+}
@@ -17,6 +17,7 @@ type SalesJournalServiceSSOT struct {
 	db               *gorm.DB
 	coaService       *COAService
 	taxAccountHelper *TaxAccountHelper
+	costingService   *InventoryCostingService
 }
 
 // NewSalesJournalServiceSSOT creates a new instance
@@ -25,6 +26,7 @@ func NewSalesJournalServiceSSOT(db *gorm.DB, coaService *COAService) *SalesJourn
 		db:               db,
 		coaService:       coaService,
 		taxAccountHelper: NewTaxAccountHelper(db),
+		costingService:   NewInventoryCostingService(db),
 	}
 }
 
@@ -282,8 +284,21 @@ func (s *SalesJournalServiceSSOT) CreateSalesJournal(sale *models.Sale, tx *gorm
 		}
 	}
 
-	// Kelompokkan total pendapatan per akun
-	revenueTotals := make(map[uint64]decimal.Decimal)
+	// Dimensi analitik per item (cost center / proyek / tag)
+	itemDimensions, err := saleItemDimensions(dbToUse, sale.SaleItems)
+	if err != nil {
+		return err
+	}
+
+	// Kelompokkan total pendapatan per akun dan dimensi
+	type revenueKey struct {
+		accountID uint64
+		dimKey    string
+	}
+	revenueTotals := make(map[revenueKey]decimal.Decimal)
+	revenueDimensions := make(map[revenueKey]models.LineDimensions)
+	var revenueOrder []revenueKey
+	var codedDimensions []models.LineDimensions
 	defaultRevenueAccID := uint64(0)
 
 	for _, item := range sale.SaleItems {
@@ -305,7 +320,14 @@ func (s *SalesJournalServiceSSOT) CreateSalesJournal(sale *models.Sale, tx *gorm
 			accID = defaultRevenueAccID
 		}
 
-		revenueTotals[accID] = revenueTotals[accID].Add(amount)
+		dims := itemDimensions[item.ID]
+		key := revenueKey{accountID: accID, dimKey: dims.Key()}
+		if _, exists := revenueTotals[key]; !exists {
+			revenueOrder = append(revenueOrder, key)
+			revenueDimensions[key] = dims
+		}
+		revenueTotals[key] = revenueTotals[key].Add(amount)
+		codedDimensions = append(codedDimensions, dims)
 	}
 
 	// Jika tidak ada item (atau semua 0), fallback ke perilaku lama
@@ -321,14 +343,16 @@ func (s *SalesJournalServiceSSOT) CreateSalesJournal(sale *models.Sale, tx *gorm
 			Description:  fmt.Sprintf("Pendapatan Penjualan - %s", sale.InvoiceNumber),
 		})
 	} else {
-		for accID, amount := range revenueTotals {
+		for _, key := range revenueOrder {
+			amount := revenueTotals[key]
 			lines = append(lines, SalesJournalLineRequest{
-				AccountID:    accID,
+				AccountID:    key.accountID,
 				DebitAmount:  decimal.Zero,
 				CreditAmount: amount,
 				Description:  fmt.Sprintf("Pendapatan Penjualan - %s", sale.InvoiceNumber),
+				Dimensions:   revenueDimensions[key],
 			})
-			log.Printf("💰 [Revenue] Credit account %d amount %.2f for Sale #%d", accID, amount.InexactFloat64(), sale.ID)
+			log.Printf("💰 [Revenue] Credit account %d amount %.2f for Sale #%d", key.accountID, amount.InexactFloat64(), sale.ID)
 		}
 	}
 
@@ -386,6 +410,7 @@ func (s *SalesJournalServiceSSOT) CreateSalesJournal(sale *models.Sale, tx *gorm
 				DebitAmount:  decimal.Zero,
 				CreditAmount: decimal.NewFromFloat(sale.ShippingCost),
 				Description:  fmt.Sprintf("Pendapatan Ongkir - %s", sale.InvoiceNumber),
+				Dimensions:   commonDimensions(codedDimensions),
 			})
 			log.Printf("💰 [ShippingCost] Recorded: Rp %.2f", sale.ShippingCost)
 		} else {
@@ -469,84 +494,71 @@ func (s *SalesJournalServiceSSOT) CreateSalesJournal(sale *models.Sale, tx *gorm
 	}
 	
 
+	// Foreign currency invoice: everything above is in invoice currency, convert to base (IDR)
+	// and keep the original amounts on the lines. COGS below is already valued in base currency.
+	currencyCode := normalizeCurrencyCode(sale.Currency)
+	exchangeRate := documentExchangeRate(currencyCode, sale.ExchangeRate)
+	if isForeignCurrency(currencyCode) {
+		var foreignDiff, baseDiff decimal.Decimal
+		for i := range lines {
+			lines[i].ForeignDebitAmount = lines[i].DebitAmount
+			lines[i].ForeignCreditAmount = lines[i].CreditAmount
+			lines[i].DebitAmount = convertToBaseCurrency(lines[i].DebitAmount, exchangeRate)
+			lines[i].CreditAmount = convertToBaseCurrency(lines[i].CreditAmount, exchangeRate)
+			foreignDiff = foreignDiff.Add(lines[i].ForeignDebitAmount).Sub(lines[i].ForeignCreditAmount)
+			baseDiff = baseDiff.Add(lines[i].DebitAmount).Sub(lines[i].CreditAmount)
+		}
+		// Absorb conversion rounding in the receivable/cash line
+		if foreignDiff.IsZero() && !baseDiff.IsZero() && len(lines) > 0 {
+			lines[0].DebitAmount = lines[0].DebitAmount.Sub(baseDiff)
+		}
+		log.Printf("💱 [SSOT] Sale #%d converted from %s at rate %s", sale.ID, currencyCode, exchangeRate.String())
+	}
+
 	// ========================================
 	// 🔥 FIX CRITICAL: ADD COGS JOURNAL ENTRY
 	// ========================================
 	// 5. COGS Recording - Cost of Goods Sold
-	// Load sale items with products to calculate COGS
-	var saleWithItems models.Sale
-	if err := dbToUse.Preload("SaleItems.Product").First(&saleWithItems, sale.ID).Error; err != nil {
-		log.Printf("⚠️ Failed to load sale items for COGS calculation: %v", err)
-	} else {
-		var totalCOGS decimal.Decimal
-		var cogsDetails []string
-		
-		// Calculate total COGS from all sale items
-		for _, item := range saleWithItems.SaleItems {
-			// Check if product is loaded
-			if item.Product.ID == 0 {
-				log.Printf("⚠️ Sale item #%d has no product loaded, skipping COGS", item.ID)
-				continue
+	// COGS is valued by the costing engine from purchase receipt layers (FIFO/LIFO/Average)
+	// Service products (IsService=true) have no layers and do not reduce inventory account 1301
+	totalCOGS, costErr := s.costingService.CostSale(dbToUse, sale)
+	if costErr != nil {
+		return fmt.Errorf("failed to calculate COGS for sale #%d: %v", sale.ID, costErr)
+	}
+	// Only create COGS entry if total COGS > 0
+	if !totalCOGS.IsZero() {
+		// DEBIT: COGS Account - use configured account
+		cogsAccount, err := s.taxAccountHelper.GetCOGSAccount(dbToUse)
+		if err != nil {
+			log.Printf("⚠️ COGS account not found, skipping COGS entry: %v", err)
+		} else {
+			cogsLines, err := s.cogsLinesByDimension(dbToUse, sale, itemDimensions, totalCOGS)
+			if err != nil {
+				return err
 			}
-			
-			// ✅ NEW: Only treat NON-SERVICE products as inventory for COGS purposes
-			// This keeps behaviour consistent with purchase journals and stock service:
-			//  - Barang (IsService=false) → ada pergerakan persediaan & COGS
-			//  - Jasa  (IsService=true)  → TIDAK mengurangi akun persediaan 1301
-			if item.Product.IsService {
-				log.Printf("ℹ️ [COGS] Product '%s' (ID: %d) is marked as service, skipping COGS & inventory movement",
-					item.Product.Name, item.Product.ID)
-				continue
+			for _, cogsLine := range cogsLines {
+				cogsLine.AccountID = uint64(cogsAccount.ID)
+				lines = append(lines, cogsLine)
 			}
 			
-			// Calculate COGS: Quantity × Cost Price
-			itemCOGS := decimal.NewFromFloat(float64(item.Quantity)).
-				Mul(decimal.NewFromFloat(item.Product.CostPrice))
-			
-			if itemCOGS.IsZero() {
-				log.Printf("⚠️ Product '%s' (ID: %d) has zero cost price, COGS = 0", 
-					item.Product.Name, item.Product.ID)
-			} else {
-				totalCOGS = totalCOGS.Add(itemCOGS)
-				cogsDetails = append(cogsDetails, fmt.Sprintf("%s(Qty:%d×Rp%.0f)", 
-					item.Product.Name, item.Quantity, item.Product.CostPrice))
-			}
-		}
-		
-		// Only create COGS entry if total COGS > 0
-		if !totalCOGS.IsZero() {
-			// DEBIT: COGS Account - use configured account
-			cogsAccount, err := s.taxAccountHelper.GetCOGSAccount(dbToUse)
+			// CREDIT: Inventory Account - use configured account
+			inventoryAccount, err := s.taxAccountHelper.GetInventoryAccount(dbToUse)
 			if err != nil {
-				log.Printf("⚠️ COGS account not found, skipping COGS entry: %v", err)
+				log.Printf("⚠️ Inventory account not found, skipping inventory credit: %v", err)
 			} else {
 				lines = append(lines, SalesJournalLineRequest{
-					AccountID:    uint64(cogsAccount.ID),
-					DebitAmount:  totalCOGS,
-					CreditAmount: decimal.Zero,
-					Description:  fmt.Sprintf("HPP - %s", sale.InvoiceNumber),
+					AccountID:    uint64(inventoryAccount.ID),
+					DebitAmount:  decimal.Zero,
+					CreditAmount: totalCOGS,
+					Description:  fmt.Sprintf("Pengurangan Persediaan - %s", sale.InvoiceNumber),
 				})
 				
-				// CREDIT: Inventory Account - use configured account
-				inventoryAccount, err := s.taxAccountHelper.GetInventoryAccount(dbToUse)
-				if err != nil {
-					log.Printf("⚠️ Inventory account not found, skipping inventory credit: %v", err)
-				} else {
-					lines = append(lines, SalesJournalLineRequest{
-						AccountID:    uint64(inventoryAccount.ID),
-						DebitAmount:  decimal.Zero,
-						CreditAmount: totalCOGS,
-						Description:  fmt.Sprintf("Pengurangan Persediaan - %s", sale.InvoiceNumber),
-					})
-					
-					log.Printf("💰 [COGS] Calculated COGS for Sale #%d: Rp %.2f (%d items: %s)", 
-						sale.ID, totalCOGS.InexactFloat64(), len(cogsDetails), 
-						strings.Join(cogsDetails, ", "))
-				}
+				log.Printf("💰 [COGS] Calculated COGS for Sale #%d from cost layers: Rp %.2f",
+					sale.ID, totalCOGS.InexactFloat64())
 			}
-		} else {
-			log.Printf("⚠️ [COGS] No COGS calculated for Sale #%d (all items have zero cost price)", sale.ID)
 		}
+	} else {
+		log.Printf("⚠️ [COGS] No COGS calculated for Sale #%d (no stock items or zero cost)", sale.ID)
 	}
 	// ========================================
 	// END COGS FIX
@@ -626,6 +638,15 @@ func (s *SalesJournalServiceSSOT) CreateSalesJournal(sale *models.Sale, tx *gorm
 			difference.InexactFloat64(), toleranceThreshold.InexactFloat64())
 	}
 
+	// Validate dimensions and mandatory dimension rules before anything is written
+	dimensionLines := make([]dimensionLine, 0, len(lines))
+	for _, l := range lines {
+		dimensionLines = append(dimensionLines, dimensionLine{AccountID: l.AccountID, Dimensions: l.Dimensions})
+	}
+	if err := checkJournalDimensions(dbToUse, models.SSOTSourceTypeSale, dimensionLines); err != nil {
+		return fmt.Errorf("sale #%d: %v", sale.ID, err)
+	}
+
 	// Create journal entry
 	// Insert as DRAFT first to avoid trigger validation before lines are created
 	sourceID := uint64(sale.ID)
@@ -666,10 +687,20 @@ func (s *SalesJournalServiceSSOT) CreateSalesJournal(sale *models.Sale, tx *gorm
 			CreatedAt:    now,
 			UpdatedAt:    now,
 		}
+		applyLineDimensions(journalLine, lineReq.Dimensions)
+		if !lineReq.ForeignDebitAmount.IsZero() || !lineReq.ForeignCreditAmount.IsZero() {
+			journalLine.CurrencyCode = currencyCode
+			journalLine.ExchangeRate = exchangeRate
+			journalLine.ForeignDebitAmount = lineReq.ForeignDebitAmount
+			journalLine.ForeignCreditAmount = lineReq.ForeignCreditAmount
+		}
 
 		if err := dbToUse.Create(journalLine).Error; err != nil {
 			return fmt.Errorf("failed to create SSOT journal line: %v", err)
 		}
+		if err := saveDimensionTags(dbToUse, models.DimensionOwnerJournalLine, journalLine.ID, lineReq.Dimensions.TagIDs); err != nil {
+			return err
+		}
 
 		// ✅ RE-ENABLED: Update account balance for COA tree view
 		// P&L uses journal entries (correct), but COA Tree View uses account.balance field
@@ -831,15 +862,123 @@ func (s *SalesJournalServiceSSOT) DeleteSalesJournal(saleID uint, tx *gorm.DB) e
 		return fmt.Errorf("failed to delete journal entry: %v", err)
 	}
 
+	// Put the consumed quantities back on their receipt layers so a rebuilt journal re-costs the sale
+	if err := s.costingService.ReleaseSale(dbToUse, saleID); err != nil {
+		return fmt.Errorf("failed to release sale cost layers: %v", err)
+	}
+
 	log.Printf("✅ [SSOT] Deleted journal entry #%d and its lines for Sale #%d", entry.ID, saleID)
 	return nil
 }
 
+// saleItemDimensions returns the analytic dimensions of each sale item, including its tags
+func saleItemDimensions(tx *gorm.DB, items []models.SaleItem) (map[uint]models.LineDimensions, error) {
+	ids := make([]uint64, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, uint64(item.ID))
+	}
+	tags, err := loadDimensionTags(tx, models.DimensionOwnerSaleItem, ids)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[uint]models.LineDimensions, len(items))
+	for _, item := range items {
+		result[item.ID] = models.LineDimensions{
+			CostCenterID: item.CostCenterID,
+			ProjectID:    item.ProjectID,
+			TagIDs:       tags[uint64(item.ID)],
+		}
+	}
+	return result, nil
+}
+
+// saveSaleItemDimensions validates the dimensions of saved sale items and stores their tags
+func saveSaleItemDimensions(tx *gorm.DB, items []models.SaleItem) error {
+	dims := make([]models.LineDimensions, 0, len(items))
+	for _, item := range items {
+		dims = append(dims, models.LineDimensions{CostCenterID: item.CostCenterID, ProjectID: item.ProjectID, TagIDs: item.TagIDs})
+	}
+	if err := validateLineDimensions(tx, dims...); err != nil {
+		return err
+	}
+	for _, item := range items {
+		if err := saveDimensionTags(tx, models.DimensionOwnerSaleItem, uint64(item.ID), item.TagIDs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cogsLinesByDimension splits the COGS debit by the dimensions of the sold items using the
+// per-item cost layers. Without dimensions on the items a single line is returned.
+func (s *SalesJournalServiceSSOT) cogsLinesByDimension(tx *gorm.DB, sale *models.Sale, itemDimensions map[uint]models.LineDimensions, totalCOGS decimal.Decimal) ([]SalesJournalLineRequest, error) {
+	description := fmt.Sprintf("HPP - %s", sale.InvoiceNumber)
+	single := []SalesJournalLineRequest{{DebitAmount: totalCOGS, CreditAmount: decimal.Zero, Description: description}}
+
+	coded := false
+	for _, dims := range itemDimensions {
+		if !dims.IsEmpty() {
+			coded = true
+			break
+		}
+	}
+	if !coded {
+		return single, nil
+	}
+
+	var itemCosts []struct {
+		SaleItemID uint
+		TotalCost  float64
+	}
+	if err := tx.Model(&models.SaleCostLayer{}).
+		Select("sale_item_id, SUM(total_cost) AS total_cost").
+		Where("sale_id = ?", sale.ID).
+		Group("sale_item_id").
+		Order("sale_item_id").
+		Scan(&itemCosts).Error; err != nil {
+		return nil, fmt.Errorf("failed to load cost layers for sale #%d: %v", sale.ID, err)
+	}
+
+	byKey := make(map[string]int)
+	var lines []SalesJournalLineRequest
+	allocated := decimal.Zero
+	for _, ic := range itemCosts {
+		amount := decimal.NewFromFloat(ic.TotalCost)
+		if amount.IsZero() {
+			continue
+		}
+		dims := itemDimensions[ic.SaleItemID]
+		idx, ok := byKey[dims.Key()]
+		if !ok {
+			idx = len(lines)
+			byKey[dims.Key()] = idx
+			lines = append(lines, SalesJournalLineRequest{CreditAmount: decimal.Zero, Description: description, Dimensions: dims})
+		}
+		lines[idx].DebitAmount = lines[idx].DebitAmount.Add(amount)
+		allocated = allocated.Add(amount)
+	}
+	if len(lines) == 0 {
+		return single, nil
+	}
+	// Keep the journal balanced against the costing total
+	if diff := totalCOGS.Sub(allocated); !diff.IsZero() {
+		lines[len(lines)-1].DebitAmount = lines[len(lines)-1].DebitAmount.Add(diff)
+	}
+	return lines, nil
+}
+
 // SalesJournalLineRequest represents a request to create a sales journal line
 type SalesJournalLineRequest struct {
 	AccountID    uint64
 	DebitAmount  decimal.Decimal
 	CreditAmount decimal.Decimal
 	Description  string
+
+	// Original invoice currency amounts (zero for base currency sales)
+	ForeignDebitAmount  decimal.Decimal
+	ForeignCreditAmount decimal.Decimal
+
+	// Analytic dimensions taken from the sale items
+	Dimensions models.LineDimensions
 }
 
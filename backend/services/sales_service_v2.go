@@ -21,6 +21,8 @@ type SalesServiceV2 struct {
 	notificationService    *NotificationService
 	settingsService        *SettingsService
 	invoiceNumberService   *InvoiceNumberService
+	creditControlService   *CreditControlService // Credit limit, credit hold and overdue checks
+	currencyService        *CurrencyService      // Settlement of foreign currency invoices
 }
 
 // NewSalesServiceV2 creates a new instance of SalesServiceV2
@@ -48,6 +50,8 @@ func NewSalesServiceV2(
 		notificationService:    notificationService,
 		settingsService:        settingsService,
 		invoiceNumberService:   invoiceNumberService,
+		creditControlService:   NewCreditControlService(db, NewApprovalService(db), settingsService),
+		currencyService:        NewCurrencyService(db),
 	}
 }
 
@@ -217,6 +221,10 @@ func (s *SalesServiceV2) CreateSale(request models.SaleCreateRequest, userID uin
 			DiscountPercent: *discountPercent,
 			Taxable:         getOrDefault(itemRequest.Taxable, true),
 			RevenueAccountID: revenueAccountID,
+			WarehouseLocationID: itemRequest.WarehouseLocationID,
+			CostCenterID:    itemRequest.CostCenterID,
+			ProjectID:       itemRequest.ProjectID,
+			TagIDs:          itemRequest.TagIDs,
 		}
 
 		// Calculate item totals
@@ -300,6 +308,10 @@ func (s *SalesServiceV2) CreateSale(request models.SaleCreateRequest, userID uin
 		tx.Rollback()
 		return nil, fmt.Errorf("failed to create sale: %v", err)
 	}
+	if err := saveSaleItemDimensions(tx, sale.SaleItems); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
 
 	// Load relationships
 	if err := tx.Preload("Customer").Preload("SaleItems.Product").First(&sale, sale.ID).Error; err != nil {
@@ -422,6 +434,12 @@ func (s *SalesServiceV2) UpdateSale(saleID uint, request models.SaleUpdateReques
 
 	// Recalculate if items are updated
 	if request.Items != nil {
+		// Items on delivery orders cannot be replaced
+		if err := s.ensureNoOpenDeliveries(tx, &sale); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+
 		// Delete old items
 		if err := tx.Where("sale_id = ?", sale.ID).Delete(&models.SaleItem{}).Error; err != nil {
 			tx.Rollback()
@@ -469,6 +487,10 @@ func (s *SalesServiceV2) UpdateSale(saleID uint, request models.SaleUpdateReques
 				DiscountPercent: *discountPercent,
 				Taxable:         getOrDefault(itemRequest.Taxable, true),
 				RevenueAccountID: revenueAccountID,
+				WarehouseLocationID: itemRequest.WarehouseLocationID,
+				CostCenterID:    itemRequest.CostCenterID,
+				ProjectID:       itemRequest.ProjectID,
+				TagIDs:          itemRequest.TagIDs,
 			}
 
 			// Calculate item totals
@@ -591,6 +613,12 @@ func (s *SalesServiceV2) UpdateSale(saleID uint, request models.SaleUpdateReques
 		tx.Rollback()
 		return nil, fmt.Errorf("failed to update sale: %v", err)
 	}
+	if request.Items != nil {
+		if err := saveSaleItemDimensions(tx, sale.SaleItems); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
 
 	// Handle journal updates based on status
 	if err := s.salesJournalService.UpdateSalesJournal(&sale, oldStatus, tx); err != nil {
@@ -629,6 +657,12 @@ func (s *SalesServiceV2) ConfirmSale(saleID uint, userID uint) (*models.Sale, er
 		return nil, fmt.Errorf("only DRAFT sales can be confirmed")
 	}
 
+	// Credit control: block or route to approval when the customer is on hold, over limit or overdue
+	if err := s.creditControlService.EnforceSale(&sale, userID); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
 	_ = sale.Status // oldStatus not needed for CONFIRMED
 	sale.Status = "CONFIRMED"
 	sale.UpdatedAt = time.Now()
@@ -681,10 +715,34 @@ func (s *SalesServiceV2) CreateInvoice(saleID uint, userID uint) (*models.Sale,
 		return nil, fmt.Errorf("only DRAFT or CONFIRMED sales can be invoiced (current status: %s)", sale.Status)
 	}
 
+	// Sales orders shipped on delivery orders are invoiced for the shipped quantities only;
+	// stock already left the warehouse on the delivery orders
+	shipped, err := saleHasDeliveries(tx, sale.ID)
+	if err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("failed to check delivery orders: %v", err)
+	}
+	if shipped {
+		if _, err := splitShippedSale(tx, &sale, s.settingsService); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+
+	// Credit control: the invoice is re-checked since exposure may have changed after confirmation
+	if err := s.creditControlService.EnforceSale(&sale, userID); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
 	// ✅ VALIDATE STOCK BEFORE INVOICE (Early Check)
 	// Prevent transaction rollback in the middle of journal creation
 	log.Printf("🔍 Validating stock availability before invoice for Sale #%d", sale.ID)
-	for _, item := range sale.SaleItems {
+	stockItems := sale.SaleItems
+	if shipped {
+		stockItems = nil // Checked and reduced on the delivery orders
+	}
+	for _, item := range stockItems {
 		var product models.Product
 		if err := tx.First(&product, item.ProductID).Error; err != nil {
 			tx.Rollback()
@@ -698,20 +756,31 @@ func (s *SalesServiceV2) CreateInvoice(saleID uint, userID uint) (*models.Sale,
 		}
 		
 		// Check stock availability BEFORE starting any journal/COGS process
-		if product.Stock == 0 {
+		// When the item names a warehouse, only that warehouse's stock counts
+		available := product.Stock
+		if s.stockService != nil && item.WarehouseLocationID != nil {
+			whStock, err := s.stockService.GetStock(product.ID, item.WarehouseLocationID)
+			if err != nil {
+				tx.Rollback()
+				return nil, fmt.Errorf("failed to check warehouse stock for product '%s': %v", product.Name, err)
+			}
+			available = whStock
+		}
+
+		if available == 0 {
 			tx.Rollback()
 			return nil, fmt.Errorf("stock tidak cukup untuk product '%s'. Tersedia: %d, Diminta: %d (Stock habis, tidak bisa membuat invoice)", 
-				product.Name, product.Stock, item.Quantity)
+				product.Name, available, item.Quantity)
 		}
 		
-		if product.Stock < item.Quantity {
+		if available < item.Quantity {
 			tx.Rollback()
 			return nil, fmt.Errorf("stock tidak cukup untuk product '%s'. Tersedia: %d, Diminta: %d", 
-				product.Name, product.Stock, item.Quantity)
+				product.Name, available, item.Quantity)
 		}
 		
 		log.Printf("✅ Stock check passed: %s (Available: %d, Required: %d)", 
-			product.Name, product.Stock, item.Quantity)
+			product.Name, available, item.Quantity)
 	}
 	log.Printf("✅ All stock validations passed for Sale #%d", sale.ID)
 
@@ -818,7 +887,7 @@ func (s *SalesServiceV2) CreateInvoice(saleID uint, userID uint) (*models.Sale,
 	}
 
 	// Update stock - CRITICAL: Block transaction if stock insufficient
-	if s.stockService != nil {
+	if s.stockService != nil && !shipped {
 		for _, item := range sale.SaleItems {
 			// Load product to check if it's a service
 			var product models.Product
@@ -847,7 +916,7 @@ func (s *SalesServiceV2) CreateInvoice(saleID uint, userID uint) (*models.Sale,
 			}
 			
 			// Reduce stock
-			if err := s.stockService.ReduceStock(item.ProductID, item.Quantity, tx); err != nil {
+			if err := s.stockService.ReduceStock(item.ProductID, item.WarehouseLocationID, item.Quantity, tx); err != nil {
 				tx.Rollback()
 				return nil, fmt.Errorf("gagal mengurangi stock untuk product '%s': %v", product.Name, err)
 			}
@@ -857,6 +926,12 @@ func (s *SalesServiceV2) CreateInvoice(saleID uint, userID uint) (*models.Sale,
 		}
 	}
 
+	// Webhook outbox: sale.invoiced is delivered only if the invoice commits
+	if err := recordWebhookEvent(tx, models.WebhookEventSaleInvoiced, "sale", sale.ID, saleInvoicedWebhookData(&sale)); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
 	if err := tx.Commit().Error; err != nil {
 		return nil, fmt.Errorf("failed to commit transaction: %v", err)
 	}
@@ -907,9 +982,17 @@ func (s *SalesServiceV2) ProcessPayment(saleID uint, paymentRequest models.SaleP
 		return nil, fmt.Errorf("failed to create payment: %v", err)
 	}
 
-	// Update sale payment amounts
-	sale.PaidAmount += payment.Amount
-	sale.OutstandingAmount = sale.TotalAmount - sale.PaidAmount
+	// Update sale payment amounts; a foreign currency invoice is settled in invoice currency at the payment rate
+	settlement, err := s.currencyService.NewDocumentSettlement(tx, models.FXItemTypeReceivable, sale.InvoiceNumber,
+		sale.Currency, sale.ExchangeRate, sale.OutstandingAmount, payment.PaymentDate, paymentRequest.ExchangeRate)
+	if err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("invoice %v", err)
+	}
+	settledAmount, fxSettlement := settlement.Settle(payment.Amount)
+	outstanding := sale.TotalAmount - sale.PaidAmount
+	sale.PaidAmount += settledAmount
+	sale.OutstandingAmount = settledOutstanding(outstanding, settledAmount, fxSettlement)
 
 	// Update status if fully paid
 	if sale.OutstandingAmount <= 0 {
@@ -928,6 +1011,12 @@ func (s *SalesServiceV2) ProcessPayment(saleID uint, paymentRequest models.SaleP
 		return nil, fmt.Errorf("failed to create payment journal: %v", err)
 	}
 
+	// Realized FX gain/loss against the rate the invoice was booked at
+	if _, err := s.currencyService.PostRealizedFXGainLoss(tx, fxSettlement, payment.ID, sale.InvoiceNumber, payment.PaymentDate, userID); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
 	// ✅ CRITICAL FIX: Update cash_banks.balance for Cash & Bank Management view
 	// This is SEPARATE from accounts.balance (COA tree view) which is updated by journal entries
 	if payment.CashBankID != nil && *payment.CashBankID > 0 {
@@ -968,6 +1057,13 @@ func (s *SalesServiceV2) ProcessPayment(saleID uint, paymentRequest models.SaleP
 
 	log.Printf("✅ Payment #%d created for Sale #%d (Amount: %.2f)", payment.ID, sale.ID, payment.Amount)
 
+	webhookData := paymentReceivedWebhookData(payment.ID, "", sale.CustomerID, payment.PaymentDate, payment.Amount,
+		payment.PaymentMethod, payment.Reference, []map[string]interface{}{{"sale_id": sale.ID, "amount": payment.Amount}})
+	if err := recordWebhookEvent(tx, models.WebhookEventPaymentReceived, "sale_payment", payment.ID, webhookData); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
 	if err := tx.Commit().Error; err != nil {
 		return nil, fmt.Errorf("failed to commit transaction: %v", err)
 	}
@@ -989,6 +1085,10 @@ func (s *SalesServiceV2) CancelSale(saleID uint, reason string, userID uint) err
 		tx.Rollback()
 		return fmt.Errorf("sale not found")
 	}
+	if err := s.ensureNoOpenDeliveries(tx, &sale); err != nil {
+		tx.Rollback()
+		return err
+	}
 
 	oldStatus := sale.Status
 	sale.Status = "CANCELLED"
@@ -1014,11 +1114,17 @@ func (s *SalesServiceV2) CancelSale(saleID uint, reason string, userID uint) err
 		var saleItems []models.SaleItem
 		if err := tx.Where("sale_id = ?", sale.ID).Find(&saleItems).Error; err == nil {
 			for _, item := range saleItems {
-				if err := s.stockService.RestoreStock(item.ProductID, item.Quantity, tx); err != nil {
+				if err := s.stockService.RestoreStock(item.ProductID, item.WarehouseLocationID, item.Quantity, tx); err != nil {
 					log.Printf("⚠️ Warning: Failed to restore stock for product %d: %v", item.ProductID, err)
 				}
 			}
 		}
+		if s.cogsService != nil {
+			if err := s.cogsService.ReleaseCOGSForSale(sale.ID, tx); err != nil {
+				tx.Rollback()
+				return fmt.Errorf("failed to release sale cost layers: %v", err)
+			}
+		}
 	}
 
 	log.Printf("❌ Sale #%d cancelled (status: %s → CANCELLED)", sale.ID, oldStatus)
@@ -1030,6 +1136,27 @@ func (s *SalesServiceV2) CancelSale(saleID uint, reason string, userID uint) err
 	return nil
 }
 
+// ensureNoOpenDeliveries rejects changes to a sales order whose goods are out on delivery orders
+// that have not been invoiced yet; the delivery orders must be cancelled first
+func (s *SalesServiceV2) ensureNoOpenDeliveries(tx *gorm.DB, sale *models.Sale) error {
+	if sale.Status != models.SaleStatusConfirmed {
+		return nil
+	}
+	shipped, err := saleHasDeliveries(tx, sale.ID)
+	if err != nil {
+		return fmt.Errorf("failed to check delivery orders: %v", err)
+	}
+	if shipped {
+		return fmt.Errorf("sale %s has delivery orders; cancel them first", sale.Code)
+	}
+	return nil
+}
+
+// GetReceivablesReport returns outstanding invoices and open customer down payments
+func (s *SalesServiceV2) GetReceivablesReport() (*models.ReceivablesReportResponse, error) {
+	return s.salesRepo.GetReceivablesReport()
+}
+
 // GetSales retrieves sales with filters
 func (s *SalesServiceV2) GetSales(filter models.SalesFilter) (*models.SalesResult, error) {
 	query := s.db.Model(&models.Sale{}).Preload("Customer").Preload("SaleItems")
@@ -1068,6 +1195,7 @@ func (s *SalesServiceV2) GetSales(filter models.SalesFilter) (*models.SalesResul
 			Where("sales.invoice_number ILIKE ? OR sales.code ILIKE ? OR sales.reference ILIKE ? OR contacts.name ILIKE ?",
 				searchPattern, searchPattern, searchPattern, searchPattern)
 	}
+	query = repositories.ApplySalesScope(query, filter.Scope)
 
 	// Count total
 	var total int64
@@ -1117,6 +1245,10 @@ func (s *SalesServiceV2) DeleteSale(saleID uint) error {
 		tx.Rollback()
 		return fmt.Errorf("sale not found")
 	}
+	if err := s.ensureNoOpenDeliveries(tx, &sale); err != nil {
+		tx.Rollback()
+		return err
+	}
 
 	// Remove journal entries if they exist
 	if s.salesJournalService.ShouldPostToJournal(sale.Status) {
@@ -1132,6 +1264,12 @@ func (s *SalesServiceV2) DeleteSale(saleID uint) error {
 		return fmt.Errorf("failed to delete sale: %v", err)
 	}
 
+	// Free the quote it came from so it can be converted again
+	if err := tx.Model(&models.Quote{}).Where("sale_id = ?", sale.ID).Update("sale_id", nil).Error; err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to unlink quote: %v", err)
+	}
+
 	log.Printf("🗑️ Deleted sale #%d", saleID)
 
 	if err := tx.Commit().Error; err != nil {
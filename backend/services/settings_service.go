@@ -179,6 +179,33 @@ func (s *SettingsService) validateSettings(updates map[string]interface{}) error
 			}
 		}
 	}
+	// Validate credit control
+	if action, ok := updates["credit_control_action"].(string); ok {
+		if action != models.CreditControlActionBlock && action != models.CreditControlActionApproval {
+			return errors.New("credit_control_action must be 'BLOCK' or 'APPROVAL'")
+		}
+	}
+	if days, ok := updates["credit_overdue_days"].(float64); ok {
+		if days < 0 || days != float64(int(days)) {
+			return errors.New("credit_overdue_days must be a whole number of days, 0 or more")
+		}
+	}
+	// Validate email (SMTP) settings
+	if encryption, ok := updates["smtp_encryption"].(string); ok {
+		if encryption != models.SMTPEncryptionNone && encryption != models.SMTPEncryptionStartTLS && encryption != models.SMTPEncryptionSSL {
+			return errors.New("smtp_encryption must be 'NONE', 'STARTTLS' or 'SSL'")
+		}
+	}
+	if port, ok := updates["smtp_port"].(float64); ok {
+		if port < 1 || port > 65535 || port != float64(int(port)) {
+			return errors.New("smtp_port must be between 1 and 65535")
+		}
+	}
+	if fromAddress, ok := updates["email_from_address"].(string); ok {
+		if fromAddress != "" && !isValidEmail(fromAddress) {
+			return errors.New("invalid email_from_address format")
+		}
+	}
 	// No more validation for next numbers — now using monthly sequences
 	return nil
 }
@@ -216,6 +243,7 @@ func (s *SettingsService) createDefaultSettings() models.Settings {
 		JournalPrefix:          journalPrefix,
 		JournalNextNumber:      1,
 		RequireJournalApproval: requireApproval,
+		CreditControlAction:    models.CreditControlActionBlock,
 	}
 }
 
@@ -569,6 +597,7 @@ func (s *SettingsService) GetValidationRules() map[string]interface{} {
 		"decimal_places_range": map[string]int{"min": 0, "max": 4},
 		"prefix_max_length": 10,
 		"min_next_number":  1,
+		"credit_control_actions": []string{models.CreditControlActionBlock, models.CreditControlActionApproval},
 	}
 }
 
@@ -690,6 +719,11 @@ func (s *SettingsService) logSettingsChanges(settingsID uint, oldValues, newValu
 			continue
 		}
 		
+		// Never write the SMTP password to history
+		if field == "smtp_password" {
+			oldValue, newValue = "********", "********"
+		}
+		
 		// Convert values to JSON strings
 		oldValueJSON, _ := json.Marshal(oldValue)
 		newValueJSON, _ := json.Marshal(newValue)
@@ -217,22 +217,7 @@ func (s *SmartNotificationService) userWantsNotification(userID uint, notificati
 	}
 
 	// Check specific notification type preferences
-	switch notificationType {
-	case models.NotificationTypeApprovalPending:
-		return pref.ApprovalPending
-	case models.NotificationTypeApprovalApproved:
-		return pref.ApprovalApproved
-	case models.NotificationTypeApprovalRejected:
-		return pref.ApprovalRejected
-	case models.NotificationTypeApprovalEscalated:
-		return pref.ApprovalEscalated
-	case models.NotificationTypeLowStock, models.NotificationTypeStockOut:
-		return pref.StockAlerts
-	case models.NotificationTypePaymentDue:
-		return pref.PaymentReminders
-	default:
-		return pref.SystemAlerts
-	}
+	return pref.WantsType(notificationType)
 }
 
 // isInQuietHours checks if current time is in user's quiet hours
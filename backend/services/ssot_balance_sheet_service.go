@@ -1026,3 +1026,49 @@ func (s *SSOTBalanceSheetService) removePPNAccountsFromDisplay(bsData *SSOTBalan
 		fmt.Printf("[DEBUG]   %s - %s: %.2f\n", item.AccountCode, item.AccountName, item.Amount)
 	}
 }
+
+// GenerateComparativeBalanceSheet generates the balance sheet with one column per as-of date (column end_date)
+func (s *SSOTBalanceSheetService) GenerateComparativeBalanceSheet(columns []ComparativeColumn) (*ComparativeStatement, error) {
+	columns, err := prepareComparativeColumns(columns, ComparativeStatementBalanceSheet)
+	if err != nil {
+		return nil, err
+	}
+
+	builder := newComparativeBuilder(columns)
+	for i, column := range columns {
+		bsData, err := s.GenerateSSOTBalanceSheet(column.EndDate)
+		if err != nil {
+			return nil, fmt.Errorf("column %s: %v", column.Key, err)
+		}
+		if i == 0 {
+			builder.company = bsData.Company
+		}
+		addBalanceSheetComparativeRows(builder, i, bsData)
+	}
+	return builder.build(ComparativeStatementBalanceSheet, "Balance Sheet"), nil
+}
+
+// addBalanceSheetComparativeRows adds the sections and totals of one balance sheet column
+func addBalanceSheetComparativeRows(b *comparativeBuilder, column int, bs *SSOTBalanceSheetData) {
+	addItems := func(order int, section string, items []BSAccountItem) {
+		for _, item := range items {
+			b.account(column, order, section, item.AccountCode, item.AccountName, item.Amount)
+		}
+	}
+
+	addItems(10, "CURRENT_ASSETS", bs.Assets.CurrentAssets.Items)
+	b.total(column, 10, "CURRENT_ASSETS", "total_current_assets", "Total Current Assets", bs.Assets.CurrentAssets.TotalCurrentAssets)
+	addItems(11, "NON_CURRENT_ASSETS", bs.Assets.NonCurrentAssets.Items)
+	b.total(column, 11, "NON_CURRENT_ASSETS", "total_non_current_assets", "Total Non-Current Assets", bs.Assets.NonCurrentAssets.TotalNonCurrentAssets)
+	b.total(column, 12, "ASSETS", "total_assets", "Total Assets", bs.Assets.TotalAssets)
+
+	addItems(20, "CURRENT_LIABILITIES", bs.Liabilities.CurrentLiabilities.Items)
+	b.total(column, 20, "CURRENT_LIABILITIES", "total_current_liabilities", "Total Current Liabilities", bs.Liabilities.CurrentLiabilities.TotalCurrentLiabilities)
+	addItems(21, "NON_CURRENT_LIABILITIES", bs.Liabilities.NonCurrentLiabilities.Items)
+	b.total(column, 21, "NON_CURRENT_LIABILITIES", "total_non_current_liabilities", "Total Non-Current Liabilities", bs.Liabilities.NonCurrentLiabilities.TotalNonCurrentLiabilities)
+	b.total(column, 22, "LIABILITIES", "total_liabilities", "Total Liabilities", bs.Liabilities.TotalLiabilities)
+
+	addItems(30, "EQUITY", bs.Equity.Items)
+	b.total(column, 30, "EQUITY", "total_equity", "Total Equity", bs.Equity.TotalEquity)
+	b.total(column, 40, "LIABILITIES_EQUITY", "total_liabilities_and_equity", "Total Liabilities and Equity", bs.TotalLiabilitiesAndEquity)
+}
@@ -2,6 +2,7 @@ package services
 
 import (
 	"fmt"
+	"math"
 	"strings"
 	"time"
 	"gorm.io/gorm"
@@ -97,6 +98,10 @@ type SSOTCashFlowData struct {
 
 	// Account Details for Drilldown
 	AccountDetails           []SSOTAccountBalance         `json:"account_details,omitempty"`
+
+	// Method is "indirect" (default) or "direct"; DirectMethod is only filled in direct mode
+	Method                   string                       `json:"method"`
+	DirectMethod             *SSOTDirectCashFlow          `json:"direct_method,omitempty"`
 }
 
 // CFSectionItem represents an item within a Cash Flow section
@@ -110,6 +115,20 @@ type CFSectionItem struct {
 
 // GenerateSSOTCashFlow generates Cash Flow statement from SSOT journal system
 func (s *SSOTCashFlowService) GenerateSSOTCashFlow(startDate, endDate string) (*SSOTCashFlowData, error) {
+	return s.GenerateSSOTCashFlowWithMethod(startDate, endDate, CashFlowMethodIndirect)
+}
+
+// GenerateSSOTCashFlowWithMethod generates the Cash Flow statement using the indirect method, or the
+// direct method (PSAK 2) together with the indirect figures it reconciles to
+func (s *SSOTCashFlowService) GenerateSSOTCashFlowWithMethod(startDate, endDate, method string) (*SSOTCashFlowData, error) {
+	method = strings.ToLower(strings.TrimSpace(method))
+	if method == "" {
+		method = CashFlowMethodIndirect
+	}
+	if method != CashFlowMethodIndirect && method != CashFlowMethodDirect {
+		return nil, fmt.Errorf("invalid cash flow method %q, use indirect or direct", method)
+	}
+
 	// Default to current fiscal year when parameters are empty
 	if strings.TrimSpace(startDate) == "" || strings.TrimSpace(endDate) == "" {
 		settingsSvc := NewSettingsService(s.db)
@@ -153,6 +172,15 @@ func (s *SSOTCashFlowService) GenerateSSOTCashFlow(startDate, endDate string) (*
 	
 	// Generate Cash Flow data structure
 	cfData := s.generateCashFlowFromTransactions(cashFlowTransactions, netIncome, beginningCash, endingCash, start, end)
+	cfData.Method = method
+	
+	if method == CashFlowMethodDirect {
+		counterparts, err := s.getCashCounterpartsFromSSOT(startDate, endExclusive)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get cash counterpart lines: %v", err)
+		}
+		cfData.DirectMethod = s.generateDirectCashFlow(counterparts, cfData)
+	}
 	
 	return cfData, nil
 }
@@ -207,10 +235,10 @@ func (s *SSOTCashFlowService) getNetIncomeFromSSOT(startDate, endDate string) (f
 					WHEN a.code LIKE '4%' THEN 
 						COALESCE(ujl.credit_amount, 0) - COALESCE(ujl.debit_amount, 0)
 					WHEN a.code LIKE '5%' OR a.code LIKE '6%' OR a.code LIKE '7%' THEN 
-						COALESCE(ujl.debit_amount, 0) - COALESCE(ujl.credit_amount, 0)
+						COALESCE(ujl.credit_amount, 0) - COALESCE(ujl.debit_amount, 0)
 					ELSE 0
 				END), 0
-			) * -1 as net_income
+			) as net_income
 		FROM accounts a
 		LEFT JOIN unified_journal_lines ujl ON ujl.account_id = a.id
 		LEFT JOIN unified_journal_ledger uje ON uje.id = ujl.journal_id
@@ -599,3 +627,297 @@ func (s *SSOTCashFlowService) calculateCashFlowTotals(cfData *SSOTCashFlowData)
 	}
 }
 
+
+// ========== DIRECT METHOD ==========
+
+// Cash flow methods
+const (
+	CashFlowMethodIndirect = "indirect"
+	CashFlowMethodDirect   = "direct"
+)
+
+// Direct method categories
+const (
+	CFDirectReceiptsFromCustomers = "RECEIPTS_FROM_CUSTOMERS"
+	CFDirectPaymentsToSuppliers   = "PAYMENTS_TO_SUPPLIERS"
+	CFDirectPaymentsToEmployees   = "PAYMENTS_TO_EMPLOYEES"
+	CFDirectTaxesPaid             = "TAXES_PAID"
+	CFDirectOtherOperating        = "OTHER_OPERATING"
+	CFDirectInvesting             = "INVESTING"
+	CFDirectFinancing             = "FINANCING"
+)
+
+// CFDirectItem is the cash moved against one counterpart account (positive = receipt, negative = payment)
+type CFDirectItem struct {
+	Category    string  `json:"category"`
+	AccountID   uint    `json:"account_id,omitempty"`
+	AccountCode string  `json:"account_code"`
+	AccountName string  `json:"account_name"`
+	Amount      float64 `json:"amount"`
+	Type        string  `json:"type"` // inflow, outflow
+}
+
+// SSOTDirectCashFlow is the direct-method statement: cash journal lines classified by their counterpart accounts
+type SSOTDirectCashFlow struct {
+	OperatingActivities struct {
+		ReceiptsFromCustomers  float64        `json:"receipts_from_customers"`
+		PaymentsToSuppliers    float64        `json:"payments_to_suppliers"`
+		PaymentsToEmployees    float64        `json:"payments_to_employees"`
+		TaxesPaid              float64        `json:"taxes_paid"`
+		OtherOperating         float64        `json:"other_operating"`
+		TotalOperatingCashFlow float64        `json:"total_operating_cash_flow"`
+		Items                  []CFDirectItem `json:"items"`
+	} `json:"operating_activities"`
+
+	InvestingActivities struct {
+		TotalInvestingCashFlow float64        `json:"total_investing_cash_flow"`
+		Items                  []CFDirectItem `json:"items"`
+	} `json:"investing_activities"`
+
+	FinancingActivities struct {
+		TotalFinancingCashFlow float64        `json:"total_financing_cash_flow"`
+		Items                  []CFDirectItem `json:"items"`
+	} `json:"financing_activities"`
+
+	NetCashFlow     float64 `json:"net_cash_flow"`
+	CashAtBeginning float64 `json:"cash_at_beginning"`
+	CashAtEnd       float64 `json:"cash_at_end"`
+
+	// Reconciliation of the direct operating cash flow to the indirect method
+	Reconciliation struct {
+		NetIncome                  float64 `json:"net_income"`
+		TotalAdjustments           float64 `json:"total_adjustments"`
+		TotalWorkingCapitalChanges float64 `json:"total_working_capital_changes"`
+		IndirectOperatingCashFlow  float64 `json:"indirect_operating_cash_flow"`
+		DirectOperatingCashFlow    float64 `json:"direct_operating_cash_flow"`
+		OperatingDifference        float64 `json:"operating_difference"`
+		IndirectNetCashFlow        float64 `json:"indirect_net_cash_flow"`
+		DirectNetCashFlow          float64 `json:"direct_net_cash_flow"`
+		ActualNetChangeInCash      float64 `json:"actual_net_change_in_cash"`
+		IsReconciled               bool    `json:"is_reconciled"`
+	} `json:"reconciliation"`
+}
+
+// getCashCounterpartsFromSSOT returns, per account, the non-cash lines of posted journals that move
+// cash or bank (1101%/1102%). Amount is credit - debit, so the amounts add up to the net cash movement.
+func (s *SSOTCashFlowService) getCashCounterpartsFromSSOT(startDate, endDate string) ([]CFDirectItem, error) {
+	var rows []struct {
+		AccountID   uint
+		AccountCode string
+		AccountName string
+		Amount      float64
+	}
+
+	query := `
+		SELECT 
+			a.id as account_id,
+			a.code as account_code,
+			a.name as account_name,
+			COALESCE(SUM(ujl.credit_amount - ujl.debit_amount), 0) as amount
+		FROM unified_journal_lines ujl
+		JOIN unified_journal_ledger uje ON uje.id = ujl.journal_id
+		JOIN accounts a ON a.id = ujl.account_id
+		WHERE uje.status = 'POSTED'
+			AND uje.deleted_at IS NULL
+			AND uje.entry_date >= ?
+			AND uje.entry_date < ?
+			AND UPPER(uje.source_type) != 'CLOSING'
+			AND NOT (a.code LIKE '1101%' OR a.code LIKE '1102%')
+			AND EXISTS (
+				SELECT 1 FROM unified_journal_lines cl
+				JOIN accounts ca ON ca.id = cl.account_id
+				WHERE cl.journal_id = uje.id AND (ca.code LIKE '1101%' OR ca.code LIKE '1102%')
+			)
+		GROUP BY a.id, a.code, a.name
+		HAVING COALESCE(SUM(ujl.credit_amount - ujl.debit_amount), 0) <> 0
+		ORDER BY a.code
+	`
+
+	if err := s.db.Raw(query, startDate, endDate).Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("error executing cash counterpart query: %v", err)
+	}
+
+	items := make([]CFDirectItem, 0, len(rows))
+	for _, r := range rows {
+		items = append(items, CFDirectItem{
+			AccountID:   r.AccountID,
+			AccountCode: r.AccountCode,
+			AccountName: r.AccountName,
+			Amount:      r.Amount,
+		})
+	}
+	return items, nil
+}
+
+// generateDirectCashFlow classifies the counterpart amounts and reconciles them to the indirect statement
+func (s *SSOTCashFlowService) generateDirectCashFlow(counterparts []CFDirectItem, indirect *SSOTCashFlowData) *SSOTDirectCashFlow {
+	direct := &SSOTDirectCashFlow{
+		CashAtBeginning: indirect.CashAtBeginning,
+		CashAtEnd:       indirect.CashAtEnd,
+	}
+	direct.OperatingActivities.Items = []CFDirectItem{}
+	direct.InvestingActivities.Items = []CFDirectItem{}
+	direct.FinancingActivities.Items = []CFDirectItem{}
+
+	for _, item := range counterparts {
+		item.Category = classifyDirectCashFlow(item.AccountCode, item.AccountName)
+		item.Type = "inflow"
+		if item.Amount < 0 {
+			item.Type = "outflow"
+		}
+
+		switch item.Category {
+		case CFDirectInvesting:
+			direct.InvestingActivities.TotalInvestingCashFlow += item.Amount
+			direct.InvestingActivities.Items = append(direct.InvestingActivities.Items, item)
+		case CFDirectFinancing:
+			direct.FinancingActivities.TotalFinancingCashFlow += item.Amount
+			direct.FinancingActivities.Items = append(direct.FinancingActivities.Items, item)
+		default:
+			switch item.Category {
+			case CFDirectReceiptsFromCustomers:
+				direct.OperatingActivities.ReceiptsFromCustomers += item.Amount
+			case CFDirectPaymentsToSuppliers:
+				direct.OperatingActivities.PaymentsToSuppliers += item.Amount
+			case CFDirectPaymentsToEmployees:
+				direct.OperatingActivities.PaymentsToEmployees += item.Amount
+			case CFDirectTaxesPaid:
+				direct.OperatingActivities.TaxesPaid += item.Amount
+			default:
+				direct.OperatingActivities.OtherOperating += item.Amount
+			}
+			direct.OperatingActivities.TotalOperatingCashFlow += item.Amount
+			direct.OperatingActivities.Items = append(direct.OperatingActivities.Items, item)
+		}
+	}
+
+	direct.NetCashFlow = direct.OperatingActivities.TotalOperatingCashFlow +
+		direct.InvestingActivities.TotalInvestingCashFlow +
+		direct.FinancingActivities.TotalFinancingCashFlow
+
+	rec := &direct.Reconciliation
+	rec.NetIncome = indirect.OperatingActivities.NetIncome
+	rec.TotalAdjustments = indirect.OperatingActivities.Adjustments.TotalAdjustments
+	rec.TotalWorkingCapitalChanges = indirect.OperatingActivities.WorkingCapitalChanges.TotalWorkingCapitalChanges
+	rec.IndirectOperatingCashFlow = indirect.OperatingActivities.TotalOperatingCashFlow
+	rec.DirectOperatingCashFlow = direct.OperatingActivities.TotalOperatingCashFlow
+	rec.OperatingDifference = roundAmount(rec.IndirectOperatingCashFlow - rec.DirectOperatingCashFlow)
+	rec.IndirectNetCashFlow = indirect.NetCashFlow
+	rec.DirectNetCashFlow = direct.NetCashFlow
+	rec.ActualNetChangeInCash = indirect.CashAtEnd - indirect.CashAtBeginning
+	rec.IsReconciled = math.Abs(rec.DirectNetCashFlow-rec.ActualNetChangeInCash) < 0.01 &&
+		math.Abs(rec.OperatingDifference) < 0.01
+
+	return direct
+}
+
+// classifyDirectCashFlow maps a counterpart account to a direct-method category, following the same
+// account code ranges as the indirect method
+func classifyDirectCashFlow(code, name string) string {
+	lowerName := strings.ToLower(name)
+	containsAny := func(words ...string) bool {
+		for _, w := range words {
+			if strings.Contains(lowerName, w) {
+				return true
+			}
+		}
+		return false
+	}
+
+	switch {
+	// Taxes: PPN Keluaran/Masukan, tax payables and prepaid taxes, tax expense
+	case strings.HasPrefix(code, "2103") || strings.HasPrefix(code, "124") ||
+		containsAny("pajak", "tax", "ppn", "pph"):
+		return CFDirectTaxesPaid
+
+	// Salaries and wages
+	case containsAny("gaji", "salary", "salaries", "upah", "wage", "tunjangan", "bpjs"):
+		return CFDirectPaymentsToEmployees
+
+	// Investing: intangible assets, investments, fixed assets
+	case strings.HasPrefix(code, "14") || strings.HasPrefix(code, "15") ||
+		strings.HasPrefix(code, "16") || strings.HasPrefix(code, "17"):
+		return CFDirectInvesting
+
+	// Financing: short and long term debt, equity, dividends
+	case strings.HasPrefix(code, "211") || strings.HasPrefix(code, "22") || strings.HasPrefix(code, "3") ||
+		containsAny("dividend", "dividen"):
+		return CFDirectFinancing
+
+	// Customers: revenue and receivables
+	case strings.HasPrefix(code, "4") || strings.HasPrefix(code, "12"):
+		return CFDirectReceiptsFromCustomers
+
+	// Suppliers: inventory, prepaid expenses, payables, cost of sales and operating expenses
+	case strings.HasPrefix(code, "13") || strings.HasPrefix(code, "114") || strings.HasPrefix(code, "115") ||
+		strings.HasPrefix(code, "210") || strings.HasPrefix(code, "5"):
+		return CFDirectPaymentsToSuppliers
+	}
+	return CFDirectOtherOperating
+}
+
+// DirectCashFlowCategoryLabel returns the statement caption of a direct-method category
+func DirectCashFlowCategoryLabel(category string) string {
+	switch category {
+	case CFDirectReceiptsFromCustomers:
+		return "Receipts from customers"
+	case CFDirectPaymentsToSuppliers:
+		return "Payments to suppliers"
+	case CFDirectPaymentsToEmployees:
+		return "Payments to employees"
+	case CFDirectTaxesPaid:
+		return "Taxes paid"
+	case CFDirectInvesting:
+		return "Investing activities"
+	case CFDirectFinancing:
+		return "Financing activities"
+	}
+	return "Other operating cash flows"
+}
+
+// GenerateComparativeCashFlow generates the indirect-method cash flow statement with one column per period
+func (s *SSOTCashFlowService) GenerateComparativeCashFlow(columns []ComparativeColumn) (*ComparativeStatement, error) {
+	columns, err := prepareComparativeColumns(columns, ComparativeStatementCashFlow)
+	if err != nil {
+		return nil, err
+	}
+
+	builder := newComparativeBuilder(columns)
+	for i, column := range columns {
+		cfData, err := s.GenerateSSOTCashFlow(column.StartDate, column.EndDate)
+		if err != nil {
+			return nil, fmt.Errorf("column %s: %v", column.Key, err)
+		}
+		if i == 0 {
+			builder.company = cfData.Company
+		}
+		addCashFlowComparativeRows(builder, i, cfData)
+	}
+	return builder.build(ComparativeStatementCashFlow, "Cash Flow Statement"), nil
+}
+
+// addCashFlowComparativeRows adds the activities, totals and cash balances of one cash flow column
+func addCashFlowComparativeRows(b *comparativeBuilder, column int, cf *SSOTCashFlowData) {
+	addItems := func(order int, section string, items []CFSectionItem) {
+		for _, item := range items {
+			b.account(column, order, section, item.AccountCode, item.AccountName, item.Amount)
+		}
+	}
+
+	operating := cf.OperatingActivities
+	b.total(column, 10, "OPERATING", "net_income", "Net Income", operating.NetIncome)
+	addItems(11, "ADJUSTMENTS", operating.Adjustments.Items)
+	b.total(column, 11, "ADJUSTMENTS", "total_adjustments", "Total Non-Cash Adjustments", operating.Adjustments.TotalAdjustments)
+	addItems(12, "WORKING_CAPITAL", operating.WorkingCapitalChanges.Items)
+	b.total(column, 12, "WORKING_CAPITAL", "total_working_capital_changes", "Total Working Capital Changes", operating.WorkingCapitalChanges.TotalWorkingCapitalChanges)
+	b.total(column, 13, "OPERATING", "total_operating_cash_flow", "Net Cash from Operating Activities", operating.TotalOperatingCashFlow)
+
+	addItems(20, "INVESTING", cf.InvestingActivities.Items)
+	b.total(column, 20, "INVESTING", "total_investing_cash_flow", "Net Cash from Investing Activities", cf.InvestingActivities.TotalInvestingCashFlow)
+	addItems(30, "FINANCING", cf.FinancingActivities.Items)
+	b.total(column, 30, "FINANCING", "total_financing_cash_flow", "Net Cash from Financing Activities", cf.FinancingActivities.TotalFinancingCashFlow)
+
+	b.total(column, 40, "SUMMARY", "net_cash_flow", "Net Cash Flow", cf.NetCashFlow)
+	b.total(column, 41, "SUMMARY", "cash_at_beginning", "Cash at Beginning of Period", cf.CashAtBeginning)
+	b.total(column, 42, "SUMMARY", "cash_at_end", "Cash at End of Period", cf.CashAtEnd)
+}
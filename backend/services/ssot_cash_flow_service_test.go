@@ -0,0 +1,96 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"app-sistem-akuntansi/models"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassifyDirectCashFlow(t *testing.T) {
+	tests := []struct {
+		code, name string
+		want       string
+	}{
+		{code: "4101", name: "Pendapatan Penjualan", want: CFDirectReceiptsFromCustomers},
+		{code: "1201", name: "Piutang Usaha", want: CFDirectReceiptsFromCustomers},
+		{code: "2101", name: "Utang Usaha", want: CFDirectPaymentsToSuppliers},
+		{code: "1301", name: "Persediaan Barang Dagangan", want: CFDirectPaymentsToSuppliers},
+		{code: "5201", name: "Beban Listrik", want: CFDirectPaymentsToSuppliers},
+		{code: "5101", name: "Beban Gaji", want: CFDirectPaymentsToEmployees},
+		{code: "2103", name: "PPN Keluaran", want: CFDirectTaxesPaid},
+		{code: "1240", name: "PPN Masukan", want: CFDirectTaxesPaid},
+		{code: "1501", name: "Peralatan Kantor", want: CFDirectInvesting},
+		{code: "2201", name: "Utang Bank Jangka Panjang", want: CFDirectFinancing},
+		{code: "3101", name: "Modal Pemilik", want: CFDirectFinancing},
+		{code: "2105", name: "Dividen Yang Belum Dibayar", want: CFDirectFinancing},
+		{code: "7101", name: "Pendapatan Lain-lain", want: CFDirectOtherOperating},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code+" "+tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, classifyDirectCashFlow(tt.code, tt.name))
+		})
+	}
+}
+
+func TestDirectCashFlowReconcilesToIndirect(t *testing.T) {
+	db := setupJournalTestDB(t)
+	accounts := seedTestAccounts(t, db, map[string]string{
+		"1101": "ASSET", "1102": "ASSET", "1201": "ASSET", "1501": "ASSET",
+		"2201": "LIABILITY", "4101": "REVENUE", "5201": "EXPENSE",
+	})
+	journals := NewUnifiedJournalService(db)
+	post := func(day int, debit, credit string, amount int64) {
+		value := decimal.NewFromInt(amount)
+		_, err := journals.CreateJournalEntry(&JournalEntryRequest{
+			EntryDate:   time.Date(2024, 3, day, 0, 0, 0, 0, time.UTC),
+			Description: debit + " / " + credit,
+			SourceType:  models.SSOTSourceTypeAdjustment,
+			CreatedBy:   1,
+			AutoPost:    true,
+			Lines: []JournalLineRequest{
+				{AccountID: uint64(accounts[debit].ID), DebitAmount: value},
+				{AccountID: uint64(accounts[credit].ID), CreditAmount: value},
+			},
+		})
+		require.NoError(t, err)
+	}
+
+	post(1, "1101", "4101", 5000000)   // cash sale
+	post(2, "1201", "4101", 3000000)   // credit sale, no cash
+	post(10, "1101", "1201", 2000000)  // collection
+	post(12, "5201", "1101", 1500000)  // electricity paid
+	post(15, "1102", "2201", 10000000) // bank loan
+	post(20, "1501", "1102", 4000000)  // equipment bought
+	post(25, "1102", "1101", 1000000)  // cash deposited to bank, not a cash flow
+
+	report, err := NewSSOTCashFlowService(db).GenerateSSOTCashFlowWithMethod("2024-03-01", "2024-03-31", "Direct")
+	require.NoError(t, err)
+	assert.Equal(t, CashFlowMethodDirect, report.Method)
+	direct := report.DirectMethod
+	require.NotNil(t, direct)
+
+	assert.InDelta(t, 7000000, direct.OperatingActivities.ReceiptsFromCustomers, 0.001)
+	assert.InDelta(t, -1500000, direct.OperatingActivities.PaymentsToSuppliers, 0.001)
+	assert.InDelta(t, 5500000, direct.OperatingActivities.TotalOperatingCashFlow, 0.001)
+	assert.InDelta(t, -4000000, direct.InvestingActivities.TotalInvestingCashFlow, 0.001)
+	assert.InDelta(t, 10000000, direct.FinancingActivities.TotalFinancingCashFlow, 0.001)
+	assert.InDelta(t, 11500000, direct.NetCashFlow, 0.001)
+	for _, item := range direct.OperatingActivities.Items {
+		assert.NotEqual(t, "1102", item.AccountCode, "transfers between cash accounts are not counterparts")
+	}
+
+	rec := direct.Reconciliation
+	assert.InDelta(t, 6500000, rec.NetIncome, 0.001)
+	assert.InDelta(t, 11500000, rec.ActualNetChangeInCash, 0.001)
+	assert.InDelta(t, rec.IndirectOperatingCashFlow, rec.DirectOperatingCashFlow, 0.001)
+	assert.True(t, rec.IsReconciled)
+
+	_, err = NewSSOTCashFlowService(db).GenerateSSOTCashFlowWithMethod("2024-03-01", "2024-03-31", "cash")
+	assert.EqualError(t, err, `invalid cash flow method "cash", use indirect or direct`)
+}
@@ -5,6 +5,8 @@ import (
 	"strings"
 	"time"
 	
+	"app-sistem-akuntansi/models"
+
 	"gorm.io/gorm"
 )
 
@@ -114,6 +116,12 @@ type PLSectionItem struct {
 
 // GenerateSSOTProfitLoss generates P&L statement from SSOT journal system
 func (s *SSOTProfitLossService) GenerateSSOTProfitLoss(startDate, endDate string) (*SSOTProfitLossData, error) {
+	return s.GenerateSSOTProfitLossFiltered(startDate, endDate, DimensionFilter{})
+}
+
+// GenerateSSOTProfitLossFiltered generates the P&L statement limited to journal lines matching the
+// dimension filter (cost center, project, tag)
+func (s *SSOTProfitLossService) GenerateSSOTProfitLossFiltered(startDate, endDate string, filter DimensionFilter) (*SSOTProfitLossData, error) {
 	// Default to current fiscal year when parameters are empty
 	if strings.TrimSpace(startDate) == "" || strings.TrimSpace(endDate) == "" {
 		settingsSvc := NewSettingsService(s.db)
@@ -135,7 +143,7 @@ func (s *SSOTProfitLossService) GenerateSSOTProfitLoss(startDate, endDate string
 	}
 	
 	// Get account balances from SSOT journal entries (with data source flag)
-	accountBalances, source, err := s.getAccountBalancesFromSSOT(startDate, endDate)
+	accountBalances, source, err := s.getAccountBalancesFromSSOT(startDate, endDate, filter)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get account balances: %v", err)
 	}
@@ -179,9 +187,10 @@ func (s *SSOTProfitLossService) getAccountBalancesFromLegacy(startDate, endDate
 }
 
 // getAccountBalancesFromSSOT retrieves account balances from SSOT journal system, with automatic fallbacks
-func (s *SSOTProfitLossService) getAccountBalancesFromSSOT(startDate, endDate string) ([]SSOTAccountBalance, string, error) {
+func (s *SSOTProfitLossService) getAccountBalancesFromSSOT(startDate, endDate string, filter DimensionFilter) ([]SSOTAccountBalance, string, error) {
 	var balances []SSOTAccountBalance
 	source := "SSOT"
+	dimensionSQL, dimensionArgs := filter.condition("ujl")
 	
 	// CRITICAL: Exclude CLOSING entries to show historical data for closed periods
 	// Similar to Balance Sheet logic - we want to see data BEFORE closing
@@ -206,14 +215,15 @@ func (s *SSOTProfitLossService) getAccountBalancesFromSSOT(startDate, endDate st
 			AND uje.deleted_at IS NULL
 			AND UPPER(uje.source_type) != 'CLOSING'
 		WHERE uje.entry_date >= ? AND uje.entry_date <= ?
-		  AND COALESCE(a.is_header, false) = false
+		  AND COALESCE(a.is_header, false) = false` + dimensionSQL + `
 		GROUP BY a.code
 		HAVING (COALESCE(SUM(ujl.debit_amount), 0) <> 0 OR COALESCE(SUM(ujl.credit_amount), 0) <> 0)
 		ORDER BY a.code
 	`
+	args := append([]interface{}{startDate, endDate}, dimensionArgs...)
 	
 	fmt.Printf("[P&L DEBUG] Executing SSOT query for period %s to %s (EXCLUDING CLOSING entries)\n", startDate, endDate)
-	if err := s.db.Raw(query, startDate, endDate).Scan(&balances).Error; err != nil {
+	if err := s.db.Raw(query, args...).Scan(&balances).Error; err != nil {
 		return nil, source, fmt.Errorf("error executing account balances query: %v", err)
 	}
 	fmt.Printf("[P&L DEBUG] Retrieved %d accounts from SSOT (excluding closing)\n", len(balances))
@@ -227,6 +237,11 @@ func (s *SSOTProfitLossService) getAccountBalancesFromSSOT(startDate, endDate st
 		}
 	}
 	
+	// Legacy journals and account balances carry no dimensions, so a filtered report never falls back
+	if !filter.IsEmpty() {
+		return balances, source, nil
+	}
+
 	// Fallback to legacy journals if SSOT returns no activity OR no PL activity (no 4xxx/5xxx)
 if len(balances) == 0 || !hasPLActivity(balances) {
 		legacy, lerr := s.getAccountBalancesFromLegacy(startDate, endDate)
@@ -500,4 +515,192 @@ func (s *SSOTProfitLossService) calculatePLTotalsAndRatios(plData *SSOTProfitLos
 	if plData.Revenue.TotalRevenue > 0 {
 		plData.NetIncomeMargin = (plData.NetIncome / plData.Revenue.TotalRevenue) * 100
 	}
-}
\ No newline at end of file
+}
+// SSOTProfitLossDimensionColumn is the P&L of one dimension value (DimensionID 0 = unassigned lines)
+type SSOTProfitLossDimensionColumn struct {
+	DimensionID     uint                `json:"dimension_id"`
+	DimensionCode   string              `json:"dimension_code"`
+	DimensionName   string              `json:"dimension_name"`
+	TotalRevenue    float64             `json:"total_revenue"`
+	TotalCOGS       float64             `json:"total_cogs"`
+	GrossProfit     float64             `json:"gross_profit"`
+	TotalOpEx       float64             `json:"total_opex"`
+	OperatingIncome float64             `json:"operating_income"`
+	NetIncome       float64             `json:"net_income"`
+	Report          *SSOTProfitLossData `json:"report"`
+}
+
+// SSOTProfitLossByDimension is the P&L split into one column per cost center, project or tag
+type SSOTProfitLossByDimension struct {
+	StartDate time.Time                       `json:"start_date"`
+	EndDate   time.Time                       `json:"end_date"`
+	GroupBy   string                          `json:"group_by"`
+	TagGroup  string                          `json:"tag_group,omitempty"`
+	Filter    DimensionFilter                 `json:"filter"`
+	Columns   []SSOTProfitLossDimensionColumn `json:"columns"`
+	Total     *SSOTProfitLossData             `json:"total"`
+}
+
+// GenerateSSOTProfitLossByDimension generates the P&L grouped by cost center, project or tag.
+// Lines carrying several tags of the group are reported under each of those tags.
+func (s *SSOTProfitLossService) GenerateSSOTProfitLossByDimension(startDate, endDate string, filter DimensionFilter, groupBy, tagGroup string) (*SSOTProfitLossByDimension, error) {
+	total, err := s.GenerateSSOTProfitLossFiltered(startDate, endDate, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	start := total.StartDate
+	dimensions, err := NewDimensionService(s.db).UsedDimensions(groupBy, tagGroup, &start, endOfDay(total.EndDate))
+	if err != nil {
+		return nil, err
+	}
+
+	result := &SSOTProfitLossByDimension{
+		StartDate: total.StartDate,
+		EndDate:   total.EndDate,
+		GroupBy:   groupBy,
+		TagGroup:  strings.ToUpper(tagGroup),
+		Filter:    filter,
+		Total:     total,
+	}
+	if groupBy == DimensionGroupTag {
+		filter.TagGroup = result.TagGroup
+	}
+
+	dimensions = append(dimensions, models.Dimension{Code: "-", Name: "Unassigned"})
+	for _, d := range dimensions {
+		report, err := s.GenerateSSOTProfitLossFiltered(total.StartDate.Format("2006-01-02"), total.EndDate.Format("2006-01-02"), filter.withGroup(groupBy, d.ID))
+		if err != nil {
+			return nil, err
+		}
+		result.Columns = append(result.Columns, SSOTProfitLossDimensionColumn{
+			DimensionID:     d.ID,
+			DimensionCode:   d.Code,
+			DimensionName:   d.Name,
+			TotalRevenue:    report.Revenue.TotalRevenue,
+			TotalCOGS:       report.COGS.TotalCOGS,
+			GrossProfit:     report.GrossProfit,
+			TotalOpEx:       report.OperatingExpenses.TotalOpEx,
+			OperatingIncome: report.OperatingIncome,
+			NetIncome:       report.NetIncome,
+			Report:          report,
+		})
+	}
+	return result, nil
+}
+
+// GenerateComparativeProfitLoss generates the P&L with one column per period or budget.
+// Actual columns use SSOT/legacy journals only: the accounts-table fallback is not period based.
+func (s *SSOTProfitLossService) GenerateComparativeProfitLoss(columns []ComparativeColumn) (*ComparativeStatement, error) {
+	columns, err := prepareComparativeColumns(columns, ComparativeStatementProfitLoss)
+	if err != nil {
+		return nil, err
+	}
+
+	builder := newComparativeBuilder(columns)
+	for i, column := range columns {
+		start, _ := time.Parse("2006-01-02", column.StartDate)
+		end, _ := time.Parse("2006-01-02", column.EndDate)
+
+		var balances []SSOTAccountBalance
+		if column.Type == ComparativeColumnBudget {
+			balances, err = s.getBudgetBalances(column.BudgetID, start, end)
+		} else {
+			var source string
+			balances, source, err = s.getAccountBalancesFromSSOT(column.StartDate, column.EndDate, DimensionFilter{})
+			if source == "ACCOUNTS" {
+				balances = nil
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("column %s: %v", column.Key, err)
+		}
+
+		plData := s.generateProfitLossFromBalances(balances, start, end)
+		if i == 0 {
+			builder.company = plData.Company
+		}
+		addProfitLossComparativeRows(builder, i, plData)
+	}
+	return builder.build(ComparativeStatementProfitLoss, "Profit and Loss Statement"), nil
+}
+
+// getBudgetBalances returns the budgeted amount per account for the months between start and end,
+// shaped like journal balances so the regular P&L layout can be reused
+func (s *SSOTProfitLossService) getBudgetBalances(budgetID uint, start, end time.Time) ([]SSOTAccountBalance, error) {
+	var budget models.Budget
+	query := s.db.Model(&models.Budget{})
+	if budgetID > 0 {
+		query = query.Where("id = ?", budgetID)
+	} else {
+		query = query.Where("year = ? AND status IN ?", start.Year(), []string{models.BudgetStatusActive, models.BudgetStatusApproved, models.BudgetStatusClosed}).
+			Order("CASE WHEN status = 'ACTIVE' THEN 0 ELSE 1 END").Order("id DESC")
+	}
+	if err := query.First(&budget).Error; err != nil {
+		if budgetID > 0 {
+			return nil, fmt.Errorf("budget %d not found", budgetID)
+		}
+		return nil, fmt.Errorf("no approved budget for %d", start.Year())
+	}
+	if budget.Year != start.Year() {
+		return nil, fmt.Errorf("budget %s is for %d, not %d", budget.Code, budget.Year, start.Year())
+	}
+
+	var items []models.BudgetItem
+	if err := s.db.Preload("Account").
+		Where("budget_id = ? AND month BETWEEN ? AND ?", budget.ID, int(start.Month()), int(end.Month())).
+		Find(&items).Error; err != nil {
+		return nil, fmt.Errorf("failed to load budget items: %v", err)
+	}
+
+	var balances []SSOTAccountBalance
+	index := make(map[uint]int)
+	for _, item := range items {
+		i, ok := index[item.AccountID]
+		if !ok {
+			balances = append(balances, SSOTAccountBalance{
+				AccountID:   item.AccountID,
+				AccountCode: item.Account.Code,
+				AccountName: item.Account.Name,
+				AccountType: item.Account.Type,
+			})
+			i = len(balances) - 1
+			index[item.AccountID] = i
+		}
+		balances[i].NetBalance += item.BudgetAmount
+	}
+	return balances, nil
+}
+
+// addProfitLossComparativeRows adds the sections, subtotals and results of one P&L column
+func addProfitLossComparativeRows(b *comparativeBuilder, column int, pl *SSOTProfitLossData) {
+	addItems := func(order int, section string, items []PLSectionItem) {
+		for _, item := range items {
+			b.account(column, order, section, item.AccountCode, item.AccountName, item.Amount)
+		}
+	}
+
+	addItems(10, "REVENUE", pl.Revenue.Items)
+	b.total(column, 10, "REVENUE", "total_revenue", "Total Revenue", pl.Revenue.TotalRevenue)
+	addItems(20, "COGS", pl.COGS.Items)
+	b.total(column, 20, "COGS", "total_cogs", "Total Cost of Goods Sold", pl.COGS.TotalCOGS)
+	b.total(column, 25, "GROSS_PROFIT", "gross_profit", "Gross Profit", pl.GrossProfit)
+
+	addItems(30, "ADMINISTRATIVE", pl.OperatingExpenses.Administrative.Items)
+	b.total(column, 30, "ADMINISTRATIVE", "total_administrative", "Total Administrative Expenses", pl.OperatingExpenses.Administrative.Subtotal)
+	addItems(31, "SELLING_MARKETING", pl.OperatingExpenses.SellingMarketing.Items)
+	b.total(column, 31, "SELLING_MARKETING", "total_selling_marketing", "Total Selling & Marketing Expenses", pl.OperatingExpenses.SellingMarketing.Subtotal)
+	addItems(32, "GENERAL", pl.OperatingExpenses.General.Items)
+	b.total(column, 32, "GENERAL", "total_general", "Total General Expenses", pl.OperatingExpenses.General.Subtotal)
+	b.total(column, 35, "OPERATING_EXPENSES", "total_operating_expenses", "Total Operating Expenses", pl.OperatingExpenses.TotalOpEx)
+	b.total(column, 36, "OPERATING_INCOME", "operating_income", "Operating Income", pl.OperatingIncome)
+
+	addItems(40, "OTHER_INCOME", pl.OtherIncomeItems)
+	b.total(column, 40, "OTHER_INCOME", "total_other_income", "Total Other Income", pl.OtherIncome)
+	addItems(45, "OTHER_EXPENSES", pl.OtherExpenseItems)
+	b.total(column, 45, "OTHER_EXPENSES", "total_other_expenses", "Total Other Expenses", pl.OtherExpenses)
+
+	b.total(column, 50, "RESULT", "income_before_tax", "Income Before Tax", pl.IncomeBeforeTax)
+	b.total(column, 51, "RESULT", "tax_expense", "Tax Expense", pl.TaxExpense)
+	b.total(column, 52, "RESULT", "net_income", "Net Income", pl.NetIncome)
+}
@@ -297,7 +297,7 @@ func (s *SSOTReportIntegrationService) GenerateIntegratedReports(ctx context.Con
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		tb, err := s.generateTrialBalance(ctx, endDate)
+		tb, err := s.generateTrialBalance(ctx, endDate, DimensionFilter{})
 		if err != nil {
 			errChan <- fmt.Errorf("trial balance generation failed: %w", err)
 			return
@@ -374,7 +374,75 @@ func (s *SSOTReportIntegrationService) GenerateVendorAnalysisFromSSot(startDate,
 // GenerateTrialBalanceFromSSot generates trial balance using SSOT journal data
 func (s *SSOTReportIntegrationService) GenerateTrialBalanceFromSSot(asOfDate time.Time) (*TrialBalanceData, error) {
 	ctx := context.Background()
-	return s.generateTrialBalance(ctx, asOfDate)
+	return s.generateTrialBalance(ctx, asOfDate, DimensionFilter{})
+}
+
+// GenerateTrialBalanceFromSSotFiltered generates trial balance limited to journal lines matching the
+// dimension filter. A dimensional trial balance only balances when every line of the entries is coded.
+func (s *SSOTReportIntegrationService) GenerateTrialBalanceFromSSotFiltered(asOfDate time.Time, filter DimensionFilter) (*TrialBalanceData, error) {
+	ctx := context.Background()
+	return s.generateTrialBalance(ctx, asOfDate, filter)
+}
+
+// TrialBalanceDimensionColumn is the trial balance of one dimension value (DimensionID 0 = unassigned lines)
+type TrialBalanceDimensionColumn struct {
+	DimensionID   uint              `json:"dimension_id"`
+	DimensionCode string            `json:"dimension_code"`
+	DimensionName string            `json:"dimension_name"`
+	TotalDebits   float64           `json:"total_debits"`
+	TotalCredits  float64           `json:"total_credits"`
+	TrialBalance  *TrialBalanceData `json:"trial_balance"`
+}
+
+// TrialBalanceByDimension is the trial balance split into one column per cost center, project or tag
+type TrialBalanceByDimension struct {
+	AsOfDate time.Time                     `json:"as_of_date"`
+	GroupBy  string                        `json:"group_by"`
+	TagGroup string                        `json:"tag_group,omitempty"`
+	Filter   DimensionFilter               `json:"filter"`
+	Columns  []TrialBalanceDimensionColumn `json:"columns"`
+	Total    *TrialBalanceData             `json:"total"`
+}
+
+// GenerateTrialBalanceByDimension generates the trial balance grouped by cost center, project or tag
+func (s *SSOTReportIntegrationService) GenerateTrialBalanceByDimension(asOfDate time.Time, filter DimensionFilter, groupBy, tagGroup string) (*TrialBalanceByDimension, error) {
+	ctx := context.Background()
+	total, err := s.generateTrialBalance(ctx, asOfDate, filter)
+	if err != nil {
+		return nil, err
+	}
+	dimensions, err := NewDimensionService(s.db).UsedDimensions(groupBy, tagGroup, nil, asOfDate)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &TrialBalanceByDimension{
+		AsOfDate: asOfDate,
+		GroupBy:  groupBy,
+		TagGroup: strings.ToUpper(tagGroup),
+		Filter:   filter,
+		Total:    total,
+	}
+	if groupBy == DimensionGroupTag {
+		filter.TagGroup = result.TagGroup
+	}
+
+	dimensions = append(dimensions, models.Dimension{Code: "-", Name: "Unassigned"})
+	for _, d := range dimensions {
+		tb, err := s.generateTrialBalance(ctx, asOfDate, filter.withGroup(groupBy, d.ID))
+		if err != nil {
+			return nil, err
+		}
+		result.Columns = append(result.Columns, TrialBalanceDimensionColumn{
+			DimensionID:   d.ID,
+			DimensionCode: d.Code,
+			DimensionName: d.Name,
+			TotalDebits:   tb.TotalDebits,
+			TotalCredits:  tb.TotalCredits,
+			TrialBalance:  tb,
+		})
+	}
+	return result, nil
 }
 
 // GenerateGeneralLedgerFromSSot generates general ledger using SSOT journal data
@@ -840,7 +908,7 @@ result.GeneratedAt = time.Now()
 	return &result, nil
 }
 
-func (s *SSOTReportIntegrationService) generateTrialBalance(ctx context.Context, asOfDate time.Time) (*TrialBalanceData, error) {
+func (s *SSOTReportIntegrationService) generateTrialBalance(ctx context.Context, asOfDate time.Time, filter DimensionFilter) (*TrialBalanceData, error) {
 	result := &TrialBalanceData{
 		Company:     s.getCompanyInfo(),
 		AsOfDate:    asOfDate,
@@ -860,6 +928,7 @@ func (s *SSOTReportIntegrationService) generateTrialBalance(ctx context.Context,
 	}
 
 	var balances []AccountBalance
+	dimensionSQL, dimensionArgs := filter.condition("sjl")
 	query := `
 		SELECT 
 			sjl.account_id,
@@ -880,12 +949,13 @@ func (s *SSOTReportIntegrationService) generateTrialBalance(ctx context.Context,
 		LEFT JOIN accounts a ON a.id = sjl.account_id
 		WHERE sje.entry_date <= ?
 			AND sje.status = ?
-			AND UPPER(sje.source_type) != 'CLOSING'
+			AND UPPER(sje.source_type) != 'CLOSING'` + dimensionSQL + `
 		GROUP BY sjl.account_id, a.code, a.name
 		ORDER BY a.code
 	`
+	args := append([]interface{}{asOfDate, models.SSOTStatusPosted}, dimensionArgs...)
 
-	err := s.db.WithContext(ctx).Raw(query, asOfDate, models.SSOTStatusPosted).Scan(&balances).Error
+	err := s.db.WithContext(ctx).Raw(query, args...).Scan(&balances).Error
 	if err != nil {
 		return nil, fmt.Errorf("failed to calculate trial balance: %w", err)
 	}
@@ -0,0 +1,169 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"app-sistem-akuntansi/models"
+	"app-sistem-akuntansi/utils"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+// GenerateStatementOfAccountPDF generates a customer or vendor statement of account in Indonesian ("id") or English ("en")
+func (p *PDFService) GenerateStatementOfAccountPDF(statement *StatementOfAccount, lang string) ([]byte, error) {
+	if statement == nil {
+		return nil, fmt.Errorf("statement data is required")
+	}
+	if lang != "id" && lang != "en" {
+		lang = p.Language()
+	}
+	t := func(key string) string { return utils.T(key, lang) }
+
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(15, 15, 15)
+	pdf.SetAutoPageBreak(true, 15)
+	pdf.AddPage()
+
+	contactLabel := t("customer")
+	if statement.Contact.Type == models.ContactTypeVendor {
+		contactLabel = t("vendor")
+	}
+	subtitles := []string{
+		fmt.Sprintf("%s: %s (%s)", contactLabel, statement.Contact.Name, statement.Contact.Code),
+	}
+	if statement.Contact.Address != "" {
+		subtitles = append(subtitles, fmt.Sprintf("%s: %s", t("address"), statement.Contact.Address))
+	}
+	subtitles = append(subtitles, fmt.Sprintf("%s: %s %s %s", t("period"),
+		statement.StartDate.Format("02/01/2006"), t("to"), statement.EndDate.Format("02/01/2006")))
+	contentW := p.addReportHeader(pdf, t("statement_of_account"), subtitles...)
+
+	lm, _, _, _ := pdf.GetMargins()
+	widths := []float64{20, 22, 30, 38, 23, 23, 24}
+	lineTypeLabels := map[string]string{
+		StatementLineInvoice:    t("statement_invoice"),
+		StatementLineBill:       t("statement_bill"),
+		StatementLinePayment:    t("statement_payment"),
+		StatementLineCreditNote: t("statement_credit_note"),
+		StatementLineReturn:     t("statement_return"),
+	}
+
+	writeHeader := func() {
+		pdf.SetX(lm)
+		pdf.SetFont("Arial", "B", 8)
+		pdf.SetFillColor(220, 220, 220)
+		headers := []string{t("date"), t("document_type"), t("reference"), t("description"), t("debit"), t("credit"), t("balance")}
+		for i, h := range headers {
+			pdf.CellFormat(widths[i], 7, h, "1", 0, "C", true, 0, "")
+		}
+		pdf.Ln(7)
+	}
+	amountCell := func(w float64, amount float64, fill bool) {
+		text := ""
+		if amount != 0 {
+			text = p.formatRupiah(amount)
+		}
+		pdf.CellFormat(w, 6, text, "1", 0, "R", fill, 0, "")
+	}
+	labelW := widths[0] + widths[1] + widths[2] + widths[3]
+
+	writeHeader()
+
+	// Opening balance
+	pdf.SetX(lm)
+	pdf.SetFont("Arial", "B", 8)
+	pdf.SetFillColor(245, 245, 245)
+	pdf.CellFormat(labelW, 6, t("opening_balance"), "1", 0, "L", true, 0, "")
+	pdf.CellFormat(widths[4]+widths[5], 6, "", "1", 0, "R", true, 0, "")
+	pdf.CellFormat(widths[6], 6, p.formatRupiah(statement.OpeningBalance), "1", 0, "R", true, 0, "")
+	pdf.Ln(6)
+
+	pdf.SetFont("Arial", "", 7)
+	_, pageH := pdf.GetPageSize()
+	for _, line := range statement.Lines {
+		if pdf.GetY()+6 > pageH-20 {
+			pdf.AddPage()
+			writeHeader()
+			pdf.SetFont("Arial", "", 7)
+		}
+		pdf.SetX(lm)
+		pdf.CellFormat(widths[0], 6, line.Date.Format("02/01/2006"), "1", 0, "C", false, 0, "")
+		pdf.CellFormat(widths[1], 6, truncateToWidth(pdf, lineTypeLabels[line.Type], widths[1]-2), "1", 0, "L", false, 0, "")
+		pdf.CellFormat(widths[2], 6, truncateToWidth(pdf, line.Reference, widths[2]-2), "1", 0, "L", false, 0, "")
+		pdf.CellFormat(widths[3], 6, truncateToWidth(pdf, line.Description, widths[3]-2), "1", 0, "L", false, 0, "")
+		amountCell(widths[4], line.Debit, false)
+		amountCell(widths[5], line.Credit, false)
+		pdf.CellFormat(widths[6], 6, p.formatRupiah(line.Balance), "1", 0, "R", false, 0, "")
+		pdf.Ln(6)
+	}
+	if len(statement.Lines) == 0 {
+		pdf.SetX(lm)
+		pdf.CellFormat(contentW, 6, t("no_data_available"), "1", 0, "C", false, 0, "")
+		pdf.Ln(6)
+	}
+
+	// Totals and closing balance
+	pdf.SetX(lm)
+	pdf.SetFont("Arial", "B", 8)
+	pdf.CellFormat(labelW, 6, t("total"), "1", 0, "R", true, 0, "")
+	amountCell(widths[4], statement.TotalDebit, true)
+	amountCell(widths[5], statement.TotalCredit, true)
+	pdf.CellFormat(widths[6], 6, "", "1", 0, "R", true, 0, "")
+	pdf.Ln(6)
+	pdf.SetX(lm)
+	pdf.CellFormat(labelW+widths[4]+widths[5], 7, t("closing_balance")+" / "+t("amount_due"), "1", 0, "R", true, 0, "")
+	pdf.CellFormat(widths[6], 7, p.formatRupiah(statement.ClosingBalance), "1", 0, "R", true, 0, "")
+	pdf.Ln(12)
+
+	// Aging footer
+	if pdf.GetY()+30 > pageH-15 {
+		pdf.AddPage()
+	}
+	pdf.SetX(lm)
+	pdf.SetFont("Arial", "B", 10)
+	pdf.Cell(contentW, 6, t("aging_summary"))
+	pdf.Ln(7)
+
+	agingColumns := []struct {
+		label  string
+		amount float64
+	}{
+		{t("aging_0_30"), statement.Aging.Days0To30},
+		{t("aging_31_60"), statement.Aging.Days31To60},
+		{t("aging_61_90"), statement.Aging.Days61To90},
+		{t("aging_over_90"), statement.Aging.Over90},
+		{t("total"), statement.Aging.Total},
+	}
+	colW := contentW / float64(len(agingColumns))
+	pdf.SetX(lm)
+	pdf.SetFont("Arial", "B", 8)
+	pdf.SetFillColor(220, 220, 220)
+	for _, col := range agingColumns {
+		pdf.CellFormat(colW, 7, col.label, "1", 0, "C", true, 0, "")
+	}
+	pdf.Ln(7)
+	pdf.SetX(lm)
+	pdf.SetFont("Arial", "", 8)
+	for _, col := range agingColumns {
+		pdf.CellFormat(colW, 7, p.formatRupiah(col.amount), "1", 0, "R", false, 0, "")
+	}
+	pdf.Ln(8)
+	if statement.Aging.UnappliedCredit > 0 {
+		pdf.SetX(lm)
+		pdf.Cell(contentW, 5, fmt.Sprintf("%s: %s", t("unapplied_credit"), p.formatRupiah(statement.Aging.UnappliedCredit)))
+		pdf.Ln(6)
+	}
+
+	pdf.Ln(4)
+	pdf.SetX(lm)
+	pdf.SetFont("Arial", "I", 8)
+	pdf.Cell(contentW, 4, fmt.Sprintf("%s %s", t("generated_on"), time.Now().Format("02/01/2006 15:04")))
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, fmt.Errorf("failed to generate statement of account PDF: %v", err)
+	}
+	return buf.Bytes(), nil
+}
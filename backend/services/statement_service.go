@@ -0,0 +1,571 @@
+package services
+
+import (
+	"app-sistem-akuntansi/models"
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// StatementService builds statements of account for customers and vendors: opening balance, every
+// invoice/bill, payment, credit note and return in the period, closing balance and an aging of the
+// documents still open at the end date. Amounts are converted to the base currency at the rate each
+// document was booked at, so a document and its settlements always net to the same outstanding.
+type StatementService struct {
+	db         *gorm.DB
+	pdfService PDFServiceInterface
+}
+
+func NewStatementService(db *gorm.DB, pdfService PDFServiceInterface) *StatementService {
+	return &StatementService{
+		db:         db,
+		pdfService: pdfService,
+	}
+}
+
+// Statement line types
+const (
+	StatementLineInvoice    = "INVOICE"
+	StatementLineBill       = "BILL"
+	StatementLinePayment    = "PAYMENT"
+	StatementLineCreditNote = "CREDIT_NOTE"
+	StatementLineReturn     = "RETURN"
+)
+
+// statementSaleStatuses are the sale statuses that put an amount on the customer's account
+var statementSaleStatuses = []string{
+	models.SaleStatusInvoiced, models.SaleStatusOverdue, models.SaleStatusPaid, models.SaleStatusCompleted,
+}
+
+// statementPurchaseStatuses are the purchase statuses that put an amount on the vendor's account
+var statementPurchaseStatuses = []string{
+	models.PurchaseStatusApproved, models.PurchaseStatusCompleted, models.PurchaseStatusPaid,
+}
+
+// StatementContact is the contact block printed on a statement
+type StatementContact struct {
+	ID           uint   `json:"id"`
+	Code         string `json:"code"`
+	Name         string `json:"name"`
+	Type         string `json:"type"`
+	Address      string `json:"address"`
+	Email        string `json:"email"`
+	Phone        string `json:"phone"`
+	PaymentTerms int    `json:"payment_terms"`
+}
+
+// StatementLine is one movement on the contact's account. Debit raises a customer balance and
+// lowers a vendor balance; Credit does the opposite. Balance is the running amount due.
+type StatementLine struct {
+	Date        time.Time  `json:"date"`
+	Type        string     `json:"type"`
+	Reference   string     `json:"reference"`
+	Description string     `json:"description"`
+	DueDate     *time.Time `json:"due_date,omitempty"`
+	Debit       float64    `json:"debit"`
+	Credit      float64    `json:"credit"`
+	Balance     float64    `json:"balance"`
+}
+
+// StatementOpenDocument is an invoice or bill still open at the statement end date
+type StatementOpenDocument struct {
+	Reference   string    `json:"reference"`
+	Date        time.Time `json:"date"`
+	DueDate     time.Time `json:"due_date"`
+	DaysOverdue int       `json:"days_overdue"`
+	Outstanding float64   `json:"outstanding"`
+}
+
+// StatementAging buckets the open documents by days past due at the end date.
+// Total minus UnappliedCredit equals the closing balance.
+type StatementAging struct {
+	Days0To30       float64 `json:"days_0_30"`
+	Days31To60      float64 `json:"days_31_60"`
+	Days61To90      float64 `json:"days_61_90"`
+	Over90          float64 `json:"over_90"`
+	Total           float64 `json:"total"`
+	UnappliedCredit float64 `json:"unapplied_credit"`
+}
+
+// StatementOfAccount is a customer or vendor statement for a date range
+type StatementOfAccount struct {
+	Contact        StatementContact        `json:"contact"`
+	StartDate      time.Time               `json:"start_date"`
+	EndDate        time.Time               `json:"end_date"`
+	Currency       string                  `json:"currency"`
+	OpeningBalance float64                 `json:"opening_balance"`
+	Lines          []StatementLine         `json:"lines"`
+	TotalDebit     float64                 `json:"total_debit"`
+	TotalCredit    float64                 `json:"total_credit"`
+	ClosingBalance float64                 `json:"closing_balance"`
+	Aging          StatementAging          `json:"aging"`
+	OpenDocuments  []StatementOpenDocument `json:"open_documents"`
+	GeneratedAt    time.Time               `json:"generated_at"`
+}
+
+// statementEntry is a movement before it is split into opening balance and period lines.
+// Amount is signed from the contact's point of view: positive raises the amount due.
+type statementEntry struct {
+	documentID  uint
+	date        time.Time
+	lineType    string
+	reference   string
+	description string
+	dueDate     *time.Time
+	amount      float64
+}
+
+// ========== STATEMENTS ==========
+
+// GetStatement - Laporan rekening satu pelanggan/vendor untuk rentang tanggal
+func (s *StatementService) GetStatement(contactID uint, startDate, endDate time.Time) (*StatementOfAccount, error) {
+	if endDate.Before(startDate) {
+		return nil, errors.New("end date must not be before start date")
+	}
+
+	var contact models.Contact
+	if err := s.db.First(&contact, contactID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("contact %d not found", contactID)
+		}
+		return nil, err
+	}
+
+	var entries []statementEntry
+	var err error
+	switch contact.Type {
+	case models.ContactTypeCustomer:
+		entries, err = s.customerEntries(contact.ID, endDate)
+	case models.ContactTypeVendor:
+		entries, err = s.vendorEntries(contact.ID, endDate)
+	default:
+		return nil, fmt.Errorf("statements are only available for customers and vendors, contact %s is %s", contact.Code, contact.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return buildStatement(contact, entries, startDate, endDate), nil
+}
+
+// GenerateStatementPDF - PDF laporan rekening dalam bahasa Indonesia ("id") atau Inggris ("en")
+func (s *StatementService) GenerateStatementPDF(contactID uint, startDate, endDate time.Time, lang string) ([]byte, *StatementOfAccount, error) {
+	if s.pdfService == nil {
+		return nil, nil, errors.New("PDF service not available")
+	}
+	statement, err := s.GetStatement(contactID, startDate, endDate)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pdfBytes, err := s.pdfService.GenerateStatementOfAccountPDF(statement, s.statementLanguage(lang))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate statement PDF: %v", err)
+	}
+	return pdfBytes, statement, nil
+}
+
+// GenerateCustomerStatementsZIP - Satu PDF laporan rekening per pelanggan yang masih memiliki saldo
+// piutang pada tanggal akhir, dikemas dalam satu file ZIP. Mengembalikan jumlah laporan di dalamnya.
+func (s *StatementService) GenerateCustomerStatementsZIP(startDate, endDate time.Time, lang string) ([]byte, int, error) {
+	if s.pdfService == nil {
+		return nil, 0, errors.New("PDF service not available")
+	}
+	if endDate.Before(startDate) {
+		return nil, 0, errors.New("end date must not be before start date")
+	}
+
+	var customerIDs []uint
+	if err := s.db.Model(&models.Sale{}).
+		Where("status IN ? AND date <= ?", statementSaleStatuses, endOfDay(endDate)).
+		Distinct().Pluck("customer_id", &customerIDs).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to find customers: %v", err)
+	}
+
+	var customers []models.Contact
+	if len(customerIDs) > 0 {
+		if err := s.db.Where("id IN ? AND type = ?", customerIDs, models.ContactTypeCustomer).
+			Order("name ASC").Find(&customers).Error; err != nil {
+			return nil, 0, fmt.Errorf("failed to load customers: %v", err)
+		}
+	}
+
+	language := s.statementLanguage(lang)
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	count := 0
+	for _, customer := range customers {
+		entries, err := s.customerEntries(customer.ID, endDate)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to build statement for %s: %v", customer.Code, err)
+		}
+		statement := buildStatement(customer, entries, startDate, endDate)
+		if statement.ClosingBalance <= 0.005 {
+			continue
+		}
+
+		pdfBytes, err := s.pdfService.GenerateStatementOfAccountPDF(statement, language)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to generate statement for %s: %v", customer.Code, err)
+		}
+		file, err := archive.Create(StatementFilename(statement))
+		if err != nil {
+			return nil, 0, err
+		}
+		if _, err := file.Write(pdfBytes); err != nil {
+			return nil, 0, err
+		}
+		count++
+	}
+	if err := archive.Close(); err != nil {
+		return nil, 0, err
+	}
+	if count == 0 {
+		return nil, 0, errors.New("no customer has an outstanding balance at the end date")
+	}
+
+	log.Printf("📨 Generated %d customer statements for %s - %s", count, startDate.Format("2006-01-02"), endDate.Format("2006-01-02"))
+	return buf.Bytes(), count, nil
+}
+
+var statementFilenameUnsafe = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// StatementFilename is the PDF file name of a statement, e.g. statement_CUST-0001_20250131.pdf
+func StatementFilename(statement *StatementOfAccount) string {
+	code := statementFilenameUnsafe.ReplaceAllString(statement.Contact.Code, "-")
+	return fmt.Sprintf("statement_%s_%s.pdf", code, statement.EndDate.Format("20060102"))
+}
+
+func (s *StatementService) statementLanguage(lang string) string {
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	if lang == "id" || lang == "en" {
+		return lang
+	}
+	return s.pdfService.Language()
+}
+
+// ========== ENTRIES ==========
+
+// customerEntries collects invoices, payments, credit notes and approved returns up to the end date
+func (s *StatementService) customerEntries(customerID uint, endDate time.Time) ([]statementEntry, error) {
+	end := endOfDay(endDate)
+
+	var sales []models.Sale
+	if err := s.db.Where("customer_id = ? AND status IN ? AND date <= ?", customerID, statementSaleStatuses, end).
+		Order("date ASC, id ASC").Find(&sales).Error; err != nil {
+		return nil, fmt.Errorf("failed to load sales: %v", err)
+	}
+	if len(sales) == 0 {
+		return nil, nil
+	}
+
+	salesByID := make(map[uint]models.Sale, len(sales))
+	saleIDs := make([]uint, 0, len(sales))
+	entries := make([]statementEntry, 0, len(sales)*2)
+	for _, sale := range sales {
+		salesByID[sale.ID] = sale
+		saleIDs = append(saleIDs, sale.ID)
+
+		reference := sale.InvoiceNumber
+		if reference == "" {
+			reference = sale.Code
+		}
+		dueDate := sale.DueDate
+		entries = append(entries, statementEntry{
+			documentID:  sale.ID,
+			date:        sale.Date,
+			lineType:    StatementLineInvoice,
+			reference:   reference,
+			description: sale.Reference,
+			dueDate:     &dueDate,
+			amount:      toStatementBase(sale.TotalAmount, sale.Currency, sale.ExchangeRate),
+		})
+	}
+
+	var payments []models.SalePayment
+	if err := s.db.Where("sale_id IN ? AND status <> ? AND payment_date <= ?", saleIDs, models.SalePaymentStatusCancelled, end).
+		Find(&payments).Error; err != nil {
+		return nil, fmt.Errorf("failed to load sale payments: %v", err)
+	}
+	for _, payment := range payments {
+		sale := salesByID[payment.SaleID]
+		entries = append(entries, statementEntry{
+			documentID:  sale.ID,
+			date:        payment.PaymentDate,
+			lineType:    StatementLinePayment,
+			reference:   firstNonEmpty(payment.Reference, sale.Code),
+			description: strings.TrimSpace(payment.PaymentMethod + " " + sale.Code),
+			amount:      -toStatementBase(payment.Amount, sale.Currency, sale.ExchangeRate),
+		})
+	}
+
+	var creditNotes []models.CreditNote
+	if err := s.db.Where("sale_id IN ? AND status <> ? AND date <= ?", saleIDs, "CANCELLED", end).
+		Find(&creditNotes).Error; err != nil {
+		return nil, fmt.Errorf("failed to load credit notes: %v", err)
+	}
+	for _, note := range creditNotes {
+		sale := salesByID[note.SaleID]
+		entries = append(entries, statementEntry{
+			documentID:  sale.ID,
+			date:        note.Date,
+			lineType:    StatementLineCreditNote,
+			reference:   note.CreditNoteNumber,
+			description: note.Reason,
+			amount:      -toStatementBase(note.Amount, sale.Currency, sale.ExchangeRate),
+		})
+	}
+
+	var returns []models.SaleReturn
+	if err := s.db.Where("sale_id IN ? AND status = ? AND date <= ?", saleIDs, models.ReturnStatusApproved, end).
+		Find(&returns).Error; err != nil {
+		return nil, fmt.Errorf("failed to load sale returns: %v", err)
+	}
+	for _, saleReturn := range returns {
+		sale := salesByID[saleReturn.SaleID]
+		entries = append(entries, statementEntry{
+			documentID:  sale.ID,
+			date:        saleReturn.Date,
+			lineType:    StatementLineReturn,
+			reference:   firstNonEmpty(saleReturn.CreditNoteNumber, saleReturn.ReturnNumber),
+			description: saleReturn.Reason,
+			amount:      -toStatementBase(saleReturn.TotalAmount, sale.Currency, sale.ExchangeRate),
+		})
+	}
+
+	return entries, nil
+}
+
+// vendorEntries collects bills, payments and purchase returns up to the end date. Vendor credit
+// applications are not listed: the return that created the credit already reduced the balance.
+func (s *StatementService) vendorEntries(vendorID uint, endDate time.Time) ([]statementEntry, error) {
+	end := endOfDay(endDate)
+
+	var purchases []models.Purchase
+	if err := s.db.Where("vendor_id = ? AND status IN ? AND date <= ?", vendorID, statementPurchaseStatuses, end).
+		Order("date ASC, id ASC").Find(&purchases).Error; err != nil {
+		return nil, fmt.Errorf("failed to load purchases: %v", err)
+	}
+	if len(purchases) == 0 {
+		return nil, nil
+	}
+
+	purchasesByID := make(map[uint]models.Purchase, len(purchases))
+	purchaseIDs := make([]uint, 0, len(purchases))
+	entries := make([]statementEntry, 0, len(purchases)*2)
+	for _, purchase := range purchases {
+		purchasesByID[purchase.ID] = purchase
+		purchaseIDs = append(purchaseIDs, purchase.ID)
+
+		dueDate := purchase.DueDate
+		entries = append(entries, statementEntry{
+			documentID:  purchase.ID,
+			date:        purchase.Date,
+			lineType:    StatementLineBill,
+			reference:   purchase.Code,
+			description: purchase.Notes,
+			dueDate:     &dueDate,
+			amount:      toStatementBase(purchase.TotalAmount, purchase.Currency, purchase.ExchangeRate),
+		})
+	}
+
+	var payments []models.PurchasePayment
+	if err := s.db.Where("purchase_id IN ? AND method <> ? AND date <= ?", purchaseIDs, models.PurchasePaymentVendorCredit, end).
+		Find(&payments).Error; err != nil {
+		return nil, fmt.Errorf("failed to load purchase payments: %v", err)
+	}
+	for _, payment := range payments {
+		purchase := purchasesByID[payment.PurchaseID]
+		entries = append(entries, statementEntry{
+			documentID:  purchase.ID,
+			date:        payment.Date,
+			lineType:    StatementLinePayment,
+			reference:   firstNonEmpty(payment.PaymentNumber, payment.Reference),
+			description: strings.TrimSpace(payment.Method + " " + purchase.Code),
+			amount:      -toStatementBase(payment.Amount, purchase.Currency, purchase.ExchangeRate),
+		})
+	}
+
+	var returns []models.PurchaseReturn
+	if err := s.db.Where("purchase_id IN ? AND status = ? AND date <= ?", purchaseIDs, models.PurchaseReturnStatusPosted, end).
+		Find(&returns).Error; err != nil {
+		return nil, fmt.Errorf("failed to load purchase returns: %v", err)
+	}
+	for _, purchaseReturn := range returns {
+		purchase := purchasesByID[purchaseReturn.PurchaseID]
+		entries = append(entries, statementEntry{
+			documentID:  purchase.ID,
+			date:        purchaseReturn.Date,
+			lineType:    StatementLineReturn,
+			reference:   firstNonEmpty(purchaseReturn.DebitNoteNumber, purchaseReturn.Code),
+			description: purchaseReturn.Reason,
+			amount:      -toStatementBase(purchaseReturn.TotalAmount, purchase.Currency, purchase.ExchangeRate),
+		})
+	}
+
+	return entries, nil
+}
+
+// buildStatement splits the entries into opening balance and period lines and ages the open documents
+func buildStatement(contact models.Contact, entries []statementEntry, startDate, endDate time.Time) *StatementOfAccount {
+	start := dateOnly(startDate)
+	end := endOfDay(endDate)
+	customer := contact.Type == models.ContactTypeCustomer
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		if !entries[i].date.Equal(entries[j].date) {
+			return entries[i].date.Before(entries[j].date)
+		}
+		// Charges before their settlements on the same day
+		return entries[i].amount > 0 && entries[j].amount <= 0
+	})
+
+	statement := &StatementOfAccount{
+		Contact: StatementContact{
+			ID:           contact.ID,
+			Code:         contact.Code,
+			Name:         contact.Name,
+			Type:         contact.Type,
+			Address:      contact.Address,
+			Email:        contact.Email,
+			Phone:        contact.Phone,
+			PaymentTerms: contact.PaymentTerms,
+		},
+		StartDate:   startDate,
+		EndDate:     endDate,
+		Currency:    models.BaseCurrency,
+		Lines:       []StatementLine{},
+		GeneratedAt: time.Now(),
+	}
+
+	outstanding := make(map[uint]float64)
+	documents := make(map[uint]statementEntry)
+	for _, entry := range entries {
+		outstanding[entry.documentID] += entry.amount
+		if entry.dueDate != nil {
+			documents[entry.documentID] = entry
+		}
+		if entry.date.Before(start) {
+			statement.OpeningBalance += entry.amount
+		}
+	}
+
+	balance := statement.OpeningBalance
+	for _, entry := range entries {
+		if entry.date.Before(start) {
+			continue
+		}
+		balance += entry.amount
+
+		line := StatementLine{
+			Date:        entry.date,
+			Type:        entry.lineType,
+			Reference:   entry.reference,
+			Description: entry.description,
+			DueDate:     entry.dueDate,
+			Balance:     roundAmount(balance),
+		}
+		// Customer: charges are debits. Vendor: bills are credits.
+		if (entry.amount >= 0) == customer {
+			line.Debit = roundAmount(math.Abs(entry.amount))
+			statement.TotalDebit += line.Debit
+		} else {
+			line.Credit = roundAmount(math.Abs(entry.amount))
+			statement.TotalCredit += line.Credit
+		}
+		statement.Lines = append(statement.Lines, line)
+	}
+
+	statement.OpeningBalance = roundAmount(statement.OpeningBalance)
+	statement.TotalDebit = roundAmount(statement.TotalDebit)
+	statement.TotalCredit = roundAmount(statement.TotalCredit)
+	if customer {
+		statement.ClosingBalance = roundAmount(statement.OpeningBalance + statement.TotalDebit - statement.TotalCredit)
+	} else {
+		statement.ClosingBalance = roundAmount(statement.OpeningBalance + statement.TotalCredit - statement.TotalDebit)
+	}
+
+	statement.Aging, statement.OpenDocuments = ageOpenDocuments(documents, outstanding, end)
+	return statement
+}
+
+// ageOpenDocuments buckets each open invoice/bill by days past its due date at the end date.
+// Documents settled beyond their amount (e.g. a return larger than what was still owed) are
+// reported as unapplied credit.
+func ageOpenDocuments(documents map[uint]statementEntry, outstanding map[uint]float64, asOf time.Time) (StatementAging, []StatementOpenDocument) {
+	var aging StatementAging
+	open := []StatementOpenDocument{}
+
+	for documentID, amount := range outstanding {
+		amount = roundAmount(amount)
+		if amount < 0 {
+			aging.UnappliedCredit += -amount
+			continue
+		}
+		if amount == 0 {
+			continue
+		}
+
+		document := documents[documentID]
+		dueDate := document.date
+		if document.dueDate != nil && !document.dueDate.IsZero() {
+			dueDate = *document.dueDate
+		}
+		days := int(dateOnly(asOf).Sub(dateOnly(dueDate)).Hours() / 24)
+		if days < 0 {
+			days = 0
+		}
+
+		switch {
+		case days <= 30:
+			aging.Days0To30 += amount
+		case days <= 60:
+			aging.Days31To60 += amount
+		case days <= 90:
+			aging.Days61To90 += amount
+		default:
+			aging.Over90 += amount
+		}
+		open = append(open, StatementOpenDocument{
+			Reference:   document.reference,
+			Date:        document.date,
+			DueDate:     dueDate,
+			DaysOverdue: days,
+			Outstanding: amount,
+		})
+	}
+
+	aging.Days0To30 = roundAmount(aging.Days0To30)
+	aging.Days31To60 = roundAmount(aging.Days31To60)
+	aging.Days61To90 = roundAmount(aging.Days61To90)
+	aging.Over90 = roundAmount(aging.Over90)
+	aging.Total = roundAmount(aging.Days0To30 + aging.Days31To60 + aging.Days61To90 + aging.Over90)
+	aging.UnappliedCredit = roundAmount(aging.UnappliedCredit)
+
+	sort.Slice(open, func(i, j int) bool { return open[i].DueDate.Before(open[j].DueDate) })
+	return aging, open
+}
+
+// toStatementBase converts a document amount to the base currency at the document's booked rate
+func toStatementBase(amount float64, currency string, rate float64) float64 {
+	return convertToBaseCurrency(decimal.NewFromFloat(amount), documentExchangeRate(currency, rate)).InexactFloat64()
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if strings.TrimSpace(value) != "" {
+			return value
+		}
+	}
+	return ""
+}
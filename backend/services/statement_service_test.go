@@ -0,0 +1,75 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"app-sistem-akuntansi/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func statementDate(month time.Month, day int) time.Time {
+	return time.Date(2024, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestBuildCustomerStatement(t *testing.T) {
+	due := func(month time.Month, day int) *time.Time {
+		date := statementDate(month, day)
+		return &date
+	}
+	customer := models.Contact{Code: "CUST/001", Name: "PT Pelanggan", Type: models.ContactTypeCustomer}
+	entries := []statementEntry{
+		{documentID: 2, date: statementDate(3, 10), lineType: "PAYMENT", reference: "PAY-001", amount: -400000},
+		{documentID: 1, date: statementDate(1, 5), lineType: "INVOICE", reference: "INV-001", dueDate: due(2, 4), amount: 1000000},
+		{documentID: 2, date: statementDate(3, 10), lineType: "INVOICE", reference: "INV-002", dueDate: due(4, 9), amount: 600000},
+		{documentID: 1, date: statementDate(2, 20), lineType: "PAYMENT", reference: "PAY-000", amount: -250000},
+		{documentID: 3, date: statementDate(3, 1), lineType: "INVOICE", reference: "INV-003", dueDate: due(3, 1), amount: 200000},
+		{documentID: 3, date: statementDate(3, 15), lineType: "RETURN", reference: "RET-001", amount: -300000},
+	}
+
+	statement := buildStatement(customer, entries, statementDate(3, 1), statementDate(3, 31))
+
+	assert.InDelta(t, 750000, statement.OpeningBalance, 0.001)
+	require.Len(t, statement.Lines, 4)
+	assert.Equal(t, "INV-003", statement.Lines[0].Reference)
+	assert.Equal(t, "INV-002", statement.Lines[1].Reference, "charges come before settlements on the same day")
+	assert.Equal(t, "PAY-001", statement.Lines[2].Reference)
+	assert.InDelta(t, 400000, statement.Lines[2].Credit, 0.001)
+	assert.InDelta(t, 800000, statement.TotalDebit, 0.001)
+	assert.InDelta(t, 700000, statement.TotalCredit, 0.001)
+	assert.InDelta(t, 850000, statement.ClosingBalance, 0.001)
+	assert.InDelta(t, statement.ClosingBalance, statement.Lines[3].Balance, 0.001)
+
+	aging := statement.Aging
+	assert.InDelta(t, 200000, aging.Days0To30, 0.001)
+	assert.InDelta(t, 750000, aging.Days31To60, 0.001)
+	assert.InDelta(t, 100000, aging.UnappliedCredit, 0.001, "a return larger than the open invoice")
+	assert.InDelta(t, statement.ClosingBalance, aging.Total-aging.UnappliedCredit, 0.001)
+	require.Len(t, statement.OpenDocuments, 2)
+	assert.Equal(t, "INV-001", statement.OpenDocuments[0].Reference)
+	assert.Equal(t, 56, statement.OpenDocuments[0].DaysOverdue)
+	assert.Equal(t, 0, statement.OpenDocuments[1].DaysOverdue, "not yet due")
+
+	assert.Equal(t, "statement_CUST-001_20240331.pdf", StatementFilename(statement))
+}
+
+func TestBuildVendorStatement(t *testing.T) {
+	vendor := models.Contact{Code: "VEND-001", Name: "PT Pemasok", Type: models.ContactTypeVendor}
+	entries := []statementEntry{
+		{documentID: 1, date: statementDate(1, 10), lineType: "BILL", reference: "PO-001", dueDate: &time.Time{}, amount: 2000000},
+		{documentID: 1, date: statementDate(5, 10), lineType: "PAYMENT", reference: "PAY-001", amount: -500000},
+	}
+
+	statement := buildStatement(vendor, entries, statementDate(5, 1), statementDate(5, 31))
+
+	assert.InDelta(t, 2000000, statement.OpeningBalance, 0.001)
+	require.Len(t, statement.Lines, 1)
+	assert.InDelta(t, 500000, statement.Lines[0].Debit, 0.001, "payments lower a vendor balance as debits")
+	assert.InDelta(t, 1500000, statement.ClosingBalance, 0.001)
+	// Without a due date the bill ages from its own date
+	assert.InDelta(t, 1500000, statement.Aging.Over90, 0.001)
+	require.Len(t, statement.OpenDocuments, 1)
+	assert.Equal(t, 142, statement.OpenDocuments[0].DaysOverdue)
+}
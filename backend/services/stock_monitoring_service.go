@@ -22,43 +22,68 @@ func NewStockMonitoringService(db *gorm.DB, notificationService *NotificationSer
 	}
 }
 
-// CheckMinimumStock checks all products for minimum stock levels
+// CheckMinimumStock checks all products for minimum stock levels.
+// Products tracked per warehouse are checked in every warehouse; the others company-wide.
 func (s *StockMonitoringService) CheckMinimumStock() error {
 	var products []models.Product
 	
 	// Get products where current stock is <= minimum stock
 	err := s.db.Where("stock <= min_stock AND min_stock > 0 AND is_active = ?", true).
+		Where("NOT EXISTS (SELECT 1 FROM warehouse_stocks ws WHERE ws.product_id = products.id)").
 		Preload("Category").Find(&products).Error
 	if err != nil {
 		return err
 	}
 
 	for _, product := range products {
-		if err := s.createMinimumStockNotification(&product); err != nil {
+		if err := s.createMinimumStockNotification(&product, nil); err != nil {
 			log.Printf("Failed to create minimum stock notification for product %s: %v", product.Code, err)
 		}
 	}
 
+	balances, err := s.GetLowStockByWarehouse()
+	if err != nil {
+		return err
+	}
+	for i := range balances {
+		if err := s.createMinimumStockNotification(&balances[i].Product, &balances[i]); err != nil {
+			log.Printf("Failed to create minimum stock notification for product %s in warehouse %d: %v",
+				balances[i].Product.Code, balances[i].WarehouseLocationID, err)
+		}
+	}
+
 	return nil
 }
 
-// CheckReorderLevel checks all products for reorder level
+// CheckReorderLevel checks all products for reorder level, per warehouse where tracked
 func (s *StockMonitoringService) CheckReorderLevel() error {
 	var products []models.Product
 	
 	// Get products where current stock is <= reorder level
 	err := s.db.Where("stock <= reorder_level AND reorder_level > 0 AND is_active = ?", true).
+		Where("NOT EXISTS (SELECT 1 FROM warehouse_stocks ws WHERE ws.product_id = products.id)").
 		Preload("Category").Find(&products).Error
 	if err != nil {
 		return err
 	}
 
 	for _, product := range products {
-		if err := s.createReorderNotification(&product); err != nil {
+		if err := s.createReorderNotification(&product, nil); err != nil {
 			log.Printf("Failed to create reorder notification for product %s: %v", product.Code, err)
 		}
 	}
 
+	balances, err := s.GetReorderByWarehouse()
+	if err != nil {
+		return err
+	}
+	for i := range balances {
+		if err := s.createReorderNotification(&balances[i].Product, &balances[i]); err != nil {
+			log.Printf("Failed to create reorder notification for product %s in warehouse %d: %v",
+				balances[i].Product.Code, balances[i].WarehouseLocationID, err)
+		}
+	}
+
 	return nil
 }
 
@@ -70,17 +95,37 @@ func (s *StockMonitoringService) CheckSingleProductStock(productID uint) error {
 		return err
 	}
 
-	// Check minimum stock
-	if product.MinStock > 0 && product.Stock <= product.MinStock {
-		if err := s.createMinimumStockNotification(&product); err != nil {
-			log.Printf("Failed to create minimum stock notification: %v", err)
+	var balances []models.WarehouseStock
+	if err := s.db.Preload("WarehouseLocation").Where("product_id = ?", productID).Find(&balances).Error; err != nil {
+		return err
+	}
+
+	// Not tracked per warehouse: check the company-wide stock
+	if len(balances) == 0 {
+		if product.MinStock > 0 && product.Stock <= product.MinStock {
+			if err := s.createMinimumStockNotification(&product, nil); err != nil {
+				log.Printf("Failed to create minimum stock notification: %v", err)
+			}
 		}
+		if product.ReorderLevel > 0 && product.Stock <= product.ReorderLevel {
+			if err := s.createReorderNotification(&product, nil); err != nil {
+				log.Printf("Failed to create reorder notification: %v", err)
+			}
+		}
+		return nil
 	}
 
-	// Check reorder level
-	if product.ReorderLevel > 0 && product.Stock <= product.ReorderLevel {
-		if err := s.createReorderNotification(&product); err != nil {
-			log.Printf("Failed to create reorder notification: %v", err)
+	for i := range balances {
+		balance := &balances[i]
+		if minStock := balance.EffectiveMinStock(&product); minStock > 0 && balance.Quantity <= minStock {
+			if err := s.createMinimumStockNotification(&product, balance); err != nil {
+				log.Printf("Failed to create minimum stock notification: %v", err)
+			}
+		}
+		if reorderLevel := balance.EffectiveReorderLevel(&product); reorderLevel > 0 && balance.Quantity <= reorderLevel {
+			if err := s.createReorderNotification(&product, balance); err != nil {
+				log.Printf("Failed to create reorder notification: %v", err)
+			}
 		}
 	}
 
@@ -107,6 +152,30 @@ func (s *StockMonitoringService) GetReorderProducts() ([]models.Product, error)
 	return products, err
 }
 
+// GetLowStockByWarehouse returns warehouse balances at or below their minimum stock
+func (s *StockMonitoringService) GetLowStockByWarehouse() ([]models.WarehouseStock, error) {
+	var balances []models.WarehouseStock
+	err := s.db.Joins("JOIN products p ON p.id = warehouse_stocks.product_id AND p.deleted_at IS NULL").
+		Where("p.is_active = ? AND p.is_service = ?", true, false).
+		Where("COALESCE(NULLIF(warehouse_stocks.min_stock, 0), p.min_stock) > 0").
+		Where("warehouse_stocks.quantity <= COALESCE(NULLIF(warehouse_stocks.min_stock, 0), p.min_stock)").
+		Preload("Product.Category").Preload("WarehouseLocation").
+		Find(&balances).Error
+	return balances, err
+}
+
+// GetReorderByWarehouse returns warehouse balances at or below their reorder level
+func (s *StockMonitoringService) GetReorderByWarehouse() ([]models.WarehouseStock, error) {
+	var balances []models.WarehouseStock
+	err := s.db.Joins("JOIN products p ON p.id = warehouse_stocks.product_id AND p.deleted_at IS NULL").
+		Where("p.is_active = ? AND p.is_service = ?", true, false).
+		Where("COALESCE(NULLIF(warehouse_stocks.reorder_level, 0), p.reorder_level) > 0").
+		Where("warehouse_stocks.quantity <= COALESCE(NULLIF(warehouse_stocks.reorder_level, 0), p.reorder_level)").
+		Preload("Product.Category").Preload("WarehouseLocation").
+		Find(&balances).Error
+	return balances, err
+}
+
 // GetStockAlerts returns comprehensive stock alerts
 func (s *StockMonitoringService) GetStockAlerts() (map[string]interface{}, error) {
 	alerts := make(map[string]interface{})
@@ -127,6 +196,14 @@ func (s *StockMonitoringService) GetStockAlerts() (map[string]interface{}, error
 	alerts["reorder_products"] = reorderProducts
 	alerts["reorder_count"] = len(reorderProducts)
 	
+	// Get per-warehouse low stock
+	lowStockByWarehouse, err := s.GetLowStockByWarehouse()
+	if err != nil {
+		return nil, err
+	}
+	alerts["low_stock_by_warehouse"] = lowStockByWarehouse
+	alerts["low_stock_by_warehouse_count"] = len(lowStockByWarehouse)
+	
 	// Get out of stock products
 	var outOfStockCount int64
 	err = s.db.Model(&models.Product{}).Where("stock = 0 AND is_active = ?", true).Count(&outOfStockCount).Error
@@ -140,20 +217,25 @@ func (s *StockMonitoringService) GetStockAlerts() (map[string]interface{}, error
 
 // Private helper methods
 
-func (s *StockMonitoringService) createMinimumStockNotification(product *models.Product) error {
-	// Check if active stock alert already exists for this product
+// createMinimumStockNotification raises a low stock alert for a product, scoped to one warehouse
+// when balance is given, otherwise company-wide
+func (s *StockMonitoringService) createMinimumStockNotification(product *models.Product, balance *models.WarehouseStock) error {
+	level := stockLevelFor(product, balance, false)
+
+	// Check if active stock alert already exists for this product (and warehouse)
 	var existingAlert models.StockAlert
-	err := s.db.Where("product_id = ? AND alert_type = ? AND status = ?",
-		product.ID, models.StockAlertTypeLowStock, models.StockAlertStatusActive).
+	err := s.alertScope(product.ID, level.warehouseID).
+		Where("alert_type = ? AND status = ?", models.StockAlertTypeLowStock, models.StockAlertStatusActive).
 		First(&existingAlert).Error
 	
 	if err == nil {
 		// Update existing alert with current stock
-		existingAlert.CurrentStock = product.Stock
+		existingAlert.CurrentStock = level.current
+		existingAlert.ThresholdStock = level.threshold
 		existingAlert.LastAlertAt = time.Now()
 		s.db.Save(&existingAlert)
-		log.Printf("[STOCK-ALERT] Updated existing low stock alert for product '%s' (ID: %d) - Current: %d, Min: %d", 
-			product.Name, product.ID, product.Stock, product.MinStock)
+		log.Printf("[STOCK-ALERT] Updated existing low stock alert for product '%s' (ID: %d)%s - Current: %d, Min: %d", 
+			product.Name, product.ID, level.label, level.current, level.threshold)
 		return nil // Don't create duplicate notification
 	}
 	
@@ -165,19 +247,33 @@ func (s *StockMonitoringService) createMinimumStockNotification(product *models.
 
 	// Create new stock alert record
 	stockAlert := models.StockAlert{
-		ProductID:      product.ID,
-		AlertType:      models.StockAlertTypeLowStock,
-		CurrentStock:   product.Stock,
-		ThresholdStock: product.MinStock,
-		Status:         models.StockAlertStatusActive,
-		LastAlertAt:    time.Now(),
+		ProductID:           product.ID,
+		WarehouseLocationID: level.warehouseID,
+		AlertType:           models.StockAlertTypeLowStock,
+		CurrentStock:        level.current,
+		ThresholdStock:      level.threshold,
+		Status:              models.StockAlertStatusActive,
+		LastAlertAt:         time.Now(),
 	}
 	if err := s.db.Create(&stockAlert).Error; err != nil {
 		log.Printf("[STOCK-ALERT-ERROR] Failed to create stock alert record for product %d: %v", product.ID, err)
 		return err
 	}
-	log.Printf("[STOCK-ALERT] Created new low stock alert for product '%s' (ID: %d) - Current: %d, Min: %d", 
-		product.Name, product.ID, product.Stock, product.MinStock)
+	log.Printf("[STOCK-ALERT] Created new low stock alert for product '%s' (ID: %d)%s - Current: %d, Min: %d", 
+		product.Name, product.ID, level.label, level.current, level.threshold)
+
+	webhookData := map[string]interface{}{
+		"stock_alert_id":        stockAlert.ID,
+		"product_id":            product.ID,
+		"product_code":          product.Code,
+		"product_name":          product.Name,
+		"warehouse_location_id": level.warehouseID,
+		"current_stock":         level.current,
+		"minimum_stock":         level.threshold,
+	}
+	if err := recordWebhookEvent(s.db, models.WebhookEventStockLow, "product", product.ID, webhookData); err != nil {
+		log.Printf("[STOCK-ALERT-ERROR] Failed to record stock.low webhook for product %d: %v", product.ID, err)
+	}
 
 	// Get all inventory managers and admins
 	userIDs, err := s.getInventoryManagers()
@@ -186,19 +282,21 @@ func (s *StockMonitoringService) createMinimumStockNotification(product *models.
 	}
 
 	title := "🚨 Minimum Stock Alert"
-	message := fmt.Sprintf("Product '%s' has reached minimum stock level. Current: %d, Minimum: %d", 
-		product.Name, product.Stock, product.MinStock)
+	message := fmt.Sprintf("Product '%s' has reached minimum stock level%s. Current: %d, Minimum: %d", 
+		product.Name, level.label, level.current, level.threshold)
 
 	data := map[string]interface{}{
-		"product_id":     product.ID,
-		"product_code":   product.Code,
-		"product_name":   product.Name,
-		"current_stock":  product.Stock,
-		"minimum_stock":  product.MinStock,
-		"category_name":  "",
-		"alert_type":     "minimum_stock",
-		"urgency":        "high",
-		"stock_alert_id": stockAlert.ID,
+		"product_id":            product.ID,
+		"product_code":          product.Code,
+		"product_name":          product.Name,
+		"current_stock":         level.current,
+		"minimum_stock":         level.threshold,
+		"category_name":         "",
+		"alert_type":            "minimum_stock",
+		"urgency":               "high",
+		"stock_alert_id":        stockAlert.ID,
+		"warehouse_location_id": level.notificationWarehouseID(),
+		"warehouse_name":        level.warehouseName,
 	}
 
 	if product.Category != nil {
@@ -213,9 +311,9 @@ func (s *StockMonitoringService) createMinimumStockNotification(product *models.
 	for _, userID := range userIDs {
 		// Check if notification already exists for this product and user
 		var existingNotif models.Notification
-		err := s.db.Session(&gorm.Session{Logger: logger.Default.LogMode(logger.Silent)}).Where("user_id = ? AND type = ? AND data::text LIKE ? AND is_read = ?",
+		err := s.db.Session(&gorm.Session{Logger: logger.Default.LogMode(logger.Silent)}).Where("user_id = ? AND type = ? AND data::text LIKE ? AND data::text LIKE ? AND is_read = ?",
 			userID, models.NotificationTypeLowStock, 
-			fmt.Sprintf(`%%"product_id":%d%%`, product.ID), false).
+			fmt.Sprintf(`%%"product_id":%d%%`, product.ID), level.notificationPattern(), false).
 			First(&existingNotif).Error
 		
 		if err == nil {
@@ -254,29 +352,34 @@ func (s *StockMonitoringService) createMinimumStockNotification(product *models.
 	return nil
 }
 
-func (s *StockMonitoringService) createReorderNotification(product *models.Product) error {
+// createReorderNotification notifies inventory managers that a product (in a warehouse, when
+// balance is given) has reached its reorder level
+func (s *StockMonitoringService) createReorderNotification(product *models.Product, balance *models.WarehouseStock) error {
 	userIDs, err := s.getInventoryManagers()
 	if err != nil {
 		return err
 	}
 
-	log.Printf("[REORDER-ALERT] Product '%s' (ID: %d) needs reordering - Current: %d, Reorder Level: %d", 
-		product.Name, product.ID, product.Stock, product.ReorderLevel)
+	level := stockLevelFor(product, balance, true)
+	log.Printf("[REORDER-ALERT] Product '%s' (ID: %d)%s needs reordering - Current: %d, Reorder Level: %d", 
+		product.Name, product.ID, level.label, level.current, level.threshold)
 
 	title := "📋 Reorder Alert"
-	message := fmt.Sprintf("Product '%s' needs reordering. Current: %d, Reorder Level: %d", 
-		product.Name, product.Stock, product.ReorderLevel)
+	message := fmt.Sprintf("Product '%s' needs reordering%s. Current: %d, Reorder Level: %d", 
+		product.Name, level.label, level.current, level.threshold)
 
 	data := map[string]interface{}{
-		"product_id":      product.ID,
-		"product_code":    product.Code,
-		"product_name":    product.Name,
-		"current_stock":   product.Stock,
-		"reorder_level":   product.ReorderLevel,
-		"category_name":   "",
-		"alert_type":      "reorder_needed",
-		"urgency":         "medium",
-		"suggested_qty":   product.MaxStock - product.Stock, // Suggest to fill up to max stock
+		"product_id":            product.ID,
+		"product_code":          product.Code,
+		"product_name":          product.Name,
+		"current_stock":         level.current,
+		"reorder_level":         level.threshold,
+		"category_name":         "",
+		"alert_type":            "reorder_needed",
+		"urgency":               "medium",
+		"suggested_qty":         product.MaxStock - product.Stock, // Suggest to fill up to max stock
+		"warehouse_location_id": level.notificationWarehouseID(),
+		"warehouse_name":        level.warehouseName,
 	}
 
 	if product.Category != nil {
@@ -289,9 +392,9 @@ func (s *StockMonitoringService) createReorderNotification(product *models.Produ
 	for _, userID := range userIDs {
 		// Check if notification already exists for this product and user
 		var existingNotif models.Notification
-		err := s.db.Session(&gorm.Session{Logger: logger.Default.LogMode(logger.Silent)}).Where("user_id = ? AND type = ? AND data::text LIKE ? AND is_read = ?",
+		err := s.db.Session(&gorm.Session{Logger: logger.Default.LogMode(logger.Silent)}).Where("user_id = ? AND type = ? AND data::text LIKE ? AND data::text LIKE ? AND is_read = ?",
 			userID, models.NotificationTypeReorderAlert,
-			fmt.Sprintf(`%%"product_id":%d%%`, product.ID), false).
+			fmt.Sprintf(`%%"product_id":%d%%`, product.ID), level.notificationPattern(), false).
 			First(&existingNotif).Error
 		
 		if err == nil {
@@ -359,26 +462,38 @@ func (s *StockMonitoringService) ResolveStockAlerts() error {
 	}
 
 	for _, alert := range activeAlerts {
+		current, threshold := alert.Product.Stock, alert.Product.MinStock
+		if alert.WarehouseLocationID != nil {
+			var balance models.WarehouseStock
+			if err := s.db.Where("product_id = ? AND warehouse_location_id = ?", alert.ProductID, *alert.WarehouseLocationID).
+				First(&balance).Error; err != nil {
+				continue
+			}
+			current, threshold = balance.Quantity, balance.EffectiveMinStock(&alert.Product)
+		}
+
 		// Check if stock is now above minimum
-		if alert.Product.Stock > alert.Product.MinStock {
+		if current > threshold {
 			// Resolve the alert
 			alert.Status = models.StockAlertStatusResolved
 			s.db.Save(&alert)
 
 			// Mark related notifications as read
-			s.markStockNotificationsAsRead(alert.ProductID)
+			s.markStockNotificationsAsRead(alert.ProductID, alert.WarehouseLocationID)
 		}
 	}
 
 	return nil
 }
 
-// markStockNotificationsAsRead marks all MIN_STOCK notifications for a product as read
-func (s *StockMonitoringService) markStockNotificationsAsRead(productID uint) error {
+// markStockNotificationsAsRead marks all MIN_STOCK notifications for a product (in a warehouse) as read
+func (s *StockMonitoringService) markStockNotificationsAsRead(productID uint, warehouseID *uint) error {
+	level := stockLevel{warehouseID: warehouseID}
 	return s.db.Model(&models.Notification{}).
-		Where("type = ? AND data::text LIKE ? AND is_read = ?",
+		Where("type = ? AND data::text LIKE ? AND data::text LIKE ? AND is_read = ?",
 			models.NotificationTypeLowStock,
 			fmt.Sprintf(`%%"product_id":%d%%`, productID),
+			level.notificationPattern(),
 			false).
 		Updates(map[string]interface{}{
 			"is_read": true,
@@ -392,6 +507,7 @@ func (s *StockMonitoringService) GetActiveStockAlerts() ([]models.StockAlert, er
 	err := s.db.Where("status = ?", models.StockAlertStatusActive).
 		Preload("Product").
 		Preload("Product.Category").
+		Preload("WarehouseLocation").
 		Order("last_alert_at DESC").
 		Find(&alerts).Error
 	return alerts, err
@@ -421,3 +537,63 @@ func (s *StockMonitoringService) ScheduledStockCheck() error {
 	log.Println("[STOCK-MONITOR] Scheduled stock monitoring check completed")
 	return nil
 }
+
+// stockLevel is the quantity and threshold an alert is raised on, company-wide or for one warehouse
+type stockLevel struct {
+	warehouseID   *uint
+	warehouseName string
+	label         string
+	current       int
+	threshold     int
+}
+
+func stockLevelFor(product *models.Product, balance *models.WarehouseStock, reorder bool) stockLevel {
+	if balance == nil {
+		level := stockLevel{current: product.Stock, threshold: product.MinStock}
+		if reorder {
+			level.threshold = product.ReorderLevel
+		}
+		return level
+	}
+
+	warehouseID := balance.WarehouseLocationID
+	level := stockLevel{
+		warehouseID: &warehouseID,
+		current:     balance.Quantity,
+		threshold:   balance.EffectiveMinStock(product),
+	}
+	if reorder {
+		level.threshold = balance.EffectiveReorderLevel(product)
+	}
+	level.warehouseName = balance.WarehouseLocation.Name
+	if level.warehouseName == "" {
+		level.warehouseName = fmt.Sprintf("#%d", warehouseID)
+	}
+	level.label = fmt.Sprintf(" in warehouse %s", level.warehouseName)
+	return level
+}
+
+// notificationWarehouseID is stored in the notification data (0 = company-wide)
+func (l stockLevel) notificationWarehouseID() uint {
+	if l.warehouseID == nil {
+		return 0
+	}
+	return *l.warehouseID
+}
+
+// notificationPattern matches notifications raised for the same warehouse scope.
+// Company-wide notifications created before warehouse tracking have no warehouse key at all.
+func (l stockLevel) notificationPattern() string {
+	if l.warehouseID == nil {
+		return "%"
+	}
+	return fmt.Sprintf(`%%"warehouse_location_id":%d,%%`, *l.warehouseID)
+}
+
+// alertScope filters stock alerts of a product for one warehouse or company-wide
+func (s *StockMonitoringService) alertScope(productID uint, warehouseID *uint) *gorm.DB {
+	if warehouseID == nil {
+		return s.db.Where("product_id = ? AND warehouse_location_id IS NULL", productID)
+	}
+	return s.db.Where("product_id = ? AND warehouse_location_id = ?", productID, *warehouseID)
+}
@@ -3,10 +3,13 @@ package services
 import (
 	"fmt"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"app-sistem-akuntansi/models"
 )
 
 // StockService handles stock/inventory operations
+// Product.Stock is the company-wide total; WarehouseStock holds the quantity per warehouse location.
+// Stock that predates warehouse tracking is assigned to the product's default warehouse on first use.
 type StockService struct {
 	db *gorm.DB
 }
@@ -16,8 +19,8 @@ func NewStockService(db *gorm.DB) *StockService {
 	return &StockService{db: db}
 }
 
-// ReduceStock reduces stock for a product
-func (s *StockService) ReduceStock(productID uint, quantity int, tx *gorm.DB) error {
+// ReduceStock reduces stock for a product in a warehouse (nil = product's default warehouse)
+func (s *StockService) ReduceStock(productID uint, warehouseID *uint, quantity int, tx *gorm.DB) error {
 	dbToUse := s.db
 	if tx != nil {
 		dbToUse = tx
@@ -39,13 +42,28 @@ func (s *StockService) ReduceStock(productID uint, quantity int, tx *gorm.DB) er
 		return fmt.Errorf("insufficient stock: available %d, requested %d", product.Stock, quantity)
 	}
 
+	if whID := resolveWarehouseID(&product, warehouseID); whID != nil {
+		if err := s.assignUnallocatedStock(dbToUse, &product); err != nil {
+			return err
+		}
+		balance, err := s.lockWarehouseStock(dbToUse, productID, *whID)
+		if err != nil {
+			return err
+		}
+		if balance.Quantity < quantity {
+			return fmt.Errorf("insufficient stock in warehouse %d: available %d, requested %d", *whID, balance.Quantity, quantity)
+		}
+		if err := dbToUse.Model(balance).UpdateColumn("quantity", balance.Quantity-quantity).Error; err != nil {
+			return fmt.Errorf("failed to update warehouse stock: %v", err)
+		}
+	}
+
 	// Reduce stock
-	product.Stock -= quantity
-	return dbToUse.Save(&product).Error
+	return dbToUse.Model(&product).UpdateColumn("stock", product.Stock-quantity).Error
 }
 
-// RestoreStock restores stock for a product
-func (s *StockService) RestoreStock(productID uint, quantity int, tx *gorm.DB) error {
+// RestoreStock restores stock for a product in a warehouse (nil = product's default warehouse)
+func (s *StockService) RestoreStock(productID uint, warehouseID *uint, quantity int, tx *gorm.DB) error {
 	dbToUse := s.db
 	if tx != nil {
 		dbToUse = tx
@@ -62,25 +80,278 @@ func (s *StockService) RestoreStock(productID uint, quantity int, tx *gorm.DB) e
 		return nil // No stock management needed for services
 	}
 
+	if whID := resolveWarehouseID(&product, warehouseID); whID != nil {
+		if err := s.assignUnallocatedStock(dbToUse, &product); err != nil {
+			return err
+		}
+		balance, err := s.lockWarehouseStock(dbToUse, productID, *whID)
+		if err != nil {
+			return err
+		}
+		if err := dbToUse.Model(balance).UpdateColumn("quantity", balance.Quantity+quantity).Error; err != nil {
+			return fmt.Errorf("failed to update warehouse stock: %v", err)
+		}
+	}
+
 	// Restore stock
-	product.Stock += quantity
-	return dbToUse.Save(&product).Error
+	return dbToUse.Model(&product).UpdateColumn("stock", product.Stock+quantity).Error
 }
 
-// GetStock gets current stock for a product
-func (s *StockService) GetStock(productID uint) (int, error) {
+// GetStock gets current stock for a product, in one warehouse when warehouseID is given
+func (s *StockService) GetStock(productID uint, warehouseID *uint) (int, error) {
 	var product models.Product
 	if err := s.db.First(&product, productID).Error; err != nil {
 		return 0, fmt.Errorf("product not found: %v", err)
 	}
-	return product.Stock, nil
+	if warehouseID == nil || *warehouseID == 0 {
+		return product.Stock, nil
+	}
+
+	var balance models.WarehouseStock
+	quantity := 0
+	if err := s.db.Where("product_id = ? AND warehouse_location_id = ?", productID, *warehouseID).First(&balance).Error; err == nil {
+		quantity = balance.Quantity
+	} else if err != gorm.ErrRecordNotFound {
+		return 0, err
+	}
+
+	// Stock not yet assigned to any warehouse belongs to the default warehouse
+	if whID := resolveWarehouseID(&product, nil); whID != nil && *whID == *warehouseID {
+		unallocated, err := s.unallocatedQuantity(s.db, &product)
+		if err != nil {
+			return 0, err
+		}
+		quantity += unallocated
+	}
+	return quantity, nil
 }
 
-// CheckStock checks if sufficient stock is available
-func (s *StockService) CheckStock(productID uint, quantity int) (bool, error) {
-	stock, err := s.GetStock(productID)
+// CheckStock checks if sufficient stock is available, in one warehouse when warehouseID is given
+func (s *StockService) CheckStock(productID uint, warehouseID *uint, quantity int) (bool, error) {
+	stock, err := s.GetStock(productID, warehouseID)
 	if err != nil {
 		return false, err
 	}
 	return stock >= quantity, nil
-}
\ No newline at end of file
+}
+
+// SyncWarehouseStock books quantity already added to Product.Stock (e.g. on purchase approval) on a
+// warehouse (nil = product's default warehouse). Stock that predates warehouse tracking is settled on
+// the default warehouse first, so only the new quantity lands on the given warehouse.
+func (s *StockService) SyncWarehouseStock(tx *gorm.DB, productID uint, warehouseID *uint, quantity int) error {
+	dbToUse := s.db
+	if tx != nil {
+		dbToUse = tx
+	}
+
+	var product models.Product
+	if err := dbToUse.First(&product, productID).Error; err != nil {
+		return fmt.Errorf("product not found: %v", err)
+	}
+	if product.IsService {
+		return nil
+	}
+	whID := resolveWarehouseID(&product, warehouseID)
+	if whID == nil {
+		return nil
+	}
+
+	before := product
+	before.Stock -= quantity
+	if err := s.assignUnallocatedStock(dbToUse, &before); err != nil {
+		return err
+	}
+	if quantity <= 0 {
+		return nil
+	}
+	return s.addToWarehouse(dbToUse, productID, *whID, quantity)
+}
+
+// MoveWarehouseStock relocates quantity between two warehouses without changing the product total
+func (s *StockService) MoveWarehouseStock(tx *gorm.DB, productID, fromWarehouseID, toWarehouseID uint, quantity int) error {
+	dbToUse := s.db
+	if tx != nil {
+		dbToUse = tx
+	}
+	if fromWarehouseID == toWarehouseID || quantity <= 0 {
+		return nil
+	}
+
+	if err := s.takeFromWarehouse(dbToUse, productID, fromWarehouseID, quantity); err != nil {
+		return err
+	}
+	return s.addToWarehouse(dbToUse, productID, toWarehouseID, quantity)
+}
+
+// GetWarehouseStocks returns per-warehouse balances, filtered by product and/or warehouse when non-zero
+func (s *StockService) GetWarehouseStocks(productID, warehouseID uint) ([]models.WarehouseStock, error) {
+	var balances []models.WarehouseStock
+	query := s.db.Preload("Product").Preload("WarehouseLocation")
+	if productID > 0 {
+		query = query.Where("product_id = ?", productID)
+	}
+	if warehouseID > 0 {
+		query = query.Where("warehouse_location_id = ?", warehouseID)
+	}
+	err := query.Order("warehouse_location_id, product_id").Find(&balances).Error
+	return balances, err
+}
+
+// SetWarehouseThresholds sets the per-warehouse minimum stock and reorder level (0 = use product values)
+func (s *StockService) SetWarehouseThresholds(productID, warehouseID uint, minStock, reorderLevel int) (*models.WarehouseStock, error) {
+	var balance *models.WarehouseStock
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		balance, err = s.lockWarehouseStock(tx, productID, warehouseID)
+		if err != nil {
+			return err
+		}
+		balance.MinStock = minStock
+		balance.ReorderLevel = reorderLevel
+		return tx.Model(balance).Updates(map[string]interface{}{
+			"min_stock":     minStock,
+			"reorder_level": reorderLevel,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return balance, nil
+}
+
+// takeFromWarehouse removes quantity from a warehouse balance only (Product.Stock untouched)
+func (s *StockService) takeFromWarehouse(tx *gorm.DB, productID, warehouseID uint, quantity int) error {
+	var product models.Product
+	if err := tx.First(&product, productID).Error; err != nil {
+		return fmt.Errorf("product not found: %v", err)
+	}
+	if err := s.assignUnallocatedStock(tx, &product); err != nil {
+		return err
+	}
+	balance, err := s.lockWarehouseStock(tx, productID, warehouseID)
+	if err != nil {
+		return err
+	}
+	if balance.Quantity < quantity {
+		return fmt.Errorf("insufficient stock of product '%s' in warehouse %d: available %d, requested %d",
+			product.Name, warehouseID, balance.Quantity, quantity)
+	}
+	return tx.Model(balance).UpdateColumn("quantity", balance.Quantity-quantity).Error
+}
+
+// addToWarehouse adds quantity to a warehouse balance only (Product.Stock untouched)
+func (s *StockService) addToWarehouse(tx *gorm.DB, productID, warehouseID uint, quantity int) error {
+	balance, err := s.lockWarehouseStock(tx, productID, warehouseID)
+	if err != nil {
+		return err
+	}
+	return tx.Model(balance).UpdateColumn("quantity", balance.Quantity+quantity).Error
+}
+
+// lockWarehouseStock loads the balance row FOR UPDATE, creating an empty one when missing
+func (s *StockService) lockWarehouseStock(tx *gorm.DB, productID, warehouseID uint) (*models.WarehouseStock, error) {
+	var balance models.WarehouseStock
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("product_id = ? AND warehouse_location_id = ?", productID, warehouseID).
+		First(&balance).Error
+	if err == gorm.ErrRecordNotFound {
+		balance = models.WarehouseStock{ProductID: productID, WarehouseLocationID: warehouseID}
+		if err := tx.Create(&balance).Error; err != nil {
+			return nil, fmt.Errorf("failed to create warehouse stock: %v", err)
+		}
+		return &balance, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load warehouse stock: %v", err)
+	}
+	return &balance, nil
+}
+
+// unallocatedQuantity is Product.Stock not held by any warehouse balance or an in-transit transfer
+func (s *StockService) unallocatedQuantity(db *gorm.DB, product *models.Product) (int, error) {
+	var allocated int
+	if err := db.Model(&models.WarehouseStock{}).Where("product_id = ?", product.ID).
+		Select("COALESCE(SUM(quantity), 0)").Scan(&allocated).Error; err != nil {
+		return 0, fmt.Errorf("failed to sum warehouse stock: %v", err)
+	}
+	var inTransit int
+	if err := db.Model(&models.StockTransferItem{}).
+		Joins("JOIN stock_transfers st ON st.id = stock_transfer_items.stock_transfer_id").
+		Where("stock_transfer_items.product_id = ? AND st.status = ? AND st.deleted_at IS NULL",
+			product.ID, models.StockTransferStatusInTransit).
+		Select("COALESCE(SUM(stock_transfer_items.quantity), 0)").Scan(&inTransit).Error; err != nil {
+		return 0, fmt.Errorf("failed to sum in-transit stock: %v", err)
+	}
+	return product.Stock - allocated - inTransit, nil
+}
+
+// assignUnallocatedStock books the unallocated difference on the product's default warehouse, whichever
+// warehouse the current operation touches; without a default warehouse it stays unallocated. A negative
+// difference (stock reduced outside warehouse tracking) is taken from the default warehouse first, then
+// from the others.
+func (s *StockService) assignUnallocatedStock(tx *gorm.DB, product *models.Product) error {
+	diff, err := s.unallocatedQuantity(tx, product)
+	if err != nil || diff == 0 {
+		return err
+	}
+
+	defaultID := resolveWarehouseID(product, nil)
+	shortfall := -diff
+	if defaultID != nil {
+		balance, err := s.lockWarehouseStock(tx, product.ID, *defaultID)
+		if err != nil {
+			return err
+		}
+		if diff > 0 {
+			return tx.Model(balance).UpdateColumn("quantity", balance.Quantity+diff).Error
+		}
+
+		take := shortfall
+		if take > balance.Quantity {
+			take = balance.Quantity
+		}
+		if take > 0 {
+			if err := tx.Model(balance).UpdateColumn("quantity", balance.Quantity-take).Error; err != nil {
+				return err
+			}
+			shortfall -= take
+		}
+	}
+	if shortfall <= 0 {
+		return nil
+	}
+
+	query := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("product_id = ? AND quantity > 0", product.ID)
+	if defaultID != nil {
+		query = query.Where("warehouse_location_id <> ?", *defaultID)
+	}
+	var others []models.WarehouseStock
+	if err := query.Order("quantity DESC").Find(&others).Error; err != nil {
+		return err
+	}
+	for i := range others {
+		if shortfall == 0 {
+			break
+		}
+		take := shortfall
+		if take > others[i].Quantity {
+			take = others[i].Quantity
+		}
+		if err := tx.Model(&others[i]).UpdateColumn("quantity", others[i].Quantity-take).Error; err != nil {
+			return err
+		}
+		shortfall -= take
+	}
+	return nil
+}
+
+// resolveWarehouseID picks the requested warehouse or falls back to the product's default location
+func resolveWarehouseID(product *models.Product, warehouseID *uint) *uint {
+	if warehouseID != nil && *warehouseID > 0 {
+		return warehouseID
+	}
+	if product.WarehouseLocationID != nil && *product.WarehouseLocationID > 0 {
+		return product.WarehouseLocationID
+	}
+	return nil
+}
@@ -0,0 +1,303 @@
+package services
+
+import (
+	"app-sistem-akuntansi/models"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// StockTransferService moves stock between warehouse locations.
+// Shipping takes the quantity out of the source warehouse; it stays in transit
+// (still counted in Product.Stock) until it is received at the destination.
+type StockTransferService struct {
+	db           *gorm.DB
+	stockService *StockService
+}
+
+func NewStockTransferService(db *gorm.DB) *StockTransferService {
+	return &StockTransferService{
+		db:           db,
+		stockService: NewStockService(db),
+	}
+}
+
+// ========== REQUEST TYPES ==========
+
+// StockTransferItemRequest - Baris produk yang dipindahkan
+type StockTransferItemRequest struct {
+	ProductID uint   `json:"product_id" binding:"required"`
+	Quantity  int    `json:"quantity" binding:"required,min=1"`
+	Notes     string `json:"notes"`
+}
+
+// StockTransferRequest - Input dokumen transfer stok antar gudang
+type StockTransferRequest struct {
+	FromWarehouseID uint                       `json:"from_warehouse_id" binding:"required"`
+	ToWarehouseID   uint                       `json:"to_warehouse_id" binding:"required"`
+	TransferDate    time.Time                  `json:"transfer_date" binding:"required"`
+	Notes           string                     `json:"notes"`
+	Items           []StockTransferItemRequest `json:"items" binding:"required,min=1,dive"`
+}
+
+// ========== QUERIES ==========
+
+// GetTransfers - Daftar transfer stok, opsional difilter status dan gudang
+func (s *StockTransferService) GetTransfers(status string, warehouseID uint) ([]models.StockTransfer, error) {
+	var transfers []models.StockTransfer
+	query := s.db.Preload("FromWarehouse").Preload("ToWarehouse").Preload("Items.Product")
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if warehouseID > 0 {
+		query = query.Where("from_warehouse_id = ? OR to_warehouse_id = ?", warehouseID, warehouseID)
+	}
+	err := query.Order("transfer_date DESC, id DESC").Find(&transfers).Error
+	return transfers, err
+}
+
+// GetTransferByID - Detail transfer stok
+func (s *StockTransferService) GetTransferByID(id uint) (*models.StockTransfer, error) {
+	var transfer models.StockTransfer
+	err := s.db.Preload("FromWarehouse").Preload("ToWarehouse").Preload("Items.Product").First(&transfer, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &transfer, nil
+}
+
+// ========== DRAFT ==========
+
+// CreateTransfer - Buat transfer stok berstatus DRAFT
+func (s *StockTransferService) CreateTransfer(req StockTransferRequest, userID uint) (*models.StockTransfer, error) {
+	if err := s.validateRequest(s.db, req); err != nil {
+		return nil, err
+	}
+
+	transfer := &models.StockTransfer{
+		FromWarehouseID: req.FromWarehouseID,
+		ToWarehouseID:   req.ToWarehouseID,
+		TransferDate:    req.TransferDate,
+		Status:          models.StockTransferStatusDraft,
+		Notes:           req.Notes,
+		CreatedBy:       userID,
+		Items:           buildTransferItems(req.Items),
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		code, err := s.generateTransferCode(tx, req.TransferDate)
+		if err != nil {
+			return err
+		}
+		transfer.Code = code
+		return tx.Create(transfer).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stock transfer: %v", err)
+	}
+	return s.GetTransferByID(transfer.ID)
+}
+
+// UpdateTransfer - Ubah transfer stok selama masih DRAFT
+func (s *StockTransferService) UpdateTransfer(id uint, req StockTransferRequest) (*models.StockTransfer, error) {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var transfer models.StockTransfer
+		if err := tx.First(&transfer, id).Error; err != nil {
+			return err
+		}
+		if transfer.Status != models.StockTransferStatusDraft {
+			return errors.New("only draft transfers can be edited")
+		}
+		if err := s.validateRequest(tx, req); err != nil {
+			return err
+		}
+
+		if err := tx.Where("stock_transfer_id = ?", id).Delete(&models.StockTransferItem{}).Error; err != nil {
+			return err
+		}
+		items := buildTransferItems(req.Items)
+		for i := range items {
+			items[i].StockTransferID = id
+		}
+		if err := tx.Create(&items).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&transfer).Updates(map[string]interface{}{
+			"from_warehouse_id": req.FromWarehouseID,
+			"to_warehouse_id":   req.ToWarehouseID,
+			"transfer_date":     req.TransferDate,
+			"notes":             req.Notes,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetTransferByID(id)
+}
+
+// DeleteTransfer - Hapus transfer stok DRAFT
+func (s *StockTransferService) DeleteTransfer(id uint) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var transfer models.StockTransfer
+		if err := tx.First(&transfer, id).Error; err != nil {
+			return err
+		}
+		if transfer.Status != models.StockTransferStatusDraft {
+			return errors.New("only draft transfers can be deleted")
+		}
+		if err := tx.Where("stock_transfer_id = ?", id).Delete(&models.StockTransferItem{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&transfer).Error
+	})
+}
+
+// ========== WORKFLOW ==========
+
+// ShipTransfer - DRAFT → IN_TRANSIT, stok keluar dari gudang asal
+func (s *StockTransferService) ShipTransfer(id uint, userID uint) (*models.StockTransfer, error) {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var transfer models.StockTransfer
+		if err := tx.Preload("Items").First(&transfer, id).Error; err != nil {
+			return err
+		}
+		if transfer.Status != models.StockTransferStatusDraft {
+			return fmt.Errorf("cannot ship transfer with status %s", transfer.Status)
+		}
+
+		for _, item := range transfer.Items {
+			if err := s.stockService.takeFromWarehouse(tx, item.ProductID, transfer.FromWarehouseID, item.Quantity); err != nil {
+				return err
+			}
+		}
+
+		now := time.Now()
+		return tx.Model(&transfer).Updates(map[string]interface{}{
+			"status":     models.StockTransferStatusInTransit,
+			"shipped_by": userID,
+			"shipped_at": &now,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("🚚 Stock transfer #%d shipped", id)
+	return s.GetTransferByID(id)
+}
+
+// ReceiveTransfer - IN_TRANSIT → RECEIVED, stok masuk ke gudang tujuan
+func (s *StockTransferService) ReceiveTransfer(id uint, userID uint) (*models.StockTransfer, error) {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var transfer models.StockTransfer
+		if err := tx.Preload("Items").First(&transfer, id).Error; err != nil {
+			return err
+		}
+		if transfer.Status != models.StockTransferStatusInTransit {
+			return fmt.Errorf("cannot receive transfer with status %s", transfer.Status)
+		}
+
+		// Mark received first so the quantity no longer counts as in transit
+		now := time.Now()
+		if err := tx.Model(&transfer).Updates(map[string]interface{}{
+			"status":      models.StockTransferStatusReceived,
+			"received_by": userID,
+			"received_at": &now,
+		}).Error; err != nil {
+			return err
+		}
+
+		for _, item := range transfer.Items {
+			if err := s.stockService.addToWarehouse(tx, item.ProductID, transfer.ToWarehouseID, item.Quantity); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("📥 Stock transfer #%d received", id)
+	return s.GetTransferByID(id)
+}
+
+// CancelTransfer - Batalkan transfer; barang yang sedang dikirim kembali ke gudang asal
+func (s *StockTransferService) CancelTransfer(id uint) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var transfer models.StockTransfer
+		if err := tx.Preload("Items").First(&transfer, id).Error; err != nil {
+			return err
+		}
+		if transfer.Status != models.StockTransferStatusDraft && transfer.Status != models.StockTransferStatusInTransit {
+			return fmt.Errorf("cannot cancel transfer with status %s", transfer.Status)
+		}
+		wasInTransit := transfer.Status == models.StockTransferStatusInTransit
+
+		if err := tx.Model(&transfer).Update("status", models.StockTransferStatusCancelled).Error; err != nil {
+			return err
+		}
+		if wasInTransit {
+			for _, item := range transfer.Items {
+				if err := s.stockService.addToWarehouse(tx, item.ProductID, transfer.FromWarehouseID, item.Quantity); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// ========== HELPERS ==========
+
+func (s *StockTransferService) validateRequest(tx *gorm.DB, req StockTransferRequest) error {
+	if req.FromWarehouseID == req.ToWarehouseID {
+		return errors.New("source and destination warehouse must be different")
+	}
+
+	var count int64
+	if err := tx.Model(&models.WarehouseLocation{}).
+		Where("id IN ? AND is_active = ?", []uint{req.FromWarehouseID, req.ToWarehouseID}, true).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count != 2 {
+		return errors.New("source or destination warehouse not found or inactive")
+	}
+
+	for _, item := range req.Items {
+		var product models.Product
+		if err := tx.First(&product, item.ProductID).Error; err != nil {
+			return fmt.Errorf("product %d not found", item.ProductID)
+		}
+		if product.IsService {
+			return fmt.Errorf("product '%s' is a service and has no stock", product.Name)
+		}
+	}
+	return nil
+}
+
+func buildTransferItems(reqs []StockTransferItemRequest) []models.StockTransferItem {
+	items := make([]models.StockTransferItem, 0, len(reqs))
+	for _, r := range reqs {
+		items = append(items, models.StockTransferItem{
+			ProductID: r.ProductID,
+			Quantity:  r.Quantity,
+			Notes:     r.Notes,
+		})
+	}
+	return items
+}
+
+func (s *StockTransferService) generateTransferCode(tx *gorm.DB, date time.Time) (string, error) {
+	prefix := fmt.Sprintf("TRF-%s-", date.Format("200601"))
+	var count int64
+	if err := tx.Unscoped().Model(&models.StockTransfer{}).
+		Where("code LIKE ?", prefix+"%").
+		Count(&count).Error; err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s%04d", prefix, count+1), nil
+}
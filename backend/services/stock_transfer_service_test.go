@@ -0,0 +1,128 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"app-sistem-akuntansi/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestResolveWarehouseID(t *testing.T) {
+	defaultID, requestedID, zero := uint(1), uint(2), uint(0)
+	tests := []struct {
+		name      string
+		product   models.Product
+		requested *uint
+		want      *uint
+	}{
+		{name: "requested warehouse wins", product: models.Product{WarehouseLocationID: &defaultID}, requested: &requestedID, want: &requestedID},
+		{name: "nil falls back to the product default", product: models.Product{WarehouseLocationID: &defaultID}, want: &defaultID},
+		{name: "zero falls back to the product default", product: models.Product{WarehouseLocationID: &defaultID}, requested: &zero, want: &defaultID},
+		{name: "no warehouse at all", product: models.Product{}},
+		{name: "zero default is no warehouse", product: models.Product{WarehouseLocationID: &zero}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := resolveWarehouseID(&tt.product, tt.requested)
+			if tt.want == nil {
+				assert.Nil(t, got)
+				return
+			}
+			require.NotNil(t, got)
+			assert.Equal(t, *tt.want, *got)
+		})
+	}
+}
+
+func setupStockTransferTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1) // Every connection to :memory: is a separate database
+	require.NoError(t, db.AutoMigrate(&models.WarehouseLocation{}, &models.Product{}, &models.WarehouseStock{}, &models.StockTransfer{}, &models.StockTransferItem{}))
+	return db
+}
+
+func TestStockTransferMovesWarehouseStock(t *testing.T) {
+	db := setupStockTransferTestDB(t)
+	service := NewStockTransferService(db)
+	stock := service.stockService
+
+	main := models.WarehouseLocation{Code: "WH-MAIN", Name: "Gudang Utama", IsActive: true}
+	branch := models.WarehouseLocation{Code: "WH-SBY", Name: "Gudang Surabaya", IsActive: true}
+	require.NoError(t, db.Create(&main).Error)
+	require.NoError(t, db.Create(&branch).Error)
+	// Stock recorded before warehouse tracking: all of it belongs to the default warehouse
+	product := models.Product{Code: "PRD-001", Name: "Kertas A4", Unit: "rim", Stock: 10, WarehouseLocationID: &main.ID}
+	require.NoError(t, db.Create(&product).Error)
+
+	quantityIn := func(warehouseID uint) int {
+		quantity, err := stock.GetStock(product.ID, &warehouseID)
+		require.NoError(t, err)
+		return quantity
+	}
+	productStock := func() int {
+		var stored models.Product
+		require.NoError(t, db.First(&stored, product.ID).Error)
+		return stored.Stock
+	}
+	assert.Equal(t, 10, quantityIn(main.ID))
+	assert.Equal(t, 0, quantityIn(branch.ID))
+
+	request := StockTransferRequest{
+		FromWarehouseID: main.ID,
+		ToWarehouseID:   branch.ID,
+		TransferDate:    time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC),
+		Items:           []StockTransferItemRequest{{ProductID: product.ID, Quantity: 4}},
+	}
+	transfer, err := service.CreateTransfer(request, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "TRF-202403-0001", transfer.Code)
+
+	_, err = service.ShipTransfer(transfer.ID, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 6, quantityIn(main.ID))
+	assert.Equal(t, 0, quantityIn(branch.ID), "in transit until received")
+	assert.Equal(t, 10, productStock(), "the company-wide total includes stock in transit")
+
+	received, err := service.ReceiveTransfer(transfer.ID, 1)
+	require.NoError(t, err)
+	assert.Equal(t, models.StockTransferStatusReceived, received.Status)
+	assert.Equal(t, 6, quantityIn(main.ID))
+	assert.Equal(t, 4, quantityIn(branch.ID))
+
+	// A sale from the branch only draws on the branch balance
+	assert.ErrorContains(t, stock.ReduceStock(product.ID, &branch.ID, 5, nil), "insufficient stock in warehouse")
+	require.NoError(t, stock.ReduceStock(product.ID, &branch.ID, 3, nil))
+	assert.Equal(t, 1, quantityIn(branch.ID))
+	assert.Equal(t, 7, productStock())
+
+	// Cancelling a shipped transfer returns the goods to the source warehouse
+	request.Items[0].Quantity = 2
+	transfer, err = service.CreateTransfer(request, 1)
+	require.NoError(t, err)
+	_, err = service.ShipTransfer(transfer.ID, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 4, quantityIn(main.ID))
+	require.NoError(t, service.CancelTransfer(transfer.ID))
+	assert.Equal(t, 6, quantityIn(main.ID))
+	assert.Equal(t, 7, productStock())
+
+	request.Items[0].Quantity = 7
+	transfer, err = service.CreateTransfer(request, 1)
+	require.NoError(t, err)
+	_, err = service.ShipTransfer(transfer.ID, 1)
+	assert.ErrorContains(t, err, "insufficient stock of product 'Kertas A4'")
+
+	request.ToWarehouseID = main.ID
+	_, err = service.CreateTransfer(request, 1)
+	assert.EqualError(t, err, "source and destination warehouse must be different")
+}
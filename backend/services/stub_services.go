@@ -79,6 +79,9 @@ func (s *UnifiedJournalService) CreateJournalEntry(entry interface{}) (*models.S
 
 	// Persist with lines in a transaction
 	if err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := checkJournalDimensions(tx, req.SourceType, journalDimensionLines(req.Lines)); err != nil {
+			return err
+		}
 		if err := tx.Create(entryModel).Error; err != nil {
 			return fmt.Errorf("failed to create journal entry: %w", err)
 		}
@@ -92,9 +95,13 @@ func (s *UnifiedJournalService) CreateJournalEntry(entry interface{}) (*models.S
 				DebitAmount: l.DebitAmount,
 				CreditAmount:l.CreditAmount,
 			}
+			applyLineDimensions(line, l.Dimensions)
 			if err := tx.Create(line).Error; err != nil {
 				return fmt.Errorf("failed to create journal line: %w", err)
 			}
+			if err := saveDimensionTags(tx, models.DimensionOwnerJournalLine, line.ID, l.Dimensions.TagIDs); err != nil {
+				return err
+			}
 		}
 		
 		// ✅ UPDATE ACCOUNT BALANCES if journal is auto-posted
@@ -102,34 +109,9 @@ func (s *UnifiedJournalService) CreateJournalEntry(entry interface{}) (*models.S
 			log.Printf("🔄 Updating account balances for posted journal entry ID=%d", entryModel.ID)
 			
 			for _, line := range req.Lines {
-				// Get account to determine normal balance type
-				var account models.Account
-				if err := tx.First(&account, line.AccountID).Error; err != nil {
-					log.Printf("⚠️ Warning: Failed to get account %d for balance update: %v", line.AccountID, err)
-					continue
-				}
-				
-				// Calculate balance change based on normal balance
-				// Debit increases Asset/Expense, Credit increases Liability/Equity/Revenue
-				var balanceChange float64
-				if account.Type == models.AccountTypeAsset || account.Type == models.AccountTypeExpense {
-					// Normal balance = DEBIT
-					// Debit increases (+), Credit decreases (-)
-					balanceChange = line.DebitAmount.InexactFloat64() - line.CreditAmount.InexactFloat64()
-				} else {
-					// Normal balance = CREDIT (Liability, Equity, Revenue)
-					// Credit increases (+), Debit decreases (-)
-					balanceChange = line.CreditAmount.InexactFloat64() - line.DebitAmount.InexactFloat64()
+				if err := applyPostedLineBalance(tx, line.AccountID, line.DebitAmount, line.CreditAmount); err != nil {
+					return err
 				}
-				
-				// Update account balance
-				if err := tx.Model(&models.Account{}).
-					Where("id = ?", line.AccountID).
-					UpdateColumn("balance", gorm.Expr("balance + ?", balanceChange)).Error; err != nil {
-					return fmt.Errorf("failed to update account %d balance: %w", line.AccountID, err)
-				}
-				
-				log.Printf("✅ Updated account %d (%s) balance: %+.2f", line.AccountID, account.Code, balanceChange)
 			}
 		}
 		
@@ -339,6 +321,11 @@ type PDFServiceInterface interface {
 	GenerateVendorHistoryPDF(historyData interface{}) ([]byte, error)
 	GenerateCustomerHistoryCSV(historyData interface{}) ([]byte, error)
 	GenerateVendorHistoryCSV(historyData interface{}) ([]byte, error)
+	GenerateBudgetVsActualPDF(report *BudgetVsActualReport) ([]byte, error)
+	GenerateDebitNotePDF(purchaseReturn *models.PurchaseReturn) ([]byte, error)
+	GenerateStatementOfAccountPDF(statement *StatementOfAccount, lang string) ([]byte, error)
+	GenerateComparativeStatementPDF(statement *ComparativeStatement) ([]byte, error)
+	GenerateDeliveryNotePDF(delivery *models.DeliveryOrder) ([]byte, error)
 	// Language returns current language based on settings
 	Language() string
 }
@@ -726,6 +713,9 @@ func (s *UnifiedJournalService) CreateJournalEntryWithTx(tx *gorm.DB, request *J
 		PostedBy:       postedBy,
 		CreatedBy:      request.CreatedBy,
 	}
+	if err := checkJournalDimensions(tx, request.SourceType, journalDimensionLines(request.Lines)); err != nil {
+		return nil, err
+	}
 	if err := tx.Create(entryModel).Error; err != nil {
 		return nil, fmt.Errorf("failed to create journal entry: %w", err)
 	}
@@ -738,9 +728,13 @@ func (s *UnifiedJournalService) CreateJournalEntryWithTx(tx *gorm.DB, request *J
 			DebitAmount: l.DebitAmount,
 			CreditAmount:l.CreditAmount,
 		}
+		applyLineDimensions(line, l.Dimensions)
 		if err := tx.Create(line).Error; err != nil {
 			return nil, fmt.Errorf("failed to create journal line: %w", err)
 		}
+		if err := saveDimensionTags(tx, models.DimensionOwnerJournalLine, line.ID, l.Dimensions.TagIDs); err != nil {
+			return nil, err
+		}
 	}
 	
 	// ✅ UPDATE ACCOUNT BALANCES if journal is auto-posted
@@ -748,40 +742,70 @@ func (s *UnifiedJournalService) CreateJournalEntryWithTx(tx *gorm.DB, request *J
 		log.Printf("🔄 Updating account balances for posted journal entry ID=%d", entryModel.ID)
 		
 		for _, line := range request.Lines {
-			// Get account to determine normal balance type
-			var account models.Account
-			if err := tx.First(&account, line.AccountID).Error; err != nil {
-				log.Printf("⚠️ Warning: Failed to get account %d for balance update: %v", line.AccountID, err)
-				continue
+			if err := applyPostedLineBalance(tx, line.AccountID, line.DebitAmount, line.CreditAmount); err != nil {
+				return nil, err
 			}
-			
-			// Calculate balance change based on normal balance
-			// Debit increases Asset/Expense, Credit increases Liability/Equity/Revenue
-			var balanceChange float64
-			if account.Type == models.AccountTypeAsset || account.Type == models.AccountTypeExpense {
-				// Normal balance = DEBIT
-				// Debit increases (+), Credit decreases (-)
-				balanceChange = line.DebitAmount.InexactFloat64() - line.CreditAmount.InexactFloat64()
-			} else {
-				// Normal balance = CREDIT (Liability, Equity, Revenue)
-				// Credit increases (+), Debit decreases (-)
-				balanceChange = line.CreditAmount.InexactFloat64() - line.DebitAmount.InexactFloat64()
-			}
-			
-			// Update account balance
-			if err := tx.Model(&models.Account{}).
-				Where("id = ?", line.AccountID).
-				UpdateColumn("balance", gorm.Expr("balance + ?", balanceChange)).Error; err != nil {
-				return nil, fmt.Errorf("failed to update account %d balance: %w", line.AccountID, err)
-			}
-			
-			log.Printf("✅ Updated account %d (%s) balance: %+.2f", line.AccountID, account.Code, balanceChange)
 		}
 	}
 	
 	return entryModel, nil
 }
 
+// PostDraftJournalEntryWithTx posts a DRAFT journal entry, e.g. a manual journal once its
+// approval request is approved, and updates the account balances of its lines
+func (s *UnifiedJournalService) PostDraftJournalEntryWithTx(tx *gorm.DB, entryID uint64, postedBy uint64) error {
+	var entry models.SSOTJournalEntry
+	if err := tx.Preload("Lines").First(&entry, entryID).Error; err != nil {
+		return fmt.Errorf("journal entry not found: %w", err)
+	}
+	if !entry.CanPost() {
+		return fmt.Errorf("journal entry %d cannot be posted in status %s", entry.ID, entry.Status)
+	}
+
+	now := time.Now()
+	if err := tx.Model(&entry).Updates(map[string]interface{}{
+		"status":    models.SSOTStatusPosted,
+		"posted_at": now,
+		"posted_by": postedBy,
+	}).Error; err != nil {
+		return fmt.Errorf("failed to post journal entry: %w", err)
+	}
+
+	log.Printf("🔄 Updating account balances for posted journal entry ID=%d", entry.ID)
+	for _, line := range entry.Lines {
+		if err := applyPostedLineBalance(tx, line.AccountID, line.DebitAmount, line.CreditAmount); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyPostedLineBalance moves the balance of the account of one posted journal line.
+// Debit increases Asset/Expense, Credit increases Liability/Equity/Revenue.
+func applyPostedLineBalance(tx *gorm.DB, accountID uint64, debit, credit decimal.Decimal) error {
+	var account models.Account
+	if err := tx.First(&account, accountID).Error; err != nil {
+		log.Printf("⚠️ Warning: Failed to get account %d for balance update: %v", accountID, err)
+		return nil
+	}
+
+	var balanceChange float64
+	if account.Type == models.AccountTypeAsset || account.Type == models.AccountTypeExpense {
+		balanceChange = debit.InexactFloat64() - credit.InexactFloat64()
+	} else {
+		balanceChange = credit.InexactFloat64() - debit.InexactFloat64()
+	}
+
+	if err := tx.Model(&models.Account{}).
+		Where("id = ?", accountID).
+		UpdateColumn("balance", gorm.Expr("balance + ?", balanceChange)).Error; err != nil {
+		return fmt.Errorf("failed to update account %d balance: %w", accountID, err)
+	}
+
+	log.Printf("✅ Updated account %d (%s) balance: %+.2f", accountID, account.Code, balanceChange)
+	return nil
+}
+
 func (s *UnifiedJournalService) GetAccountBalances() ([]models.Account, error) {
 	// Try to load from SSOT materialized view if available; otherwise, return empty slice safely
 	var balances []models.Account
@@ -880,6 +904,15 @@ type JournalLineRequest struct {
 	DebitAmount decimal.Decimal
 	CreditAmount decimal.Decimal
 	Description string
+	Dimensions  models.LineDimensions // optional cost center / project / tags
+}
+
+func journalDimensionLines(lines []JournalLineRequest) []dimensionLine {
+	result := make([]dimensionLine, 0, len(lines))
+	for _, l := range lines {
+		result = append(result, dimensionLine{AccountID: l.AccountID, Dimensions: l.Dimensions})
+	}
+	return result
 }
 
 type JournalEntryRequest struct {
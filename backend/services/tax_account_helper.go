@@ -130,6 +130,52 @@ func (h *TaxAccountHelper) GetTaxPayableAccount(tx *gorm.DB) (*models.Account, e
 	return h.getAccountByCode(tx, "2104")
 }
 
+// GetReceivableAccount returns the configured Accounts Receivable account or fallback
+func (h *TaxAccountHelper) GetReceivableAccount(tx *gorm.DB) (*models.Account, error) {
+	// Ensure settings are loaded
+	if h.cachedSettings == nil {
+		if err := h.LoadSettings(); err != nil {
+			// Fallback to hardcoded account
+			return h.getAccountByCode(tx, "1201") // Default Piutang Usaha
+		}
+	}
+
+	// Use configured account if available
+	if h.cachedSettings.SalesReceivableAccountID > 0 {
+		var account models.Account
+		if err := tx.First(&account, h.cachedSettings.SalesReceivableAccountID).Error; err == nil {
+			return &account, nil
+		}
+	}
+
+	// Fallback to default
+	log.Printf("⚠️ [TaxAccountHelper] Receivable account not configured, using default (1201)")
+	return h.getAccountByCode(tx, "1201")
+}
+
+// GetPayableAccount returns the configured Accounts Payable account or fallback
+func (h *TaxAccountHelper) GetPayableAccount(tx *gorm.DB) (*models.Account, error) {
+	// Ensure settings are loaded
+	if h.cachedSettings == nil {
+		if err := h.LoadSettings(); err != nil {
+			// Fallback to hardcoded account
+			return h.getAccountByCode(tx, "2101") // Default Hutang Usaha
+		}
+	}
+
+	// Use configured account if available
+	if h.cachedSettings.PurchasePayableAccountID > 0 {
+		var account models.Account
+		if err := tx.First(&account, h.cachedSettings.PurchasePayableAccountID).Error; err == nil {
+			return &account, nil
+		}
+	}
+
+	// Fallback to default
+	log.Printf("⚠️ [TaxAccountHelper] Payable account not configured, using default (2101)")
+	return h.getAccountByCode(tx, "2101")
+}
+
 // GetInventoryAccount returns the configured Inventory account or fallback
 func (h *TaxAccountHelper) GetInventoryAccount(tx *gorm.DB) (*models.Account, error) {
 	// Ensure settings are loaded
@@ -0,0 +1,1223 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"app-sistem-akuntansi/models"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// emptyNPWP is reported for buyers without a tax number
+const emptyNPWP = "000000000000000"
+
+// taxInvoiceSaleStatuses are the sale statuses for which a tax invoice can be issued
+var taxInvoiceSaleStatuses = []string{
+	models.SaleStatusInvoiced, models.SaleStatusOverdue, models.SaleStatusPaid, models.SaleStatusCompleted,
+}
+
+// taxInvoicePurchaseStatuses are the purchase statuses whose PPN Masukan is booked
+var taxInvoicePurchaseStatuses = []string{
+	models.PurchaseStatusApproved, models.PurchaseStatusCompleted, models.PurchaseStatusPaid,
+}
+
+type TaxInvoiceService struct {
+	db                *gorm.DB
+	taxAccountService *TaxAccountService
+	taxPaymentService *TaxPaymentService
+}
+
+func NewTaxInvoiceService(db *gorm.DB) *TaxInvoiceService {
+	return &TaxInvoiceService{
+		db:                db,
+		taxAccountService: NewTaxAccountService(db),
+		taxPaymentService: NewTaxPaymentService(db),
+	}
+}
+
+// TaxInvoiceImportResult - Ringkasan impor faktur pajak masukan
+type TaxInvoiceImportResult struct {
+	Imported   int      `json:"imported"`
+	Matched    int      `json:"matched"`
+	Unmatched  int      `json:"unmatched"`
+	Duplicates int      `json:"duplicates"`
+	Errors     []string `json:"errors"`
+}
+
+// PPNDocument is a sale or purchase with PPN that has no tax invoice in the period
+type PPNDocument struct {
+	ID     uint      `json:"id"`
+	Code   string    `json:"code"`
+	Date   time.Time `json:"date"`
+	Name   string    `json:"name"`
+	NPWP   string    `json:"npwp"`
+	DPP    float64   `json:"dpp"`
+	PPN    float64   `json:"ppn"`
+	Status string    `json:"status"`
+}
+
+// PPNWorksheetSection reconciles the tax invoices of one side against the PPN account in the ledger
+type PPNWorksheetSection struct {
+	AccountID        uint          `json:"account_id"`
+	AccountCode      string        `json:"account_code"`
+	AccountName      string        `json:"account_name"`
+	InvoiceCount     int64         `json:"invoice_count"`
+	DPP              float64       `json:"dpp"`
+	PPN              float64       `json:"ppn"`
+	NonCreditablePPN float64       `json:"non_creditable_ppn,omitempty"` // INPUT only
+	LedgerPPN        float64       `json:"ledger_ppn"`                   // Account movement of the month, PPN remittances excluded
+	Difference       float64       `json:"difference"`                   // Ledger - invoices
+	AccountBalance   float64       `json:"account_balance"`              // Current balance (TaxPaymentService.GetPPNBalance)
+	UnmatchedCount   int64         `json:"unmatched_count,omitempty"`    // INPUT only: imported invoices without purchase
+	MissingInvoices  []PPNDocument `json:"missing_invoices"`             // Documents with PPN but no tax invoice
+}
+
+// SPTMasaPPNWorksheet - Kertas kerja SPT Masa PPN bulanan
+type SPTMasaPPNWorksheet struct {
+	Month       int                    `json:"month"`
+	Year        int                    `json:"year"`
+	PeriodStart time.Time              `json:"period_start"`
+	PeriodEnd   time.Time              `json:"period_end"`
+	CompanyName string                 `json:"company_name"`
+	CompanyNPWP string                 `json:"company_npwp"`
+	Output      PPNWorksheetSection    `json:"output"`
+	Input       PPNWorksheetSection    `json:"input"`
+	NetPPN      float64                `json:"net_ppn"` // Output - creditable input
+	Result      string                 `json:"result"`  // KURANG_BAYAR, LEBIH_BAYAR, NIHIL
+	Payments    map[string]interface{} `json:"payments"`
+	Warnings    []string               `json:"warnings"`
+	GeneratedAt time.Time              `json:"generated_at"`
+}
+
+// ========== NUMBER RANGES ==========
+
+// GetNumberRanges - Daftar rentang NSFP
+func (s *TaxInvoiceService) GetNumberRanges(year int) ([]models.TaxInvoiceNumberRange, error) {
+	var ranges []models.TaxInvoiceNumberRange
+	query := s.db.Model(&models.TaxInvoiceNumberRange{})
+	if year != 0 {
+		query = query.Where("year = ?", year)
+	}
+	if err := query.Order("year DESC, id ASC").Find(&ranges).Error; err != nil {
+		return nil, err
+	}
+	return ranges, nil
+}
+
+// CreateNumberRange - Simpan rentang NSFP yang diberikan DJP
+func (s *TaxInvoiceService) CreateNumberRange(req models.TaxInvoiceRangeRequest, userID uint) (*models.TaxInvoiceNumberRange, error) {
+	start, err := normalizeNSFP(req.StartNumber)
+	if err != nil {
+		return nil, fmt.Errorf("start_number: %v", err)
+	}
+	end, err := normalizeNSFP(req.EndNumber)
+	if err != nil {
+		return nil, fmt.Errorf("end_number: %v", err)
+	}
+	if start[:5] != end[:5] {
+		return nil, errors.New("start and end number must share the same branch and year prefix")
+	}
+	startSerial, _ := strconv.ParseInt(start[5:], 10, 64)
+	endSerial, _ := strconv.ParseInt(end[5:], 10, 64)
+	if endSerial < startSerial {
+		return nil, errors.New("end number must not be lower than the start number")
+	}
+
+	numberRange := &models.TaxInvoiceNumberRange{
+		Year:          2000 + atoiOrZero(start[3:5]),
+		Prefix:        start[:5],
+		StartSerial:   startSerial,
+		EndSerial:     endSerial,
+		NextSerial:    startSerial,
+		Status:        models.TaxInvoiceRangeActive,
+		AllocationRef: req.AllocationRef,
+		Notes:         req.Notes,
+		CreatedBy:     userID,
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		var overlapping int64
+		if err := tx.Model(&models.TaxInvoiceNumberRange{}).
+			Where("prefix = ? AND start_serial <= ? AND end_serial >= ?", numberRange.Prefix, endSerial, startSerial).
+			Count(&overlapping).Error; err != nil {
+			return err
+		}
+		if overlapping > 0 {
+			return errors.New("the range overlaps an existing tax invoice number range")
+		}
+		return tx.Create(numberRange).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	numberRange.AfterFind(nil)
+	return numberRange, nil
+}
+
+// CloseNumberRange - Tutup rentang NSFP agar tidak dipakai lagi
+func (s *TaxInvoiceService) CloseNumberRange(id uint) (*models.TaxInvoiceNumberRange, error) {
+	var numberRange models.TaxInvoiceNumberRange
+	if err := s.db.First(&numberRange, id).Error; err != nil {
+		return nil, errors.New("tax invoice number range not found")
+	}
+	if numberRange.Status != models.TaxInvoiceRangeActive {
+		return nil, fmt.Errorf("number range is already %s", numberRange.Status)
+	}
+	if err := s.db.Model(&numberRange).Update("status", models.TaxInvoiceRangeClosed).Error; err != nil {
+		return nil, err
+	}
+	return &numberRange, nil
+}
+
+// ========== TAX INVOICES ==========
+
+// GetTaxInvoices - Daftar faktur pajak per masa
+func (s *TaxInvoiceService) GetTaxInvoices(direction string, month, year int, status string) ([]models.TaxInvoice, error) {
+	var invoices []models.TaxInvoice
+	query := s.db.Model(&models.TaxInvoice{})
+	if direction != "" {
+		query = query.Where("direction = ?", strings.ToUpper(direction))
+	}
+	if month != 0 {
+		query = query.Where("tax_period_month = ?", month)
+	}
+	if year != 0 {
+		query = query.Where("tax_period_year = ?", year)
+	}
+	if status != "" {
+		query = query.Where("status = ?", strings.ToUpper(status))
+	}
+	if err := query.Order("invoice_date ASC, number ASC").Find(&invoices).Error; err != nil {
+		return nil, err
+	}
+	return invoices, nil
+}
+
+// AllocateSaleInvoice assigns the next NSFP of an active range to a sale and records the PPN Keluaran invoice
+func (s *TaxInvoiceService) AllocateSaleInvoice(saleID uint, req models.TaxInvoiceAllocateRequest, userID uint) (*models.TaxInvoice, error) {
+	transactionCode, err := normalizeTransactionCode(req.TransactionCode)
+	if err != nil {
+		return nil, err
+	}
+
+	var invoice *models.TaxInvoice
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		var sale models.Sale
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&sale, saleID).Error; err != nil {
+			return errors.New("sale not found")
+		}
+		if !containsString(taxInvoiceSaleStatuses, sale.Status) {
+			return fmt.Errorf("a tax invoice cannot be issued for a %s sale", strings.ToLower(sale.Status))
+		}
+		dpp, ppn := saleTaxAmounts(&sale)
+		if ppn.LessThanOrEqual(decimal.Zero) {
+			return errors.New("sale has no PPN")
+		}
+
+		var existing int64
+		if err := tx.Model(&models.TaxInvoice{}).
+			Where("sale_id = ? AND direction = ? AND status = ?", sale.ID, models.TaxInvoiceOutput, models.TaxInvoiceStatusActive).
+			Count(&existing).Error; err != nil {
+			return err
+		}
+		if existing > 0 {
+			return errors.New("sale already has an active tax invoice")
+		}
+
+		var customer models.Contact
+		if err := tx.First(&customer, sale.CustomerID).Error; err != nil {
+			return errors.New("customer not found")
+		}
+
+		invoiceDate := dateOnly(sale.Date)
+		if req.InvoiceDate != nil {
+			invoiceDate = dateOnly(*req.InvoiceDate)
+		}
+
+		var numberRange models.TaxInvoiceNumberRange
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("year = ? AND status = ? AND next_serial <= end_serial", invoiceDate.Year(), models.TaxInvoiceRangeActive).
+			Order("id ASC").First(&numberRange).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("no tax invoice numbers left for %d, register a new NSFP range", invoiceDate.Year())
+			}
+			return err
+		}
+
+		number := models.FormatNSFP(numberRange.Prefix, numberRange.NextSerial)
+		updates := map[string]interface{}{"next_serial": numberRange.NextSerial + 1}
+		if numberRange.NextSerial >= numberRange.EndSerial {
+			updates["status"] = models.TaxInvoiceRangeExhausted
+		}
+		if err := tx.Model(&numberRange).Updates(updates).Error; err != nil {
+			return fmt.Errorf("failed to advance number range: %v", err)
+		}
+
+		address := strings.TrimSpace(sale.BillingAddress)
+		if address == "" {
+			address = customer.Address
+		}
+		invoice = &models.TaxInvoice{
+			Direction:           models.TaxInvoiceOutput,
+			Number:              number,
+			TransactionCode:     transactionCode,
+			InvoiceDate:         invoiceDate,
+			TaxPeriodMonth:      int(invoiceDate.Month()),
+			TaxPeriodYear:       invoiceDate.Year(),
+			RangeID:             &numberRange.ID,
+			SaleID:              &sale.ID,
+			Reference:           sale.Code,
+			CounterpartyNPWP:    normalizeNPWP(customer.TaxNumber),
+			CounterpartyName:    customer.Name,
+			CounterpartyAddress: address,
+			DPP:                 dpp.InexactFloat64(),
+			PPN:                 ppn.InexactFloat64(),
+			Creditable:          true,
+			Status:              models.TaxInvoiceStatusActive,
+			CreatedBy:           userID,
+		}
+		return tx.Create(invoice).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("🧾 Tax invoice %s allocated to sale %s", invoice.Number, invoice.Reference)
+	return invoice, nil
+}
+
+// CancelTaxInvoice cancels a tax invoice. The number of a cancelled output invoice is not reused.
+func (s *TaxInvoiceService) CancelTaxInvoice(id uint) (*models.TaxInvoice, error) {
+	var invoice models.TaxInvoice
+	if err := s.db.First(&invoice, id).Error; err != nil {
+		return nil, errors.New("tax invoice not found")
+	}
+	if invoice.Status != models.TaxInvoiceStatusActive {
+		return nil, fmt.Errorf("tax invoice is already %s", strings.ToLower(invoice.Status))
+	}
+	now := time.Now()
+	if err := s.db.Model(&invoice).Updates(map[string]interface{}{
+		"status":       models.TaxInvoiceStatusCancelled,
+		"cancelled_at": now,
+	}).Error; err != nil {
+		return nil, err
+	}
+	invoice.Status = models.TaxInvoiceStatusCancelled
+	invoice.CancelledAt = &now
+	return &invoice, nil
+}
+
+// RecordPurchaseInvoice records the vendor tax invoice (PPN Masukan) of a purchase
+func (s *TaxInvoiceService) RecordPurchaseInvoice(purchaseID uint, req models.PurchaseTaxInvoiceRequest, userID uint) (*models.TaxInvoice, error) {
+	number, code, flag, err := parseTaxInvoiceNumber(req.Number)
+	if err != nil {
+		return nil, err
+	}
+	if req.TransactionCode != "" {
+		if code, err = normalizeTransactionCode(req.TransactionCode); err != nil {
+			return nil, err
+		}
+	}
+	if req.ReplacementFlag != 0 {
+		flag = req.ReplacementFlag
+	}
+
+	var purchase models.Purchase
+	if err := s.db.Preload("Vendor").First(&purchase, purchaseID).Error; err != nil {
+		return nil, errors.New("purchase not found")
+	}
+	if !containsString(taxInvoicePurchaseStatuses, purchase.Status) {
+		return nil, fmt.Errorf("a tax invoice cannot be recorded for a %s purchase", strings.ToLower(purchase.Status))
+	}
+
+	dpp, ppn := purchaseTaxAmounts(&purchase)
+	if req.DPP != nil {
+		dpp = decimal.NewFromFloat(*req.DPP).Round(2)
+	}
+	if req.PPN != nil {
+		ppn = decimal.NewFromFloat(*req.PPN).Round(2)
+	}
+	invoiceDate := dateOnly(req.InvoiceDate)
+	month, year := req.TaxPeriodMonth, req.TaxPeriodYear
+	if month == 0 || year == 0 {
+		month, year = int(invoiceDate.Month()), invoiceDate.Year()
+	}
+	creditable := true
+	if req.Creditable != nil {
+		creditable = *req.Creditable
+	}
+
+	invoice := &models.TaxInvoice{
+		Direction:           models.TaxInvoiceInput,
+		Number:              number,
+		TransactionCode:     code,
+		ReplacementFlag:     flag,
+		InvoiceDate:         invoiceDate,
+		TaxPeriodMonth:      month,
+		TaxPeriodYear:       year,
+		PurchaseID:          &purchase.ID,
+		Reference:           purchase.Code,
+		CounterpartyNPWP:    normalizeNPWP(purchase.Vendor.TaxNumber),
+		CounterpartyName:    purchase.Vendor.Name,
+		CounterpartyAddress: purchase.Vendor.Address,
+		DPP:                 dpp.InexactFloat64(),
+		PPN:                 ppn.InexactFloat64(),
+		Creditable:          creditable,
+		Status:              models.TaxInvoiceStatusActive,
+		MatchStatus:         models.TaxInvoiceMatched,
+		CreatedBy:           userID,
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if exists, err := s.invoiceExists(tx, models.TaxInvoiceInput, number, flag); err != nil {
+			return err
+		} else if exists {
+			return fmt.Errorf("tax invoice %s has already been recorded", number)
+		}
+		return tx.Create(invoice).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return invoice, nil
+}
+
+// ImportInputInvoices imports PPN Masukan invoices in the e-Faktur FM layout and matches each one to a
+// purchase of the same vendor NPWP with the same PPN amount
+func (s *TaxInvoiceService) ImportInputInvoices(r io.Reader, userID uint) (*TaxInvoiceImportResult, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %v", err)
+	}
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	if firstLine := strings.SplitN(string(data), "\n", 2)[0]; strings.Count(firstLine, ";") > strings.Count(firstLine, ",") {
+		reader.Comma = ';'
+	}
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV file: %v", err)
+	}
+
+	result := &TaxInvoiceImportResult{Errors: []string{}}
+	for i, record := range records {
+		line := i + 1
+		if len(record) == 0 || strings.ToUpper(strings.TrimSpace(record[0])) != "FM" {
+			continue
+		}
+		if len(record) < 12 || strings.EqualFold(strings.TrimSpace(record[3]), "NOMOR_FAKTUR") {
+			continue // header row
+		}
+
+		invoice, err := parseFMRecord(record)
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("line %d: %v", line, err))
+			continue
+		}
+		invoice.CreatedBy = userID
+
+		err = s.db.Transaction(func(tx *gorm.DB) error {
+			exists, err := s.invoiceExists(tx, models.TaxInvoiceInput, invoice.Number, invoice.ReplacementFlag)
+			if err != nil {
+				return err
+			}
+			if exists {
+				result.Duplicates++
+				return nil
+			}
+
+			purchase, err := s.findPurchaseForInvoice(tx, invoice)
+			if err != nil {
+				return err
+			}
+			invoice.MatchStatus = models.TaxInvoiceUnmatched
+			if purchase != nil {
+				invoice.PurchaseID = &purchase.ID
+				invoice.Reference = purchase.Code
+				invoice.MatchStatus = models.TaxInvoiceMatched
+			}
+			if err := tx.Create(invoice).Error; err != nil {
+				return err
+			}
+
+			result.Imported++
+			if purchase != nil {
+				result.Matched++
+			} else {
+				result.Unmatched++
+			}
+			return nil
+		})
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("line %d: %v", line, err))
+		}
+	}
+
+	log.Printf("🧾 PPN Masukan import: %d imported, %d matched, %d duplicates", result.Imported, result.Matched, result.Duplicates)
+	return result, nil
+}
+
+// MatchInputInvoice links an imported PPN Masukan invoice to a purchase by hand
+func (s *TaxInvoiceService) MatchInputInvoice(id uint, req models.TaxInvoiceMatchRequest) (*models.TaxInvoice, error) {
+	var invoice models.TaxInvoice
+	if err := s.db.First(&invoice, id).Error; err != nil {
+		return nil, errors.New("tax invoice not found")
+	}
+	if invoice.Direction != models.TaxInvoiceInput {
+		return nil, errors.New("only PPN Masukan invoices can be matched to a purchase")
+	}
+	var purchase models.Purchase
+	if err := s.db.First(&purchase, req.PurchaseID).Error; err != nil {
+		return nil, errors.New("purchase not found")
+	}
+	if err := s.db.Model(&invoice).Updates(map[string]interface{}{
+		"purchase_id":  purchase.ID,
+		"reference":    purchase.Code,
+		"match_status": models.TaxInvoiceMatched,
+	}).Error; err != nil {
+		return nil, err
+	}
+	invoice.PurchaseID = &purchase.ID
+	invoice.Reference = purchase.Code
+	invoice.MatchStatus = models.TaxInvoiceMatched
+	return &invoice, nil
+}
+
+// ========== EXPORT ==========
+
+// ExportOutputCSV exports the PPN Keluaran invoices of a tax period in the e-Faktur FK import layout
+func (s *TaxInvoiceService) ExportOutputCSV(month, year int) ([]byte, error) {
+	invoices, err := s.loadOutputInvoices(month, year)
+	if err != nil {
+		return nil, err
+	}
+	settings := s.companySettings()
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write([]string{"FK", "KD_JENIS_TRANSAKSI", "FG_PENGGANTI", "NOMOR_FAKTUR", "MASA_PAJAK", "TAHUN_PAJAK", "TANGGAL_FAKTUR", "NPWP", "NAMA", "ALAMAT_LENGKAP", "JUMLAH_DPP", "JUMLAH_PPN", "JUMLAH_PPNBM", "ID_KETERANGAN_TAMBAHAN", "FG_UANG_MUKA", "UANG_MUKA_DPP", "UANG_MUKA_PPN", "UANG_MUKA_PPNBM", "REFERENSI", "KODE_DOKUMEN_PENDUKUNG"})
+	w.Write([]string{"LT", "NPWP", "NAMA", "JALAN", "BLOK", "NOMOR", "RT", "RW", "KECAMATAN", "KELURAHAN", "KABUPATEN", "PROPINSI", "KODE_POS", "NOMOR_TELEPON"})
+	w.Write([]string{"OF", "KODE_OBJEK", "NAMA", "HARGA_SATUAN", "JUMLAH_BARANG", "HARGA_TOTAL", "DISKON", "DPP", "PPN", "TARIF_PPNBM", "PPNBM"})
+
+	for _, invoice := range invoices {
+		w.Write([]string{
+			"FK", invoice.TransactionCode, strconv.Itoa(invoice.ReplacementFlag), invoice.Number,
+			strconv.Itoa(invoice.TaxPeriodMonth), strconv.Itoa(invoice.TaxPeriodYear), invoice.InvoiceDate.Format("02/01/2006"),
+			invoice.CounterpartyNPWP, invoice.CounterpartyName, invoice.CounterpartyAddress,
+			efakturAmount(invoice.DPP), efakturAmount(invoice.PPN), efakturAmount(invoice.PPnBM),
+			"", "0", "0", "0", "0", invoice.Reference, "",
+		})
+		w.Write([]string{"FAPR", settings.CompanyName, settings.CompanyAddress, "", "", "", "", "", "", "", "", "", "", ""})
+		for _, item := range efakturItems(invoice) {
+			w.Write([]string{
+				"OF", item.Code, item.Name, efakturAmount(item.UnitPrice), strconv.FormatFloat(item.Quantity, 'f', -1, 64),
+				efakturAmount(item.Total), efakturAmount(item.Discount), efakturAmount(item.DPP), efakturAmount(item.PPN), "0", "0",
+			})
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, err
+	}
+
+	s.markExported(invoices)
+	return buf.Bytes(), nil
+}
+
+// efakturXML is the Coretax bulk tax invoice import document
+type efakturXML struct {
+	XMLName  xml.Name            `xml:"TaxInvoiceBulk"`
+	TIN      string              `xml:"TIN"`
+	Invoices []efakturXMLInvoice `xml:"ListOfTaxInvoice>TaxInvoice"`
+}
+
+type efakturXMLInvoice struct {
+	TaxInvoiceDate string           `xml:"TaxInvoiceDate"`
+	TaxInvoiceOpt  string           `xml:"TaxInvoiceOpt"`
+	TrxCode        string           `xml:"TrxCode"`
+	AddInfo        string           `xml:"AddInfo"`
+	CustomDoc      string           `xml:"CustomDoc"`
+	RefDesc        string           `xml:"RefDesc"`
+	FacilityStamp  string           `xml:"FacilityStamp"`
+	SellerIDTKU    string           `xml:"SellerIDTKU"`
+	BuyerTin       string           `xml:"BuyerTin"`
+	BuyerDocument  string           `xml:"BuyerDocument"`
+	BuyerCountry   string           `xml:"BuyerCountry"`
+	BuyerDocNumber string           `xml:"BuyerDocumentNumber"`
+	BuyerName      string           `xml:"BuyerName"`
+	BuyerAdress    string           `xml:"BuyerAdress"`
+	BuyerEmail     string           `xml:"BuyerEmail"`
+	BuyerIDTKU     string           `xml:"BuyerIDTKU"`
+	Items          []efakturXMLItem `xml:"ListOfGoodService>GoodService"`
+}
+
+type efakturXMLItem struct {
+	Opt           string `xml:"Opt"`
+	Code          string `xml:"Code"`
+	Name          string `xml:"Name"`
+	Unit          string `xml:"Unit"`
+	Price         string `xml:"Price"`
+	Qty           string `xml:"Qty"`
+	TotalDiscount string `xml:"TotalDiscount"`
+	TaxBase       string `xml:"TaxBase"`
+	OtherTaxBase  string `xml:"OtherTaxBase"`
+	VATRate       string `xml:"VATRate"`
+	VAT           string `xml:"VAT"`
+	STLGRate      string `xml:"STLGRate"`
+	STLG          string `xml:"STLG"`
+}
+
+// ExportOutputXML exports the PPN Keluaran invoices of a tax period as a Coretax bulk import XML
+func (s *TaxInvoiceService) ExportOutputXML(month, year int) ([]byte, error) {
+	invoices, err := s.loadOutputInvoices(month, year)
+	if err != nil {
+		return nil, err
+	}
+	settings := s.companySettings()
+	sellerTIN := normalizeNPWP(settings.TaxNumber)
+
+	doc := efakturXML{TIN: sellerTIN}
+	for _, invoice := range invoices {
+		option := "Normal"
+		if invoice.ReplacementFlag == 1 {
+			option = "Replacement"
+		}
+		entry := efakturXMLInvoice{
+			TaxInvoiceDate: invoice.InvoiceDate.Format("2006-01-02"),
+			TaxInvoiceOpt:  option,
+			TrxCode:        invoice.TransactionCode,
+			RefDesc:        invoice.Reference,
+			SellerIDTKU:    sellerTIN + "000000",
+			BuyerTin:       invoice.CounterpartyNPWP,
+			BuyerDocument:  "TIN",
+			BuyerCountry:   "IDN",
+			BuyerDocNumber: "-",
+			BuyerName:      invoice.CounterpartyName,
+			BuyerAdress:    invoice.CounterpartyAddress,
+			BuyerIDTKU:     invoice.CounterpartyNPWP + "000000",
+		}
+		for _, item := range efakturItems(invoice) {
+			entry.Items = append(entry.Items, efakturXMLItem{
+				Opt:           "A",
+				Code:          "000000",
+				Name:          item.Name,
+				Unit:          "UM.0018",
+				Price:         decimal.NewFromFloat(item.UnitPrice).StringFixed(2),
+				Qty:           strconv.FormatFloat(item.Quantity, 'f', -1, 64),
+				TotalDiscount: decimal.NewFromFloat(item.Discount).StringFixed(2),
+				TaxBase:       decimal.NewFromFloat(item.DPP).StringFixed(2),
+				OtherTaxBase:  decimal.NewFromFloat(item.OtherTaxBase).StringFixed(2),
+				VATRate:       strconv.FormatFloat(item.Rate, 'f', -1, 64),
+				VAT:           decimal.NewFromFloat(item.PPN).StringFixed(2),
+				STLGRate:      "0",
+				STLG:          "0",
+			})
+		}
+		doc.Invoices = append(doc.Invoices, entry)
+	}
+
+	out, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	s.markExported(invoices)
+	return append([]byte(xml.Header), out...), nil
+}
+
+// ExportInputCSV exports the PPN Masukan invoices of a tax period in the e-Faktur FM import layout
+func (s *TaxInvoiceService) ExportInputCSV(month, year int) ([]byte, error) {
+	var invoices []models.TaxInvoice
+	if err := s.db.Where("direction = ? AND status = ? AND tax_period_month = ? AND tax_period_year = ?",
+		models.TaxInvoiceInput, models.TaxInvoiceStatusActive, month, year).
+		Order("invoice_date ASC, number ASC").Find(&invoices).Error; err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write([]string{"FM", "KD_JENIS_TRANSAKSI", "FG_PENGGANTI", "NOMOR_FAKTUR", "MASA_PAJAK", "TAHUN_PAJAK", "TANGGAL_FAKTUR", "NPWP", "NAMA", "ALAMAT_LENGKAP", "JUMLAH_DPP", "JUMLAH_PPN", "JUMLAH_PPNBM", "IS_CREDITABLE"})
+	for _, invoice := range invoices {
+		creditable := "0"
+		if invoice.Creditable {
+			creditable = "1"
+		}
+		w.Write([]string{
+			"FM", invoice.TransactionCode, strconv.Itoa(invoice.ReplacementFlag), invoice.Number,
+			strconv.Itoa(invoice.TaxPeriodMonth), strconv.Itoa(invoice.TaxPeriodYear), invoice.InvoiceDate.Format("02/01/2006"),
+			invoice.CounterpartyNPWP, invoice.CounterpartyName, invoice.CounterpartyAddress,
+			efakturAmount(invoice.DPP), efakturAmount(invoice.PPN), efakturAmount(invoice.PPnBM), creditable,
+		})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, err
+	}
+
+	s.markExported(invoices)
+	return buf.Bytes(), nil
+}
+
+// ========== SPT MASA PPN ==========
+
+// GetSPTMasaWorksheet reconciles the tax invoices of a month against the PPN Keluaran and PPN Masukan
+// accounts configured in the tax account settings
+func (s *TaxInvoiceService) GetSPTMasaWorksheet(month, year int) (*SPTMasaPPNWorksheet, error) {
+	if month < 1 || month > 12 || year < 2000 {
+		return nil, errors.New("invalid tax period")
+	}
+	settings, err := s.taxAccountService.GetSettings()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tax settings: %v", err)
+	}
+
+	start := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 1, -1)
+	company := s.companySettings()
+	worksheet := &SPTMasaPPNWorksheet{
+		Month:       month,
+		Year:        year,
+		PeriodStart: start,
+		PeriodEnd:   end,
+		CompanyName: company.CompanyName,
+		CompanyNPWP: normalizeNPWP(company.TaxNumber),
+		Warnings:    []string{},
+		GeneratedAt: time.Now(),
+	}
+
+	// PPN Keluaran
+	output := &worksheet.Output
+	if err := s.fillInvoiceTotals(output, models.TaxInvoiceOutput, month, year); err != nil {
+		return nil, err
+	}
+	if err := s.fillLedger(output, settings.SalesOutputVATAccountID, start, end, false); err != nil {
+		return nil, err
+	}
+	if output.MissingInvoices, err = s.salesWithoutInvoice(start, end); err != nil {
+		return nil, err
+	}
+	if balance, err := s.taxPaymentService.GetPPNBalance("OUTPUT"); err != nil {
+		worksheet.Warnings = append(worksheet.Warnings, err.Error())
+	} else {
+		output.AccountBalance = balance
+	}
+
+	// PPN Masukan
+	input := &worksheet.Input
+	if err := s.fillInvoiceTotals(input, models.TaxInvoiceInput, month, year); err != nil {
+		return nil, err
+	}
+	if err := s.fillLedger(input, settings.PurchaseInputVATAccountID, start, end, true); err != nil {
+		return nil, err
+	}
+	if input.MissingInvoices, err = s.purchasesWithoutInvoice(start, end); err != nil {
+		return nil, err
+	}
+	if err := s.db.Model(&models.TaxInvoice{}).
+		Where("direction = ? AND status = ? AND match_status = ? AND tax_period_month = ? AND tax_period_year = ?",
+			models.TaxInvoiceInput, models.TaxInvoiceStatusActive, models.TaxInvoiceUnmatched, month, year).
+		Count(&input.UnmatchedCount).Error; err != nil {
+		return nil, err
+	}
+	if balance, err := s.taxPaymentService.GetPPNBalance("INPUT"); err != nil {
+		worksheet.Warnings = append(worksheet.Warnings, err.Error())
+	} else {
+		input.AccountBalance = balance
+	}
+
+	worksheet.NetPPN = roundAmount(output.PPN - input.PPN)
+	switch {
+	case worksheet.NetPPN > 0:
+		worksheet.Result = "KURANG_BAYAR"
+	case worksheet.NetPPN < 0:
+		worksheet.Result = "LEBIH_BAYAR"
+	default:
+		worksheet.Result = "NIHIL"
+	}
+	if worksheet.Payments, err = s.taxPaymentService.GetPPNPaymentSummary(start, endOfDay(end)); err != nil {
+		worksheet.Warnings = append(worksheet.Warnings, err.Error())
+	}
+
+	if math.Abs(output.Difference) >= 1 {
+		worksheet.Warnings = append(worksheet.Warnings, fmt.Sprintf("PPN Keluaran in the ledger differs from the tax invoices by %.2f", output.Difference))
+	}
+	if math.Abs(input.Difference) >= 1 {
+		worksheet.Warnings = append(worksheet.Warnings, fmt.Sprintf("PPN Masukan in the ledger differs from the tax invoices by %.2f", input.Difference))
+	}
+	if len(output.MissingInvoices) > 0 {
+		worksheet.Warnings = append(worksheet.Warnings, fmt.Sprintf("%d sales with PPN have no tax invoice number", len(output.MissingInvoices)))
+	}
+	if input.UnmatchedCount > 0 {
+		worksheet.Warnings = append(worksheet.Warnings, fmt.Sprintf("%d imported PPN Masukan invoices are not matched to a purchase", input.UnmatchedCount))
+	}
+
+	return worksheet, nil
+}
+
+func (s *TaxInvoiceService) fillInvoiceTotals(section *PPNWorksheetSection, direction string, month, year int) error {
+	var totals struct {
+		Count         int64
+		DPP           float64
+		PPN           float64
+		NonCreditable float64
+	}
+	if err := s.db.Model(&models.TaxInvoice{}).
+		Select(`COUNT(*) AS count, COALESCE(SUM(dpp), 0) AS dpp,
+			COALESCE(SUM(CASE WHEN creditable THEN ppn ELSE 0 END), 0) AS ppn,
+			COALESCE(SUM(CASE WHEN creditable THEN 0 ELSE ppn END), 0) AS non_creditable`).
+		Where("direction = ? AND status = ? AND tax_period_month = ? AND tax_period_year = ?",
+			direction, models.TaxInvoiceStatusActive, month, year).
+		Scan(&totals).Error; err != nil {
+		return err
+	}
+	section.InvoiceCount = totals.Count
+	section.DPP = roundAmount(totals.DPP)
+	section.PPN = roundAmount(totals.PPN)
+	if direction == models.TaxInvoiceInput {
+		section.NonCreditablePPN = roundAmount(totals.NonCreditable)
+	}
+	return nil
+}
+
+// fillLedger sums the movement of the PPN account in the month. Remittance journals (Setor PPN) that
+// clear PPN Keluaran against PPN Masukan are left out, they settle the previous period.
+func (s *TaxInvoiceService) fillLedger(section *PPNWorksheetSection, accountID uint, start, end time.Time, debitNormal bool) error {
+	if accountID == 0 {
+		return errors.New("PPN accounts are not configured in tax settings")
+	}
+	var account models.Account
+	if err := s.db.First(&account, accountID).Error; err != nil {
+		return fmt.Errorf("PPN account not found: %v", err)
+	}
+	section.AccountID = account.ID
+	section.AccountCode = account.Code
+	section.AccountName = account.Name
+
+	var movement struct {
+		Debit  float64
+		Credit float64
+	}
+	if err := s.db.Raw(`
+		SELECT COALESCE(SUM(ujl.debit_amount), 0) AS debit, COALESCE(SUM(ujl.credit_amount), 0) AS credit
+		FROM unified_journal_lines ujl
+		JOIN unified_journal_ledger uje ON uje.id = ujl.journal_id
+		WHERE ujl.account_id = ?
+		  AND uje.status = 'POSTED'
+		  AND uje.deleted_at IS NULL
+		  AND uje.entry_date >= ? AND uje.entry_date <= ?
+		  AND NOT (uje.source_type = ? AND uje.source_id IN (
+			SELECT id FROM payments WHERE payment_type IN ?))
+	`, accountID, start, endOfDay(end), models.SSOTSourceTypePayment,
+		[]string{models.PaymentTypeTaxPPNInput, models.PaymentTypeTaxPPNOutput}).
+		Scan(&movement).Error; err != nil {
+		return err
+	}
+
+	if debitNormal {
+		section.LedgerPPN = roundAmount(movement.Debit - movement.Credit)
+	} else {
+		section.LedgerPPN = roundAmount(movement.Credit - movement.Debit)
+	}
+	section.Difference = roundAmount(section.LedgerPPN - section.PPN - section.NonCreditablePPN)
+	return nil
+}
+
+func (s *TaxInvoiceService) salesWithoutInvoice(start, end time.Time) ([]PPNDocument, error) {
+	var sales []models.Sale
+	if err := s.db.Preload("Customer").
+		Where("date >= ? AND date <= ? AND status IN ? AND (ppn_amount > 0 OR ppn > 0)", start, endOfDay(end), taxInvoiceSaleStatuses).
+		Where("NOT EXISTS (SELECT 1 FROM tax_invoices ti WHERE ti.sale_id = sales.id AND ti.direction = ? AND ti.status = ?)",
+			models.TaxInvoiceOutput, models.TaxInvoiceStatusActive).
+		Order("date ASC").Find(&sales).Error; err != nil {
+		return nil, err
+	}
+
+	documents := make([]PPNDocument, 0, len(sales))
+	for i := range sales {
+		dpp, ppn := saleTaxAmounts(&sales[i])
+		documents = append(documents, PPNDocument{
+			ID: sales[i].ID, Code: sales[i].Code, Date: sales[i].Date, Status: sales[i].Status,
+			Name: sales[i].Customer.Name, NPWP: normalizeNPWP(sales[i].Customer.TaxNumber),
+			DPP: dpp.InexactFloat64(), PPN: ppn.InexactFloat64(),
+		})
+	}
+	return documents, nil
+}
+
+func (s *TaxInvoiceService) purchasesWithoutInvoice(start, end time.Time) ([]PPNDocument, error) {
+	var purchases []models.Purchase
+	if err := s.db.Preload("Vendor").
+		Where("date >= ? AND date <= ? AND status IN ? AND ppn_amount > 0", start, endOfDay(end), taxInvoicePurchaseStatuses).
+		Where("NOT EXISTS (SELECT 1 FROM tax_invoices ti WHERE ti.purchase_id = purchases.id AND ti.direction = ? AND ti.status = ?)",
+			models.TaxInvoiceInput, models.TaxInvoiceStatusActive).
+		Order("date ASC").Find(&purchases).Error; err != nil {
+		return nil, err
+	}
+
+	documents := make([]PPNDocument, 0, len(purchases))
+	for i := range purchases {
+		dpp, ppn := purchaseTaxAmounts(&purchases[i])
+		documents = append(documents, PPNDocument{
+			ID: purchases[i].ID, Code: purchases[i].Code, Date: purchases[i].Date, Status: purchases[i].Status,
+			Name: purchases[i].Vendor.Name, NPWP: normalizeNPWP(purchases[i].Vendor.TaxNumber),
+			DPP: dpp.InexactFloat64(), PPN: ppn.InexactFloat64(),
+		})
+	}
+	return documents, nil
+}
+
+// ========== HELPERS ==========
+
+// efakturItem is one OF line (goods or service) of an output tax invoice
+type efakturItem struct {
+	Code         string
+	Name         string
+	UnitPrice    float64
+	Quantity     float64
+	Total        float64
+	Discount     float64
+	DPP          float64
+	OtherTaxBase float64
+	Rate         float64
+	PPN          float64
+}
+
+// efakturItems splits the invoice DPP and PPN over the sale items. Amounts are converted to base
+// currency and the rounding difference is put on the last item so the lines add up to the invoice.
+func efakturItems(invoice models.TaxInvoice) []efakturItem {
+	if invoice.Sale == nil || len(invoice.Sale.SaleItems) == 0 {
+		return []efakturItem{{
+			Code: "000000", Name: invoice.Reference, UnitPrice: invoice.DPP, Quantity: 1,
+			Total: invoice.DPP, DPP: invoice.DPP, OtherTaxBase: invoice.DPP, Rate: effectiveRate(invoice.DPP, invoice.PPN), PPN: invoice.PPN,
+		}}
+	}
+
+	sale := invoice.Sale
+	rate := documentExchangeRate(sale.Currency, sale.ExchangeRate)
+	var lineSum decimal.Decimal
+	for _, item := range sale.SaleItems {
+		lineSum = lineSum.Add(decimal.NewFromFloat(item.LineTotal))
+	}
+
+	items := make([]efakturItem, 0, len(sale.SaleItems))
+	dppLeft := decimal.NewFromFloat(invoice.DPP)
+	ppnLeft := decimal.NewFromFloat(invoice.PPN)
+	for i, item := range sale.SaleItems {
+		gross := convertToBaseCurrency(decimal.NewFromFloat(item.UnitPrice).Mul(decimal.NewFromInt(int64(item.Quantity))), rate)
+		dpp, ppn := dppLeft, ppnLeft
+		if i < len(sale.SaleItems)-1 && lineSum.GreaterThan(decimal.Zero) {
+			share := decimal.NewFromFloat(item.LineTotal).Div(lineSum)
+			dpp = decimal.NewFromFloat(invoice.DPP).Mul(share).Round(2)
+			ppn = decimal.NewFromFloat(invoice.PPN).Mul(share).Round(2)
+		}
+		dppLeft = dppLeft.Sub(dpp)
+		ppnLeft = ppnLeft.Sub(ppn)
+
+		discount := gross.Sub(dpp)
+		if discount.LessThan(decimal.Zero) {
+			discount = decimal.Zero
+		}
+		name := item.Description
+		if item.Product.Name != "" {
+			name = item.Product.Name
+		}
+		code := item.Product.Code
+		if code == "" {
+			code = "000000"
+		}
+		items = append(items, efakturItem{
+			Code:         code,
+			Name:         name,
+			UnitPrice:    convertToBaseCurrency(decimal.NewFromFloat(item.UnitPrice), rate).InexactFloat64(),
+			Quantity:     float64(item.Quantity),
+			Total:        gross.InexactFloat64(),
+			Discount:     discount.InexactFloat64(),
+			DPP:          dpp.InexactFloat64(),
+			OtherTaxBase: otherTaxBase(dpp, ppn).InexactFloat64(),
+			Rate:         effectiveRate(invoice.DPP, invoice.PPN),
+			PPN:          ppn.InexactFloat64(),
+		})
+	}
+	return items
+}
+
+// otherTaxBase returns the DPP Nilai Lain (11/12 of the DPP) used from the 12% PPN rate onwards
+func otherTaxBase(dpp, ppn decimal.Decimal) decimal.Decimal {
+	if dpp.IsZero() || effectiveRate(dpp.InexactFloat64(), ppn.InexactFloat64()) < 12 {
+		return dpp
+	}
+	return dpp.Mul(decimal.NewFromInt(11)).Div(decimal.NewFromInt(12)).Round(2)
+}
+
+// effectiveRate is the nominal PPN rate (whole percent) implied by the amounts
+func effectiveRate(dpp, ppn float64) float64 {
+	if dpp == 0 {
+		return 0
+	}
+	return math.Round(ppn / dpp * 100)
+}
+
+func (s *TaxInvoiceService) loadOutputInvoices(month, year int) ([]models.TaxInvoice, error) {
+	if month < 1 || month > 12 || year < 2000 {
+		return nil, errors.New("invalid tax period")
+	}
+	var invoices []models.TaxInvoice
+	if err := s.db.Preload("Sale.SaleItems.Product").
+		Where("direction = ? AND status = ? AND tax_period_month = ? AND tax_period_year = ?",
+			models.TaxInvoiceOutput, models.TaxInvoiceStatusActive, month, year).
+		Order("invoice_date ASC, number ASC").Find(&invoices).Error; err != nil {
+		return nil, err
+	}
+	return invoices, nil
+}
+
+func (s *TaxInvoiceService) markExported(invoices []models.TaxInvoice) {
+	if len(invoices) == 0 {
+		return
+	}
+	ids := make([]uint, len(invoices))
+	for i, invoice := range invoices {
+		ids[i] = invoice.ID
+	}
+	if err := s.db.Model(&models.TaxInvoice{}).Where("id IN ?", ids).Update("exported_at", time.Now()).Error; err != nil {
+		log.Printf("⚠️ Failed to mark tax invoices as exported: %v", err)
+	}
+}
+
+func (s *TaxInvoiceService) companySettings() models.Settings {
+	var settings models.Settings
+	if err := s.db.First(&settings).Error; err != nil {
+		log.Printf("⚠️ Company settings not found: %v", err)
+	}
+	return settings
+}
+
+func (s *TaxInvoiceService) invoiceExists(tx *gorm.DB, direction, number string, flag int) (bool, error) {
+	var count int64
+	if err := tx.Model(&models.TaxInvoice{}).
+		Where("direction = ? AND number = ? AND replacement_flag = ?", direction, number, flag).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// findPurchaseForInvoice looks for an unmatched purchase of the seller NPWP with the same PPN amount,
+// preferring the purchase dated closest to the invoice
+func (s *TaxInvoiceService) findPurchaseForInvoice(tx *gorm.DB, invoice *models.TaxInvoice) (*models.Purchase, error) {
+	if invoice.CounterpartyNPWP == "" || invoice.CounterpartyNPWP == emptyNPWP {
+		return nil, nil
+	}
+	var candidates []models.Purchase
+	if err := tx.Joins("JOIN contacts c ON c.id = purchases.vendor_id").
+		Where("REGEXP_REPLACE(COALESCE(c.tax_number, ''), '[^0-9]', '', 'g') = ?", invoice.CounterpartyNPWP).
+		Where("purchases.status IN ? AND purchases.ppn_amount > 0", taxInvoicePurchaseStatuses).
+		Where("NOT EXISTS (SELECT 1 FROM tax_invoices ti WHERE ti.purchase_id = purchases.id AND ti.direction = ? AND ti.status = ?)",
+			models.TaxInvoiceInput, models.TaxInvoiceStatusActive).
+		Find(&candidates).Error; err != nil {
+		return nil, err
+	}
+
+	var best *models.Purchase
+	var bestGap time.Duration
+	for i := range candidates {
+		_, ppn := purchaseTaxAmounts(&candidates[i])
+		if math.Abs(ppn.InexactFloat64()-invoice.PPN) > 1 {
+			continue
+		}
+		gap := candidates[i].Date.Sub(invoice.InvoiceDate)
+		if gap < 0 {
+			gap = -gap
+		}
+		if best == nil || gap < bestGap {
+			best, bestGap = &candidates[i], gap
+		}
+	}
+	return best, nil
+}
+
+// saleTaxAmounts returns the DPP and PPN of a sale in base currency
+func saleTaxAmounts(sale *models.Sale) (decimal.Decimal, decimal.Decimal) {
+	dpp := sale.NetBeforeTax
+	if dpp == 0 {
+		dpp = sale.TaxableAmount
+	}
+	if dpp == 0 {
+		dpp = sale.Subtotal - sale.DiscountAmount
+	}
+	ppn := sale.PPNAmount
+	if ppn == 0 {
+		ppn = sale.PPN
+	}
+	rate := documentExchangeRate(sale.Currency, sale.ExchangeRate)
+	return convertToBaseCurrency(decimal.NewFromFloat(dpp), rate), convertToBaseCurrency(decimal.NewFromFloat(ppn), rate)
+}
+
+// purchaseTaxAmounts returns the DPP and PPN of a purchase in base currency
+func purchaseTaxAmounts(purchase *models.Purchase) (decimal.Decimal, decimal.Decimal) {
+	rate := documentExchangeRate(purchase.Currency, purchase.ExchangeRate)
+	return convertToBaseCurrency(decimal.NewFromFloat(purchase.NetBeforeTax), rate),
+		convertToBaseCurrency(decimal.NewFromFloat(purchase.PPNAmount), rate)
+}
+
+// parseFMRecord converts one FM row of the e-Faktur PPN Masukan layout
+func parseFMRecord(record []string) (*models.TaxInvoice, error) {
+	field := func(i int) string {
+		if i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	number, code, flag, err := parseTaxInvoiceNumber(field(3))
+	if err != nil {
+		return nil, err
+	}
+	if field(1) != "" {
+		if code, err = normalizeTransactionCode(field(1)); err != nil {
+			return nil, err
+		}
+	}
+	if field(2) == "1" {
+		flag = 1
+	}
+	date, err := time.Parse("02/01/2006", field(6))
+	if err != nil {
+		return nil, fmt.Errorf("invalid TANGGAL_FAKTUR %q", field(6))
+	}
+	month, year := atoiOrZero(field(4)), atoiOrZero(field(5))
+	if month < 1 || month > 12 || year < 2000 {
+		month, year = int(date.Month()), date.Year()
+	}
+	dpp, err := parseEfakturAmount(field(10))
+	if err != nil {
+		return nil, fmt.Errorf("invalid JUMLAH_DPP: %v", err)
+	}
+	ppn, err := parseEfakturAmount(field(11))
+	if err != nil {
+		return nil, fmt.Errorf("invalid JUMLAH_PPN: %v", err)
+	}
+	ppnbm, _ := parseEfakturAmount(field(12))
+
+	return &models.TaxInvoice{
+		Direction:           models.TaxInvoiceInput,
+		Number:              number,
+		TransactionCode:     code,
+		ReplacementFlag:     flag,
+		InvoiceDate:         dateOnly(date),
+		TaxPeriodMonth:      month,
+		TaxPeriodYear:       year,
+		CounterpartyNPWP:    normalizeNPWP(field(7)),
+		CounterpartyName:    field(8),
+		CounterpartyAddress: field(9),
+		DPP:                 dpp,
+		PPN:                 ppn,
+		PPnBM:               ppnbm,
+		Creditable:          field(13) != "0",
+		Status:              models.TaxInvoiceStatusActive,
+	}, nil
+}
+
+// parseTaxInvoiceNumber accepts a 13 digit NSFP or a full 16 digit tax invoice number
+// (transaction code + replacement flag + NSFP), with or without punctuation
+func parseTaxInvoiceNumber(raw string) (string, string, int, error) {
+	digits := digitsOnly(raw)
+	switch len(digits) {
+	case 13:
+		return digits, "01", 0, nil
+	case 16:
+		flag := 0
+		if digits[2] == '1' {
+			flag = 1
+		}
+		return digits[3:], digits[:2], flag, nil
+	default:
+		return "", "", 0, fmt.Errorf("invalid tax invoice number %q", raw)
+	}
+}
+
+func normalizeNSFP(raw string) (string, error) {
+	digits := digitsOnly(raw)
+	if len(digits) != 13 {
+		return "", fmt.Errorf("NSFP must have 13 digits, got %q", raw)
+	}
+	return digits, nil
+}
+
+func normalizeTransactionCode(code string) (string, error) {
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return "01", nil
+	}
+	if len(code) == 1 {
+		code = "0" + code
+	}
+	if n := atoiOrZero(code); len(code) != 2 || n < 1 || n > 10 {
+		return "", fmt.Errorf("invalid transaction code %q", code)
+	}
+	return code, nil
+}
+
+// normalizeNPWP strips punctuation; buyers without NPWP are reported with zeros
+func normalizeNPWP(npwp string) string {
+	digits := digitsOnly(npwp)
+	if digits == "" {
+		return emptyNPWP
+	}
+	return digits
+}
+
+func digitsOnly(value string) string {
+	var b strings.Builder
+	for _, r := range value {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// efakturAmount formats an amount the way the e-Faktur import expects: whole rupiah, no separators
+func efakturAmount(amount float64) string {
+	return decimal.NewFromFloat(amount).Floor().String()
+}
+
+func parseEfakturAmount(value string) (float64, error) {
+	value = strings.ReplaceAll(strings.TrimSpace(value), ",", "")
+	if value == "" {
+		return 0, nil
+	}
+	amount, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, err
+	}
+	return amount, nil
+}
+
+func atoiOrZero(value string) int {
+	n, _ := strconv.Atoi(strings.TrimSpace(value))
+	return n
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
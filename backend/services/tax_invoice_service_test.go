@@ -0,0 +1,226 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"app-sistem-akuntansi/models"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestParseTaxInvoiceNumber(t *testing.T) {
+	tests := []struct {
+		raw      string
+		wantNSFP string
+		wantCode string
+		wantFlag int
+		wantErr  bool
+	}{
+		{raw: "0102400000123", wantNSFP: "0102400000123", wantCode: "01"},
+		{raw: "010.002-24.00000123", wantNSFP: "0022400000123", wantCode: "01"},
+		{raw: "070.000-24.00000123", wantNSFP: "0002400000123", wantCode: "07"},
+		{raw: "011.002-24.00000123", wantNSFP: "0022400000123", wantCode: "01", wantFlag: 1},
+		{raw: "12345", wantErr: true},
+		{raw: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			nsfp, code, flag, err := parseTaxInvoiceNumber(tt.raw)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantNSFP, nsfp)
+			assert.Equal(t, tt.wantCode, code)
+			assert.Equal(t, tt.wantFlag, flag)
+		})
+	}
+}
+
+func TestNormalizeTransactionCode(t *testing.T) {
+	tests := []struct {
+		code    string
+		want    string
+		wantErr bool
+	}{
+		{code: "", want: "01"},
+		{code: "1", want: "01"},
+		{code: " 07 ", want: "07"},
+		{code: "10", want: "10"},
+		{code: "00", wantErr: true},
+		{code: "11", wantErr: true},
+		{code: "AB", wantErr: true},
+		{code: "010", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			got, err := normalizeTransactionCode(tt.code)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestEfakturAmounts(t *testing.T) {
+	assert.Equal(t, "1250000", efakturAmount(1250000.99), "whole rupiah, rounded down")
+	assert.Equal(t, "0", efakturAmount(0))
+
+	amount, err := parseEfakturAmount(" 1,250,000.50 ")
+	require.NoError(t, err)
+	assert.InDelta(t, 1250000.5, amount, 0.001)
+	amount, err = parseEfakturAmount("")
+	require.NoError(t, err)
+	assert.Zero(t, amount)
+	_, err = parseEfakturAmount("1.250.000")
+	assert.Error(t, err)
+
+	assert.Equal(t, normalizeNPWP(""), emptyNPWP)
+	assert.Equal(t, "012345678901000", normalizeNPWP("01.234.567.8-901.000"))
+
+	tests := []struct {
+		name string
+		dpp  float64
+		ppn  float64
+		want float64
+	}{
+		{name: "11% uses the DPP", dpp: 1000000, ppn: 110000, want: 1000000},
+		{name: "12% uses 11/12 of the DPP", dpp: 1200000, ppn: 144000, want: 1100000},
+		{name: "zero DPP", dpp: 0, ppn: 0, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := otherTaxBase(decimal.NewFromFloat(tt.dpp), decimal.NewFromFloat(tt.ppn))
+			assert.InDelta(t, tt.want, got.InexactFloat64(), 0.001)
+		})
+	}
+}
+
+func TestParseFMRecord(t *testing.T) {
+	record := []string{"FM", "01", "0", "0102400000123", "3", "2024", "15/03/2024",
+		"01.234.567.8-901.000", "PT Pemasok", "Jl. Industri 1", "1,000,000", "110000", "0", "1"}
+
+	invoice, err := parseFMRecord(record)
+	require.NoError(t, err)
+	assert.Equal(t, models.TaxInvoiceInput, invoice.Direction)
+	assert.Equal(t, "0102400000123", invoice.Number)
+	assert.Equal(t, "01", invoice.TransactionCode)
+	assert.Equal(t, 0, invoice.ReplacementFlag)
+	assert.Equal(t, 3, invoice.TaxPeriodMonth)
+	assert.Equal(t, 2024, invoice.TaxPeriodYear)
+	assert.Equal(t, "012345678901000", invoice.CounterpartyNPWP)
+	assert.InDelta(t, 1000000, invoice.DPP, 0.001)
+	assert.InDelta(t, 110000, invoice.PPN, 0.001)
+	assert.True(t, invoice.Creditable)
+
+	// Replacement invoice without a tax period falls back to the invoice month
+	record[2], record[4], record[5], record[13] = "1", "", "", "0"
+	invoice, err = parseFMRecord(record)
+	require.NoError(t, err)
+	assert.Equal(t, 1, invoice.ReplacementFlag)
+	assert.Equal(t, 3, invoice.TaxPeriodMonth)
+	assert.False(t, invoice.Creditable)
+
+	record[6] = "2024-03-15"
+	_, err = parseFMRecord(record)
+	assert.ErrorContains(t, err, "invalid TANGGAL_FAKTUR")
+}
+
+func TestEfakturItemsAddUpToInvoice(t *testing.T) {
+	invoice := models.TaxInvoice{
+		Reference: "INV/2024/0001",
+		DPP:       1000000,
+		PPN:       110000,
+		Sale: &models.Sale{SaleItems: []models.SaleItem{
+			{Description: "Jasa instalasi", Quantity: 1, UnitPrice: 333333.33, LineTotal: 333333.33},
+			{Quantity: 3, UnitPrice: 111111.11, LineTotal: 333333.33, Product: models.Product{Code: "PRD-001", Name: "Kabel"}},
+			{Quantity: 1, UnitPrice: 333333.34, LineTotal: 333333.34},
+		}},
+	}
+
+	items := efakturItems(invoice)
+	require.Len(t, items, 3)
+	dpp, ppn := decimal.Zero, decimal.Zero
+	for _, item := range items {
+		dpp = dpp.Add(decimal.NewFromFloat(item.DPP))
+		ppn = ppn.Add(decimal.NewFromFloat(item.PPN))
+		assert.Equal(t, float64(11), item.Rate)
+	}
+	assert.Equal(t, "1000000", dpp.String(), "rounding lands on the last item")
+	assert.Equal(t, "110000", ppn.String())
+	assert.Equal(t, "000000", items[0].Code)
+	assert.Equal(t, "Jasa instalasi", items[0].Name)
+	assert.Equal(t, "PRD-001", items[1].Code)
+	assert.Equal(t, "Kabel", items[1].Name)
+
+	// Without sale items the invoice is reported as a single line
+	invoice.Sale = nil
+	items = efakturItems(invoice)
+	require.Len(t, items, 1)
+	assert.Equal(t, "INV/2024/0001", items[0].Name)
+	assert.InDelta(t, 110000, items[0].PPN, 0.001)
+}
+
+func TestAllocateSaleInvoiceNumbering(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1) // Every connection to :memory: is a separate database
+	require.NoError(t, db.AutoMigrate(&models.Contact{}, &models.Sale{}, &models.TaxInvoiceNumberRange{}, &models.TaxInvoice{}))
+	service := &TaxInvoiceService{db: db}
+
+	customer := models.Contact{Code: "CUST-001", Name: "PT Pelanggan", Type: "CUSTOMER", TaxNumber: "01.234.567.8-901.000", Address: "Jl. Sudirman 1"}
+	require.NoError(t, db.Create(&customer).Error)
+	newSale := func(code string) models.Sale {
+		sale := models.Sale{Code: code, CustomerID: customer.ID, UserID: 1, Date: time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC),
+			Status: models.SaleStatusInvoiced, NetBeforeTax: 1000000, PPNAmount: 110000}
+		require.NoError(t, db.Create(&sale).Error)
+		return sale
+	}
+
+	_, err = service.CreateNumberRange(models.TaxInvoiceRangeRequest{StartNumber: "010.24.00000010", EndNumber: "010.25.00000011"}, 1)
+	assert.ErrorContains(t, err, "same branch and year prefix")
+	numberRange, err := service.CreateNumberRange(models.TaxInvoiceRangeRequest{StartNumber: "010.24.00000010", EndNumber: "010.24.00000011"}, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 2024, numberRange.Year)
+	assert.Equal(t, int64(2), numberRange.RemainingNumber)
+	_, err = service.CreateNumberRange(models.TaxInvoiceRangeRequest{StartNumber: "010.24.00000011", EndNumber: "010.24.00000020"}, 1)
+	assert.ErrorContains(t, err, "overlaps")
+
+	first := newSale("SO-0001")
+	invoice, err := service.AllocateSaleInvoice(first.ID, models.TaxInvoiceAllocateRequest{}, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "0102400000010", invoice.Number)
+	assert.Equal(t, "012345678901000", invoice.CounterpartyNPWP)
+	assert.Equal(t, "Jl. Sudirman 1", invoice.CounterpartyAddress)
+	assert.InDelta(t, 110000, invoice.PPN, 0.001)
+	_, err = service.AllocateSaleInvoice(first.ID, models.TaxInvoiceAllocateRequest{}, 1)
+	assert.EqualError(t, err, "sale already has an active tax invoice")
+
+	// A cancelled number is not reused
+	_, err = service.CancelTaxInvoice(invoice.ID)
+	require.NoError(t, err)
+	invoice, err = service.AllocateSaleInvoice(first.ID, models.TaxInvoiceAllocateRequest{TransactionCode: "4"}, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "0102400000011", invoice.Number)
+	assert.Equal(t, "04", invoice.TransactionCode)
+
+	require.NoError(t, db.First(numberRange, numberRange.ID).Error)
+	assert.Equal(t, models.TaxInvoiceRangeExhausted, numberRange.Status)
+	second := newSale("SO-0002")
+	_, err = service.AllocateSaleInvoice(second.ID, models.TaxInvoiceAllocateRequest{}, 1)
+	assert.EqualError(t, err, "no tax invoice numbers left for 2024, register a new NSFP range")
+}
@@ -0,0 +1,742 @@
+package services
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"app-sistem-akuntansi/config"
+	"app-sistem-akuntansi/models"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// TwoFactorService - Autentikasi dua faktor TOTP (RFC 6238), kode pemulihan, perangkat tepercaya dan step-up
+type TwoFactorService struct {
+	db              *gorm.DB
+	securityService *SecurityService
+	issuer          string
+	requiredRoles   map[string]bool
+	encryptionKey   []byte
+	trustDuration   time.Duration
+	stepUpValidity  time.Duration
+}
+
+func NewTwoFactorService(db *gorm.DB) *TwoFactorService {
+	cfg := config.LoadConfig()
+	key := sha256.Sum256([]byte(cfg.TwoFactorSecretKey))
+
+	requiredRoles := make(map[string]bool, len(cfg.TwoFactorRequiredRoles))
+	for _, role := range cfg.TwoFactorRequiredRoles {
+		requiredRoles[strings.ToLower(role)] = true
+	}
+
+	return &TwoFactorService{
+		db:              db,
+		securityService: NewSecurityService(db),
+		issuer:          cfg.TwoFactorIssuer,
+		requiredRoles:   requiredRoles,
+		encryptionKey:   key[:],
+		trustDuration:   cfg.TwoFactorTrustDuration,
+		stepUpValidity:  cfg.StepUpValidity,
+	}
+}
+
+// TwoFactorClientInfo - Data request yang ikut dicatat pada insiden keamanan
+type TwoFactorClientInfo struct {
+	IPAddress string
+	UserAgent string
+	Method    string
+	Path      string
+	SessionID string
+}
+
+const (
+	totpDigits             = 6
+	totpPeriod             = 30
+	totpSkew               = 1 // Accept one step before and after to absorb clock drift
+	twoFactorMaxFailures   = 5
+	twoFactorLockout       = 15 * time.Minute
+	twoFactorChallengeTTL  = 5 * time.Minute
+	twoFactorEnrollmentTTL = 15 * time.Minute // Time to install an authenticator app and scan the QR code
+	recoveryCodeCount      = 10
+)
+
+var (
+	ErrInvalidTwoFactorCode    = errors.New("invalid two-factor code")
+	ErrTwoFactorLocked         = errors.New("too many invalid codes, two-factor verification is temporarily locked")
+	ErrTwoFactorNotEnabled     = errors.New("two-factor authentication is not enabled")
+	ErrTwoFactorSetupRequired  = errors.New("two-factor authentication is mandatory for your role, enrol before performing this action")
+	ErrStepUpRequired          = errors.New("confirm this action with your two-factor code")
+	ErrInvalidTwoFactorSession = errors.New("login challenge is invalid or has expired, please sign in again")
+)
+
+// ========== STATUS ==========
+
+// IsRequired - Apakah 2FA wajib untuk role tersebut
+func (s *TwoFactorService) IsRequired(role string) bool {
+	return s.requiredRoles[strings.ToLower(role)]
+}
+
+// IsEnabled - Apakah user sudah menyelesaikan pendaftaran 2FA
+func (s *TwoFactorService) IsEnabled(userID uint) (bool, error) {
+	var count int64
+	err := s.db.Model(&models.UserTwoFactor{}).Where("user_id = ? AND is_enabled = ?", userID, true).Count(&count).Error
+	return count > 0, err
+}
+
+// GetStatus - Ringkasan 2FA user beserta status step-up sesi saat ini
+func (s *TwoFactorService) GetStatus(user models.User, sessionID string) (*models.TwoFactorStatus, error) {
+	status := &models.TwoFactorStatus{
+		Required:           s.IsRequired(user.Role),
+		StepUpValidSeconds: int64(s.stepUpValidity.Seconds()),
+	}
+
+	var record models.UserTwoFactor
+	err := s.db.Where("user_id = ?", user.ID).First(&record).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if err == nil && record.IsEnabled {
+		status.Enabled = true
+		status.EnabledAt = record.EnabledAt
+		status.LockedUntil = record.LockedUntil
+	}
+
+	s.db.Model(&models.TwoFactorRecoveryCode{}).Where("user_id = ? AND used_at IS NULL", user.ID).Count(&status.RecoveryCodesLeft)
+	s.db.Model(&models.TrustedDevice{}).Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", user.ID, time.Now()).Count(&status.TrustedDevices)
+
+	if sessionID != "" {
+		var session models.UserSession
+		if err := s.db.Select("id, two_factor_verified_at").Where("session_id = ?", sessionID).First(&session).Error; err == nil {
+			status.StepUpVerifiedAt = session.TwoFactorVerifiedAt
+		}
+	}
+	return status, nil
+}
+
+// ========== ENROLMENT ==========
+
+// BeginEnrollment - Membuat secret baru dan URL otpauth untuk QR code; belum aktif sampai dikonfirmasi
+func (s *TwoFactorService) BeginEnrollment(user models.User) (*models.TwoFactorEnrollment, error) {
+	var record models.UserTwoFactor
+	err := s.db.Where("user_id = ?", user.ID).First(&record).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if err == nil && record.IsEnabled {
+		return nil, errors.New("two-factor authentication is already enabled, disable it first to enrol a new authenticator")
+	}
+
+	raw := make([]byte, 20)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, err
+	}
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(raw)
+	encrypted, err := s.encryptSecret(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	record.UserID = user.ID
+	record.SecretEncrypted = encrypted
+	record.IsEnabled = false
+	record.EnabledAt = nil
+	record.LastUsedStep = 0
+	record.FailedAttempts = 0
+	record.LockedUntil = nil
+	if err := s.db.Omit(clause.Associations).Save(&record).Error; err != nil {
+		return nil, fmt.Errorf("failed to save two-factor secret: %v", err)
+	}
+
+	account := firstNonEmpty(user.Email, user.Username)
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", s.issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprintf("%d", totpDigits))
+	query.Set("period", fmt.Sprintf("%d", totpPeriod))
+
+	return &models.TwoFactorEnrollment{
+		Secret:     secret,
+		OTPAuthURL: "otpauth://totp/" + url.PathEscape(s.issuer+":"+account) + "?" + query.Encode(),
+		Issuer:     s.issuer,
+		Account:    account,
+		Digits:     totpDigits,
+		Period:     totpPeriod,
+		Algorithm:  "SHA1",
+	}, nil
+}
+
+// ConfirmEnrollment - Mengaktifkan 2FA setelah kode pertama benar dan mengembalikan kode pemulihan
+func (s *TwoFactorService) ConfirmEnrollment(user models.User, code string, info TwoFactorClientInfo) ([]string, error) {
+	var record models.UserTwoFactor
+	if err := s.db.Where("user_id = ?", user.ID).First(&record).Error; err != nil {
+		return nil, errors.New("start two-factor enrolment first")
+	}
+	if record.IsEnabled {
+		return nil, errors.New("two-factor authentication is already enabled")
+	}
+
+	secret, err := s.decryptSecret(record.SecretEncrypted)
+	if err != nil {
+		return nil, err
+	}
+	step, ok := matchTOTP(secret, normalizeTwoFactorCode(code), time.Now(), 0)
+	if !ok {
+		return nil, ErrInvalidTwoFactorCode
+	}
+
+	var codes []string
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := tx.Model(&record).Updates(map[string]interface{}{
+			"is_enabled":      true,
+			"enabled_at":      now,
+			"last_used_step":  step,
+			"failed_attempts": 0,
+			"locked_until":    nil,
+		}).Error; err != nil {
+			return err
+		}
+		var err error
+		codes, err = s.replaceRecoveryCodes(tx, user.ID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.markSessionVerified(info.SessionID)
+	s.logIncident(models.IncidentTypeTwoFactorEnrolled, models.SeverityLow, user.ID,
+		fmt.Sprintf("User %s enabled two-factor authentication", user.Username), info)
+	log.Printf("🔐 Two-factor authentication enabled for user %d", user.ID)
+	return codes, nil
+}
+
+// Disable - User mematikan 2FA sendiri; tidak boleh untuk role yang mewajibkannya
+func (s *TwoFactorService) Disable(user models.User, code string, info TwoFactorClientInfo) error {
+	if s.IsRequired(user.Role) {
+		return fmt.Errorf("two-factor authentication is mandatory for role %s, ask an administrator to reset it instead", user.Role)
+	}
+	if err := s.VerifyCode(user.ID, code, info); err != nil {
+		return err
+	}
+	if err := s.removeTwoFactor(user.ID); err != nil {
+		return err
+	}
+
+	s.logIncident(models.IncidentTypeTwoFactorDisabled, models.SeverityMedium, user.ID,
+		fmt.Sprintf("User %s disabled two-factor authentication", user.Username), info)
+	return nil
+}
+
+// AdminReset - Administrator menghapus 2FA user lain (mis. authenticator hilang); user harus mendaftar ulang
+func (s *TwoFactorService) AdminReset(targetUserID, adminID uint, reason string, info TwoFactorClientInfo) error {
+	var target models.User
+	if err := s.db.First(&target, targetUserID).Error; err != nil {
+		return errors.New("user not found")
+	}
+	if err := s.removeTwoFactor(target.ID); err != nil {
+		return err
+	}
+
+	s.logIncident(models.IncidentTypeTwoFactorReset, models.SeverityHigh, target.ID,
+		fmt.Sprintf("Two-factor authentication of %s reset by user %d: %s", target.Username, adminID, reason), info)
+	log.Printf("🔓 Two-factor authentication of user %d reset by user %d", target.ID, adminID)
+	return nil
+}
+
+// RegenerateRecoveryCodes - Mengganti semua kode pemulihan; kode lama tidak berlaku lagi
+func (s *TwoFactorService) RegenerateRecoveryCodes(user models.User, code string, info TwoFactorClientInfo) ([]string, error) {
+	if err := s.VerifyCode(user.ID, code, info); err != nil {
+		return nil, err
+	}
+	var codes []string
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		codes, err = s.replaceRecoveryCodes(tx, user.ID)
+		return err
+	})
+	return codes, err
+}
+
+// ========== VERIFICATION ==========
+
+// VerifyCode - Memeriksa kode TOTP atau kode pemulihan, dengan perlindungan replay dan penguncian
+func (s *TwoFactorService) VerifyCode(userID uint, code string, info TwoFactorClientInfo) error {
+	var record models.UserTwoFactor
+	if err := s.db.Where("user_id = ? AND is_enabled = ?", userID, true).First(&record).Error; err != nil {
+		return ErrTwoFactorNotEnabled
+	}
+	if record.LockedUntil != nil && record.LockedUntil.After(time.Now()) {
+		return ErrTwoFactorLocked
+	}
+
+	code = normalizeTwoFactorCode(code)
+	if len(code) == totpDigits && isDigits(code) {
+		secret, err := s.decryptSecret(record.SecretEncrypted)
+		if err != nil {
+			return err
+		}
+		if step, ok := matchTOTP(secret, code, time.Now(), record.LastUsedStep); ok {
+			// Conditional update so the same code cannot be accepted twice by concurrent requests
+			result := s.db.Model(&models.UserTwoFactor{}).
+				Where("id = ? AND last_used_step < ?", record.ID, step).
+				Updates(map[string]interface{}{"last_used_step": step, "failed_attempts": 0, "locked_until": nil})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 1 {
+				return nil
+			}
+		}
+	} else if s.useRecoveryCode(userID, code) {
+		s.db.Model(&models.UserTwoFactor{}).Where("id = ?", record.ID).Updates(map[string]interface{}{"failed_attempts": 0, "locked_until": nil})
+		var left int64
+		s.db.Model(&models.TwoFactorRecoveryCode{}).Where("user_id = ? AND used_at IS NULL", userID).Count(&left)
+		s.logIncident(models.IncidentTypeTwoFactorRecoveryUsed, models.SeverityMedium, userID,
+			fmt.Sprintf("Recovery code used, %d left", left), info)
+		return nil
+	}
+
+	return s.registerFailure(record, info)
+}
+
+// CreateLoginChallenge - Tiket login sementara setelah password benar, ditukar dengan kode 2FA
+func (s *TwoFactorService) CreateLoginChallenge(userID uint, deviceInfo string, info TwoFactorClientInfo) (string, time.Time, error) {
+	return s.createChallenge(userID, models.TwoFactorChallengeLogin, twoFactorChallengeTTL, deviceInfo, info)
+}
+
+// CompleteLoginChallenge - Memverifikasi kode untuk tiket login; mengembalikan user dan info perangkat
+func (s *TwoFactorService) CompleteLoginChallenge(token, code string, info TwoFactorClientInfo) (*models.User, string, error) {
+	challenge, err := s.openChallenge(token, models.TwoFactorChallengeLogin)
+	if err != nil {
+		return nil, "", err
+	}
+	s.db.Model(challenge).Update("attempts", gorm.Expr("attempts + 1"))
+
+	if err := s.VerifyCode(challenge.UserID, code, info); err != nil {
+		return nil, "", err
+	}
+
+	return s.consumeChallenge(challenge)
+}
+
+// CreateEnrollmentChallenge - Tiket pendaftaran 2FA untuk user yang wajib 2FA tapi belum mendaftar;
+// tiket ini hanya bisa dipakai untuk mendaftarkan authenticator, bukan untuk mengakses API
+func (s *TwoFactorService) CreateEnrollmentChallenge(userID uint, deviceInfo string, info TwoFactorClientInfo) (string, time.Time, error) {
+	return s.createChallenge(userID, models.TwoFactorChallengeEnrollment, twoFactorEnrollmentTTL, deviceInfo, info)
+}
+
+// BeginChallengeEnrollment - Membuat secret 2FA untuk pemilik tiket pendaftaran
+func (s *TwoFactorService) BeginChallengeEnrollment(token string) (*models.TwoFactorEnrollment, error) {
+	challenge, err := s.openChallenge(token, models.TwoFactorChallengeEnrollment)
+	if err != nil {
+		return nil, err
+	}
+
+	var user models.User
+	if err := s.db.First(&user, challenge.UserID).Error; err != nil || !user.IsActive {
+		return nil, errors.New("user account is disabled")
+	}
+	return s.BeginEnrollment(user)
+}
+
+// CompleteEnrollmentChallenge - Mengaktifkan 2FA dengan kode pertama lalu menyelesaikan login;
+// mengembalikan user, info perangkat dan kode pemulihan
+func (s *TwoFactorService) CompleteEnrollmentChallenge(token, code string, info TwoFactorClientInfo) (*models.User, string, []string, error) {
+	challenge, err := s.openChallenge(token, models.TwoFactorChallengeEnrollment)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	s.db.Model(challenge).Update("attempts", gorm.Expr("attempts + 1"))
+
+	var user models.User
+	if err := s.db.First(&user, challenge.UserID).Error; err != nil || !user.IsActive {
+		return nil, "", nil, errors.New("user account is disabled")
+	}
+	codes, err := s.ConfirmEnrollment(user, code, info)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	consumedUser, deviceInfo, err := s.consumeChallenge(challenge)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	return consumedUser, deviceInfo, codes, nil
+}
+
+func (s *TwoFactorService) createChallenge(userID uint, purpose string, ttl time.Duration, deviceInfo string, info TwoFactorClientInfo) (string, time.Time, error) {
+	token, err := randomToken(32)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	challenge := models.TwoFactorChallenge{
+		TokenHash:  hashToken(token),
+		UserID:     userID,
+		Purpose:    purpose,
+		DeviceInfo: deviceInfo,
+		IPAddress:  info.IPAddress,
+		UserAgent:  info.UserAgent,
+		ExpiresAt:  time.Now().Add(ttl),
+	}
+	if err := s.db.Create(&challenge).Error; err != nil {
+		return "", time.Time{}, err
+	}
+	return token, challenge.ExpiresAt, nil
+}
+
+// openChallenge loads an unconsumed, unexpired challenge of the given purpose that has attempts left
+func (s *TwoFactorService) openChallenge(token, purpose string) (*models.TwoFactorChallenge, error) {
+	var challenge models.TwoFactorChallenge
+	if err := s.db.Where("token_hash = ? AND purpose = ? AND consumed_at IS NULL AND expires_at > ?", hashToken(token), purpose, time.Now()).
+		First(&challenge).Error; err != nil {
+		return nil, ErrInvalidTwoFactorSession
+	}
+	if challenge.Attempts >= twoFactorMaxFailures {
+		return nil, ErrInvalidTwoFactorSession
+	}
+	return &challenge, nil
+}
+
+// consumeChallenge marks the challenge as used exactly once and returns its (still active) user
+func (s *TwoFactorService) consumeChallenge(challenge *models.TwoFactorChallenge) (*models.User, string, error) {
+	result := s.db.Model(&models.TwoFactorChallenge{}).Where("id = ? AND consumed_at IS NULL", challenge.ID).Update("consumed_at", time.Now())
+	if result.Error != nil || result.RowsAffected == 0 {
+		return nil, "", ErrInvalidTwoFactorSession
+	}
+
+	var user models.User
+	if err := s.db.First(&user, challenge.UserID).Error; err != nil || !user.IsActive {
+		return nil, "", errors.New("user account is disabled")
+	}
+	return &user, challenge.DeviceInfo, nil
+}
+
+// ========== STEP-UP ==========
+
+// StepUp - Konfirmasi ulang dengan kode 2FA sebelum aksi berisiko tinggi; berlaku untuk sesi saat ini
+func (s *TwoFactorService) StepUp(userID uint, code string, info TwoFactorClientInfo) (time.Time, error) {
+	if info.SessionID == "" {
+		return time.Time{}, errors.New("no active session")
+	}
+	if err := s.VerifyCode(userID, code, info); err != nil {
+		return time.Time{}, err
+	}
+	s.markSessionVerified(info.SessionID)
+	return time.Now().Add(s.stepUpValidity), nil
+}
+
+// CheckStepUp - Nil jika sesi boleh menjalankan aksi berisiko tinggi
+func (s *TwoFactorService) CheckStepUp(userID uint, role, sessionID string) error {
+	enabled, err := s.IsEnabled(userID)
+	if err != nil {
+		return err
+	}
+	if !enabled {
+		if s.IsRequired(role) {
+			return ErrTwoFactorSetupRequired
+		}
+		// 2FA is optional for this role and the user has not opted in
+		return nil
+	}
+
+	var session models.UserSession
+	if err := s.db.Select("id, two_factor_verified_at").Where("session_id = ?", sessionID).First(&session).Error; err != nil {
+		return ErrStepUpRequired
+	}
+	if session.TwoFactorVerifiedAt == nil || time.Since(*session.TwoFactorVerifiedAt) > s.stepUpValidity {
+		return ErrStepUpRequired
+	}
+	return nil
+}
+
+// MarkSessionVerified - Mencatat bahwa sesi baru saja lolos verifikasi 2FA
+func (s *TwoFactorService) MarkSessionVerified(sessionID string) {
+	s.markSessionVerified(sessionID)
+}
+
+// ========== TRUSTED DEVICES ==========
+
+// TrustDevice - Menandai browser sebagai tepercaya; token dikirim kembali saat login berikutnya
+func (s *TwoFactorService) TrustDevice(userID uint, sessionID, deviceInfo string, info TwoFactorClientInfo) (string, time.Time, error) {
+	token, err := randomToken(32)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	device := models.TrustedDevice{
+		UserID:        userID,
+		TokenHash:     hashToken(token),
+		SessionID:     sessionID,
+		LastSessionID: sessionID,
+		DeviceInfo:    deviceInfo,
+		IPAddress:     info.IPAddress,
+		UserAgent:     info.UserAgent,
+		ExpiresAt:     time.Now().Add(s.trustDuration),
+	}
+	if err := s.db.Create(&device).Error; err != nil {
+		return "", time.Time{}, err
+	}
+	return token, device.ExpiresAt, nil
+}
+
+// FindTrustedDevice - Perangkat tepercaya yang masih berlaku untuk token tersebut, nil jika tidak ada
+func (s *TwoFactorService) FindTrustedDevice(userID uint, token string) *models.TrustedDevice {
+	if token == "" {
+		return nil
+	}
+	var device models.TrustedDevice
+	if err := s.db.Where("user_id = ? AND token_hash = ? AND revoked_at IS NULL AND expires_at > ?", userID, hashToken(token), time.Now()).
+		First(&device).Error; err != nil {
+		return nil
+	}
+	return &device
+}
+
+// TouchTrustedDevice - Mencatat sesi baru yang dibuka dengan perangkat tepercaya
+func (s *TwoFactorService) TouchTrustedDevice(device *models.TrustedDevice, sessionID string) {
+	s.db.Model(device).Updates(map[string]interface{}{
+		"last_used_at":    time.Now(),
+		"last_session_id": sessionID,
+	})
+}
+
+// ListTrustedDevices - Perangkat tepercaya user yang masih berlaku
+func (s *TwoFactorService) ListTrustedDevices(userID uint) ([]models.TrustedDevice, error) {
+	var devices []models.TrustedDevice
+	err := s.db.Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("created_at DESC").Find(&devices).Error
+	return devices, err
+}
+
+// RevokeTrustedDevice - Mencabut perangkat tepercaya dan mengakhiri sesi yang dibuka dengannya
+func (s *TwoFactorService) RevokeTrustedDevice(userID, deviceID uint) error {
+	var device models.TrustedDevice
+	if err := s.db.Where("id = ? AND user_id = ? AND revoked_at IS NULL", deviceID, userID).First(&device).Error; err != nil {
+		return errors.New("trusted device not found")
+	}
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		return s.revokeDevices(tx, tx.Where("id = ?", device.ID))
+	})
+}
+
+// ========== HELPERS ==========
+
+func (s *TwoFactorService) registerFailure(record models.UserTwoFactor, info TwoFactorClientInfo) error {
+	failures := record.FailedAttempts + 1
+	updates := map[string]interface{}{"failed_attempts": failures}
+	locked := failures >= twoFactorMaxFailures
+	if locked {
+		updates["failed_attempts"] = 0
+		updates["locked_until"] = time.Now().Add(twoFactorLockout)
+	}
+	s.db.Model(&models.UserTwoFactor{}).Where("id = ?", record.ID).Updates(updates)
+
+	s.logIncident(models.IncidentTypeTwoFactorFailed, models.SeverityMedium, record.UserID,
+		fmt.Sprintf("Invalid two-factor code (%d/%d)", failures, twoFactorMaxFailures), info)
+	if locked {
+		s.logIncident(models.IncidentTypeTwoFactorLocked, models.SeverityHigh, record.UserID,
+			fmt.Sprintf("Two-factor verification locked for %s after %d invalid codes", twoFactorLockout, twoFactorMaxFailures), info)
+		return ErrTwoFactorLocked
+	}
+	return ErrInvalidTwoFactorCode
+}
+
+func (s *TwoFactorService) useRecoveryCode(userID uint, code string) bool {
+	if code == "" {
+		return false
+	}
+	var codes []models.TwoFactorRecoveryCode
+	s.db.Where("user_id = ? AND used_at IS NULL", userID).Find(&codes)
+	for _, recovery := range codes {
+		if bcrypt.CompareHashAndPassword([]byte(recovery.CodeHash), []byte(code)) != nil {
+			continue
+		}
+		result := s.db.Model(&models.TwoFactorRecoveryCode{}).Where("id = ? AND used_at IS NULL", recovery.ID).Update("used_at", time.Now())
+		return result.Error == nil && result.RowsAffected == 1
+	}
+	return false
+}
+
+// replaceRecoveryCodes deletes the user's recovery codes and returns a fresh set in plain text;
+// only hashes are stored
+func (s *TwoFactorService) replaceRecoveryCodes(tx *gorm.DB, userID uint) ([]string, error) {
+	if err := tx.Where("user_id = ?", userID).Delete(&models.TwoFactorRecoveryCode{}).Error; err != nil {
+		return nil, err
+	}
+	codes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		raw := make([]byte, 7)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, err
+		}
+		encoded := strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(raw))[:10]
+		hash, err := bcrypt.GenerateFromPassword([]byte(encoded), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, err
+		}
+		if err := tx.Create(&models.TwoFactorRecoveryCode{UserID: userID, CodeHash: string(hash)}).Error; err != nil {
+			return nil, err
+		}
+		codes = append(codes, encoded[:5]+"-"+encoded[5:])
+	}
+	return codes, nil
+}
+
+func (s *TwoFactorService) removeTwoFactor(userID uint) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&models.UserTwoFactor{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&models.TwoFactorRecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return s.revokeDevices(tx, tx.Where("user_id = ? AND revoked_at IS NULL", userID))
+	})
+}
+
+// revokeDevices revokes the selected trusted devices and deactivates the sessions opened with them
+func (s *TwoFactorService) revokeDevices(tx *gorm.DB, scope *gorm.DB) error {
+	var devices []models.TrustedDevice
+	if err := scope.Find(&devices).Error; err != nil {
+		return err
+	}
+	now := time.Now()
+	for _, device := range devices {
+		if err := tx.Model(&models.TrustedDevice{}).Where("id = ?", device.ID).Update("revoked_at", now).Error; err != nil {
+			return err
+		}
+		sessionIDs := []string{device.SessionID, device.LastSessionID}
+		if err := tx.Model(&models.UserSession{}).Where("session_id IN ?", sessionIDs).Update("is_active", false).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *TwoFactorService) markSessionVerified(sessionID string) {
+	if sessionID == "" {
+		return
+	}
+	s.db.Model(&models.UserSession{}).Where("session_id = ?", sessionID).Update("two_factor_verified_at", time.Now())
+}
+
+func (s *TwoFactorService) logIncident(incidentType, severity string, userID uint, description string, info TwoFactorClientInfo) {
+	s.securityService.LogSecurityIncident(incidentType, severity, description,
+		info.IPAddress, info.UserAgent, info.Method, info.Path, "", &userID, info.SessionID)
+}
+
+func (s *TwoFactorService) encryptSecret(secret string) (string, error) {
+	block, err := aes.NewCipher(s.encryptionKey)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(gcm.Seal(nonce, nonce, []byte(secret), nil)), nil
+}
+
+// decryptSecret returns the raw TOTP key
+func (s *TwoFactorService) decryptSecret(encrypted string) ([]byte, error) {
+	data, err := base64.StdEncoding.DecodeString(encrypted)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(s.encryptionKey)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < gcm.NonceSize() {
+		return nil, errors.New("stored two-factor secret is corrupt")
+	}
+	plain, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+	if err != nil {
+		return nil, errors.New("stored two-factor secret cannot be decrypted, was TWO_FACTOR_SECRET_KEY changed?")
+	}
+	return base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(string(plain))
+}
+
+// totpCode computes the RFC 6238 code (HMAC-SHA1, dynamic truncation) for one time step
+func totpCode(secret []byte, step int64) string {
+	var message [8]byte
+	binary.BigEndian.PutUint64(message[:], uint64(step))
+	mac := hmac.New(sha1.New, secret)
+	mac.Write(message[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", totpDigits, value%1000000)
+}
+
+// matchTOTP returns the time step the code belongs to; steps up to lastUsedStep are rejected
+func matchTOTP(secret []byte, code string, now time.Time, lastUsedStep int64) (int64, bool) {
+	current := now.Unix() / totpPeriod
+	for skew := -totpSkew; skew <= totpSkew; skew++ {
+		step := current + int64(skew)
+		if step <= lastUsedStep {
+			continue
+		}
+		if subtle.ConstantTimeCompare([]byte(totpCode(secret, step)), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+func normalizeTwoFactorCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	code = strings.ReplaceAll(code, " ", "")
+	return strings.ReplaceAll(code, "-", "")
+}
+
+func isDigits(value string) bool {
+	for _, r := range value {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return value != ""
+}
+
+func randomToken(size int) (string, error) {
+	raw := make([]byte, size)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(raw), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// RFC 6238 appendix B SHA-1 key, codes truncated to 6 digits
+var rfc6238Secret = []byte("12345678901234567890")
+
+func TestTOTPCode(t *testing.T) {
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{unix: 59, want: "287082"},
+		{unix: 1111111109, want: "081804"},
+		{unix: 1234567890, want: "005924"},
+		{unix: 2000000000, want: "279037"},
+	}
+
+	for _, tt := range tests {
+		t.Run(time.Unix(tt.unix, 0).UTC().Format(time.RFC3339), func(t *testing.T) {
+			assert.Equal(t, tt.want, totpCode(rfc6238Secret, tt.unix/totpPeriod))
+		})
+	}
+}
+
+func TestMatchTOTP(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	current := now.Unix() / totpPeriod
+
+	tests := []struct {
+		name         string
+		code         string
+		lastUsedStep int64
+		wantStep     int64
+		wantOK       bool
+	}{
+		{name: "current step", code: totpCode(rfc6238Secret, current), wantStep: current, wantOK: true},
+		{name: "previous step is inside the drift window", code: totpCode(rfc6238Secret, current-1), wantStep: current - 1, wantOK: true},
+		{name: "next step is inside the drift window", code: totpCode(rfc6238Secret, current+1), wantStep: current + 1, wantOK: true},
+		{name: "two steps back is too old", code: totpCode(rfc6238Secret, current-2), wantOK: false},
+		{name: "two steps ahead is too early", code: totpCode(rfc6238Secret, current+2), wantOK: false},
+		{name: "replay of the last accepted step", code: totpCode(rfc6238Secret, current), lastUsedStep: current, wantOK: false},
+		{name: "older step after a newer one was used", code: totpCode(rfc6238Secret, current-1), lastUsedStep: current, wantOK: false},
+		{name: "next step after the current one was used", code: totpCode(rfc6238Secret, current+1), lastUsedStep: current, wantStep: current + 1, wantOK: true},
+		{name: "wrong code", code: "000000", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := matchTOTP(rfc6238Secret, tt.code, now, tt.lastUsedStep)
+			assert.Equal(t, tt.wantOK, ok)
+			if tt.wantOK {
+				assert.Equal(t, tt.wantStep, step)
+			}
+		})
+	}
+}
+
+func TestNormalizeTwoFactorCode(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want string
+	}{
+		{raw: " 123 456 ", want: "123456"},
+		{raw: "ABCD-EFGH", want: "abcdefgh"},
+		{raw: "123456", want: "123456"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			assert.Equal(t, tt.want, normalizeTwoFactorCode(tt.raw))
+		})
+	}
+}
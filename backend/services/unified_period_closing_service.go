@@ -358,6 +358,22 @@ func (s *UnifiedPeriodClosingService) ExecutePeriodClosing(ctx context.Context,
 			return fmt.Errorf("failed to create accounting period: %v", err)
 		}
 
+		// 9. Webhook outbox: period.closed is delivered only if the closing commits
+		webhookData := map[string]interface{}{
+			"period_id":     accountingPeriod.ID,
+			"start_date":    startDate.Format("2006-01-02"),
+			"end_date":      endDate.Format("2006-01-02"),
+			"description":   description,
+			"total_revenue": accountingPeriod.TotalRevenue,
+			"total_expense": accountingPeriod.TotalExpense,
+			"net_income":    accountingPeriod.NetIncome,
+			"closed_by":     userIDUint,
+			"closed_at":     now,
+		}
+		if err := recordWebhookEvent(tx, models.WebhookEventPeriodClosed, "accounting_period", accountingPeriod.ID, webhookData); err != nil {
+			return err
+		}
+
 		log.Printf("[UNIFIED CLOSING] ✅ Period closing completed successfully")
 		log.Printf("[UNIFIED CLOSING] Net Income: %.2f transferred to Retained Earnings", netIncome.InexactFloat64())
 
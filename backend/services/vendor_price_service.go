@@ -0,0 +1,743 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"app-sistem-akuntansi/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// VendorPriceService - Daftar harga per vendor dan permintaan penawaran (RFQ) ke beberapa vendor
+type VendorPriceService struct {
+	db *gorm.DB
+}
+
+func NewVendorPriceService(db *gorm.DB) *VendorPriceService {
+	return &VendorPriceService{db: db}
+}
+
+// ========== PRICE LISTS ==========
+
+// GetPriceLists - Daftar harga vendor sesuai filter
+func (s *VendorPriceService) GetPriceLists(filter models.VendorPriceFilter) ([]models.VendorPriceList, error) {
+	query := s.db.Preload("Vendor").Preload("Product").Order("product_id, vendor_id, min_quantity, valid_from DESC")
+	if filter.VendorID != 0 {
+		query = query.Where("vendor_id = ?", filter.VendorID)
+	}
+	if filter.ProductID != 0 {
+		query = query.Where("product_id = ?", filter.ProductID)
+	}
+	if filter.Active != nil {
+		query = query.Where("is_active = ?", *filter.Active)
+	}
+
+	var entries []models.VendorPriceList
+	if err := query.Find(&entries).Error; err != nil {
+		return nil, err
+	}
+	if filter.ValidOn == nil {
+		return entries, nil
+	}
+
+	valid := make([]models.VendorPriceList, 0, len(entries))
+	for _, entry := range entries {
+		if vendorPriceValidOn(entry, *filter.ValidOn) {
+			valid = append(valid, entry)
+		}
+	}
+	return valid, nil
+}
+
+// GetPriceListByID - Detail satu harga vendor
+func (s *VendorPriceService) GetPriceListByID(id uint) (*models.VendorPriceList, error) {
+	var entry models.VendorPriceList
+	if err := s.db.Preload("Vendor").Preload("Product").First(&entry, id).Error; err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// CreatePriceList - Menambah harga vendor untuk satu produk
+func (s *VendorPriceService) CreatePriceList(req models.VendorPriceListRequest, userID uint) (*models.VendorPriceList, error) {
+	entry := models.VendorPriceList{
+		Source: models.VendorPriceSourceManual,
+		UserID: userID,
+	}
+	if err := s.applyPriceListRequest(&entry, req); err != nil {
+		return nil, err
+	}
+	active := entry.IsActive
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&entry).Error; err != nil {
+			return err
+		}
+		// GORM writes the column default instead of false on Create
+		if !active {
+			return tx.Model(&entry).Update("is_active", false).Error
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create price list entry: %v", err)
+	}
+
+	log.Printf("🏷️ Vendor price added: vendor %d product %d @ %.2f (min qty %d) by user %d", entry.VendorID, entry.ProductID, entry.Price, entry.MinQuantity, userID)
+	return s.GetPriceListByID(entry.ID)
+}
+
+// UpdatePriceList - Mengubah harga vendor
+func (s *VendorPriceService) UpdatePriceList(id uint, req models.VendorPriceListRequest, userID uint) (*models.VendorPriceList, error) {
+	var entry models.VendorPriceList
+	if err := s.db.First(&entry, id).Error; err != nil {
+		return nil, errors.New("price list entry not found")
+	}
+	if err := s.applyPriceListRequest(&entry, req); err != nil {
+		return nil, err
+	}
+	if err := s.db.Omit(clause.Associations).Save(&entry).Error; err != nil {
+		return nil, fmt.Errorf("failed to update price list entry: %v", err)
+	}
+
+	log.Printf("🏷️ Vendor price %d updated by user %d", entry.ID, userID)
+	return s.GetPriceListByID(entry.ID)
+}
+
+// DeletePriceList - Menghapus harga vendor
+func (s *VendorPriceService) DeletePriceList(id uint) error {
+	result := s.db.Delete(&models.VendorPriceList{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("price list entry not found")
+	}
+	return nil
+}
+
+// GetBestPrice - Harga termurah yang berlaku untuk produk, jumlah dan tanggal; vendorID 0 = semua vendor
+func (s *VendorPriceService) GetBestPrice(productID, vendorID uint, quantity int, date time.Time) (*models.BestVendorPrice, error) {
+	var product models.Product
+	if err := s.db.First(&product, productID).Error; err != nil {
+		return nil, fmt.Errorf("product %d not found", productID)
+	}
+	if quantity <= 0 {
+		quantity = 1
+	}
+
+	best, err := findBestVendorPrice(s.db, vendorID, product, quantity, date)
+	if err != nil {
+		return nil, err
+	}
+	if best == nil {
+		return nil, errors.New("no valid vendor price for this product and quantity")
+	}
+
+	var vendor models.Contact
+	s.db.Select("id, name").First(&vendor, best.VendorID)
+
+	return &models.BestVendorPrice{
+		ProductID:    product.ID,
+		VendorID:     best.VendorID,
+		VendorName:   vendor.Name,
+		PriceListID:  best.ID,
+		Unit:         firstNonEmpty(best.Unit, product.Unit),
+		MinQuantity:  best.MinQuantity,
+		Price:        best.Price,
+		LeadTimeDays: best.LeadTimeDays,
+		Date:         date,
+	}, nil
+}
+
+// ========== RFQ ==========
+
+// GetRFQs - Daftar permintaan penawaran
+func (s *VendorPriceService) GetRFQs(status string, vendorID uint) ([]models.RequestForQuotation, error) {
+	query := s.db.Preload("User").Preload("Items.Product").Preload("Vendors.Vendor").Order("date DESC, id DESC")
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if vendorID != 0 {
+		query = query.Where("id IN (?)", s.db.Model(&models.RFQVendor{}).Select("rfq_id").Where("vendor_id = ?", vendorID))
+	}
+
+	var rfqs []models.RequestForQuotation
+	if err := query.Find(&rfqs).Error; err != nil {
+		return nil, err
+	}
+	return rfqs, nil
+}
+
+// GetRFQByID - Detail RFQ beserta balasan vendor
+func (s *VendorPriceService) GetRFQByID(id uint) (*models.RequestForQuotation, error) {
+	var rfq models.RequestForQuotation
+	err := s.db.Preload("User").
+		Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Preload("Items.Product").
+		Preload("Vendors", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Preload("Vendors.Vendor").Preload("Vendors.Replies").
+		First(&rfq, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &rfq, nil
+}
+
+// CreateRFQ - Membuat RFQ DRAFT untuk beberapa vendor
+func (s *VendorPriceService) CreateRFQ(req models.RFQRequest, userID uint) (*models.RequestForQuotation, error) {
+	if err := s.validateRFQRequest(req); err != nil {
+		return nil, err
+	}
+
+	rfq := models.RequestForQuotation{
+		Status: models.RFQStatusDraft,
+		UserID: userID,
+	}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		code, err := s.generateRFQCode(tx, req.Date)
+		if err != nil {
+			return err
+		}
+		rfq.Code = code
+		applyRFQHeader(&rfq, req)
+		if err := tx.Omit(clause.Associations).Create(&rfq).Error; err != nil {
+			return fmt.Errorf("failed to create RFQ: %v", err)
+		}
+		return s.replaceRFQLines(tx, &rfq, req)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("📨 RFQ %s created for %d vendors by user %d", rfq.Code, len(req.VendorIDs), userID)
+	return s.GetRFQByID(rfq.ID)
+}
+
+// UpdateRFQ - Mengubah RFQ yang belum dikirim
+func (s *VendorPriceService) UpdateRFQ(id uint, req models.RFQRequest, userID uint) (*models.RequestForQuotation, error) {
+	if err := s.validateRFQRequest(req); err != nil {
+		return nil, err
+	}
+
+	var rfq models.RequestForQuotation
+	if err := s.db.First(&rfq, id).Error; err != nil {
+		return nil, errors.New("RFQ not found")
+	}
+	if rfq.Status != models.RFQStatusDraft {
+		return nil, fmt.Errorf("only DRAFT RFQs can be changed, current status: %s", rfq.Status)
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		applyRFQHeader(&rfq, req)
+		if err := tx.Omit(clause.Associations).Save(&rfq).Error; err != nil {
+			return fmt.Errorf("failed to update RFQ: %v", err)
+		}
+		if err := s.deleteRFQLines(tx, rfq.ID); err != nil {
+			return err
+		}
+		return s.replaceRFQLines(tx, &rfq, req)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("📝 RFQ %s updated by user %d", rfq.Code, userID)
+	return s.GetRFQByID(rfq.ID)
+}
+
+// DeleteRFQ - Menghapus RFQ yang belum dikirim
+func (s *VendorPriceService) DeleteRFQ(id uint) error {
+	var rfq models.RequestForQuotation
+	if err := s.db.First(&rfq, id).Error; err != nil {
+		return errors.New("RFQ not found")
+	}
+	if rfq.Status != models.RFQStatusDraft {
+		return errors.New("only DRAFT RFQs can be deleted, cancel it instead")
+	}
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := s.deleteRFQLines(tx, rfq.ID); err != nil {
+			return err
+		}
+		return tx.Delete(&rfq).Error
+	})
+}
+
+// SendRFQ - Menandai RFQ sudah dikirim ke vendor sehingga balasan dapat dicatat
+func (s *VendorPriceService) SendRFQ(id uint, userID uint) (*models.RequestForQuotation, error) {
+	var rfq models.RequestForQuotation
+	if err := s.db.First(&rfq, id).Error; err != nil {
+		return nil, errors.New("RFQ not found")
+	}
+	if rfq.Status != models.RFQStatusDraft {
+		return nil, fmt.Errorf("only DRAFT RFQs can be sent, current status: %s", rfq.Status)
+	}
+
+	now := time.Now()
+	if err := s.db.Model(&rfq).Updates(map[string]interface{}{
+		"status":  models.RFQStatusSent,
+		"sent_at": now,
+	}).Error; err != nil {
+		return nil, err
+	}
+
+	log.Printf("📤 RFQ %s sent by user %d", rfq.Code, userID)
+	return s.GetRFQByID(rfq.ID)
+}
+
+// RecordReply - Mencatat (atau mengganti) balasan satu vendor
+func (s *VendorPriceService) RecordReply(rfqID, vendorID uint, req models.RFQReplyRequest, userID uint) (*models.RequestForQuotation, error) {
+	rfq, err := s.GetRFQByID(rfqID)
+	if err != nil {
+		return nil, errors.New("RFQ not found")
+	}
+	if rfq.Status != models.RFQStatusSent {
+		return nil, fmt.Errorf("replies can only be recorded on SENT RFQs, current status: %s", rfq.Status)
+	}
+
+	var rfqVendor *models.RFQVendor
+	for i := range rfq.Vendors {
+		if rfq.Vendors[i].VendorID == vendorID {
+			rfqVendor = &rfq.Vendors[i]
+			break
+		}
+	}
+	if rfqVendor == nil {
+		return nil, fmt.Errorf("vendor %d was not invited to RFQ %s", vendorID, rfq.Code)
+	}
+
+	itemIDs := make(map[uint]bool, len(rfq.Items))
+	for _, item := range rfq.Items {
+		itemIDs[item.ID] = true
+	}
+	if !req.Declined {
+		if len(req.Items) == 0 {
+			return nil, errors.New("reply must quote at least one item, or be marked as declined")
+		}
+		seen := make(map[uint]bool, len(req.Items))
+		for _, itemReq := range req.Items {
+			if !itemIDs[itemReq.RFQItemID] {
+				return nil, fmt.Errorf("item %d is not part of RFQ %s", itemReq.RFQItemID, rfq.Code)
+			}
+			if seen[itemReq.RFQItemID] {
+				return nil, fmt.Errorf("item %d is quoted twice", itemReq.RFQItemID)
+			}
+			seen[itemReq.RFQItemID] = true
+		}
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("rfq_vendor_id = ?", rfqVendor.ID).Delete(&models.RFQReply{}).Error; err != nil {
+			return err
+		}
+
+		status := models.RFQVendorStatusResponded
+		if req.Declined {
+			status = models.RFQVendorStatusDeclined
+		} else {
+			for _, itemReq := range req.Items {
+				reply := models.RFQReply{
+					RFQVendorID:  rfqVendor.ID,
+					RFQItemID:    itemReq.RFQItemID,
+					UnitPrice:    roundAmount(itemReq.UnitPrice),
+					Quantity:     itemReq.Quantity,
+					LeadTimeDays: itemReq.LeadTimeDays,
+					Notes:        itemReq.Notes,
+				}
+				if reply.LeadTimeDays == 0 {
+					reply.LeadTimeDays = req.LeadTimeDays
+				}
+				if err := tx.Create(&reply).Error; err != nil {
+					return fmt.Errorf("failed to save reply: %v", err)
+				}
+			}
+		}
+
+		now := time.Now()
+		return tx.Model(&models.RFQVendor{}).Where("id = ?", rfqVendor.ID).Updates(map[string]interface{}{
+			"status":         status,
+			"responded_at":   now,
+			"quote_ref":      req.QuoteRef,
+			"valid_until":    req.ValidUntil,
+			"lead_time_days": req.LeadTimeDays,
+			"notes":          req.Notes,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("📥 RFQ %s: reply of vendor %d recorded by user %d", rfq.Code, vendorID, userID)
+	return s.GetRFQByID(rfq.ID)
+}
+
+// CompareRFQ - Membandingkan balasan vendor per item secara berdampingan
+func (s *VendorPriceService) CompareRFQ(id uint) (*models.RFQComparison, error) {
+	rfq, err := s.GetRFQByID(id)
+	if err != nil {
+		return nil, errors.New("RFQ not found")
+	}
+
+	comparison := &models.RFQComparison{
+		RFQID:  rfq.ID,
+		Code:   rfq.Code,
+		Status: rfq.Status,
+	}
+
+	vendorIndex := make(map[uint]int, len(rfq.Vendors))
+	for _, rfqVendor := range rfq.Vendors {
+		vendorIndex[rfqVendor.VendorID] = len(comparison.Vendors)
+		comparison.Vendors = append(comparison.Vendors, models.RFQComparisonVendor{
+			VendorID:     rfqVendor.VendorID,
+			VendorName:   rfqVendor.Vendor.Name,
+			Status:       rfqVendor.Status,
+			LeadTimeDays: rfqVendor.LeadTimeDays,
+		})
+	}
+
+	for _, item := range rfq.Items {
+		row := models.RFQComparisonItem{
+			RFQItemID:    item.ID,
+			ProductID:    item.ProductID,
+			ProductName:  item.Product.Name,
+			Quantity:     item.Quantity,
+			Unit:         firstNonEmpty(item.Unit, item.Product.Unit),
+			CurrentPrice: item.Product.PurchasePrice,
+		}
+
+		for _, rfqVendor := range rfq.Vendors {
+			if rfqVendor.Status == models.RFQVendorStatusDeclined {
+				continue
+			}
+			for _, reply := range rfqVendor.Replies {
+				if reply.RFQItemID != item.ID {
+					continue
+				}
+				quantity := item.Quantity
+				if reply.Quantity > 0 && reply.Quantity < quantity {
+					quantity = reply.Quantity
+				}
+				row.Quotes = append(row.Quotes, models.RFQComparisonQuote{
+					VendorID:     rfqVendor.VendorID,
+					VendorName:   rfqVendor.Vendor.Name,
+					UnitPrice:    reply.UnitPrice,
+					Quantity:     quantity,
+					LeadTimeDays: reply.LeadTimeDays,
+					LineTotal:    roundAmount(reply.UnitPrice * float64(quantity)),
+				})
+			}
+		}
+
+		// Cheapest first; on equal price the faster vendor wins
+		sort.SliceStable(row.Quotes, func(i, j int) bool {
+			if row.Quotes[i].UnitPrice != row.Quotes[j].UnitPrice {
+				return row.Quotes[i].UnitPrice < row.Quotes[j].UnitPrice
+			}
+			return row.Quotes[i].LeadTimeDays < row.Quotes[j].LeadTimeDays
+		})
+		if len(row.Quotes) > 0 {
+			row.BestVendorID = row.Quotes[0].VendorID
+			row.BestPrice = row.Quotes[0].UnitPrice
+			row.Quotes[0].IsBest = true
+			comparison.Vendors[vendorIndex[row.BestVendorID]].BestPriceWins++
+		}
+		for i := range row.Quotes {
+			quote := &row.Quotes[i]
+			if row.BestPrice > 0 {
+				quote.DiffFromBest = roundAmount((quote.UnitPrice - row.BestPrice) / row.BestPrice * 100)
+			}
+			summary := &comparison.Vendors[vendorIndex[quote.VendorID]]
+			summary.ItemsQuoted++
+			summary.TotalAmount = roundAmount(summary.TotalAmount + quote.LineTotal)
+		}
+
+		comparison.Items = append(comparison.Items, row)
+	}
+
+	for i := range comparison.Vendors {
+		comparison.Vendors[i].IsComplete = comparison.Vendors[i].ItemsQuoted == len(rfq.Items)
+	}
+	return comparison, nil
+}
+
+// AwardRFQ - Menutup RFQ untuk satu vendor, opsional menyimpan harganya ke daftar harga
+func (s *VendorPriceService) AwardRFQ(id uint, req models.RFQAwardRequest, userID uint) (*models.RequestForQuotation, error) {
+	rfq, err := s.GetRFQByID(id)
+	if err != nil {
+		return nil, errors.New("RFQ not found")
+	}
+	if rfq.Status != models.RFQStatusSent {
+		return nil, fmt.Errorf("only SENT RFQs can be awarded, current status: %s", rfq.Status)
+	}
+
+	var winner *models.RFQVendor
+	for i := range rfq.Vendors {
+		if rfq.Vendors[i].VendorID == req.VendorID {
+			winner = &rfq.Vendors[i]
+			break
+		}
+	}
+	if winner == nil {
+		return nil, fmt.Errorf("vendor %d was not invited to RFQ %s", req.VendorID, rfq.Code)
+	}
+	if winner.Status != models.RFQVendorStatusResponded || len(winner.Replies) == 0 {
+		return nil, errors.New("only a vendor that replied with prices can be awarded")
+	}
+
+	validTo := req.ValidTo
+	if validTo == nil {
+		validTo = winner.ValidUntil
+	}
+	today := dateOnly(time.Now())
+	if validTo != nil && dateOnly(*validTo).Before(today) {
+		return nil, errors.New("the vendor's quotation has already expired")
+	}
+
+	items := make(map[uint]models.RFQItem, len(rfq.Items))
+	for _, item := range rfq.Items {
+		items[item.ID] = item
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := tx.Model(&models.RFQVendor{}).Where("id = ?", winner.ID).Update("status", models.RFQVendorStatusAwarded).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.RequestForQuotation{}).Where("id = ?", rfq.ID).Updates(map[string]interface{}{
+			"status":    models.RFQStatusClosed,
+			"closed_at": now,
+		}).Error; err != nil {
+			return err
+		}
+		if !req.SaveToPriceList {
+			return nil
+		}
+
+		rfqID := rfq.ID
+		for _, reply := range winner.Replies {
+			item := items[reply.RFQItemID]
+			entry := models.VendorPriceList{
+				VendorID:     winner.VendorID,
+				ProductID:    item.ProductID,
+				Unit:         item.Unit,
+				MinQuantity:  1,
+				Price:        reply.UnitPrice,
+				ValidFrom:    today,
+				ValidTo:      validTo,
+				LeadTimeDays: reply.LeadTimeDays,
+				IsActive:     true,
+				Source:       models.VendorPriceSourceRFQ,
+				RFQID:        &rfqID,
+				Notes:        fmt.Sprintf("Awarded from %s %s", rfq.Code, winner.QuoteRef),
+				UserID:       userID,
+			}
+			entry.Notes = strings.TrimSpace(entry.Notes)
+			if err := tx.Create(&entry).Error; err != nil {
+				return fmt.Errorf("failed to save price list entry: %v", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("🏆 RFQ %s awarded to vendor %d by user %d", rfq.Code, winner.VendorID, userID)
+	return s.GetRFQByID(rfq.ID)
+}
+
+// CancelRFQ - Membatalkan RFQ yang belum diberikan ke vendor
+func (s *VendorPriceService) CancelRFQ(id uint, reason string, userID uint) (*models.RequestForQuotation, error) {
+	var rfq models.RequestForQuotation
+	if err := s.db.First(&rfq, id).Error; err != nil {
+		return nil, errors.New("RFQ not found")
+	}
+	if rfq.Status != models.RFQStatusDraft && rfq.Status != models.RFQStatusSent {
+		return nil, fmt.Errorf("RFQ cannot be cancelled in status %s", rfq.Status)
+	}
+
+	notes := strings.TrimSpace(rfq.Notes + "\nCancelled: " + reason)
+	if err := s.db.Model(&rfq).Updates(map[string]interface{}{
+		"status":    models.RFQStatusCancelled,
+		"closed_at": time.Now(),
+		"notes":     notes,
+	}).Error; err != nil {
+		return nil, err
+	}
+
+	log.Printf("🚫 RFQ %s cancelled by user %d: %s", rfq.Code, userID, reason)
+	return s.GetRFQByID(rfq.ID)
+}
+
+// ========== HELPERS ==========
+
+func (s *VendorPriceService) applyPriceListRequest(entry *models.VendorPriceList, req models.VendorPriceListRequest) error {
+	if err := s.validateVendor(s.db, req.VendorID); err != nil {
+		return err
+	}
+	var product models.Product
+	if err := s.db.First(&product, req.ProductID).Error; err != nil {
+		return fmt.Errorf("product %d not found", req.ProductID)
+	}
+	if req.ValidTo != nil && dateOnly(*req.ValidTo).Before(dateOnly(req.ValidFrom)) {
+		return errors.New("valid-to date cannot be before the valid-from date")
+	}
+
+	entry.VendorID = req.VendorID
+	entry.ProductID = req.ProductID
+	entry.Unit = strings.TrimSpace(req.Unit)
+	entry.MinQuantity = req.MinQuantity
+	if entry.MinQuantity <= 0 {
+		entry.MinQuantity = 1
+	}
+	entry.Price = roundAmount(req.Price)
+	entry.ValidFrom = req.ValidFrom
+	entry.ValidTo = req.ValidTo
+	entry.LeadTimeDays = req.LeadTimeDays
+	entry.IsActive = req.IsActive == nil || *req.IsActive
+	entry.Notes = req.Notes
+	return nil
+}
+
+func (s *VendorPriceService) validateVendor(tx *gorm.DB, vendorID uint) error {
+	var vendor models.Contact
+	if err := tx.Select("id, type").First(&vendor, vendorID).Error; err != nil {
+		return fmt.Errorf("vendor %d not found", vendorID)
+	}
+	if vendor.Type != models.ContactTypeVendor {
+		return fmt.Errorf("contact %d is not a vendor", vendorID)
+	}
+	return nil
+}
+
+func (s *VendorPriceService) validateRFQRequest(req models.RFQRequest) error {
+	if dateOnly(req.ResponseDeadline).Before(dateOnly(req.Date)) {
+		return errors.New("response deadline cannot be before the RFQ date")
+	}
+	if req.RequisitionID != nil {
+		var count int64
+		s.db.Model(&models.PurchaseRequisition{}).Where("id = ?", *req.RequisitionID).Count(&count)
+		if count == 0 {
+			return errors.New("purchase requisition not found")
+		}
+	}
+	seen := make(map[uint]bool, len(req.VendorIDs))
+	for _, vendorID := range req.VendorIDs {
+		if seen[vendorID] {
+			return fmt.Errorf("vendor %d is listed twice", vendorID)
+		}
+		seen[vendorID] = true
+		if err := s.validateVendor(s.db, vendorID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *VendorPriceService) replaceRFQLines(tx *gorm.DB, rfq *models.RequestForQuotation, req models.RFQRequest) error {
+	for _, itemReq := range req.Items {
+		var product models.Product
+		if err := tx.First(&product, itemReq.ProductID).Error; err != nil {
+			return fmt.Errorf("product %d not found", itemReq.ProductID)
+		}
+		item := models.RFQItem{
+			RFQID:       rfq.ID,
+			ProductID:   itemReq.ProductID,
+			Description: itemReq.Description,
+			Quantity:    itemReq.Quantity,
+			Unit:        firstNonEmpty(strings.TrimSpace(itemReq.Unit), product.Unit),
+		}
+		if err := tx.Create(&item).Error; err != nil {
+			return fmt.Errorf("failed to save RFQ item: %v", err)
+		}
+	}
+	for _, vendorID := range req.VendorIDs {
+		rfqVendor := models.RFQVendor{
+			RFQID:    rfq.ID,
+			VendorID: vendorID,
+			Status:   models.RFQVendorStatusInvited,
+		}
+		if err := tx.Create(&rfqVendor).Error; err != nil {
+			return fmt.Errorf("failed to save RFQ vendor: %v", err)
+		}
+	}
+	return nil
+}
+
+func (s *VendorPriceService) deleteRFQLines(tx *gorm.DB, rfqID uint) error {
+	if err := tx.Where("rfq_vendor_id IN (?)", tx.Model(&models.RFQVendor{}).Select("id").Where("rfq_id = ?", rfqID)).
+		Delete(&models.RFQReply{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("rfq_id = ?", rfqID).Delete(&models.RFQVendor{}).Error; err != nil {
+		return err
+	}
+	return tx.Where("rfq_id = ?", rfqID).Delete(&models.RFQItem{}).Error
+}
+
+func (s *VendorPriceService) generateRFQCode(tx *gorm.DB, date time.Time) (string, error) {
+	var count int64
+	prefix := fmt.Sprintf("RFQ-%s-", date.Format("200601"))
+	if err := tx.Unscoped().Model(&models.RequestForQuotation{}).
+		Where("code LIKE ?", prefix+"%").
+		Count(&count).Error; err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s%04d", prefix, count+1), nil
+}
+
+func applyRFQHeader(rfq *models.RequestForQuotation, req models.RFQRequest) {
+	rfq.Date = req.Date
+	rfq.ResponseDeadline = req.ResponseDeadline
+	rfq.RequisitionID = req.RequisitionID
+	rfq.Notes = req.Notes
+}
+
+// findBestVendorPrice returns the cheapest active price list entry valid on date for the product
+// unit and quantity; vendorID 0 searches every vendor. Nil means no entry applies.
+func findBestVendorPrice(db *gorm.DB, vendorID uint, product models.Product, quantity int, date time.Time) (*models.VendorPriceList, error) {
+	query := db.Where("product_id = ? AND is_active = ? AND min_quantity <= ?", product.ID, true, quantity)
+	if vendorID != 0 {
+		query = query.Where("vendor_id = ?", vendorID)
+	}
+
+	var entries []models.VendorPriceList
+	if err := query.Find(&entries).Error; err != nil {
+		return nil, err
+	}
+	return pickBestVendorPrice(entries, product.Unit, quantity, date), nil
+}
+
+// pickBestVendorPrice chooses the lowest price among entries that apply; on equal price the
+// shorter lead time, then the newest entry wins
+func pickBestVendorPrice(entries []models.VendorPriceList, unit string, quantity int, date time.Time) *models.VendorPriceList {
+	var best *models.VendorPriceList
+	for i := range entries {
+		entry := &entries[i]
+		if !entry.IsActive || entry.MinQuantity > quantity || !vendorPriceValidOn(*entry, date) {
+			continue
+		}
+		if entry.Unit != "" && unit != "" && !strings.EqualFold(entry.Unit, unit) {
+			continue
+		}
+		if best == nil || entry.Price < best.Price ||
+			(entry.Price == best.Price && entry.LeadTimeDays < best.LeadTimeDays) ||
+			(entry.Price == best.Price && entry.LeadTimeDays == best.LeadTimeDays && entry.ValidFrom.After(best.ValidFrom)) {
+			best = entry
+		}
+	}
+	return best
+}
+
+func vendorPriceValidOn(entry models.VendorPriceList, date time.Time) bool {
+	day := dateOnly(date)
+	if dateOnly(entry.ValidFrom).After(day) {
+		return false
+	}
+	return entry.ValidTo == nil || !dateOnly(*entry.ValidTo).Before(day)
+}
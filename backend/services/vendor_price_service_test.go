@@ -0,0 +1,196 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"app-sistem-akuntansi/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestPickBestVendorPrice(t *testing.T) {
+	day := func(month time.Month, d int) time.Time { return time.Date(2024, month, d, 0, 0, 0, 0, time.UTC) }
+	until := func(month time.Month, d int) *time.Time {
+		date := day(month, d)
+		return &date
+	}
+	date := time.Date(2024, 3, 15, 14, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		entries  []models.VendorPriceList
+		quantity int
+		wantID   uint
+	}{
+		{
+			name: "lowest price",
+			entries: []models.VendorPriceList{
+				{ID: 1, Price: 50000, MinQuantity: 1, ValidFrom: day(1, 1), IsActive: true},
+				{ID: 2, Price: 47500, MinQuantity: 1, ValidFrom: day(1, 1), IsActive: true},
+			},
+			quantity: 1, wantID: 2,
+		},
+		{
+			name: "quantity tier reached",
+			entries: []models.VendorPriceList{
+				{ID: 1, Price: 50000, MinQuantity: 1, ValidFrom: day(1, 1), IsActive: true},
+				{ID: 2, Price: 45000, MinQuantity: 10, ValidFrom: day(1, 1), IsActive: true},
+			},
+			quantity: 10, wantID: 2,
+		},
+		{
+			name: "quantity tier not reached",
+			entries: []models.VendorPriceList{
+				{ID: 1, Price: 50000, MinQuantity: 1, ValidFrom: day(1, 1), IsActive: true},
+				{ID: 2, Price: 45000, MinQuantity: 10, ValidFrom: day(1, 1), IsActive: true},
+			},
+			quantity: 9, wantID: 1,
+		},
+		{
+			name: "validity period including its last day",
+			entries: []models.VendorPriceList{
+				{ID: 1, Price: 40000, MinQuantity: 1, ValidFrom: day(1, 1), ValidTo: until(3, 14), IsActive: true},
+				{ID: 2, Price: 42000, MinQuantity: 1, ValidFrom: day(3, 16), IsActive: true},
+				{ID: 3, Price: 48000, MinQuantity: 1, ValidFrom: day(2, 1), ValidTo: until(3, 15), IsActive: true},
+			},
+			quantity: 1, wantID: 3,
+		},
+		{
+			name: "inactive entries and other units are skipped",
+			entries: []models.VendorPriceList{
+				{ID: 1, Price: 30000, MinQuantity: 1, ValidFrom: day(1, 1), IsActive: false},
+				{ID: 2, Price: 35000, Unit: "box", MinQuantity: 1, ValidFrom: day(1, 1), IsActive: true},
+				{ID: 3, Price: 50000, Unit: "RIM", MinQuantity: 1, ValidFrom: day(1, 1), IsActive: true},
+			},
+			quantity: 1, wantID: 3,
+		},
+		{
+			name: "equal price goes to the shorter lead time, then the newest entry",
+			entries: []models.VendorPriceList{
+				{ID: 1, Price: 45000, MinQuantity: 1, ValidFrom: day(3, 1), LeadTimeDays: 7, IsActive: true},
+				{ID: 2, Price: 45000, MinQuantity: 1, ValidFrom: day(1, 1), LeadTimeDays: 3, IsActive: true},
+				{ID: 3, Price: 45000, MinQuantity: 1, ValidFrom: day(2, 1), LeadTimeDays: 3, IsActive: true},
+			},
+			quantity: 1, wantID: 3,
+		},
+		{
+			name: "nothing applies",
+			entries: []models.VendorPriceList{
+				{ID: 1, Price: 45000, MinQuantity: 1, ValidFrom: day(4, 1), IsActive: true},
+			},
+			quantity: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			best := pickBestVendorPrice(tt.entries, "rim", tt.quantity, date)
+			if tt.wantID == 0 {
+				assert.Nil(t, best)
+				return
+			}
+			require.NotNil(t, best)
+			assert.Equal(t, tt.wantID, best.ID)
+		})
+	}
+}
+
+func TestRFQComparisonAndAward(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1) // Every connection to :memory: is a separate database
+	require.NoError(t, db.AutoMigrate(&models.User{}, &models.Contact{}, &models.Product{}, &models.PurchaseRequisition{},
+		&models.VendorPriceList{}, &models.RequestForQuotation{}, &models.RFQItem{}, &models.RFQVendor{}, &models.RFQReply{}))
+	service := NewVendorPriceService(db)
+
+	customer := models.Contact{Code: "CUST-001", Name: "PT Pelanggan", Type: models.ContactTypeCustomer}
+	vendorA := models.Contact{Code: "VEND-001", Name: "PT Kertas Jaya", Type: models.ContactTypeVendor}
+	vendorB := models.Contact{Code: "VEND-002", Name: "CV Toner Murah", Type: models.ContactTypeVendor}
+	for _, contact := range []*models.Contact{&customer, &vendorA, &vendorB} {
+		require.NoError(t, db.Create(contact).Error)
+	}
+	paper := models.Product{Code: "PRD-001", Name: "Kertas A4", Unit: "rim", PurchasePrice: 50000}
+	toner := models.Product{Code: "PRD-002", Name: "Toner", Unit: "pcs", PurchasePrice: 300000}
+	require.NoError(t, db.Create(&paper).Error)
+	require.NoError(t, db.Create(&toner).Error)
+	today := dateOnly(time.Now())
+
+	inactive := false
+	entry, err := service.CreatePriceList(models.VendorPriceListRequest{VendorID: vendorA.ID, ProductID: paper.ID, Price: 40000, ValidFrom: today, IsActive: &inactive}, 1)
+	require.NoError(t, err)
+	assert.False(t, entry.IsActive, "a price can be entered before it is switched on")
+	assert.Equal(t, 1, entry.MinQuantity)
+	_, err = service.CreatePriceList(models.VendorPriceListRequest{VendorID: customer.ID, ProductID: paper.ID, Price: 40000, ValidFrom: today}, 1)
+	assert.EqualError(t, err, "contact 1 is not a vendor")
+
+	request := models.RFQRequest{Date: today, ResponseDeadline: today.AddDate(0, 0, 7), VendorIDs: []uint{vendorA.ID, vendorA.ID},
+		Items: []models.RFQItemRequest{{ProductID: paper.ID, Quantity: 100}, {ProductID: toner.ID, Quantity: 4}}}
+	_, err = service.CreateRFQ(request, 1)
+	assert.EqualError(t, err, "vendor 2 is listed twice")
+	request.VendorIDs = []uint{vendorA.ID, vendorB.ID}
+	rfq, err := service.CreateRFQ(request, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "RFQ-"+today.Format("200601")+"-0001", rfq.Code)
+	require.Len(t, rfq.Items, 2)
+	assert.Equal(t, "rim", rfq.Items[0].Unit)
+	paperItem, tonerItem := rfq.Items[0], rfq.Items[1]
+
+	_, err = service.RecordReply(rfq.ID, vendorA.ID, models.RFQReplyRequest{Declined: true}, 1)
+	assert.EqualError(t, err, "replies can only be recorded on SENT RFQs, current status: DRAFT")
+	_, err = service.SendRFQ(rfq.ID, 1)
+	require.NoError(t, err)
+
+	validUntil := today.AddDate(0, 1, 0)
+	_, err = service.RecordReply(rfq.ID, vendorA.ID, models.RFQReplyRequest{Items: []models.RFQReplyItemRequest{
+		{RFQItemID: paperItem.ID, UnitPrice: 48000}, {RFQItemID: paperItem.ID, UnitPrice: 47000}}}, 1)
+	assert.EqualError(t, err, "item 1 is quoted twice")
+	_, err = service.RecordReply(rfq.ID, vendorA.ID, models.RFQReplyRequest{QuoteRef: "QA-77", ValidUntil: &validUntil, LeadTimeDays: 3,
+		Items: []models.RFQReplyItemRequest{{RFQItemID: paperItem.ID, UnitPrice: 48000}, {RFQItemID: tonerItem.ID, UnitPrice: 310000}}}, 1)
+	require.NoError(t, err)
+	_, err = service.RecordReply(rfq.ID, vendorB.ID, models.RFQReplyRequest{LeadTimeDays: 5,
+		Items: []models.RFQReplyItemRequest{{RFQItemID: paperItem.ID, UnitPrice: 48000, Quantity: 60}, {RFQItemID: tonerItem.ID, UnitPrice: 279000}}}, 1)
+	require.NoError(t, err)
+
+	comparison, err := service.CompareRFQ(rfq.ID)
+	require.NoError(t, err)
+	require.Len(t, comparison.Items, 2)
+	paperRow := comparison.Items[0]
+	assert.Equal(t, vendorA.ID, paperRow.BestVendorID, "equal price goes to the faster vendor")
+	assert.InDelta(t, 50000, paperRow.CurrentPrice, 0.001)
+	require.Len(t, paperRow.Quotes, 2)
+	assert.True(t, paperRow.Quotes[0].IsBest)
+	assert.Equal(t, 60, paperRow.Quotes[1].Quantity, "vendor B can only supply 60")
+	assert.InDelta(t, 2880000, paperRow.Quotes[1].LineTotal, 0.001)
+	tonerRow := comparison.Items[1]
+	assert.Equal(t, vendorB.ID, tonerRow.BestVendorID)
+	assert.InDelta(t, 11.11, tonerRow.Quotes[1].DiffFromBest, 0.001)
+	assert.Equal(t, models.RFQComparisonVendor{VendorID: vendorA.ID, VendorName: "PT Kertas Jaya", Status: models.RFQVendorStatusResponded,
+		LeadTimeDays: 3, ItemsQuoted: 2, TotalAmount: 6040000, BestPriceWins: 1, IsComplete: true}, comparison.Vendors[0])
+	assert.InDelta(t, 3996000, comparison.Vendors[1].TotalAmount, 0.001)
+
+	awarded, err := service.AwardRFQ(rfq.ID, models.RFQAwardRequest{VendorID: vendorA.ID, SaveToPriceList: true}, 1)
+	require.NoError(t, err)
+	assert.Equal(t, models.RFQStatusClosed, awarded.Status)
+	assert.Equal(t, models.RFQVendorStatusAwarded, awarded.Vendors[0].Status)
+	_, err = service.CancelRFQ(rfq.ID, "Batal", 1)
+	assert.EqualError(t, err, "RFQ cannot be cancelled in status CLOSED")
+
+	// The awarded prices become the vendor's price list, the inactive manual price does not count
+	best, err := service.GetBestPrice(paper.ID, 0, 100, today)
+	require.NoError(t, err)
+	assert.Equal(t, vendorA.ID, best.VendorID)
+	assert.InDelta(t, 48000, best.Price, 0.001)
+	assert.Equal(t, 3, best.LeadTimeDays)
+	assert.Equal(t, "rim", best.Unit)
+	_, err = service.GetBestPrice(toner.ID, vendorB.ID, 1, today)
+	assert.EqualError(t, err, "no valid vendor price for this product and quantity")
+	_, err = service.GetBestPrice(paper.ID, 0, 1, validUntil.AddDate(0, 0, 1))
+	assert.EqualError(t, err, "no valid vendor price for this product and quantity", "the award is only valid as long as the quotation")
+}
@@ -0,0 +1,278 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"app-sistem-akuntansi/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ========== WEBHOOK OUTBOX ==========
+
+// recordWebhookEvent writes an event to the webhook outbox. Call it with the transaction of the business
+// change so the event exists only if the change commits. Nothing is written when no subscription listens
+// for the event type; disabled subscriptions still count, so their events wait until they are enabled again.
+func recordWebhookEvent(tx *gorm.DB, eventType, entityType string, entityID uint, data interface{}) error {
+	subscribed, err := webhookEventSubscribed(tx, eventType)
+	if err != nil || !subscribed {
+		return err
+	}
+
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s webhook event: %v", eventType, err)
+	}
+	event := models.WebhookEvent{
+		EventUUID:  uuid.New().String(),
+		EventType:  eventType,
+		EntityType: entityType,
+		EntityID:   &entityID,
+		Data:       string(payload),
+		Status:     models.WebhookEventStatusPending,
+		OccurredAt: time.Now(),
+	}
+	if err := tx.Create(&event).Error; err != nil {
+		return fmt.Errorf("failed to record %s webhook event: %v", eventType, err)
+	}
+	return nil
+}
+
+// webhookEventSubscribed reports whether any subscription that still queues events listens for the event type
+func webhookEventSubscribed(db *gorm.DB, eventType string) (bool, error) {
+	var subscriptions []models.WebhookSubscription
+	if err := db.Select("id", "events", "is_active", "disabled_by").Find(&subscriptions).Error; err != nil {
+		return false, fmt.Errorf("failed to load webhook subscriptions: %v", err)
+	}
+	for i := range subscriptions {
+		if subscriptions[i].QueuesEvents() && subscriptions[i].Subscribes(eventType) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// saleInvoicedWebhookData - Payload sale.invoiced
+func saleInvoicedWebhookData(sale *models.Sale) map[string]interface{} {
+	items := make([]map[string]interface{}, 0, len(sale.SaleItems))
+	for _, item := range sale.SaleItems {
+		items = append(items, map[string]interface{}{
+			"product_id": item.ProductID,
+			"quantity":   item.Quantity,
+			"unit_price": item.UnitPrice,
+			"line_total": item.LineTotal,
+		})
+	}
+	return map[string]interface{}{
+		"sale_id":            sale.ID,
+		"code":               sale.Code,
+		"invoice_number":     sale.InvoiceNumber,
+		"customer_id":        sale.CustomerID,
+		"date":               sale.Date.Format("2006-01-02"),
+		"due_date":           sale.DueDate.Format("2006-01-02"),
+		"currency":           sale.Currency,
+		"total_amount":       sale.TotalAmount,
+		"paid_amount":        sale.PaidAmount,
+		"outstanding_amount": sale.OutstandingAmount,
+		"status":             sale.Status,
+		"items":              items,
+	}
+}
+
+// paymentReceivedWebhookData - Payload payment.received; allocations are {sale_id, amount}
+func paymentReceivedWebhookData(paymentID uint, code string, contactID uint, date time.Time, amount float64, method, reference string, allocations []map[string]interface{}) map[string]interface{} {
+	if allocations == nil {
+		allocations = []map[string]interface{}{}
+	}
+	return map[string]interface{}{
+		"payment_id":  paymentID,
+		"code":        code,
+		"contact_id":  contactID,
+		"date":        date.Format("2006-01-02"),
+		"amount":      amount,
+		"method":      method,
+		"reference":   reference,
+		"allocations": allocations,
+	}
+}
+
+// paymentAllocationsWebhookData - Alokasi payment ke invoice dalam format payload payment.received
+func paymentAllocationsWebhookData(allocations []models.PaymentAllocation) []map[string]interface{} {
+	result := make([]map[string]interface{}, 0, len(allocations))
+	for _, allocation := range allocations {
+		if allocation.InvoiceID == nil {
+			continue
+		}
+		result = append(result, map[string]interface{}{
+			"sale_id": *allocation.InvoiceID,
+			"amount":  allocation.AllocatedAmount,
+		})
+	}
+	return result
+}
+
+// ========== JOURNAL EVENT LOG IMPORT ==========
+
+const (
+	webhookJournalCursor    = "journal_event_log"
+	webhookJournalBatchSize = 200
+)
+
+// journalEventLogRow is the part of journal_event_log the webhook worker needs
+type journalEventLogRow struct {
+	ID             uint64
+	JournalID      *uint64
+	EventType      string
+	EventData      string
+	EventTimestamp time.Time
+	CorrelationID  *string
+}
+
+// importJournalEvents converts new POSTED and REVERSED rows of journal_event_log (and CREATED rows of
+// journals inserted directly as POSTED) into journal.posted and journal.reversed webhook events.
+// The position in the log is kept in WebhookCursor, so history from before the first run is skipped.
+func (s *WebhookService) importJournalEvents() error {
+	var cursor models.WebhookCursor
+	if err := s.db.Where("name = ?", webhookJournalCursor).First(&cursor).Error; err != nil {
+		if err != gorm.ErrRecordNotFound {
+			return err
+		}
+		cursor.Name = webhookJournalCursor
+		s.db.Raw("SELECT COALESCE(MAX(id), 0) FROM journal_event_log").Scan(&cursor.Position)
+		return s.db.Create(&cursor).Error
+	}
+
+	var rows []journalEventLogRow
+	if err := s.db.Raw(`
+		SELECT id, journal_id, event_type, event_data::text AS event_data, event_timestamp, correlation_id::text AS correlation_id
+		FROM journal_event_log
+		WHERE id > ?
+		ORDER BY id
+		LIMIT ?`, cursor.Position, webhookJournalBatchSize).Scan(&rows).Error; err != nil {
+		return fmt.Errorf("failed to read journal event log: %v", err)
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+
+	postedWanted, err := webhookEventSubscribed(s.db, models.WebhookEventJournalPosted)
+	if err != nil {
+		return err
+	}
+	reversedWanted, err := webhookEventSubscribed(s.db, models.WebhookEventJournalReversed)
+	if err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		eventType := journalWebhookEventType(row)
+		if row.JournalID == nil || eventType == "" ||
+			(eventType == models.WebhookEventJournalPosted && !postedWanted) ||
+			(eventType == models.WebhookEventJournalReversed && !reversedWanted) {
+			continue
+		}
+		if err := s.recordJournalEvent(row, eventType); err != nil {
+			// Stop here; the cursor stays before this row and it is retried on the next run
+			s.saveCursor(&cursor)
+			return err
+		}
+		cursor.Position = row.ID
+	}
+	cursor.Position = rows[len(rows)-1].ID
+	return s.saveCursor(&cursor)
+}
+
+func (s *WebhookService) saveCursor(cursor *models.WebhookCursor) error {
+	return s.db.Model(cursor).Where("name = ?", cursor.Name).Update("position", cursor.Position).Error
+}
+
+// journalWebhookEventType - Tipe webhook untuk baris journal_event_log, kosong bila tidak dikirim
+func journalWebhookEventType(row journalEventLogRow) string {
+	switch row.EventType {
+	case models.SSOTEventTypePosted:
+		return models.WebhookEventJournalPosted
+	case models.SSOTEventTypeReversed:
+		return models.WebhookEventJournalReversed
+	case models.SSOTEventTypeCreated:
+		var data struct {
+			Status string `json:"status"`
+		}
+		if json.Unmarshal([]byte(row.EventData), &data) == nil && data.Status == "POSTED" {
+			return models.WebhookEventJournalPosted
+		}
+	}
+	return ""
+}
+
+// recordJournalEvent loads the journal and stores the webhook event; the unique source_event_id makes it idempotent
+func (s *WebhookService) recordJournalEvent(row journalEventLogRow, eventType string) error {
+	var journal models.SSOTJournalEntry
+	if err := s.db.Preload("Lines", func(db *gorm.DB) *gorm.DB {
+		return db.Order("line_number")
+	}).Preload("Lines.Account").First(&journal, *row.JournalID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			log.Printf("⚠️ Journal %d of journal event %d no longer exists, webhook skipped", *row.JournalID, row.ID)
+			return nil
+		}
+		return fmt.Errorf("failed to load journal %d: %v", *row.JournalID, err)
+	}
+
+	lines := make([]map[string]interface{}, 0, len(journal.Lines))
+	for _, line := range journal.Lines {
+		item := map[string]interface{}{
+			"line_number":   line.LineNumber,
+			"account_id":    line.AccountID,
+			"description":   line.Description,
+			"debit_amount":  line.DebitAmount,
+			"credit_amount": line.CreditAmount,
+		}
+		if line.Account != nil {
+			item["account_code"] = line.Account.Code
+			item["account_name"] = line.Account.Name
+		}
+		lines = append(lines, item)
+	}
+	data := map[string]interface{}{
+		"journal_id":      journal.ID,
+		"entry_number":    journal.EntryNumber,
+		"source_type":     journal.SourceType,
+		"source_id":       journal.SourceID,
+		"source_code":     journal.SourceCode,
+		"entry_date":      journal.EntryDate.Format("2006-01-02"),
+		"description":     journal.Description,
+		"reference":       journal.Reference,
+		"total_debit":     journal.TotalDebit,
+		"total_credit":    journal.TotalCredit,
+		"status":          journal.Status,
+		"reversed_by":     journal.ReversedBy,
+		"reversed_from":   journal.ReversedFrom,
+		"reversal_reason": journal.ReversalReason,
+		"lines":           lines,
+	}
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	journalID := uint(journal.ID)
+	sourceEventID := row.ID
+	event := models.WebhookEvent{
+		EventUUID:     uuid.New().String(),
+		EventType:     eventType,
+		EntityType:    "journal",
+		EntityID:      &journalID,
+		Data:          string(payload),
+		SourceEventID: &sourceEventID,
+		Status:        models.WebhookEventStatusPending,
+		OccurredAt:    row.EventTimestamp,
+	}
+	if row.CorrelationID != nil {
+		event.CorrelationID = *row.CorrelationID
+	}
+	return s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&event).Error
+}
@@ -0,0 +1,702 @@
+package services
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"app-sistem-akuntansi/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// WebhookService manages webhook subscriptions and delivers the webhook outbox: events are fanned out
+// into one delivery per matching subscription, POSTed with an HMAC-SHA256 signature and retried with
+// exponential backoff. Subscriptions that keep failing are disabled and the admins are notified.
+type WebhookService struct {
+	db     *gorm.DB
+	client *http.Client
+}
+
+func NewWebhookService(db *gorm.DB) *WebhookService {
+	return &WebhookService{
+		db:     db,
+		client: &http.Client{Timeout: webhookRequestTimeout},
+	}
+}
+
+const (
+	webhookWorkerInterval        = 30 * time.Second
+	webhookRequestTimeout        = 10 * time.Second
+	webhookBatchSize             = 50
+	webhookSendingStaleAfter     = 5 * time.Minute
+	webhookMaxAttempts           = 8
+	webhookBaseBackoff           = 30 * time.Second
+	webhookMaxBackoff            = 6 * time.Hour
+	webhookDisableAfterFailures  = 20 // Consecutive failed attempts before the subscription is disabled
+	webhookResponseBodyLimit     = 2048
+	webhookSignatureHeader       = "X-Webhook-Signature"
+	webhookSecretPrefix          = "whsec_"
+	webhookSubscriptionNotFound  = "webhook subscription not found"
+	webhookDeliveryNotFoundError = "webhook delivery not found"
+)
+
+// WebhookDeliveryListResult is one page of the delivery log
+type WebhookDeliveryListResult struct {
+	Data       []models.WebhookDelivery `json:"data"`
+	Total      int64                    `json:"total"`
+	Page       int                      `json:"page"`
+	Limit      int                      `json:"limit"`
+	TotalPages int                      `json:"total_pages"`
+}
+
+// WebhookSubscriptionWithSecret is returned once when a subscription is created or its secret rotated
+type WebhookSubscriptionWithSecret struct {
+	models.WebhookSubscription
+	Secret string `json:"secret"`
+}
+
+// ========== SUBSCRIPTIONS ==========
+
+// GetSubscriptions - Daftar subscription webhook
+func (s *WebhookService) GetSubscriptions() ([]models.WebhookSubscription, error) {
+	var subscriptions []models.WebhookSubscription
+	if err := s.db.Order("name").Find(&subscriptions).Error; err != nil {
+		return nil, fmt.Errorf("failed to load webhook subscriptions: %v", err)
+	}
+	return subscriptions, nil
+}
+
+// GetSubscription - Detail subscription webhook
+func (s *WebhookService) GetSubscription(id uint) (*models.WebhookSubscription, error) {
+	var subscription models.WebhookSubscription
+	if err := s.db.First(&subscription, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New(webhookSubscriptionNotFound)
+		}
+		return nil, err
+	}
+	return &subscription, nil
+}
+
+// CreateSubscription - Buat subscription; secret dibuat otomatis bila kosong dan hanya dikembalikan sekali
+func (s *WebhookService) CreateSubscription(req models.WebhookSubscriptionRequest, userID uint) (*WebhookSubscriptionWithSecret, error) {
+	events, err := validateWebhookRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	secret := strings.TrimSpace(req.Secret)
+	if secret == "" {
+		secret = newWebhookSecret()
+	}
+
+	subscription := models.WebhookSubscription{
+		Name:        strings.TrimSpace(req.Name),
+		URL:         strings.TrimSpace(req.URL),
+		Secret:      secret,
+		Events:      events,
+		Description: req.Description,
+		IsActive:    req.IsActive == nil || *req.IsActive,
+		CreatedBy:   userID,
+	}
+	if err := s.db.Create(&subscription).Error; err != nil {
+		return nil, fmt.Errorf("failed to create webhook subscription: %v", err)
+	}
+	if !subscription.IsActive {
+		// The column defaults to true, which GORM applies to a false zero value on create
+		s.db.Model(&subscription).Updates(map[string]interface{}{"is_active": false, "disabled_by": models.WebhookDisabledByUser})
+	}
+	log.Printf("🔗 Webhook subscription %s created for %s (%s)", subscription.Name, subscription.URL, strings.Join(events, ", "))
+	return &WebhookSubscriptionWithSecret{WebhookSubscription: subscription, Secret: secret}, nil
+}
+
+// UpdateSubscription - Ubah URL, filter event atau status; secret hanya diganti bila diisi
+func (s *WebhookService) UpdateSubscription(id uint, req models.WebhookSubscriptionRequest) (*models.WebhookSubscription, error) {
+	subscription, err := s.GetSubscription(id)
+	if err != nil {
+		return nil, err
+	}
+	events, err := validateWebhookRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Map updates bypass the JSON serializer of Events
+	encodedEvents, err := json.Marshal(events)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode webhook events: %v", err)
+	}
+	updates := map[string]interface{}{
+		"name":        strings.TrimSpace(req.Name),
+		"url":         strings.TrimSpace(req.URL),
+		"events":      string(encodedEvents),
+		"description": req.Description,
+	}
+	if secret := strings.TrimSpace(req.Secret); secret != "" {
+		updates["secret"] = secret
+	}
+	pausing := false
+	if req.IsActive != nil {
+		if *req.IsActive && !subscription.IsActive {
+			updates["consecutive_failures"] = 0
+			updates["disabled_at"] = nil
+			updates["disabled_reason"] = ""
+			updates["disabled_by"] = ""
+		}
+		if !*req.IsActive && subscription.IsActive {
+			pausing = true
+			updates["disabled_at"] = time.Now()
+			updates["disabled_reason"] = "Paused by user"
+			updates["disabled_by"] = models.WebhookDisabledByUser
+		}
+		updates["is_active"] = *req.IsActive
+	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(subscription).Updates(updates).Error; err != nil {
+			return fmt.Errorf("failed to update webhook subscription: %v", err)
+		}
+		if !pausing {
+			return nil
+		}
+		// A paused subscription does not get the events it missed
+		return tx.Model(&models.WebhookDelivery{}).
+			Where("subscription_id = ? AND status = ?", id, models.WebhookDeliveryPending).
+			Update("status", models.WebhookDeliveryCancelled).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetSubscription(id)
+}
+
+// EnableSubscription - Aktifkan kembali subscription yang dinonaktifkan otomatis atau dijeda
+func (s *WebhookService) EnableSubscription(id uint) (*models.WebhookSubscription, error) {
+	subscription, err := s.GetSubscription(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.db.Model(subscription).Updates(map[string]interface{}{
+		"is_active":            true,
+		"consecutive_failures": 0,
+		"disabled_at":          nil,
+		"disabled_reason":      "",
+		"disabled_by":          "",
+	}).Error; err != nil {
+		return nil, fmt.Errorf("failed to enable webhook subscription: %v", err)
+	}
+	return s.GetSubscription(id)
+}
+
+// RotateSecret - Ganti secret dengan yang baru; dikembalikan sekali
+func (s *WebhookService) RotateSecret(id uint) (*WebhookSubscriptionWithSecret, error) {
+	subscription, err := s.GetSubscription(id)
+	if err != nil {
+		return nil, err
+	}
+	secret := newWebhookSecret()
+	if err := s.db.Model(subscription).Update("secret", secret).Error; err != nil {
+		return nil, fmt.Errorf("failed to rotate webhook secret: %v", err)
+	}
+	return &WebhookSubscriptionWithSecret{WebhookSubscription: *subscription, Secret: secret}, nil
+}
+
+// DeleteSubscription - Hapus subscription dan batalkan pengiriman yang belum terkirim
+func (s *WebhookService) DeleteSubscription(id uint) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Delete(&models.WebhookSubscription{}, id)
+		if result.Error != nil {
+			return fmt.Errorf("failed to delete webhook subscription: %v", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return errors.New(webhookSubscriptionNotFound)
+		}
+		return tx.Model(&models.WebhookDelivery{}).
+			Where("subscription_id = ? AND status IN ?", id, []string{models.WebhookDeliveryPending, models.WebhookDeliverySending}).
+			Update("status", models.WebhookDeliveryCancelled).Error
+	})
+}
+
+// SendPing - Kirim event webhook.ping langsung ke subscription untuk menguji endpoint dan signature
+func (s *WebhookService) SendPing(id uint) (*models.WebhookDelivery, error) {
+	subscription, err := s.GetSubscription(id)
+	if err != nil {
+		return nil, err
+	}
+	payload, _ := json.Marshal(map[string]interface{}{
+		"subscription_id": subscription.ID,
+		"message":         "Webhook endpoint reachable",
+	})
+
+	now := time.Now()
+	event := models.WebhookEvent{
+		EventUUID:    uuid.New().String(),
+		EventType:    models.WebhookEventPing,
+		EntityType:   "webhook_subscription",
+		EntityID:     &subscription.ID,
+		Data:         string(payload),
+		Status:       models.WebhookEventStatusDispatched,
+		DispatchedAt: &now,
+		OccurredAt:   now,
+	}
+	delivery := models.WebhookDelivery{
+		SubscriptionID: subscription.ID,
+		EventType:      event.EventType,
+		Status:         models.WebhookDeliverySending,
+		MaxAttempts:    1,
+		NextAttemptAt:  now,
+	}
+	if err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&event).Error; err != nil {
+			return err
+		}
+		delivery.EventID = event.ID
+		return tx.Create(&delivery).Error
+	}); err != nil {
+		return nil, fmt.Errorf("failed to create ping delivery: %v", err)
+	}
+
+	delivery.Subscription = subscription
+	delivery.Event = &event
+	s.deliver(&delivery)
+	return s.GetDelivery(delivery.ID)
+}
+
+func validateWebhookRequest(req models.WebhookSubscriptionRequest) ([]string, error) {
+	parsed, err := url.Parse(strings.TrimSpace(req.URL))
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, errors.New("url must be an absolute http or https URL")
+	}
+
+	known := make(map[string]bool, len(models.WebhookEventTypes))
+	prefixes := make(map[string]bool)
+	for _, eventType := range models.WebhookEventTypes {
+		known[eventType] = true
+		prefixes[eventType[:strings.Index(eventType, ".")+1]+"*"] = true
+	}
+	events := make([]string, 0, len(req.Events))
+	seen := make(map[string]bool)
+	for _, event := range req.Events {
+		event = strings.ToLower(strings.TrimSpace(event))
+		if event == "" || seen[event] {
+			continue
+		}
+		if event != "*" && !known[event] && !prefixes[event] {
+			return nil, fmt.Errorf("unknown event type %s, use one of %s, a prefix such as sale.* or *", event, strings.Join(models.WebhookEventTypes, ", "))
+		}
+		seen[event] = true
+		events = append(events, event)
+	}
+	if len(events) == 0 {
+		return nil, errors.New("at least one event type is required")
+	}
+	return events, nil
+}
+
+func newWebhookSecret() string {
+	random := make([]byte, 24)
+	rand.Read(random)
+	return webhookSecretPrefix + hex.EncodeToString(random)
+}
+
+// ========== DELIVERY LOG ==========
+
+// GetDeliveries - Log pengiriman dengan filter dan paginasi
+func (s *WebhookService) GetDeliveries(filter models.WebhookDeliveryFilter) (*WebhookDeliveryListResult, error) {
+	if filter.Page <= 0 {
+		filter.Page = 1
+	}
+	if filter.Limit <= 0 || filter.Limit > 100 {
+		filter.Limit = 20
+	}
+
+	query := s.db.Model(&models.WebhookDelivery{})
+	if filter.SubscriptionID != 0 {
+		query = query.Where("subscription_id = ?", filter.SubscriptionID)
+	}
+	if filter.EventType != "" {
+		query = query.Where("event_type = ?", filter.EventType)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", strings.ToUpper(filter.Status))
+	}
+	if filter.EventID != 0 {
+		query = query.Where("event_id = ?", filter.EventID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, fmt.Errorf("failed to count webhook deliveries: %v", err)
+	}
+	var deliveries []models.WebhookDelivery
+	if err := query.Preload("Subscription").Order("created_at DESC").
+		Offset((filter.Page - 1) * filter.Limit).Limit(filter.Limit).
+		Find(&deliveries).Error; err != nil {
+		return nil, fmt.Errorf("failed to load webhook deliveries: %v", err)
+	}
+
+	return &WebhookDeliveryListResult{
+		Data:       deliveries,
+		Total:      total,
+		Page:       filter.Page,
+		Limit:      filter.Limit,
+		TotalPages: int(math.Ceil(float64(total) / float64(filter.Limit))),
+	}, nil
+}
+
+// GetDelivery - Detail pengiriman beserta event dan log setiap percobaan
+func (s *WebhookService) GetDelivery(id uint) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	if err := s.db.Preload("Subscription").Preload("Event").
+		Preload("AttemptLog", func(db *gorm.DB) *gorm.DB {
+			return db.Order("attempt_number")
+		}).First(&delivery, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New(webhookDeliveryNotFoundError)
+		}
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// ReplayDelivery - Kirim ulang event yang sama ke subscription yang sama sebagai pengiriman baru
+func (s *WebhookService) ReplayDelivery(id uint, userID uint) (*models.WebhookDelivery, error) {
+	original, err := s.GetDelivery(id)
+	if err != nil {
+		return nil, err
+	}
+	if original.Subscription == nil {
+		return nil, errors.New("the subscription of this delivery was deleted")
+	}
+	if !original.Subscription.IsActive {
+		return nil, errors.New("the subscription is disabled, enable it before replaying")
+	}
+	if original.Status == models.WebhookDeliveryPending || original.Status == models.WebhookDeliverySending {
+		return nil, fmt.Errorf("the delivery is still %s", original.Status)
+	}
+
+	replay := models.WebhookDelivery{
+		SubscriptionID: original.SubscriptionID,
+		EventID:        original.EventID,
+		EventType:      original.EventType,
+		Status:         models.WebhookDeliveryPending,
+		MaxAttempts:    webhookMaxAttempts,
+		NextAttemptAt:  time.Now(),
+		ReplayOfID:     &original.ID,
+		ReplayedBy:     &userID,
+	}
+	if err := s.db.Create(&replay).Error; err != nil {
+		return nil, fmt.Errorf("failed to replay webhook delivery: %v", err)
+	}
+	log.Printf("🔁 Webhook delivery #%d replayed as #%d", original.ID, replay.ID)
+	return s.GetDelivery(replay.ID)
+}
+
+// ========== WORKER ==========
+
+// StartWorker imports journal events, fans out new events and delivers due webhooks every 30 seconds
+func (s *WebhookService) StartWorker() {
+	ticker := time.NewTicker(webhookWorkerInterval)
+	defer ticker.Stop()
+
+	log.Println("🔗 Webhook worker started - running every 30 seconds")
+	s.runWorker()
+	for range ticker.C {
+		s.runWorker()
+	}
+}
+
+func (s *WebhookService) runWorker() {
+	if err := s.importJournalEvents(); err != nil {
+		log.Printf("❌ Webhook journal event import failed: %v", err)
+	}
+	if err := s.dispatchEvents(); err != nil {
+		log.Printf("❌ Webhook event dispatch failed: %v", err)
+	}
+	if _, err := s.ProcessDeliveries(); err != nil {
+		log.Printf("❌ Webhook delivery run failed: %v", err)
+	}
+}
+
+// dispatchEvents creates a delivery for every subscription that listens for each pending event. Deliveries of
+// a subscription disabled after failures stay PENDING, since ProcessDeliveries only claims active ones, and
+// go out once the subscription is enabled again; subscriptions paused by a user are skipped.
+func (s *WebhookService) dispatchEvents() error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var events []models.WebhookEvent
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ?", models.WebhookEventStatusPending).
+			Order("id").Limit(webhookBatchSize).
+			Find(&events).Error; err != nil {
+			return err
+		}
+		if len(events) == 0 {
+			return nil
+		}
+
+		var subscriptions []models.WebhookSubscription
+		if err := tx.Find(&subscriptions).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		for _, event := range events {
+			for i := range subscriptions {
+				if !subscriptions[i].QueuesEvents() || !subscriptions[i].Subscribes(event.EventType) {
+					continue
+				}
+				delivery := models.WebhookDelivery{
+					SubscriptionID: subscriptions[i].ID,
+					EventID:        event.ID,
+					EventType:      event.EventType,
+					Status:         models.WebhookDeliveryPending,
+					MaxAttempts:    webhookMaxAttempts,
+					NextAttemptAt:  now,
+				}
+				if err := tx.Create(&delivery).Error; err != nil {
+					return fmt.Errorf("failed to create webhook delivery: %v", err)
+				}
+			}
+			if err := tx.Model(&models.WebhookEvent{}).Where("id = ?", event.ID).Updates(map[string]interface{}{
+				"status":        models.WebhookEventStatusDispatched,
+				"dispatched_at": now,
+			}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ProcessDeliveries - Kirim delivery PENDING yang sudah waktunya ke subscription yang aktif
+func (s *WebhookService) ProcessDeliveries() (int, error) {
+	// Deliveries left in SENDING by a crashed worker go back to the queue
+	s.db.Model(&models.WebhookDelivery{}).
+		Where("status = ? AND updated_at < ?", models.WebhookDeliverySending, time.Now().Add(-webhookSendingStaleAfter)).
+		Update("status", models.WebhookDeliveryPending)
+
+	var deliveries []models.WebhookDelivery
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", models.WebhookDeliveryPending, time.Now()).
+			Where("subscription_id IN (?)", tx.Model(&models.WebhookSubscription{}).Select("id").Where("is_active = ?", true)).
+			Order("next_attempt_at").Limit(webhookBatchSize).
+			Find(&deliveries).Error; err != nil {
+			return err
+		}
+		if len(deliveries) == 0 {
+			return nil
+		}
+		ids := make([]uint, len(deliveries))
+		for i, delivery := range deliveries {
+			ids[i] = delivery.ID
+		}
+		return tx.Model(&models.WebhookDelivery{}).Where("id IN ?", ids).Update("status", models.WebhookDeliverySending).Error
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to claim webhook deliveries: %v", err)
+	}
+
+	succeeded := 0
+	for i := range deliveries {
+		if err := s.db.Preload("Subscription").Preload("Event").First(&deliveries[i], deliveries[i].ID).Error; err != nil {
+			log.Printf("⚠️ Failed to load webhook delivery #%d: %v", deliveries[i].ID, err)
+			continue
+		}
+		if deliveries[i].Subscription == nil || !deliveries[i].Subscription.IsActive {
+			// Disabled after being claimed; back to the queue until it is enabled again
+			s.db.Model(&deliveries[i]).Update("status", models.WebhookDeliveryPending)
+			continue
+		}
+		if s.deliver(&deliveries[i]) {
+			succeeded++
+		}
+	}
+	return succeeded, nil
+}
+
+// deliver POSTs one delivery, writes the attempt to the delivery log and schedules a retry on failure
+func (s *WebhookService) deliver(delivery *models.WebhookDelivery) bool {
+	subscription, event := delivery.Subscription, delivery.Event
+	body, err := json.Marshal(map[string]interface{}{
+		"id":             event.EventUUID,
+		"type":           event.EventType,
+		"occurred_at":    event.OccurredAt,
+		"correlation_id": event.CorrelationID,
+		"delivery_id":    delivery.ID,
+		"entity_type":    event.EntityType,
+		"entity_id":      event.EntityID,
+		"data":           json.RawMessage(event.Data),
+	})
+	if err != nil {
+		s.recordAttempt(delivery, 0, "", 0, fmt.Errorf("failed to encode payload: %v", err))
+		return false
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	request, err := http.NewRequest(http.MethodPost, subscription.URL, bytes.NewReader(body))
+	if err != nil {
+		s.recordAttempt(delivery, 0, "", 0, err)
+		return false
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("User-Agent", "App-Sistem-Akuntansi-Webhook/1.0")
+	request.Header.Set("X-Webhook-Event", event.EventType)
+	request.Header.Set("X-Webhook-Event-ID", event.EventUUID)
+	request.Header.Set("X-Webhook-Delivery", strconv.FormatUint(uint64(delivery.ID), 10))
+	request.Header.Set("X-Webhook-Timestamp", timestamp)
+	request.Header.Set(webhookSignatureHeader, "sha256="+signWebhookPayload(subscription.Secret, timestamp, body))
+
+	started := time.Now()
+	response, err := s.client.Do(request)
+	duration := time.Since(started).Milliseconds()
+	if err != nil {
+		s.recordAttempt(delivery, 0, "", duration, err)
+		return false
+	}
+	defer response.Body.Close()
+	responseBody, _ := io.ReadAll(io.LimitReader(response.Body, webhookResponseBodyLimit))
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		s.recordAttempt(delivery, response.StatusCode, string(responseBody), duration, fmt.Errorf("endpoint answered HTTP %d", response.StatusCode))
+		return false
+	}
+	s.recordAttempt(delivery, response.StatusCode, string(responseBody), duration, nil)
+	return true
+}
+
+// signWebhookPayload returns hex(HMAC-SHA256(secret, "<timestamp>.<body>")). Receivers recompute it from
+// the X-Webhook-Timestamp header and the raw body and compare it with X-Webhook-Signature.
+func signWebhookPayload(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// recordAttempt stores the attempt, updates the delivery and the failure counter of the subscription
+func (s *WebhookService) recordAttempt(delivery *models.WebhookDelivery, statusCode int, responseBody string, durationMs int64, deliveryErr error) {
+	now := time.Now()
+	delivery.Attempts++
+	attempt := models.WebhookDeliveryAttempt{
+		DeliveryID:    delivery.ID,
+		AttemptNumber: delivery.Attempts,
+		StatusCode:    statusCode,
+		Success:       deliveryErr == nil,
+		ResponseBody:  responseBody,
+		DurationMs:    durationMs,
+	}
+	if deliveryErr != nil {
+		attempt.Error = deliveryErr.Error()
+	}
+	if err := s.db.Create(&attempt).Error; err != nil {
+		log.Printf("⚠️ Failed to write webhook delivery log for #%d: %v", delivery.ID, err)
+	}
+
+	updates := map[string]interface{}{
+		"attempts":         delivery.Attempts,
+		"last_status_code": statusCode,
+		"last_error":       attempt.Error,
+	}
+	if deliveryErr == nil {
+		delivery.Status = models.WebhookDeliverySucceeded
+		updates["delivered_at"] = now
+		s.db.Model(&models.WebhookSubscription{}).Where("id = ?", delivery.SubscriptionID).Updates(map[string]interface{}{
+			"consecutive_failures": 0,
+			"last_success_at":      now,
+		})
+	} else {
+		if delivery.Attempts >= delivery.MaxAttempts {
+			delivery.Status = models.WebhookDeliveryFailed
+			log.Printf("❌ Webhook delivery #%d (%s) failed after %d attempts: %v", delivery.ID, delivery.EventType, delivery.Attempts, deliveryErr)
+		} else {
+			delivery.Status = models.WebhookDeliveryPending
+			backoff := webhookBackoff(delivery.Attempts)
+			updates["next_attempt_at"] = now.Add(backoff)
+			log.Printf("⚠️ Webhook delivery #%d (%s) failed (attempt %d), retrying in %v: %v", delivery.ID, delivery.EventType, delivery.Attempts, backoff, deliveryErr)
+		}
+		s.recordSubscriptionFailure(delivery.SubscriptionID, deliveryErr)
+	}
+	updates["status"] = delivery.Status
+	s.db.Model(&models.WebhookDelivery{}).Where("id = ?", delivery.ID).Updates(updates)
+}
+
+// webhookBackoff doubles the wait after every failed attempt: 30s, 1m, 2m, 4m ... capped at 6 hours
+func webhookBackoff(attempts int) time.Duration {
+	backoff := webhookBaseBackoff * time.Duration(math.Pow(2, float64(attempts-1)))
+	if backoff <= 0 || backoff > webhookMaxBackoff {
+		return webhookMaxBackoff
+	}
+	return backoff
+}
+
+// recordSubscriptionFailure counts the failed attempt and disables the subscription once it keeps failing
+func (s *WebhookService) recordSubscriptionFailure(subscriptionID uint, deliveryErr error) {
+	now := time.Now()
+	s.db.Model(&models.WebhookSubscription{}).Where("id = ?", subscriptionID).Updates(map[string]interface{}{
+		"consecutive_failures": gorm.Expr("consecutive_failures + 1"),
+		"last_failure_at":      now,
+	})
+
+	reason := fmt.Sprintf("Disabled after %d consecutive failed deliveries, last error: %v", webhookDisableAfterFailures, deliveryErr)
+	result := s.db.Model(&models.WebhookSubscription{}).
+		Where("id = ? AND is_active = ? AND consecutive_failures >= ?", subscriptionID, true, webhookDisableAfterFailures).
+		Updates(map[string]interface{}{
+			"is_active":       false,
+			"disabled_at":     now,
+			"disabled_reason": reason,
+			"disabled_by":     models.WebhookDisabledByFailures,
+		})
+	if result.Error != nil || result.RowsAffected == 0 {
+		return
+	}
+
+	var subscription models.WebhookSubscription
+	if err := s.db.Unscoped().First(&subscription, subscriptionID).Error; err != nil {
+		return
+	}
+	log.Printf("🚫 Webhook subscription %s (%s) disabled: %s", subscription.Name, subscription.URL, reason)
+	s.notifyAdminsSubscriptionDisabled(&subscription)
+}
+
+// notifyAdminsSubscriptionDisabled - Notifikasi in-app (dan email bila aktif) ke admin
+func (s *WebhookService) notifyAdminsSubscriptionDisabled(subscription *models.WebhookSubscription) {
+	var admins []models.User
+	if err := s.db.Where("role = ? AND is_active = ?", "admin", true).Find(&admins).Error; err != nil {
+		log.Printf("⚠️ Failed to load admins for webhook notification: %v", err)
+		return
+	}
+	data, _ := json.Marshal(map[string]interface{}{
+		"webhook_subscription_id": subscription.ID,
+		"url":                     subscription.URL,
+		"disabled_reason":         subscription.DisabledReason,
+	})
+	for _, admin := range admins {
+		notification := models.Notification{
+			UserID:   admin.ID,
+			Type:     models.NotificationTypeCriticalAlert,
+			Title:    "Webhook disabled",
+			Message:  fmt.Sprintf("Webhook %s (%s) was disabled because it kept failing. Fix the endpoint and enable it again.", subscription.Name, subscription.URL),
+			Data:     string(data),
+			Priority: models.NotificationPriorityHigh,
+		}
+		if err := s.db.Create(&notification).Error; err != nil {
+			log.Printf("⚠️ Failed to notify admin %d about disabled webhook: %v", admin.ID, err)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"app-sistem-akuntansi/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestSignWebhookPayload(t *testing.T) {
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp string
+		body      string
+		want      string
+	}{
+		{
+			name:      "known vector", // printf "1700000000.<body>" | openssl dgst -sha256 -hmac whsec_test
+			secret:    "whsec_test",
+			timestamp: "1700000000",
+			body:      `{"type":"sale.invoiced"}`,
+			want:      "eaf09d0fd42f8d2f11904528dd8a46d4c1518566a8475b853b660c34fed4da74",
+		},
+		{name: "empty body", secret: "whsec_test", timestamp: "1700000000", body: ""},
+		{name: "payload with a dot", secret: "s3cret", timestamp: "1", body: `{"amount":1.5}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mac := hmac.New(sha256.New, []byte(tt.secret))
+			mac.Write([]byte(tt.timestamp + "." + tt.body))
+			expected := hex.EncodeToString(mac.Sum(nil))
+
+			got := signWebhookPayload(tt.secret, tt.timestamp, []byte(tt.body))
+			assert.Equal(t, expected, got)
+			if tt.want != "" {
+				assert.Equal(t, tt.want, got)
+			}
+			assert.NotEqual(t, got, signWebhookPayload(tt.secret+"x", tt.timestamp, []byte(tt.body)), "secret is part of the signature")
+			assert.NotEqual(t, got, signWebhookPayload(tt.secret, tt.timestamp+"0", []byte(tt.body)), "timestamp is part of the signature")
+		})
+	}
+}
+
+func TestWebhookBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{attempts: 1, want: 30 * time.Second},
+		{attempts: 2, want: time.Minute},
+		{attempts: 4, want: 4 * time.Minute},
+		{attempts: 10, want: 256 * time.Minute},
+		{attempts: 11, want: webhookMaxBackoff},
+		{attempts: 80, want: webhookMaxBackoff},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want.String(), func(t *testing.T) {
+			assert.Equal(t, tt.want, webhookBackoff(tt.attempts))
+		})
+	}
+}
+
+func setupWebhookTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1) // Every connection to :memory: is a separate database
+	require.NoError(t, db.AutoMigrate(&models.WebhookSubscription{}, &models.WebhookEvent{}, &models.WebhookDelivery{}, &models.WebhookDeliveryAttempt{}))
+	return db
+}
+
+func TestWebhookOutboxKeepsEventsOfDisabledSubscriptions(t *testing.T) {
+	var mu sync.Mutex
+	var received []*http.Request
+	endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		mu.Lock()
+		received = append(received, r)
+		mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer endpoint.Close()
+
+	db := setupWebhookTestDB(t)
+	service := &WebhookService{db: db, client: endpoint.Client()}
+
+	// Disabled by the webhook worker after repeated failures
+	subscription := models.WebhookSubscription{Name: "Storefront", URL: endpoint.URL, Secret: "whsec_test", Events: []string{"sale.*"}}
+	require.NoError(t, db.Create(&subscription).Error)
+	require.NoError(t, db.Model(&subscription).Updates(map[string]interface{}{"is_active": false, "disabled_by": models.WebhookDisabledByFailures}).Error)
+	deleted := models.WebhookSubscription{Name: "Old WMS", URL: endpoint.URL, Secret: "whsec_old", Events: []string{"*"}}
+	require.NoError(t, db.Create(&deleted).Error)
+	require.NoError(t, db.Delete(&deleted).Error)
+	paused := models.WebhookSubscription{Name: "Warehouse", URL: endpoint.URL, Secret: "whsec_wms", Events: []string{"sale.*", "stock.*"}}
+	require.NoError(t, db.Create(&paused).Error)
+	inactive := false
+	_, err := service.UpdateSubscription(paused.ID, models.WebhookSubscriptionRequest{
+		Name: paused.Name, URL: paused.URL, Events: paused.Events, IsActive: &inactive,
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.First(&paused, paused.ID).Error)
+	assert.Equal(t, models.WebhookDisabledByUser, paused.DisabledBy)
+
+	// Recorded while the only live subscription is disabled
+	require.NoError(t, recordWebhookEvent(db, models.WebhookEventSaleInvoiced, "sale", 1, map[string]interface{}{"code": "INV/2024/0001"}))
+	require.NoError(t, recordWebhookEvent(db, models.WebhookEventStockLow, "product", 2, map[string]interface{}{"stock": 1}))
+	var events []models.WebhookEvent
+	require.NoError(t, db.Find(&events).Error)
+	require.Len(t, events, 1, "only deleted and paused subscriptions listen for stock.low")
+	assert.Equal(t, models.WebhookEventSaleInvoiced, events[0].EventType)
+
+	require.NoError(t, service.dispatchEvents())
+	var deliveries []models.WebhookDelivery
+	require.NoError(t, db.Find(&deliveries).Error)
+	require.Len(t, deliveries, 1, "nothing is queued for the paused subscription")
+	assert.Equal(t, subscription.ID, deliveries[0].SubscriptionID)
+
+	sent, err := service.ProcessDeliveries()
+	require.NoError(t, err)
+	assert.Zero(t, sent, "nothing goes out while the subscription is disabled")
+	require.NoError(t, db.First(&deliveries[0], deliveries[0].ID).Error)
+	assert.Equal(t, models.WebhookDeliveryPending, deliveries[0].Status)
+
+	_, err = service.EnableSubscription(subscription.ID)
+	require.NoError(t, err)
+	_, err = service.EnableSubscription(paused.ID)
+	require.NoError(t, err)
+	sent, err = service.ProcessDeliveries()
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent, "the resumed subscription does not get the events it missed")
+	require.NoError(t, db.First(&deliveries[0], deliveries[0].ID).Error)
+	assert.Equal(t, models.WebhookDeliverySucceeded, deliveries[0].Status)
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, received, 1)
+	assert.Equal(t, models.WebhookEventSaleInvoiced, received[0].Header.Get("X-Webhook-Event"))
+	assert.True(t, strings.HasPrefix(received[0].Header.Get(webhookSignatureHeader), "sha256="))
+}
+
+func TestWebhookPauseCancelsQueuedDeliveries(t *testing.T) {
+	db := setupWebhookTestDB(t)
+	service := &WebhookService{db: db}
+
+	subscription := models.WebhookSubscription{Name: "Storefront", URL: "https://shop.example.test/hooks", Secret: "whsec_test", Events: []string{"sale.*"}}
+	require.NoError(t, db.Create(&subscription).Error)
+	require.NoError(t, recordWebhookEvent(db, models.WebhookEventSaleInvoiced, "sale", 1, map[string]interface{}{"code": "INV/2024/0001"}))
+	require.NoError(t, service.dispatchEvents())
+
+	inactive := false
+	updated, err := service.UpdateSubscription(subscription.ID, models.WebhookSubscriptionRequest{
+		Name: subscription.Name, URL: subscription.URL, Events: subscription.Events, IsActive: &inactive,
+	})
+	require.NoError(t, err)
+	assert.False(t, updated.IsActive)
+	assert.Equal(t, models.WebhookDisabledByUser, updated.DisabledBy)
+	assert.NotNil(t, updated.DisabledAt)
+
+	var delivery models.WebhookDelivery
+	require.NoError(t, db.Where("subscription_id = ?", subscription.ID).First(&delivery).Error)
+	assert.Equal(t, models.WebhookDeliveryCancelled, delivery.Status)
+}
@@ -0,0 +1,73 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"app-sistem-akuntansi/models"
+
+	"gorm.io/gorm"
+)
+
+// WriteOffSuggestionService - Usulan penghapusan piutang dari OverdueManagementService, disetujui
+// melalui workflow WRITE_OFF sebelum dihapusbukukan
+type WriteOffSuggestionService struct {
+	db              *gorm.DB
+	approvalService *ApprovalService
+}
+
+func NewWriteOffSuggestionService(db *gorm.DB, approvalService *ApprovalService) *WriteOffSuggestionService {
+	return &WriteOffSuggestionService{db: db, approvalService: approvalService}
+}
+
+// GetSuggestions - Daftar usulan write-off, terbaru dahulu
+func (s *WriteOffSuggestionService) GetSuggestions(status string) ([]models.WriteOffSuggestion, error) {
+	query := s.db.Preload("Sale").Preload("Sale.Customer").Preload("Approver").Order("created_at DESC")
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var suggestions []models.WriteOffSuggestion
+	if err := query.Find(&suggestions).Error; err != nil {
+		return nil, err
+	}
+	return suggestions, nil
+}
+
+// SubmitForApproval - Mengirim usulan write-off ke ApprovalService
+func (s *WriteOffSuggestionService) SubmitForApproval(id uint, userID uint) (*models.WriteOffSuggestion, error) {
+	var suggestion models.WriteOffSuggestion
+	if err := s.db.Preload("Sale").First(&suggestion, id).Error; err != nil {
+		return nil, errors.New("write-off suggestion not found")
+	}
+	if suggestion.Status != models.WriteOffStatusPendingApproval {
+		return nil, fmt.Errorf("only %s suggestions can be submitted, current status: %s", models.WriteOffStatusPendingApproval, suggestion.Status)
+	}
+	if suggestion.ApprovalRequestID != nil {
+		return nil, errors.New("write-off suggestion already has an approval request")
+	}
+
+	request := models.CreateApprovalRequestDTO{
+		EntityType:     models.EntityTypeWriteOff,
+		EntityID:       suggestion.ID,
+		Amount:         suggestion.OutstandingAmount,
+		RequestTitle:   fmt.Sprintf("Write-off suggestion #%d", suggestion.ID),
+		RequestMessage: suggestion.Reason,
+	}
+	if suggestion.Sale != nil {
+		request.RequestTitle = fmt.Sprintf("Write-off of invoice %s (%d days overdue)", suggestion.Sale.InvoiceNumber, suggestion.DaysOverdue)
+		request.ContactID = &suggestion.Sale.CustomerID
+	}
+
+	approvalRequest, err := s.approvalService.CreateApprovalRequest(request, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create approval request: %v", err)
+	}
+
+	if err := s.db.Model(&models.WriteOffSuggestion{}).Where("id = ?", suggestion.ID).
+		Update("approval_request_id", approvalRequest.ID).Error; err != nil {
+		return nil, err
+	}
+	suggestion.ApprovalRequestID = &approvalRequest.ID
+	return &suggestion, nil
+}
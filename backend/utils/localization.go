@@ -173,6 +173,22 @@ var PDFTranslations = TranslationMap{
 		"amount_rp":                 "Jumlah Rp.",
 		"received_by":               "Diterima oleh",
 
+		// Statement of account translations
+		"statement_of_account":      "LAPORAN REKENING",
+		"document_type":             "Jenis",
+		"statement_invoice":         "Faktur",
+		"statement_bill":            "Tagihan",
+		"statement_payment":         "Pembayaran",
+		"statement_credit_note":     "Nota Kredit",
+		"statement_return":          "Retur",
+		"amount_due":                "Jumlah Terutang",
+		"aging_summary":             "UMUR SALDO",
+		"aging_0_30":                "0-30 Hari",
+		"aging_31_60":               "31-60 Hari",
+		"aging_61_90":               "61-90 Hari",
+		"aging_over_90":             "> 90 Hari",
+		"unapplied_credit":          "Kredit Belum Dialokasikan",
+
 		// Error messages
 		"no_data_available":         "Tidak ada data tersedia",
 		"report_generation_error":   "Terjadi kesalahan dalam pembuatan laporan",
@@ -331,6 +347,22 @@ var PDFTranslations = TranslationMap{
 		"amount_rp":                 "Amount Rp.",
 		"received_by":               "Received by",
 
+		// Statement of account translations
+		"statement_of_account":      "STATEMENT OF ACCOUNT",
+		"document_type":             "Type",
+		"statement_invoice":         "Invoice",
+		"statement_bill":            "Bill",
+		"statement_payment":         "Payment",
+		"statement_credit_note":     "Credit Note",
+		"statement_return":          "Return",
+		"amount_due":                "Amount Due",
+		"aging_summary":             "AGING",
+		"aging_0_30":                "0-30 Days",
+		"aging_31_60":               "31-60 Days",
+		"aging_61_90":               "61-90 Days",
+		"aging_over_90":             "Over 90 Days",
+		"unapplied_credit":          "Unapplied Credit",
+
 		// Error messages
 		"no_data_available":         "No data available",
 		"report_generation_error":   "Error occurred during report generation",